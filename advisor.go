@@ -0,0 +1,175 @@
+package resilience
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// advisorSamples is the number of recent latencies kept for percentiles
+const advisorSamples = 1024
+
+// configAdvisor compares an executor's configuration with observed traffic
+// and reports settings that are configured but have no effect
+type configAdvisor struct {
+	config AdvisorConfig
+	name   string
+	mu     sync.Mutex
+	now    func() time.Time
+
+	windowStart time.Time
+	calls       int
+
+	// timeout
+	latencies []time.Duration
+	next      int
+	full      bool
+
+	// rate limiter
+	second     time.Time
+	perSecond  int
+	peakPerSec int
+
+	// circuit breaker
+	peakRequests uint32
+}
+
+// newConfigAdvisor creates an advisor, filling zero values from the defaults
+func newConfigAdvisor(config AdvisorConfig, name string, clock Clock) *configAdvisor {
+	defaults := DefaultAdvisorConfig()
+	if config.Interval <= 0 {
+		config.Interval = defaults.Interval
+	}
+	if config.MinCalls <= 0 {
+		config.MinCalls = defaults.MinCalls
+	}
+	if config.TimeoutFactor <= 0 {
+		config.TimeoutFactor = defaults.TimeoutFactor
+	}
+	if config.RateLimitUsage <= 0 {
+		config.RateLimitUsage = defaults.RateLimitUsage
+	}
+
+	a := &configAdvisor{
+		config:    config,
+		name:      name,
+		now:       clock.Now,
+		latencies: make([]time.Duration, advisorSamples),
+	}
+	a.windowStart = a.now()
+	return a
+}
+
+// observeCall counts an executor call and returns true when the evaluation
+// window has ended and evaluate should be called
+func (a *configAdvisor) observeCall() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	a.calls++
+
+	second := now.Truncate(time.Second)
+	if !second.Equal(a.second) {
+		a.second = second
+		a.perSecond = 0
+	}
+	a.perSecond++
+	if a.perSecond > a.peakPerSec {
+		a.peakPerSec = a.perSecond
+	}
+
+	return now.Sub(a.windowStart) >= a.config.Interval
+}
+
+// observeLatency records the latency of a successful call under the timeout
+func (a *configAdvisor) observeLatency(elapsed time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.latencies[a.next] = elapsed
+	a.next++
+	if a.next == len(a.latencies) {
+		a.next = 0
+		a.full = true
+	}
+}
+
+// observeBreaker records the request count the breaker has accumulated
+func (a *configAdvisor) observeBreaker(requests uint32) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if requests > a.peakRequests {
+		a.peakRequests = requests
+	}
+}
+
+// evaluate reports advisories for the ended window and starts a new one
+func (a *configAdvisor) evaluate(e *executor) {
+	a.mu.Lock()
+	var advisories []Advisory
+	if a.calls >= a.config.MinCalls {
+		advisories = a.advise(e)
+	}
+
+	a.windowStart = a.now()
+	a.calls = 0
+	a.next = 0
+	a.full = false
+	a.peakPerSec = 0
+	a.peakRequests = 0
+	a.mu.Unlock()
+
+	for _, advisory := range advisories {
+		a.config.OnAdvisory(advisory)
+	}
+}
+
+// advise compares the configuration with the window's observations;
+// callers hold a.mu
+func (a *configAdvisor) advise(e *executor) []Advisory {
+	var advisories []Advisory
+	add := func(pattern Pattern, setting, message string) {
+		advisories = append(advisories, Advisory{
+			Executor: a.name,
+			Pattern:  pattern,
+			Setting:  setting,
+			Message:  message,
+		})
+	}
+
+	count := a.next
+	if a.full {
+		count = len(a.latencies)
+	}
+	if e.hasTimeout && count > 0 {
+		p99 := percentile(a.latencies[:count], 0.99)
+		timeout := e.timeout.Duration()
+		if p99 > 0 && float64(timeout) > a.config.TimeoutFactor*float64(p99) {
+			add(PatternTimeout, "timeout.duration", fmt.Sprintf(
+				"timeout %s is %.0fx the observed p99 latency of %s; consider lowering it",
+				timeout, float64(timeout)/float64(p99), p99))
+		}
+	}
+
+	if limiter, ok := e.rateLimiter.(*rateLimiter); ok && e.hasRateLimiter {
+		rate := limiter.config.Rate
+		if float64(a.peakPerSec) < a.config.RateLimitUsage*rate {
+			add(PatternRateLimiter, "rate_limiter.rate", fmt.Sprintf(
+				"rate limit %.0f/s was never approached (peak %d/s); it does not protect the target",
+				rate, a.peakPerSec))
+		}
+	}
+
+	if cb, ok := e.circuitBreaker.(*circuitBreaker); ok && e.hasCircuitBreaker {
+		consecutive := cb.trip == nil && cb.config.TripStrategy == TripStrategyConsecutive
+		if !consecutive && a.peakRequests < cb.config.MinRequests {
+			add(PatternCircuitBreaker, "circuit_breaker.min_requests", fmt.Sprintf(
+				"circuit breaker never accumulated min_requests %d per interval (peak %d); it cannot trip",
+				cb.config.MinRequests, a.peakRequests))
+		}
+	}
+
+	return advisories
+}
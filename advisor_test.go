@@ -0,0 +1,96 @@
+package resilience
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigAdvisor(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	work := func(ctx context.Context) error {
+		time.Sleep(time.Millisecond)
+		return nil
+	}
+
+	build := func(b Builder, clock *time.Time, advisories *[]Advisory) Executor {
+		exec := b.WithConfigAdvisor(AdvisorConfig{
+			Interval: time.Hour,
+			MinCalls: 50,
+			OnAdvisory: func(a Advisory) {
+				*advisories = append(*advisories, a)
+			},
+		}).Build()
+		advisor := exec.(*executor).advisor
+		advisor.now = func() time.Time { return *clock }
+		advisor.windowStart = *clock
+		return exec
+	}
+
+	t.Run("reports settings far from observed traffic", func(t *testing.T) {
+		clock := start
+		var advisories []Advisory
+		cbConfig := DefaultCircuitBreakerConfig()
+		cbConfig.MinRequests = 500
+		exec := build(NewBuilder().
+			WithName("search").
+			WithRateLimiter(RateLimiterConfig{Rate: 10000, Burst: 10000}).
+			WithTimeout(10*time.Second).
+			WithCircuitBreaker(cbConfig), &clock, &advisories)
+
+		for i := 0; i < 60; i++ {
+			require.NoError(t, exec.Execute(ctx, work))
+		}
+		assert.Empty(t, advisories, "nothing reported before the window ends")
+
+		clock = clock.Add(time.Hour)
+		require.NoError(t, exec.Execute(ctx, work))
+
+		settings := make(map[string]Advisory)
+		for _, a := range advisories {
+			settings[a.Setting] = a
+		}
+		require.Len(t, settings, 3)
+		assert.Equal(t, PatternTimeout, settings["timeout.duration"].Pattern)
+		assert.Equal(t, PatternRateLimiter, settings["rate_limiter.rate"].Pattern)
+		assert.Equal(t, PatternCircuitBreaker, settings["circuit_breaker.min_requests"].Pattern)
+		assert.Equal(t, "search", settings["timeout.duration"].Executor)
+	})
+
+	t.Run("stays quiet for well tuned settings", func(t *testing.T) {
+		clock := start
+		var advisories []Advisory
+		cbConfig := DefaultCircuitBreakerConfig()
+		cbConfig.MinRequests = 10
+		exec := build(NewBuilder().
+			WithRateLimiter(RateLimiterConfig{Rate: 100, Burst: 1000}).
+			WithTimeout(5*time.Millisecond).
+			WithCircuitBreaker(cbConfig), &clock, &advisories)
+
+		for i := 0; i < 60; i++ {
+			_ = exec.Execute(ctx, work)
+		}
+		clock = clock.Add(time.Hour)
+		_ = exec.Execute(ctx, work)
+
+		assert.Empty(t, advisories)
+	})
+
+	t.Run("needs MinCalls in the window", func(t *testing.T) {
+		clock := start
+		var advisories []Advisory
+		exec := build(NewBuilder().WithTimeout(10*time.Second), &clock, &advisories)
+
+		for i := 0; i < 10; i++ {
+			require.NoError(t, exec.Execute(ctx, work))
+		}
+		clock = clock.Add(time.Hour)
+		require.NoError(t, exec.Execute(ctx, work))
+
+		assert.Empty(t, advisories)
+	})
+}
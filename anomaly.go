@@ -0,0 +1,187 @@
+package resilience
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// anomalyDetector applies alerting heuristics to an executor's calls.
+// Each heuristic reports at most once per window (or per saturation
+// episode) so a single incident produces a single alert.
+type anomalyDetector struct {
+	config AnomalyConfig
+	name   string
+	mu     sync.Mutex
+	now    func() time.Time
+
+	// flapping
+	opens    []time.Time
+	lastOpen time.Time // StateSince of the last opening counted
+
+	// retry amplification
+	windowStart time.Time
+	calls       int
+	attempts    int
+
+	// saturation
+	saturatedSince time.Time
+	saturationSent bool
+
+	reported map[AnomalyKind]time.Time
+}
+
+// newAnomalyDetector creates a detector, filling zero values from the defaults
+func newAnomalyDetector(config AnomalyConfig, name string, clock Clock) *anomalyDetector {
+	defaults := DefaultAnomalyConfig()
+	if config.FlapThreshold <= 0 {
+		config.FlapThreshold = defaults.FlapThreshold
+	}
+	if config.FlapWindow <= 0 {
+		config.FlapWindow = defaults.FlapWindow
+	}
+	if config.RetryAmplification <= 0 {
+		config.RetryAmplification = defaults.RetryAmplification
+	}
+	if config.RetryWindow <= 0 {
+		config.RetryWindow = defaults.RetryWindow
+	}
+	if config.RetryMinCalls <= 0 {
+		config.RetryMinCalls = defaults.RetryMinCalls
+	}
+	if config.SaturationDuration <= 0 {
+		config.SaturationDuration = defaults.SaturationDuration
+	}
+
+	return &anomalyDetector{
+		config:   config,
+		name:     name,
+		now:      clock.Now,
+		reported: make(map[AnomalyKind]time.Time),
+	}
+}
+
+// observeOpen counts a breaker opening identified by when it was entered
+func (d *anomalyDetector) observeOpen(since time.Time) {
+	d.mu.Lock()
+	if since.Equal(d.lastOpen) {
+		d.mu.Unlock()
+		return
+	}
+	d.lastOpen = since
+
+	now := d.now()
+	cutoff := now.Add(-d.config.FlapWindow)
+	kept := d.opens[:0]
+	for _, t := range d.opens {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	d.opens = append(kept, now)
+
+	var anomaly *Anomaly
+	if len(d.opens) > d.config.FlapThreshold && d.allow(AnomalyFlapping, now, d.config.FlapWindow) {
+		anomaly = &Anomaly{
+			Kind:      AnomalyFlapping,
+			Value:     float64(len(d.opens)),
+			Threshold: float64(d.config.FlapThreshold),
+			Message:   fmt.Sprintf("circuit breaker opened %d times in %s", len(d.opens), d.config.FlapWindow),
+		}
+	}
+	d.mu.Unlock()
+
+	d.report(anomaly, now)
+}
+
+// observeCall records how many attempts a completed call made
+func (d *anomalyDetector) observeCall(attempts int) {
+	if attempts == 0 {
+		return
+	}
+
+	d.mu.Lock()
+	now := d.now()
+	if now.Sub(d.windowStart) >= d.config.RetryWindow {
+		d.windowStart = now
+		d.calls = 0
+		d.attempts = 0
+	}
+	d.calls++
+	d.attempts += attempts
+
+	var anomaly *Anomaly
+	ratio := float64(d.attempts) / float64(d.calls)
+	if d.calls >= d.config.RetryMinCalls && ratio > d.config.RetryAmplification &&
+		d.allow(AnomalyRetryAmplification, now, d.config.RetryWindow) {
+		anomaly = &Anomaly{
+			Kind:      AnomalyRetryAmplification,
+			Value:     ratio,
+			Threshold: d.config.RetryAmplification,
+			Message:   fmt.Sprintf("%.2f attempts per call over %d calls", ratio, d.calls),
+		}
+	}
+	d.mu.Unlock()
+
+	d.report(anomaly, now)
+}
+
+// observeSaturation records whether the bulkhead was full at admission
+func (d *anomalyDetector) observeSaturation(full bool) {
+	d.mu.Lock()
+	now := d.now()
+	if !full {
+		d.saturatedSince = time.Time{}
+		d.saturationSent = false
+		d.mu.Unlock()
+		return
+	}
+	if d.saturatedSince.IsZero() {
+		d.saturatedSince = now
+	}
+
+	var anomaly *Anomaly
+	elapsed := now.Sub(d.saturatedSince)
+	if !d.saturationSent && elapsed >= d.config.SaturationDuration {
+		d.saturationSent = true
+		anomaly = &Anomaly{
+			Kind:      AnomalySaturation,
+			Value:     elapsed.Seconds(),
+			Threshold: d.config.SaturationDuration.Seconds(),
+			Message:   fmt.Sprintf("bulkhead full for %s", elapsed),
+		}
+	}
+	d.mu.Unlock()
+
+	d.report(anomaly, now)
+}
+
+// observePanic reports a panic recovered from a wrapped function
+func (d *anomalyDetector) observePanic(value any) {
+	d.report(&Anomaly{
+		Kind:    AnomalyPanic,
+		Message: fmt.Sprintf("panic: %v", value),
+		Panic:   value,
+		Stack:   debug.Stack(),
+	}, d.now())
+}
+
+// allow reports whether kind may fire again; callers hold d.mu
+func (d *anomalyDetector) allow(kind AnomalyKind, now time.Time, cooldown time.Duration) bool {
+	if last, ok := d.reported[kind]; ok && now.Sub(last) < cooldown {
+		return false
+	}
+	d.reported[kind] = now
+	return true
+}
+
+// report delivers an anomaly outside the lock
+func (d *anomalyDetector) report(anomaly *Anomaly, now time.Time) {
+	if anomaly == nil || d.config.OnAnomaly == nil {
+		return
+	}
+	anomaly.Executor = d.name
+	anomaly.Time = now
+	d.config.OnAnomaly(*anomaly)
+}
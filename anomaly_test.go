@@ -0,0 +1,122 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnomalyDetection(t *testing.T) {
+	ctx := context.Background()
+	ok := func(ctx context.Context) error { return nil }
+
+	collect := func(config AnomalyConfig) (AnomalyConfig, *[]Anomaly) {
+		var anomalies []Anomaly
+		config.OnAnomaly = func(a Anomaly) { anomalies = append(anomalies, a) }
+		return config, &anomalies
+	}
+
+	t.Run("reports breaker flapping once per window", func(t *testing.T) {
+		config, anomalies := collect(AnomalyConfig{FlapThreshold: 2})
+		breaker := NewCircuitBreaker(DefaultCircuitBreakerConfig())
+		exec := NewBuilder().
+			WithName("payments").
+			WithSharedCircuitBreaker(breaker).
+			WithAnomalyDetection(config).
+			Build()
+
+		for i := 0; i < 5; i++ {
+			breaker.Trip()
+			assert.ErrorIs(t, exec.Execute(ctx, ok), ErrCircuitOpen)
+			assert.ErrorIs(t, exec.Execute(ctx, ok), ErrCircuitOpen)
+			breaker.Reset()
+		}
+
+		require.Len(t, *anomalies, 1)
+		a := (*anomalies)[0]
+		assert.Equal(t, AnomalyFlapping, a.Kind)
+		assert.Equal(t, "payments", a.Executor)
+		assert.Equal(t, float64(3), a.Value)
+		assert.Equal(t, float64(2), a.Threshold)
+	})
+
+	t.Run("reports retry amplification", func(t *testing.T) {
+		config, anomalies := collect(AnomalyConfig{RetryMinCalls: 5, RetryAmplification: 2})
+		retry := DefaultRetryConfig()
+		retry.InitialInterval = time.Millisecond
+		retry.MaxInterval = time.Millisecond
+		exec := NewBuilder().
+			WithRetry(retry).
+			WithAnomalyDetection(config).
+			Build()
+
+		failing := errors.New("unavailable")
+		for i := 0; i < 10; i++ {
+			_ = exec.Execute(ctx, func(ctx context.Context) error { return failing })
+		}
+
+		require.Len(t, *anomalies, 1)
+		assert.Equal(t, AnomalyRetryAmplification, (*anomalies)[0].Kind)
+		assert.Equal(t, float64(3), (*anomalies)[0].Value)
+	})
+
+	t.Run("healthy calls report nothing", func(t *testing.T) {
+		config, anomalies := collect(AnomalyConfig{RetryMinCalls: 5})
+		exec := NewBuilder().
+			WithCircuitBreaker(DefaultCircuitBreakerConfig()).
+			WithRetry(DefaultRetryConfig()).
+			WithBulkhead(DefaultBulkheadConfig()).
+			WithAnomalyDetection(config).
+			Build()
+
+		for i := 0; i < 50; i++ {
+			require.NoError(t, exec.Execute(ctx, ok))
+		}
+		assert.Empty(t, *anomalies)
+	})
+
+	t.Run("reports and re-raises panics", func(t *testing.T) {
+		config, anomalies := collect(AnomalyConfig{})
+		exec := NewBuilder().WithAnomalyDetection(config).Build()
+
+		assert.PanicsWithValue(t, "boom", func() {
+			_ = exec.Execute(ctx, func(ctx context.Context) error { panic("boom") })
+		})
+
+		require.Len(t, *anomalies, 1)
+		assert.Equal(t, AnomalyPanic, (*anomalies)[0].Kind)
+		assert.Equal(t, "boom", (*anomalies)[0].Panic)
+		assert.NotEmpty(t, (*anomalies)[0].Stack)
+	})
+
+	t.Run("reports sustained saturation once per episode", func(t *testing.T) {
+		config, anomalies := collect(AnomalyConfig{SaturationDuration: 30 * time.Second})
+		d := newAnomalyDetector(config, "search", systemClock{})
+		now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+		d.now = func() time.Time { return now }
+
+		d.observeSaturation(true)
+		now = now.Add(20 * time.Second)
+		d.observeSaturation(true)
+		assert.Empty(t, *anomalies)
+
+		now = now.Add(10 * time.Second)
+		d.observeSaturation(true)
+		now = now.Add(10 * time.Second)
+		d.observeSaturation(true)
+		require.Len(t, *anomalies, 1)
+		assert.Equal(t, AnomalySaturation, (*anomalies)[0].Kind)
+		assert.Equal(t, float64(30), (*anomalies)[0].Value)
+
+		// A free slot ends the episode
+		d.observeSaturation(false)
+		d.observeSaturation(true)
+		now = now.Add(30 * time.Second)
+		d.observeSaturation(true)
+		assert.Len(t, *anomalies, 2)
+	})
+}
@@ -0,0 +1,185 @@
+package resilience
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// asyncExecutor implements the AsyncExecutor interface
+type asyncExecutor struct {
+	Executor
+	config  AsyncConfig
+	backoff BackoffStrategy
+	now     func() time.Time
+
+	mu      sync.Mutex
+	pending int
+	closed  bool
+
+	ctx    context.Context // canceled by Close
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewAsyncExecutor wraps an executor with a bounded background retry queue
+// for fire-and-forget calls. Each attempt runs under the wrapped executor,
+// so its own retries happen within a single queue attempt.
+func NewAsyncExecutor(executor Executor, config AsyncConfig) AsyncExecutor {
+	defaults := DefaultAsyncConfig()
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaults.QueueSize
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+	if config.InitialInterval <= 0 {
+		config.InitialInterval = defaults.InitialInterval
+	}
+	if config.MaxInterval <= 0 {
+		config.MaxInterval = defaults.MaxInterval
+	}
+	if config.Multiplier == 0 {
+		config.Multiplier = defaults.Multiplier
+	}
+	if config.RandomizationFactor == 0 {
+		config.RandomizationFactor = defaults.RandomizationFactor
+	}
+	if config.ShouldRetry == nil {
+		config.ShouldRetry = DefaultShouldRetry
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &asyncExecutor{
+		Executor: executor,
+		config:   config,
+		backoff: &exponentialBackoff{
+			initialInterval:     config.InitialInterval,
+			maxInterval:         config.MaxInterval,
+			multiplier:          config.Multiplier,
+			randomizationFactor: config.RandomizationFactor,
+		},
+		now:    time.Now,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+func (a *asyncExecutor) Go(ctx context.Context, id string, fn func(context.Context) error) error {
+	return a.enqueue(ctx, QueuedCall{ID: id, NextAttempt: a.now()}, fn)
+}
+
+func (a *asyncExecutor) Restore(ctx context.Context, call QueuedCall, fn func(context.Context) error) error {
+	return a.enqueue(ctx, call, fn)
+}
+
+func (a *asyncExecutor) Pending() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.pending
+}
+
+func (a *asyncExecutor) Close(ctx context.Context) error {
+	a.mu.Lock()
+	a.closed = true
+	a.mu.Unlock()
+	a.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		a.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// enqueue admits a call to the queue and starts running it
+func (a *asyncExecutor) enqueue(ctx context.Context, call QueuedCall, fn func(context.Context) error) error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return ErrExecutorClosed
+	}
+	if a.pending >= a.config.QueueSize {
+		a.mu.Unlock()
+		return ErrRetryQueueFull
+	}
+	a.pending++
+	a.wg.Add(1)
+	a.mu.Unlock()
+
+	a.save(call)
+	go a.run(context.WithoutCancel(ctx), call, fn)
+	return nil
+}
+
+// run attempts the call until it succeeds, is dead-lettered or the
+// executor is closed
+func (a *asyncExecutor) run(ctx context.Context, call QueuedCall, fn func(context.Context) error) {
+	defer func() {
+		a.mu.Lock()
+		a.pending--
+		a.mu.Unlock()
+		a.wg.Done()
+	}()
+
+	// Attempts are canceled when the executor closes
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(a.ctx, cancel)
+	defer stop()
+
+	for {
+		if wait := call.NextAttempt.Sub(a.now()); wait > 0 {
+			if err := sleep(a.ctx, wait, a.config.TimerWheel, nil); err != nil {
+				return
+			}
+		}
+
+		err := a.Execute(ctx, fn)
+		call.Attempts++
+		if err == nil {
+			a.remove(call.ID)
+			return
+		}
+
+		// Closing interrupted the attempt; leave it queued for Restore
+		if a.ctx.Err() != nil {
+			call.LastError = err.Error()
+			a.save(call)
+			return
+		}
+
+		retryable := a.config.ShouldRetry(err)
+		if !retryable || call.Attempts >= a.config.MaxAttempts {
+			a.remove(call.ID)
+			if a.config.OnDeadLetter != nil {
+				a.config.OnDeadLetter(call, err)
+			}
+			return
+		}
+
+		call.LastError = err.Error()
+		call.NextAttempt = a.now().Add(a.backoff.Next(call.Attempts - 1))
+		a.save(call)
+	}
+}
+
+// save persists the call; store errors leave the call queued in memory
+func (a *asyncExecutor) save(call QueuedCall) {
+	if a.config.Store != nil {
+		_ = a.config.Store.Save(call)
+	}
+}
+
+// remove deletes the call from the store
+func (a *asyncExecutor) remove(id string) {
+	if a.config.Store != nil {
+		_ = a.config.Store.Delete(id)
+	}
+}
@@ -0,0 +1,214 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryQueueStore keeps queued calls for assertions
+type memoryQueueStore struct {
+	mu    sync.Mutex
+	calls map[string]QueuedCall
+}
+
+func (s *memoryQueueStore) Save(call QueuedCall) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls[call.ID] = call
+	return nil
+}
+
+func (s *memoryQueueStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.calls, id)
+	return nil
+}
+
+func (s *memoryQueueStore) get(id string) (QueuedCall, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	call, ok := s.calls[id]
+	return call, ok
+}
+
+func TestAsyncExecutor(t *testing.T) {
+	fast := AsyncConfig{InitialInterval: time.Millisecond, MaxInterval: 5 * time.Millisecond}
+	unavailable := errors.New("unavailable")
+
+	t.Run("retries failed calls in the background", func(t *testing.T) {
+		store := &memoryQueueStore{calls: map[string]QueuedCall{}}
+		config := fast
+		config.Store = store
+		async := NewAsyncExecutor(NewBuilder().Build(), config)
+
+		var attempts atomic.Int32
+		done := make(chan struct{})
+		err := async.Go(context.Background(), "order-1", func(ctx context.Context) error {
+			if attempts.Add(1) < 3 {
+				return unavailable
+			}
+			close(done)
+			return nil
+		})
+		require.NoError(t, err)
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("call was not retried")
+		}
+		require.NoError(t, async.Close(context.Background()))
+		assert.Equal(t, int32(3), attempts.Load())
+		assert.Equal(t, 0, async.Pending())
+		_, ok := store.get("order-1")
+		assert.False(t, ok)
+	})
+
+	t.Run("dead-letters exhausted calls", func(t *testing.T) {
+		config := fast
+		config.MaxAttempts = 2
+		letters := make(chan QueuedCall, 1)
+		config.OnDeadLetter = func(call QueuedCall, err error) {
+			assert.ErrorIs(t, err, unavailable)
+			letters <- call
+		}
+		async := NewAsyncExecutor(NewBuilder().Build(), config)
+
+		require.NoError(t, async.Go(context.Background(), "order-2", func(ctx context.Context) error {
+			return unavailable
+		}))
+
+		select {
+		case call := <-letters:
+			assert.Equal(t, "order-2", call.ID)
+			assert.Equal(t, 2, call.Attempts)
+		case <-time.After(time.Second):
+			t.Fatal("call was not dead-lettered")
+		}
+		require.NoError(t, async.Close(context.Background()))
+	})
+
+	t.Run("dead-letters errors that should not be retried", func(t *testing.T) {
+		config := fast
+		config.ShouldRetry = func(err error) bool { return false }
+		letters := make(chan QueuedCall, 1)
+		config.OnDeadLetter = func(call QueuedCall, err error) { letters <- call }
+		async := NewAsyncExecutor(NewBuilder().Build(), config)
+
+		require.NoError(t, async.Go(context.Background(), "order-3", func(ctx context.Context) error {
+			return unavailable
+		}))
+
+		call := <-letters
+		assert.Equal(t, 1, call.Attempts)
+		require.NoError(t, async.Close(context.Background()))
+	})
+
+	t.Run("dead-letters permanent errors by default", func(t *testing.T) {
+		letters := make(chan QueuedCall, 1)
+		config := fast
+		config.OnDeadLetter = func(call QueuedCall, err error) { letters <- call }
+		async := NewAsyncExecutor(NewBuilder().Build(), config)
+
+		require.NoError(t, async.Go(context.Background(), "order-4", func(ctx context.Context) error {
+			return Permanent(unavailable)
+		}))
+
+		select {
+		case call := <-letters:
+			assert.Equal(t, 1, call.Attempts)
+		case <-time.After(time.Second):
+			t.Fatal("call was not dead-lettered")
+		}
+		require.NoError(t, async.Close(context.Background()))
+	})
+
+	t.Run("rejects calls beyond the queue size", func(t *testing.T) {
+		config := fast
+		config.QueueSize = 1
+		async := NewAsyncExecutor(NewBuilder().Build(), config)
+
+		release := make(chan struct{})
+		require.NoError(t, async.Go(context.Background(), "a", func(ctx context.Context) error {
+			<-release
+			return nil
+		}))
+		assert.ErrorIs(t, async.Go(context.Background(), "b", func(ctx context.Context) error {
+			return nil
+		}), ErrRetryQueueFull)
+
+		close(release)
+		require.NoError(t, async.Close(context.Background()))
+		assert.ErrorIs(t, async.Go(context.Background(), "c", func(ctx context.Context) error {
+			return nil
+		}), ErrExecutorClosed)
+	})
+
+	t.Run("leaves queued calls in the store on close", func(t *testing.T) {
+		store := &memoryQueueStore{calls: map[string]QueuedCall{}}
+		config := fast
+		config.Store = store
+		config.InitialInterval = time.Hour
+		config.MaxInterval = time.Hour
+		async := NewAsyncExecutor(NewBuilder().Build(), config)
+
+		attempted := make(chan struct{})
+		require.NoError(t, async.Go(context.Background(), "order-4", func(ctx context.Context) error {
+			close(attempted)
+			return unavailable
+		}))
+		<-attempted
+		require.Eventually(t, func() bool {
+			call, _ := store.get("order-4")
+			return call.LastError != ""
+		}, time.Second, time.Millisecond)
+		require.NoError(t, async.Close(context.Background()))
+
+		call, ok := store.get("order-4")
+		require.True(t, ok)
+		assert.Equal(t, 1, call.Attempts)
+		assert.Equal(t, unavailable.Error(), call.LastError)
+	})
+
+	t.Run("restores persisted calls", func(t *testing.T) {
+		async := NewAsyncExecutor(NewBuilder().Build(), fast)
+
+		done := make(chan struct{})
+		call := QueuedCall{ID: "order-5", Attempts: 3, NextAttempt: time.Now().Add(10 * time.Millisecond)}
+		require.NoError(t, async.Restore(context.Background(), call, func(ctx context.Context) error {
+			close(done)
+			return nil
+		}))
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("restored call did not run")
+		}
+		require.NoError(t, async.Close(context.Background()))
+	})
+
+	t.Run("does not inherit caller cancellation", func(t *testing.T) {
+		async := NewAsyncExecutor(NewBuilder().Build(), fast)
+		ctx, cancel := context.WithCancel(context.Background())
+
+		errs := make(chan error, 1)
+		require.NoError(t, async.Go(ctx, "order-6", func(ctx context.Context) error {
+			time.Sleep(10 * time.Millisecond)
+			errs <- ctx.Err()
+			return nil
+		}))
+		cancel()
+
+		assert.NoError(t, <-errs)
+		require.NoError(t, async.Close(context.Background()))
+	})
+}
@@ -0,0 +1,47 @@
+package resilience
+
+import "context"
+
+// attemptKey is the context key of the current Attempt
+type attemptKey struct{}
+
+// withAttempt returns ctx carrying attempt, passed through hook when the
+// attempt is a retry or a hedge
+func withAttempt(ctx context.Context, attempt Attempt, hook AttemptHook) context.Context {
+	ctx = context.WithValue(ctx, attemptKey{}, attempt)
+	if hook != nil && (attempt.Retry > 0 || attempt.Hedge > 0) {
+		ctx = hook(ctx, attempt)
+	}
+	return ctx
+}
+
+// withRetryAttempt returns ctx carrying the Attempt of retry number retry
+// out of maxAttempts, following an attempt that failed with lastErr
+func withRetryAttempt(ctx context.Context, retry, maxAttempts int, lastErr error) context.Context {
+	attempt := AttemptFromContext(ctx)
+	attempt.Retry, attempt.MaxAttempts, attempt.LastErr = retry, maxAttempts, lastErr
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+type targetKey struct{}
+
+// WithTarget returns a context whose call goes to target, as chosen by a
+// TargetSelector
+func WithTarget(ctx context.Context, target string) context.Context {
+	return context.WithValue(ctx, targetKey{}, target)
+}
+
+// TargetFromContext returns the target set by WithTarget, or ""
+func TargetFromContext(ctx context.Context) string {
+	target, _ := ctx.Value(targetKey{}).(string)
+	return target
+}
+
+// AttemptFromContext returns the Attempt carried by the context passed to a
+// retried function, or the zero Attempt outside a retry or hedge. Use it to
+// add the attempt to logs or to vary an attempt, e.g. switch replicas once
+// Retry is 1 or more.
+func AttemptFromContext(ctx context.Context) Attempt {
+	attempt, _ := ctx.Value(attemptKey{}).(Attempt)
+	return attempt
+}
@@ -0,0 +1,89 @@
+package resilience
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SplitBudget divides the time remaining until ctx's deadline among n
+// sequential downstream calls according to weights (equal when omitted).
+//
+// Child i gets a deadline at the end of its cumulative share, so time left
+// over by a call that finishes early carries forward to the next one, and
+// the last child ends at the parent deadline. Executors applied with a
+// child context time out at the earlier of their configured timeout and the
+// child deadline, so composed calls always fit within the parent budget.
+//
+// If ctx has no deadline, every child is ctx itself. The returned cancel
+// function releases all children. SplitBudget panics if n < 1, if weights
+// are given but their count differs from n, or if any weight is negative
+// or all are zero.
+func SplitBudget(ctx context.Context, n int, weights ...float64) ([]context.Context, context.CancelFunc) {
+	return SplitBudgetWithClock(ctx, nil, n, weights...)
+}
+
+// SplitBudgetWithClock is SplitBudget measuring the remaining time on
+// clock; nil uses the system clock. Like a Timeout on another clock, the
+// children of other clocks keep the parent's deadline and are canceled when
+// clock reaches the end of their share.
+func SplitBudgetWithClock(ctx context.Context, clock Clock, n int, weights ...float64) ([]context.Context, context.CancelFunc) {
+	if n < 1 {
+		panic(fmt.Sprintf("resilience: SplitBudget: invalid call count %d", n))
+	}
+	if len(weights) == 0 {
+		weights = make([]float64, n)
+		for i := range weights {
+			weights[i] = 1
+		}
+	}
+	if len(weights) != n {
+		panic(fmt.Sprintf("resilience: SplitBudget: %d weights for %d calls", len(weights), n))
+	}
+
+	var total float64
+	for _, w := range weights {
+		if w < 0 {
+			panic("resilience: SplitBudget: negative weight")
+		}
+		total += w
+	}
+	if total == 0 {
+		panic("resilience: SplitBudget: weights sum to zero")
+	}
+
+	children := make([]context.Context, n)
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		for i := range children {
+			children[i] = ctx
+		}
+		return children, func() {}
+	}
+
+	clock = clockOrSystem(clock)
+	_, system := clock.(systemClock)
+	now := clock.Now()
+	remaining := deadline.Sub(now)
+	cancels := make([]context.CancelFunc, n)
+
+	var cumulative float64
+	for i, w := range weights {
+		cumulative += w
+		childDeadline := deadline
+		if i < n-1 {
+			childDeadline = now.Add(time.Duration(float64(remaining) * cumulative / total))
+		}
+		if system {
+			children[i], cancels[i] = context.WithDeadline(ctx, childDeadline)
+		} else {
+			children[i], cancels[i] = withTimeout(ctx, clock, childDeadline.Sub(now))
+		}
+	}
+
+	return children, func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package resilience
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitBudget(t *testing.T) {
+	t.Run("splits remaining budget by weight", func(t *testing.T) {
+		parent, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		parentDeadline, _ := parent.Deadline()
+
+		children, cancelChildren := SplitBudget(parent, 3, 1, 2, 1)
+		defer cancelChildren()
+
+		assert.Len(t, children, 3)
+		first, _ := children[0].Deadline()
+		second, _ := children[1].Deadline()
+		last, _ := children[2].Deadline()
+
+		assert.InDelta(t, 250*time.Millisecond, time.Until(first), float64(20*time.Millisecond))
+		assert.InDelta(t, 750*time.Millisecond, time.Until(second), float64(20*time.Millisecond))
+		assert.Equal(t, parentDeadline, last)
+	})
+
+	t.Run("splits the budget remaining on the clock", func(t *testing.T) {
+		parent, cancel := context.WithTimeout(context.Background(), time.Hour)
+		defer cancel()
+		parentDeadline, _ := parent.Deadline()
+		clock := NewFakeClock(parentDeadline.Add(-4 * time.Second))
+
+		children, cancelChildren := SplitBudgetWithClock(parent, clock, 2, 1, 3)
+		defer cancelChildren()
+
+		clock.Advance(999 * time.Millisecond)
+		assert.NoError(t, children[0].Err())
+		clock.Advance(time.Millisecond)
+		assert.ErrorIs(t, context.Cause(children[0]), ErrTimeout)
+		assert.NoError(t, children[1].Err())
+		clock.Advance(3 * time.Second)
+		assert.Error(t, children[1].Err())
+	})
+
+	t.Run("returns parent when it has no deadline", func(t *testing.T) {
+		parent := context.Background()
+		children, cancel := SplitBudget(parent, 2)
+		defer cancel()
+
+		for _, child := range children {
+			assert.Equal(t, parent, child)
+		}
+	})
+
+	t.Run("executor timeout respects child deadline", func(t *testing.T) {
+		parent, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		children, cancelChildren := SplitBudget(parent, 4)
+		defer cancelChildren()
+
+		executor := NewBuilder().WithTimeout(time.Second).Build()
+		start := time.Now()
+		err := executor.Execute(children[0], func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		assert.ErrorIs(t, err, ErrTimeout)
+		assert.Less(t, time.Since(start), 80*time.Millisecond)
+	})
+
+	t.Run("panics on invalid arguments", func(t *testing.T) {
+		ctx := context.Background()
+		assert.Panics(t, func() { SplitBudget(ctx, 0) })
+		assert.Panics(t, func() { SplitBudget(ctx, 2, 1) })
+		assert.Panics(t, func() { SplitBudget(ctx, 2, 1, -1) })
+		assert.Panics(t, func() { SplitBudget(ctx, 2, 0, 0) })
+	})
+}
@@ -2,6 +2,10 @@ package resilience
 
 import (
 	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -9,15 +13,31 @@ import (
 type builder struct {
 	name              string
 	circuitBreaker    CircuitBreaker
+	keyedBreakers     KeyedCircuitBreaker
+	breakerKey        KeyFunc
 	retry             Retry
 	rateLimiter       RateLimiter
 	bulkhead          Bulkhead
 	timeout           Timeout
+	hedge             Hedge
 	hasCircuitBreaker bool
 	hasRetry          bool
 	hasRateLimiter    bool
 	hasBulkhead       bool
 	hasTimeout        bool
+	hasHedge          bool
+	flagProvider      FlagProvider
+	flagTTL           time.Duration
+	recorder          *EventRecorder
+	anomaly           *AnomalyConfig
+	metrics           *MetricsConfig
+	advisor           *AdvisorConfig
+	attemptHook       AttemptHook
+	attemptCanceled   OnAttemptCanceled
+	targetSelector    TargetSelector
+	onMisuse          OnMisuse
+	sampling          *PayloadSamplingConfig
+	clock             Clock
 }
 
 // NewBuilder creates a new builder
@@ -34,6 +54,36 @@ func (b *builder) WithName(name string) Builder {
 
 func (b *builder) WithCircuitBreaker(config CircuitBreakerConfig) Builder {
 	b.circuitBreaker = NewCircuitBreaker(config)
+	b.keyedBreakers = nil
+	b.hasCircuitBreaker = true
+	return b
+}
+
+func (b *builder) WithKeyedCircuitBreaker(config CircuitBreakerConfig, maxKeys int, key KeyFunc) Builder {
+	if key == nil {
+		key = BreakerKeyFromContext
+	}
+	b.circuitBreaker = nil
+	b.keyedBreakers = NewKeyedCircuitBreaker(config, maxKeys)
+	b.breakerKey = key
+	b.hasCircuitBreaker = true
+	return b
+}
+
+func (b *builder) WithSharedKeyedCircuitBreaker(breakers KeyedCircuitBreaker, key KeyFunc) Builder {
+	if key == nil {
+		key = BreakerKeyFromContext
+	}
+	b.circuitBreaker = nil
+	b.keyedBreakers = breakers
+	b.breakerKey = key
+	b.hasCircuitBreaker = true
+	return b
+}
+
+func (b *builder) WithSharedCircuitBreaker(breaker CircuitBreaker) Builder {
+	b.circuitBreaker = breaker
+	b.keyedBreakers = nil
 	b.hasCircuitBreaker = true
 	return b
 }
@@ -50,6 +100,12 @@ func (b *builder) WithRateLimiter(config RateLimiterConfig) Builder {
 	return b
 }
 
+func (b *builder) WithSharedRateLimiter(limiter RateLimiter) Builder {
+	b.rateLimiter = limiter
+	b.hasRateLimiter = true
+	return b
+}
+
 func (b *builder) WithBulkhead(config BulkheadConfig) Builder {
 	b.bulkhead = NewBulkhead(config)
 	b.hasBulkhead = true
@@ -62,19 +118,129 @@ func (b *builder) WithTimeout(duration time.Duration) Builder {
 	return b
 }
 
+func (b *builder) WithTimeoutConfig(config TimeoutConfig) Builder {
+	b.timeout = NewTimeoutWithConfig(config, b.name)
+	b.hasTimeout = true
+	return b
+}
+
+func (b *builder) WithHedge(config HedgeConfig) Builder {
+	b.hedge = NewHedge(config)
+	b.hasHedge = true
+	return b
+}
+
+func (b *builder) WithFlagProvider(provider FlagProvider, ttl time.Duration) Builder {
+	b.flagProvider = provider
+	b.flagTTL = ttl
+	return b
+}
+
+func (b *builder) WithEventRecorder(recorder *EventRecorder) Builder {
+	b.recorder = recorder
+	return b
+}
+
+func (b *builder) WithMetrics(config MetricsConfig) Builder {
+	b.metrics = &config
+	return b
+}
+
+func (b *builder) WithConfigAdvisor(config AdvisorConfig) Builder {
+	b.advisor = &config
+	return b
+}
+
+func (b *builder) WithAnomalyDetection(config AnomalyConfig) Builder {
+	b.anomaly = &config
+	return b
+}
+
+func (b *builder) WithAttemptHook(hook AttemptHook) Builder {
+	b.attemptHook = hook
+	return b
+}
+
+func (b *builder) WithAttemptCanceledHook(hook OnAttemptCanceled) Builder {
+	b.attemptCanceled = hook
+	return b
+}
+
+func (b *builder) WithTargetSelector(selector TargetSelector) Builder {
+	b.targetSelector = selector
+	return b
+}
+
+func (b *builder) WithMisuseDetection(onMisuse OnMisuse) Builder {
+	b.onMisuse = onMisuse
+	return b
+}
+
+func (b *builder) WithPayloadSampling(config PayloadSamplingConfig) Builder {
+	b.sampling = &config
+	return b
+}
+
+func (b *builder) WithClock(clock Clock) Builder {
+	b.clock = clock
+	return b
+}
+
 func (b *builder) Build() Executor {
+	clock := clockOrSystem(b.clock)
+
+	var flags *flagCache
+	if b.flagProvider != nil {
+		flags = newFlagCache(b.flagProvider, b.name, b.flagTTL, clock)
+	}
+
+	var metrics *executorMetrics
+	if b.metrics != nil && b.metrics.Recorder != nil {
+		metrics = newExecutorMetrics(*b.metrics)
+	}
+
+	var advisor *configAdvisor
+	if b.advisor != nil && b.advisor.OnAdvisory != nil {
+		advisor = newConfigAdvisor(*b.advisor, b.name, clock)
+	}
+
+	var anomalies *anomalyDetector
+	if b.anomaly != nil {
+		anomalies = newAnomalyDetector(*b.anomaly, b.name, clock)
+	}
+
+	var sampler *payloadSampler
+	if b.sampling != nil && b.sampling.Extractor != nil && b.sampling.OnSample != nil {
+		sampler = newPayloadSampler(*b.sampling, b.name, clock)
+	}
+
 	return &executor{
 		name:              b.name,
 		circuitBreaker:    b.circuitBreaker,
+		keyedBreakers:     b.keyedBreakers,
+		breakerKey:        b.breakerKey,
 		retry:             b.retry,
 		rateLimiter:       b.rateLimiter,
 		bulkhead:          b.bulkhead,
 		timeout:           b.timeout,
+		hedge:             b.hedge,
 		hasCircuitBreaker: b.hasCircuitBreaker,
 		hasRetry:          b.hasRetry,
 		hasRateLimiter:    b.hasRateLimiter,
 		hasBulkhead:       b.hasBulkhead,
 		hasTimeout:        b.hasTimeout,
+		hasHedge:          b.hasHedge,
+		flags:             flags,
+		recorder:          b.recorder,
+		anomalies:         anomalies,
+		metrics:           metrics,
+		advisor:           advisor,
+		attemptHook:       b.attemptHook,
+		attemptCanceled:   b.attemptCanceled,
+		targetSelector:    b.targetSelector,
+		onMisuse:          b.onMisuse,
+		sampler:           sampler,
+		clock:             clock,
 	}
 }
 
@@ -82,99 +248,334 @@ func (b *builder) Build() Executor {
 type executor struct {
 	name              string
 	circuitBreaker    CircuitBreaker
+	keyedBreakers     KeyedCircuitBreaker
+	breakerKey        KeyFunc
 	retry             Retry
 	rateLimiter       RateLimiter
 	bulkhead          Bulkhead
 	timeout           Timeout
+	hedge             Hedge
 	hasCircuitBreaker bool
 	hasRetry          bool
 	hasRateLimiter    bool
 	hasBulkhead       bool
 	hasTimeout        bool
+	hasHedge          bool
+	flags             *flagCache
+	recorder          *EventRecorder
+	anomalies         *anomalyDetector
+	metrics           *executorMetrics
+	advisor           *configAdvisor
+	attemptHook       AttemptHook
+	attemptCanceled   OnAttemptCanceled
+	targetSelector    TargetSelector
+	onMisuse          OnMisuse
+	sampler           *payloadSampler
+	clock             Clock
+	counters          callCounters
 }
 
 func (e *executor) Name() string {
 	return e.name
 }
 
-func (e *executor) Execute(ctx context.Context, fn func(context.Context) error) error {
+func (e *executor) Execute(ctx context.Context, fn func(context.Context) error, opts ...CallOption) error {
 	_, err := e.ExecuteWithResult(ctx, func(ctx context.Context) (any, error) {
 		return nil, fn(ctx)
-	})
+	}, opts...)
 	return err
 }
 
-func (e *executor) ExecuteWithResult(ctx context.Context, fn func(context.Context) (any, error)) (any, error) {
+func (e *executor) TryExecute(ctx context.Context, fn func(context.Context) error, opts ...CallOption) error {
+	return e.Execute(ctx, fn, append(opts, NoWait())...)
+}
+
+func (e *executor) ExecuteWithResult(ctx context.Context, fn func(context.Context) (any, error), opts ...CallOption) (any, error) {
+	if e.onMisuse != nil {
+		var call *misuseFrame
+		ctx, call = e.checkMisuse(ctx)
+		defer call.returned.Store(true)
+	}
+
+	if e.metrics == nil && e.sampler == nil {
+		result, err := e.execute(ctx, fn, opts)
+		e.counters.observe(err)
+		return result, err
+	}
+
+	start := e.clock.Now()
+	result, err := e.execute(ctx, fn, opts)
+	e.counters.observe(err)
+	if e.metrics != nil {
+		e.metrics.record(ctx, e.name, e.clock.Now().Sub(start), err)
+	}
+	if e.sampler != nil {
+		e.sampler.observe(ctx, start, err)
+	}
+	return result, err
+}
+
+// execute runs fn through the configured patterns
+func (e *executor) execute(ctx context.Context, fn func(context.Context) (any, error), opts []CallOption) (any, error) {
+	options := newCallOptions(opts)
+
+	if e.advisor != nil && e.advisor.observeCall() {
+		e.advisor.evaluate(e)
+	}
+
 	// Wrap the function with all patterns in order:
 	// 1. Rate Limiter (outermost - control admission)
 	// 2. Bulkhead (limit concurrency)
 	// 3. Timeout (add deadline)
-	// 4. Circuit Breaker (protect downstream)
-	// 5. Retry (innermost - retry failures)
+	// 4. Hedge (race duplicate attempts against slow calls)
+	// 5. Circuit Breaker (protect downstream)
+	// 6. Retry (innermost - retry failures)
+	// Patterns may be bypassed per call by feature flags or the context.
 
 	wrappedFn := func(ctx context.Context) (any, error) {
 		return fn(ctx)
 	}
 
+	// Report attempts canceled while running
+	if e.attemptCanceled != nil {
+		originalFn := wrappedFn
+		wrappedFn = func(ctx context.Context) (any, error) {
+			attempt := AttemptFromContext(ctx)
+			attempt.Executor = e.name
+			stop := context.AfterFunc(ctx, func() {
+				e.attemptCanceled(ctx, attempt)
+			})
+			defer stop()
+			return originalFn(ctx)
+		}
+	}
+
+	// Direct each attempt, retried or hedged, to a target
+	if e.targetSelector != nil {
+		var mu sync.Mutex
+		var tried []string
+		originalFn := wrappedFn
+		wrappedFn = func(ctx context.Context) (any, error) {
+			attempt := AttemptFromContext(ctx)
+			attempt.Executor = e.name
+
+			mu.Lock()
+			target := e.targetSelector(ctx, attempt, slices.Clone(tried))
+			tried = append(tried, target)
+			mu.Unlock()
+
+			return originalFn(WithTarget(ctx, target))
+		}
+	}
+
+	// Count attempts and report panics for anomaly detection
+	var attempts atomic.Int32
+	if e.anomalies != nil {
+		originalFn := wrappedFn
+		wrappedFn = func(ctx context.Context) (any, error) {
+			attempts.Add(1)
+			defer func() {
+				if r := recover(); r != nil {
+					e.anomalies.observePanic(r)
+					panic(r)
+				}
+			}()
+			return originalFn(ctx)
+		}
+	}
+
 	// Apply retry (innermost)
-	if e.hasRetry {
+	if e.hasRetry && e.patternEnabled(ctx, PatternRetry) {
 		originalFn := wrappedFn
 		wrappedFn = func(ctx context.Context) (any, error) {
-			var result any
-			err := e.retry.Execute(ctx, func(ctx context.Context) error {
-				var execErr error
-				result, execErr = originalFn(ctx)
-				return execErr
+			return e.retry.ExecuteWithResult(ctx, func(ctx context.Context) (any, error) {
+				if e.tracksAttempts() {
+					attempt := AttemptFromContext(ctx)
+					attempt.Executor = e.name
+					ctx = withAttempt(ctx, attempt, e.attemptHook)
+				}
+				return originalFn(ctx)
 			})
+		}
+	}
+
+	// Select the breaker for this call
+	var cb CircuitBreaker
+	if e.hasCircuitBreaker && e.patternEnabled(ctx, PatternCircuitBreaker) {
+		cb = e.circuitBreaker
+		if e.keyedBreakers != nil {
+			cb = e.keyedBreakers.CircuitBreaker(e.breakerKey(ctx))
+		}
+	}
+
+	// Record outcomes as seen by the circuit breaker
+	if e.recorder != nil {
+		originalFn := wrappedFn
+		wrappedFn = func(ctx context.Context) (any, error) {
+			start := e.clock.Now()
+			result, err := originalFn(ctx)
+			e.recordEvent(cb, start, err)
 			return result, err
 		}
 	}
 
 	// Apply circuit breaker
-	if e.hasCircuitBreaker {
+	if cb != nil {
 		originalFn := wrappedFn
 		wrappedFn = func(ctx context.Context) (any, error) {
 			var result any
-			err := e.circuitBreaker.Execute(ctx, func(ctx context.Context) error {
+			called := false
+			err := cb.Execute(ctx, func(ctx context.Context) error {
+				called = true
 				var execErr error
 				result, execErr = originalFn(ctx)
 				return execErr
 			})
+			if !called && e.recorder != nil {
+				e.recordRejection(err)
+			}
+			if e.advisor != nil {
+				e.advisor.observeBreaker(cb.Metrics().Requests)
+			}
+			if e.anomalies != nil && cb.State() == StateOpen {
+				e.anomalies.observeOpen(cb.Metrics().StateSince)
+			}
 			return result, err
 		}
 	}
 
+	// Apply hedging
+	if e.hasHedge && e.patternEnabled(ctx, PatternHedge) {
+		originalFn := wrappedFn
+		wrappedFn = func(ctx context.Context) (any, error) {
+			if !e.tracksAttempts() {
+				return e.hedge.ExecuteWithResult(ctx, originalFn)
+			}
+			var hedges atomic.Int32
+			return e.hedge.ExecuteWithResult(ctx, func(ctx context.Context) (any, error) {
+				attempt := Attempt{Executor: e.name, Hedge: int(hedges.Add(1) - 1)}
+				return originalFn(withAttempt(ctx, attempt, e.attemptHook))
+			})
+		}
+	}
+
 	// Apply timeout
-	if e.hasTimeout {
+	if e.hasTimeout && !options.noTimeout && e.patternEnabled(ctx, PatternTimeout) {
+		t := e.timeout
+		if options.timeoutExtend > 0 {
+			t = extendTimeout(t, options.timeoutExtend)
+		}
 		originalFn := wrappedFn
 		wrappedFn = func(ctx context.Context) (any, error) {
-			result, err := e.timeout.ExecuteWithResult(ctx, func(ctx context.Context) (any, error) {
+			start := e.clock.Now()
+			result, err := t.ExecuteWithResult(ctx, func(ctx context.Context) (any, error) {
 				return originalFn(ctx)
 			})
+			if e.advisor != nil && err == nil {
+				e.advisor.observeLatency(e.clock.Now().Sub(start))
+			}
 			return result, err
 		}
 	}
 
 	// Apply bulkhead
-	if e.hasBulkhead {
+	if e.hasBulkhead && e.patternEnabled(ctx, PatternBulkhead) {
 		originalFn := wrappedFn
 		wrappedFn = func(ctx context.Context) (any, error) {
+			if e.anomalies != nil {
+				e.anomalies.observeSaturation(e.bulkhead.Available() == 0)
+			}
 			var result any
-			err := e.bulkhead.Execute(ctx, func(ctx context.Context) error {
+			run := e.bulkhead.Execute
+			if options.noWait {
+				run = e.bulkhead.TryExecute
+			}
+			err := run(ctx, func(ctx context.Context) error {
 				var execErr error
 				result, execErr = originalFn(ctx)
 				return execErr
 			})
+			if cb != nil && errors.Is(err, ErrQueueTimeout) && e.queueTimeoutToBreaker() {
+				if permit, allowErr := cb.Allow(); allowErr == nil {
+					permit.Record(err)
+				}
+			}
 			return result, err
 		}
 	}
 
 	// Apply rate limiter (outermost)
-	if e.hasRateLimiter {
-		if err := e.rateLimiter.Wait(ctx); err != nil {
+	if e.hasRateLimiter && e.patternEnabled(ctx, PatternRateLimiter) {
+		if options.noWait {
+			if !e.rateLimiter.Allow() {
+				return nil, ErrRateLimitExceeded
+			}
+		} else if err := e.rateLimiter.Wait(ctx); err != nil {
 			return nil, err
 		}
 	}
 
-	return wrappedFn(ctx)
+	if e.anomalies == nil {
+		return wrappedFn(ctx)
+	}
+	result, err := wrappedFn(ctx)
+	e.anomalies.observeCall(int(attempts.Load()))
+	return result, err
+}
+
+// tracksAttempts reports whether attempts carry their Attempt in the context
+func (e *executor) tracksAttempts() bool {
+	return e.attemptHook != nil || e.attemptCanceled != nil || e.targetSelector != nil
+}
+
+// queueTimeoutToBreaker reports whether bulkhead queue timeouts count
+// against the circuit breaker
+func (e *executor) queueTimeoutToBreaker() bool {
+	b, ok := e.bulkhead.(*bulkhead)
+	return ok && b.config.QueueTimeoutToBreaker
+}
+
+// patternEnabled reports whether a configured pattern applies to this call
+func (e *executor) patternEnabled(ctx context.Context, pattern Pattern) bool {
+	if patternDisabled(ctx, pattern) {
+		return false
+	}
+	if e.flags != nil {
+		return e.flags.enabled(pattern)
+	}
+	return true
+}
+
+// recordEvent records a completed call
+func (e *executor) recordEvent(breaker CircuitBreaker, start time.Time, err error) {
+	if breaker == nil {
+		breaker = e.circuitBreaker
+	}
+	event := Event{
+		Time:     start,
+		Executor: e.name,
+		Outcome:  OutcomeSuccess,
+		Duration: e.clock.Now().Sub(start),
+	}
+	if err != nil {
+		event.Error = err.Error()
+		event.Outcome = OutcomeFailure
+		if cb, ok := breaker.(*circuitBreaker); ok {
+			event.Outcome = cb.outcome(err)
+		}
+	}
+	e.recorder.Record(event)
+}
+
+// recordRejection records a call rejected by the circuit breaker
+func (e *executor) recordRejection(err error) {
+	event := Event{
+		Time:     e.clock.Now(),
+		Executor: e.name,
+		Outcome:  OutcomeRejected,
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	e.recorder.Record(event)
 }
@@ -3,10 +3,14 @@ package resilience
 import (
 	"context"
 	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewBuilder(t *testing.T) {
@@ -150,3 +154,199 @@ func TestExecutorExecuteWithResult(t *testing.T) {
 		assert.Nil(t, result)
 	})
 }
+
+func TestExecutorAttemptHook(t *testing.T) {
+	type hookKey struct{}
+	ctx := context.Background()
+
+	t.Run("tags retried attempts", func(t *testing.T) {
+		var hooked []Attempt
+		var seen []any
+		failure := errors.New("error")
+		exec := NewBuilder().
+			WithName("payments").
+			WithRetry(RetryConfig{MaxAttempts: 3, InitialInterval: time.Millisecond}).
+			WithAttemptHook(func(ctx context.Context, attempt Attempt) context.Context {
+				hooked = append(hooked, attempt)
+				return context.WithValue(ctx, hookKey{}, attempt.Retry)
+			}).
+			Build()
+
+		err := exec.Execute(ctx, func(ctx context.Context) error {
+			seen = append(seen, ctx.Value(hookKey{}))
+			return failure
+		})
+
+		assert.Error(t, err)
+		assert.Equal(t, []Attempt{
+			{Executor: "payments", Retry: 1, MaxAttempts: 3, LastErr: failure},
+			{Executor: "payments", Retry: 2, MaxAttempts: 3, LastErr: failure},
+		}, hooked)
+		assert.Equal(t, []any{nil, 1, 2}, seen)
+	})
+
+	t.Run("tags hedged attempts", func(t *testing.T) {
+		var mu sync.Mutex
+		var hooked []Attempt
+		exec := NewBuilder().
+			WithName("search").
+			WithHedge(HedgeConfig{Delay: time.Millisecond, MaxHedges: 1}).
+			WithAttemptHook(func(ctx context.Context, attempt Attempt) context.Context {
+				mu.Lock()
+				defer mu.Unlock()
+				hooked = append(hooked, attempt)
+				return ctx
+			}).
+			Build()
+
+		err := exec.Execute(ctx, func(ctx context.Context) error {
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		})
+
+		assert.NoError(t, err)
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, []Attempt{{Executor: "search", Hedge: 1}}, hooked)
+	})
+}
+
+func TestExecutorAttemptCanceledHook(t *testing.T) {
+	type resourceKey struct{}
+	ctx := context.Background()
+
+	t.Run("reports hedges canceled by a faster attempt", func(t *testing.T) {
+		canceled := make(chan Attempt, 2)
+		released := make(chan any, 2)
+		exec := NewBuilder().
+			WithName("search").
+			WithHedge(HedgeConfig{Delay: time.Millisecond, MaxHedges: 1}).
+			WithAttemptHook(func(ctx context.Context, attempt Attempt) context.Context {
+				return context.WithValue(ctx, resourceKey{}, "tmp-1")
+			}).
+			WithAttemptCanceledHook(func(ctx context.Context, attempt Attempt) {
+				released <- ctx.Value(resourceKey{})
+				canceled <- attempt
+			}).
+			Build()
+
+		var calls atomic.Int32
+		err := exec.Execute(ctx, func(ctx context.Context) error {
+			if calls.Add(1) == 1 {
+				// The original attempt is slow and waits to be canceled
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, Attempt{Executor: "search"}, <-canceled)
+		assert.Nil(t, <-released, "the original attempt is not hooked")
+	})
+
+	t.Run("ignores attempts that complete", func(t *testing.T) {
+		var canceled atomic.Int32
+		exec := NewBuilder().
+			WithRetry(RetryConfig{MaxAttempts: 2, InitialInterval: time.Millisecond}).
+			WithAttemptCanceledHook(func(ctx context.Context, attempt Attempt) {
+				canceled.Add(1)
+			}).
+			Build()
+
+		_ = exec.Execute(ctx, func(ctx context.Context) error { return errors.New("error") })
+		time.Sleep(10 * time.Millisecond)
+		assert.Zero(t, canceled.Load())
+	})
+
+	t.Run("reports attempts canceled by the timeout", func(t *testing.T) {
+		canceled := make(chan Attempt, 1)
+		exec := NewBuilder().
+			WithName("payments").
+			WithTimeout(10 * time.Millisecond).
+			WithAttemptCanceledHook(func(ctx context.Context, attempt Attempt) {
+				canceled <- attempt
+			}).
+			Build()
+
+		err := exec.Execute(ctx, func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		assert.ErrorIs(t, err, ErrTimeout)
+		assert.Equal(t, Attempt{Executor: "payments"}, <-canceled)
+	})
+}
+
+func TestExecutorTargetSelector(t *testing.T) {
+	ctx := context.Background()
+	replicas := []string{"replica-a", "replica-b", "replica-c"}
+	nextUntried := func(ctx context.Context, attempt Attempt, tried []string) string {
+		for _, replica := range replicas {
+			if !slices.Contains(tried, replica) {
+				return replica
+			}
+		}
+		return replicas[0]
+	}
+
+	t.Run("retries on another replica", func(t *testing.T) {
+		var attempts []Attempt
+		var targets []string
+		unavailable := errors.New("unavailable")
+		exec := NewBuilder().
+			WithName("inventory").
+			WithRetry(RetryConfig{MaxAttempts: 3, InitialInterval: time.Millisecond}).
+			WithTargetSelector(func(ctx context.Context, attempt Attempt, tried []string) string {
+				attempts = append(attempts, attempt)
+				return nextUntried(ctx, attempt, tried)
+			}).
+			Build()
+
+		err := exec.Execute(ctx, func(ctx context.Context) error {
+			targets = append(targets, TargetFromContext(ctx))
+			if TargetFromContext(ctx) != "replica-c" {
+				return unavailable
+			}
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, replicas, targets)
+		assert.Equal(t, []Attempt{
+			{Executor: "inventory", MaxAttempts: 3},
+			{Executor: "inventory", Retry: 1, MaxAttempts: 3, LastErr: unavailable},
+			{Executor: "inventory", Retry: 2, MaxAttempts: 3, LastErr: unavailable},
+		}, attempts)
+	})
+
+	t.Run("sends hedges elsewhere", func(t *testing.T) {
+		var mu sync.Mutex
+		var targets []string
+		exec := NewBuilder().
+			WithHedge(HedgeConfig{Delay: time.Millisecond, MaxHedges: 1}).
+			WithTargetSelector(nextUntried).
+			Build()
+
+		err := exec.Execute(ctx, func(ctx context.Context) error {
+			mu.Lock()
+			targets = append(targets, TargetFromContext(ctx))
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		})
+
+		require.NoError(t, err)
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, replicas[:2], targets)
+	})
+
+	t.Run("leaves the target empty without a selector", func(t *testing.T) {
+		err := NewBuilder().Build().Execute(ctx, func(ctx context.Context) error {
+			assert.Empty(t, TargetFromContext(ctx))
+			return nil
+		})
+		assert.NoError(t, err)
+	})
+}
@@ -2,13 +2,18 @@ package resilience
 
 import (
 	"context"
+	"sync/atomic"
 )
 
 // bulkhead implements the Bulkhead interface using semaphore pattern
 type bulkhead struct {
 	config BulkheadConfig
+	clock  Clock
 	sem    chan struct{}
 	queue  chan struct{}
+	active *smoothedGauge
+
+	queueTimeouts atomic.Uint64
 }
 
 // NewBulkhead creates a new bulkhead
@@ -20,10 +25,17 @@ func NewBulkhead(config BulkheadConfig) Bulkhead {
 		config.MaxQueueSize = DefaultBulkheadConfig().MaxQueueSize
 	}
 
+	if config.StatsSmoothing == 0 {
+		config.StatsSmoothing = DefaultBulkheadConfig().StatsSmoothing
+	}
+
+	clock := clockOrSystem(config.Clock)
 	return &bulkhead{
 		config: config,
+		clock:  clock,
 		sem:    make(chan struct{}, config.MaxConcurrent),
 		queue:  make(chan struct{}, config.MaxQueueSize),
+		active: newSmoothedGauge(config.StatsSmoothing, 0, clock.Now()),
 	}
 }
 
@@ -36,24 +48,21 @@ func (b *bulkhead) Execute(ctx context.Context, fn func(context.Context) error)
 	select {
 	case b.sem <- struct{}{}:
 		// Got a slot, execute immediately
-		defer func() { <-b.sem }()
+		defer b.release()
+		b.active.add(b.clock.Now(), 1)
 		return fn(ctx)
 
 	default:
 		// No slot available, try to queue
 		select {
 		case b.queue <- struct{}{}:
-			// Queued successfully
-			defer func() { <-b.queue }()
-
-			// Wait for a slot
-			select {
-			case b.sem <- struct{}{}:
-				defer func() { <-b.sem }()
-				return fn(ctx)
-			case <-ctx.Done():
-				return ctx.Err()
+			// Queued successfully; wait for a slot
+			if err := b.awaitSlot(ctx); err != nil {
+				return err
 			}
+			defer b.release()
+			b.active.add(b.clock.Now(), 1)
+			return fn(ctx)
 
 		default:
 			// Queue is full
@@ -65,6 +74,62 @@ func (b *bulkhead) Execute(ctx context.Context, fn func(context.Context) error)
 	}
 }
 
+func (b *bulkhead) TryExecute(ctx context.Context, fn func(context.Context) error) error {
+	select {
+	case b.sem <- struct{}{}:
+		defer b.release()
+		b.active.add(b.clock.Now(), 1)
+		return fn(ctx)
+	default:
+		if b.config.OnBulkheadFull != nil {
+			b.config.OnBulkheadFull(b.config.Name)
+		}
+		return ErrBulkheadFull
+	}
+}
+
 func (b *bulkhead) Available() int {
 	return b.config.MaxConcurrent - len(b.sem)
 }
+
+func (b *bulkhead) Stats() BulkheadStats {
+	inFlight := len(b.sem)
+	smoothed := b.active.value(b.clock.Now())
+
+	return BulkheadStats{
+		Available:         b.config.MaxConcurrent - inFlight,
+		InFlight:          inFlight,
+		Queued:            len(b.queue),
+		SmoothedAvailable: float64(b.config.MaxConcurrent) - smoothed,
+		SmoothedInFlight:  smoothed,
+		QueueTimeouts:     b.queueTimeouts.Load(),
+	}
+}
+
+// awaitSlot waits in the queue for a slot, leaving the queue as soon as it
+// gets one or gives up, so Queued only counts calls still waiting
+func (b *bulkhead) awaitSlot(ctx context.Context) error {
+	defer func() { <-b.queue }()
+
+	var expired <-chan struct{}
+	if b.config.MaxQueueWait > 0 {
+		var stop func()
+		expired, stop = after(b.config.MaxQueueWait, b.config.TimerWheel, b.clock)
+		defer stop()
+	}
+	select {
+	case b.sem <- struct{}{}:
+		return nil
+	case <-expired:
+		b.queueTimeouts.Add(1)
+		return ErrQueueTimeout
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees a slot taken by Execute
+func (b *bulkhead) release() {
+	b.active.add(b.clock.Now(), -1)
+	<-b.sem
+}
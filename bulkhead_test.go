@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewBulkhead(t *testing.T) {
@@ -135,4 +136,237 @@ func TestBulkheadFull(t *testing.T) {
 		close(done1)
 		close(done2)
 	})
+
+	t.Run("TryExecute rejects instead of queuing", func(t *testing.T) {
+		bulkhead := NewBulkhead(BulkheadConfig{Name: "test", MaxConcurrent: 1, MaxQueueSize: 10})
+		ctx := context.Background()
+
+		release := make(chan struct{})
+		started := make(chan struct{})
+		go func() {
+			_ = bulkhead.Execute(ctx, func(ctx context.Context) error {
+				close(started)
+				<-release
+				return nil
+			})
+		}()
+		<-started
+
+		err := bulkhead.TryExecute(ctx, func(ctx context.Context) error { return nil })
+		assert.ErrorIs(t, err, ErrBulkheadFull)
+		assert.Zero(t, bulkhead.Stats().Queued)
+
+		close(release)
+		assert.Eventually(t, func() bool { return bulkhead.Available() == 1 }, time.Second, time.Millisecond)
+		assert.NoError(t, bulkhead.TryExecute(ctx, func(ctx context.Context) error { return nil }))
+	})
+}
+
+func TestBulkheadStats(t *testing.T) {
+	t.Run("reports raw and smoothed gauges", func(t *testing.T) {
+		bulkhead := NewBulkhead(BulkheadConfig{
+			Name:           "test",
+			MaxConcurrent:  2,
+			MaxQueueSize:   1,
+			StatsSmoothing: time.Hour,
+		})
+
+		running := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			bulkhead.Execute(context.Background(), func(ctx context.Context) error {
+				close(running)
+				<-done
+				return nil
+			})
+		}()
+		<-running
+
+		stats := bulkhead.Stats()
+		assert.Equal(t, 1, stats.InFlight)
+		assert.Equal(t, 1, stats.Available)
+		assert.Equal(t, 0, stats.Queued)
+		assert.Less(t, stats.SmoothedInFlight, 0.01)
+		assert.Greater(t, stats.SmoothedAvailable, 1.99)
+
+		close(done)
+	})
+
+	t.Run("stops counting calls that left the queue", func(t *testing.T) {
+		bulkhead := NewBulkhead(BulkheadConfig{Name: "test", MaxConcurrent: 1, MaxQueueSize: 1})
+		ctx := context.Background()
+
+		releaseFirst := make(chan struct{})
+		firstRunning := make(chan struct{})
+		go func() {
+			_ = bulkhead.Execute(ctx, func(ctx context.Context) error {
+				close(firstRunning)
+				<-releaseFirst
+				return nil
+			})
+		}()
+		<-firstRunning
+
+		releaseSecond := make(chan struct{})
+		secondRunning := make(chan struct{})
+		go func() {
+			_ = bulkhead.Execute(ctx, func(ctx context.Context) error {
+				close(secondRunning)
+				<-releaseSecond
+				return nil
+			})
+		}()
+		assert.Eventually(t, func() bool { return bulkhead.Stats().Queued == 1 }, time.Second, time.Millisecond)
+
+		close(releaseFirst)
+		<-secondRunning
+		assert.Equal(t, 0, bulkhead.Stats().Queued)
+
+		// The queue has room while the dequeued call runs
+		queued := make(chan error, 1)
+		go func() {
+			queued <- bulkhead.Execute(ctx, func(ctx context.Context) error { return nil })
+		}()
+		assert.Eventually(t, func() bool { return bulkhead.Stats().Queued == 1 }, time.Second, time.Millisecond)
+
+		close(releaseSecond)
+		assert.NoError(t, <-queued)
+	})
+}
+
+func TestBulkheadQueueTimeout(t *testing.T) {
+	// occupy fills the only slot until the returned func is called
+	occupy := func(bulkhead Bulkhead) func() {
+		running := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			bulkhead.Execute(context.Background(), func(ctx context.Context) error {
+				close(running)
+				<-done
+				return nil
+			})
+		}()
+		<-running
+		return func() { close(done) }
+	}
+
+	t.Run("fails queued calls after MaxQueueWait", func(t *testing.T) {
+		bulkhead := NewBulkhead(BulkheadConfig{
+			Name:          "test",
+			MaxConcurrent: 1,
+			MaxQueueSize:  1,
+			MaxQueueWait:  20 * time.Millisecond,
+		})
+		release := occupy(bulkhead)
+		defer release()
+
+		called := false
+		err := bulkhead.Execute(context.Background(), func(ctx context.Context) error {
+			called = true
+			return nil
+		})
+
+		assert.ErrorIs(t, err, ErrQueueTimeout)
+		assert.False(t, called)
+		assert.Equal(t, uint64(1), bulkhead.Stats().QueueTimeouts)
+	})
+
+	t.Run("times MaxQueueWait on a timer wheel", func(t *testing.T) {
+		bulkhead := NewBulkhead(BulkheadConfig{
+			Name:          "test",
+			MaxConcurrent: 1,
+			MaxQueueSize:  1,
+			MaxQueueWait:  20 * time.Millisecond,
+			TimerWheel:    NewTimerWheel(time.Millisecond),
+		})
+		release := occupy(bulkhead)
+		defer release()
+
+		start := time.Now()
+		err := bulkhead.Execute(context.Background(), func(ctx context.Context) error { return nil })
+		assert.ErrorIs(t, err, ErrQueueTimeout)
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	})
+
+	t.Run("times MaxQueueWait on the configured clock", func(t *testing.T) {
+		clock := NewFakeClock(time.Unix(0, 0))
+		bulkhead := NewBulkhead(BulkheadConfig{
+			Name:          "test",
+			MaxConcurrent: 1,
+			MaxQueueSize:  1,
+			MaxQueueWait:  time.Hour,
+			Clock:         clock,
+		})
+		release := occupy(bulkhead)
+		defer release()
+
+		done := make(chan error)
+		go func() {
+			done <- bulkhead.Execute(context.Background(), func(ctx context.Context) error { return nil })
+		}()
+
+		require.NoError(t, clock.BlockUntil(context.Background(), 1))
+		clock.Advance(time.Hour)
+		assert.ErrorIs(t, <-done, ErrQueueTimeout)
+	})
+
+	t.Run("context errors are not queue timeouts", func(t *testing.T) {
+		bulkhead := NewBulkhead(BulkheadConfig{
+			Name:          "test",
+			MaxConcurrent: 1,
+			MaxQueueSize:  1,
+			MaxQueueWait:  time.Hour,
+		})
+		release := occupy(bulkhead)
+		defer release()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		err := bulkhead.Execute(ctx, func(ctx context.Context) error { return nil })
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Zero(t, bulkhead.Stats().QueueTimeouts)
+	})
+
+	t.Run("feeds the executor's circuit breaker", func(t *testing.T) {
+		for _, feed := range []bool{false, true} {
+			config := BulkheadConfig{
+				Name:                  "test",
+				MaxConcurrent:         1,
+				MaxQueueSize:          1,
+				MaxQueueWait:          20 * time.Millisecond,
+				QueueTimeoutToBreaker: feed,
+			}
+			breaker := NewCircuitBreaker(DefaultCircuitBreakerConfig())
+			exec := NewBuilder().
+				WithSharedCircuitBreaker(breaker).
+				WithBulkhead(config).
+				Build()
+
+			running := make(chan struct{})
+			done := make(chan struct{})
+			go func() {
+				exec.Execute(context.Background(), func(ctx context.Context) error {
+					close(running)
+					<-done
+					return nil
+				})
+			}()
+			<-running
+
+			err := exec.Execute(context.Background(), func(ctx context.Context) error { return nil })
+			assert.ErrorIs(t, err, ErrQueueTimeout)
+
+			// The running call is admitted but not yet recorded
+			metrics := breaker.Metrics()
+			if feed {
+				assert.Equal(t, uint32(2), metrics.Requests)
+				assert.Equal(t, uint32(1), metrics.Failures)
+			} else {
+				assert.Equal(t, uint32(1), metrics.Requests)
+				assert.Zero(t, metrics.Failures)
+			}
+			close(done)
+		}
+	})
 }
@@ -0,0 +1,105 @@
+package resilience
+
+import (
+	"context"
+	"time"
+
+	"github.com/gostratum/resiliencex/cache"
+)
+
+// degradationCache implements the DegradationCache interface
+type degradationCache struct {
+	config  CacheConfig
+	entries *cache.Cache[string, any]
+	now     func() time.Time
+}
+
+// NewDegradationCache creates a degradation cache
+func NewDegradationCache(config CacheConfig) DegradationCache {
+	if config.TTL <= 0 {
+		config.TTL = DefaultCacheConfig().TTL
+	}
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = DefaultCacheConfig().MaxEntries
+	}
+	c := &degradationCache{config: config, now: time.Now}
+	c.entries = cache.New[string, any](cache.Config{
+		TTL:        config.TTL,
+		MaxEntries: config.MaxEntries,
+		Now:        func() time.Time { return c.now() },
+	})
+	return c
+}
+
+func (c *degradationCache) Wrap(executor Executor) Executor {
+	return &cachedExecutor{next: executor, cache: c}
+}
+
+func (c *degradationCache) Get(key string) (any, bool) {
+	return c.entries.Get(key)
+}
+
+func (c *degradationCache) Refresh(key string, value any) {
+	c.entries.Set(key, value)
+}
+
+func (c *degradationCache) Invalidate(keys ...string) {
+	c.entries.Delete(keys...)
+}
+
+// afterCall applies the cache options of a completed call
+func (c *degradationCache) afterCall(options callOptions, result any, err error) (any, error) {
+	if err == nil {
+		if options.cacheKey != "" {
+			c.Refresh(options.cacheKey, result)
+		}
+		if len(options.invalidate) > 0 {
+			c.Invalidate(options.invalidate...)
+		}
+		if options.refresh != "" {
+			c.Refresh(options.refresh, result)
+		}
+		return result, nil
+	}
+
+	if options.cacheKey == "" {
+		return result, err
+	}
+	if c.config.ServeStaleOn != nil && !c.config.ServeStaleOn(err) {
+		return result, err
+	}
+	entry, ok := c.entries.Lookup(options.cacheKey)
+	if !ok {
+		return result, err
+	}
+	if c.config.OnServeStale != nil {
+		c.config.OnServeStale(options.cacheKey, c.now().Sub(entry.Stored), err)
+	}
+	return entry.Value, nil
+}
+
+// cachedExecutor applies a degradation cache to a wrapped executor
+type cachedExecutor struct {
+	next  Executor
+	cache *degradationCache
+}
+
+func (e *cachedExecutor) Name() string {
+	return e.next.Name()
+}
+
+func (e *cachedExecutor) Execute(ctx context.Context, fn func(context.Context) error, opts ...CallOption) error {
+	_, err := e.ExecuteWithResult(ctx, func(ctx context.Context) (any, error) {
+		return nil, fn(ctx)
+	}, opts...)
+	return err
+}
+
+func (e *cachedExecutor) TryExecute(ctx context.Context, fn func(context.Context) error, opts ...CallOption) error {
+	return e.Execute(ctx, fn, append(opts, NoWait())...)
+}
+
+func (e *cachedExecutor) ExecuteWithResult(ctx context.Context, fn func(context.Context) (any, error), opts ...CallOption) (any, error) {
+	result, err := e.next.ExecuteWithResult(ctx, fn, opts...)
+	return e.cache.afterCall(newCallOptions(opts), result, err)
+}
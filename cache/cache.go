@@ -0,0 +1,193 @@
+// Package cache is an in-memory TTL and LRU cache with stampede protection.
+//
+// It backs the degradation cache of resiliencex executors and can keep
+// fallback data of its own. Concurrent loads of a missing key share a
+// single call to the loader, so an expired hot key reaches the backend
+// once instead of once per caller.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrLoadPanicked is returned to GetOrLoad callers waiting on a load that
+// panicked
+var ErrLoadPanicked = errors.New("cache: load panicked")
+
+// Config configures a Cache
+type Config struct {
+	// TTL is the maximum age of an entry; 0 keeps entries until evicted
+	TTL time.Duration `mapstructure:"ttl"`
+
+	// MaxEntries bounds the number of entries; the least recently used
+	// entry is evicted when full. 0 leaves the cache unbounded.
+	MaxEntries int `mapstructure:"max_entries"`
+
+	// Now is the time source for entry ages (nil: time.Now)
+	Now func() time.Time `mapstructure:"-"`
+}
+
+// Entry is a cached value and the time it was stored
+type Entry[V any] struct {
+	Value  V
+	Stored time.Time
+}
+
+// Cache is a TTL and LRU cache safe for concurrent use
+type Cache[K comparable, V any] struct {
+	config Config
+	mu     sync.Mutex
+	items  map[K]*list.Element
+	order  *list.List // of *item[K, V], most recently used first
+	calls  map[K]*call[V]
+}
+
+// item is a cache entry with its key, kept in the LRU list
+type item[K comparable, V any] struct {
+	key   K
+	entry Entry[V]
+}
+
+// call is a load in flight shared by concurrent GetOrLoad callers
+type call[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// New creates a cache
+func New[K comparable, V any](config Config) *Cache[K, V] {
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	return &Cache[K, V]{
+		config: config,
+		items:  make(map[K]*list.Element),
+		order:  list.New(),
+		calls:  make(map[K]*call[V]),
+	}
+}
+
+// Get returns the value for key if it is within the TTL
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	entry, ok := c.Lookup(key)
+	return entry.Value, ok
+}
+
+// Lookup returns the entry for key if it is within the TTL, marking it
+// recently used
+func (c *Cache[K, V]) Lookup(key K) (Entry[V], bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lookup(key)
+}
+
+// lookup implements Lookup; callers hold c.mu
+func (c *Cache[K, V]) lookup(key K) (Entry[V], bool) {
+	elem, ok := c.items[key]
+	if !ok {
+		return Entry[V]{}, false
+	}
+	it := elem.Value.(*item[K, V])
+	if c.expired(it.entry) {
+		c.remove(elem)
+		return Entry[V]{}, false
+	}
+	c.order.MoveToFront(elem)
+	return it.entry, true
+}
+
+// Set stores value for key, evicting the least recently used entry when
+// the cache is full
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := Entry[V]{Value: value, Stored: c.config.Now()}
+	if elem, ok := c.items[key]; ok {
+		elem.Value.(*item[K, V]).entry = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	if c.config.MaxEntries > 0 && c.order.Len() >= c.config.MaxEntries {
+		c.remove(c.order.Back())
+	}
+	c.items[key] = c.order.PushFront(&item[K, V]{key: key, entry: entry})
+}
+
+// Delete removes the entries for keys
+func (c *Cache[K, V]) Delete(keys ...K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if elem, ok := c.items[key]; ok {
+			c.remove(elem)
+		}
+	}
+}
+
+// Len returns the number of entries, including expired entries that have
+// not been looked up since they expired
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+// GetOrLoad returns the value for key, calling load and storing its result
+// on a miss. Concurrent callers missing the same key wait for one load
+// instead of starting their own; a caller whose ctx ends stops waiting
+// with ctx.Err(). Errors from load are returned to every waiter and are
+// not cached.
+func (c *Cache[K, V]) GetOrLoad(ctx context.Context, key K, load func(context.Context) (V, error)) (V, error) {
+	c.mu.Lock()
+	if entry, ok := c.lookup(key); ok {
+		c.mu.Unlock()
+		return entry.Value, nil
+	}
+	if cl, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+		select {
+		case <-cl.done:
+			return cl.value, cl.err
+		case <-ctx.Done():
+			var zero V
+			return zero, ctx.Err()
+		}
+	}
+	cl := &call[V]{done: make(chan struct{}), err: ErrLoadPanicked}
+	c.calls[key] = cl
+	c.mu.Unlock()
+
+	// Release waiters even if load panics
+	defer func() {
+		c.mu.Lock()
+		delete(c.calls, key)
+		c.mu.Unlock()
+		close(cl.done)
+	}()
+
+	cl.value, cl.err = load(ctx)
+	if cl.err == nil {
+		c.Set(key, cl.value)
+	}
+	return cl.value, cl.err
+}
+
+// expired reports whether entry is older than the TTL; callers hold c.mu
+func (c *Cache[K, V]) expired(entry Entry[V]) bool {
+	return c.config.TTL > 0 && c.config.Now().Sub(entry.Stored) > c.config.TTL
+}
+
+// remove deletes elem from the cache; callers hold c.mu
+func (c *Cache[K, V]) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*item[K, V]).key)
+}
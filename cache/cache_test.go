@@ -0,0 +1,167 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	newCache := func(config Config) (*Cache[string, int], *time.Time) {
+		clock := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+		config.Now = func() time.Time { return clock }
+		return New[string, int](config), &clock
+	}
+
+	t.Run("expires entries after the TTL", func(t *testing.T) {
+		c, clock := newCache(Config{TTL: time.Minute})
+		c.Set("a", 1)
+
+		*clock = clock.Add(time.Minute)
+		entry, ok := c.Lookup("a")
+		require.True(t, ok)
+		assert.Equal(t, 1, entry.Value)
+		assert.Equal(t, clock.Add(-time.Minute), entry.Stored)
+
+		*clock = clock.Add(time.Second)
+		_, ok = c.Get("a")
+		assert.False(t, ok)
+		assert.Equal(t, 0, c.Len())
+	})
+
+	t.Run("evicts the least recently used entry", func(t *testing.T) {
+		c, _ := newCache(Config{MaxEntries: 2})
+		c.Set("a", 1)
+		c.Set("b", 2)
+		_, _ = c.Get("a")
+		c.Set("c", 3)
+
+		_, ok := c.Get("b")
+		assert.False(t, ok)
+		_, ok = c.Get("a")
+		assert.True(t, ok)
+		_, ok = c.Get("c")
+		assert.True(t, ok)
+	})
+
+	t.Run("overwrites and deletes entries", func(t *testing.T) {
+		c, _ := newCache(Config{})
+		c.Set("a", 1)
+		c.Set("a", 2)
+		value, _ := c.Get("a")
+		assert.Equal(t, 2, value)
+
+		c.Delete("a", "missing")
+		_, ok := c.Get("a")
+		assert.False(t, ok)
+	})
+}
+
+func TestCacheGetOrLoad(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("shares one load between concurrent callers", func(t *testing.T) {
+		c := New[string, int](Config{})
+		var loads atomic.Int32
+		release := make(chan struct{})
+		load := func(ctx context.Context) (int, error) {
+			loads.Add(1)
+			<-release
+			return 42, nil
+		}
+
+		var wg sync.WaitGroup
+		results := make([]int, 10)
+		for i := range results {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i], _ = c.GetOrLoad(ctx, "a", load)
+			}()
+		}
+		require.Eventually(t, func() bool { return loads.Load() == 1 }, time.Second, time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), loads.Load())
+		for _, result := range results {
+			assert.Equal(t, 42, result)
+		}
+		value, ok := c.Get("a")
+		assert.True(t, ok)
+		assert.Equal(t, 42, value)
+	})
+
+	t.Run("does not cache errors", func(t *testing.T) {
+		c := New[string, int](Config{})
+		failure := errors.New("failure")
+
+		_, err := c.GetOrLoad(ctx, "a", func(ctx context.Context) (int, error) { return 0, failure })
+		assert.ErrorIs(t, err, failure)
+
+		value, err := c.GetOrLoad(ctx, "a", func(ctx context.Context) (int, error) { return 7, nil })
+		require.NoError(t, err)
+		assert.Equal(t, 7, value)
+	})
+
+	t.Run("waiters stop when their context ends", func(t *testing.T) {
+		c := New[string, int](Config{})
+		started := make(chan struct{})
+		release := make(chan struct{})
+		defer close(release)
+		go func() {
+			_, _ = c.GetOrLoad(ctx, "a", func(ctx context.Context) (int, error) {
+				close(started)
+				<-release
+				return 1, nil
+			})
+		}()
+		<-started
+
+		waitCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err := c.GetOrLoad(waitCtx, "a", func(ctx context.Context) (int, error) {
+			t.Fatal("started a second load")
+			return 0, nil
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("releases waiters when the load panics", func(t *testing.T) {
+		c := New[string, int](Config{})
+		started := make(chan struct{})
+		release := make(chan struct{})
+		go func() {
+			defer func() { _ = recover() }()
+			_, _ = c.GetOrLoad(ctx, "a", func(ctx context.Context) (int, error) {
+				close(started)
+				<-release
+				panic("boom")
+			})
+		}()
+		<-started
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := c.GetOrLoad(ctx, "a", func(ctx context.Context) (int, error) { return 1, nil })
+			done <- err
+		}()
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+		select {
+		case err := <-done:
+			// The waiter either shared the panicked load or ran its own
+			if err != nil {
+				assert.ErrorIs(t, err, ErrLoadPanicked)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("waiter was not released")
+		}
+	})
+}
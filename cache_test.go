@@ -0,0 +1,114 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDegradationCache(t *testing.T) {
+	ctx := context.Background()
+	unavailable := errors.New("unavailable")
+	value := func(v any) func(context.Context) (any, error) {
+		return func(ctx context.Context) (any, error) { return v, nil }
+	}
+	failing := func(ctx context.Context) (any, error) { return nil, unavailable }
+
+	newCache := func(config CacheConfig) (*degradationCache, *time.Time) {
+		clock := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+		c := NewDegradationCache(config).(*degradationCache)
+		c.now = func() time.Time { return clock }
+		return c, &clock
+	}
+
+	t.Run("serves cached results when calls fail", func(t *testing.T) {
+		var staleKey string
+		cache, _ := newCache(CacheConfig{OnServeStale: func(key string, age time.Duration, err error) {
+			staleKey = key
+		}})
+		reads := cache.Wrap(NewBuilder().Build())
+
+		result, err := reads.ExecuteWithResult(ctx, value("v1"), CacheKey("user:1"))
+		require.NoError(t, err)
+		assert.Equal(t, "v1", result)
+
+		result, err = reads.ExecuteWithResult(ctx, failing, CacheKey("user:1"))
+		require.NoError(t, err)
+		assert.Equal(t, "v1", result)
+		assert.Equal(t, "user:1", staleKey)
+
+		_, err = reads.ExecuteWithResult(ctx, failing, CacheKey("user:2"))
+		assert.ErrorIs(t, err, unavailable)
+	})
+
+	t.Run("does not serve expired entries", func(t *testing.T) {
+		cache, clock := newCache(CacheConfig{TTL: time.Minute})
+		reads := cache.Wrap(NewBuilder().Build())
+
+		_, _ = reads.ExecuteWithResult(ctx, value("v1"), CacheKey("user:1"))
+		*clock = clock.Add(2 * time.Minute)
+
+		_, err := reads.ExecuteWithResult(ctx, failing, CacheKey("user:1"))
+		assert.ErrorIs(t, err, unavailable)
+	})
+
+	t.Run("writes invalidate entries read through another executor", func(t *testing.T) {
+		cache, _ := newCache(CacheConfig{})
+		reads := cache.Wrap(NewBuilder().WithName("users-read").Build())
+		writes := cache.Wrap(NewBuilder().WithName("users-write").Build())
+
+		_, _ = reads.ExecuteWithResult(ctx, value("v1"), CacheKey("user:1"))
+
+		_, err := writes.ExecuteWithResult(ctx, failing, InvalidateOnSuccess("user:1"))
+		require.Error(t, err)
+		_, ok := cache.Get("user:1")
+		assert.True(t, ok, "failed writes keep the entry")
+
+		_, err = writes.ExecuteWithResult(ctx, value(nil), InvalidateOnSuccess("user:1"))
+		require.NoError(t, err)
+		_, ok = cache.Get("user:1")
+		assert.False(t, ok)
+	})
+
+	t.Run("writes refresh entries with their result", func(t *testing.T) {
+		cache, _ := newCache(CacheConfig{})
+		writes := cache.Wrap(NewBuilder().Build())
+
+		_, err := writes.ExecuteWithResult(ctx, value("v2"), RefreshOnSuccess("user:1"))
+		require.NoError(t, err)
+
+		cached, ok := cache.Get("user:1")
+		assert.True(t, ok)
+		assert.Equal(t, "v2", cached)
+	})
+
+	t.Run("only serves stale results for selected errors", func(t *testing.T) {
+		cache, _ := newCache(CacheConfig{ServeStaleOn: func(err error) bool {
+			return errors.Is(err, ErrCircuitOpen)
+		}})
+		reads := cache.Wrap(NewBuilder().Build())
+
+		_, _ = reads.ExecuteWithResult(ctx, value("v1"), CacheKey("user:1"))
+		_, err := reads.ExecuteWithResult(ctx, failing, CacheKey("user:1"))
+		assert.ErrorIs(t, err, unavailable)
+	})
+
+	t.Run("evicts the oldest entry when full", func(t *testing.T) {
+		cache, clock := newCache(CacheConfig{MaxEntries: 2})
+
+		cache.Refresh("a", 1)
+		*clock = clock.Add(time.Second)
+		cache.Refresh("b", 2)
+		*clock = clock.Add(time.Second)
+		cache.Refresh("c", 3)
+
+		_, ok := cache.Get("a")
+		assert.False(t, ok)
+		_, ok = cache.Get("c")
+		assert.True(t, ok)
+	})
+}
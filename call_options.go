@@ -0,0 +1,77 @@
+package resilience
+
+import (
+	"time"
+)
+
+// CallOption customizes a single Executor call
+type CallOption func(*callOptions)
+
+// callOptions holds the per-call settings applied by CallOptions
+type callOptions struct {
+	noTimeout     bool
+	timeoutExtend time.Duration
+	cacheKey      string
+	invalidate    []string
+	refresh       string
+	noWait        bool
+}
+
+// NoTimeout bypasses the executor's timeout for this call while still
+// applying the other patterns. Use it for known long-running operations
+// such as migrations or exports.
+func NoTimeout() CallOption {
+	return func(o *callOptions) {
+		o.noTimeout = true
+	}
+}
+
+// ExtendTimeout adds d to the executor's configured timeout for this call
+func ExtendTimeout(d time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.timeoutExtend += d
+	}
+}
+
+// CacheKey caches the call's result under key in the DegradationCache
+// wrapping the executor, and answers the call from the cache if it fails
+func CacheKey(key string) CallOption {
+	return func(o *callOptions) {
+		o.cacheKey = key
+	}
+}
+
+// InvalidateOnSuccess removes keys from the DegradationCache wrapping the
+// executor when the call succeeds, e.g. for writes to cached entities
+func InvalidateOnSuccess(keys ...string) CallOption {
+	return func(o *callOptions) {
+		o.invalidate = append(o.invalidate, keys...)
+	}
+}
+
+// RefreshOnSuccess stores the call's result under key in the
+// DegradationCache wrapping the executor when the call succeeds, e.g. for
+// writes that return the updated entity
+func RefreshOnSuccess(key string) CallOption {
+	return func(o *callOptions) {
+		o.refresh = key
+	}
+}
+
+// NoWait rejects the call immediately where a pattern would block: the
+// rate limiter returns ErrRateLimitExceeded instead of waiting for a token
+// and the bulkhead returns ErrBulkheadFull instead of queuing. Use it on
+// latency-critical paths that prefer failing fast to queuing.
+func NoWait() CallOption {
+	return func(o *callOptions) {
+		o.noWait = true
+	}
+}
+
+func newCallOptions(opts []CallOption) callOptions {
+	var o callOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
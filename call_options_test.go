@@ -0,0 +1,50 @@
+package resilience
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCallOptions(t *testing.T) {
+	executor := NewBuilder().
+		WithTimeout(20 * time.Millisecond).
+		Build()
+	ctx := context.Background()
+	slow := func(ctx context.Context) error {
+		time.Sleep(40 * time.Millisecond)
+		return nil
+	}
+
+	t.Run("timeout applies by default", func(t *testing.T) {
+		assert.ErrorIs(t, executor.Execute(ctx, slow), ErrTimeout)
+	})
+
+	t.Run("NoTimeout bypasses the timeout", func(t *testing.T) {
+		assert.NoError(t, executor.Execute(ctx, slow, NoTimeout()))
+	})
+
+	t.Run("ExtendTimeout extends the timeout", func(t *testing.T) {
+		assert.NoError(t, executor.Execute(ctx, slow, ExtendTimeout(100*time.Millisecond)))
+	})
+
+	t.Run("NoWait rejects instead of waiting for a token", func(t *testing.T) {
+		limited := NewBuilder().
+			WithRateLimiter(RateLimiterConfig{Name: "test", Rate: 1, Burst: 1}).
+			Build()
+		fast := func(ctx context.Context) error { return nil }
+
+		assert.NoError(t, limited.TryExecute(ctx, fast))
+		start := time.Now()
+		assert.ErrorIs(t, limited.TryExecute(ctx, fast), ErrRateLimitExceeded)
+		assert.ErrorIs(t, limited.Execute(ctx, fast, NoWait()), ErrRateLimitExceeded)
+		assert.Less(t, time.Since(start), 100*time.Millisecond)
+	})
+}
+
+func TestTimeoutDuration(t *testing.T) {
+	assert.Equal(t, time.Second, NewTimeout(time.Second, "test").Duration())
+	assert.Equal(t, DefaultTimeoutConfig().Duration, NewTimeout(0, "test").Duration())
+}
@@ -0,0 +1,178 @@
+package resilience
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// canaryWindowBuckets is the number of buckets used by canary windows
+const canaryWindowBuckets = 30
+
+// canaryExecutor implements the CanaryExecutor interface
+type canaryExecutor struct {
+	baseline  Executor
+	candidate Executor
+	config    CanaryConfig
+	now       func() time.Time
+
+	mu           sync.Mutex
+	arms         [2]*canaryArm // baseline, candidate
+	rolledBackAt time.Time     // zero while the candidate receives calls
+}
+
+// canaryArm records the outcomes of one executor
+type canaryArm struct {
+	outcomes *rollingWindow
+	latency  *latencyHistogram
+}
+
+// NewCanaryExecutor routes Fraction of calls through candidate and the rest
+// through baseline. Once both have MinCalls within Window, a candidate whose
+// failure rate or latency is worse than the baseline's by more than the
+// configured margins is rolled back: every call goes to baseline until Reset.
+func NewCanaryExecutor(baseline, candidate Executor, config CanaryConfig) CanaryExecutor {
+	defaults := DefaultCanaryConfig()
+	if config.Name == "" {
+		config.Name = baseline.Name()
+	}
+	config.Fraction = min(max(config.Fraction, 0), 1)
+	if config.Window <= 0 {
+		config.Window = defaults.Window
+	}
+	if config.MinCalls <= 0 {
+		config.MinCalls = defaults.MinCalls
+	}
+	if config.MaxFailureRateIncrease <= 0 {
+		config.MaxFailureRateIncrease = defaults.MaxFailureRateIncrease
+	}
+
+	c := &canaryExecutor{
+		baseline:  baseline,
+		candidate: candidate,
+		config:    config,
+		now:       time.Now,
+	}
+	c.resetArms(c.now())
+	return c
+}
+
+func (c *canaryExecutor) Name() string {
+	return c.config.Name
+}
+
+func (c *canaryExecutor) Execute(ctx context.Context, fn func(context.Context) error, opts ...CallOption) error {
+	_, err := c.ExecuteWithResult(ctx, func(ctx context.Context) (any, error) {
+		return nil, fn(ctx)
+	}, opts...)
+	return err
+}
+
+func (c *canaryExecutor) TryExecute(ctx context.Context, fn func(context.Context) error, opts ...CallOption) error {
+	return c.Execute(ctx, fn, append(opts, NoWait())...)
+}
+
+func (c *canaryExecutor) ExecuteWithResult(ctx context.Context, fn func(context.Context) (any, error), opts ...CallOption) (any, error) {
+	executor, arm := c.route()
+	start := c.now()
+	result, err := executor.ExecuteWithResult(ctx, fn, opts...)
+	c.record(arm, start, err)
+	return result, err
+}
+
+func (c *canaryExecutor) Stats() CanaryStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats(c.now())
+}
+
+func (c *canaryExecutor) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.resetArms(c.now())
+	c.rolledBackAt = time.Time{}
+}
+
+// route picks the executor for a call
+func (c *canaryExecutor) route() (Executor, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rolledBackAt.IsZero() && rand.Float64() < c.config.Fraction {
+		return c.candidate, 1
+	}
+	return c.baseline, 0
+}
+
+// record adds a call's outcome and rolls the candidate back if it degraded
+func (c *canaryExecutor) record(arm int, start time.Time, err error) {
+	c.mu.Lock()
+	now := c.now()
+	c.arms[arm].outcomes.record(now, err == nil)
+	c.arms[arm].latency.record(now, now.Sub(start))
+
+	var stats CanaryStats
+	rolledBack := false
+	if c.rolledBackAt.IsZero() {
+		stats = c.stats(now)
+		if c.degraded(stats) {
+			c.rolledBackAt = now
+			stats.RolledBack, stats.RolledBackAt = true, now
+			rolledBack = true
+		}
+	}
+	c.mu.Unlock()
+
+	if rolledBack && c.config.OnRollback != nil {
+		c.config.OnRollback(c.config.Name, stats)
+	}
+}
+
+// degraded reports whether the candidate is worse than the baseline by
+// more than the configured margins
+func (c *canaryExecutor) degraded(stats CanaryStats) bool {
+	minCalls := uint64(c.config.MinCalls)
+	if stats.Baseline.Calls < minCalls || stats.Candidate.Calls < minCalls {
+		return false
+	}
+	if stats.Candidate.FailureRate-stats.Baseline.FailureRate > c.config.MaxFailureRateIncrease {
+		return true
+	}
+	return c.config.MaxLatencyRatio > 0 && stats.Baseline.LatencyP95 > 0 &&
+		float64(stats.Candidate.LatencyP95) > c.config.MaxLatencyRatio*float64(stats.Baseline.LatencyP95)
+}
+
+// stats returns the snapshot at now; the caller holds c.mu
+func (c *canaryExecutor) stats(now time.Time) CanaryStats {
+	return CanaryStats{
+		Baseline:     c.arms[0].stats(now),
+		Candidate:    c.arms[1].stats(now),
+		RolledBack:   !c.rolledBackAt.IsZero(),
+		RolledBackAt: c.rolledBackAt,
+	}
+}
+
+// resetArms discards the observed outcomes; the caller holds c.mu
+func (c *canaryExecutor) resetArms(now time.Time) {
+	for i := range c.arms {
+		c.arms[i] = &canaryArm{
+			outcomes: newRollingWindow(c.config.Window, canaryWindowBuckets, now),
+			// Percentiles cover between half a window and a window
+			latency: newLatencyHistogram(c.config.Window/2, now),
+		}
+	}
+}
+
+// stats summarizes the arm's calls within the window
+func (a *canaryArm) stats(now time.Time) CanaryArmStats {
+	successes, failures := a.outcomes.totals(now)
+	stats := CanaryArmStats{
+		Calls:      successes + failures,
+		Failures:   failures,
+		LatencyP95: a.latency.quantiles(now, 0.95)[0],
+	}
+	if stats.Calls > 0 {
+		stats.FailureRate = float64(failures) / float64(stats.Calls)
+	}
+	return stats
+}
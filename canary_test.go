@@ -0,0 +1,102 @@
+package resilience
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanaryExecutor(t *testing.T) {
+	ctx := context.Background()
+	ok := func(ctx context.Context) error { return nil }
+
+	t.Run("routes the configured fraction to the candidate", func(t *testing.T) {
+		baseline := NewBuilder().WithName("baseline").Build()
+		candidate := NewBuilder().WithName("candidate").Build()
+
+		all := NewCanaryExecutor(baseline, candidate, CanaryConfig{Fraction: 1})
+		none := NewCanaryExecutor(baseline, candidate, CanaryConfig{Fraction: 0})
+		some := NewCanaryExecutor(baseline, candidate, CanaryConfig{Fraction: 0.2})
+		for i := 0; i < 1000; i++ {
+			require.NoError(t, all.Execute(ctx, ok))
+			require.NoError(t, none.Execute(ctx, ok))
+			require.NoError(t, some.Execute(ctx, ok))
+		}
+
+		assert.Equal(t, "baseline", all.Name())
+		assert.Equal(t, uint64(1000), all.Stats().Candidate.Calls)
+		assert.Zero(t, all.Stats().Baseline.Calls)
+		assert.Equal(t, uint64(1000), none.Stats().Baseline.Calls)
+		assert.Zero(t, none.Stats().Candidate.Calls)
+		assert.InDelta(t, 200, some.Stats().Candidate.Calls, 60)
+	})
+
+	t.Run("rolls back a degraded candidate", func(t *testing.T) {
+		breaker := NewCircuitBreaker(DefaultCircuitBreakerConfig())
+		breaker.ForceOpen()
+		baseline := NewBuilder().Build()
+		candidate := NewBuilder().WithSharedCircuitBreaker(breaker).Build()
+
+		var rollbacks []CanaryStats
+		canary := NewCanaryExecutor(baseline, candidate, CanaryConfig{
+			Name:     "api",
+			Fraction: 0.5,
+			MinCalls: 10,
+			OnRollback: func(name string, stats CanaryStats) {
+				assert.Equal(t, "api", name)
+				rollbacks = append(rollbacks, stats)
+			},
+		})
+
+		for i := 0; i < 200; i++ {
+			canary.Execute(ctx, ok)
+		}
+
+		require.Len(t, rollbacks, 1)
+		assert.True(t, rollbacks[0].RolledBack)
+		assert.Equal(t, 1.0, rollbacks[0].Candidate.FailureRate)
+		assert.Zero(t, rollbacks[0].Baseline.FailureRate)
+
+		// Calls after the rollback all go to the baseline
+		calls := canary.Stats().Candidate.Calls
+		for i := 0; i < 100; i++ {
+			require.NoError(t, canary.Execute(ctx, ok))
+		}
+		assert.Equal(t, calls, canary.Stats().Candidate.Calls)
+		assert.True(t, canary.Stats().RolledBack)
+
+		canary.Reset()
+		stats := canary.Stats()
+		assert.False(t, stats.RolledBack)
+		assert.Zero(t, stats.Baseline.Calls)
+		assert.Zero(t, stats.Candidate.Calls)
+	})
+
+	t.Run("tolerates failures within the margin", func(t *testing.T) {
+		canary := NewCanaryExecutor(NewBuilder().Build(), NewBuilder().Build(), CanaryConfig{
+			Fraction: 0.5,
+			MinCalls: 10,
+		})
+		for i := 0; i < 200; i++ {
+			canary.Execute(ctx, ok)
+		}
+		assert.False(t, canary.Stats().RolledBack)
+	})
+
+	t.Run("compares latency when configured", func(t *testing.T) {
+		c := NewCanaryExecutor(NewBuilder().Build(), NewBuilder().Build(), CanaryConfig{
+			MinCalls:        1,
+			MaxLatencyRatio: 2,
+		}).(*canaryExecutor)
+
+		stats := CanaryStats{
+			Baseline:  CanaryArmStats{Calls: 1, LatencyP95: 10},
+			Candidate: CanaryArmStats{Calls: 1, LatencyP95: 20},
+		}
+		assert.False(t, c.degraded(stats))
+		stats.Candidate.LatencyP95 = 21
+		assert.True(t, c.degraded(stats))
+	})
+}
@@ -0,0 +1,453 @@
+// Package chaos injects scheduled faults into resilience executors for
+// game-day exercises.
+//
+// Faults are injected at the executor boundary, so a scenario that "opens"
+// a breaker rejects calls with resilience.ErrCircuitOpen without changing
+// the real breaker state, and the system returns to normal as soon as the
+// scenario ends or is canceled. Rate limit faults scale the wrapped
+// executor's rate limiter instead, restoring it on the first call after
+// the scenario ends.
+//
+// Wrapped executors unwrap to the executor they decorate, so registries,
+// resilience.Warm and dashboards still reach its patterns.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	resilience "github.com/gostratum/resiliencex"
+)
+
+// ErrInjected is returned by calls failed by an error fault
+var ErrInjected = errors.New("chaos: injected failure")
+
+// ErrUnknownScenario is returned when no scenario has the given name
+var ErrUnknownScenario = errors.New("chaos: unknown scenario")
+
+// Fault is the kind of failure a scenario injects
+type Fault string
+
+const (
+	// FaultOpenBreaker rejects every call with resilience.ErrCircuitOpen
+	FaultOpenBreaker Fault = "open_breaker"
+
+	// FaultRateLimit lowers the executor's rate limit by a Ratio share with
+	// RateLimiter.SetRateMultiplier, e.g. 0.5 to halve it; executors
+	// without a rate limiter are unaffected
+	FaultRateLimit Fault = "rate_limit"
+
+	// FaultError fails a Ratio share of calls with ErrInjected
+	FaultError Fault = "error"
+
+	// FaultLatency delays every call by Latency
+	FaultLatency Fault = "latency"
+)
+
+// Status is where a scenario is in its lifecycle
+type Status string
+
+const (
+	// StatusScheduled is a scenario that has not started yet
+	StatusScheduled Status = "scheduled"
+
+	// StatusRunning is a scenario injecting faults now
+	StatusRunning Status = "running"
+
+	// StatusEnded is a scenario whose window has passed
+	StatusEnded Status = "ended"
+
+	// StatusStopped is a scenario ended early with Stop
+	StatusStopped Status = "stopped"
+)
+
+// Scenario is a fault applied to an executor during a time window
+type Scenario struct {
+	// Name identifies the scenario
+	Name string `mapstructure:"name" json:"name"`
+
+	// Executor is the name of the executor to affect
+	Executor string `mapstructure:"executor" json:"executor"`
+
+	// Fault is the kind of failure to inject
+	Fault Fault `mapstructure:"fault" json:"fault"`
+
+	// Start is when the scenario begins, in RFC 3339 in config; zero starts
+	// it as soon as it is added
+	Start time.Time `mapstructure:"start" json:"start"`
+
+	// Duration is how long the scenario lasts
+	Duration time.Duration `mapstructure:"duration" json:"duration"`
+
+	// Ratio is the share of the rate limit removed by rate_limit faults, in
+	// (0, 1), and of calls failed by error faults, in (0, 1]
+	Ratio float64 `mapstructure:"ratio" json:"ratio,omitempty"`
+
+	// Latency is the delay added by latency faults
+	Latency time.Duration `mapstructure:"latency" json:"latency,omitempty"`
+}
+
+// ScenarioStatus is a scenario with its status, as listed by Schedule.List
+type ScenarioStatus struct {
+	Scenario
+
+	// Status is where the scenario is in its lifecycle
+	Status Status `json:"status"`
+}
+
+// Config configures a game-day schedule. It binds from the
+// "resilience.chaos" section of the configuration:
+//
+//	resilience:
+//	  chaos:
+//	    scenarios:
+//	      - name: payments-outage
+//	        executor: payments
+//	        fault: open_breaker
+//	        start: 2026-03-01T10:00:00Z
+//	        duration: 15m
+type Config struct {
+	// Scenarios are the scheduled fault scenarios
+	Scenarios []Scenario `mapstructure:"scenarios"`
+
+	// OnInject is called when a fault affects a call
+	OnInject func(scenario Scenario) `mapstructure:"-"`
+
+	// Clock tells time for scenario windows and latency faults; nil uses
+	// the system clock
+	Clock resilience.Clock `mapstructure:"-"`
+}
+
+// Prefix returns the configuration prefix for game-day schedules
+func (Config) Prefix() string {
+	return "resilience.chaos"
+}
+
+// Validate checks the scenario for errors
+func (s Scenario) Validate() error {
+	if s.Name == "" {
+		return errors.New("chaos: scenario name is required")
+	}
+	if s.Executor == "" {
+		return fmt.Errorf("chaos: scenario %q: executor is required", s.Name)
+	}
+	if s.Duration <= 0 {
+		return fmt.Errorf("chaos: scenario %q: duration must be positive", s.Name)
+	}
+	switch s.Fault {
+	case FaultOpenBreaker:
+	case FaultRateLimit:
+		if s.Ratio <= 0 || s.Ratio >= 1 {
+			return fmt.Errorf("chaos: scenario %q: ratio must be in (0, 1)", s.Name)
+		}
+	case FaultError:
+		if s.Ratio <= 0 || s.Ratio > 1 {
+			return fmt.Errorf("chaos: scenario %q: ratio must be in (0, 1]", s.Name)
+		}
+	case FaultLatency:
+		if s.Latency <= 0 {
+			return fmt.Errorf("chaos: scenario %q: latency must be positive", s.Name)
+		}
+	default:
+		return fmt.Errorf("chaos: scenario %q: unknown fault %q", s.Name, s.Fault)
+	}
+	return nil
+}
+
+// activeAt reports whether the scenario is running at now
+func (s Scenario) activeAt(now time.Time) bool {
+	return !now.Before(s.Start) && now.Before(s.Start.Add(s.Duration))
+}
+
+// Schedule holds game-day scenarios and applies them to wrapped executors.
+// Scenarios can be added, started, stopped and canceled while the schedule
+// is in use, e.g. from an admin endpoint.
+type Schedule struct {
+	mu        sync.RWMutex
+	scenarios map[string]*entry
+	onInject  func(Scenario)
+	clock     resilience.Clock
+	now       func() time.Time
+}
+
+// entry is a scheduled scenario
+type entry struct {
+	scenario Scenario
+	stopped  bool
+}
+
+// status returns where the scenario is at now
+func (e *entry) status(now time.Time) Status {
+	switch {
+	case e.stopped:
+		return StatusStopped
+	case now.Before(e.scenario.Start):
+		return StatusScheduled
+	case e.scenario.activeAt(now):
+		return StatusRunning
+	default:
+		return StatusEnded
+	}
+}
+
+// NewSchedule creates a schedule from config
+func NewSchedule(config Config) (*Schedule, error) {
+	clock := config.Clock
+	if clock == nil {
+		clock = resilience.SystemClock()
+	}
+	s := &Schedule{
+		scenarios: make(map[string]*entry),
+		onInject:  config.OnInject,
+		clock:     clock,
+		now:       clock.Now,
+	}
+	for _, scenario := range config.Scenarios {
+		if err := s.Add(scenario); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Add schedules a scenario, replacing any scenario with the same name
+func (s *Schedule) Add(scenario Scenario) error {
+	if err := scenario.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if scenario.Start.IsZero() {
+		scenario.Start = s.now()
+	}
+	s.scenarios[scenario.Name] = &entry{scenario: scenario}
+	return nil
+}
+
+// Start runs the named scenario from now for its Duration, whether it was
+// scheduled for later, has ended or was stopped
+func (s *Schedule) Start(name string) (Scenario, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.scenarios[name]
+	if !ok {
+		return Scenario{}, fmt.Errorf("%w %q", ErrUnknownScenario, name)
+	}
+	e.scenario.Start = s.now()
+	e.stopped = false
+	return e.scenario, nil
+}
+
+// Stop ends the named scenario now, or keeps it from starting, while
+// leaving it in the schedule to be started again
+func (s *Schedule) Stop(name string) (Scenario, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.scenarios[name]
+	if !ok {
+		return Scenario{}, fmt.Errorf("%w %q", ErrUnknownScenario, name)
+	}
+	e.stopped = true
+	return e.scenario, nil
+}
+
+// Cancel removes a scenario, ending it immediately if running.
+// It returns false if no scenario has that name.
+func (s *Schedule) Cancel(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.scenarios[name]
+	delete(s.scenarios, name)
+	return ok
+}
+
+// Scenarios returns the scheduled scenarios ordered by start time
+func (s *Schedule) Scenarios() []Scenario {
+	list := s.List()
+	scenarios := make([]Scenario, len(list))
+	for i, status := range list {
+		scenarios[i] = status.Scenario
+	}
+	return scenarios
+}
+
+// List returns the scheduled scenarios with their status, ordered by start
+// time
+func (s *Schedule) List() []ScenarioStatus {
+	now := s.now()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]ScenarioStatus, 0, len(s.scenarios))
+	for _, e := range s.scenarios {
+		list = append(list, ScenarioStatus{Scenario: e.scenario, Status: e.status(now)})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].Start.Equal(list[j].Start) {
+			return list[i].Start.Before(list[j].Start)
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Active returns the scenarios running now for the named executor, ordered
+// by start time
+func (s *Schedule) Active(executor string) []Scenario {
+	return s.appendActive(nil, executor)
+}
+
+// appendActive appends the scenarios running now for the named executor to
+// active, ordered by start time. It runs on every call, so it neither
+// lists the whole schedule nor allocates unless active needs to grow.
+func (s *Schedule) appendActive(active []Scenario, executor string) []Scenario {
+	now := s.now()
+	s.mu.RLock()
+	for _, e := range s.scenarios {
+		if e.scenario.Executor == executor && e.status(now) == StatusRunning {
+			active = append(active, e.scenario)
+		}
+	}
+	s.mu.RUnlock()
+
+	if len(active) > 1 {
+		slices.SortFunc(active, func(a, b Scenario) int {
+			if c := a.Start.Compare(b.Start); c != 0 {
+				return c
+			}
+			return strings.Compare(a.Name, b.Name)
+		})
+	}
+	return active
+}
+
+// Wrap returns an executor that applies the schedule's scenarios for
+// exec.Name() before running calls through exec
+func (s *Schedule) Wrap(exec resilience.Executor) resilience.Executor {
+	e := &executor{next: exec, schedule: s, limiter: resilience.ExecutorRateLimiter(exec)}
+	e.multiplier.Store(math.Float64bits(1))
+	return e
+}
+
+// inject applies active scenarios and returns the error to fail the call with
+func (e *executor) inject(ctx context.Context) error {
+	s := e.schedule
+	var buf [4]Scenario
+	active := s.appendActive(buf[:0], e.next.Name())
+	e.limitRate(active)
+
+	for _, scenario := range active {
+		var err error
+		switch scenario.Fault {
+		case FaultOpenBreaker:
+			err = resilience.ErrCircuitOpen
+		case FaultError:
+			if rand.Float64() < scenario.Ratio {
+				err = ErrInjected
+			}
+		case FaultLatency:
+			if s.onInject != nil {
+				s.onInject(scenario)
+			}
+			timer := s.clock.NewTimer(scenario.Latency)
+			select {
+			case <-timer.C():
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			}
+			continue
+		}
+
+		if err != nil {
+			if s.onInject != nil {
+				s.onInject(scenario)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// limitRate scales the wrapped executor's rate limiter to the rate left by
+// the active rate limit scenarios, restoring it once none is active
+func (e *executor) limitRate(active []Scenario) {
+	if e.limiter == nil {
+		return
+	}
+	multiplier := 1.0
+	for _, scenario := range active {
+		if scenario.Fault == FaultRateLimit {
+			multiplier *= 1 - scenario.Ratio
+		}
+	}
+	if math.Float64bits(multiplier) == e.multiplier.Load() {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if math.Float64bits(multiplier) == e.multiplier.Load() {
+		return
+	}
+	if e.limiter.SetRateMultiplier(multiplier) != nil {
+		return
+	}
+	e.multiplier.Store(math.Float64bits(multiplier))
+	if e.schedule.onInject != nil {
+		for _, scenario := range active {
+			if scenario.Fault == FaultRateLimit {
+				e.schedule.onInject(scenario)
+			}
+		}
+	}
+}
+
+// executor applies a schedule to a wrapped executor
+type executor struct {
+	next       resilience.Executor
+	schedule   *Schedule
+	limiter    resilience.RateLimiter // nil if next has none
+	mu         sync.Mutex
+	multiplier atomic.Uint64 // float64 bits of the rate multiplier applied to limiter
+}
+
+func (e *executor) Name() string {
+	return e.next.Name()
+}
+
+// Unwrap returns the wrapped executor
+func (e *executor) Unwrap() resilience.Executor {
+	return e.next
+}
+
+func (e *executor) Execute(ctx context.Context, fn func(context.Context) error, opts ...resilience.CallOption) error {
+	if err := e.inject(ctx); err != nil {
+		return err
+	}
+	return e.next.Execute(ctx, fn, opts...)
+}
+
+func (e *executor) TryExecute(ctx context.Context, fn func(context.Context) error, opts ...resilience.CallOption) error {
+	return e.Execute(ctx, fn, append(opts, resilience.NoWait())...)
+}
+
+func (e *executor) ExecuteWithResult(ctx context.Context, fn func(context.Context) (any, error), opts ...resilience.CallOption) (any, error) {
+	if err := e.inject(ctx); err != nil {
+		return nil, err
+	}
+	return e.next.ExecuteWithResult(ctx, fn, opts...)
+}
@@ -0,0 +1,293 @@
+package chaos
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	resilience "github.com/gostratum/resiliencex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule(t *testing.T) {
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+	ok := func(ctx context.Context) error { return nil }
+
+	newSchedule := func(t *testing.T, clock *time.Time, scenarios ...Scenario) *Schedule {
+		s, err := NewSchedule(Config{Scenarios: scenarios})
+		require.NoError(t, err)
+		s.now = func() time.Time { return *clock }
+		return s
+	}
+	payments := resilience.NewBuilder().WithName("payments").Build()
+
+	t.Run("opens the breaker only during the window", func(t *testing.T) {
+		clock := start.Add(-time.Minute)
+		s := newSchedule(t, &clock, Scenario{
+			Name:     "payments-outage",
+			Executor: "payments",
+			Fault:    FaultOpenBreaker,
+			Start:    start,
+			Duration: 10 * time.Minute,
+		})
+		exec := s.Wrap(payments)
+
+		assert.NoError(t, exec.Execute(ctx, ok))
+
+		clock = start.Add(5 * time.Minute)
+		assert.ErrorIs(t, exec.Execute(ctx, ok), resilience.ErrCircuitOpen)
+
+		clock = start.Add(10 * time.Minute)
+		assert.NoError(t, exec.Execute(ctx, ok))
+	})
+
+	t.Run("degrades the rate limit by ratio until the scenario ends", func(t *testing.T) {
+		clock := start
+		var injected []string
+		s, err := NewSchedule(Config{
+			Scenarios: []Scenario{{
+				Name:     "half-rate",
+				Executor: "payments",
+				Fault:    FaultRateLimit,
+				Start:    start,
+				Duration: time.Hour,
+				Ratio:    0.5,
+			}},
+			OnInject: func(scenario Scenario) { injected = append(injected, scenario.Name) },
+		})
+		require.NoError(t, err)
+		s.now = func() time.Time { return clock }
+		limiter := resilience.NewRateLimiter(resilience.RateLimiterConfig{Name: "payments", Rate: 100, Burst: 1000})
+		exec := s.Wrap(resilience.NewBuilder().WithName("payments").WithSharedRateLimiter(limiter).Build())
+
+		for i := 0; i < 10; i++ {
+			assert.NoError(t, exec.Execute(ctx, ok))
+		}
+		assert.Equal(t, 50.0, limiter.Stats().Rate)
+		assert.Equal(t, []string{"half-rate"}, injected)
+
+		clock = start.Add(time.Hour)
+		assert.NoError(t, exec.Execute(ctx, ok))
+		assert.Equal(t, 100.0, limiter.Stats().Rate)
+	})
+
+	t.Run("unwraps to the wrapped executor", func(t *testing.T) {
+		clock := start
+		s := newSchedule(t, &clock)
+		breaker := resilience.NewCircuitBreaker(resilience.CircuitBreakerConfig{Name: "payments"})
+		inner := resilience.NewBuilder().WithName("payments").WithSharedCircuitBreaker(breaker).Build()
+		exec := s.Wrap(inner)
+		assert.Equal(t, inner, resilience.UnwrapExecutor(exec))
+
+		registry := resilience.NewRegistry(nil)
+		registry.Register("payments", exec)
+		_, err := registry.ForceOpenMatching("payments")
+		require.NoError(t, err)
+		assert.Equal(t, resilience.StateOpen, breaker.State())
+
+		standby := resilience.NewBuilder().WithName("payments").
+			WithCircuitBreaker(resilience.CircuitBreakerConfig{Name: "payments"}).Build()
+		require.NoError(t, resilience.Warm(s.Wrap(standby), exec))
+	})
+
+	t.Run("finds active scenarios without allocating", func(t *testing.T) {
+		clock := start
+		s := newSchedule(t, &clock, Scenario{
+			Name:     "payments-outage",
+			Executor: "payments",
+			Fault:    FaultOpenBreaker,
+			Start:    start,
+			Duration: time.Hour,
+		}, Scenario{
+			Name:     "ledger-outage",
+			Executor: "ledger",
+			Fault:    FaultOpenBreaker,
+			Start:    start,
+			Duration: time.Hour,
+		})
+
+		allocs := testing.AllocsPerRun(100, func() {
+			var buf [4]Scenario
+			if len(s.appendActive(buf[:0], "payments")) != 1 {
+				t.Fatal("expected one active scenario")
+			}
+		})
+		assert.Zero(t, allocs)
+	})
+
+	t.Run("only affects the named executor", func(t *testing.T) {
+		clock := start
+		s := newSchedule(t, &clock, Scenario{
+			Name:     "ledger-errors",
+			Executor: "ledger",
+			Fault:    FaultError,
+			Start:    start,
+			Duration: time.Hour,
+			Ratio:    1,
+		})
+
+		assert.NoError(t, s.Wrap(payments).Execute(ctx, ok))
+	})
+
+	t.Run("canceled scenarios stop immediately", func(t *testing.T) {
+		clock := start
+		s := newSchedule(t, &clock, Scenario{
+			Name:     "payments-errors",
+			Executor: "payments",
+			Fault:    FaultError,
+			Start:    start,
+			Duration: time.Hour,
+			Ratio:    1,
+		})
+		exec := s.Wrap(payments)
+
+		assert.ErrorIs(t, exec.Execute(ctx, ok), ErrInjected)
+		assert.True(t, s.Cancel("payments-errors"))
+		assert.False(t, s.Cancel("payments-errors"))
+		assert.NoError(t, exec.Execute(ctx, ok))
+	})
+
+	t.Run("starts and stops scenarios on demand", func(t *testing.T) {
+		clock := start
+		s := newSchedule(t, &clock, Scenario{
+			Name:     "payments-outage",
+			Executor: "payments",
+			Fault:    FaultOpenBreaker,
+			Start:    start.Add(time.Hour),
+			Duration: 10 * time.Minute,
+		})
+		exec := s.Wrap(payments)
+		status := func() Status { return s.List()[0].Status }
+
+		assert.Equal(t, StatusScheduled, status())
+		assert.NoError(t, exec.Execute(ctx, ok))
+
+		started, err := s.Start("payments-outage")
+		require.NoError(t, err)
+		assert.Equal(t, start, started.Start)
+		assert.Equal(t, StatusRunning, status())
+		assert.ErrorIs(t, exec.Execute(ctx, ok), resilience.ErrCircuitOpen)
+
+		_, err = s.Stop("payments-outage")
+		require.NoError(t, err)
+		assert.Equal(t, StatusStopped, status())
+		assert.NoError(t, exec.Execute(ctx, ok))
+
+		clock = start.Add(2 * time.Hour)
+		_, err = s.Start("payments-outage")
+		require.NoError(t, err)
+		assert.ErrorIs(t, exec.Execute(ctx, ok), resilience.ErrCircuitOpen)
+
+		clock = clock.Add(10 * time.Minute)
+		assert.Equal(t, StatusEnded, status())
+		assert.NoError(t, exec.Execute(ctx, ok))
+
+		_, err = s.Start("missing")
+		assert.ErrorIs(t, err, ErrUnknownScenario)
+		_, err = s.Stop("missing")
+		assert.ErrorIs(t, err, ErrUnknownScenario)
+	})
+
+	t.Run("starts scenarios without a start time when added", func(t *testing.T) {
+		clock := start
+		s := newSchedule(t, &clock)
+		require.NoError(t, s.Add(Scenario{
+			Name:     "now",
+			Executor: "payments",
+			Fault:    FaultOpenBreaker,
+			Duration: time.Minute,
+		}))
+
+		assert.Equal(t, []ScenarioStatus{{
+			Scenario: Scenario{Name: "now", Executor: "payments", Fault: FaultOpenBreaker, Start: start, Duration: time.Minute},
+			Status:   StatusRunning,
+		}}, s.List())
+	})
+
+	t.Run("latency respects the context", func(t *testing.T) {
+		clock := start
+		s := newSchedule(t, &clock, Scenario{
+			Name:     "slow",
+			Executor: "payments",
+			Fault:    FaultLatency,
+			Start:    start,
+			Duration: time.Hour,
+			Latency:  time.Hour,
+		})
+
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, s.Wrap(payments).Execute(ctx, ok), context.DeadlineExceeded)
+	})
+
+	t.Run("delays calls on the configured clock", func(t *testing.T) {
+		clock := resilience.NewFakeClock(start)
+		s, err := NewSchedule(Config{Clock: clock, Scenarios: []Scenario{{
+			Name:     "slow",
+			Executor: "payments",
+			Fault:    FaultLatency,
+			Duration: time.Hour,
+			Latency:  time.Minute,
+		}}})
+		require.NoError(t, err)
+
+		done := make(chan error)
+		go func() { done <- s.Wrap(payments).Execute(ctx, ok) }()
+
+		require.NoError(t, clock.BlockUntil(ctx, 1))
+		clock.Advance(time.Minute)
+		assert.NoError(t, <-done)
+	})
+
+	t.Run("rejects invalid scenarios", func(t *testing.T) {
+		_, err := NewSchedule(Config{Scenarios: []Scenario{{
+			Name:     "bad",
+			Executor: "payments",
+			Fault:    FaultRateLimit,
+			Duration: time.Minute,
+		}}})
+		assert.Error(t, err)
+
+		_, err = NewSchedule(Config{Scenarios: []Scenario{{
+			Name:     "bad",
+			Executor: "payments",
+			Fault:    FaultRateLimit,
+			Duration: time.Minute,
+			Ratio:    1,
+		}}})
+		assert.Error(t, err)
+
+		_, err = NewSchedule(Config{Scenarios: []Scenario{{
+			Name:     "bad",
+			Executor: "payments",
+			Fault:    "meteor",
+			Duration: time.Minute,
+		}}})
+		assert.Error(t, err)
+	})
+}
+
+func TestScenarioJSON(t *testing.T) {
+	var scenario Scenario
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"name": "half-rate",
+		"executor": "payments",
+		"fault": "rate_limit",
+		"start": "2026-03-01T10:00:00Z",
+		"duration": 900000000000,
+		"ratio": 0.5
+	}`), &scenario))
+
+	assert.Equal(t, Scenario{
+		Name:     "half-rate",
+		Executor: "payments",
+		Fault:    FaultRateLimit,
+		Start:    time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC),
+		Duration: 15 * time.Minute,
+		Ratio:    0.5,
+	}, scenario)
+	assert.NoError(t, scenario.Validate())
+}
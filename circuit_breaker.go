@@ -2,33 +2,131 @@ package resilience
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// maxProportionalShed caps shedding in proportional mode so some calls keep
+// flowing to detect recovery
+const maxProportionalShed = 0.95
+
+// baselineBuckets is the number of buckets in the adaptive baseline window
+const baselineBuckets = 60
+
 // circuitBreaker implements the CircuitBreaker interface
 type circuitBreaker struct {
-	config    CircuitBreakerConfig
-	mu        sync.RWMutex
-	state     CircuitState
-	counts    *counts
-	stateTime time.Time
+	config      CircuitBreakerConfig
+	mu          sync.RWMutex
+	state       CircuitState
+	gen         atomic.Pointer[genCounts] // counts of the current generation
+	stateTime   time.Time                 // start of the current generation
+	since       time.Time                 // when the current state was entered
+	openedAt    time.Time                 // when the breaker last opened
+	openFor     time.Duration             // how long the last opening lasts before half-open
+	opens       []time.Time               // openings within OpenFlapWindow when damping
+	warmUntil   time.Time                 // end of WarmupPeriod, zero without one
+	slowUntil   time.Time                 // end of the current slow start, zero outside one
+	history     []Transition              // ring of the last HistorySize transitions
+	historyAt   int                       // next slot in history once full
+	trip        *TripCondition
+	shed        exprNode // ShedShare, nil for the failure rate
+	remote      bool     // applying a transition received from the Coordinator
+	forced      bool     // state pinned by ForceOpen or ForceClosed until Reset
+	now         func() time.Time
+	window      *rollingWindow // closed-state outcomes in sliding window mode
+	notifier    *stateNotifier // asynchronous OnStateChange delivery
+	sharing     *countSharing  // fleet counts when the Coordinator aggregates them
+	baseline    *rollingWindow // closed-state outcomes with the adaptive strategy
+	timeouts    *rollingWindow // closed-state timeouts in sliding separate timeout mode
+	latency     *latencyHistogram
+	fastPath    bool         // config allows closed-state calls to skip mu
+	probing     bool         // a HealthProbe is running
+	openings    int          // transitions to open in the current MetricsInterval
+	reportAt    atomic.Int64 // unix nanos when the current MetricsInterval ends
+	onReject    func()       // called for each rejected call, set by keyed breakers and groups
+	unsubscribe func()       // removes the Coordinator subscription, nil without one
 }
 
-// counts tracks circuit breaker statistics
+// counts is a snapshot of circuit breaker statistics
 type counts struct {
 	requests       uint32
 	totalSuccesses uint32
 	totalFailures  uint32
 	consecSuccess  uint32
 	consecFailures uint32
+	totalTimeouts  uint32 // separate timeout mode only
+}
+
+// public returns the counts as passed to a TripPolicy
+func (c counts) public() Counts {
+	return Counts{
+		Requests:             c.requests,
+		Successes:            c.totalSuccesses,
+		Failures:             c.totalFailures,
+		Timeouts:             c.totalTimeouts,
+		ConsecutiveSuccesses: c.consecSuccess,
+		ConsecutiveFailures:  c.consecFailures,
+	}
+}
+
+// genCounts holds the counts of one generation. The counters are atomic so
+// admissions and successes in closed state can update them without taking
+// cb.mu; everything else updates them under cb.mu. A new generation gets a
+// new genCounts, so updates racing with the swap land in the old one and
+// are dropped like any result from an earlier generation. The genCounts
+// pointer identifies its generation: start times alone can repeat, e.g.
+// under a FakeClock.
+type genCounts struct {
+	start          int64       // start of the generation in unix nanos
+	fast           atomic.Bool // closed, unforced and fastPath
+	requests       atomic.Uint32
+	totalSuccesses atomic.Uint32
+	totalFailures  atomic.Uint32
+	consecSuccess  atomic.Uint32
+	consecFailures atomic.Uint32
+	totalTimeouts  atomic.Uint32
+}
+
+// newGenCounts creates generation counts starting at start with c
+func newGenCounts(start time.Time, c counts) *genCounts {
+	g := &genCounts{start: start.UnixNano()}
+	g.requests.Store(c.requests)
+	g.totalSuccesses.Store(c.totalSuccesses)
+	g.totalFailures.Store(c.totalFailures)
+	g.consecSuccess.Store(c.consecSuccess)
+	g.consecFailures.Store(c.consecFailures)
+	g.totalTimeouts.Store(c.totalTimeouts)
+	return g
+}
+
+// load returns a snapshot of the counts; fields updated concurrently on the
+// fast path may be a call apart
+func (g *genCounts) load() counts {
+	return counts{
+		requests:       g.requests.Load(),
+		totalSuccesses: g.totalSuccesses.Load(),
+		totalFailures:  g.totalFailures.Load(),
+		consecSuccess:  g.consecSuccess.Load(),
+		consecFailures: g.consecFailures.Load(),
+		totalTimeouts:  g.totalTimeouts.Load(),
+	}
 }
 
 // NewCircuitBreaker creates a new circuit breaker
+// It panics if config.TripWhen, ShedShare, TripStrategy, TripMode, WindowType or HalfOpenMode is invalid; use
+// CircuitBreakerConfig.Validate to check untrusted configuration first
 func NewCircuitBreaker(config CircuitBreakerConfig) CircuitBreaker {
 	if config.MaxRequests == 0 {
 		config.MaxRequests = DefaultCircuitBreakerConfig().MaxRequests
 	}
+	if config.HalfOpenSuccessThreshold == 0 || config.HalfOpenSuccessThreshold > config.MaxRequests {
+		config.HalfOpenSuccessThreshold = config.MaxRequests
+	}
 	if config.Interval == 0 {
 		config.Interval = DefaultCircuitBreakerConfig().Interval
 	}
@@ -41,12 +139,172 @@ func NewCircuitBreaker(config CircuitBreakerConfig) CircuitBreaker {
 	if config.MinRequests == 0 {
 		config.MinRequests = DefaultCircuitBreakerConfig().MinRequests
 	}
+	if config.TripStrategy == "" {
+		config.TripStrategy = DefaultCircuitBreakerConfig().TripStrategy
+	}
+	switch config.TripStrategy {
+	case TripStrategyRatio, TripStrategyConsecutive, TripStrategyAdaptive:
+	default:
+		panic(fmt.Sprintf("resilience: invalid trip_strategy %q", config.TripStrategy))
+	}
+	if config.ConsecutiveFailures == 0 {
+		config.ConsecutiveFailures = DefaultCircuitBreakerConfig().ConsecutiveFailures
+	}
+	if config.BaselineWindow <= 0 {
+		config.BaselineWindow = DefaultCircuitBreakerConfig().BaselineWindow
+	}
+	if config.BaselineFactor <= 0 {
+		config.BaselineFactor = DefaultCircuitBreakerConfig().BaselineFactor
+	}
+	if config.BaselineFloor <= 0 {
+		config.BaselineFloor = DefaultCircuitBreakerConfig().BaselineFloor
+	}
+	if config.BaselineMinRequests == 0 {
+		config.BaselineMinRequests = DefaultCircuitBreakerConfig().BaselineMinRequests
+	}
+	if config.TripMode == "" {
+		config.TripMode = DefaultCircuitBreakerConfig().TripMode
+	}
+	if config.TripMode != TripModeBinary && config.TripMode != TripModeProportional {
+		panic(fmt.Sprintf("resilience: invalid trip_mode %q", config.TripMode))
+	}
+	if config.TimeoutMode == "" {
+		config.TimeoutMode = DefaultCircuitBreakerConfig().TimeoutMode
+	}
+	switch config.TimeoutMode {
+	case TimeoutModeFailure, TimeoutModeDouble, TimeoutModeSeparate:
+	default:
+		panic(fmt.Sprintf("resilience: invalid timeout_mode %q", config.TimeoutMode))
+	}
+	if config.TimeoutThreshold <= 0 {
+		config.TimeoutThreshold = DefaultCircuitBreakerConfig().TimeoutThreshold
+	}
+	if config.WindowType == "" {
+		config.WindowType = DefaultCircuitBreakerConfig().WindowType
+	}
+	if config.WindowType != WindowTypeFixed && config.WindowType != WindowTypeSliding {
+		panic(fmt.Sprintf("resilience: invalid window_type %q", config.WindowType))
+	}
+	if config.WindowBuckets <= 0 {
+		config.WindowBuckets = DefaultCircuitBreakerConfig().WindowBuckets
+	}
+	if config.HalfOpenMode == "" {
+		config.HalfOpenMode = DefaultCircuitBreakerConfig().HalfOpenMode
+	}
+	if config.HalfOpenMode != HalfOpenModeFixed && config.HalfOpenMode != HalfOpenModeRamp {
+		panic(fmt.Sprintf("resilience: invalid half_open_mode %q", config.HalfOpenMode))
+	}
+	if config.RampDuration <= 0 {
+		config.RampDuration = DefaultCircuitBreakerConfig().RampDuration
+	}
+	if len(config.RampSteps) == 0 {
+		config.RampSteps = DefaultCircuitBreakerConfig().RampSteps
+	}
+	if config.SlowStartMinShare <= 0 || config.SlowStartMinShare > 1 {
+		config.SlowStartMinShare = DefaultCircuitBreakerConfig().SlowStartMinShare
+	}
+	if config.CoordinationTimeout <= 0 {
+		config.CoordinationTimeout = DefaultCircuitBreakerConfig().CoordinationTimeout
+	}
+	if config.ProbeTimeout <= 0 {
+		config.ProbeTimeout = DefaultCircuitBreakerConfig().ProbeTimeout
+	}
+	if config.MetricsInterval <= 0 {
+		config.MetricsInterval = DefaultCircuitBreakerConfig().MetricsInterval
+	}
+	if config.OpenFlapWindow <= 0 {
+		config.OpenFlapWindow = DefaultCircuitBreakerConfig().OpenFlapWindow
+	}
+	if config.OpenFlapBackoff <= 1 {
+		config.OpenFlapBackoff = DefaultCircuitBreakerConfig().OpenFlapBackoff
+	}
+	if config.MaxOpenDuration <= 0 {
+		config.MaxOpenDuration = DefaultCircuitBreakerConfig().MaxOpenDuration
+	}
+	if config.HistorySize <= 0 {
+		config.HistorySize = DefaultCircuitBreakerConfig().HistorySize
+	}
 
-	return &circuitBreaker{
+	var trip *TripCondition
+	if config.TripWhen != "" {
+		var err error
+		if trip, err = CompileTripCondition(config.TripWhen); err != nil {
+			panic(err)
+		}
+	}
+	var shed exprNode
+	if config.ShedShare != "" {
+		var err error
+		if shed, err = compileShedShare(config.ShedShare); err != nil {
+			panic(err)
+		}
+	}
+
+	clock := clockOrSystem(config.Clock)
+	cb := &circuitBreaker{
 		config:    config,
 		state:     StateClosed,
-		counts:    &counts{},
-		stateTime: time.Now(),
+		stateTime: clock.Now(),
+		trip:      trip,
+		shed:      shed,
+		now:       clock.Now,
+	}
+	cb.since = cb.stateTime
+	if config.WarmupPeriod > 0 {
+		cb.warmUntil = cb.stateTime.Add(config.WarmupPeriod)
+	}
+	cb.openFor = max(config.Timeout, config.MinOpenDuration)
+	cb.reportAt.Store(math.MaxInt64)
+	if config.MetricsRecorder != nil {
+		cb.reportAt.Store(cb.stateTime.Add(config.MetricsInterval).UnixNano())
+	}
+	cb.latency = newLatencyHistogram(config.Interval, cb.stateTime)
+	if config.Disabled {
+		cb.state = StateDisabled
+		cb.forced = true
+	}
+
+	if config.OnStateChange != nil && !config.SyncStateChange {
+		cb.notifier = newStateNotifier(config.Name, config.OnStateChange)
+	}
+
+	if config.WindowType == WindowTypeSliding {
+		cb.window = newRollingWindow(config.Interval, config.WindowBuckets, cb.stateTime)
+		if config.TimeoutMode == TimeoutModeSeparate {
+			cb.timeouts = newRollingWindow(config.Interval, config.WindowBuckets, cb.stateTime)
+		}
+	}
+	if config.TripStrategy == TripStrategyAdaptive {
+		cb.baseline = newRollingWindow(config.BaselineWindow, baselineBuckets, cb.stateTime)
+	}
+
+	if config.Coordinator != nil {
+		if counter, ok := config.Coordinator.(CountCoordinator); ok {
+			cb.sharing = &countSharing{coordinator: counter}
+		}
+	}
+
+	// Features that record closed-state outcomes outside the counts, or
+	// decide per call, keep every call under mu
+	cb.fastPath = cb.window == nil && cb.baseline == nil && cb.sharing == nil &&
+		config.TripMode != TripModeProportional && !cb.slowTrips()
+	cb.setGeneration(cb.stateTime, counts{})
+
+	if config.Coordinator != nil {
+		cb.unsubscribe = config.Coordinator.Subscribe(cb.onCoordinationEvent)
+		if group, ok := config.Coordinator.(*GroupCoordinator); ok {
+			cb.onReject = func() { group.rejected.record(config.Name) }
+		}
+	}
+
+	return cb
+}
+
+// close releases the breaker's Coordinator subscription once it is no
+// longer used, e.g. when a keyed breaker is evicted
+func (cb *circuitBreaker) close() {
+	if cb.unsubscribe != nil {
+		cb.unsubscribe()
 	}
 }
 
@@ -60,97 +318,546 @@ func (cb *circuitBreaker) State() CircuitState {
 	return cb.state
 }
 
+func (cb *circuitBreaker) Metrics() CircuitBreakerMetrics {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := cb.now()
+	c := cb.tripCounts(now)
+	latency := cb.latency.quantiles(now, 0.5, 0.95, 0.99)
+
+	return CircuitBreakerMetrics{
+		State:                cb.state,
+		Requests:             c.requests,
+		Successes:            c.totalSuccesses,
+		Failures:             c.totalFailures,
+		Timeouts:             c.totalTimeouts,
+		ConsecutiveSuccesses: c.consecSuccess,
+		ConsecutiveFailures:  c.consecFailures,
+		StateSince:           cb.since,
+		TimeInState:          now.Sub(cb.since),
+		OpenDuration:         cb.openFor,
+		Forced:               cb.forced,
+		FailureThreshold:     cb.failureThreshold(now),
+		LatencyP50:           latency[0],
+		LatencyP95:           latency[1],
+		LatencyP99:           latency[2],
+	}
+}
+
 func (cb *circuitBreaker) Execute(ctx context.Context, fn func(context.Context) error) error {
 	// Check if we can proceed
-	generation, err := cb.beforeRequest()
+	p, err := cb.allow(IsHighPriority(ctx), 1)
 	if err != nil {
 		return err
 	}
+	p.weight = callWeight(ctx)
 
 	// Execute the function
 	err = fn(ctx)
 
-	// Record the result
-	cb.afterRequest(generation, err == nil)
+	// Record the result; the caller giving up says nothing about the
+	// downstream
+	if cb.callerCanceled(ctx, err) {
+		p.release()
+		return err
+	}
+	p.Record(err)
 
 	return err
 }
 
+// callerCanceled reports whether err is the caller's cancellation or
+// deadline, rather than a Timeout policy expiring, and is to be neutral
+func (cb *circuitBreaker) callerCanceled(ctx context.Context, err error) bool {
+	if cb.config.CountCallerCancellation || err == nil || ctx.Err() == nil {
+		return false
+	}
+	if errors.Is(context.Cause(ctx), ErrTimeout) {
+		return false
+	}
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+func (cb *circuitBreaker) Allow() (Permit, error) {
+	p, err := cb.allow(false, 1)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (cb *circuitBreaker) AllowN(n int) (Permit, error) {
+	if n <= 0 || n > maxCallWeight {
+		return nil, fmt.Errorf("resilience: invalid batch size %d, must be 1 to %d", n, maxCallWeight)
+	}
+	p, err := cb.allow(false, uint32(n))
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// allow admits a call taking slots requests, returning its permit by value
+// so Execute doesn't allocate; priority calls pass a degraded breaker
+func (cb *circuitBreaker) allow(priority bool, slots uint32) (permit, error) {
+	generation, err := cb.beforeRequest(priority, slots)
+	if err != nil {
+		if cb.onReject != nil {
+			cb.onReject()
+		}
+		return permit{}, err
+	}
+	return permit{cb: cb, generation: generation, start: cb.now(), weight: slots, slots: slots}, nil
+}
+
+// permit implements the Permit interface
+type permit struct {
+	cb         *circuitBreaker
+	generation *genCounts
+	start      time.Time
+	weight     uint32 // requests the outcome counts as while closed
+	slots      uint32 // requests taken on admission, 1 or the batch size
+	recorded   atomic.Bool
+}
+
+func (p *permit) RecordSuccess() {
+	p.record(OutcomeSuccess)
+}
+
+func (p *permit) RecordFailure() {
+	p.record(OutcomeFailure)
+}
+
+func (p *permit) Record(err error) {
+	p.record(p.cb.outcome(err))
+}
+
+func (p *permit) RecordBatch(successes, failures uint32) {
+	if p.recorded.Swap(true) {
+		return
+	}
+	now := p.cb.now()
+	p.cb.latency.record(now, now.Sub(p.start))
+	// Outcomes beyond the batch size are dropped, keeping failures first
+	failures = min(failures, p.slots)
+	successes = min(successes, p.slots-failures)
+	p.cb.afterBatch(p.generation, p.slots, successes, failures)
+}
+
+// release gives back the admission without recording an outcome, freeing
+// its half-open slots
+func (p *permit) release() {
+	if p.recorded.Swap(true) {
+		return
+	}
+	if g := p.cb.gen.Load(); g == p.generation {
+		g.requests.Add(-p.slots)
+	}
+}
+
+func (p *permit) record(outcome Outcome) {
+	if p.recorded.Swap(true) {
+		return
+	}
+	now := p.cb.now()
+	p.cb.latency.record(now, now.Sub(p.start))
+	p.cb.afterRequest(p.generation, outcome, p.weight, p.slots)
+}
+
+// outcome classifies err as the breaker records it
+func (cb *circuitBreaker) outcome(err error) Outcome {
+	switch {
+	case !cb.isFailure(err):
+		return OutcomeSuccess
+	case errors.Is(err, ErrTimeout), errors.Is(err, ErrQueueTimeout), errors.Is(err, context.DeadlineExceeded):
+		return OutcomeTimeout
+	default:
+		return OutcomeFailure
+	}
+}
+
+// isFailure reports whether err counts against the breaker
+func (cb *circuitBreaker) isFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	if cb.config.IsFailure != nil {
+		return cb.config.IsFailure(err)
+	}
+	return true
+}
+
 func (cb *circuitBreaker) Reset() {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
-	cb.toNewGeneration(time.Now())
-	cb.setState(StateClosed, time.Now())
+	cb.forced = false
+	cb.toNewGeneration(cb.now())
+	cb.setState(StateClosed, cb.now())
 }
 
-func (cb *circuitBreaker) beforeRequest() (uint64, error) {
+func (cb *circuitBreaker) Trip() {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
+	if cb.forced {
+		return
+	}
+	cb.setState(StateOpen, cb.now())
+}
 
-	now := time.Now()
+func (cb *circuitBreaker) ForceOpen() {
+	cb.force(StateOpen)
+}
+
+func (cb *circuitBreaker) ForceClosed() {
+	cb.force(StateClosed)
+}
+
+func (cb *circuitBreaker) Disable() {
+	cb.force(StateDisabled)
+}
+
+// force pins the breaker in state until Reset
+func (cb *circuitBreaker) force(state CircuitState) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := cb.now()
+	cb.forced = true
+	cb.setState(state, now)
+	cb.toNewGeneration(now)
+}
+
+// beforeRequest admits a call taking slots requests: 1, or the size of a
+// batch, which must fit the half-open trials left and passes a share of
+// calls only if each of its operations would
+func (cb *circuitBreaker) beforeRequest(priority bool, slots uint32) (*genCounts, error) {
+	now := cb.now()
+
+	// Fast path: closed and within the interval, with no openings due
+	if g := cb.gen.Load(); g.fast.Load() && now.UnixNano()-g.start <= int64(cb.config.Interval) && now.UnixNano() < cb.reportAt.Load() {
+		g.requests.Add(slots)
+		return g, nil
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.reportOpenings(now)
 	state := cb.state
 
+	if cb.forced {
+		if state == StateOpen {
+			return nil, cb.openError(0)
+		}
+		// Disabled breakers keep closed-state counting so metrics stay current
+		if state == StateDisabled && now.Sub(cb.stateTime) > cb.config.Interval {
+			cb.toNewGeneration(now)
+		}
+		g := cb.gen.Load()
+		g.requests.Add(slots)
+		return g, nil
+	}
+
 	switch state {
-	case StateClosed:
-		// Reset counts if interval has passed
-		if now.Sub(cb.stateTime) > cb.config.Interval {
+	case StateClosed, StateDegraded:
+		// Reset counts if interval has passed (sliding windows expire continuously)
+		if cb.window == nil && now.Sub(cb.stateTime) > cb.config.Interval {
 			cb.toNewGeneration(now)
 		}
 
+		// Degraded: shed best-effort calls
+		if state == StateDegraded && !priority {
+			return nil, cb.openError(0)
+		}
+
+		// Brownout: shed a share of calls instead of opening
+		if cb.config.TripMode == TripModeProportional && cb.shouldShed(now, slots) {
+			return nil, cb.openError(0)
+		}
+
+		// Slow start: admit a growing share of calls after recovery
+		if share, ok := cb.slowStartShare(now); ok && !admitShare(share, slots) {
+			return nil, cb.openError(0)
+		}
+
 	case StateOpen:
 		// Check if timeout has passed to move to half-open
-		if now.Sub(cb.stateTime) > cb.config.Timeout {
-			cb.setState(StateHalfOpen, now)
-			return 0, nil
+		if elapsed := now.Sub(cb.stateTime); elapsed <= cb.openFor {
+			return nil, cb.openError(cb.openFor - elapsed)
+		}
+		// A health probe, rather than a user request, decides whether to
+		// try half-open
+		if cb.config.HealthProbe != nil {
+			if !cb.probing {
+				cb.probing = true
+				go cb.probe(cb.currentGeneration())
+			}
+			return nil, cb.openError(0)
+		}
+		// The first trial request belongs to the half-open generation so
+		// its outcome is recorded
+		cb.setState(StateHalfOpen, now)
+		cb.toNewGeneration(now)
+		if cb.config.HalfOpenMode == HalfOpenModeFixed && slots > cb.config.MaxRequests {
+			return nil, cb.openError(0)
 		}
-		return 0, ErrCircuitOpen
 
 	case StateHalfOpen:
+		if cb.config.HalfOpenMode == HalfOpenModeRamp {
+			share, done := cb.rampShare(now)
+			if done {
+				cb.setState(StateClosed, now)
+				break
+			}
+			if !admitShare(share, slots) {
+				return nil, cb.openError(0)
+			}
+			break
+		}
+
 		// Limit requests in half-open state
-		if cb.counts.requests >= cb.config.MaxRequests {
-			return 0, ErrCircuitOpen
+		if cb.gen.Load().requests.Load()+slots > cb.config.MaxRequests {
+			return nil, cb.openError(0)
 		}
 	}
 
-	cb.counts.requests++
-	return cb.currentGeneration(), nil
+	g := cb.gen.Load()
+	g.requests.Add(slots)
+	return g, nil
 }
 
-func (cb *circuitBreaker) afterRequest(generation uint64, success bool) {
+// admitShare randomly admits a call taking slots requests when share of
+// calls pass, as likely as all of its operations passing on their own
+func admitShare(share float64, slots uint32) bool {
+	if slots > 1 {
+		share = math.Pow(share, float64(slots))
+	}
+	return rand.Float64() < share
+}
+
+// openError describes a rejection; retryAfter is the estimated time
+// until half-open, zero when unknown. The caller holds cb.mu.
+func (cb *circuitBreaker) openError(retryAfter time.Duration) error {
+	return &CircuitOpenError{
+		Breaker:    cb.config.Name,
+		State:      cb.state,
+		OpenedAt:   cb.openedAt,
+		RetryAfter: retryAfter,
+	}
+}
+
+// probe runs the HealthProbe and moves the breaker to half-open when it
+// succeeds; a failed probe keeps the breaker open for another Timeout
+func (cb *circuitBreaker) probe(generation *genCounts) {
+	ctx, cancel := context.WithTimeout(context.Background(), cb.config.ProbeTimeout)
+	err := cb.config.HealthProbe(ctx)
+	cancel()
+
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
 
-	now := time.Now()
+	cb.probing = false
+	if generation != cb.currentGeneration() || cb.state != StateOpen || cb.forced {
+		return
+	}
+	now := cb.now()
+	if err != nil {
+		cb.setGeneration(now, cb.gen.Load().load())
+		return
+	}
+	cb.setState(StateHalfOpen, now)
+	cb.toNewGeneration(now)
+}
+
+// afterRequest records the outcome of a call admitted in generation as
+// slots requests, counted as weight requests while closed or degraded
+func (cb *circuitBreaker) afterRequest(generation *genCounts, outcome Outcome, weight, slots uint32) {
+	// Fast path: successes in closed state can't cause a transition
+	if outcome == OutcomeSuccess {
+		if g := cb.gen.Load(); g.fast.Load() {
+			if g == generation {
+				// The slots were counted when admitted
+				g.requests.Add(weight - slots)
+				g.totalSuccesses.Add(weight)
+				g.consecSuccess.Add(weight)
+				g.consecFailures.Store(0)
+			}
+			return
+		}
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := cb.now()
 
 	// Ignore if generation has changed
 	if generation != cb.currentGeneration() {
 		return
 	}
+	// Half-open trials count once whatever their weight, batches once
+	// per operation
+	if !cb.counting() {
+		weight = slots
+	}
+	// The outcome counts its own request as admitted already
+	cb.gen.Load().requests.Add(1 - slots)
 
-	if success {
-		cb.onSuccess(now)
-	} else {
-		cb.onFailure(now)
+	switch outcome {
+	case OutcomeSuccess:
+		cb.onSuccess(now, weight)
+	case OutcomeTimeout:
+		cb.onTimeout(now, weight)
+	default:
+		cb.onFailure(now, weight)
+	}
+}
+
+// afterBatch records the aggregate outcome of a batch of slots operations
+// admitted in generation; successes and failures add up to at most slots
+func (cb *circuitBreaker) afterBatch(generation *genCounts, slots, successes, failures uint32) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := cb.now()
+	if generation != cb.currentGeneration() {
+		return
+	}
+
+	g := cb.gen.Load()
+	if successes == 0 && failures == 0 {
+		g.requests.Add(-slots)
+		return
+	}
+	// Operations without an outcome free their slots; the outcome counts
+	// its own request as admitted already
+	g.requests.Add(1 - slots)
+
+	switch {
+	case !cb.counting():
+		// Half-open trials fail if any operation failed
+		if failures > 0 {
+			cb.onFailure(now, failures)
+		} else {
+			cb.onSuccess(now, successes)
+		}
+	case failures == 0:
+		cb.onSuccess(now, successes)
+	case successes == 0:
+		cb.onFailure(now, failures)
+	default:
+		// Successes first so the trip check sees the whole batch; the
+		// failures are counted as further requests
+		cb.onSuccess(now, successes)
+		if generation == cb.currentGeneration() {
+			cb.gen.Load().requests.Add(1)
+			cb.onFailure(now, failures)
+		}
 	}
 }
 
-func (cb *circuitBreaker) onSuccess(now time.Time) {
-	cb.counts.totalSuccesses++
-	cb.counts.consecSuccess++
-	cb.counts.consecFailures = 0
+// recordDelayed counts the outcome err of a call admitted in generation
+// that timed out outside the breaker, as a further request next to the
+// recorded timeout. Only the counting generation the call started in takes
+// it, like a permit's outcome; half-open trials were decided already.
+func (cb *circuitBreaker) recordDelayed(generation *genCounts, err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if generation != cb.currentGeneration() || !cb.counting() {
+		return
+	}
+	now := cb.now()
+	cb.gen.Load().requests.Add(1)
+	switch cb.outcome(err) {
+	case OutcomeSuccess:
+		cb.onSuccess(now, 1)
+	case OutcomeTimeout:
+		cb.onTimeout(now, 1)
+	default:
+		cb.onFailure(now, 1)
+	}
+}
+
+// onSuccess records a success counted as weight successful requests
+func (cb *circuitBreaker) onSuccess(now time.Time, weight uint32) {
+	if cb.counting() {
+		n := uint64(weight)
+		if cb.window != nil {
+			cb.window.recordN(now, true, n)
+		}
+		if cb.sharing != nil {
+			cb.shareOutcome(now, n, 0)
+		}
+		if cb.baseline != nil {
+			cb.baseline.recordN(now, true, n)
+		}
+		if cb.timeouts != nil {
+			cb.timeouts.recordN(now, true, n)
+		}
+	}
+	// The request itself was counted when admitted
+	g := cb.gen.Load()
+	g.requests.Add(weight - 1)
+	g.totalSuccesses.Add(weight)
+	g.consecSuccess.Add(weight)
+	g.consecFailures.Store(0)
+
+	if cb.state == StateDegraded {
+		cb.checkDegraded(now)
+		return
+	}
+
+	// Slow successes can meet a trip condition on slow_rate
+	if cb.state == StateClosed && cb.slowTrips() && !cb.forced && cb.config.TripMode != TripModeProportional && cb.readyToTrip(now) {
+		cb.setState(StateOpen, now)
+		return
+	}
 
 	if cb.state == StateHalfOpen {
-		// Transition to closed after consecutive successes
-		if cb.counts.consecSuccess >= cb.config.MaxRequests {
+		if cb.config.HalfOpenMode == HalfOpenModeRamp {
+			// Close once the ramp has completed without failures
+			if _, done := cb.rampShare(now); done {
+				cb.setState(StateClosed, now)
+			}
+			return
+		}
+
+		// Transition to closed after enough consecutive successes
+		if g.consecSuccess.Load() >= cb.config.HalfOpenSuccessThreshold {
 			cb.setState(StateClosed, now)
 		}
 	}
 }
 
-func (cb *circuitBreaker) onFailure(now time.Time) {
-	cb.counts.totalFailures++
-	cb.counts.consecFailures++
-	cb.counts.consecSuccess = 0
+// onFailure records a failure counted as weight failed requests
+func (cb *circuitBreaker) onFailure(now time.Time, weight uint32) {
+	if cb.counting() {
+		n := uint64(weight)
+		if cb.window != nil {
+			cb.window.recordN(now, false, n)
+		}
+		if cb.timeouts != nil {
+			cb.timeouts.recordN(now, true, n)
+		}
+		if cb.sharing != nil && !cb.forced {
+			cb.shareOutcome(now, n, n)
+		}
+		if cb.baseline != nil {
+			cb.baseline.recordN(now, false, n)
+		}
+	}
+	// The request itself was counted when admitted
+	g := cb.gen.Load()
+	g.requests.Add(weight - 1)
+	g.totalFailures.Add(weight)
+	g.consecFailures.Add(weight)
+	g.consecSuccess.Store(0)
+
+	if cb.forced {
+		return
+	}
 
 	if cb.state == StateHalfOpen {
 		// Transition back to open on any failure in half-open
@@ -159,19 +866,176 @@ func (cb *circuitBreaker) onFailure(now time.Time) {
 	}
 
 	// Check if we should trip the circuit
-	if cb.readyToTrip() {
+	if cb.config.TripMode != TripModeProportional && cb.readyToTrip(now) {
 		cb.setState(StateOpen, now)
+		return
+	}
+	if cb.state == StateClosed {
+		cb.checkDegraded(now)
 	}
 }
 
-func (cb *circuitBreaker) readyToTrip() bool {
+// onTimeout records a failure that timed out according to TimeoutMode,
+// counted as weight requests
+func (cb *circuitBreaker) onTimeout(now time.Time, weight uint32) {
+	switch {
+	case cb.config.TimeoutMode == TimeoutModeDouble:
+		cb.onFailure(now, 2*weight)
+		return
+	case cb.config.TimeoutMode != TimeoutModeSeparate, cb.state == StateHalfOpen:
+		// Half-open trials fail on timeouts whatever the mode
+		cb.onFailure(now, weight)
+		return
+	}
+
+	// Separate mode: a request, but neither a success nor a failure. The
+	// sliding window records it as a success that tripCounts takes back.
+	if cb.window != nil && cb.counting() {
+		cb.window.recordN(now, true, uint64(weight))
+		cb.timeouts.recordN(now, false, uint64(weight))
+	}
+	g := cb.gen.Load()
+	g.requests.Add(weight - 1)
+	g.totalTimeouts.Add(weight)
+
+	if cb.forced {
+		return
+	}
+	if cb.config.TripMode != TripModeProportional && cb.readyToTrip(now) {
+		cb.setState(StateOpen, now)
+	}
+}
+
+// shouldShed randomly rejects calls taking slots requests in proportion to
+// the failure rate while the trip condition holds
+func (cb *circuitBreaker) shouldShed(now time.Time, slots uint32) bool {
+	c := cb.tripCounts(now)
+	if c.requests == 0 || !cb.readyToTrip(now) {
+		return false
+	}
+
+	shed := float64(c.totalFailures) / float64(c.requests)
+	if cb.shed != nil {
+		shed = max(cb.shed.eval(cb.tripVars(c, now)), 0)
+	}
+	if shed > maxProportionalShed {
+		shed = maxProportionalShed
+	}
+	return !admitShare(1-shed, slots)
+}
+
+// rampShare returns the share of traffic the half-open ramp admits at now,
+// and whether the ramp has completed
+func (cb *circuitBreaker) rampShare(now time.Time) (float64, bool) {
+	steps := cb.config.RampSteps
+	elapsed := now.Sub(cb.since)
+	if elapsed >= cb.config.RampDuration {
+		return 1, true
+	}
+	step := int(elapsed * time.Duration(len(steps)) / cb.config.RampDuration)
+	return steps[step], false
+}
+
+// tripCounts returns the counts trip decisions are based on: the sliding
+// window in closed state when enabled, otherwise the generation counts
+func (cb *circuitBreaker) tripCounts(now time.Time) counts {
+	c := cb.gen.Load().load()
+	if cb.window == nil || !cb.counting() {
+		return c
+	}
+
+	successes, failures := cb.window.totals(now)
+	c.requests = uint32(successes + failures)
+	c.totalSuccesses = uint32(successes)
+	c.totalFailures = uint32(failures)
+	if cb.timeouts != nil {
+		_, timeouts := cb.timeouts.totals(now)
+		c.totalSuccesses -= uint32(timeouts)
+		c.totalTimeouts = uint32(timeouts)
+	}
+	return c
+}
+
+// countsWindow returns the period tripCounts cover: the sliding window, or
+// the time since the generation started
+func (cb *circuitBreaker) countsWindow(now time.Time) time.Duration {
+	if cb.window != nil && cb.counting() {
+		return cb.config.Interval
+	}
+	return now.Sub(cb.stateTime)
+}
+
+func (cb *circuitBreaker) readyToTrip(now time.Time) bool {
+	if now.Before(cb.warmUntil) {
+		return false
+	}
+	c := cb.tripCounts(now)
+
+	// Timeouts counted separately trip on their own ratio
+	if cb.config.TimeoutMode == TimeoutModeSeparate && c.requests >= cb.config.MinRequests &&
+		float64(c.totalTimeouts)/float64(c.requests) >= cb.config.TimeoutThreshold {
+		return true
+	}
+
+	if policy := cb.config.TripPolicy; policy != nil {
+		return policy.ShouldTrip(c.public(), cb.countsWindow(now))
+	}
+
+	if cb.trip == nil && cb.config.TripStrategy == TripStrategyConsecutive {
+		return c.consecFailures >= cb.config.ConsecutiveFailures
+	}
+
 	// Need minimum requests before checking failure ratio
-	if cb.counts.requests < cb.config.MinRequests {
+	if c.requests < cb.config.MinRequests {
 		return false
 	}
 
-	failureRatio := float64(cb.counts.totalFailures) / float64(cb.counts.requests)
-	return failureRatio >= cb.config.FailureThreshold
+	if cb.trip != nil {
+		return cb.trip.eval(cb.tripVars(c, now))
+	}
+
+	failureRatio := float64(c.totalFailures) / float64(c.requests)
+	return failureRatio >= cb.failureThreshold(now)
+}
+
+// tripVars returns the variables trip and shed expressions see for c
+func (cb *circuitBreaker) tripVars(c counts, now time.Time) *tripVars {
+	v := &tripVars{
+		requests:             float64(c.requests),
+		successes:            float64(c.totalSuccesses),
+		failures:             float64(c.totalFailures),
+		timeouts:             float64(c.totalTimeouts),
+		consecutiveSuccesses: float64(c.consecSuccess),
+		consecutiveFailures:  float64(c.consecFailures),
+	}
+	if cb.config.SlowCallThreshold > 0 {
+		v.slowRate = cb.latency.shareAbove(now, cb.config.SlowCallThreshold)
+	}
+	return v
+}
+
+// slowTrips reports whether a trip condition may hold on slow calls alone,
+// so successes are checked against it too
+func (cb *circuitBreaker) slowTrips() bool {
+	return cb.trip != nil && cb.config.SlowCallThreshold > 0
+}
+
+// failureThreshold returns the failure ratio that trips the circuit: the
+// configured FailureThreshold, or with the adaptive strategy the baseline
+// failure rate times BaselineFactor once the baseline has enough requests
+func (cb *circuitBreaker) failureThreshold(now time.Time) float64 {
+	if cb.baseline == nil {
+		return cb.config.FailureThreshold
+	}
+
+	successes, failures := cb.baseline.totals(now)
+	requests := successes + failures
+	if requests < uint64(cb.config.BaselineMinRequests) {
+		return cb.config.FailureThreshold
+	}
+
+	threshold := float64(failures) / float64(requests) * cb.config.BaselineFactor
+	return min(max(threshold, cb.config.BaselineFloor), 1)
 }
 
 func (cb *circuitBreaker) setState(state CircuitState, now time.Time) {
@@ -180,24 +1044,197 @@ func (cb *circuitBreaker) setState(state CircuitState, now time.Time) {
 	}
 
 	prev := cb.state
+	cb.remember(Transition{Time: now, From: prev, To: state, Counts: cb.tripCounts(now).public()})
+	if recorder := cb.config.MetricsRecorder; recorder != nil {
+		recorder.RecordStateDuration(cb.config.Name, prev, now.Sub(cb.since))
+		cb.reportOpenings(now)
+		if state == StateOpen {
+			cb.openings++
+		}
+	}
 	cb.state = state
-	cb.stateTime = now
+	cb.since = now
+	cb.slowUntil = time.Time{}
+	if state == StateClosed && prev == StateHalfOpen && !cb.remote && cb.config.SlowStartDuration > 0 {
+		cb.slowUntil = now.Add(cb.config.SlowStartDuration)
+	}
+	if state == StateOpen {
+		cb.openedAt = now
+		cb.openFor = cb.openDuration(now)
+	}
 
+	// A transition starts a new generation so results of calls admitted
+	// before it are ignored; counts carry over except on closing
 	if cb.state == StateClosed {
 		cb.toNewGeneration(now)
+	} else {
+		cb.setGeneration(now, cb.gen.Load().load())
+	}
+
+	cb.notifyStateChange(prev, state)
+
+	// Share local trips and recoveries with other instances; leaving the
+	// degraded state is a local matter
+	shared := state == StateOpen || (state == StateClosed && prev != StateDegraded)
+	if shared && cb.config.Coordinator != nil && !cb.remote {
+		event := CoordinationEvent{Breaker: cb.coordinationName(), State: state, Time: now}
+		go cb.publish(event)
+	}
+}
+
+// notifyStateChange calls OnStateChange. The caller holds cb.mu.
+func (cb *circuitBreaker) notifyStateChange(from, to CircuitState) {
+	if cb.config.OnStateChange == nil {
+		return
+	}
+	if cb.config.SyncStateChange {
+		cb.config.OnStateChange(cb.config.Name, from, to)
+	} else {
+		cb.notifier.notify(stateChange{from: from, to: to})
+	}
+}
+
+// remember adds t to the history ring. The caller holds cb.mu.
+func (cb *circuitBreaker) remember(t Transition) {
+	if len(cb.history) < cb.config.HistorySize {
+		cb.history = append(cb.history, t)
+		return
+	}
+	cb.history[cb.historyAt] = t
+	cb.historyAt = (cb.historyAt + 1) % len(cb.history)
+}
+
+func (cb *circuitBreaker) History() []Transition {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+
+	history := make([]Transition, 0, len(cb.history))
+	history = append(history, cb.history[cb.historyAt:]...)
+	return append(history, cb.history[:cb.historyAt]...)
+}
+
+// openDuration returns how long an opening at now lasts: Timeout, at least
+// MinOpenDuration, extended by OpenFlapBackoff for each opening beyond
+// OpenFlapThreshold within OpenFlapWindow. The caller holds cb.mu.
+func (cb *circuitBreaker) openDuration(now time.Time) time.Duration {
+	d := max(cb.config.Timeout, cb.config.MinOpenDuration)
+	if cb.config.OpenFlapThreshold <= 0 {
+		return d
+	}
+
+	cutoff := now.Add(-cb.config.OpenFlapWindow)
+	kept := cb.opens[:0]
+	for _, t := range cb.opens {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	cb.opens = append(kept, now)
+
+	excess := len(cb.opens) - cb.config.OpenFlapThreshold
+	if excess <= 0 {
+		return d
+	}
+	limit := max(cb.config.MaxOpenDuration, d)
+	damped := float64(d) * math.Pow(cb.config.OpenFlapBackoff, float64(excess))
+	if damped >= float64(limit) {
+		return limit
+	}
+	return time.Duration(damped)
+}
+
+// reportOpenings reports the openings of each MetricsInterval that ended
+// by now; an interval without calls or transitions is reported as zero with
+// the next one that ends. The caller holds cb.mu.
+func (cb *circuitBreaker) reportOpenings(now time.Time) {
+	end := cb.reportAt.Load()
+	if now.UnixNano() < end {
+		return
 	}
+	interval := cb.config.MetricsInterval
+	ended := (now.UnixNano()-end)/int64(interval) + 1
 
-	// Call state change callback
-	if cb.config.OnStateChange != nil {
-		cb.config.OnStateChange(cb.config.Name, prev, state)
+	recorder := cb.config.MetricsRecorder
+	recorder.RecordOpenings(cb.config.Name, cb.openings, interval)
+	if ended > 1 {
+		recorder.RecordOpenings(cb.config.Name, 0, interval)
 	}
+	cb.openings = 0
+	cb.reportAt.Store(end + ended*int64(interval))
+}
+
+// publish sends event to the Coordinator; failures leave other instances
+// deciding on their own counts
+func (cb *circuitBreaker) publish(event CoordinationEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), cb.config.CoordinationTimeout)
+	defer cancel()
+	_ = cb.config.Coordinator.Publish(ctx, event)
+}
+
+// coordinationName is the name transitions are shared under: the group
+// name for grouped breakers, otherwise the breaker name
+func (cb *circuitBreaker) coordinationName() string {
+	if cb.config.GroupName != "" {
+		return cb.config.GroupName
+	}
+	return cb.config.Name
+}
+
+// onCoordinationEvent opens the breaker when another instance or group
+// member tripped it, and closes it when one recovered after the local trip
+func (cb *circuitBreaker) onCoordinationEvent(event CoordinationEvent) {
+	if event.Breaker != cb.coordinationName() {
+		return
+	}
+	if event.State != StateOpen && event.State != StateClosed {
+		return
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.forced {
+		return
+	}
+	// Events older than the local transition are stale, including a
+	// breaker's own trip delivered back by a GroupCoordinator
+	if !event.Time.After(cb.since) || (event.State == StateClosed && cb.counting()) {
+		return
+	}
+	// Another instance recovering doesn't cut short the minimum open time
+	if event.State == StateClosed && cb.state == StateOpen && cb.now().Sub(cb.since) < cb.config.MinOpenDuration {
+		return
+	}
+
+	cb.remote = true
+	cb.setState(event.State, cb.now())
+	cb.remote = false
 }
 
 func (cb *circuitBreaker) toNewGeneration(now time.Time) {
-	cb.counts = &counts{}
-	cb.stateTime = now
+	if cb.window != nil {
+		cb.window.reset(now)
+	}
+	if cb.timeouts != nil {
+		cb.timeouts.reset(now)
+	}
+	cb.setGeneration(now, counts{})
+}
+
+// setGeneration starts a generation at start with counts c
+func (cb *circuitBreaker) setGeneration(start time.Time, c counts) {
+	g := newGenCounts(start, c)
+	g.fast.Store(cb.fastEligible())
+	cb.stateTime = start
+	cb.gen.Store(g)
+}
+
+// fastEligible reports whether calls may take the fast path. The caller
+// holds cb.mu.
+func (cb *circuitBreaker) fastEligible() bool {
+	return cb.fastPath && cb.state == StateClosed && !cb.forced && cb.slowUntil.IsZero()
 }
 
-func (cb *circuitBreaker) currentGeneration() uint64 {
-	return uint64(cb.stateTime.UnixNano())
+func (cb *circuitBreaker) currentGeneration() *genCounts {
+	return cb.gen.Load()
 }
@@ -3,10 +3,15 @@ package resilience
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCircuitBreakerStates(t *testing.T) {
@@ -116,11 +121,12 @@ func TestCircuitBreakerExecution(t *testing.T) {
 		})
 
 		assert.Error(t, err)
-		assert.Equal(t, ErrCircuitOpen, err)
+		assert.ErrorIs(t, err, ErrCircuitOpen)
 		assert.False(t, called) // Function should not be called
 	})
 
 	t.Run("transitions to half-open after timeout", func(t *testing.T) {
+		clock := NewFakeClock(time.Now())
 		config := CircuitBreakerConfig{
 			Name:             "test",
 			MaxRequests:      2,
@@ -128,6 +134,7 @@ func TestCircuitBreakerExecution(t *testing.T) {
 			Timeout:          50 * time.Millisecond, // Short timeout
 			FailureThreshold: 0.5,
 			MinRequests:      2,
+			Clock:            clock,
 		}
 		cb := NewCircuitBreaker(config)
 		ctx := context.Background()
@@ -139,14 +146,11 @@ func TestCircuitBreakerExecution(t *testing.T) {
 		assert.Equal(t, StateOpen, cb.State())
 
 		// Wait for timeout
-		time.Sleep(60 * time.Millisecond)
+		clock.Advance(60 * time.Millisecond)
 
 		// Next request should transition to half-open
 		cb.Execute(ctx, func(ctx context.Context) error { return nil })
-
-		// Should now be in half-open or closed (depending on result)
-		state := cb.State()
-		assert.True(t, state == StateHalfOpen || state == StateClosed)
+		assert.Equal(t, StateHalfOpen, cb.State())
 	})
 
 	t.Run("manual reset closes circuit", func(t *testing.T) {
@@ -198,6 +202,7 @@ func TestCircuitBreakerStateCallback(t *testing.T) {
 			OnStateChange: func(name string, from, to CircuitState) {
 				stateChanges = append(stateChanges, to)
 			},
+			SyncStateChange: true,
 		}
 		cb := NewCircuitBreaker(config)
 		ctx := context.Background()
@@ -209,6 +214,46 @@ func TestCircuitBreakerStateCallback(t *testing.T) {
 		// Should have transitioned to open
 		assert.Contains(t, stateChanges, StateOpen)
 	})
+
+	t.Run("slow callbacks do not block traffic", func(t *testing.T) {
+		release := make(chan struct{})
+		changes := make(chan CircuitState, 10)
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name: "test",
+			OnStateChange: func(name string, from, to CircuitState) {
+				<-release
+				changes <- to
+			},
+		})
+		ctx := context.Background()
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			cb.Trip()
+			cb.Reset()
+			cb.Trip()
+			_ = cb.Execute(ctx, func(ctx context.Context) error { return nil })
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("breaker blocked on OnStateChange")
+		}
+
+		close(release)
+		var got []CircuitState
+		for i := 0; i < 3; i++ {
+			select {
+			case to := <-changes:
+				got = append(got, to)
+			case <-time.After(time.Second):
+				t.Fatal("state change not delivered")
+			}
+		}
+		assert.Equal(t, []CircuitState{StateOpen, StateClosed, StateOpen}, got)
+	})
 }
 
 func TestDefaultCircuitBreakerConfig(t *testing.T) {
@@ -224,3 +269,1496 @@ func TestDefaultCircuitBreakerConfig(t *testing.T) {
 		assert.Equal(t, uint32(10), config.MinRequests)
 	})
 }
+
+func TestCircuitBreakerProportionalMode(t *testing.T) {
+	t.Run("sheds calls instead of opening", func(t *testing.T) {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name:             "test",
+			Interval:         time.Minute,
+			FailureThreshold: 0.5,
+			MinRequests:      4,
+			TripMode:         TripModeProportional,
+		})
+		ctx := context.Background()
+
+		for i := 0; i < 4; i++ {
+			cb.Execute(ctx, func(ctx context.Context) error { return errors.New("error") })
+		}
+
+		rejected, passed := 0, 0
+		for i := 0; i < 200; i++ {
+			err := cb.Execute(ctx, func(ctx context.Context) error { return errors.New("error") })
+			if errors.Is(err, ErrCircuitOpen) {
+				rejected++
+			} else {
+				passed++
+			}
+		}
+
+		assert.Equal(t, StateClosed, cb.State())
+		assert.Greater(t, rejected, 100)
+		assert.Greater(t, passed, 0)
+	})
+
+	t.Run("does not shed below threshold", func(t *testing.T) {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name:             "test",
+			Interval:         time.Minute,
+			FailureThreshold: 0.5,
+			MinRequests:      4,
+			TripMode:         TripModeProportional,
+		})
+		ctx := context.Background()
+
+		for i := 0; i < 100; i++ {
+			err := cb.Execute(ctx, func(ctx context.Context) error { return nil })
+			assert.NoError(t, err)
+		}
+	})
+
+	t.Run("rejects unknown trip mode", func(t *testing.T) {
+		config := DefaultCircuitBreakerConfig()
+		config.TripMode = "sideways"
+		assert.Error(t, config.Validate())
+		assert.Panics(t, func() { NewCircuitBreaker(config) })
+	})
+}
+
+func TestCircuitBreakerSlidingWindow(t *testing.T) {
+	newBreaker := func(clock *time.Time) *circuitBreaker {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name:             "test",
+			Interval:         10 * time.Second,
+			FailureThreshold: 0.5,
+			MinRequests:      4,
+			WindowType:       WindowTypeSliding,
+			WindowBuckets:    10,
+		}).(*circuitBreaker)
+		cb.now = func() time.Time { return *clock }
+		cb.toNewGeneration(*clock)
+		return cb
+	}
+	ctx := context.Background()
+	fail := func(ctx context.Context) error { return errors.New("error") }
+	succeed := func(ctx context.Context) error { return nil }
+
+	t.Run("trips on failures spanning a fixed interval boundary", func(t *testing.T) {
+		clock := time.Unix(1000, 0)
+		cb := newBreaker(&clock)
+
+		clock = clock.Add(8 * time.Second)
+		_ = cb.Execute(ctx, fail)
+		_ = cb.Execute(ctx, fail)
+
+		clock = clock.Add(4 * time.Second)
+		_ = cb.Execute(ctx, fail)
+		_ = cb.Execute(ctx, fail)
+
+		assert.Equal(t, StateOpen, cb.State())
+	})
+
+	t.Run("forgets outcomes older than the interval", func(t *testing.T) {
+		clock := time.Unix(1000, 0)
+		cb := newBreaker(&clock)
+
+		for i := 0; i < 3; i++ {
+			_ = cb.Execute(ctx, fail)
+		}
+
+		clock = clock.Add(11 * time.Second)
+		_ = cb.Execute(ctx, fail)
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, cb.Execute(ctx, succeed))
+		}
+
+		assert.Equal(t, StateClosed, cb.State())
+	})
+
+	t.Run("rejects unknown window type", func(t *testing.T) {
+		config := DefaultCircuitBreakerConfig()
+		config.WindowType = "tumbling"
+		assert.Error(t, config.Validate())
+		assert.Panics(t, func() { NewCircuitBreaker(config) })
+	})
+}
+
+func TestCircuitBreakerConsecutiveStrategy(t *testing.T) {
+	ctx := context.Background()
+	fail := func(ctx context.Context) error { return errors.New("error") }
+	succeed := func(ctx context.Context) error { return nil }
+
+	t.Run("opens after consecutive failures without min requests", func(t *testing.T) {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name:                "test",
+			TripStrategy:        TripStrategyConsecutive,
+			ConsecutiveFailures: 3,
+		})
+
+		_ = cb.Execute(ctx, fail)
+		_ = cb.Execute(ctx, fail)
+		assert.Equal(t, StateClosed, cb.State())
+
+		_ = cb.Execute(ctx, fail)
+		assert.Equal(t, StateOpen, cb.State())
+	})
+
+	t.Run("a success resets the streak", func(t *testing.T) {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name:                "test",
+			TripStrategy:        TripStrategyConsecutive,
+			ConsecutiveFailures: 3,
+		})
+
+		for i := 0; i < 10; i++ {
+			_ = cb.Execute(ctx, fail)
+			_ = cb.Execute(ctx, fail)
+			assert.NoError(t, cb.Execute(ctx, succeed))
+		}
+		assert.Equal(t, StateClosed, cb.State())
+	})
+
+	t.Run("rejects unknown trip strategy", func(t *testing.T) {
+		config := DefaultCircuitBreakerConfig()
+		config.TripStrategy = "vibes"
+		assert.Error(t, config.Validate())
+		assert.Panics(t, func() { NewCircuitBreaker(config) })
+	})
+}
+
+func TestCircuitBreakerMetrics(t *testing.T) {
+	t.Run("reports counts and time in state", func(t *testing.T) {
+		clock := time.Unix(1000, 0)
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name:             "test",
+			FailureThreshold: 0.5,
+			MinRequests:      4,
+		}).(*circuitBreaker)
+		cb.now = func() time.Time { return clock }
+		cb.toNewGeneration(clock)
+		cb.since = clock
+		ctx := context.Background()
+
+		_ = cb.Execute(ctx, func(ctx context.Context) error { return nil })
+		_ = cb.Execute(ctx, func(ctx context.Context) error { return errors.New("error") })
+		_ = cb.Execute(ctx, func(ctx context.Context) error { return errors.New("error") })
+		clock = clock.Add(5 * time.Second)
+
+		m := cb.Metrics()
+		assert.Equal(t, StateClosed, m.State)
+		assert.Equal(t, uint32(3), m.Requests)
+		assert.Equal(t, uint32(1), m.Successes)
+		assert.Equal(t, uint32(2), m.Failures)
+		assert.Equal(t, uint32(0), m.ConsecutiveSuccesses)
+		assert.Equal(t, uint32(2), m.ConsecutiveFailures)
+		assert.Equal(t, 5*time.Second, m.TimeInState)
+
+		_ = cb.Execute(ctx, func(ctx context.Context) error { return errors.New("error") })
+		m = cb.Metrics()
+		assert.Equal(t, StateOpen, m.State)
+		assert.Equal(t, clock, m.StateSince)
+		assert.Equal(t, time.Duration(0), m.TimeInState)
+	})
+}
+
+func TestCircuitBreakerIsFailure(t *testing.T) {
+	errNotFound := errors.New("not found")
+	ctx := context.Background()
+
+	t.Run("errors classified as non-failures count as successes", func(t *testing.T) {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name:                "test",
+			TripStrategy:        TripStrategyConsecutive,
+			ConsecutiveFailures: 2,
+			IsFailure: func(err error) bool {
+				return !errors.Is(err, errNotFound) && !errors.Is(err, context.Canceled)
+			},
+		})
+
+		for i := 0; i < 5; i++ {
+			assert.ErrorIs(t, cb.Execute(ctx, func(ctx context.Context) error { return errNotFound }), errNotFound)
+			_ = cb.Execute(ctx, func(ctx context.Context) error { return context.Canceled })
+		}
+		assert.Equal(t, StateClosed, cb.State())
+		assert.Equal(t, uint32(10), cb.Metrics().Successes)
+
+		_ = cb.Execute(ctx, func(ctx context.Context) error { return errors.New("boom") })
+		_ = cb.Execute(ctx, func(ctx context.Context) error { return errors.New("boom") })
+		assert.Equal(t, StateOpen, cb.State())
+	})
+}
+
+func TestCircuitBreakerManualControls(t *testing.T) {
+	ctx := context.Background()
+	fail := func(ctx context.Context) error { return errors.New("error") }
+	succeed := func(ctx context.Context) error { return nil }
+
+	t.Run("trip opens until the timeout passes", func(t *testing.T) {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name:    "test",
+			Timeout: 10 * time.Millisecond,
+		})
+
+		cb.Trip()
+		assert.Equal(t, StateOpen, cb.State())
+		assert.ErrorIs(t, cb.Execute(ctx, succeed), ErrCircuitOpen)
+
+		time.Sleep(20 * time.Millisecond)
+		assert.NoError(t, cb.Execute(ctx, succeed))
+		assert.Equal(t, StateHalfOpen, cb.State())
+	})
+
+	t.Run("force open is sticky until reset", func(t *testing.T) {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name:    "test",
+			Timeout: 10 * time.Millisecond,
+		})
+
+		cb.ForceOpen()
+		time.Sleep(20 * time.Millisecond)
+		assert.ErrorIs(t, cb.Execute(ctx, succeed), ErrCircuitOpen)
+		assert.Equal(t, StateOpen, cb.State())
+		assert.True(t, cb.Metrics().Forced)
+
+		cb.Reset()
+		assert.NoError(t, cb.Execute(ctx, succeed))
+		assert.False(t, cb.Metrics().Forced)
+	})
+
+	t.Run("force closed ignores failures until reset", func(t *testing.T) {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name:                "test",
+			TripStrategy:        TripStrategyConsecutive,
+			ConsecutiveFailures: 2,
+		})
+
+		cb.ForceClosed()
+		cb.Trip()
+		for i := 0; i < 10; i++ {
+			assert.EqualError(t, cb.Execute(ctx, fail), "error")
+		}
+		assert.Equal(t, StateClosed, cb.State())
+
+		cb.Reset()
+		_ = cb.Execute(ctx, fail)
+		_ = cb.Execute(ctx, fail)
+		assert.Equal(t, StateOpen, cb.State())
+	})
+	t.Run("disabled counts calls without rejecting them", func(t *testing.T) {
+		var transitions []CircuitState
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name:                "test",
+			TripStrategy:        TripStrategyConsecutive,
+			ConsecutiveFailures: 2,
+			SyncStateChange:     true,
+			OnStateChange: func(name string, from, to CircuitState) {
+				transitions = append(transitions, to)
+			},
+		})
+
+		cb.Disable()
+		cb.Trip()
+		for i := 0; i < 5; i++ {
+			assert.EqualError(t, cb.Execute(ctx, fail), "error")
+		}
+		require.NoError(t, cb.Execute(ctx, succeed))
+
+		m := cb.Metrics()
+		assert.Equal(t, StateDisabled, m.State)
+		assert.Equal(t, uint32(6), m.Requests)
+		assert.Equal(t, uint32(5), m.Failures)
+		assert.Equal(t, uint32(1), m.Successes)
+		assert.Equal(t, []CircuitState{StateDisabled}, transitions)
+
+		cb.Reset()
+		assert.Equal(t, StateClosed, cb.State())
+		_ = cb.Execute(ctx, fail)
+		_ = cb.Execute(ctx, fail)
+		assert.Equal(t, StateOpen, cb.State())
+	})
+
+	t.Run("disabled from config", func(t *testing.T) {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{Name: "test", Disabled: true})
+		assert.Equal(t, StateDisabled, cb.State())
+		assert.Equal(t, "disabled", cb.State().String())
+
+		standby := NewCircuitBreaker(CircuitBreakerConfig{Name: "test"})
+		require.NoError(t, standby.Import(cb.Export()))
+		assert.Equal(t, StateDisabled, standby.State())
+		assert.True(t, standby.Metrics().Forced)
+	})
+}
+
+func TestCircuitBreakerRampRecovery(t *testing.T) {
+	newBreaker := func(clock *time.Time) *circuitBreaker {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name:         "test",
+			Timeout:      10 * time.Second,
+			HalfOpenMode: HalfOpenModeRamp,
+			RampDuration: 30 * time.Second,
+			RampSteps:    []float64{0.05, 0.25, 1.0},
+		}).(*circuitBreaker)
+		cb.now = func() time.Time { return *clock }
+		cb.toNewGeneration(*clock)
+		return cb
+	}
+	ctx := context.Background()
+	fail := func(ctx context.Context) error { return errors.New("error") }
+	succeed := func(ctx context.Context) error { return nil }
+	admitted := func(cb *circuitBreaker, calls int) int {
+		n := 0
+		for i := 0; i < calls; i++ {
+			if cb.Execute(ctx, succeed) == nil {
+				n++
+			}
+		}
+		return n
+	}
+
+	t.Run("admits a growing share of traffic before closing", func(t *testing.T) {
+		clock := time.Unix(1000, 0)
+		cb := newBreaker(&clock)
+		cb.Trip()
+
+		clock = clock.Add(11 * time.Second)
+		require.NoError(t, cb.Execute(ctx, succeed))
+		assert.Equal(t, StateHalfOpen, cb.State())
+
+		assert.InDelta(t, 50, admitted(cb, 1000), 30)
+
+		clock = clock.Add(12 * time.Second)
+		assert.InDelta(t, 250, admitted(cb, 1000), 60)
+
+		clock = clock.Add(10 * time.Second)
+		assert.Equal(t, 1000, admitted(cb, 1000))
+		assert.Equal(t, StateHalfOpen, cb.State())
+
+		clock = clock.Add(10 * time.Second)
+		require.NoError(t, cb.Execute(ctx, succeed))
+		assert.Equal(t, StateClosed, cb.State())
+	})
+
+	t.Run("reopens on failure during the ramp", func(t *testing.T) {
+		clock := time.Unix(1000, 0)
+		cb := newBreaker(&clock)
+		cb.Trip()
+
+		clock = clock.Add(11 * time.Second)
+		assert.Error(t, cb.Execute(ctx, fail))
+		assert.Equal(t, StateOpen, cb.State())
+	})
+
+	t.Run("rejects invalid ramp settings", func(t *testing.T) {
+		config := DefaultCircuitBreakerConfig()
+		config.HalfOpenMode = "gradual"
+		assert.Error(t, config.Validate())
+		assert.Panics(t, func() { NewCircuitBreaker(config) })
+
+		config = DefaultCircuitBreakerConfig()
+		config.RampSteps = []float64{0.5, 1.5}
+		assert.Error(t, config.Validate())
+	})
+}
+
+func TestCircuitBreakerHalfOpenSuccessThreshold(t *testing.T) {
+	ctx := context.Background()
+	succeed := func(ctx context.Context) error { return nil }
+	newBreaker := func(clock *time.Time, threshold uint32) *circuitBreaker {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name:                     "test",
+			MaxRequests:              10,
+			HalfOpenSuccessThreshold: threshold,
+			Timeout:                  time.Second,
+		}).(*circuitBreaker)
+		cb.now = func() time.Time { return *clock }
+		cb.Trip()
+		*clock = clock.Add(2 * time.Second)
+		return cb
+	}
+
+	t.Run("closes after the threshold rather than MaxRequests", func(t *testing.T) {
+		clock := time.Unix(1000, 0)
+		cb := newBreaker(&clock, 3)
+
+		require.NoError(t, cb.Execute(ctx, succeed))
+		require.NoError(t, cb.Execute(ctx, succeed))
+		assert.Equal(t, StateHalfOpen, cb.State())
+
+		require.NoError(t, cb.Execute(ctx, succeed))
+		assert.Equal(t, StateClosed, cb.State())
+	})
+
+	t.Run("defaults to MaxRequests", func(t *testing.T) {
+		clock := time.Unix(1000, 0)
+		cb := newBreaker(&clock, 0)
+
+		for i := 0; i < 9; i++ {
+			require.NoError(t, cb.Execute(ctx, succeed))
+		}
+		assert.Equal(t, StateHalfOpen, cb.State())
+
+		require.NoError(t, cb.Execute(ctx, succeed))
+		assert.Equal(t, StateClosed, cb.State())
+	})
+
+	t.Run("rejects a threshold above MaxRequests", func(t *testing.T) {
+		config := DefaultCircuitBreakerConfig()
+		config.HalfOpenSuccessThreshold = config.MaxRequests + 1
+		assert.Error(t, config.Validate())
+	})
+}
+
+func TestCircuitBreakerAdaptiveThreshold(t *testing.T) {
+	newBreaker := func(clock *time.Time) *circuitBreaker {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name:         "test",
+			Interval:     time.Minute,
+			MinRequests:  10,
+			TripStrategy: TripStrategyAdaptive,
+		}).(*circuitBreaker)
+		cb.now = func() time.Time { return *clock }
+		cb.toNewGeneration(*clock)
+		cb.baseline.reset(*clock)
+		return cb
+	}
+	ctx := context.Background()
+	// minute runs ten calls in the next interval, the last failures of them failing
+	minute := func(cb *circuitBreaker, clock *time.Time, failures int) {
+		*clock = clock.Add(time.Minute + time.Second)
+		for i := 0; i < 10; i++ {
+			cb.Execute(ctx, func(ctx context.Context) error {
+				if i >= 10-failures {
+					return errors.New("error")
+				}
+				return nil
+			})
+		}
+	}
+
+	t.Run("uses the failure threshold until the baseline is learned", func(t *testing.T) {
+		clock := time.Unix(1000, 0)
+		cb := newBreaker(&clock)
+		assert.Equal(t, DefaultCircuitBreakerConfig().FailureThreshold, cb.Metrics().FailureThreshold)
+
+		minute(cb, &clock, 5)
+		assert.Equal(t, StateClosed, cb.State())
+		minute(cb, &clock, 6)
+		assert.Equal(t, StateOpen, cb.State())
+	})
+
+	t.Run("tolerates a noisy dependency's usual error rate", func(t *testing.T) {
+		clock := time.Unix(1000, 0)
+		cb := newBreaker(&clock)
+		for i := 0; i < 30; i++ {
+			minute(cb, &clock, 2)
+		}
+		require.Equal(t, StateClosed, cb.State())
+		assert.InDelta(t, 0.6, cb.Metrics().FailureThreshold, 0.001)
+
+		minute(cb, &clock, 5)
+		assert.Equal(t, StateClosed, cb.State())
+		minute(cb, &clock, 8)
+		assert.Equal(t, StateOpen, cb.State())
+	})
+
+	t.Run("trips a reliable dependency early", func(t *testing.T) {
+		clock := time.Unix(1000, 0)
+		cb := newBreaker(&clock)
+		for i := 0; i < 30; i++ {
+			minute(cb, &clock, 0)
+		}
+		assert.Equal(t, 0.05, cb.Metrics().FailureThreshold)
+
+		minute(cb, &clock, 1)
+		assert.Equal(t, StateOpen, cb.State())
+	})
+
+	t.Run("rejects unknown strategies", func(t *testing.T) {
+		assert.NoError(t, CircuitBreakerConfig{TripStrategy: TripStrategyAdaptive}.Validate())
+		assert.Error(t, CircuitBreakerConfig{TripStrategy: "psychic"}.Validate())
+	})
+}
+
+func TestCircuitBreakerTimeoutMode(t *testing.T) {
+	ctx := context.Background()
+	run := func(cb CircuitBreaker, errs ...error) {
+		for _, err := range errs {
+			cb.Execute(ctx, func(ctx context.Context) error { return err })
+		}
+	}
+	repeat := func(err error, n int) []error {
+		errs := make([]error, n)
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+	timedOut := fmt.Errorf("query: %w", context.DeadlineExceeded)
+
+	t.Run("counts timeouts as failures by default", func(t *testing.T) {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{Name: "test", MinRequests: 4, FailureThreshold: 0.6})
+		run(cb, nil, nil, ErrTimeout, timedOut)
+		assert.Equal(t, StateClosed, cb.State())
+		assert.Equal(t, uint32(2), cb.Metrics().Failures)
+	})
+
+	t.Run("counts timeouts double", func(t *testing.T) {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name: "test", MinRequests: 4, FailureThreshold: 0.6, TimeoutMode: TimeoutModeDouble,
+		})
+		run(cb, nil, nil, ErrTimeout)
+		m := cb.Metrics()
+		assert.Equal(t, uint32(4), m.Requests)
+		assert.Equal(t, uint32(2), m.Failures)
+
+		run(cb, timedOut)
+		assert.Equal(t, StateOpen, cb.State())
+	})
+
+	for _, windowType := range []string{WindowTypeFixed, WindowTypeSliding} {
+		t.Run("tracks timeouts separately in "+windowType+" windows", func(t *testing.T) {
+			cb := NewCircuitBreaker(CircuitBreakerConfig{
+				Name:             "test",
+				MinRequests:      10,
+				FailureThreshold: 0.6,
+				WindowType:       windowType,
+				TimeoutMode:      TimeoutModeSeparate,
+				TimeoutThreshold: 0.25,
+			})
+			run(cb, repeat(nil, 7)...)
+			run(cb, errors.New("error"), ErrTimeout)
+			m := cb.Metrics()
+			assert.Equal(t, uint32(9), m.Requests)
+			assert.Equal(t, uint32(7), m.Successes)
+			assert.Equal(t, uint32(1), m.Failures)
+			assert.Equal(t, uint32(1), m.Timeouts)
+
+			run(cb, ErrTimeout)
+			assert.Equal(t, StateClosed, cb.State(), "timeout ratio 0.2")
+			run(cb, timedOut)
+			assert.Equal(t, StateOpen, cb.State(), "timeout ratio 0.27 after 11 requests")
+		})
+	}
+
+	t.Run("fails half-open trials on timeouts", func(t *testing.T) {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name: "test", Timeout: time.Millisecond, TimeoutMode: TimeoutModeSeparate,
+		})
+		cb.Trip()
+		time.Sleep(5 * time.Millisecond)
+		run(cb, ErrTimeout)
+		assert.Equal(t, StateOpen, cb.State())
+	})
+
+	t.Run("validates the mode", func(t *testing.T) {
+		assert.NoError(t, CircuitBreakerConfig{TimeoutMode: TimeoutModeSeparate}.Validate())
+		assert.Error(t, CircuitBreakerConfig{TimeoutMode: "triple"}.Validate())
+		assert.Panics(t, func() { NewCircuitBreaker(CircuitBreakerConfig{TimeoutMode: "triple"}) })
+	})
+}
+
+func TestCircuitBreakerAllow(t *testing.T) {
+	t.Run("records outcomes of admitted calls", func(t *testing.T) {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name:                "test",
+			TripStrategy:        TripStrategyConsecutive,
+			ConsecutiveFailures: 2,
+		})
+
+		p, err := cb.Allow()
+		require.NoError(t, err)
+		p.RecordSuccess()
+		for i := 0; i < 2; i++ {
+			p, err := cb.Allow()
+			require.NoError(t, err)
+			p.RecordFailure()
+		}
+		assert.Equal(t, StateOpen, cb.State())
+
+		_, err = cb.Allow()
+		assert.ErrorIs(t, err, ErrCircuitOpen)
+	})
+
+	t.Run("counts the first outcome only", func(t *testing.T) {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{Name: "test"})
+		p, err := cb.Allow()
+		require.NoError(t, err)
+		p.RecordFailure()
+		p.RecordFailure()
+		p.RecordSuccess()
+
+		m := cb.Metrics()
+		assert.Equal(t, uint32(1), m.Failures)
+		assert.Equal(t, uint32(0), m.Successes)
+	})
+
+	t.Run("ignores permits from an earlier generation", func(t *testing.T) {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{Name: "test"})
+		p, err := cb.Allow()
+		require.NoError(t, err)
+
+		cb.Trip()
+		cb.Reset()
+		p.RecordFailure()
+		assert.Equal(t, uint32(0), cb.Metrics().Failures)
+	})
+
+	t.Run("ignores permits from an earlier generation started at the same time", func(t *testing.T) {
+		clock := NewFakeClock(time.Unix(1000, 0))
+		cb := NewCircuitBreaker(CircuitBreakerConfig{Name: "test", Clock: clock})
+		p, err := cb.Allow()
+		require.NoError(t, err)
+
+		cb.Trip()
+		cb.Reset()
+		p.RecordFailure()
+		assert.Equal(t, uint32(0), cb.Metrics().Failures)
+		assert.Equal(t, uint32(0), cb.Metrics().Requests)
+	})
+
+	t.Run("classifies recorded errors", func(t *testing.T) {
+		notFound := errors.New("not found")
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name:        "test",
+			IsFailure:   func(err error) bool { return !errors.Is(err, notFound) },
+			TimeoutMode: TimeoutModeSeparate,
+		})
+		for _, err := range []error{notFound, ErrTimeout, errors.New("boom")} {
+			p, allowErr := cb.Allow()
+			require.NoError(t, allowErr)
+			p.Record(err)
+		}
+
+		m := cb.Metrics()
+		assert.Equal(t, uint32(1), m.Successes)
+		assert.Equal(t, uint32(1), m.Timeouts)
+		assert.Equal(t, uint32(1), m.Failures)
+	})
+}
+
+func TestCircuitBreakerLatency(t *testing.T) {
+	cb := NewCircuitBreaker(CircuitBreakerConfig{Name: "test"}).(*circuitBreaker)
+	clock := cb.stateTime
+	cb.now = func() time.Time { return clock }
+
+	for i := 1; i <= 100; i++ {
+		p, err := cb.allow(false, 1)
+		require.NoError(t, err)
+		clock = clock.Add(time.Duration(i) * time.Millisecond)
+		if i%10 == 0 {
+			p.Record(errors.New("error"))
+		} else {
+			p.Record(nil)
+		}
+	}
+
+	m := cb.Metrics()
+	assert.InEpsilon(t, float64(50*time.Millisecond), float64(m.LatencyP50), 0.25)
+	assert.InEpsilon(t, float64(95*time.Millisecond), float64(m.LatencyP95), 0.25)
+	assert.InEpsilon(t, float64(99*time.Millisecond), float64(m.LatencyP99), 0.25)
+
+	cb.ForceOpen()
+	err := cb.Execute(context.Background(), func(ctx context.Context) error { return nil })
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, m.LatencyP99, cb.Metrics().LatencyP99, "rejected calls are not timed")
+}
+
+func TestCircuitBreakerConcurrentCounts(t *testing.T) {
+	const workers, calls = 8, 500
+	ctx := context.Background()
+	run := func(cb CircuitBreaker, fail func(worker, call int) bool) {
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < calls; i++ {
+					cb.Execute(ctx, func(ctx context.Context) error {
+						if fail(w, i) {
+							return errors.New("error")
+						}
+						return nil
+					})
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+	t.Run("counts every call on the fast path", func(t *testing.T) {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{Name: "test", Interval: time.Hour})
+		require.True(t, cb.(*circuitBreaker).gen.Load().fast.Load())
+
+		run(cb, func(w, i int) bool { return false })
+
+		m := cb.Metrics()
+		assert.Equal(t, uint32(workers*calls), m.Requests)
+		assert.Equal(t, uint32(workers*calls), m.Successes)
+	})
+
+	t.Run("failures still trip under concurrent successes", func(t *testing.T) {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name:             "test",
+			Interval:         time.Hour,
+			Timeout:          time.Hour,
+			MinRequests:      100,
+			FailureThreshold: 0.5,
+		})
+
+		run(cb, func(w, i int) bool { return w%4 != 0 })
+
+		assert.Equal(t, StateOpen, cb.State())
+		assert.False(t, cb.(*circuitBreaker).gen.Load().fast.Load())
+	})
+
+	t.Run("features recording outside the counts keep the lock", func(t *testing.T) {
+		for _, config := range []CircuitBreakerConfig{
+			{WindowType: WindowTypeSliding},
+			{TripStrategy: TripStrategyAdaptive},
+			{TripMode: TripModeProportional},
+		} {
+			cb := NewCircuitBreaker(config).(*circuitBreaker)
+			assert.False(t, cb.gen.Load().fast.Load(), "%+v", config)
+		}
+
+		cb := NewCircuitBreaker(CircuitBreakerConfig{}).(*circuitBreaker)
+		cb.ForceClosed()
+		assert.False(t, cb.gen.Load().fast.Load())
+		cb.Reset()
+		assert.True(t, cb.gen.Load().fast.Load())
+	})
+}
+
+// BenchmarkCircuitBreakerParallel measures closed-state calls from every
+// processor, the path that used to serialize on the breaker's mutex
+func BenchmarkCircuitBreakerParallel(b *testing.B) {
+	ctx := context.Background()
+	fn := func(ctx context.Context) error { return nil }
+	cb := NewCircuitBreaker(CircuitBreakerConfig{Name: "bench", Interval: time.Hour})
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = cb.Execute(ctx, fn)
+		}
+	})
+}
+
+func TestCircuitBreakerHealthProbe(t *testing.T) {
+	ctx := context.Background()
+	ok := func(ctx context.Context) error { return nil }
+	newProbed := func(probe HealthProbe) (*circuitBreaker, *time.Time) {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name:        "test",
+			Timeout:     time.Minute,
+			HealthProbe: probe,
+		}).(*circuitBreaker)
+		clock := cb.stateTime
+		cb.now = func() time.Time { return clock }
+		cb.Trip()
+		return cb, &clock
+	}
+
+	t.Run("moves to half-open when the probe succeeds", func(t *testing.T) {
+		probes := make(chan error)
+		cb, clock := newProbed(func(ctx context.Context) error { return <-probes })
+
+		*clock = clock.Add(2 * time.Minute)
+		assert.ErrorIs(t, cb.Execute(ctx, ok), ErrCircuitOpen, "the request starting the probe is not a trial")
+		assert.ErrorIs(t, cb.Execute(ctx, ok), ErrCircuitOpen, "one probe at a time")
+
+		probes <- nil
+		assert.Eventually(t, func() bool { return cb.State() == StateHalfOpen }, time.Second, time.Millisecond)
+		assert.NoError(t, cb.Execute(ctx, ok))
+	})
+
+	t.Run("stays open for another timeout when the probe fails", func(t *testing.T) {
+		var calls atomic.Int32
+		cb, clock := newProbed(func(ctx context.Context) error {
+			calls.Add(1)
+			return errors.New("still down")
+		})
+
+		*clock = clock.Add(2 * time.Minute)
+		cb.Execute(ctx, ok)
+		require.Eventually(t, func() bool {
+			cb.mu.RLock()
+			defer cb.mu.RUnlock()
+			return !cb.probing
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, StateOpen, cb.State())
+
+		*clock = clock.Add(30 * time.Second)
+		assert.ErrorIs(t, cb.Execute(ctx, ok), ErrCircuitOpen)
+		assert.Equal(t, int32(1), calls.Load(), "no probe before the next timeout")
+
+		*clock = clock.Add(time.Minute)
+		cb.Execute(ctx, ok)
+		assert.Eventually(t, func() bool { return calls.Load() == 2 }, time.Second, time.Millisecond)
+	})
+
+	t.Run("ignores probes outliving the open state", func(t *testing.T) {
+		probes := make(chan error)
+		cb, clock := newProbed(func(ctx context.Context) error { return <-probes })
+
+		*clock = clock.Add(2 * time.Minute)
+		cb.Execute(ctx, ok)
+		cb.Reset()
+		probes <- nil
+
+		assert.Never(t, func() bool { return cb.State() != StateClosed }, 50*time.Millisecond, 5*time.Millisecond)
+	})
+}
+
+// stateMetrics records BreakerMetricsRecorder calls
+type stateMetrics struct {
+	durations map[CircuitState]time.Duration
+	openings  []int
+}
+
+func (m *stateMetrics) RecordStateDuration(name string, state CircuitState, d time.Duration) {
+	m.durations[state] += d
+}
+
+func (m *stateMetrics) RecordOpenings(name string, openings int, interval time.Duration) {
+	m.openings = append(m.openings, openings)
+}
+
+func TestCircuitBreakerMetricsRecorder(t *testing.T) {
+	ctx := context.Background()
+	succeed := func(ctx context.Context) error { return nil }
+
+	t.Run("reports time in state and openings per interval", func(t *testing.T) {
+		metrics := &stateMetrics{durations: map[CircuitState]time.Duration{}}
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name:            "test",
+			MetricsRecorder: metrics,
+			MetricsInterval: time.Minute,
+		}).(*circuitBreaker)
+		clock := time.Unix(1000, 0)
+		cb.now = func() time.Time { return clock }
+		cb.since = clock
+		cb.reportAt.Store(clock.Add(time.Minute).UnixNano())
+
+		// Flap twice within the first interval
+		clock = clock.Add(10 * time.Second)
+		cb.Trip()
+		clock = clock.Add(5 * time.Second)
+		cb.Reset()
+		clock = clock.Add(5 * time.Second)
+		cb.Trip()
+		clock = clock.Add(time.Second)
+		cb.Reset()
+
+		assert.Equal(t, 15*time.Second, metrics.durations[StateClosed])
+		assert.Equal(t, 6*time.Second, metrics.durations[StateOpen])
+		assert.Empty(t, metrics.openings)
+
+		clock = clock.Add(50 * time.Second)
+		require.NoError(t, cb.Execute(ctx, succeed))
+		assert.Equal(t, []int{2}, metrics.openings)
+
+		// Quiet intervals are reported as zero once the next one ends
+		clock = clock.Add(3 * time.Minute)
+		require.NoError(t, cb.Execute(ctx, succeed))
+		assert.Equal(t, []int{2, 0, 0}, metrics.openings)
+	})
+}
+
+func TestCircuitOpenError(t *testing.T) {
+	ctx := context.Background()
+	succeed := func(ctx context.Context) error { return nil }
+
+	t.Run("names the breaker and estimates the time until half-open", func(t *testing.T) {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{Name: "payments", Timeout: 30 * time.Second}).(*circuitBreaker)
+		clock := time.Unix(1000, 0)
+		cb.now = func() time.Time { return clock }
+
+		cb.Trip()
+		clock = clock.Add(10 * time.Second)
+		err := cb.Execute(ctx, succeed)
+
+		var openErr *CircuitOpenError
+		require.ErrorAs(t, err, &openErr)
+		assert.ErrorIs(t, err, ErrCircuitOpen)
+		assert.Equal(t, "payments", openErr.Breaker)
+		assert.Equal(t, StateOpen, openErr.State)
+		assert.Equal(t, time.Unix(1000, 0), openErr.OpenedAt)
+		assert.Equal(t, 20*time.Second, openErr.RetryAfter)
+		assert.Equal(t, `resilience: circuit breaker "payments" is open (retry after 20s)`, err.Error())
+	})
+
+	t.Run("leaves the retry hint unknown when forced open", func(t *testing.T) {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{Name: "payments"})
+		cb.ForceOpen()
+
+		var openErr *CircuitOpenError
+		require.ErrorAs(t, cb.Execute(ctx, succeed), &openErr)
+		assert.Zero(t, openErr.RetryAfter)
+		assert.Equal(t, `resilience: circuit breaker "payments" is open`, openErr.Error())
+	})
+}
+
+func TestCircuitBreakerOpenDuration(t *testing.T) {
+	ctx := context.Background()
+	succeed := func(ctx context.Context) error { return nil }
+	newBreaker := func(config CircuitBreakerConfig, clock *time.Time) *circuitBreaker {
+		config.Name = "test"
+		cb := NewCircuitBreaker(config).(*circuitBreaker)
+		cb.now = func() time.Time { return *clock }
+		return cb
+	}
+
+	t.Run("MinOpenDuration extends a shorter timeout", func(t *testing.T) {
+		clock := time.Unix(1000, 0)
+		cb := newBreaker(CircuitBreakerConfig{Timeout: time.Second, MinOpenDuration: 10 * time.Second}, &clock)
+
+		cb.Trip()
+		clock = clock.Add(5 * time.Second)
+		var openErr *CircuitOpenError
+		require.ErrorAs(t, cb.Execute(ctx, succeed), &openErr)
+		assert.Equal(t, 5*time.Second, openErr.RetryAfter)
+
+		clock = clock.Add(6 * time.Second)
+		require.NoError(t, cb.Execute(ctx, succeed))
+	})
+
+	t.Run("MinOpenDuration holds off remote closes", func(t *testing.T) {
+		clock := time.Unix(1000, 0)
+		cb := newBreaker(CircuitBreakerConfig{MinOpenDuration: 10 * time.Second}, &clock)
+		cb.Trip()
+
+		clock = clock.Add(5 * time.Second)
+		cb.onCoordinationEvent(CoordinationEvent{Breaker: "test", State: StateClosed, Time: clock})
+		assert.Equal(t, StateOpen, cb.State())
+
+		clock = clock.Add(6 * time.Second)
+		cb.onCoordinationEvent(CoordinationEvent{Breaker: "test", State: StateClosed, Time: clock})
+		assert.Equal(t, StateClosed, cb.State())
+	})
+
+	t.Run("damps flapping with longer openings", func(t *testing.T) {
+		clock := time.Unix(1000, 0)
+		cb := newBreaker(CircuitBreakerConfig{
+			Timeout:           10 * time.Second,
+			OpenFlapThreshold: 2,
+			OpenFlapWindow:    time.Minute,
+			MaxOpenDuration:   30 * time.Second,
+		}, &clock)
+
+		var durations []time.Duration
+		for i := 0; i < 4; i++ {
+			cb.Trip()
+			durations = append(durations, cb.Metrics().OpenDuration)
+			cb.Reset()
+			clock = clock.Add(time.Second)
+		}
+		assert.Equal(t, []time.Duration{10 * time.Second, 10 * time.Second, 20 * time.Second, 30 * time.Second}, durations)
+
+		// Openings age out of the window
+		clock = clock.Add(2 * time.Minute)
+		cb.Trip()
+		assert.Equal(t, 10*time.Second, cb.Metrics().OpenDuration)
+	})
+
+	t.Run("validates the backoff", func(t *testing.T) {
+		assert.Error(t, CircuitBreakerConfig{OpenFlapBackoff: 0.5}.Validate())
+		assert.NoError(t, CircuitBreakerConfig{OpenFlapBackoff: 3}.Validate())
+	})
+}
+
+func TestCircuitBreakerWarmupPeriod(t *testing.T) {
+	ctx := context.Background()
+	fail := func(ctx context.Context) error { return errors.New("cold start") }
+
+	clock := time.Now()
+	cb := NewCircuitBreaker(CircuitBreakerConfig{
+		Name:                "test",
+		TripStrategy:        TripStrategyConsecutive,
+		ConsecutiveFailures: 3,
+		Interval:            time.Hour,
+		WarmupPeriod:        time.Minute,
+	}).(*circuitBreaker)
+	cb.now = func() time.Time { return clock }
+
+	t.Run("collects stats without tripping", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			_ = cb.Execute(ctx, fail)
+		}
+		assert.Equal(t, StateClosed, cb.State())
+		assert.Equal(t, uint32(5), cb.Metrics().ConsecutiveFailures)
+	})
+
+	t.Run("trips after the warm-up period", func(t *testing.T) {
+		clock = cb.warmUntil
+		_ = cb.Execute(ctx, fail)
+		assert.Equal(t, StateOpen, cb.State())
+	})
+
+	t.Run("Trip is not held off", func(t *testing.T) {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{WarmupPeriod: time.Hour})
+		cb.Trip()
+		assert.Equal(t, StateOpen, cb.State())
+	})
+}
+
+func TestCircuitBreakerCallerCancellation(t *testing.T) {
+	tripOnFirst := CircuitBreakerConfig{
+		Name:                "test",
+		MaxRequests:         1,
+		TripStrategy:        TripStrategyConsecutive,
+		ConsecutiveFailures: 1,
+	}
+	canceled := func(ctx context.Context) (context.Context, func(context.Context) error) {
+		ctx, cancel := context.WithCancel(ctx)
+		return ctx, func(ctx context.Context) error {
+			cancel()
+			return ctx.Err()
+		}
+	}
+
+	t.Run("caller cancellation is neutral", func(t *testing.T) {
+		cb := NewCircuitBreaker(tripOnFirst)
+		ctx, fn := canceled(context.Background())
+
+		require.ErrorIs(t, cb.Execute(ctx, fn), context.Canceled)
+		assert.Equal(t, StateClosed, cb.State())
+		metrics := cb.Metrics()
+		assert.Zero(t, metrics.Requests)
+		assert.Zero(t, metrics.Failures)
+	})
+
+	t.Run("frees the half-open slot", func(t *testing.T) {
+		clock := time.Now()
+		cb := NewCircuitBreaker(tripOnFirst).(*circuitBreaker)
+		cb.now = func() time.Time { return clock }
+		cb.Trip()
+		clock = clock.Add(time.Minute)
+
+		ctx, fn := canceled(context.Background())
+		require.ErrorIs(t, cb.Execute(ctx, fn), context.Canceled)
+		assert.Equal(t, StateHalfOpen, cb.State())
+		require.NoError(t, cb.Execute(context.Background(), func(ctx context.Context) error { return nil }))
+		assert.Equal(t, StateClosed, cb.State())
+	})
+
+	t.Run("Timeout policy expiring is still a failure", func(t *testing.T) {
+		cb := NewCircuitBreaker(tripOnFirst)
+		ctx, cancel := context.WithTimeoutCause(context.Background(), time.Millisecond, ErrTimeout)
+		defer cancel()
+
+		err := cb.Execute(ctx, func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, StateOpen, cb.State())
+	})
+
+	t.Run("CountCallerCancellation records a failure", func(t *testing.T) {
+		config := tripOnFirst
+		config.CountCallerCancellation = true
+		cb := NewCircuitBreaker(config)
+		ctx, fn := canceled(context.Background())
+
+		require.ErrorIs(t, cb.Execute(ctx, fn), context.Canceled)
+		assert.Equal(t, StateOpen, cb.State())
+	})
+}
+
+func TestCircuitBreakerDegraded(t *testing.T) {
+	fail := func(ctx context.Context) error { return errors.New("error") }
+	succeed := func(ctx context.Context) error { return nil }
+	priority := WithHighPriority(context.Background())
+	newBreaker := func() CircuitBreaker {
+		return NewCircuitBreaker(CircuitBreakerConfig{
+			Name:              "test",
+			MinRequests:       4,
+			FailureThreshold:  0.75,
+			DegradedThreshold: 0.5,
+		})
+	}
+
+	t.Run("sheds best-effort calls below the trip condition", func(t *testing.T) {
+		cb := newBreaker()
+		_ = cb.Execute(priority, succeed)
+		_ = cb.Execute(priority, succeed)
+		_ = cb.Execute(priority, fail)
+		assert.Equal(t, StateClosed, cb.State())
+		_ = cb.Execute(priority, fail)
+		assert.Equal(t, StateDegraded, cb.State())
+
+		err := cb.Execute(context.Background(), succeed)
+		var openErr *CircuitOpenError
+		require.ErrorAs(t, err, &openErr)
+		assert.ErrorIs(t, err, ErrCircuitOpen)
+		assert.Equal(t, StateDegraded, openErr.State)
+		assert.NoError(t, cb.Execute(priority, succeed))
+	})
+
+	t.Run("opens when the trip condition is met", func(t *testing.T) {
+		cb := newBreaker()
+		_ = cb.Execute(priority, succeed)
+		for i := 0; i < 3; i++ {
+			_ = cb.Execute(priority, fail)
+		}
+		assert.Equal(t, StateOpen, cb.State())
+	})
+
+	t.Run("closes once the failure ratio recovers", func(t *testing.T) {
+		cb := newBreaker()
+		_ = cb.Execute(priority, succeed)
+		_ = cb.Execute(priority, succeed)
+		_ = cb.Execute(priority, fail)
+		_ = cb.Execute(priority, fail)
+		require.Equal(t, StateDegraded, cb.State())
+
+		_ = cb.Execute(priority, succeed)
+		assert.Equal(t, StateClosed, cb.State())
+		assert.NoError(t, cb.Execute(context.Background(), succeed))
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{Name: "test", MinRequests: 4, FailureThreshold: 0.75})
+		for i := 0; i < 2; i++ {
+			_ = cb.Execute(context.Background(), succeed)
+			_ = cb.Execute(context.Background(), fail)
+		}
+		assert.Equal(t, StateClosed, cb.State())
+	})
+}
+
+func TestCircuitBreakerTripPolicy(t *testing.T) {
+	ctx := context.Background()
+	fail := func(ctx context.Context) error { return errors.New("error") }
+
+	t.Run("replaces the trip strategy", func(t *testing.T) {
+		var seen []Counts
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name:        "test",
+			MinRequests: 100,
+			TripPolicy: TripPolicyFunc(func(counts Counts, window time.Duration) bool {
+				seen = append(seen, counts)
+				return counts.ConsecutiveFailures >= 2
+			}),
+		})
+
+		_ = cb.Execute(ctx, fail)
+		assert.Equal(t, StateClosed, cb.State())
+		_ = cb.Execute(ctx, fail)
+		assert.Equal(t, StateOpen, cb.State())
+		require.Len(t, seen, 2)
+		assert.Equal(t, Counts{Requests: 2, Failures: 2, ConsecutiveFailures: 2}, seen[1])
+	})
+
+	t.Run("receives the window the counts cover", func(t *testing.T) {
+		clock := time.Now()
+		var window time.Duration
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name: "test",
+			TripPolicy: TripPolicyFunc(func(counts Counts, w time.Duration) bool {
+				window = w
+				return false
+			}),
+		}).(*circuitBreaker)
+		cb.now = func() time.Time { return clock }
+		cb.stateTime = clock
+
+		clock = clock.Add(10 * time.Second)
+		_ = cb.Execute(ctx, fail)
+		assert.Equal(t, 10*time.Second, window)
+	})
+}
+
+func TestCircuitBreakerHistory(t *testing.T) {
+	ctx := context.Background()
+	fail := func(ctx context.Context) error { return errors.New("error") }
+
+	t.Run("records transitions with their counts", func(t *testing.T) {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name:                "test",
+			TripStrategy:        TripStrategyConsecutive,
+			ConsecutiveFailures: 2,
+		})
+		assert.Empty(t, cb.History())
+
+		_ = cb.Execute(ctx, fail)
+		_ = cb.Execute(ctx, fail)
+		cb.Reset()
+
+		history := cb.History()
+		require.Len(t, history, 2)
+		assert.Equal(t, StateClosed, history[0].From)
+		assert.Equal(t, StateOpen, history[0].To)
+		assert.Equal(t, Counts{Requests: 2, Failures: 2, ConsecutiveFailures: 2}, history[0].Counts)
+		assert.Equal(t, StateOpen, history[1].From)
+		assert.Equal(t, StateClosed, history[1].To)
+		assert.False(t, history[1].Time.Before(history[0].Time))
+	})
+
+	t.Run("keeps the last HistorySize transitions", func(t *testing.T) {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{Name: "test", HistorySize: 3})
+		cb.Trip()
+		cb.Reset()
+		cb.Trip()
+		cb.Disable()
+
+		history := cb.History()
+		require.Len(t, history, 3)
+		assert.Equal(t, []CircuitState{StateOpen, StateClosed, StateOpen}, []CircuitState{history[0].From, history[1].From, history[2].From})
+		assert.Equal(t, StateDisabled, history[2].To)
+	})
+}
+
+func TestCircuitBreakerSlowStart(t *testing.T) {
+	ctx := context.Background()
+	ok := func(ctx context.Context) error { return nil }
+	fail := func(ctx context.Context) error { return errors.New("error") }
+	admitted := func(cb CircuitBreaker, calls int) int {
+		n := 0
+		for i := 0; i < calls; i++ {
+			if cb.Execute(ctx, ok) == nil {
+				n++
+			}
+		}
+		return n
+	}
+
+	newRecovered := func(t *testing.T) (*circuitBreaker, *time.Time) {
+		clock := time.Now()
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name:                "test",
+			MaxRequests:         1,
+			Interval:            time.Hour,
+			Timeout:             time.Second,
+			TripStrategy:        TripStrategyConsecutive,
+			ConsecutiveFailures: 1,
+			SlowStartDuration:   10 * time.Second,
+		}).(*circuitBreaker)
+		cb.now = func() time.Time { return clock }
+
+		_ = cb.Execute(ctx, fail)
+		clock = clock.Add(2 * time.Second)
+		require.NoError(t, cb.Execute(ctx, ok))
+		require.Equal(t, StateClosed, cb.State())
+		return cb, &clock
+	}
+
+	t.Run("ramps admitted traffic after recovery", func(t *testing.T) {
+		cb, clock := newRecovered(t)
+
+		n := admitted(cb, 1000)
+		assert.InDelta(t, 100, n, 60, "starts at SlowStartMinShare")
+
+		*clock = clock.Add(5 * time.Second)
+		assert.InDelta(t, 550, admitted(cb, 1000), 100)
+
+		*clock = clock.Add(5 * time.Second)
+		assert.Equal(t, 1000, admitted(cb, 1000))
+		assert.True(t, cb.gen.Load().fast.Load(), "fast path restored")
+		assert.Equal(t, StateClosed, cb.State())
+	})
+
+	t.Run("does not slow start after Reset", func(t *testing.T) {
+		cb, _ := newRecovered(t)
+		cb.Trip()
+		cb.Reset()
+		assert.Equal(t, 100, admitted(cb, 100))
+	})
+}
+
+func TestCircuitBreakerCallWeight(t *testing.T) {
+	ctx := context.Background()
+	ok := func(ctx context.Context) error { return nil }
+	fail := func(ctx context.Context) error { return errors.New("error") }
+
+	t.Run("defaults to 1", func(t *testing.T) {
+		assert.Equal(t, 1, CallWeightFromContext(ctx))
+		assert.Equal(t, 1, CallWeightFromContext(WithCallWeight(ctx, 0)))
+		assert.Equal(t, 50, CallWeightFromContext(WithCallWeight(ctx, 50)))
+	})
+
+	t.Run("weighs outcomes in the failure ratio", func(t *testing.T) {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name:             "test",
+			MinRequests:      10,
+			FailureThreshold: 0.5,
+		})
+
+		require.NoError(t, cb.Execute(WithCallWeight(ctx, 20), ok))
+		for i := 0; i < 9; i++ {
+			_ = cb.Execute(ctx, fail)
+		}
+		assert.Equal(t, StateClosed, cb.State(), "9 of 29 failed")
+		assert.Equal(t, uint32(29), cb.Metrics().Requests)
+
+		_ = cb.Execute(WithCallWeight(ctx, 20), fail)
+		assert.Equal(t, StateOpen, cb.State(), "29 of 49 failed")
+	})
+
+	t.Run("weighs outcomes in the sliding window", func(t *testing.T) {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name:             "test",
+			MinRequests:      10,
+			FailureThreshold: 0.5,
+			WindowType:       WindowTypeSliding,
+		})
+
+		require.NoError(t, cb.Execute(WithCallWeight(ctx, math.MaxUint16), ok))
+		_ = cb.Execute(WithCallWeight(ctx, math.MaxUint16-1), fail)
+		m := cb.Metrics()
+		assert.Equal(t, StateClosed, m.State)
+		assert.Equal(t, uint32(2*math.MaxUint16-1), m.Requests)
+		assert.Equal(t, uint32(math.MaxUint16-1), m.Failures)
+
+		_ = cb.Execute(WithCallWeight(ctx, 2), fail)
+		assert.Equal(t, StateOpen, cb.State())
+	})
+
+	t.Run("counts half-open trials once", func(t *testing.T) {
+		clock := time.Now()
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name:                     "test",
+			MaxRequests:              3,
+			HalfOpenSuccessThreshold: 3,
+			Timeout:                  time.Second,
+		}).(*circuitBreaker)
+		cb.now = func() time.Time { return clock }
+		cb.Trip()
+
+		clock = clock.Add(2 * time.Second)
+		require.NoError(t, cb.Execute(WithCallWeight(ctx, 5), ok))
+		assert.Equal(t, StateHalfOpen, cb.State())
+		assert.Equal(t, uint32(1), cb.Metrics().Successes)
+	})
+}
+
+func TestCircuitBreakerAllowN(t *testing.T) {
+	newBreaker := func() CircuitBreaker {
+		return NewCircuitBreaker(CircuitBreakerConfig{
+			Name:             "test",
+			MinRequests:      10,
+			FailureThreshold: 0.5,
+			Timeout:          time.Hour,
+		})
+	}
+
+	t.Run("rejects invalid sizes", func(t *testing.T) {
+		_, err := newBreaker().AllowN(0)
+		assert.Error(t, err)
+		_, err = newBreaker().AllowN(math.MaxUint16 + 1)
+		assert.Error(t, err)
+	})
+
+	t.Run("drops outcomes beyond the batch size", func(t *testing.T) {
+		cb := newBreaker()
+		p, err := cb.AllowN(10)
+		require.NoError(t, err)
+		p.RecordBatch(math.MaxUint32, 4)
+
+		m := cb.Metrics()
+		assert.Equal(t, uint32(10), m.Requests)
+		assert.Equal(t, uint32(6), m.Successes)
+		assert.Equal(t, uint32(4), m.Failures)
+	})
+
+	t.Run("frees the slots of operations without an outcome", func(t *testing.T) {
+		cb := newBreaker()
+		p, err := cb.AllowN(10)
+		require.NoError(t, err)
+		p.RecordBatch(3, 0)
+		assert.Equal(t, uint32(3), cb.Metrics().Requests)
+	})
+
+	t.Run("records the aggregate outcome", func(t *testing.T) {
+		cb := newBreaker()
+		p, err := cb.AllowN(100)
+		require.NoError(t, err)
+		p.RecordBatch(60, 40)
+
+		m := cb.Metrics()
+		assert.Equal(t, StateClosed, m.State)
+		assert.Equal(t, uint32(100), m.Requests)
+		assert.Equal(t, uint32(60), m.Successes)
+		assert.Equal(t, uint32(40), m.Failures)
+
+		p, err = cb.AllowN(50)
+		require.NoError(t, err)
+		p.RecordBatch(0, 50)
+		assert.Equal(t, StateOpen, cb.State(), "90 of 150 failed")
+
+		_, err = cb.AllowN(1)
+		assert.ErrorIs(t, err, ErrCircuitOpen)
+	})
+
+	t.Run("counts the whole batch on RecordFailure", func(t *testing.T) {
+		cb := newBreaker()
+		p, err := cb.AllowN(10)
+		require.NoError(t, err)
+		p.RecordFailure()
+		assert.Equal(t, StateOpen, cb.State())
+	})
+
+	t.Run("releases empty batches", func(t *testing.T) {
+		cb := newBreaker()
+		p, err := cb.AllowN(10)
+		require.NoError(t, err)
+		p.RecordBatch(0, 0)
+		assert.Zero(t, cb.Metrics().Requests)
+	})
+
+	t.Run("counts a half-open batch as one trial per operation", func(t *testing.T) {
+		clock := NewFakeClock(time.Now())
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name:        "test",
+			MaxRequests: 10,
+			Timeout:     time.Second,
+			Clock:       clock,
+		})
+		cb.Trip()
+		clock.Advance(2 * time.Second)
+
+		_, err := cb.AllowN(11)
+		assert.ErrorIs(t, err, ErrCircuitOpen, "more than MaxRequests")
+		p, err := cb.AllowN(4)
+		require.NoError(t, err)
+		p.RecordBatch(3, 1)
+		assert.Equal(t, StateOpen, cb.State())
+
+		clock.Advance(time.Hour)
+		p, err = cb.AllowN(6)
+		require.NoError(t, err)
+		_, err = cb.AllowN(5)
+		assert.ErrorIs(t, err, ErrCircuitOpen, "only 4 trials left")
+		p.RecordBatch(6, 0)
+		assert.Equal(t, StateHalfOpen, cb.State())
+
+		p, err = cb.AllowN(4)
+		require.NoError(t, err)
+		p.RecordBatch(4, 0)
+		assert.Equal(t, StateClosed, cb.State())
+	})
+
+	t.Run("holds back large batches during slow start", func(t *testing.T) {
+		clock := NewFakeClock(time.Now())
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name:              "test",
+			MaxRequests:       1,
+			Timeout:           time.Second,
+			SlowStartDuration: time.Minute,
+			Clock:             clock,
+		})
+		cb.Trip()
+		clock.Advance(2 * time.Second)
+		require.NoError(t, cb.Execute(context.Background(), func(ctx context.Context) error { return nil }))
+		require.Equal(t, StateClosed, cb.State())
+
+		for i := 0; i < 100; i++ {
+			_, err := cb.AllowN(1000)
+			require.ErrorIs(t, err, ErrCircuitOpen)
+		}
+
+		clock.Advance(time.Minute)
+		_, err := cb.AllowN(1000)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,199 @@
+package resilience
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// systemClock is the Clock used when none is configured
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return systemTimer{time.AfterFunc(d, f)}
+}
+
+// systemTimer adapts a runtime timer to the Timer interface
+type systemTimer struct {
+	t *time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time {
+	return t.t.C
+}
+
+func (t systemTimer) Stop() bool {
+	return t.t.Stop()
+}
+
+func (t systemTimer) Reset(d time.Duration) bool {
+	return t.t.Reset(d)
+}
+
+// SystemClock returns the Clock reading the runtime's time, the one a nil
+// Clock stands for
+func SystemClock() Clock {
+	return systemClock{}
+}
+
+// clockOrSystem returns c, or the system clock if c is nil
+func clockOrSystem(c Clock) Clock {
+	if c == nil {
+		return systemClock{}
+	}
+	return c
+}
+
+// FakeClock is a Clock that only moves when told to, for tests that would
+// otherwise sleep. Timers fire in order of their deadlines during Advance;
+// AfterFunc functions run on the goroutine calling Advance.
+type FakeClock struct {
+	mu      sync.Mutex
+	changed *sync.Cond // signaled when timers are added
+	now     time.Time
+	timers  []*fakeTimer
+}
+
+// NewFakeClock creates a fake clock reading now
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.changed = sync.NewCond(&c.mu)
+	return c
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C()
+}
+
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{clock: c, ch: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	t := &fakeTimer{clock: c, fn: f}
+	t.Reset(d)
+	return t
+}
+
+// Advance moves the clock forward by d, firing the timers due on the way
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	end := c.now.Add(d)
+	for {
+		sort.SliceStable(c.timers, func(i, j int) bool {
+			return c.timers[i].when.Before(c.timers[j].when)
+		})
+		if len(c.timers) == 0 || c.timers[0].when.After(end) {
+			break
+		}
+		t := c.timers[0]
+		c.timers = c.timers[1:]
+		if t.when.After(c.now) {
+			c.now = t.when
+		}
+
+		// Fire without the lock so AfterFunc functions can use the clock
+		now := c.now
+		c.mu.Unlock()
+		t.fire(now)
+		c.mu.Lock()
+	}
+	c.now = end
+	c.mu.Unlock()
+}
+
+// BlockUntil waits until at least n timers are pending, e.g. until a
+// goroutine under test has started waiting, or ctx is done
+func (c *FakeClock) BlockUntil(ctx context.Context, n int) error {
+	stop := context.AfterFunc(ctx, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.changed.Broadcast()
+	})
+	defer stop()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.timers) < n {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		c.changed.Wait()
+	}
+	return nil
+}
+
+// fakeTimer is a timer of a FakeClock
+type fakeTimer struct {
+	clock *FakeClock
+	when  time.Time
+	ch    chan time.Time
+	fn    func()
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.remove()
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	active := t.remove()
+	t.when = c.now.Add(d)
+	c.timers = append(c.timers, t)
+	c.changed.Broadcast()
+	return active
+}
+
+// remove unschedules the timer, reporting whether it was pending. The
+// caller holds the clock's lock.
+func (t *fakeTimer) remove() bool {
+	for i, other := range t.clock.timers {
+		if other == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// fire delivers the time to the channel, or runs the function
+func (t *fakeTimer) fire(now time.Time) {
+	if t.fn != nil {
+		t.fn()
+		return
+	}
+	select {
+	case t.ch <- now:
+	default:
+	}
+}
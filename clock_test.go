@@ -0,0 +1,124 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("moves only when advanced", func(t *testing.T) {
+		clock := NewFakeClock(start)
+		assert.Equal(t, start, clock.Now())
+		clock.Advance(time.Minute)
+		assert.Equal(t, start.Add(time.Minute), clock.Now())
+	})
+
+	t.Run("fires due timers in order", func(t *testing.T) {
+		clock := NewFakeClock(start)
+		var fired []time.Time
+		clock.AfterFunc(2*time.Second, func() { fired = append(fired, clock.Now()) })
+		clock.AfterFunc(time.Second, func() { fired = append(fired, clock.Now()) })
+		after := clock.After(3 * time.Second)
+
+		clock.Advance(1500 * time.Millisecond)
+		assert.Equal(t, []time.Time{start.Add(time.Second)}, fired)
+
+		clock.Advance(5 * time.Second)
+		assert.Equal(t, []time.Time{start.Add(time.Second), start.Add(2 * time.Second)}, fired)
+		assert.Equal(t, start.Add(3*time.Second), <-after)
+	})
+
+	t.Run("stops and resets timers", func(t *testing.T) {
+		clock := NewFakeClock(start)
+		timer := clock.NewTimer(time.Second)
+		assert.True(t, timer.Stop())
+		assert.False(t, timer.Stop())
+
+		clock.Advance(time.Second)
+		select {
+		case <-timer.C():
+			t.Fatal("stopped timer fired")
+		default:
+		}
+
+		assert.False(t, timer.Reset(time.Second))
+		clock.Advance(time.Second)
+		assert.Equal(t, start.Add(2*time.Second), <-timer.C())
+	})
+
+	t.Run("blocks until timers are pending", func(t *testing.T) {
+		clock := NewFakeClock(start)
+		go clock.After(time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		require.NoError(t, clock.BlockUntil(ctx, 1))
+
+		ctx, cancel = context.WithCancel(context.Background())
+		cancel()
+		assert.ErrorIs(t, clock.BlockUntil(ctx, 2), context.Canceled)
+	})
+}
+
+func TestClockInjection(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("retry backs off on the clock", func(t *testing.T) {
+		clock := NewFakeClock(time.Now())
+		r := NewRetry(RetryConfig{
+			Name:            "test",
+			MaxAttempts:     2,
+			InitialInterval: time.Hour,
+			MaxInterval:     time.Hour,
+			Multiplier:      1,
+			Clock:           clock,
+		})
+
+		attempts := 0
+		done := make(chan error, 1)
+		go func() {
+			done <- r.Execute(ctx, func(ctx context.Context) error {
+				attempts++
+				return errors.New("error")
+			})
+		}()
+
+		waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		require.NoError(t, clock.BlockUntil(waitCtx, 1))
+		clock.Advance(2 * time.Hour)
+		assert.Error(t, <-done)
+		assert.Equal(t, 2, attempts)
+	})
+
+	t.Run("timeout expires on the clock", func(t *testing.T) {
+		clock := NewFakeClock(time.Now())
+		timeout := NewTimeoutWithConfig(TimeoutConfig{Duration: time.Hour, Clock: clock}, "test")
+
+		done := make(chan error, 1)
+		go func() {
+			done <- timeout.Execute(ctx, func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			})
+		}()
+
+		waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		require.NoError(t, clock.BlockUntil(waitCtx, 1))
+		clock.Advance(time.Hour)
+		assert.ErrorIs(t, <-done, ErrTimeout)
+	})
+
+	t.Run("timeout does not expire early", func(t *testing.T) {
+		clock := NewFakeClock(time.Now())
+		timeout := NewTimeoutWithConfig(TimeoutConfig{Duration: time.Hour, Clock: clock}, "test")
+		assert.NoError(t, timeout.Execute(ctx, func(ctx context.Context) error { return nil }))
+	})
+}
@@ -0,0 +1,420 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// resilienceImport is the import path of the resilience package
+const resilienceImport = "github.com/gostratum/resiliencex"
+
+// Options configures code generation
+type Options struct {
+	// Dir is the directory of the package declaring the interface
+	Dir string
+
+	// Type is the interface name
+	Type string
+
+	// Policies maps method names to policy (executor) names
+	Policies map[string]string
+
+	// DefaultPolicy is used for methods missing from Policies; methods
+	// without any policy call the wrapped implementation directly
+	DefaultPolicy string
+
+	// Exclude is a file name to skip when parsing, typically the output
+	Exclude string
+}
+
+// method is an interface method to generate
+type method struct {
+	name    string
+	params  []param
+	results []string
+	policy  string
+	wrap    bool
+}
+
+// param is a method parameter
+type param struct {
+	name     string
+	typ      string
+	variadic bool
+}
+
+// reserved are identifiers used by generated method bodies
+var reserved = map[string]bool{
+	"c": true, "ctx": true, "err": true, "result": true, "results": true, "out": true,
+}
+
+var resultName = regexp.MustCompile(`^r[0-9]+$`)
+
+// Generate returns the formatted source of a resilient decorator for the
+// interface described by opts
+func Generate(opts Options) ([]byte, error) {
+	fset := token.NewFileSet()
+	files, pkgName, err := parsePackage(fset, opts.Dir, opts.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{fset: fset, files: files, imports: map[string]string{}}
+	methods, err := g.interfaceMethods(opts.Type, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	for i := range methods {
+		seen[methods[i].name] = true
+		methods[i].policy = opts.Policies[methods[i].name]
+		if methods[i].policy == "" {
+			methods[i].policy = opts.DefaultPolicy
+		}
+		methods[i].wrap = methods[i].wrap && methods[i].policy != ""
+	}
+	for name := range opts.Policies {
+		if !seen[name] {
+			return nil, fmt.Errorf("policy for unknown method %s.%s", opts.Type, name)
+		}
+	}
+
+	src := g.render(pkgName, opts.Type, methods)
+	formatted, err := format.Source(src)
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w\n%s", err, src)
+	}
+	return formatted, nil
+}
+
+// parsePackage parses the non-test Go files of dir
+func parsePackage(fset *token.FileSet, dir, exclude string) ([]*ast.File, string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var files []*ast.File
+	pkgName := ""
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") || name == exclude {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, "", err
+		}
+		if pkgName == "" {
+			pkgName = file.Name.Name
+		}
+		if file.Name.Name == pkgName {
+			files = append(files, file)
+		}
+	}
+	if len(files) == 0 {
+		return nil, "", fmt.Errorf("no Go files in %s", dir)
+	}
+	return files, pkgName, nil
+}
+
+// generator renders decorator source
+type generator struct {
+	fset    *token.FileSet
+	files   []*ast.File
+	imports map[string]string // package name -> import path used by signatures
+}
+
+// lookupInterface finds the named interface and the file declaring it
+func (g *generator) lookupInterface(name string) (*ast.InterfaceType, *ast.File, error) {
+	for _, file := range g.files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if ts.Name.Name != name {
+					continue
+				}
+				if ts.TypeParams != nil {
+					return nil, nil, fmt.Errorf("%s: generic interfaces are not supported", name)
+				}
+				iface, ok := ts.Type.(*ast.InterfaceType)
+				if !ok {
+					return nil, nil, fmt.Errorf("%s is not an interface", name)
+				}
+				return iface, file, nil
+			}
+		}
+	}
+	return nil, nil, fmt.Errorf("interface %s not found", name)
+}
+
+// interfaceMethods returns the methods of the named interface, including
+// those of interfaces it embeds from the same package
+func (g *generator) interfaceMethods(name string, visiting map[string]bool) ([]method, error) {
+	if visiting[name] {
+		return nil, fmt.Errorf("%s embeds itself", name)
+	}
+	visiting[name] = true
+	defer delete(visiting, name)
+
+	iface, file, err := g.lookupInterface(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var methods []method
+	for _, field := range iface.Methods.List {
+		switch t := field.Type.(type) {
+		case *ast.FuncType:
+			for _, n := range field.Names {
+				m, err := g.method(n.Name, t, file)
+				if err != nil {
+					return nil, fmt.Errorf("%s.%s: %w", name, n.Name, err)
+				}
+				methods = append(methods, m)
+			}
+		case *ast.Ident:
+			embedded, err := g.interfaceMethods(t.Name, visiting)
+			if err != nil {
+				return nil, err
+			}
+			methods = append(methods, embedded...)
+		default:
+			return nil, fmt.Errorf("%s: embedded %s is not supported", name, g.expr(field.Type))
+		}
+	}
+	return methods, nil
+}
+
+// method converts an interface method declaration
+func (g *generator) method(name string, fn *ast.FuncType, file *ast.File) (method, error) {
+	if err := g.collectImports(fn, file); err != nil {
+		return method{}, err
+	}
+
+	m := method{name: name}
+	i := 0
+	for _, field := range fn.Params.List {
+		names := field.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{nil}
+		}
+		for _, n := range names {
+			p := param{typ: g.expr(field.Type)}
+			if ell, ok := field.Type.(*ast.Ellipsis); ok {
+				p.variadic = true
+				p.typ = "..." + g.expr(ell.Elt)
+			}
+			switch {
+			case i == 0:
+				p.name = "ctx"
+			case n == nil || n.Name == "_" || reserved[n.Name] || resultName.MatchString(n.Name):
+				p.name = "p" + strconv.Itoa(i)
+			default:
+				p.name = n.Name
+			}
+			m.params = append(m.params, p)
+			i++
+		}
+	}
+
+	if fn.Results != nil {
+		for _, field := range fn.Results.List {
+			count := len(field.Names)
+			if count == 0 {
+				count = 1
+			}
+			for j := 0; j < count; j++ {
+				m.results = append(m.results, g.expr(field.Type))
+			}
+		}
+	}
+
+	m.wrap = len(m.params) > 0 && m.params[0].typ == "context.Context" &&
+		len(m.results) > 0 && m.results[len(m.results)-1] == "error"
+	return m, nil
+}
+
+// collectImports records the imports of file used by a signature
+func (g *generator) collectImports(fn *ast.FuncType, file *ast.File) error {
+	var err error
+	ast.Inspect(fn, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		pkg, ok := sel.X.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		importPath, found := fileImport(file, pkg.Name)
+		if !found {
+			err = fmt.Errorf("no import for package %s", pkg.Name)
+			return false
+		}
+		if existing, dup := g.imports[pkg.Name]; dup && existing != importPath {
+			err = fmt.Errorf("package name %s refers to both %s and %s", pkg.Name, existing, importPath)
+			return false
+		}
+		g.imports[pkg.Name] = importPath
+		return false
+	})
+	return err
+}
+
+// fileImport returns the path file imports under name
+func fileImport(file *ast.File, name string) (string, bool) {
+	for _, spec := range file.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		local := importName(importPath)
+		if spec.Name != nil {
+			local = spec.Name.Name
+		}
+		if local == name {
+			return importPath, true
+		}
+	}
+	return "", false
+}
+
+// importName guesses the package name of an import path
+func importName(importPath string) string {
+	base := path.Base(importPath)
+	if len(base) > 1 && base[0] == 'v' && strings.Trim(base[1:], "0123456789") == "" {
+		base = path.Base(path.Dir(importPath))
+	}
+	return base
+}
+
+// isStdImport reports whether importPath is in the standard library
+func isStdImport(importPath string) bool {
+	first, _, _ := strings.Cut(importPath, "/")
+	return !strings.Contains(first, ".")
+}
+
+// expr prints a type expression
+func (g *generator) expr(e ast.Expr) string {
+	var buf bytes.Buffer
+	_ = printer.Fprint(&buf, g.fset, e)
+	return buf.String()
+}
+
+// render writes the decorator source
+func (g *generator) render(pkgName, typeName string, methods []method) []byte {
+	impl := "resilient" + typeName
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "// Code generated by resiliencegen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", pkgName)
+
+	g.imports["context"] = "context"
+	g.imports["resilience"] = resilienceImport
+	names := make([]string, 0, len(g.imports))
+	for name := range g.imports {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return g.imports[names[i]] < g.imports[names[j]] })
+	b.WriteString("import (\n")
+	for _, std := range []bool{true, false} {
+		for _, name := range names {
+			importPath := g.imports[name]
+			if isStdImport(importPath) != std {
+				continue
+			}
+			if importName(importPath) == name {
+				fmt.Fprintf(&b, "\t%q\n", importPath)
+			} else {
+				fmt.Fprintf(&b, "\t%s %q\n", name, importPath)
+			}
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(")\n\n")
+
+	fmt.Fprintf(&b, "// %s runs %s methods through resilience executors\n", impl, typeName)
+	fmt.Fprintf(&b, "type %s struct {\n\tnext %s\n\tpolicies resilience.Registry\n\topts []resilience.CallOption\n}\n\n", impl, typeName)
+
+	fmt.Fprintf(&b, "// NewResilient%s wraps next so each method runs through the executor\n", typeName)
+	fmt.Fprintf(&b, "// for its policy from policies, passing opts to every call\n")
+	fmt.Fprintf(&b, "func NewResilient%s(next %s, policies resilience.Registry, opts ...resilience.CallOption) %s {\n", typeName, typeName, typeName)
+	fmt.Fprintf(&b, "\treturn &%s{next: next, policies: policies, opts: opts}\n}\n", impl)
+
+	for _, m := range methods {
+		b.WriteString("\n")
+		g.renderMethod(&b, impl, m)
+	}
+	return b.Bytes()
+}
+
+// renderMethod writes one decorator method
+func (g *generator) renderMethod(b *bytes.Buffer, impl string, m method) {
+	params := make([]string, len(m.params))
+	args := make([]string, len(m.params))
+	for i, p := range m.params {
+		params[i] = p.name + " " + p.typ
+		args[i] = p.name
+		if p.variadic {
+			args[i] += "..."
+		}
+	}
+	results := strings.Join(m.results, ", ")
+	if len(m.results) > 1 {
+		results = "(" + results + ")"
+	}
+	call := fmt.Sprintf("c.next.%s(%s)", m.name, strings.Join(args, ", "))
+
+	fmt.Fprintf(b, "func (c *%s) %s(%s) %s {\n", impl, m.name, strings.Join(params, ", "), results)
+	defer b.WriteString("}\n")
+
+	if !m.wrap {
+		if len(m.results) == 0 {
+			fmt.Fprintf(b, "\t%s\n", call)
+		} else {
+			fmt.Fprintf(b, "\treturn %s\n", call)
+		}
+		return
+	}
+
+	executor := fmt.Sprintf("c.policies.Executor(%q)", m.policy)
+	values := m.results[:len(m.results)-1]
+	if len(values) == 0 {
+		fmt.Fprintf(b, "\treturn %s.Execute(ctx, func(ctx context.Context) error {\n", executor)
+		fmt.Fprintf(b, "\t\treturn %s\n", call)
+		fmt.Fprintf(b, "\t}, c.opts...)\n")
+		return
+	}
+
+	names := make([]string, len(values))
+	fields := make([]string, len(values))
+	outs := make([]string, len(values))
+	for i, typ := range values {
+		names[i] = "r" + strconv.Itoa(i)
+		fields[i] = names[i] + " " + typ
+		outs[i] = "out." + names[i]
+	}
+
+	fmt.Fprintf(b, "\ttype results struct {\n\t\t%s\n\t}\n", strings.Join(fields, "\n\t\t"))
+	fmt.Fprintf(b, "\tresult, err := %s.ExecuteWithResult(ctx, func(ctx context.Context) (any, error) {\n", executor)
+	fmt.Fprintf(b, "\t\t%s, err := %s\n", strings.Join(names, ", "), call)
+	fmt.Fprintf(b, "\t\treturn results{%s}, err\n", strings.Join(names, ", "))
+	fmt.Fprintf(b, "\t}, c.opts...)\n")
+	fmt.Fprintf(b, "\tout, _ := result.(results)\n")
+	fmt.Fprintf(b, "\treturn %s, err\n", strings.Join(outs, ", "))
+}
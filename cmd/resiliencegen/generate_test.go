@@ -0,0 +1,106 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Run("matches the checked-in example", func(t *testing.T) {
+		dir := filepath.Join("internal", "example")
+		src, err := Generate(Options{
+			Dir:           dir,
+			Type:          "Client",
+			Policies:      map[string]string{"Put": "writes"},
+			DefaultPolicy: "reads",
+			Exclude:       "client_resilient.go",
+		})
+		require.NoError(t, err)
+
+		golden, err := os.ReadFile(filepath.Join(dir, "client_resilient.go"))
+		require.NoError(t, err)
+		assert.Equal(t, string(golden), string(src), "run go generate ./cmd/resiliencegen/internal/example")
+	})
+
+	t.Run("calls methods without a policy directly", func(t *testing.T) {
+		src, err := Generate(Options{
+			Dir:     filepath.Join("internal", "example"),
+			Type:    "Reader",
+			Exclude: "client_resilient.go",
+		})
+		require.NoError(t, err)
+
+		assert.Contains(t, string(src), "return c.next.Get(ctx, id)")
+		assert.NotContains(t, string(src), "c.policies.Executor(")
+	})
+
+	t.Run("renames parameters that clash with generated code", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, dir, "svc.go", `package svc
+
+import "context"
+
+type Service interface {
+	Do(_ context.Context, err string, c int, r0 bool, _ string) error
+}
+`)
+		src, err := Generate(Options{Dir: dir, Type: "Service", DefaultPolicy: "svc"})
+		require.NoError(t, err)
+
+		assert.Contains(t, string(src), "Do(ctx context.Context, p1 string, p2 int, p3 bool, p4 string) error")
+		assert.Contains(t, string(src), "c.next.Do(ctx, p1, p2, p3, p4)")
+	})
+
+	t.Run("reports unsupported input", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, dir, "svc.go", `package svc
+
+import (
+	"context"
+	"io"
+)
+
+type Service interface {
+	io.Closer
+	Do(ctx context.Context) error
+}
+
+type Generic[T any] interface {
+	Get(ctx context.Context) (T, error)
+}
+
+type NotInterface struct{}
+`)
+
+		_, err := Generate(Options{Dir: dir, Type: "Service"})
+		assert.ErrorContains(t, err, "embedded io.Closer is not supported")
+
+		_, err = Generate(Options{Dir: dir, Type: "Generic"})
+		assert.ErrorContains(t, err, "generic interfaces are not supported")
+
+		_, err = Generate(Options{Dir: dir, Type: "NotInterface"})
+		assert.ErrorContains(t, err, "is not an interface")
+
+		_, err = Generate(Options{Dir: dir, Type: "Missing"})
+		assert.ErrorContains(t, err, "not found")
+	})
+
+	t.Run("rejects policies for unknown methods", func(t *testing.T) {
+		_, err := Generate(Options{
+			Dir:      filepath.Join("internal", "example"),
+			Type:     "Client",
+			Policies: map[string]string{"Patch": "writes"},
+			Exclude:  "client_resilient.go",
+		})
+		assert.ErrorContains(t, err, "unknown method Client.Patch")
+	})
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+}
@@ -0,0 +1,32 @@
+// Package example is a client used to test resiliencegen output
+package example
+
+import (
+	"context"
+	"time"
+)
+
+//go:generate go run github.com/gostratum/resiliencex/cmd/resiliencegen -type Client -policy Put=writes -default reads
+
+// Reader reads items
+type Reader interface {
+	// Get returns the item with the given id
+	Get(ctx context.Context, id string) (string, error)
+
+	// List returns items changed since a time
+	List(ctx context.Context, since time.Time, limit int) ([]string, int, error)
+}
+
+// Client is a key-value store client
+type Client interface {
+	Reader
+
+	// Put stores an item
+	Put(ctx context.Context, id, value string) error
+
+	// Delete removes items
+	Delete(ctx context.Context, ids ...string) error
+
+	// Close releases the client
+	Close()
+}
@@ -0,0 +1,64 @@
+// Code generated by resiliencegen. DO NOT EDIT.
+
+package example
+
+import (
+	"context"
+	"time"
+
+	resilience "github.com/gostratum/resiliencex"
+)
+
+// resilientClient runs Client methods through resilience executors
+type resilientClient struct {
+	next     Client
+	policies resilience.Registry
+	opts     []resilience.CallOption
+}
+
+// NewResilientClient wraps next so each method runs through the executor
+// for its policy from policies, passing opts to every call
+func NewResilientClient(next Client, policies resilience.Registry, opts ...resilience.CallOption) Client {
+	return &resilientClient{next: next, policies: policies, opts: opts}
+}
+
+func (c *resilientClient) Get(ctx context.Context, id string) (string, error) {
+	type results struct {
+		r0 string
+	}
+	result, err := c.policies.Executor("reads").ExecuteWithResult(ctx, func(ctx context.Context) (any, error) {
+		r0, err := c.next.Get(ctx, id)
+		return results{r0}, err
+	}, c.opts...)
+	out, _ := result.(results)
+	return out.r0, err
+}
+
+func (c *resilientClient) List(ctx context.Context, since time.Time, limit int) ([]string, int, error) {
+	type results struct {
+		r0 []string
+		r1 int
+	}
+	result, err := c.policies.Executor("reads").ExecuteWithResult(ctx, func(ctx context.Context) (any, error) {
+		r0, r1, err := c.next.List(ctx, since, limit)
+		return results{r0, r1}, err
+	}, c.opts...)
+	out, _ := result.(results)
+	return out.r0, out.r1, err
+}
+
+func (c *resilientClient) Put(ctx context.Context, id string, value string) error {
+	return c.policies.Executor("writes").Execute(ctx, func(ctx context.Context) error {
+		return c.next.Put(ctx, id, value)
+	}, c.opts...)
+}
+
+func (c *resilientClient) Delete(ctx context.Context, ids ...string) error {
+	return c.policies.Executor("reads").Execute(ctx, func(ctx context.Context) error {
+		return c.next.Delete(ctx, ids...)
+	}, c.opts...)
+}
+
+func (c *resilientClient) Close() {
+	c.next.Close()
+}
@@ -0,0 +1,97 @@
+package example
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	resilience "github.com/gostratum/resiliencex"
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyClient fails every call until failures run out
+type flakyClient struct {
+	failures int
+	calls    map[string]int
+}
+
+func (f *flakyClient) fail(method string) error {
+	f.calls[method]++
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("unavailable")
+	}
+	return nil
+}
+
+func (f *flakyClient) Get(ctx context.Context, id string) (string, error) {
+	if err := f.fail("Get"); err != nil {
+		return "", err
+	}
+	return "value-" + id, nil
+}
+
+func (f *flakyClient) List(ctx context.Context, since time.Time, limit int) ([]string, int, error) {
+	if err := f.fail("List"); err != nil {
+		return nil, 0, err
+	}
+	return []string{"a", "b"}, 2, nil
+}
+
+func (f *flakyClient) Put(ctx context.Context, id, value string) error {
+	return f.fail("Put")
+}
+
+func (f *flakyClient) Delete(ctx context.Context, ids ...string) error {
+	return f.fail("Delete")
+}
+
+func (f *flakyClient) Close() {
+	f.calls["Close"]++
+}
+
+func TestResilientClient(t *testing.T) {
+	policies := resilience.NewRegistry(func(name string) resilience.Executor {
+		attempts := 3
+		if name == "writes" {
+			attempts = 1
+		}
+		return resilience.NewBuilder().
+			WithName(name).
+			WithRetry(resilience.RetryConfig{
+				Name:            name,
+				MaxAttempts:     attempts,
+				InitialInterval: time.Millisecond,
+				MaxInterval:     time.Millisecond,
+				Multiplier:      1,
+			}).
+			Build()
+	})
+	ctx := context.Background()
+
+	t.Run("retries reads", func(t *testing.T) {
+		next := &flakyClient{failures: 2, calls: map[string]int{}}
+		client := NewResilientClient(next, policies)
+
+		items, n, err := client.List(ctx, time.Now(), 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, items)
+		assert.Equal(t, 2, n)
+		assert.Equal(t, 3, next.calls["List"])
+	})
+
+	t.Run("uses the write policy for Put", func(t *testing.T) {
+		next := &flakyClient{failures: 1, calls: map[string]int{}}
+		client := NewResilientClient(next, policies)
+
+		assert.Error(t, client.Put(ctx, "1", "x"))
+		assert.Equal(t, 1, next.calls["Put"])
+	})
+
+	t.Run("passes through methods without context", func(t *testing.T) {
+		next := &flakyClient{calls: map[string]int{}}
+		NewResilientClient(next, policies).Close()
+		assert.Equal(t, 1, next.calls["Close"])
+	})
+}
@@ -0,0 +1,76 @@
+// Command resiliencegen generates typed resilient decorators for interfaces.
+//
+// It is meant to run from go:generate in the package declaring the interface:
+//
+//	//go:generate go run github.com/gostratum/resiliencex/cmd/resiliencegen -type PaymentsClient -policy Charge=payments-write -default payments-read
+//
+// The generated NewResilientPaymentsClient(next, policies, opts...) returns a
+// PaymentsClient whose methods run through the executor named by their
+// policy, taken from a resilience.Registry. Methods must take a
+// context.Context first and return an error last to be wrapped; methods
+// without a policy, or with another signature, call next directly.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// policyFlag collects repeated -policy Method=name flags
+type policyFlag map[string]string
+
+func (p policyFlag) String() string {
+	pairs := make([]string, 0, len(p))
+	for method, policy := range p {
+		pairs = append(pairs, method+"="+policy)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (p policyFlag) Set(value string) error {
+	method, policy, ok := strings.Cut(value, "=")
+	if !ok || method == "" || policy == "" {
+		return fmt.Errorf("expected Method=policy, got %q", value)
+	}
+	p[method] = policy
+	return nil
+}
+
+func main() {
+	policies := policyFlag{}
+	typeName := flag.String("type", "", "interface to decorate (required)")
+	defaultPolicy := flag.String("default", "", "policy for methods without a -policy mapping")
+	output := flag.String("output", "", "output file (default <type>_resilient.go)")
+	dir := flag.String("dir", ".", "package directory containing the interface")
+	flag.Var(policies, "policy", "method policy as Method=name (repeatable)")
+	flag.Parse()
+
+	if *typeName == "" {
+		fmt.Fprintln(os.Stderr, "resiliencegen: -type is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *output == "" {
+		*output = strings.ToLower(*typeName) + "_resilient.go"
+	}
+
+	src, err := Generate(Options{
+		Dir:           *dir,
+		Type:          *typeName,
+		Policies:      policies,
+		DefaultPolicy: *defaultPolicy,
+		Exclude:       filepath.Base(*output),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "resiliencegen: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := os.WriteFile(filepath.Join(*dir, *output), src, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "resiliencegen: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -1,9 +1,8 @@
 package resilience
 
 import (
+	"fmt"
 	"time"
-
-	"github.com/gostratum/core/configx"
 )
 
 // Config represents the configuration for the resilience module
@@ -22,6 +21,21 @@ type Config struct {
 
 	// Timeout configuration
 	Timeout TimeoutConfig `mapstructure:"timeout"`
+
+	// Coordination configuration
+	Coordination CoordinationConfig `mapstructure:"coordination"`
+
+	// Hedge configuration
+	Hedge HedgeConfig `mapstructure:"hedge"`
+
+	// Anomaly detection configuration
+	Anomaly AnomalyConfig `mapstructure:"anomaly"`
+
+	// Metrics configuration
+	Metrics MetricsConfig `mapstructure:"metrics"`
+
+	// Advisor configuration
+	Advisor AdvisorConfig `mapstructure:"advisor"`
 }
 
 // Prefix returns the configuration prefix for resilience
@@ -29,6 +43,60 @@ func (Config) Prefix() string {
 	return "resilience"
 }
 
+// Circuit breaker window types
+const (
+	// WindowTypeFixed resets counts at the end of every interval
+	WindowTypeFixed = "fixed"
+
+	// WindowTypeSliding counts outcomes over a rolling interval
+	WindowTypeSliding = "sliding"
+)
+
+// Circuit breaker trip strategies
+const (
+	// TripStrategyRatio trips when the failure ratio reaches FailureThreshold
+	// after MinRequests requests
+	TripStrategyRatio = "ratio"
+
+	// TripStrategyConsecutive trips after ConsecutiveFailures failures in a row
+	TripStrategyConsecutive = "consecutive"
+
+	// TripStrategyAdaptive trips when the failure ratio exceeds the learned
+	// baseline failure rate by BaselineFactor after MinRequests requests
+	TripStrategyAdaptive = "adaptive"
+)
+
+// Circuit breaker trip modes
+const (
+	// TripModeBinary opens the circuit when the trip condition is met
+	TripModeBinary = "binary"
+
+	// TripModeProportional sheds calls in proportion to the failure rate
+	TripModeProportional = "proportional"
+)
+
+// Circuit breaker timeout modes
+const (
+	// TimeoutModeFailure counts timeouts as failures
+	TimeoutModeFailure = "failure"
+
+	// TimeoutModeDouble counts each timeout as two failed requests
+	TimeoutModeDouble = "double"
+
+	// TimeoutModeSeparate counts timeouts apart from failures and trips when
+	// their ratio reaches TimeoutThreshold
+	TimeoutModeSeparate = "separate"
+)
+
+// Circuit breaker half-open modes
+const (
+	// HalfOpenModeFixed admits MaxRequests trial requests
+	HalfOpenModeFixed = "fixed"
+
+	// HalfOpenModeRamp admits a growing share of traffic over RampDuration
+	HalfOpenModeRamp = "ramp"
+)
+
 // CircuitBreakerConfig configures circuit breaker behavior
 type CircuitBreakerConfig struct {
 	// Enabled determines if circuit breaker is enabled
@@ -37,15 +105,45 @@ type CircuitBreakerConfig struct {
 	// Name is the circuit breaker identifier
 	Name string `mapstructure:"name"`
 
+	// Disabled starts the breaker in StateDisabled, where it counts calls
+	// but never rejects them; unlike Enabled false, metrics keep flowing,
+	// so a new configuration can be canaried without false-positive opens
+	Disabled bool `mapstructure:"disabled"`
+
 	// MaxRequests is the max requests allowed in half-open state
 	MaxRequests uint32 `mapstructure:"max_requests"`
 
-	// Interval is the cyclic period in closed state for resetting counters
+	// HalfOpenSuccessThreshold is the number of successes in half-open
+	// state needed to close the circuit; zero uses MaxRequests
+	HalfOpenSuccessThreshold uint32 `mapstructure:"half_open_success_threshold"`
+
+	// Interval is the cyclic period in closed state for resetting counters;
+	// with the sliding window type it is the length of the window
 	Interval time.Duration `mapstructure:"interval"`
 
+	// WindowType selects how failures are counted in closed state: "fixed"
+	// (default) resets counts every Interval, "sliding" counts the outcomes
+	// of the last Interval continuously
+	WindowType string `mapstructure:"window_type"`
+
+	// WindowBuckets is the number of time buckets in a sliding window
+	WindowBuckets int `mapstructure:"window_buckets"`
+
 	// Timeout is the period of open state before transitioning to half-open
 	Timeout time.Duration `mapstructure:"timeout"`
 
+	// HalfOpenMode selects how half-open recovers: "fixed" (default) admits
+	// MaxRequests trial requests, "ramp" admits the RampSteps shares of
+	// traffic in turn over RampDuration and closes when the ramp completes
+	HalfOpenMode string `mapstructure:"half_open_mode"`
+
+	// RampDuration is how long the ramp lasts in ramp mode
+	RampDuration time.Duration `mapstructure:"ramp_duration"`
+
+	// RampSteps are the shares of traffic admitted during equal parts of
+	// the ramp, e.g. [0.05, 0.25, 1.0]
+	RampSteps []float64 `mapstructure:"ramp_steps"`
+
 	// ReadyToTrip determines when to trip the circuit to open state
 	// Circuit trips when failure ratio > threshold and request count > min requests
 	FailureThreshold float64 `mapstructure:"failure_threshold"`
@@ -53,23 +151,256 @@ type CircuitBreakerConfig struct {
 	// MinRequests is the minimum requests needed before checking failure ratio
 	MinRequests uint32 `mapstructure:"min_requests"`
 
-	// OnStateChange is called when state changes
+	// TripStrategy selects the built-in trip condition: "ratio" (default)
+	// uses FailureThreshold and MinRequests, "consecutive" trips after
+	// ConsecutiveFailures failures in a row, "adaptive" trips when the
+	// failure ratio exceeds the baseline failure rate by BaselineFactor
+	TripStrategy string `mapstructure:"trip_strategy"`
+
+	// ConsecutiveFailures is the number of failures in a row that trips the
+	// circuit with the consecutive strategy
+	ConsecutiveFailures uint32 `mapstructure:"consecutive_failures"`
+
+	// BaselineWindow is the trailing window over which the adaptive strategy
+	// learns the steady-state failure rate
+	BaselineWindow time.Duration `mapstructure:"baseline_window"`
+
+	// BaselineFactor is how many times the baseline failure rate the current
+	// failure ratio must reach to trip with the adaptive strategy
+	BaselineFactor float64 `mapstructure:"baseline_factor"`
+
+	// BaselineFloor is the lowest failure ratio the adaptive strategy trips
+	// at, so a dependency that never fails doesn't trip on one error
+	BaselineFloor float64 `mapstructure:"baseline_floor"`
+
+	// BaselineMinRequests is the number of requests the baseline window needs
+	// before it is trusted; until then FailureThreshold applies
+	BaselineMinRequests uint32 `mapstructure:"baseline_min_requests"`
+
+	// TripWhen is an optional expression that replaces the TripStrategy check,
+	// e.g. "failure_rate > 0.5 && slow_rate > 0.3"
+	// See CompileTripCondition for the supported syntax
+	TripWhen string `mapstructure:"trip_when"`
+
+	// SlowCallThreshold is the latency at which a call counts as slow for
+	// the slow_rate expression variable; 0 leaves slow_rate at 0
+	SlowCallThreshold time.Duration `mapstructure:"slow_call_threshold"`
+
+	// TripPolicy, when set, replaces TripStrategy and TripWhen; MinRequests
+	// is left to the policy
+	TripPolicy TripPolicy `mapstructure:"-"`
+
+	// TripMode selects what happens when the trip condition is met:
+	// "binary" (default) opens the circuit, "proportional" stays closed and
+	// sheds a share of calls equal to the failure rate (brownout)
+	TripMode string `mapstructure:"trip_mode"`
+
+	// ShedShare is an optional expression for the share of calls the
+	// proportional mode sheds instead of the failure rate, e.g.
+	// "failure_rate * 2"; the result is capped at 0.95. It takes the
+	// variables of TripWhen.
+	ShedShare string `mapstructure:"shed_share"`
+
+	// IsFailure classifies errors returned by wrapped functions; errors it
+	// returns false for are recorded as successes. Nil counts every error.
+	IsFailure IsFailure `mapstructure:"-"`
+
+	// TimeoutMode selects how failures that are timeouts (ErrTimeout or
+	// context.DeadlineExceeded) are counted: "failure" (default) like any
+	// failure, "double" as two failed requests, "separate" apart from
+	// failures with their own TimeoutThreshold
+	TimeoutMode string `mapstructure:"timeout_mode"`
+
+	// DegradedThreshold is a failure ratio below the trip condition at
+	// which the circuit degrades: calls marked WithHighPriority pass while
+	// best-effort calls are rejected. It closes again once the ratio falls
+	// below the threshold. Requires trip_mode binary (0 disables).
+	DegradedThreshold float64 `mapstructure:"degraded_threshold"`
+
+	// CountCallerCancellation records calls that fail because the caller's
+	// context was canceled or hit its deadline as failures. By default they
+	// are neutral: Execute records neither a success nor a failure, so our
+	// own cancellations don't count against the downstream.
+	CountCallerCancellation bool `mapstructure:"count_caller_cancellation"`
+
+	// TimeoutThreshold is the timeout ratio that trips the circuit after
+	// MinRequests requests in separate timeout mode
+	TimeoutThreshold float64 `mapstructure:"timeout_threshold"`
+
+	// OnStateChange is called when state changes. Calls are made in
+	// transition order from a background goroutine, so a slow callback does
+	// not hold up traffic through the breaker.
 	OnStateChange OnStateChange `mapstructure:"-"`
+
+	// SyncStateChange calls OnStateChange inline, before the call that
+	// caused the transition returns; intended for tests
+	SyncStateChange bool `mapstructure:"sync_state_change"`
+
+	// MetricsRecorder receives the time spent in each state and the number
+	// of openings per MetricsInterval, e.g. to alert on a flapping breaker
+	MetricsRecorder BreakerMetricsRecorder `mapstructure:"-"`
+
+	// MetricsInterval is the period over which openings are counted
+	MetricsInterval time.Duration `mapstructure:"metrics_interval"`
+
+	// MinOpenDuration is a floor on how long the circuit stays open: it
+	// extends a shorter Timeout and holds off closes from other instances
+	MinOpenDuration time.Duration `mapstructure:"min_open_duration"`
+
+	// OpenFlapThreshold damps flapping: once the circuit opens more than
+	// this many times within OpenFlapWindow, each further opening stays
+	// open OpenFlapBackoff times longer than the last, up to
+	// MaxOpenDuration (0 disables)
+	OpenFlapThreshold int `mapstructure:"open_flap_threshold"`
+
+	// OpenFlapWindow is the period over which openings are counted for
+	// damping
+	OpenFlapWindow time.Duration `mapstructure:"open_flap_window"`
+
+	// OpenFlapBackoff multiplies the open duration for each opening past
+	// OpenFlapThreshold
+	OpenFlapBackoff float64 `mapstructure:"open_flap_backoff"`
+
+	// MaxOpenDuration caps the open duration extended by damping
+	MaxOpenDuration time.Duration `mapstructure:"max_open_duration"`
+
+	// HistorySize is how many transitions History keeps
+	HistorySize int `mapstructure:"history_size"`
+
+	// WarmupPeriod is how long after creation the circuit collects stats
+	// but does not trip, riding out cold-start failures such as DNS warmup
+	// and connection pool fill (0 disables)
+	WarmupPeriod time.Duration `mapstructure:"warmup_period"`
+
+	// SlowStartDuration is how long after half-open closes the circuit the
+	// admitted share of traffic ramps from SlowStartMinShare to all of it,
+	// sparing a recovered downstream whose caches are still cold (0 disables)
+	SlowStartDuration time.Duration `mapstructure:"slow_start_duration"`
+
+	// SlowStartMinShare is the share of traffic admitted as slow start begins
+	SlowStartMinShare float64 `mapstructure:"slow_start_min_share"`
+
+	// HealthProbe, when set, decides when an open circuit tries half-open:
+	// after Timeout the next request starts the probe in the background
+	// and is rejected; success moves to half-open, failure keeps the circuit
+	// open for another Timeout. No user request is used as the first trial.
+	HealthProbe HealthProbe `mapstructure:"-"`
+
+	// ProbeTimeout bounds each HealthProbe call
+	ProbeTimeout time.Duration `mapstructure:"probe_timeout"`
+
+	// GroupName joins breakers sharing the name into a group that trips and
+	// recovers together: transitions are published under the group name,
+	// so their Coordinator (a GroupCoordinator in-process) opens every
+	// member when one opens
+	GroupName string `mapstructure:"group_name"`
+
+	// Coordinator shares trips with other instances (nil for local only).
+	// A CountCoordinator also aggregates failure counts across instances.
+	Coordinator Coordinator `mapstructure:"-"`
+
+	// CoordinationTimeout bounds calls to the Coordinator; when the store
+	// is slow or unreachable the breaker keeps deciding on local counts
+	CoordinationTimeout time.Duration `mapstructure:"coordination_timeout"`
+
+	// Clock tells time for intervals and open durations; nil uses the
+	// system clock
+	Clock Clock `mapstructure:"-"`
 }
 
 // DefaultCircuitBreakerConfig returns default circuit breaker configuration
 func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
 	return CircuitBreakerConfig{
-		Enabled:          true,
-		Name:             "default",
-		MaxRequests:      5,
-		Interval:         60 * time.Second,
-		Timeout:          30 * time.Second,
-		FailureThreshold: 0.6, // 60% failure rate
-		MinRequests:      10,
+		Enabled:             true,
+		Name:                "default",
+		MaxRequests:         5,
+		Interval:            60 * time.Second,
+		Timeout:             30 * time.Second,
+		FailureThreshold:    0.6, // 60% failure rate
+		MinRequests:         10,
+		TripStrategy:        TripStrategyRatio,
+		ConsecutiveFailures: 5,
+		BaselineWindow:      time.Hour,
+		BaselineFactor:      3,
+		BaselineFloor:       0.05, // 5% failure rate
+		BaselineMinRequests: 100,
+		TripMode:            TripModeBinary,
+		TimeoutMode:         TimeoutModeFailure,
+		TimeoutThreshold:    0.3, // 30% timeout rate
+		WindowType:          WindowTypeFixed,
+		WindowBuckets:       10,
+		HalfOpenMode:        HalfOpenModeFixed,
+		RampDuration:        30 * time.Second,
+		RampSteps:           []float64{0.05, 0.25, 1.0},
+		CoordinationTimeout: 100 * time.Millisecond,
+		ProbeTimeout:        5 * time.Second,
+		MetricsInterval:     time.Minute,
+		OpenFlapWindow:      time.Minute,
+		OpenFlapBackoff:     2,
+		MaxOpenDuration:     10 * time.Minute,
+		HistorySize:         32,
+		SlowStartMinShare:   0.1,
 	}
 }
 
+// Validate checks the circuit breaker configuration for errors
+func (c CircuitBreakerConfig) Validate() error {
+	switch c.TripStrategy {
+	case "", TripStrategyRatio, TripStrategyConsecutive, TripStrategyAdaptive:
+	default:
+		return fmt.Errorf("resilience: invalid trip_strategy %q", c.TripStrategy)
+	}
+	switch c.TripMode {
+	case "", TripModeBinary, TripModeProportional:
+	default:
+		return fmt.Errorf("resilience: invalid trip_mode %q", c.TripMode)
+	}
+	switch c.WindowType {
+	case "", WindowTypeFixed, WindowTypeSliding:
+	default:
+		return fmt.Errorf("resilience: invalid window_type %q", c.WindowType)
+	}
+	switch c.TimeoutMode {
+	case "", TimeoutModeFailure, TimeoutModeDouble, TimeoutModeSeparate:
+	default:
+		return fmt.Errorf("resilience: invalid timeout_mode %q", c.TimeoutMode)
+	}
+	if c.MaxRequests > 0 && c.HalfOpenSuccessThreshold > c.MaxRequests {
+		return fmt.Errorf("resilience: half_open_success_threshold %d exceeds max_requests %d",
+			c.HalfOpenSuccessThreshold, c.MaxRequests)
+	}
+	switch c.HalfOpenMode {
+	case "", HalfOpenModeFixed, HalfOpenModeRamp:
+	default:
+		return fmt.Errorf("resilience: invalid half_open_mode %q", c.HalfOpenMode)
+	}
+	for _, step := range c.RampSteps {
+		if step <= 0 || step > 1 {
+			return fmt.Errorf("resilience: ramp_steps must be in (0, 1], got %v", step)
+		}
+	}
+	if c.DegradedThreshold < 0 || c.DegradedThreshold >= 1 {
+		return fmt.Errorf("resilience: degraded_threshold must be in [0, 1), got %v", c.DegradedThreshold)
+	}
+	if c.SlowStartMinShare < 0 || c.SlowStartMinShare > 1 {
+		return fmt.Errorf("resilience: slow_start_min_share must be in (0, 1], got %v", c.SlowStartMinShare)
+	}
+	if c.OpenFlapBackoff != 0 && c.OpenFlapBackoff <= 1 {
+		return fmt.Errorf("resilience: open_flap_backoff must exceed 1, got %v", c.OpenFlapBackoff)
+	}
+	if c.TripWhen != "" {
+		if _, err := CompileTripCondition(c.TripWhen); err != nil {
+			return err
+		}
+	}
+	if c.ShedShare != "" {
+		if _, err := compileShedShare(c.ShedShare); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // RetryConfig configures retry behavior
 type RetryConfig struct {
 	// Enabled determines if retry is enabled
@@ -93,11 +424,54 @@ type RetryConfig struct {
 	// RandomizationFactor adds jitter to prevent thundering herd
 	RandomizationFactor float64 `mapstructure:"randomization_factor"`
 
-	// ShouldRetry determines if an error should trigger a retry
+	// MinFirstAttempt guarantees the first attempt runs for at least this
+	// long: when the incoming context's deadline is nearer or has passed,
+	// the first attempt runs on a detached context with this timeout.
+	// Later attempts use the incoming context. Zero disables the guarantee.
+	MinFirstAttempt time.Duration `mapstructure:"min_first_attempt"`
+
+	// MaxElapsedTime bounds the whole retry loop, attempts and backoff
+	// waits included: no retry is started whose backoff would end past it.
+	// Zero leaves MaxAttempts as the only bound.
+	MaxElapsedTime time.Duration `mapstructure:"max_elapsed_time"`
+
+	// AttemptTimeout bounds each attempt on its own, so one hung attempt
+	// fails with ErrTimeout and is retried instead of using up the
+	// caller's deadline. Zero leaves attempts bound by the caller's context.
+	AttemptTimeout time.Duration `mapstructure:"attempt_timeout"`
+
+	// ShouldRetry determines if an error should trigger a retry; nil uses
+	// DefaultShouldRetry
 	ShouldRetry ShouldRetry `mapstructure:"-"`
 
+	// ShouldRetryResult, when set, decides instead of ShouldRetry whether
+	// to retry an attempt from its result and error, so logically failed
+	// results can be retried. A successful attempt it retries counts as
+	// failed with ErrResultRejected.
+	ShouldRetryResult ShouldRetryResult `mapstructure:"-"`
+
+	// RetryAfterFunc, when it finds a server-provided delay in an error,
+	// replaces the computed backoff with it; MaxElapsedTime and the context
+	// deadline still apply
+	RetryAfterFunc RetryAfterFunc `mapstructure:"-"`
+
 	// OnRetry is called before each retry attempt
 	OnRetry OnRetry `mapstructure:"-"`
+
+	// OnGiveUp is called when the retry returns an error, whether it ran
+	// out of attempts or stopped early
+	OnGiveUp OnGiveUp `mapstructure:"-"`
+
+	// MetricsRecorder receives the outcome and attempt count of every call
+	MetricsRecorder RetryMetricsRecorder `mapstructure:"-"`
+
+	// TimerWheel schedules backoff waits on a shared wheel instead of a
+	// timer per call; nil uses runtime timers
+	TimerWheel *TimerWheel `mapstructure:"-"`
+
+	// Clock tells time for backoff waits when TimerWheel is nil; nil uses
+	// the system clock
+	Clock Clock `mapstructure:"-"`
 }
 
 // DefaultRetryConfig returns default retry configuration
@@ -127,20 +501,100 @@ type RateLimiterConfig struct {
 	// Burst is the maximum burst size
 	Burst int `mapstructure:"burst"`
 
+	// ReservationTTL is how long tokens reserved with Acquire remain usable
+	// before unconsumed tokens are returned to the limiter
+	ReservationTTL time.Duration `mapstructure:"reservation_ttl"`
+
+	// StatsSmoothing is the time constant of the smoothed token gauge in Stats
+	StatsSmoothing time.Duration `mapstructure:"stats_smoothing"`
+
 	// OnRateLimit is called when rate limit is exceeded
 	OnRateLimit OnRateLimit `mapstructure:"-"`
+
+	// Clock tells time for refills and waits; nil uses the system clock.
+	// TimerWheel, when set, schedules the waits.
+	Clock Clock `mapstructure:"-"`
+
+	// TimerWheel schedules Wait and Acquire sleeps on a shared wheel
+	// instead of a timer per call; nil uses runtime timers
+	TimerWheel *TimerWheel `mapstructure:"-"`
+
+	// ShareUsage makes Rate a limit for the whole fleet: instances exchange
+	// their demand through Coordinator, which must be a UsageCoordinator,
+	// and each refills at a share of Rate following its demand. Limiters
+	// of a RateLimiterGroup do not share usage.
+	ShareUsage bool `mapstructure:"share_usage"`
+
+	// UsageInterval is how often demand is published and the share
+	// recomputed with ShareUsage
+	UsageInterval time.Duration `mapstructure:"usage_interval"`
+
+	// Coordinator shares usage with other instances when ShareUsage is set
+	Coordinator Coordinator `mapstructure:"-"`
 }
 
 // DefaultRateLimiterConfig returns default rate limiter configuration
 func DefaultRateLimiterConfig() RateLimiterConfig {
 	return RateLimiterConfig{
-		Enabled: true,
-		Name:    "default",
-		Rate:    100.0, // 100 requests per second
-		Burst:   200,   // Allow burst of 200
+		Enabled:        true,
+		Name:           "default",
+		Rate:           100.0, // 100 requests per second
+		Burst:          200,   // Allow burst of 200
+		ReservationTTL: 30 * time.Second,
+		StatsSmoothing: 10 * time.Second,
+		UsageInterval:  time.Second,
+	}
+}
+
+// RateLimiterGroupConfig configures a group of keyed rate limiters refilled
+// by one shared loop
+type RateLimiterGroupConfig struct {
+	// Limiter configures the limiter of each key; its Name prefixes the keys
+	Limiter RateLimiterConfig `mapstructure:"limiter"`
+
+	// RefillInterval is the tick of the refill loop; tokens accrue once per
+	// tick, so waits are rounded up to it
+	RefillInterval time.Duration `mapstructure:"refill_interval"`
+
+	// IdleTimeout drops limiters whose bucket stayed full this long; zero
+	// keeps every key
+	IdleTimeout time.Duration `mapstructure:"idle_timeout"`
+}
+
+// DefaultRateLimiterGroupConfig returns default rate limiter group
+// configuration
+func DefaultRateLimiterGroupConfig() RateLimiterGroupConfig {
+	return RateLimiterGroupConfig{
+		Limiter:        DefaultRateLimiterConfig(),
+		RefillInterval: 10 * time.Millisecond,
+		IdleTimeout:    5 * time.Minute,
 	}
 }
 
+// QuotaSharingConfig configures a group of executors lending each other
+// unused rate limiter tokens
+type QuotaSharingConfig struct {
+	// Name is the sharing group identifier
+	Name string `mapstructure:"name"`
+
+	// Loans lists who may borrow from whom; a member with several lenders
+	// borrows from them in order
+	Loans []QuotaLoan `mapstructure:"loans"`
+}
+
+// QuotaLoan allows one member of a sharing group to borrow from another
+type QuotaLoan struct {
+	// From is the lending member
+	From string `mapstructure:"from"`
+
+	// To is the borrowing member
+	To string `mapstructure:"to"`
+
+	// Ratio is the share of the lender's burst it may lend, in (0, 1]; it
+	// keeps the rest for itself
+	Ratio float64 `mapstructure:"ratio"`
+}
+
 // BulkheadConfig configures bulkhead behavior
 type BulkheadConfig struct {
 	// Enabled determines if bulkhead is enabled
@@ -155,6 +609,25 @@ type BulkheadConfig struct {
 	// MaxQueueSize is the maximum queue size for waiting operations
 	MaxQueueSize int `mapstructure:"max_queue_size"`
 
+	// MaxQueueWait bounds how long a queued call waits for a slot before
+	// failing with ErrQueueTimeout; zero waits until the context is done
+	MaxQueueWait time.Duration `mapstructure:"max_queue_wait"`
+
+	// QueueTimeoutToBreaker records queue timeouts on the executor's circuit
+	// breaker as timeouts, so a backed-up bulkhead counts as slow calls
+	QueueTimeoutToBreaker bool `mapstructure:"queue_timeout_to_breaker"`
+
+	// StatsSmoothing is the time constant of the smoothed gauges in Stats
+	StatsSmoothing time.Duration `mapstructure:"stats_smoothing"`
+
+	// TimerWheel times MaxQueueWait on a shared wheel instead of a timer
+	// per queued call; nil uses runtime timers
+	TimerWheel *TimerWheel `mapstructure:"-"`
+
+	// Clock tells time for queue waits when TimerWheel is nil and for the
+	// smoothed gauges; nil uses the system clock
+	Clock Clock `mapstructure:"-"`
+
 	// OnBulkheadFull is called when bulkhead is at capacity
 	OnBulkheadFull OnBulkheadFull `mapstructure:"-"`
 }
@@ -162,10 +635,11 @@ type BulkheadConfig struct {
 // DefaultBulkheadConfig returns default bulkhead configuration
 func DefaultBulkheadConfig() BulkheadConfig {
 	return BulkheadConfig{
-		Enabled:       true,
-		Name:          "default",
-		MaxConcurrent: 10,
-		MaxQueueSize:  100,
+		Enabled:        true,
+		Name:           "default",
+		MaxConcurrent:  10,
+		MaxQueueSize:   100,
+		StatsSmoothing: 10 * time.Second,
 	}
 }
 
@@ -176,33 +650,584 @@ type TimeoutConfig struct {
 
 	// Duration is the timeout duration
 	Duration time.Duration `mapstructure:"duration"`
+
+	// Jitter randomizes each call's timeout by up to ±Jitter of Duration
+	// (0.1 is ±10%) so calls started together don't expire together;
+	// values above MaxTimeoutJitter (0.5) are capped
+	Jitter float64 `mapstructure:"jitter"`
+
+	// StragglerThreshold reports wrapped functions still running this long
+	// after their context was canceled; zero disables detection
+	StragglerThreshold time.Duration `mapstructure:"straggler_threshold"`
+
+	// CaptureStack includes the straggling goroutine's stack in reports
+	CaptureStack bool `mapstructure:"capture_stack"`
+
+	// OnStraggler is called when a wrapped function ignores cancellation
+	OnStraggler OnStraggler `mapstructure:"-"`
+
+	// OnLateResult is called with the outcome of each call returning after
+	// it was abandoned
+	OnLateResult OnLateResult `mapstructure:"-"`
+
+	// LateResultBreaker records the late outcomes of timed out calls as
+	// delayed outcomes, so a breaker outside the timeout learns that a
+	// timed out dependency still answers. A late outcome counts as a
+	// further request next to the timeout the breaker already recorded, so
+	// a call that times out and then succeeds counts twice, once as each;
+	// outcomes arriving after the breaker started a new generation of
+	// counts are dropped. Leave it nil when the breaker runs inside the
+	// timeout, as in a Builder, since it already sees them.
+	LateResultBreaker CircuitBreaker `mapstructure:"-"`
+
+	// Clock tells time for expiry; nil uses the system clock, which also
+	// sets the context deadline
+	Clock Clock `mapstructure:"-"`
 }
 
 // DefaultTimeoutConfig returns default timeout configuration
 func DefaultTimeoutConfig() TimeoutConfig {
 	return TimeoutConfig{
-		Enabled:  true,
-		Duration: 30 * time.Second,
+		Enabled:            true,
+		Duration:           30 * time.Second,
+		StragglerThreshold: 5 * time.Second,
+	}
+}
+
+// MetricsConfig holds configuration for executor call metrics
+type MetricsConfig struct {
+	// MaxLabelKeys is the most distinct extra label keys recorded; further
+	// keys are dropped
+	MaxLabelKeys int `mapstructure:"max_label_keys"`
+
+	// MaxLabelValues is the most distinct values recorded per label key;
+	// further values are recorded as LabelValueOther
+	MaxLabelValues int `mapstructure:"max_label_values"`
+
+	// Recorder receives call metrics
+	Recorder MetricsRecorder `mapstructure:"-"`
+
+	// LabelExtractor adds labels from the call context, e.g. the tenant
+	LabelExtractor LabelExtractor `mapstructure:"-"`
+}
+
+// DefaultMetricsConfig returns default metrics configuration
+func DefaultMetricsConfig() MetricsConfig {
+	return MetricsConfig{
+		MaxLabelKeys:   5,
+		MaxLabelValues: 100,
+	}
+}
+
+// AdvisorConfig holds configuration for stale configuration advisories
+type AdvisorConfig struct {
+	// Enabled determines if the advisor is enabled
+	Enabled bool `mapstructure:"enabled"`
+
+	// Interval is the observation window evaluated at a time
+	Interval time.Duration `mapstructure:"interval"`
+
+	// MinCalls is the minimum number of calls in a window before advising
+	MinCalls int `mapstructure:"min_calls"`
+
+	// TimeoutFactor is how many times the observed p99 latency the timeout
+	// may be before it is reported as too loose
+	TimeoutFactor float64 `mapstructure:"timeout_factor"`
+
+	// RateLimitUsage is the share of the rate limit the peak per-second
+	// rate must reach for the limit to count as approached
+	RateLimitUsage float64 `mapstructure:"rate_limit_usage"`
+
+	// OnAdvisory is called for each advisory
+	OnAdvisory OnAdvisory `mapstructure:"-"`
+}
+
+// DefaultAdvisorConfig returns default advisor configuration
+func DefaultAdvisorConfig() AdvisorConfig {
+	return AdvisorConfig{
+		Enabled:        true,
+		Interval:       time.Hour,
+		MinCalls:       100,
+		TimeoutFactor:  10,
+		RateLimitUsage: 0.1,
+	}
+}
+
+// AnomalyConfig holds configuration for executor anomaly detection
+type AnomalyConfig struct {
+	// Enabled determines if anomaly detection is enabled
+	Enabled bool `mapstructure:"enabled"`
+
+	// FlapThreshold is how many times the circuit breaker may open within
+	// FlapWindow before it is reported as flapping
+	FlapThreshold int `mapstructure:"flap_threshold"`
+
+	// FlapWindow is the period over which breaker openings are counted
+	FlapWindow time.Duration `mapstructure:"flap_window"`
+
+	// RetryAmplification is the average number of attempts per call above
+	// which retries are reported as amplifying load
+	RetryAmplification float64 `mapstructure:"retry_amplification"`
+
+	// RetryWindow is the period over which attempts per call are measured
+	RetryWindow time.Duration `mapstructure:"retry_window"`
+
+	// RetryMinCalls is the minimum number of calls in a window before
+	// retry amplification is evaluated
+	RetryMinCalls int `mapstructure:"retry_min_calls"`
+
+	// SaturationDuration is how long the bulkhead must stay full before
+	// it is reported as saturated
+	SaturationDuration time.Duration `mapstructure:"saturation_duration"`
+
+	// OnAnomaly is called when a heuristic fires
+	OnAnomaly OnAnomaly `mapstructure:"-"`
+}
+
+// DefaultAnomalyConfig returns default anomaly detection configuration
+func DefaultAnomalyConfig() AnomalyConfig {
+	return AnomalyConfig{
+		Enabled:            true,
+		FlapThreshold:      5,
+		FlapWindow:         time.Hour,
+		RetryAmplification: 2,
+		RetryWindow:        time.Minute,
+		RetryMinCalls:      20,
+		SaturationDuration: 30 * time.Second,
+	}
+}
+
+// HedgeConfig configures hedging behavior
+type HedgeConfig struct {
+	// Enabled determines if hedging is enabled
+	Enabled bool `mapstructure:"enabled"`
+
+	// Name is the hedge identifier
+	Name string `mapstructure:"name"`
+
+	// MaxHedges is the maximum number of extra attempts per call
+	MaxHedges int `mapstructure:"max_hedges"`
+
+	// Delay is the static hedge delay, used until MinSamples latencies
+	// are observed or when Percentile is zero
+	Delay time.Duration `mapstructure:"delay"`
+
+	// Percentile of recent successful call latencies after which a hedge
+	// is started, e.g. 0.95; zero always uses Delay
+	Percentile float64 `mapstructure:"percentile"`
+
+	// WindowSize is the number of recent latencies the percentile covers
+	WindowSize int `mapstructure:"window_size"`
+
+	// MinSamples is the number of latencies needed before the percentile
+	// replaces Delay
+	MinSamples int `mapstructure:"min_samples"`
+
+	// OnHedge is called when a hedged attempt is started
+	OnHedge OnHedge `mapstructure:"-"`
+
+	// Clock tells time for hedge delays and latencies; nil uses the system
+	// clock
+	Clock Clock `mapstructure:"-"`
+}
+
+// DefaultHedgeConfig returns default hedge configuration. Hedging adds
+// load to the target, so it is disabled by default.
+func DefaultHedgeConfig() HedgeConfig {
+	return HedgeConfig{
+		Enabled:    false,
+		Name:       "default",
+		MaxHedges:  1,
+		Delay:      100 * time.Millisecond,
+		Percentile: 0.95,
+		WindowSize: 100,
+		MinSamples: 20,
+	}
+}
+
+// CoordinationNone is the backend name that disables coordination
+const CoordinationNone = "none"
+
+// CoordinationConfig configures how breaker trips are shared between instances
+type CoordinationConfig struct {
+	// Backend selects the coordination backend ("none", or a registered backend
+	// such as "gossip")
+	Backend string `mapstructure:"backend"`
+
+	// NodeName uniquely identifies this instance (defaults to the hostname)
+	NodeName string `mapstructure:"node_name"`
+
+	// BindAddr is the address the backend listens on
+	BindAddr string `mapstructure:"bind_addr"`
+
+	// BindPort is the port the backend listens on
+	BindPort int `mapstructure:"bind_port"`
+
+	// Peers are the addresses of existing instances to join
+	Peers []string `mapstructure:"peers"`
+
+	// Options holds backend specific settings
+	Options map[string]string `mapstructure:"options"`
+}
+
+// DefaultCoordinationConfig returns default coordination configuration
+func DefaultCoordinationConfig() CoordinationConfig {
+	return CoordinationConfig{
+		Backend: CoordinationNone,
+	}
+}
+
+// SLOConfig configures an SLOGuard
+type SLOConfig struct {
+	// Name is the SLO identifier
+	Name string `mapstructure:"name"`
+
+	// Objective is the target success ratio, e.g. 0.999 for 99.9%
+	Objective float64 `mapstructure:"objective"`
+
+	// Window is the period over which the error budget is measured
+	Window time.Duration `mapstructure:"window"`
+
+	// BurnRateWindow is the short trailing window used to compute burn rate
+	BurnRateWindow time.Duration `mapstructure:"burn_rate_window"`
+
+	// BurnRateThreshold is the burn rate above which retries are suspended;
+	// a burn rate of 1 consumes the budget exactly over Window
+	BurnRateThreshold float64 `mapstructure:"burn_rate_threshold"`
+
+	// MinRequests is the number of calls within BurnRateWindow needed
+	// before the burn rate can suspend retries, so a single early failure
+	// does not
+	MinRequests uint32 `mapstructure:"min_requests"`
+
+	// OnBurnRateChange is called when the guard starts or stops tightening
+	OnBurnRateChange OnBurnRateChange `mapstructure:"-"`
+
+	// Clock tells time for the budget and burn rate windows; nil uses the
+	// system clock
+	Clock Clock `mapstructure:"-"`
+}
+
+// DefaultSLOConfig returns default SLO guard configuration
+func DefaultSLOConfig() SLOConfig {
+	return SLOConfig{
+		Name:              "default",
+		Objective:         0.999,               // 99.9% success
+		Window:            30 * 24 * time.Hour, // 30 days
+		BurnRateWindow:    time.Hour,
+		BurnRateThreshold: 14.4, // Budget gone in ~2 days
+		MinRequests:       10,
 	}
 }
 
-// NewConfig creates a new Config from the configuration loader
-func NewConfig(loader configx.Loader) (Config, error) {
-	var cfg Config
+// PayloadSamplingConfig configures error-rate-triggered sampling of request
+// metadata. Nothing is extracted while the executor is healthy.
+type PayloadSamplingConfig struct {
+	// FailureThreshold is the failure rate above which calls are sampled
+	FailureThreshold float64 `mapstructure:"failure_threshold"`
+
+	// Window is the period over which the failure rate is measured
+	Window time.Duration `mapstructure:"window"`
+
+	// MinCalls is the minimum number of calls in Window before the failure
+	// rate is trusted
+	MinCalls int `mapstructure:"min_calls"`
 
-	// Set defaults
-	cfg.CircuitBreaker = DefaultCircuitBreakerConfig()
-	cfg.Retry = DefaultRetryConfig()
-	cfg.RateLimiter = DefaultRateLimiterConfig()
-	cfg.Bulkhead = DefaultBulkheadConfig()
-	cfg.Timeout = DefaultTimeoutConfig()
+	// SampleRate is the share of calls captured while sampling is active
+	SampleRate float64 `mapstructure:"sample_rate"`
+
+	// RedactKeys are metadata keys, matched case-insensitively, whose values
+	// are replaced with RedactedValue
+	RedactKeys []string `mapstructure:"redact_keys"`
+
+	// Extractor returns the metadata of a call
+	Extractor PayloadExtractor `mapstructure:"-"`
+
+	// OnSample receives captured samples; it runs on the calling goroutine
+	OnSample OnPayloadSample `mapstructure:"-"`
+}
 
-	// Bind configuration
-	if err := loader.Bind(&cfg); err != nil {
-		return cfg, err
+// DefaultPayloadSamplingConfig returns default payload sampling configuration
+func DefaultPayloadSamplingConfig() PayloadSamplingConfig {
+	return PayloadSamplingConfig{
+		FailureThreshold: 0.2,
+		Window:           time.Minute,
+		MinCalls:         20,
+		SampleRate:       0.1,
 	}
+}
+
+// CanaryConfig configures a CanaryExecutor
+type CanaryConfig struct {
+	// Name is the canary identifier
+	Name string `mapstructure:"name"`
+
+	// Fraction is the share of calls (0-1) routed to the candidate
+	Fraction float64 `mapstructure:"fraction"`
+
+	// Window is the trailing period over which the arms are compared
+	Window time.Duration `mapstructure:"window"`
+
+	// MinCalls is the number of calls each arm needs within Window before
+	// they are compared
+	MinCalls int `mapstructure:"min_calls"`
+
+	// MaxFailureRateIncrease is how far the candidate's failure rate may
+	// exceed the baseline's, e.g. 0.05 for five percentage points
+	MaxFailureRateIncrease float64 `mapstructure:"max_failure_rate_increase"`
+
+	// MaxLatencyRatio is how many times the baseline's p95 latency the
+	// candidate's may reach; zero doesn't compare latency
+	MaxLatencyRatio float64 `mapstructure:"max_latency_ratio"`
+
+	// OnRollback is called when the candidate is rolled back
+	OnRollback OnCanaryRollback `mapstructure:"-"`
+}
+
+// DefaultCanaryConfig returns default canary configuration
+func DefaultCanaryConfig() CanaryConfig {
+	return CanaryConfig{
+		Name:                   "default",
+		Fraction:               0.05,
+		Window:                 5 * time.Minute,
+		MinCalls:               100,
+		MaxFailureRateIncrease: 0.05,
+	}
+}
+
+// OutlierDetectionConfig configures an OutlierDetector
+type OutlierDetectionConfig struct {
+	// Name is the detector identifier
+	Name string `mapstructure:"name"`
+
+	// Interval is how often success rates are compared across endpoints
+	Interval time.Duration `mapstructure:"interval"`
+
+	// ConsecutiveFailures ejects an endpoint after this many failures in a
+	// row; negative disables
+	ConsecutiveFailures int `mapstructure:"consecutive_failures"`
 
-	return cfg, nil
+	// SuccessRateStdevFactor ejects endpoints whose success rate over an
+	// Interval is more than this many standard deviations below the mean;
+	// negative disables
+	SuccessRateStdevFactor float64 `mapstructure:"success_rate_stdev_factor"`
+
+	// SuccessRateMinHosts is the number of endpoints with
+	// SuccessRateRequestVolume calls an Interval needs for success rates to
+	// be compared
+	SuccessRateMinHosts int `mapstructure:"success_rate_min_hosts"`
+
+	// SuccessRateRequestVolume is the number of calls an endpoint needs
+	// within an Interval to take part in the comparison
+	SuccessRateRequestVolume int `mapstructure:"success_rate_request_volume"`
+
+	// BaseEjectionTime is how long an endpoint is ejected, multiplied by
+	// the number of times it was ejected recently
+	BaseEjectionTime time.Duration `mapstructure:"base_ejection_time"`
+
+	// MaxEjectionTime caps the ejection time
+	MaxEjectionTime time.Duration `mapstructure:"max_ejection_time"`
+
+	// MaxEjectionRatio is the share of endpoints that may be ejected at
+	// once; one endpoint can always be ejected
+	MaxEjectionRatio float64 `mapstructure:"max_ejection_ratio"`
+
+	// Breakers are keyed by endpoint: ejecting an endpoint forces its
+	// breaker open until readmission, and endpoints with an open breaker
+	// are left out of the healthy set
+	Breakers KeyedCircuitBreaker `mapstructure:"-"`
+
+	// OnEjection is called when an endpoint is ejected or readmitted
+	OnEjection OnOutlierEjection `mapstructure:"-"`
+}
+
+// DefaultOutlierDetectionConfig returns default outlier detection
+// configuration, matching Envoy's defaults
+func DefaultOutlierDetectionConfig() OutlierDetectionConfig {
+	return OutlierDetectionConfig{
+		Name:                     "default",
+		Interval:                 10 * time.Second,
+		ConsecutiveFailures:      5,
+		SuccessRateStdevFactor:   1.9,
+		SuccessRateMinHosts:      5,
+		SuccessRateRequestVolume: 100,
+		BaseEjectionTime:         30 * time.Second,
+		MaxEjectionTime:          300 * time.Second,
+		MaxEjectionRatio:         0.1,
+	}
+}
+
+// CacheConfig configures a DegradationCache
+type CacheConfig struct {
+	// TTL is the maximum age of an entry served in place of a failed call
+	TTL time.Duration `mapstructure:"ttl"`
+
+	// MaxEntries bounds the number of cached entries; the least recently
+	// used entry is evicted when full
+	MaxEntries int `mapstructure:"max_entries"`
+
+	// ServeStaleOn reports whether a failed call may be answered from the
+	// cache; nil serves cached entries for every error
+	ServeStaleOn func(error) bool `mapstructure:"-"`
+
+	// OnServeStale is called when a cached entry answers a failed call
+	OnServeStale OnServeStale `mapstructure:"-"`
+}
+
+// DefaultCacheConfig returns default degradation cache configuration
+func DefaultCacheConfig() CacheConfig {
+	return CacheConfig{
+		TTL:        5 * time.Minute,
+		MaxEntries: 10000,
+	}
+}
+
+// AsyncConfig configures an AsyncExecutor
+type AsyncConfig struct {
+	// QueueSize bounds the number of calls running or waiting to be retried
+	QueueSize int `mapstructure:"queue_size"`
+
+	// MaxAttempts is the number of attempts before a call is dead-lettered
+	MaxAttempts int `mapstructure:"max_attempts"`
+
+	// InitialInterval is the initial backoff interval between attempts
+	InitialInterval time.Duration `mapstructure:"initial_interval"`
+
+	// MaxInterval is the maximum backoff interval
+	MaxInterval time.Duration `mapstructure:"max_interval"`
+
+	// Multiplier is the backoff multiplier
+	Multiplier float64 `mapstructure:"multiplier"`
+
+	// RandomizationFactor adds jitter to prevent thundering herd
+	RandomizationFactor float64 `mapstructure:"randomization_factor"`
+
+	// ShouldRetry determines if an error should be retried; nil uses
+	// DefaultShouldRetry
+	ShouldRetry ShouldRetry `mapstructure:"-"`
+
+	// Store persists queued calls; nil keeps them in memory only
+	Store QueueStore `mapstructure:"-"`
+
+	// OnDeadLetter is called for calls that will not be retried again
+	OnDeadLetter OnDeadLetter `mapstructure:"-"`
+
+	// TimerWheel schedules backoff waits on a shared wheel instead of a
+	// timer per call; nil uses runtime timers
+	TimerWheel *TimerWheel `mapstructure:"-"`
+}
+
+// DefaultAsyncConfig returns default async executor configuration
+func DefaultAsyncConfig() AsyncConfig {
+	return AsyncConfig{
+		QueueSize:           1000,
+		MaxAttempts:         5,
+		InitialInterval:     time.Second,
+		MaxInterval:         time.Minute,
+		Multiplier:          2.0,
+		RandomizationFactor: 0.1,
+	}
+}
+
+// RemediationConfig configures a Remediator
+type RemediationConfig struct {
+	// CheckInterval is how often conditions are evaluated
+	CheckInterval time.Duration `mapstructure:"check_interval"`
+
+	// Cooldown is the minimum time between runs of a remediation that
+	// doesn't set its own
+	Cooldown time.Duration `mapstructure:"cooldown"`
+
+	// ActionTimeout bounds each run of an action
+	ActionTimeout time.Duration `mapstructure:"action_timeout"`
+
+	// OnRemediation is called when an action returns
+	OnRemediation OnRemediation `mapstructure:"-"`
+}
+
+// DefaultRemediationConfig returns default remediation configuration
+func DefaultRemediationConfig() RemediationConfig {
+	return RemediationConfig{
+		CheckInterval: time.Second,
+		Cooldown:      5 * time.Minute,
+		ActionTimeout: time.Minute,
+	}
+}
+
+// SnapshotConfig configures a MetricsSnapshotter
+type SnapshotConfig struct {
+	// Interval is how often a snapshot is written
+	Interval time.Duration `mapstructure:"interval"`
+
+	// Sink receives the snapshots
+	Sink SnapshotSink `mapstructure:"-"`
+
+	// OnError is called when the sink fails to write a snapshot
+	OnError func(err error) `mapstructure:"-"`
+
+	// Clock tells time for the interval; nil uses the system clock
+	Clock Clock `mapstructure:"-"`
+}
+
+// DefaultSnapshotConfig returns default metrics snapshot configuration
+func DefaultSnapshotConfig() SnapshotConfig {
+	return SnapshotConfig{
+		Interval: time.Minute,
+	}
+}
+
+// FileSnapshotSinkConfig configures a FileSnapshotSink
+type FileSnapshotSinkConfig struct {
+	// Dir is the directory snapshot files are written to
+	Dir string `mapstructure:"dir"`
+
+	// Prefix starts the name of every snapshot file
+	Prefix string `mapstructure:"prefix"`
+
+	// RotateInterval is how long a file is written to before a new one
+	// is started
+	RotateInterval time.Duration `mapstructure:"rotate_interval"`
+
+	// MaxFileSize is the size in bytes beyond which a new file is started
+	MaxFileSize int64 `mapstructure:"max_file_size"`
+
+	// MaxFiles is the number of files kept, the current one included;
+	// older files are deleted on rotation
+	MaxFiles int `mapstructure:"max_files"`
+
+	// MaxAge deletes files started longer ago on rotation; zero keeps
+	// files regardless of age
+	MaxAge time.Duration `mapstructure:"max_age"`
+
+	// Clock tells time for rotation and file names; nil uses the system
+	// clock
+	Clock Clock `mapstructure:"-"`
+}
+
+// DefaultFileSnapshotSinkConfig returns default snapshot file configuration
+func DefaultFileSnapshotSinkConfig() FileSnapshotSinkConfig {
+	return FileSnapshotSinkConfig{
+		Prefix:         "resilience-metrics",
+		RotateInterval: time.Hour,
+		MaxFileSize:    10 << 20,
+		MaxFiles:       24,
+	}
+}
+
+// DefaultConfig returns the default configuration of every pattern
+func DefaultConfig() Config {
+	return Config{
+		CircuitBreaker: DefaultCircuitBreakerConfig(),
+		Retry:          DefaultRetryConfig(),
+		RateLimiter:    DefaultRateLimiterConfig(),
+		Bulkhead:       DefaultBulkheadConfig(),
+		Timeout:        DefaultTimeoutConfig(),
+		Coordination:   DefaultCoordinationConfig(),
+		Hedge:          DefaultHedgeConfig(),
+		Anomaly:        DefaultAnomalyConfig(),
+		Metrics:        DefaultMetricsConfig(),
+		Advisor:        DefaultAdvisorConfig(),
+	}
 }
 
 // ConfigSummary returns a compact diagnostic map safe for logging.
@@ -212,5 +1237,7 @@ func (c *Config) ConfigSummary() map[string]any {
 		"retry_enabled":           c.Retry.Enabled,
 		"rate_limiter_enabled":    c.RateLimiter.Enabled,
 		"bulkhead_enabled":        c.Bulkhead.Enabled,
+		"coordination_backend":    c.Coordination.Backend,
+		"anomaly_enabled":         c.Anomaly.Enabled,
 	}
 }
@@ -0,0 +1,55 @@
+package resilience
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+// Policy returns the effective configuration as nested maps keyed by
+// mapstructure key, with durations as strings, for logging at startup.
+// Callbacks set in code and the per-instance Coordination settings are left
+// out.
+func (c Config) Policy() map[string]any {
+	policy := policyMap(reflect.ValueOf(c))
+	delete(policy, "coordination")
+	return policy
+}
+
+// Fingerprint returns a hash of Policy. Replicas running the same policies
+// report the same fingerprint, so fleet tooling can check a config rollout
+// has converged.
+func (c Config) Fingerprint() string {
+	// Map keys are marshaled in sorted order, so equal policies encode
+	// identically
+	data, _ := json.Marshal(c.Policy())
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// policyMap returns the mapstructure fields of struct v, skipping funcs and
+// interfaces
+func policyMap(v reflect.Value) map[string]any {
+	policy := map[string]any{}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := field.Tag.Get("mapstructure")
+		if !field.IsExported() || key == "" || key == "-" {
+			continue
+		}
+		value := v.Field(i)
+		switch {
+		case field.Type == durationType:
+			policy[key] = time.Duration(value.Int()).String()
+		case field.Type.Kind() == reflect.Struct:
+			policy[key] = policyMap(value)
+		case field.Type.Kind() == reflect.Func, field.Type.Kind() == reflect.Interface:
+		default:
+			policy[key] = value.Interface()
+		}
+	}
+	return policy
+}
@@ -0,0 +1,32 @@
+package resilience
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigFingerprint(t *testing.T) {
+	t.Run("is stable for equal policies", func(t *testing.T) {
+		a, b := DefaultConfig(), DefaultConfig()
+		b.CircuitBreaker.OnStateChange = func(string, CircuitState, CircuitState) {}
+		b.Coordination.NodeName = "replica-2"
+		assert.Equal(t, a.Fingerprint(), b.Fingerprint())
+		assert.Len(t, a.Fingerprint(), 16)
+	})
+
+	t.Run("changes with the policy", func(t *testing.T) {
+		a, b := DefaultConfig(), DefaultConfig()
+		b.Retry.MaxAttempts++
+		assert.NotEqual(t, a.Fingerprint(), b.Fingerprint())
+	})
+
+	t.Run("dumps the policy by key", func(t *testing.T) {
+		policy := DefaultConfig().Policy()
+		breaker := policy["circuit_breaker"].(map[string]any)
+		assert.Equal(t, "30s", breaker["timeout"])
+		assert.Equal(t, 0.6, breaker["failure_threshold"])
+		assert.NotContains(t, breaker, "on_state_change")
+		assert.NotContains(t, policy, "coordination")
+	})
+}
@@ -0,0 +1,127 @@
+package resilience
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+// durationPattern matches the duration strings accepted by time.ParseDuration
+const durationPattern = `^[-+]?(0|([0-9]+(\.[0-9]*)?|\.[0-9]+)(ns|us|µs|ms|s|m|h))+$`
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// ConfigSchema returns a JSON Schema (draft 2020-12) of Config as found under
+// the resilience prefix. It is generated from the config structs, so it
+// lists every mapstructure key with its type, the DefaultConfig value and,
+// for mode fields, the accepted values, including registered coordination
+// backends. Callbacks and classifiers are set in code and are not part of
+// the schema.
+func ConfigSchema() ([]byte, error) {
+	schema := objectSchema(reflect.TypeOf(Config{}), reflect.ValueOf(DefaultConfig()), "")
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = Config{}.Prefix()
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// configEnums returns the accepted values of string fields by key path
+func configEnums() map[string][]string {
+	return map[string][]string{
+		"circuit_breaker.window_type":    {WindowTypeFixed, WindowTypeSliding},
+		"circuit_breaker.half_open_mode": {HalfOpenModeFixed, HalfOpenModeRamp},
+		"circuit_breaker.trip_strategy":  {TripStrategyRatio, TripStrategyConsecutive, TripStrategyAdaptive},
+		"circuit_breaker.trip_mode":      {TripModeBinary, TripModeProportional},
+		"circuit_breaker.timeout_mode":   {TimeoutModeFailure, TimeoutModeDouble, TimeoutModeSeparate},
+		"coordination.backend":           append([]string{CoordinationNone}, registeredCoordinators()...),
+	}
+}
+
+// objectSchema describes the mapstructure fields of struct type t with
+// defaults taken from def
+func objectSchema(t reflect.Type, def reflect.Value, path string) map[string]any {
+	properties := map[string]any{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := field.Tag.Get("mapstructure")
+		if !field.IsExported() || key == "" || key == "-" {
+			continue
+		}
+		var value reflect.Value
+		if def.IsValid() {
+			value = def.Field(i)
+		}
+		if schema := fieldSchema(field.Type, value, joinKey(path, key)); schema != nil {
+			properties[key] = schema
+		}
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// fieldSchema describes a value of type t, or returns nil for types that
+// can't be configured, such as funcs and interfaces
+func fieldSchema(t reflect.Type, def reflect.Value, path string) map[string]any {
+	var schema map[string]any
+	switch {
+	case t == durationType:
+		schema = map[string]any{"type": "string", "pattern": durationPattern}
+		if def.IsValid() {
+			schema["default"] = time.Duration(def.Int()).String()
+		}
+		return schema
+	case t.Kind() == reflect.Struct:
+		return objectSchema(t, def, path)
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		schema = map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		schema = map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		schema = map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		schema = map[string]any{"type": "number"}
+	case reflect.String:
+		schema = map[string]any{"type": "string"}
+		if values, ok := configEnums()[path]; ok {
+			schema["enum"] = values
+		}
+	case reflect.Slice:
+		items := fieldSchema(t.Elem(), reflect.Value{}, path)
+		if items == nil {
+			return nil
+		}
+		schema = map[string]any{"type": "array", "items": items}
+		if def.IsValid() && def.IsNil() {
+			return schema
+		}
+	case reflect.Map:
+		values := fieldSchema(t.Elem(), reflect.Value{}, path)
+		if t.Key().Kind() != reflect.String || values == nil {
+			return nil
+		}
+		schema = map[string]any{"type": "object", "additionalProperties": values}
+		if def.IsValid() && def.IsNil() {
+			return schema
+		}
+	default:
+		return nil
+	}
+
+	if def.IsValid() {
+		schema["default"] = def.Interface()
+	}
+	return schema
+}
+
+// joinKey appends key to a dotted key path
+func joinKey(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
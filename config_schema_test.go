@@ -0,0 +1,73 @@
+package resilience
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigSchema(t *testing.T) {
+	data, err := ConfigSchema()
+	require.NoError(t, err)
+
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal(data, &schema))
+	assert.Equal(t, "resilience", schema["title"])
+	assert.Equal(t, "https://json-schema.org/draft/2020-12/schema", schema["$schema"])
+
+	// property returns the schema at a path of keys
+	property := func(keys ...string) map[string]any {
+		node := schema
+		for _, key := range keys {
+			props, ok := node["properties"].(map[string]any)
+			require.True(t, ok, "no properties at %s", key)
+			node, ok = props[key].(map[string]any)
+			require.True(t, ok, "missing %s", key)
+		}
+		return node
+	}
+
+	t.Run("describes every configurable field", func(t *testing.T) {
+		sections := reflect.TypeOf(Config{})
+		for i := 0; i < sections.NumField(); i++ {
+			section := sections.Field(i)
+			sectionKey := section.Tag.Get("mapstructure")
+			fields := property(sectionKey)["properties"].(map[string]any)
+			for j := 0; j < section.Type.NumField(); j++ {
+				key := section.Type.Field(j).Tag.Get("mapstructure")
+				if key == "" || key == "-" {
+					continue
+				}
+				assert.Contains(t, fields, key, "%s.%s", sectionKey, key)
+			}
+		}
+	})
+
+	t.Run("includes types, defaults and accepted values", func(t *testing.T) {
+		strategy := property("circuit_breaker", "trip_strategy")
+		assert.Equal(t, "string", strategy["type"])
+		assert.Equal(t, TripStrategyRatio, strategy["default"])
+		assert.ElementsMatch(t, []any{"ratio", "consecutive", "adaptive"}, strategy["enum"])
+
+		timeout := property("circuit_breaker", "timeout")
+		assert.Equal(t, "string", timeout["type"])
+		assert.Equal(t, "30s", timeout["default"])
+
+		steps := property("circuit_breaker", "ramp_steps")
+		assert.Equal(t, "array", steps["type"])
+		assert.Equal(t, []any{0.05, 0.25, 1.0}, steps["default"])
+
+		assert.Equal(t, "integer", property("circuit_breaker", "max_requests")["type"])
+		assert.Equal(t, "object", property("coordination", "options")["type"])
+		assert.Contains(t, property("coordination", "backend")["enum"], CoordinationNone)
+	})
+
+	t.Run("omits callbacks", func(t *testing.T) {
+		fields := property("circuit_breaker")["properties"].(map[string]any)
+		assert.NotContains(t, fields, "on_state_change")
+		assert.NotContains(t, fields, "is_failure")
+	})
+}
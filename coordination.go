@@ -0,0 +1,311 @@
+package resilience
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CoordinationEvent is a breaker state transition shared between instances
+type CoordinationEvent struct {
+	// Breaker is the name of the circuit breaker
+	Breaker string `json:"breaker"`
+
+	// State is the state the breaker transitioned to
+	State CircuitState `json:"state"`
+
+	// Origin identifies the instance that published the event
+	Origin string `json:"origin"`
+
+	// Time is when the transition happened
+	Time time.Time `json:"time"`
+}
+
+// Coordinator shares circuit breaker trips between service instances
+type Coordinator interface {
+	// Publish announces a local state transition to other instances
+	Publish(ctx context.Context, event CoordinationEvent) error
+
+	// Subscribe registers a handler for transitions published by other
+	// instances and returns a function that removes it
+	Subscribe(handler func(CoordinationEvent)) (unsubscribe func())
+
+	// Close releases the coordinator's resources
+	Close() error
+}
+
+// SharedCounts are circuit breaker outcomes aggregated across instances
+type SharedCounts struct {
+	// Requests is the number of calls with a recorded outcome
+	Requests uint64 `json:"requests"`
+
+	// Failures is the number of those calls that failed
+	Failures uint64 `json:"failures"`
+}
+
+// CountCoordinator is a Coordinator that also aggregates breaker outcomes
+// across instances, so the failure ratio reflects the whole fleet and an
+// outage trips every instance as soon as the fleet crosses the threshold
+type CountCoordinator interface {
+	Coordinator
+
+	// AddCounts adds delta to the breaker's counts for the window starting
+	// at window and lasting interval, and returns the fleet totals
+	AddCounts(ctx context.Context, breaker string, window time.Time, interval time.Duration, delta SharedCounts) (SharedCounts, error)
+}
+
+// CoordinatorFactory creates a Coordinator for a backend
+type CoordinatorFactory func(config CoordinationConfig) (Coordinator, error)
+
+var (
+	coordinatorsMu sync.RWMutex
+	coordinators   = make(map[string]CoordinatorFactory)
+)
+
+// RegisterCoordinator makes a coordination backend available by name.
+// Backends typically register themselves from an init function, so
+// selecting one only requires importing its package.
+func RegisterCoordinator(backend string, factory CoordinatorFactory) {
+	coordinatorsMu.Lock()
+	defer coordinatorsMu.Unlock()
+
+	if factory == nil {
+		panic("resilience: RegisterCoordinator factory is nil")
+	}
+	if _, dup := coordinators[backend]; dup {
+		panic("resilience: RegisterCoordinator called twice for backend " + backend)
+	}
+	coordinators[backend] = factory
+}
+
+// NewCoordinator creates the coordinator selected by config.Backend.
+// It returns a nil Coordinator for the "none" backend.
+func NewCoordinator(config CoordinationConfig) (Coordinator, error) {
+	if config.Backend == "" || config.Backend == CoordinationNone {
+		return nil, nil
+	}
+
+	coordinatorsMu.RLock()
+	factory, ok := coordinators[config.Backend]
+	coordinatorsMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("resilience: unknown coordination backend %q (registered: %v)", config.Backend, registeredCoordinators())
+	}
+	return factory(config)
+}
+
+func registeredCoordinators() []string {
+	coordinatorsMu.RLock()
+	defer coordinatorsMu.RUnlock()
+
+	names := make([]string, 0, len(coordinators))
+	for name := range coordinators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// countSharing exchanges a breaker's closed-state outcomes with a
+// CountCoordinator. Windows are aligned to the breaker interval so every
+// instance adds to the same counts; outcomes are batched while a call to
+// the coordinator is in flight.
+type countSharing struct {
+	coordinator CountCoordinator
+	window      time.Time    // window of pending
+	pending     SharedCounts // outcomes not yet sent
+	syncing     bool
+}
+
+// shareOutcome queues requests outcomes, failures of them failed, for the
+// fleet counts; callers hold cb.mu
+func (cb *circuitBreaker) shareOutcome(now time.Time, requests, failures uint64) {
+	s := cb.sharing
+	window := now.Truncate(cb.config.Interval)
+	if !window.Equal(s.window) {
+		s.window = window
+		s.pending = SharedCounts{}
+	}
+	s.pending.Requests += requests
+	s.pending.Failures += failures
+
+	if !s.syncing {
+		s.syncing = true
+		go cb.syncCounts()
+	}
+}
+
+// syncCounts sends pending outcomes until none are left, tripping the
+// breaker when the fleet totals cross the threshold. Errors drop back to
+// local counts: the batch is requeued and the breaker keeps deciding alone.
+func (cb *circuitBreaker) syncCounts() {
+	s := cb.sharing
+	for {
+		cb.mu.Lock()
+		if s.pending.Requests == 0 || !cb.counting() {
+			s.pending = SharedCounts{}
+			s.syncing = false
+			cb.mu.Unlock()
+			return
+		}
+		window, delta := s.window, s.pending
+		s.pending = SharedCounts{}
+		generation := cb.currentGeneration()
+		cb.mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), cb.config.CoordinationTimeout)
+		totals, err := s.coordinator.AddCounts(ctx, cb.config.Name, window, cb.config.Interval, delta)
+		cancel()
+
+		cb.mu.Lock()
+		if err != nil {
+			if window.Equal(s.window) {
+				s.pending.Requests += delta.Requests
+				s.pending.Failures += delta.Failures
+			}
+			s.syncing = false
+			cb.mu.Unlock()
+			return
+		}
+		if generation == cb.currentGeneration() && cb.counting() && !cb.forced && cb.fleetReadyToTrip(totals) {
+			cb.setState(StateOpen, cb.now())
+		}
+		cb.mu.Unlock()
+	}
+}
+
+// fleetReadyToTrip applies the trip condition to fleet totals; the
+// consecutive strategy and proportional mode have no fleet equivalent and
+// are left to local counts
+func (cb *circuitBreaker) fleetReadyToTrip(totals SharedCounts) bool {
+	if cb.now().Before(cb.warmUntil) {
+		return false
+	}
+	if cb.config.TripMode == TripModeProportional {
+		return false
+	}
+	if policy := cb.config.TripPolicy; policy != nil {
+		return policy.ShouldTrip(Counts{
+			Requests:  uint32(totals.Requests),
+			Successes: uint32(totals.Requests - totals.Failures),
+			Failures:  uint32(totals.Failures),
+		}, cb.config.Interval)
+	}
+	if cb.trip == nil && cb.config.TripStrategy == TripStrategyConsecutive {
+		return false
+	}
+	if totals.Requests < uint64(cb.config.MinRequests) {
+		return false
+	}
+	if cb.trip != nil {
+		// Latency is not shared, so slow_rate is the local one
+		return cb.trip.eval(cb.tripVars(counts{
+			requests:       uint32(totals.Requests),
+			totalSuccesses: uint32(totals.Requests - totals.Failures),
+			totalFailures:  uint32(totals.Failures),
+		}, cb.now()))
+	}
+	return float64(totals.Failures)/float64(totals.Requests) >= cb.failureThreshold(cb.now())
+}
+
+// GroupCoordinator is an in-process Coordinator that shares transitions
+// between breakers of the same group, so "payments-read" and
+// "payments-write" with GroupName "payments" open together. With an inner
+// Coordinator the group's transitions also reach other instances.
+// Failure counts are not aggregated through a GroupCoordinator. It also
+// ranks its breakers by rejected calls, on the system clock.
+type GroupCoordinator struct {
+	inner       Coordinator
+	unsubscribe func()
+	rejected    *keyRejections
+
+	mu       sync.RWMutex
+	handlers map[int]func(CoordinationEvent)
+	nextID   int
+}
+
+// NewGroupCoordinator creates a group coordinator; inner may be nil to share
+// transitions within the process only
+func NewGroupCoordinator(inner Coordinator) *GroupCoordinator {
+	g := &GroupCoordinator{
+		inner:    inner,
+		rejected: newKeyRejections(time.Now, maxRankedKeys),
+		handlers: make(map[int]func(CoordinationEvent)),
+	}
+	if inner != nil {
+		g.unsubscribe = inner.Subscribe(g.deliver)
+	}
+	return g
+}
+
+// Publish delivers the event to the local group members and the inner
+// Coordinator
+func (g *GroupCoordinator) Publish(ctx context.Context, event CoordinationEvent) error {
+	g.deliver(event)
+	if g.inner != nil {
+		return g.inner.Publish(ctx, event)
+	}
+	return nil
+}
+
+func (g *GroupCoordinator) Subscribe(handler func(CoordinationEvent)) func() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	id := g.nextID
+	g.nextID++
+	g.handlers[id] = handler
+
+	return func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		delete(g.handlers, id)
+	}
+}
+
+// TopRejected returns up to k breakers of the group that rejected the most
+// calls within window, most rejected first. window is rounded up to whole
+// minutes and covers at most the last hour.
+func (g *GroupCoordinator) TopRejected(k int, window time.Duration) []KeyRejections {
+	return g.rejected.top(k, window)
+}
+
+// Stats returns the number of breakers in the group and the most rejected
+// ones
+func (g *GroupCoordinator) Stats() BreakerGroupStats {
+	g.mu.RLock()
+	members := len(g.handlers)
+	g.mu.RUnlock()
+
+	return BreakerGroupStats{
+		Members:     members,
+		TopRejected: g.TopRejected(statsTopKeys, statsRejectionWindow),
+	}
+}
+
+// Close unsubscribes from and closes the inner Coordinator
+func (g *GroupCoordinator) Close() error {
+	if g.inner == nil {
+		return nil
+	}
+	g.unsubscribe()
+	return g.inner.Close()
+}
+
+// deliver hands the event to every local subscriber; a breaker receiving
+// its own transition is already in that state and ignores it
+func (g *GroupCoordinator) deliver(event CoordinationEvent) {
+	g.mu.RLock()
+	handlers := make([]func(CoordinationEvent), 0, len(g.handlers))
+	for _, h := range g.handlers {
+		handlers = append(handlers, h)
+	}
+	g.mu.RUnlock()
+
+	for _, h := range handlers {
+		h(event)
+	}
+}
@@ -0,0 +1,314 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryHub connects in-process coordinators for tests
+type memoryHub struct {
+	mu    sync.Mutex
+	nodes []*memoryCoordinator
+}
+
+type memoryCoordinator struct {
+	hub           *memoryHub
+	name          string
+	mu            sync.Mutex
+	handlers      []func(CoordinationEvent)
+	usageHandlers []func(RateUsage)
+}
+
+func (h *memoryHub) join(name string) *memoryCoordinator {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	c := &memoryCoordinator{hub: h, name: name}
+	h.nodes = append(h.nodes, c)
+	return c
+}
+
+func (c *memoryCoordinator) Publish(ctx context.Context, event CoordinationEvent) error {
+	event.Origin = c.name
+	c.hub.mu.Lock()
+	nodes := append([]*memoryCoordinator(nil), c.hub.nodes...)
+	c.hub.mu.Unlock()
+
+	for _, node := range nodes {
+		if node == c {
+			continue
+		}
+		node.mu.Lock()
+		handlers := append([]func(CoordinationEvent){}, node.handlers...)
+		node.mu.Unlock()
+		for _, h := range handlers {
+			h(event)
+		}
+	}
+	return nil
+}
+
+func (c *memoryCoordinator) Subscribe(handler func(CoordinationEvent)) func() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handlers = append(c.handlers, handler)
+	return func() {}
+}
+
+func (c *memoryCoordinator) Close() error { return nil }
+
+func (c *memoryCoordinator) PublishUsage(ctx context.Context, usage RateUsage) error {
+	usage.Origin = c.name
+	c.hub.mu.Lock()
+	nodes := append([]*memoryCoordinator(nil), c.hub.nodes...)
+	c.hub.mu.Unlock()
+
+	for _, node := range nodes {
+		if node == c {
+			continue
+		}
+		node.mu.Lock()
+		handlers := append([]func(RateUsage){}, node.usageHandlers...)
+		node.mu.Unlock()
+		for _, h := range handlers {
+			h(usage)
+		}
+	}
+	return nil
+}
+
+func (c *memoryCoordinator) SubscribeUsage(handler func(RateUsage)) func() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.usageHandlers = append(c.usageHandlers, handler)
+	return func() {}
+}
+
+func TestCoordinatedCircuitBreakers(t *testing.T) {
+	hub := &memoryHub{}
+	config := CircuitBreakerConfig{
+		Name:             "payments",
+		Interval:         time.Minute,
+		Timeout:          time.Minute,
+		FailureThreshold: 0.5,
+		MinRequests:      2,
+	}
+
+	configA := config
+	configA.Coordinator = hub.join("a")
+	configB := config
+	configB.Coordinator = hub.join("b")
+	cbA := NewCircuitBreaker(configA)
+	cbB := NewCircuitBreaker(configB)
+
+	ctx := context.Background()
+	cbA.Execute(ctx, func(ctx context.Context) error { return errors.New("error") })
+	cbA.Execute(ctx, func(ctx context.Context) error { return errors.New("error") })
+	assert.Equal(t, StateOpen, cbA.State())
+
+	assert.Eventually(t, func() bool {
+		return cbB.State() == StateOpen
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestBreakerGroups(t *testing.T) {
+	member := func(name, group string, coordinator Coordinator) CircuitBreaker {
+		return NewCircuitBreaker(CircuitBreakerConfig{
+			Name:        name,
+			GroupName:   group,
+			Interval:    time.Minute,
+			Timeout:     time.Minute,
+			Coordinator: coordinator,
+		})
+	}
+	isOpen := func(cb CircuitBreaker) func() bool {
+		return func() bool { return cb.State() == StateOpen }
+	}
+	isClosed := func(cb CircuitBreaker) func() bool {
+		return func() bool { return cb.State() == StateClosed }
+	}
+
+	t.Run("members trip and recover together", func(t *testing.T) {
+		group := NewGroupCoordinator(nil)
+		read := member("payments-read", "payments", group)
+		write := member("payments-write", "payments", group)
+		other := member("inventory", "", group)
+
+		read.Trip()
+		assert.Eventually(t, isOpen(write), time.Second, 5*time.Millisecond)
+
+		read.Reset()
+		assert.Eventually(t, isClosed(write), time.Second, 5*time.Millisecond)
+		assert.Equal(t, StateClosed, other.State())
+	})
+
+	t.Run("a member ignores its own delivered trip", func(t *testing.T) {
+		cb := member("payments-read", "payments", NewGroupCoordinator(nil)).(*circuitBreaker)
+		clock := cb.stateTime
+		cb.now = func() time.Time { return clock }
+
+		cb.Trip()
+		tripped := clock
+		clock = clock.Add(2 * time.Minute)
+		require.NoError(t, cb.Execute(context.Background(), func(ctx context.Context) error { return nil }))
+		require.Equal(t, StateHalfOpen, cb.State())
+
+		cb.onCoordinationEvent(CoordinationEvent{Breaker: "payments", State: StateOpen, Time: tripped})
+		assert.Equal(t, StateHalfOpen, cb.State())
+	})
+
+	t.Run("ranks members by rejected calls", func(t *testing.T) {
+		group := NewGroupCoordinator(nil)
+		read := member("payments-read", "payments", group)
+		write := member("payments-write", "payments", group)
+		ok := func(ctx context.Context) error { return nil }
+
+		read.ForceOpen()
+		write.ForceOpen()
+		for i := 0; i < 3; i++ {
+			assert.ErrorIs(t, read.Execute(context.Background(), ok), ErrCircuitOpen)
+		}
+		assert.ErrorIs(t, write.Execute(context.Background(), ok), ErrCircuitOpen)
+
+		assert.Equal(t, BreakerGroupStats{
+			Members: 2,
+			TopRejected: []KeyRejections{
+				{Key: "payments-read", Rejections: 3},
+				{Key: "payments-write", Rejections: 1},
+			},
+		}, group.Stats())
+		assert.Equal(t, []KeyRejections{{Key: "payments-read", Rejections: 3}}, group.TopRejected(1, time.Minute))
+	})
+
+	t.Run("groups span instances through the inner coordinator", func(t *testing.T) {
+		hub := &memoryHub{}
+		groupA := NewGroupCoordinator(hub.join("a"))
+		groupB := NewGroupCoordinator(hub.join("b"))
+		read := member("payments-read", "payments", groupA)
+		write := member("payments-write", "payments", groupB)
+
+		read.Trip()
+		assert.Eventually(t, isOpen(write), time.Second, 5*time.Millisecond)
+		assert.NoError(t, groupA.Close())
+	})
+}
+
+// countingCoordinator adds fleet counts kept on a shared map, failing
+// AddCounts while down is set
+type countingCoordinator struct {
+	*memoryCoordinator
+	mu     *sync.Mutex
+	counts map[string]SharedCounts
+	down   bool
+}
+
+func (c *countingCoordinator) AddCounts(ctx context.Context, breaker string, window time.Time, interval time.Duration, delta SharedCounts) (SharedCounts, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.down {
+		return SharedCounts{}, errors.New("store unreachable")
+	}
+	key := breaker + "/" + window.String()
+	totals := c.counts[key]
+	totals.Requests += delta.Requests
+	totals.Failures += delta.Failures
+	c.counts[key] = totals
+	return totals, nil
+}
+
+func TestSharedCircuitBreakerState(t *testing.T) {
+	config := CircuitBreakerConfig{
+		Name:             "payments",
+		Interval:         time.Minute,
+		Timeout:          time.Minute,
+		FailureThreshold: 0.5,
+		MinRequests:      4,
+	}
+	ctx := context.Background()
+	fail := func(cb CircuitBreaker, n int) {
+		for i := 0; i < n; i++ {
+			cb.Execute(ctx, func(ctx context.Context) error { return errors.New("error") })
+		}
+	}
+
+	t.Run("shares recoveries", func(t *testing.T) {
+		hub := &memoryHub{}
+		configA, configB := config, config
+		configA.Coordinator = hub.join("a")
+		configB.Coordinator = hub.join("b")
+		cbA := NewCircuitBreaker(configA)
+		cbB := NewCircuitBreaker(configB)
+
+		cbA.Trip()
+		require.Eventually(t, func() bool { return cbB.State() == StateOpen }, time.Second, 5*time.Millisecond)
+
+		cbA.Reset()
+		assert.Eventually(t, func() bool { return cbB.State() == StateClosed }, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("trips on fleet failure counts", func(t *testing.T) {
+		hub := &memoryHub{}
+		var mu sync.Mutex
+		counts := map[string]SharedCounts{}
+		configA, configB := config, config
+		configA.Coordinator = &countingCoordinator{memoryCoordinator: hub.join("a"), mu: &mu, counts: counts}
+		configB.Coordinator = &countingCoordinator{memoryCoordinator: hub.join("b"), mu: &mu, counts: counts}
+		cbA := NewCircuitBreaker(configA)
+		cbB := NewCircuitBreaker(configB)
+
+		fail(cbA, 2)
+		fail(cbB, 2)
+
+		assert.Eventually(t, func() bool {
+			return cbA.State() == StateOpen && cbB.State() == StateOpen
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("falls back to local counts when the store is unreachable", func(t *testing.T) {
+		configA := config
+		configA.Coordinator = &countingCoordinator{
+			memoryCoordinator: (&memoryHub{}).join("a"),
+			mu:                &sync.Mutex{},
+			counts:            map[string]SharedCounts{},
+			down:              true,
+		}
+		cbA := NewCircuitBreaker(configA)
+
+		fail(cbA, 3)
+		assert.Equal(t, StateClosed, cbA.State())
+		fail(cbA, 1)
+		assert.Equal(t, StateOpen, cbA.State())
+	})
+}
+
+func TestNewCoordinator(t *testing.T) {
+	t.Run("none backend returns nil", func(t *testing.T) {
+		c, err := NewCoordinator(DefaultCoordinationConfig())
+		assert.NoError(t, err)
+		assert.Nil(t, c)
+	})
+
+	t.Run("unknown backend fails", func(t *testing.T) {
+		_, err := NewCoordinator(CoordinationConfig{Backend: "carrier-pigeon"})
+		assert.Error(t, err)
+	})
+
+	t.Run("uses registered backend", func(t *testing.T) {
+		hub := &memoryHub{}
+		RegisterCoordinator("memory-test", func(config CoordinationConfig) (Coordinator, error) {
+			return hub.join(config.NodeName), nil
+		})
+
+		c, err := NewCoordinator(CoordinationConfig{Backend: "memory-test", NodeName: "a"})
+		require.NoError(t, err)
+		assert.NotNil(t, c)
+		assert.Panics(t, func() {
+			RegisterCoordinator("memory-test", func(CoordinationConfig) (Coordinator, error) { return nil, nil })
+		})
+	})
+}
@@ -0,0 +1,50 @@
+package resilience
+
+import (
+	"context"
+	"time"
+)
+
+type highPriorityKey struct{}
+
+// WithHighPriority returns a context whose calls pass a degraded circuit
+// breaker, e.g. checkout traffic as opposed to recommendations
+func WithHighPriority(ctx context.Context) context.Context {
+	return context.WithValue(ctx, highPriorityKey{}, true)
+}
+
+// IsHighPriority reports whether ctx was marked by WithHighPriority
+func IsHighPriority(ctx context.Context) bool {
+	priority, _ := ctx.Value(highPriorityKey{}).(bool)
+	return priority
+}
+
+// counting reports whether outcomes are recorded as closed-state outcomes:
+// closed, or degraded where only priority calls are measured
+func (cb *circuitBreaker) counting() bool {
+	return cb.state == StateClosed || cb.state == StateDegraded
+}
+
+// checkDegraded moves a closed circuit to degraded once the failure ratio
+// reaches DegradedThreshold, and a degraded one back to closed once it
+// falls below; both need MinRequests. The caller holds cb.mu.
+func (cb *circuitBreaker) checkDegraded(now time.Time) {
+	if cb.forced || !cb.counting() || cb.config.TripMode == TripModeProportional {
+		return
+	}
+	if cb.config.DegradedThreshold <= 0 || now.Before(cb.warmUntil) {
+		return
+	}
+	c := cb.tripCounts(now)
+	if c.requests < cb.config.MinRequests {
+		return
+	}
+
+	degraded := float64(c.totalFailures)/float64(c.requests) >= cb.config.DegradedThreshold
+	switch {
+	case degraded && cb.state == StateClosed:
+		cb.setState(StateDegraded, now)
+	case !degraded && cb.state == StateDegraded:
+		cb.setState(StateClosed, now)
+	}
+}
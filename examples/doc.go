@@ -0,0 +1,15 @@
+// Package examples holds runnable reference integrations of resiliencex,
+// each a main package wired through fx:
+//
+//   - httpclient calls a flaky HTTP API through an executor with the full
+//     policy: rate limiter, bulkhead, timeout, shared circuit breaker and
+//     retry
+//   - grpcserver sheds load with a bulkhead in a server interceptor and
+//     calls itself through resiliencegrpc's client interceptor
+//   - worker runs periodic jobs whose failed deliveries are retried in the
+//     background from a durable, file-backed queue
+//
+// Each program starts a local stand-in for its dependency, so it runs
+// without external services; its test runs it to completion so the
+// examples keep compiling and working as the library changes.
+package examples
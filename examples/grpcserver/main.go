@@ -0,0 +1,189 @@
+// Command grpcserver serves gRPC health checks behind a load-shedding
+// interceptor: calls beyond the bulkhead's capacity are rejected at once
+// with ResourceExhausted and a give-up trailer, instead of queuing. A
+// client calls it through resiliencegrpc's interceptor, which stops
+// retrying when told to give up, so shedding is not amplified by retries.
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gostratum/core"
+	"github.com/gostratum/core/logx"
+	"go.uber.org/fx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	resilience "github.com/gostratum/resiliencex"
+	"github.com/gostratum/resiliencex/resiliencefx"
+	"github.com/gostratum/resiliencex/resiliencegrpc"
+)
+
+func main() {
+	core.Run(newApp())
+}
+
+// newApp wires the example; opts are appended, e.g. by tests
+func newApp(opts ...fx.Option) *fx.App {
+	return core.New(
+		resiliencefx.Module(),
+		fx.Provide(newServer, newClient),
+		fx.Invoke(run),
+		fx.Options(opts...),
+	)
+}
+
+// server is the gRPC server and the address it listens on
+type server struct {
+	Addr string
+}
+
+func newServer(lc fx.Lifecycle) (*server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	// Admit two calls at a time and shed the rest without queuing
+	shedder := resilience.NewBuilder().
+		WithName("health-server").
+		WithBulkhead(resilience.BulkheadConfig{Name: "health-server", MaxConcurrent: 2}).
+		Build()
+
+	s := grpc.NewServer(grpc.UnaryInterceptor(sheddingInterceptor(shedder)))
+	healthpb.RegisterHealthServer(s, slowHealth{})
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go s.Serve(listener)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			s.GracefulStop()
+			return nil
+		},
+	})
+	return &server{Addr: listener.Addr().String()}, nil
+}
+
+// sheddingInterceptor runs handlers under executor without waiting for
+// capacity, rejecting calls it can't admit with ResourceExhausted
+func sheddingInterceptor(executor resilience.Executor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := executor.ExecuteWithResult(ctx, func(ctx context.Context) (any, error) {
+			return handler(ctx, req)
+		}, resilience.NoWait())
+
+		if errors.Is(err, resilience.ErrBulkheadFull) || errors.Is(err, resilience.ErrRateLimitExceeded) {
+			grpc.SetTrailer(ctx, metadata.Pairs(resiliencegrpc.GiveUpTrailer, "true"))
+			return nil, status.Error(codes.ResourceExhausted, "server overloaded")
+		}
+		return resp, err
+	}
+}
+
+// slowHealth answers health checks after some work
+type slowHealth struct {
+	healthpb.UnimplementedHealthServer
+}
+
+func (slowHealth) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	select {
+	case <-time.After(50 * time.Millisecond):
+		return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// newClient dials the server with a retrying client executor
+func newClient(lc fx.Lifecycle, srv *server) (healthpb.HealthClient, error) {
+	executor := resilience.NewBuilder().
+		WithName("health-client").
+		WithRetry(resilience.RetryConfig{Name: "health-client", MaxAttempts: 3, InitialInterval: 10 * time.Millisecond}).
+		Build()
+
+	conn, err := grpc.NewClient(srv.Addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(resiliencegrpc.UnaryClientInterceptor(executor)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	lc.Append(fx.Hook{OnStop: func(context.Context) error { return conn.Close() }})
+	return healthpb.NewHealthClient(conn), nil
+}
+
+// run sends a burst of checks once the app has started, then shuts it down
+func run(lc fx.Lifecycle, shutdowner fx.Shutdowner, client healthpb.HealthClient, logger logx.Logger) {
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				err := burst(ctx, client, logger)
+				if err != nil {
+					logger.Error("Health check burst failed", logx.Err(err))
+				}
+				shutdowner.Shutdown(fx.ExitCode(exitCode(err)))
+			}()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+}
+
+// burst sends ten concurrent checks and counts those served and shed
+func burst(ctx context.Context, client healthpb.HealthClient, logger logx.Logger) error {
+	var served, shed atomic.Int64
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+			switch status.Code(err) {
+			case codes.OK:
+				served.Add(1)
+			case codes.ResourceExhausted:
+				shed.Add(1)
+			default:
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	if err := <-errs; err != nil {
+		return err
+	}
+	if served.Load() == 0 {
+		return errors.New("no check was served")
+	}
+	logger.Info("Health check burst done",
+		logx.Int64("served", served.Load()),
+		logx.Int64("shed", shed.Load()),
+	)
+	return nil
+}
+
+// exitCode maps the outcome of the example to a process exit code
+func exitCode(err error) int {
+	if err != nil {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,140 @@
+// Command httpclient calls a flaky HTTP API through an executor with the
+// full policy: rate limiter, bulkhead, timeout, circuit breaker and retry.
+// The breaker comes from the fx module's registry, so every client of the
+// API shares it.
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gostratum/core"
+	"github.com/gostratum/core/logx"
+	"go.uber.org/fx"
+
+	resilience "github.com/gostratum/resiliencex"
+	"github.com/gostratum/resiliencex/resiliencefx"
+	"github.com/gostratum/resiliencex/resiliencehttp"
+)
+
+func main() {
+	core.Run(newApp())
+}
+
+// newApp wires the example; opts are appended, e.g. by tests
+func newApp(opts ...fx.Option) *fx.App {
+	return core.New(
+		resiliencefx.Module(),
+		fx.Provide(newUpstream, newClient),
+		fx.Invoke(run),
+		fx.Options(opts...),
+	)
+}
+
+// upstream is a local inventory API failing every third request, standing
+// in for a real dependency
+type upstream struct {
+	URL string
+}
+
+func newUpstream(lc fx.Lifecycle) (*upstream, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	var requests atomic.Int64
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1)%3 == 0 {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, "item %s: in stock", r.URL.Path)
+	})}
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go server.Serve(listener)
+			return nil
+		},
+		OnStop: server.Shutdown,
+	})
+	return &upstream{URL: "http://" + listener.Addr().String()}, nil
+}
+
+// newClient builds an HTTP client whose requests run through the full policy
+func newClient(breakers resilience.CircuitBreakerRegistry) *http.Client {
+	executor := resilience.NewBuilder().
+		WithName("inventory-api").
+		WithRateLimiter(resilience.RateLimiterConfig{Name: "inventory-api", Rate: 50, Burst: 10}).
+		WithBulkhead(resilience.BulkheadConfig{Name: "inventory-api", MaxConcurrent: 8, MaxQueueSize: 16}).
+		WithTimeout(2 * time.Second).
+		WithSharedCircuitBreaker(breakers.CircuitBreaker("inventory-api")).
+		WithRetry(resilience.RetryConfig{
+			Name:            "inventory-api",
+			MaxAttempts:     3,
+			InitialInterval: 10 * time.Millisecond,
+			MaxElapsedTime:  time.Second,
+		}).
+		Build()
+
+	return &http.Client{Transport: resiliencehttp.NewTransport(http.DefaultTransport, executor)}
+}
+
+// run fetches a few items once the app has started, then shuts it down
+func run(lc fx.Lifecycle, shutdowner fx.Shutdowner, client *http.Client, api *upstream, logger logx.Logger) {
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				err := fetchItems(ctx, client, api.URL, logger)
+				if err != nil {
+					logger.Error("Fetching items failed", logx.Err(err))
+				}
+				shutdowner.Shutdown(fx.ExitCode(exitCode(err)))
+			}()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+}
+
+// fetchItems gets ten items; the failures of the upstream are retried away
+func fetchItems(ctx context.Context, client *http.Client, url string, logger logx.Logger) error {
+	for i := 1; i <= 10; i++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/items/%d", url, i), nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("item %d: %s", i, resp.Status)
+		}
+		logger.Info(string(body))
+	}
+	return nil
+}
+
+// exitCode maps the outcome of the example to a process exit code
+func exitCode(err error) int {
+	if err != nil {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,216 @@
+// Command worker runs a periodic job that queues webhook deliveries on an
+// AsyncExecutor. Failed deliveries are retried in the background with
+// backoff; a file-backed QueueStore keeps pending deliveries across
+// restarts, and those found at startup are restored into the queue.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gostratum/core"
+	"github.com/gostratum/core/logx"
+	"go.uber.org/fx"
+
+	resilience "github.com/gostratum/resiliencex"
+	"github.com/gostratum/resiliencex/jobs"
+	"github.com/gostratum/resiliencex/resiliencefx"
+)
+
+// deliveries is the number of webhooks the example delivers before exiting
+const deliveries = 3
+
+func main() {
+	core.Run(newApp(os.TempDir()))
+}
+
+// newApp wires the example, keeping the queue in a directory under dir;
+// opts are appended, e.g. by tests
+func newApp(dir string, opts ...fx.Option) *fx.App {
+	return core.New(
+		resiliencefx.Module(),
+		fx.Supply(queueDir(filepath.Join(dir, "resiliencex-worker-queue"))),
+		fx.Provide(newQueueStore, newWebhooks, newAsyncExecutor),
+		fx.Invoke(restore, run),
+		fx.Options(opts...),
+	)
+}
+
+// queueDir is the directory of the durable delivery queue
+type queueDir string
+
+// fileQueueStore is a QueueStore keeping each queued call in a JSON file
+type fileQueueStore struct {
+	dir string
+}
+
+func newQueueStore(dir queueDir) (*fileQueueStore, error) {
+	if err := os.MkdirAll(string(dir), 0o755); err != nil {
+		return nil, err
+	}
+	return &fileQueueStore{dir: string(dir)}, nil
+}
+
+func (s *fileQueueStore) Save(call resilience.QueuedCall) error {
+	data, err := json.Marshal(call)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path(call.ID), data, 0o644)
+}
+
+func (s *fileQueueStore) Delete(id string) error {
+	err := os.Remove(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Load returns the calls left in the store by a previous run
+func (s *fileQueueStore) Load() ([]resilience.QueuedCall, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var calls []resilience.QueuedCall
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var call resilience.QueuedCall
+		if err := json.Unmarshal(data, &call); err != nil {
+			return nil, err
+		}
+		calls = append(calls, call)
+	}
+	return calls, nil
+}
+
+func (s *fileQueueStore) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+// webhooks stands in for a webhook endpoint that fails every first
+// delivery attempt
+type webhooks struct {
+	mu        sync.Mutex
+	attempts  map[string]int
+	delivered atomic.Int64
+	done      chan struct{}
+	doneOnce  sync.Once
+}
+
+func newWebhooks() *webhooks {
+	return &webhooks{attempts: make(map[string]int), done: make(chan struct{})}
+}
+
+// deliver returns the delivery of the webhook id
+func (w *webhooks) deliver(id string) func(context.Context) error {
+	return func(ctx context.Context) error {
+		w.mu.Lock()
+		w.attempts[id]++
+		attempt := w.attempts[id]
+		w.mu.Unlock()
+
+		if attempt == 1 {
+			return fmt.Errorf("webhook %s: endpoint unavailable", id)
+		}
+		if w.delivered.Add(1) >= deliveries {
+			w.doneOnce.Do(func() { close(w.done) })
+		}
+		return nil
+	}
+}
+
+// newAsyncExecutor queues deliveries on the store, retrying failed ones
+func newAsyncExecutor(lc fx.Lifecycle, store *fileQueueStore, logger logx.Logger) resilience.AsyncExecutor {
+	executor := resilience.NewBuilder().
+		WithName("webhooks").
+		WithTimeout(time.Second).
+		Build()
+
+	async := resilience.NewAsyncExecutor(executor, resilience.AsyncConfig{
+		QueueSize:       100,
+		MaxAttempts:     5,
+		InitialInterval: 10 * time.Millisecond,
+		Store:           store,
+		OnDeadLetter: func(call resilience.QueuedCall, err error) {
+			logger.Error("Webhook dropped", logx.String("id", call.ID), logx.Err(err))
+		},
+	})
+	lc.Append(fx.Hook{OnStop: async.Close})
+	return async
+}
+
+// restore re-enqueues the deliveries a previous run left pending
+func restore(lc fx.Lifecycle, async resilience.AsyncExecutor, store *fileQueueStore, hooks *webhooks, logger logx.Logger) {
+	lc.Append(fx.Hook{OnStart: func(ctx context.Context) error {
+		calls, err := store.Load()
+		if err != nil {
+			return err
+		}
+		for _, call := range calls {
+			if err := async.Restore(context.Background(), call, hooks.deliver(call.ID)); err != nil {
+				return err
+			}
+		}
+		logger.Info("Restored pending webhooks", logx.Int("count", len(calls)))
+		return nil
+	}})
+}
+
+// run queues a delivery on every run of a periodic job until enough
+// webhooks were delivered, then shuts the app down
+func run(lc fx.Lifecycle, shutdowner fx.Shutdowner, async resilience.AsyncExecutor, hooks *webhooks, logger logx.Logger) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	var runs atomic.Int64
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				_ = jobs.Run(ctx, async, jobs.Config{
+					Name:           "webhook-producer",
+					Interval:       20 * time.Millisecond,
+					RunImmediately: true,
+					OnError: func(name string, err error) {
+						logger.Warn("Job run failed", logx.String("job", name), logx.Err(err))
+					},
+				}, func(ctx context.Context) error {
+					id := fmt.Sprintf("event-%d-%d", time.Now().UnixNano(), runs.Add(1))
+					return async.Go(ctx, id, hooks.deliver(id))
+				})
+			}()
+			go func() {
+				defer wg.Done()
+				select {
+				case <-hooks.done:
+					logger.Info("Webhooks delivered", logx.Int64("count", hooks.delivered.Load()))
+					shutdowner.Shutdown()
+				case <-ctx.Done():
+				}
+			}()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			wg.Wait()
+			return nil
+		},
+	})
+}
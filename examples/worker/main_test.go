@@ -0,0 +1,21 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExample(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	app := newApp(t.TempDir())
+	require.NoError(t, app.Start(ctx))
+	signal := <-app.Wait()
+	require.NoError(t, app.Stop(ctx))
+	assert.Equal(t, 0, signal.ExitCode)
+}
@@ -0,0 +1,135 @@
+package resilience
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Pattern identifies a resilience pattern applied by an Executor
+type Pattern string
+
+const (
+	// PatternCircuitBreaker identifies the circuit breaker pattern
+	PatternCircuitBreaker Pattern = "circuit_breaker"
+
+	// PatternRetry identifies the retry pattern
+	PatternRetry Pattern = "retry"
+
+	// PatternRateLimiter identifies the rate limiter pattern
+	PatternRateLimiter Pattern = "rate_limiter"
+
+	// PatternBulkhead identifies the bulkhead pattern
+	PatternBulkhead Pattern = "bulkhead"
+
+	// PatternTimeout identifies the timeout pattern
+	PatternTimeout Pattern = "timeout"
+
+	// PatternHedge identifies the hedging pattern
+	PatternHedge Pattern = "hedge"
+)
+
+// FlagProvider supplies feature flags that control patterns at runtime
+type FlagProvider interface {
+	// Rollout returns the fraction of calls (0.0-1.0) for which the pattern
+	// is applied by the named executor. Values are cached by the executor.
+	Rollout(executor string, pattern Pattern) float64
+}
+
+// FlagProviderFunc adapts a function to the FlagProvider interface
+type FlagProviderFunc func(executor string, pattern Pattern) float64
+
+// Rollout calls f(executor, pattern)
+func (f FlagProviderFunc) Rollout(executor string, pattern Pattern) float64 {
+	return f(executor, pattern)
+}
+
+type disabledPatternsKey struct{}
+
+// WithDisabledPatterns returns a context that bypasses the given patterns
+// for calls made with it, regardless of the configured FlagProvider
+func WithDisabledPatterns(ctx context.Context, patterns ...Pattern) context.Context {
+	disabled := make(map[Pattern]struct{})
+	if existing, ok := ctx.Value(disabledPatternsKey{}).(map[Pattern]struct{}); ok {
+		for p := range existing {
+			disabled[p] = struct{}{}
+		}
+	}
+	for _, p := range patterns {
+		disabled[p] = struct{}{}
+	}
+	return context.WithValue(ctx, disabledPatternsKey{}, disabled)
+}
+
+// patternDisabled reports whether the pattern was disabled via the context
+func patternDisabled(ctx context.Context, pattern Pattern) bool {
+	disabled, ok := ctx.Value(disabledPatternsKey{}).(map[Pattern]struct{})
+	if !ok {
+		return false
+	}
+	_, found := disabled[pattern]
+	return found
+}
+
+// DefaultFlagTTL is how long flag values are cached when no TTL is given
+const DefaultFlagTTL = 10 * time.Second
+
+// flagCache caches FlagProvider lookups per pattern
+type flagCache struct {
+	provider FlagProvider
+	executor string
+	ttl      time.Duration
+	mu       sync.RWMutex
+	entries  map[Pattern]flagEntry
+	now      func() time.Time
+}
+
+type flagEntry struct {
+	rollout float64
+	expires time.Time
+}
+
+func newFlagCache(provider FlagProvider, executor string, ttl time.Duration, clock Clock) *flagCache {
+	if ttl <= 0 {
+		ttl = DefaultFlagTTL
+	}
+	return &flagCache{
+		provider: provider,
+		executor: executor,
+		ttl:      ttl,
+		entries:  make(map[Pattern]flagEntry),
+		now:      clock.Now,
+	}
+}
+
+// enabled reports whether the pattern applies to the current call
+func (f *flagCache) enabled(pattern Pattern) bool {
+	rollout := f.rollout(pattern)
+	if rollout >= 1 {
+		return true
+	}
+	if rollout <= 0 {
+		return false
+	}
+	return rand.Float64() < rollout
+}
+
+func (f *flagCache) rollout(pattern Pattern) float64 {
+	now := f.now()
+
+	f.mu.RLock()
+	entry, ok := f.entries[pattern]
+	f.mu.RUnlock()
+	if ok && now.Before(entry.expires) {
+		return entry.rollout
+	}
+
+	rollout := f.provider.Rollout(f.executor, pattern)
+
+	f.mu.Lock()
+	f.entries[pattern] = flagEntry{rollout: rollout, expires: now.Add(f.ttl)}
+	f.mu.Unlock()
+
+	return rollout
+}
@@ -0,0 +1,71 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlagProvider(t *testing.T) {
+	t.Run("disables pattern when rollout is zero", func(t *testing.T) {
+		provider := FlagProviderFunc(func(executor string, pattern Pattern) float64 {
+			if pattern == PatternRetry {
+				return 0
+			}
+			return 1
+		})
+		executor := NewBuilder().
+			WithRetry(RetryConfig{Name: "test", MaxAttempts: 3, InitialInterval: time.Millisecond}).
+			WithFlagProvider(provider, time.Minute).
+			Build()
+
+		attempts := 0
+		err := executor.Execute(context.Background(), func(ctx context.Context) error {
+			attempts++
+			return errors.New("error")
+		})
+
+		assert.Error(t, err)
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("caches provider lookups", func(t *testing.T) {
+		var lookups atomic.Int32
+		provider := FlagProviderFunc(func(executor string, pattern Pattern) float64 {
+			lookups.Add(1)
+			assert.Equal(t, "flagged", executor)
+			return 1
+		})
+		executor := NewBuilder().
+			WithName("flagged").
+			WithRetry(DefaultRetryConfig()).
+			WithFlagProvider(provider, time.Minute).
+			Build()
+
+		for i := 0; i < 5; i++ {
+			assert.NoError(t, executor.Execute(context.Background(), func(ctx context.Context) error { return nil }))
+		}
+		assert.Equal(t, int32(1), lookups.Load())
+	})
+}
+
+func TestWithDisabledPatterns(t *testing.T) {
+	executor := NewBuilder().
+		WithRetry(RetryConfig{Name: "test", MaxAttempts: 3, InitialInterval: time.Millisecond}).
+		Build()
+
+	ctx := WithDisabledPatterns(context.Background(), PatternRetry)
+	assert.True(t, patternDisabled(ctx, PatternRetry))
+	assert.False(t, patternDisabled(ctx, PatternTimeout))
+
+	attempts := 0
+	executor.Execute(ctx, func(ctx context.Context) error {
+		attempts++
+		return errors.New("error")
+	})
+	assert.Equal(t, 1, attempts)
+}
@@ -0,0 +1,74 @@
+package resilience
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// smoothedGauge is a time-weighted exponential moving average of a gauge.
+// Each raw value is weighted by how long it was held, so bursts of updates
+// don't dominate the average.
+type smoothedGauge struct {
+	mu       sync.Mutex
+	tau      time.Duration
+	raw      float64
+	smoothed float64
+	last     time.Time
+}
+
+// newSmoothedGauge creates a gauge starting at value; tau is the time
+// constant of the average, after which a step change is ~63% reflected
+func newSmoothedGauge(tau time.Duration, value float64, now time.Time) *smoothedGauge {
+	return &smoothedGauge{
+		tau:      tau,
+		raw:      value,
+		smoothed: value,
+		last:     now,
+	}
+}
+
+// set records a new raw value and returns the smoothed value
+func (g *smoothedGauge) set(now time.Time, value float64) float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.decay(now)
+	g.raw = value
+	return g.smoothed
+}
+
+// add changes the raw value by delta and returns the smoothed value
+func (g *smoothedGauge) add(now time.Time, delta float64) float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.decay(now)
+	g.raw += delta
+	return g.smoothed
+}
+
+// value returns the smoothed value at now
+func (g *smoothedGauge) value(now time.Time) float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.decay(now)
+	return g.smoothed
+}
+
+// decay moves the smoothed value toward the raw value held since the last update
+func (g *smoothedGauge) decay(now time.Time) {
+	elapsed := now.Sub(g.last)
+	if elapsed <= 0 {
+		return
+	}
+	g.last = now
+
+	if g.tau <= 0 {
+		g.smoothed = g.raw
+		return
+	}
+	alpha := 1 - math.Exp(-float64(elapsed)/float64(g.tau))
+	g.smoothed += alpha * (g.raw - g.smoothed)
+}
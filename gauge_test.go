@@ -0,0 +1,36 @@
+package resilience
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSmoothedGauge(t *testing.T) {
+	start := time.Unix(1000, 0)
+
+	t.Run("follows a step change gradually", func(t *testing.T) {
+		g := newSmoothedGauge(10*time.Second, 0, start)
+		g.set(start, 100)
+
+		assert.Equal(t, 0.0, g.value(start))
+		assert.InDelta(t, 63.2, g.value(start.Add(10*time.Second)), 0.1)
+		assert.InDelta(t, 100, g.value(start.Add(time.Hour)), 0.001)
+	})
+
+	t.Run("ignores short spikes", func(t *testing.T) {
+		g := newSmoothedGauge(10*time.Second, 0, start)
+		g.add(start, 10)
+		g.add(start.Add(10*time.Millisecond), -10)
+
+		assert.Less(t, g.value(start.Add(time.Second)), 0.1)
+	})
+
+	t.Run("zero time constant tracks the raw value", func(t *testing.T) {
+		g := newSmoothedGauge(0, 0, start)
+		g.set(start, 5)
+
+		assert.Equal(t, 5.0, g.value(start.Add(time.Nanosecond)))
+	})
+}
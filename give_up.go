@@ -0,0 +1,40 @@
+package resilience
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// giveUpSignal is set when a downstream asks callers to stop retrying
+type giveUpSignal struct {
+	set    atomic.Bool
+	parent *giveUpSignal
+}
+
+type giveUpKey struct{}
+
+// withGiveUpSignal returns a context carrying a new signal linked to any
+// signal already in ctx, so giving up stops every enclosing retry loop and
+// hedge
+func withGiveUpSignal(ctx context.Context) (context.Context, *giveUpSignal) {
+	parent, _ := ctx.Value(giveUpKey{}).(*giveUpSignal)
+	signal := &giveUpSignal{parent: parent}
+	return context.WithValue(ctx, giveUpKey{}, signal), signal
+}
+
+// GiveUp tells the retry loops executing the current call to stop after
+// the current attempt, and hedges to launch no further attempts. Adapters
+// call it when a downstream response says the request must not be retried
+// (for example a "do not retry" header); the attempt's error is then
+// returned to the caller as is.
+// It is a no-op when ctx is not running under a retry or hedge.
+func GiveUp(ctx context.Context) {
+	signal, _ := ctx.Value(giveUpKey{}).(*giveUpSignal)
+	for ; signal != nil; signal = signal.parent {
+		signal.set.Store(true)
+	}
+}
+
+func (s *giveUpSignal) isSet() bool {
+	return s.set.Load()
+}
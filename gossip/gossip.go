@@ -0,0 +1,256 @@
+// Package gossip provides a memberlist based coordination backend that shares
+// circuit breaker trips and rate limiter usage between service instances
+// without external storage.
+//
+// Import the package for its side effect to make the "gossip" backend
+// available to the resilience module:
+//
+//	import _ "github.com/gostratum/resiliencex/gossip"
+package gossip
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/hashicorp/memberlist"
+
+	resilience "github.com/gostratum/resiliencex"
+)
+
+// Backend is the coordination backend name
+const Backend = "gossip"
+
+func init() {
+	resilience.RegisterCoordinator(Backend, New)
+}
+
+// coordinator implements resilience.UsageCoordinator on top of memberlist
+type coordinator struct {
+	list          *memberlist.Memberlist
+	mu            sync.RWMutex
+	handlers      map[int]func(resilience.CoordinationEvent)
+	usageHandlers map[int]func(resilience.RateUsage)
+	nextID        int
+}
+
+// message is exchanged between members: a breaker transition, or rate usage
+// when Usage is set. Members predating usage sharing decode a usage message
+// as the transition of an unnamed breaker, which no breaker matches.
+type message struct {
+	resilience.CoordinationEvent
+	Usage *resilience.RateUsage `json:"usage,omitempty"`
+}
+
+// New creates a gossip coordinator and joins the configured peers.
+// The "profile" option selects the memberlist profile ("lan", "wan" or
+// "local"; default "lan").
+func New(config resilience.CoordinationConfig) (resilience.Coordinator, error) {
+	mlConfig, err := memberlistConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &coordinator{
+		handlers:      make(map[int]func(resilience.CoordinationEvent)),
+		usageHandlers: make(map[int]func(resilience.RateUsage)),
+	}
+	mlConfig.Delegate = &delegate{coordinator: c}
+
+	list, err := memberlist.Create(mlConfig)
+	if err != nil {
+		return nil, fmt.Errorf("gossip: create memberlist: %w", err)
+	}
+	c.list = list
+
+	if len(config.Peers) > 0 {
+		if _, err := list.Join(config.Peers); err != nil {
+			list.Shutdown()
+			return nil, fmt.Errorf("gossip: join peers: %w", err)
+		}
+	}
+
+	return c, nil
+}
+
+func memberlistConfig(config resilience.CoordinationConfig) (*memberlist.Config, error) {
+	var mlConfig *memberlist.Config
+	switch profile := config.Options["profile"]; profile {
+	case "", "lan":
+		mlConfig = memberlist.DefaultLANConfig()
+	case "wan":
+		mlConfig = memberlist.DefaultWANConfig()
+	case "local":
+		mlConfig = memberlist.DefaultLocalConfig()
+	default:
+		return nil, fmt.Errorf("gossip: unknown profile %q", profile)
+	}
+
+	if config.NodeName != "" {
+		mlConfig.Name = config.NodeName
+	}
+	if config.BindAddr != "" {
+		mlConfig.BindAddr = config.BindAddr
+		mlConfig.AdvertiseAddr = config.BindAddr
+	}
+	mlConfig.BindPort = config.BindPort
+	mlConfig.AdvertisePort = config.BindPort
+	if v, ok := config.Options["log"]; !ok || v != "true" {
+		mlConfig.LogOutput = io.Discard
+	}
+	if v, ok := config.Options["gossip_nodes"]; ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("gossip: invalid gossip_nodes %q: %w", v, err)
+		}
+		mlConfig.GossipNodes = n
+	}
+
+	return mlConfig, nil
+}
+
+// Publish sends the event directly to every other member
+func (c *coordinator) Publish(ctx context.Context, event resilience.CoordinationEvent) error {
+	if event.Origin == "" {
+		event.Origin = c.list.LocalNode().Name
+	}
+
+	msg, err := json.Marshal(message{CoordinationEvent: event})
+	if err != nil {
+		return fmt.Errorf("gossip: encode event: %w", err)
+	}
+	return c.broadcast(ctx, msg)
+}
+
+// PublishUsage sends the usage directly to every other member
+func (c *coordinator) PublishUsage(ctx context.Context, usage resilience.RateUsage) error {
+	if usage.Origin == "" {
+		usage.Origin = c.list.LocalNode().Name
+	}
+
+	msg, err := json.Marshal(message{Usage: &usage})
+	if err != nil {
+		return fmt.Errorf("gossip: encode usage: %w", err)
+	}
+	return c.broadcast(ctx, msg)
+}
+
+// broadcast sends msg to every other member
+func (c *coordinator) broadcast(ctx context.Context, msg []byte) error {
+	local := c.list.LocalNode()
+	var firstErr error
+	for _, member := range c.list.Members() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if member.Name == local.Name {
+			continue
+		}
+		if err := c.list.SendBestEffort(member, msg); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("gossip: send to %s: %w", member.Name, err)
+		}
+	}
+	return firstErr
+}
+
+func (c *coordinator) Subscribe(handler func(resilience.CoordinationEvent)) func() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := c.nextID
+	c.nextID++
+	c.handlers[id] = handler
+
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		delete(c.handlers, id)
+	}
+}
+
+func (c *coordinator) SubscribeUsage(handler func(resilience.RateUsage)) func() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := c.nextID
+	c.nextID++
+	c.usageHandlers[id] = handler
+
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		delete(c.usageHandlers, id)
+	}
+}
+
+func (c *coordinator) Close() error {
+	if err := c.list.Leave(0); err != nil {
+		c.list.Shutdown()
+		return fmt.Errorf("gossip: leave: %w", err)
+	}
+	return c.list.Shutdown()
+}
+
+// Members returns the number of known alive members, including this one
+func (c *coordinator) Members() int {
+	return c.list.NumMembers()
+}
+
+func (c *coordinator) deliver(msg []byte) {
+	var m message
+	if err := json.Unmarshal(msg, &m); err != nil {
+		return
+	}
+	if m.Usage != nil {
+		c.deliverUsage(*m.Usage)
+		return
+	}
+	event := m.CoordinationEvent
+
+	c.mu.RLock()
+	handlers := make([]func(resilience.CoordinationEvent), 0, len(c.handlers))
+	for _, h := range c.handlers {
+		handlers = append(handlers, h)
+	}
+	c.mu.RUnlock()
+
+	for _, h := range handlers {
+		h(event)
+	}
+}
+
+func (c *coordinator) deliverUsage(usage resilience.RateUsage) {
+	c.mu.RLock()
+	handlers := make([]func(resilience.RateUsage), 0, len(c.usageHandlers))
+	for _, h := range c.usageHandlers {
+		handlers = append(handlers, h)
+	}
+	c.mu.RUnlock()
+
+	for _, h := range handlers {
+		h(usage)
+	}
+}
+
+// delegate receives user messages from memberlist
+type delegate struct {
+	coordinator *coordinator
+}
+
+func (d *delegate) NodeMeta(limit int) []byte { return nil }
+
+func (d *delegate) NotifyMsg(msg []byte) {
+	// memberlist reuses the buffer after NotifyMsg returns
+	buf := make([]byte, len(msg))
+	copy(buf, msg)
+	d.coordinator.deliver(buf)
+}
+
+func (d *delegate) GetBroadcasts(overhead, limit int) [][]byte { return nil }
+
+func (d *delegate) LocalState(join bool) []byte { return nil }
+
+func (d *delegate) MergeRemoteState(buf []byte, join bool) {}
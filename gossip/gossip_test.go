@@ -0,0 +1,106 @@
+package gossip
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	resilience "github.com/gostratum/resiliencex"
+)
+
+func newLocalCoordinator(t *testing.T, name string, peers ...string) *coordinator {
+	t.Helper()
+	c, err := resilience.NewCoordinator(resilience.CoordinationConfig{
+		Backend:  Backend,
+		NodeName: name,
+		BindAddr: "127.0.0.1",
+		Peers:    peers,
+		Options:  map[string]string{"profile": "local"},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+	return c.(*coordinator)
+}
+
+func TestGossipSharesTrips(t *testing.T) {
+	a := newLocalCoordinator(t, "a")
+	addr := "127.0.0.1:" + strconv.Itoa(int(a.list.LocalNode().Port))
+	b := newLocalCoordinator(t, "b", addr)
+
+	require.Eventually(t, func() bool {
+		return a.Members() == 2 && b.Members() == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	config := resilience.CircuitBreakerConfig{
+		Name:             "payments",
+		Interval:         time.Minute,
+		Timeout:          time.Minute,
+		FailureThreshold: 0.5,
+		MinRequests:      2,
+	}
+	configA, configB := config, config
+	configA.Coordinator = a
+	configB.Coordinator = b
+	cbA := resilience.NewCircuitBreaker(configA)
+	cbB := resilience.NewCircuitBreaker(configB)
+
+	ctx := context.Background()
+	cbA.Execute(ctx, func(ctx context.Context) error { return errors.New("error") })
+	cbA.Execute(ctx, func(ctx context.Context) error { return errors.New("error") })
+	assert.Equal(t, resilience.StateOpen, cbA.State())
+
+	assert.Eventually(t, func() bool {
+		return cbB.State() == resilience.StateOpen
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestGossipSharesRateUsage(t *testing.T) {
+	a := newLocalCoordinator(t, "a")
+	addr := "127.0.0.1:" + strconv.Itoa(int(a.list.LocalNode().Port))
+	b := newLocalCoordinator(t, "b", addr)
+
+	require.Eventually(t, func() bool {
+		return a.Members() == 2 && b.Members() == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	clock := resilience.NewFakeClock(time.Now())
+	config := resilience.RateLimiterConfig{
+		Name:          "search",
+		Rate:          100,
+		Burst:         1000,
+		Clock:         clock,
+		ShareUsage:    true,
+		UsageInterval: time.Second,
+	}
+	configA, configB := config, config
+	configA.Coordinator = a
+	configB.Coordinator = b
+	rlA := resilience.NewRateLimiter(configA)
+	rlB := resilience.NewRateLimiter(configB)
+
+	for i := 0; i < 90; i++ {
+		require.True(t, rlA.Allow())
+	}
+	clock.Advance(time.Second)
+	require.True(t, rlA.Allow())
+	require.True(t, rlB.Allow())
+
+	// The busy instance takes most of the fleet rate
+	assert.Eventually(t, func() bool {
+		return rlA.Stats().Rate > 80 && rlB.Stats().Rate < 20
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.InDelta(t, 100, rlA.Stats().Rate+rlB.Stats().Rate, 0.01)
+}
+
+func TestGossipInvalidProfile(t *testing.T) {
+	_, err := New(resilience.CoordinationConfig{
+		Backend: Backend,
+		Options: map[string]string{"profile": "galactic"},
+	})
+	assert.Error(t, err)
+}
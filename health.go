@@ -0,0 +1,134 @@
+package resilience
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// healthLatencyBaseline is the time constant of the latency average that
+// LatencyTrend compares against
+const healthLatencyBaseline = 10 * time.Minute
+
+// DefaultHealthScorer scores an open circuit 0 and otherwise scales the
+// share of successful, admitted calls down for rising latency, a half-open
+// circuit and a degraded one
+func DefaultHealthScorer(signals HealthSignals) float64 {
+	if signals.State == StateOpen {
+		return 0
+	}
+	score := (1 - signals.FailureRate) * (1 - signals.RejectionRate)
+	if signals.LatencyTrend > 1 {
+		score /= signals.LatencyTrend
+	}
+	switch signals.State {
+	case StateHalfOpen:
+		score *= 0.5
+	case StateDegraded:
+		score *= 0.75
+	}
+	return min(max(score, 0), 1)
+}
+
+// callCounters counts an executor's calls and admission rejections
+type callCounters struct {
+	calls    atomic.Uint64
+	rejected atomic.Uint64
+}
+
+// observe counts a completed call
+func (c *callCounters) observe(err error) {
+	c.calls.Add(1)
+	if err != nil && callOutcome(err) == OutcomeRejected {
+		c.rejected.Add(1)
+	}
+}
+
+// healthTracker keeps what a registry needs between evaluations of one
+// executor's health: the latency baseline and the call counts last seen
+type healthTracker struct {
+	mu       sync.Mutex
+	latency  *smoothedGauge
+	calls    uint64
+	rejected uint64
+}
+
+func (r *registry) HealthScore(name string) float64 {
+	e := r.load(name)
+	if e == nil {
+		return 0
+	}
+	signals := r.healthSignals(name, e)
+
+	r.mu.Lock()
+	scorer := r.scorer
+	r.mu.Unlock()
+	if scorer == nil {
+		scorer = DefaultHealthScorer
+	}
+	return min(max(scorer(signals), 0), 1)
+}
+
+func (r *registry) SetHealthScorer(scorer HealthScorer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.scorer = scorer
+}
+
+// healthSignals gathers the signals of executor e and advances its tracker.
+// Keyed breakers report the worst state, the combined failure rate and the
+// slowest latency among their keys.
+func (r *registry) healthSignals(name string, e Executor) HealthSignals {
+	signals := HealthSignals{State: StateClosed, LatencyTrend: 1}
+	var requests, failures uint32
+	for _, cb := range r.breakers(name) {
+		m := cb.Metrics()
+		if healthRank(m.State) > healthRank(signals.State) {
+			signals.State = m.State
+		}
+		requests += m.Requests
+		failures += m.Failures + m.Timeouts
+		signals.LatencyP95 = max(signals.LatencyP95, m.LatencyP95)
+	}
+	if requests > 0 {
+		signals.FailureRate = min(float64(failures)/float64(requests), 1)
+	}
+
+	now := r.now()
+	v, _ := r.health.LoadOrStore(name, &healthTracker{})
+	tracker := v.(*healthTracker)
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	if signals.LatencyP95 > 0 {
+		if tracker.latency == nil {
+			tracker.latency = newSmoothedGauge(healthLatencyBaseline, float64(signals.LatencyP95), now)
+		}
+		if baseline := tracker.latency.set(now, float64(signals.LatencyP95)); baseline > 0 {
+			signals.LatencyTrend = float64(signals.LatencyP95) / baseline
+		}
+	}
+
+	if ex, ok := e.(*executor); ok {
+		calls, rejected := ex.counters.calls.Load(), ex.counters.rejected.Load()
+		if calls > tracker.calls {
+			signals.RejectionRate = float64(rejected-tracker.rejected) / float64(calls-tracker.calls)
+		}
+		tracker.calls, tracker.rejected = calls, rejected
+	}
+	return signals
+}
+
+// healthRank orders states from healthy to unavailable
+func healthRank(state CircuitState) int {
+	switch state {
+	case StateOpen:
+		return 3
+	case StateHalfOpen:
+		return 2
+	case StateDegraded:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,137 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultHealthScorer(t *testing.T) {
+	tests := []struct {
+		name    string
+		signals HealthSignals
+		want    float64
+	}{
+		{"healthy", HealthSignals{LatencyTrend: 1}, 1},
+		{"open", HealthSignals{State: StateOpen}, 0},
+		{"failing", HealthSignals{FailureRate: 0.25, LatencyTrend: 1}, 0.75},
+		{"rejecting", HealthSignals{RejectionRate: 0.5, LatencyTrend: 1}, 0.5},
+		{"slowing down", HealthSignals{LatencyTrend: 2}, 0.5},
+		{"speeding up", HealthSignals{LatencyTrend: 0.5}, 1},
+		{"half-open", HealthSignals{State: StateHalfOpen, LatencyTrend: 1}, 0.5},
+		{"degraded", HealthSignals{State: StateDegraded, FailureRate: 0.2, LatencyTrend: 1}, 0.6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, DefaultHealthScorer(tt.signals), 1e-9)
+		})
+	}
+}
+
+func TestRegistryHealthScore(t *testing.T) {
+	ctx := context.Background()
+	ok := func(ctx context.Context) error { return nil }
+	fail := func(ctx context.Context) error { return errors.New("boom") }
+	newRegistry := func() Registry {
+		return NewRegistry(func(name string) Executor {
+			cbConfig := DefaultCircuitBreakerConfig()
+			cbConfig.Name = name
+			cbConfig.MinRequests = 100
+			return NewBuilder().
+				WithName(name).
+				WithCircuitBreaker(cbConfig).
+				Build()
+		})
+	}
+
+	t.Run("scores unregistered names 0", func(t *testing.T) {
+		r := newRegistry()
+		assert.Zero(t, r.HealthScore("payments"))
+		assert.Empty(t, r.Names())
+	})
+
+	t.Run("scores a healthy executor 1", func(t *testing.T) {
+		r := newRegistry()
+		require.NoError(t, r.Executor("payments").Execute(ctx, ok))
+		assert.Equal(t, 1.0, r.HealthScore("payments"))
+	})
+
+	t.Run("lowers the score with the failure rate", func(t *testing.T) {
+		r := newRegistry()
+		e := r.Executor("payments")
+		for i := 0; i < 3; i++ {
+			require.NoError(t, e.Execute(ctx, ok))
+		}
+		assert.Error(t, e.Execute(ctx, fail))
+		assert.InDelta(t, 0.75, r.HealthScore("payments"), 1e-9)
+	})
+
+	t.Run("scores an open circuit 0", func(t *testing.T) {
+		r := newRegistry()
+		r.Executor("payments")
+		_, err := r.ForceOpenMatching("payments")
+		require.NoError(t, err)
+		assert.Zero(t, r.HealthScore("payments"))
+	})
+
+	t.Run("counts rejections since the previous score", func(t *testing.T) {
+		r := NewRegistry(func(name string) Executor {
+			return NewBuilder().
+				WithName(name).
+				WithRateLimiter(RateLimiterConfig{Name: name, Rate: 0.001, Burst: 1}).
+				Build()
+		})
+		e := r.Executor("search")
+		require.NoError(t, e.TryExecute(ctx, ok))
+		assert.ErrorIs(t, e.TryExecute(ctx, ok), ErrRateLimitExceeded)
+		assert.InDelta(t, 0.5, r.HealthScore("search"), 1e-9)
+
+		// No calls since the previous score
+		assert.Equal(t, 1.0, r.HealthScore("search"))
+	})
+
+	t.Run("uses the scorer set", func(t *testing.T) {
+		r := newRegistry()
+		require.NoError(t, r.Executor("payments").Execute(ctx, ok))
+
+		var got HealthSignals
+		r.SetHealthScorer(func(signals HealthSignals) float64 {
+			got = signals
+			return 7
+		})
+		assert.Equal(t, 1.0, r.HealthScore("payments"), "scores are clamped to [0, 1]")
+		assert.Equal(t, StateClosed, got.State)
+		assert.Equal(t, 1.0, got.LatencyTrend)
+
+		r.SetHealthScorer(nil)
+		assert.Equal(t, 1.0, r.HealthScore("payments"))
+	})
+
+	t.Run("compares latency against its baseline", func(t *testing.T) {
+		r := newRegistry()
+		clock := time.Now()
+		r.(*registry).now = func() time.Time { return clock }
+		e := r.Executor("payments")
+		require.NoError(t, e.Execute(ctx, ok))
+
+		var trend float64
+		r.SetHealthScorer(func(signals HealthSignals) float64 {
+			trend = signals.LatencyTrend
+			return 1
+		})
+		r.HealthScore("payments")
+		assert.Equal(t, 1.0, trend)
+
+		require.NoError(t, e.Execute(ctx, func(ctx context.Context) error {
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		}))
+		clock = clock.Add(time.Minute)
+		r.HealthScore("payments")
+		assert.Greater(t, trend, 1.0)
+	})
+}
@@ -0,0 +1,169 @@
+package resilience
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// hedge implements the Hedge interface
+type hedge struct {
+	config  HedgeConfig
+	clock   Clock
+	mu      sync.Mutex
+	samples []time.Duration // ring buffer of recent successful latencies
+	next    int
+	full    bool
+	delay   time.Duration // cached trigger delay, zero when stale
+}
+
+// hedgeResult is the outcome of one attempt
+type hedgeResult struct {
+	value   any
+	err     error
+	elapsed time.Duration
+}
+
+// NewHedge creates a new hedge. Zero values are filled from the defaults,
+// except Percentile: zero uses the static Delay only.
+func NewHedge(config HedgeConfig) Hedge {
+	if config.MaxHedges <= 0 {
+		config.MaxHedges = DefaultHedgeConfig().MaxHedges
+	}
+	if config.Delay <= 0 {
+		config.Delay = DefaultHedgeConfig().Delay
+	}
+	if config.WindowSize <= 0 {
+		config.WindowSize = DefaultHedgeConfig().WindowSize
+	}
+	if config.MinSamples <= 0 {
+		config.MinSamples = DefaultHedgeConfig().MinSamples
+	}
+	if config.MinSamples > config.WindowSize {
+		config.MinSamples = config.WindowSize
+	}
+
+	return &hedge{
+		config:  config,
+		clock:   clockOrSystem(config.Clock),
+		samples: make([]time.Duration, config.WindowSize),
+	}
+}
+
+func (h *hedge) Name() string {
+	return h.config.Name
+}
+
+func (h *hedge) Delay() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.config.Percentile <= 0 {
+		return h.config.Delay
+	}
+	count := h.next
+	if h.full {
+		count = len(h.samples)
+	}
+	if count < h.config.MinSamples {
+		return h.config.Delay
+	}
+	if h.delay == 0 {
+		h.delay = percentile(h.samples[:count], h.config.Percentile)
+	}
+	return h.delay
+}
+
+// record adds a successful call latency
+func (h *hedge) record(elapsed time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.samples[h.next] = elapsed
+	h.next++
+	if h.next == len(h.samples) {
+		h.next = 0
+		h.full = true
+	}
+	h.delay = 0
+}
+
+func (h *hedge) ExecuteWithResult(ctx context.Context, fn func(context.Context) (any, error)) (any, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ctx, giveUp := withGiveUpSignal(ctx)
+
+	// Buffered so abandoned attempts never block
+	results := make(chan hedgeResult, h.config.MaxHedges+1)
+	launch := func() {
+		go func() {
+			start := h.clock.Now()
+			value, err := fn(ctx)
+			results <- hedgeResult{value: value, err: err, elapsed: h.clock.Now().Sub(start)}
+		}()
+	}
+
+	launch()
+	launched, inFlight := 1, 1
+	delay := h.Delay()
+	timer := h.clock.NewTimer(delay)
+	defer timer.Stop()
+	hedgeC := timer.C()
+
+	var lastErr error
+	for {
+		select {
+		case r := <-results:
+			inFlight--
+			if r.err == nil {
+				h.record(r.elapsed)
+				return r.value, nil
+			}
+			lastErr = r.err
+			if inFlight == 0 {
+				return nil, lastErr
+			}
+
+		case <-hedgeC:
+			// A downstream that asked not to be retried gets no duplicates
+			if giveUp.isSet() {
+				hedgeC = nil
+				continue
+			}
+			if h.config.OnHedge != nil {
+				h.config.OnHedge(h.config.Name, launched, delay)
+			}
+			launch()
+			launched++
+			inFlight++
+			if launched > h.config.MaxHedges {
+				hedgeC = nil
+			} else {
+				delay = h.Delay()
+				timer.Reset(delay)
+			}
+
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// percentile returns the p-th percentile (0-1) of samples using the
+// nearest-rank method
+func percentile(samples []time.Duration, p float64) time.Duration {
+	sorted := make([]time.Duration, len(samples))
+	copy(sorted, samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
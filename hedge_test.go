@@ -0,0 +1,160 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHedge(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("returns the first successful attempt", func(t *testing.T) {
+		var attempts atomic.Int32
+		h := NewHedge(HedgeConfig{Name: "test", Delay: 10 * time.Millisecond})
+
+		result, err := h.ExecuteWithResult(ctx, func(ctx context.Context) (any, error) {
+			if attempts.Add(1) == 1 {
+				<-ctx.Done() // the primary hangs until the hedge wins
+				return nil, ctx.Err()
+			}
+			return "hedged", nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "hedged", result)
+		assert.Equal(t, int32(2), attempts.Load())
+	})
+
+	t.Run("does not hedge fast calls", func(t *testing.T) {
+		var attempts atomic.Int32
+		h := NewHedge(HedgeConfig{Delay: time.Second})
+
+		_, err := h.ExecuteWithResult(ctx, func(ctx context.Context) (any, error) {
+			attempts.Add(1)
+			return nil, nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), attempts.Load())
+	})
+
+	t.Run("returns the error when every attempt fails", func(t *testing.T) {
+		h := NewHedge(HedgeConfig{Delay: time.Millisecond, MaxHedges: 2})
+		failing := errors.New("unavailable")
+
+		_, err := h.ExecuteWithResult(ctx, func(ctx context.Context) (any, error) {
+			time.Sleep(5 * time.Millisecond)
+			return nil, failing
+		})
+		assert.ErrorIs(t, err, failing)
+	})
+
+	t.Run("stops hedging once the downstream gives up", func(t *testing.T) {
+		var attempts atomic.Int32
+		h := NewHedge(HedgeConfig{Delay: time.Millisecond, MaxHedges: 3})
+		failing := errors.New("do not retry")
+
+		_, err := h.ExecuteWithResult(ctx, func(ctx context.Context) (any, error) {
+			attempts.Add(1)
+			GiveUp(ctx)
+			time.Sleep(20 * time.Millisecond)
+			return nil, failing
+		})
+		assert.ErrorIs(t, err, failing)
+		assert.Equal(t, int32(1), attempts.Load())
+	})
+
+	t.Run("triggers at the latency percentile", func(t *testing.T) {
+		h := NewHedge(HedgeConfig{
+			Delay:      time.Second,
+			Percentile: 0.95,
+			WindowSize: 100,
+			MinSamples: 20,
+		}).(*hedge)
+
+		for i := 0; i < 19; i++ {
+			h.record(time.Duration(i+1) * time.Millisecond)
+		}
+		assert.Equal(t, time.Second, h.Delay(), "static delay until enough samples")
+
+		for i := 19; i < 100; i++ {
+			h.record(time.Duration(i+1) * time.Millisecond)
+		}
+		assert.Equal(t, 95*time.Millisecond, h.Delay())
+
+		// Old latencies roll out of the window
+		for i := 0; i < 100; i++ {
+			h.record(10 * time.Millisecond)
+		}
+		assert.Equal(t, 10*time.Millisecond, h.Delay())
+	})
+
+	t.Run("waits for the delay on the configured clock", func(t *testing.T) {
+		clock := NewFakeClock(time.Unix(0, 0))
+		h := NewHedge(HedgeConfig{Delay: time.Hour, Clock: clock})
+
+		var attempts atomic.Int32
+		done := make(chan any)
+		go func() {
+			result, _ := h.ExecuteWithResult(ctx, func(ctx context.Context) (any, error) {
+				if attempts.Add(1) == 1 {
+					<-ctx.Done()
+					return nil, ctx.Err()
+				}
+				return "hedged", nil
+			})
+			done <- result
+		}()
+
+		require.NoError(t, clock.BlockUntil(ctx, 1))
+		require.Eventually(t, func() bool { return attempts.Load() == 1 }, time.Second, time.Millisecond)
+		clock.Advance(time.Hour)
+		assert.Equal(t, "hedged", <-done)
+	})
+
+	t.Run("reports hedges", func(t *testing.T) {
+		var hedges []int
+		h := NewHedge(HedgeConfig{
+			Name:      "search",
+			Delay:     time.Millisecond,
+			MaxHedges: 2,
+			OnHedge: func(name string, attempt int, delay time.Duration) {
+				hedges = append(hedges, attempt)
+			},
+		})
+
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		_, err := h.ExecuteWithResult(ctx, func(ctx context.Context) (any, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, []int{1, 2}, hedges)
+	})
+}
+
+func TestBuilderWithHedge(t *testing.T) {
+	var attempts atomic.Int32
+	exec := NewBuilder().
+		WithTimeout(time.Second).
+		WithHedge(HedgeConfig{Delay: 10 * time.Millisecond}).
+		Build()
+
+	err := exec.Execute(context.Background(), func(ctx context.Context) error {
+		if attempts.Add(1) == 1 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), attempts.Load())
+}
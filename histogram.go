@@ -0,0 +1,150 @@
+package resilience
+
+import (
+	"math"
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// Latency buckets are log-linear over microseconds: four linear sub-buckets
+// per power of two, so estimates are within 25% up to about 19 hours
+const (
+	latencySubBuckets  = 4
+	latencyMaxExponent = 36
+	latencyBucketCount = latencySubBuckets * latencyMaxExponent
+)
+
+// latencyCounts holds one period of latency observations
+type latencyCounts [latencyBucketCount]atomic.Uint64
+
+// latencyHistogram records call latencies over the current and previous
+// interval without locking, so percentiles cover between one and two
+// intervals of recent calls. A zero interval never discards observations.
+type latencyHistogram struct {
+	interval time.Duration
+	rotated  atomic.Int64 // unix nanos of the last rotation
+	current  atomic.Pointer[latencyCounts]
+	previous atomic.Pointer[latencyCounts]
+}
+
+// newLatencyHistogram creates a histogram rotating every interval
+func newLatencyHistogram(interval time.Duration, now time.Time) *latencyHistogram {
+	h := &latencyHistogram{interval: interval}
+	h.rotated.Store(now.UnixNano())
+	h.current.Store(new(latencyCounts))
+	h.previous.Store(new(latencyCounts))
+	return h
+}
+
+// record adds a latency observed at now
+func (h *latencyHistogram) record(now time.Time, latency time.Duration) {
+	h.rotate(now)
+	h.current.Load()[latencyBucket(latency)].Add(1)
+}
+
+// rotate starts a new period once the interval has elapsed; the caller that
+// wins the swap rotates and the others record into whichever period is
+// current
+func (h *latencyHistogram) rotate(now time.Time) {
+	if h.interval <= 0 {
+		return
+	}
+	last := h.rotated.Load()
+	elapsed := time.Duration(now.UnixNano() - last)
+	if elapsed < h.interval || !h.rotated.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+
+	if elapsed >= 2*h.interval {
+		h.previous.Store(new(latencyCounts))
+	} else {
+		h.previous.Store(h.current.Load())
+	}
+	h.current.Store(new(latencyCounts))
+}
+
+// quantiles returns the latency at each quantile (0-1) of the recorded
+// calls, interpolating within buckets; zero when nothing was recorded
+func (h *latencyHistogram) quantiles(now time.Time, qs ...float64) []time.Duration {
+	h.rotate(now)
+
+	var merged [latencyBucketCount]uint64
+	var total uint64
+	current, previous := h.current.Load(), h.previous.Load()
+	for i := range merged {
+		merged[i] = current[i].Load() + previous[i].Load()
+		total += merged[i]
+	}
+
+	out := make([]time.Duration, len(qs))
+	if total == 0 {
+		return out
+	}
+	for j, q := range qs {
+		rank := uint64(math.Ceil(q * float64(total)))
+		rank = min(max(rank, 1), total)
+
+		var seen uint64
+		for i, n := range merged {
+			if n == 0 || seen+n < rank {
+				seen += n
+				continue
+			}
+			lower, upper := latencyBucketBounds(i)
+			frac := float64(rank-seen) / float64(n)
+			out[j] = lower + time.Duration(frac*float64(upper-lower))
+			break
+		}
+	}
+	return out
+}
+
+// shareAbove returns the share of recorded calls that took threshold or
+// longer, interpolating within the bucket holding threshold; zero when
+// nothing was recorded
+func (h *latencyHistogram) shareAbove(now time.Time, threshold time.Duration) float64 {
+	h.rotate(now)
+
+	current, previous := h.current.Load(), h.previous.Load()
+	split := latencyBucket(threshold)
+	var total, above float64
+	for i := range latencyBucketCount {
+		n := float64(current[i].Load() + previous[i].Load())
+		total += n
+		switch {
+		case i > split:
+			above += n
+		case i == split:
+			lower, upper := latencyBucketBounds(i)
+			above += n * float64(max(upper-threshold, 0)) / float64(upper-lower)
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return above / total
+}
+
+// latencyBucket returns the bucket holding latency
+func latencyBucket(latency time.Duration) int {
+	us := latency.Microseconds()
+	if us < latencySubBuckets {
+		return int(max(us, 0))
+	}
+	exp := bits.Len64(uint64(us)) - 1
+	sub := int(us>>(exp-2)) & (latencySubBuckets - 1)
+	return min(latencySubBuckets*(exp-1)+sub, latencyBucketCount-1)
+}
+
+// latencyBucketBounds returns the range of latencies in bucket i
+func latencyBucketBounds(i int) (lower, upper time.Duration) {
+	if i < latencySubBuckets {
+		return time.Duration(i) * time.Microsecond, time.Duration(i+1) * time.Microsecond
+	}
+	exp := i/latencySubBuckets + 1
+	sub := i % latencySubBuckets
+	width := int64(1) << (exp - 2)
+	lo := (latencySubBuckets + int64(sub)) * width
+	return time.Duration(lo) * time.Microsecond, time.Duration(lo+width) * time.Microsecond
+}
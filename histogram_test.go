@@ -0,0 +1,55 @@
+package resilience
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatencyHistogram(t *testing.T) {
+	start := time.Unix(1000, 0)
+
+	t.Run("estimates percentiles within bucket resolution", func(t *testing.T) {
+		h := newLatencyHistogram(time.Minute, start)
+		for i := 1; i <= 1000; i++ {
+			h.record(start, time.Duration(i)*time.Millisecond)
+		}
+
+		q := h.quantiles(start, 0.5, 0.95, 0.99)
+		assert.InEpsilon(t, float64(500*time.Millisecond), float64(q[0]), 0.25)
+		assert.InEpsilon(t, float64(950*time.Millisecond), float64(q[1]), 0.25)
+		assert.InEpsilon(t, float64(990*time.Millisecond), float64(q[2]), 0.25)
+		assert.LessOrEqual(t, q[0], q[1])
+		assert.LessOrEqual(t, q[1], q[2])
+	})
+
+	t.Run("empty histogram reports zero", func(t *testing.T) {
+		h := newLatencyHistogram(time.Minute, start)
+		assert.Equal(t, []time.Duration{0}, h.quantiles(start, 0.99))
+	})
+
+	t.Run("forgets latencies older than two intervals", func(t *testing.T) {
+		h := newLatencyHistogram(time.Minute, start)
+		h.record(start, time.Second)
+
+		later := start.Add(90 * time.Second)
+		h.record(later, time.Millisecond)
+		assert.Greater(t, h.quantiles(later, 1)[0], 500*time.Millisecond)
+
+		h.record(start.Add(150*time.Second), time.Millisecond)
+		assert.Less(t, h.quantiles(start.Add(150*time.Second), 1)[0], 2*time.Millisecond)
+	})
+
+	t.Run("buckets are contiguous", func(t *testing.T) {
+		_, prev := latencyBucketBounds(0)
+		for i := 1; i < latencyBucketCount; i++ {
+			lower, upper := latencyBucketBounds(i)
+			assert.Equal(t, prev, lower, "bucket %d", i)
+			assert.Equal(t, i, latencyBucket(lower), "bucket %d", i)
+			prev = upper
+		}
+		assert.Equal(t, latencyBucketCount-1, latencyBucket(1000*time.Hour))
+		assert.Equal(t, 0, latencyBucket(-time.Second))
+	})
+}
@@ -0,0 +1,141 @@
+// Package jobs runs periodic background work under a resilience Executor.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	resilience "github.com/gostratum/resiliencex"
+)
+
+// SkipReason explains why a scheduled run did not execute
+type SkipReason string
+
+const (
+	// SkipOverlap means the previous run was still in progress
+	SkipOverlap SkipReason = "overlap"
+
+	// SkipCircuitOpen means the executor's circuit breaker was open
+	SkipCircuitOpen SkipReason = "circuit_open"
+)
+
+// Config configures a periodic job
+type Config struct {
+	// Name is the job identifier passed to callbacks
+	Name string
+
+	// Interval is the time between scheduled runs
+	Interval time.Duration
+
+	// Jitter randomizes each interval by ±Jitter (0.0-1.0) to avoid
+	// synchronized runs across replicas
+	Jitter float64
+
+	// RunImmediately runs the job once at start instead of after one interval
+	RunImmediately bool
+
+	// OnSkip is called when a scheduled run is skipped
+	OnSkip func(name string, reason SkipReason)
+
+	// OnError is called when a run fails
+	OnError func(name string, err error)
+
+	// Clock tells time for the interval; nil uses the system clock
+	Clock resilience.Clock
+}
+
+// DefaultJitter is the jitter applied by RunPeriodic
+const DefaultJitter = 0.1
+
+// RunPeriodic executes fn under exec every interval (±10% jitter) until ctx
+// is done. Runs never overlap and are skipped while the circuit is open.
+// It blocks until ctx is done and any in-flight run has returned.
+func RunPeriodic(ctx context.Context, exec resilience.Executor, interval time.Duration, fn func(context.Context) error) error {
+	return Run(ctx, exec, Config{
+		Name:     exec.Name(),
+		Interval: interval,
+		Jitter:   DefaultJitter,
+	}, fn)
+}
+
+// Run executes fn under exec according to config until ctx is done
+func Run(ctx context.Context, exec resilience.Executor, config Config, fn func(context.Context) error) error {
+	if config.Interval <= 0 {
+		return errors.New("jobs: interval must be positive")
+	}
+
+	var (
+		running atomic.Bool
+		wg      sync.WaitGroup
+	)
+	defer wg.Wait()
+
+	run := func() {
+		if !running.CompareAndSwap(false, true) {
+			if config.OnSkip != nil {
+				config.OnSkip(config.Name, SkipOverlap)
+			}
+			return
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer running.Store(false)
+
+			err := exec.Execute(ctx, fn)
+			switch {
+			case err == nil:
+			case errors.Is(err, resilience.ErrCircuitOpen):
+				if config.OnSkip != nil {
+					config.OnSkip(config.Name, SkipCircuitOpen)
+				}
+			default:
+				if config.OnError != nil {
+					config.OnError(config.Name, err)
+				}
+			}
+		}()
+	}
+
+	if config.RunImmediately {
+		run()
+	}
+
+	clock := config.Clock
+	if clock == nil {
+		clock = resilience.SystemClock()
+	}
+	timer := clock.NewTimer(jittered(config.Interval, config.Jitter))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C():
+			run()
+			timer.Reset(jittered(config.Interval, config.Jitter))
+		}
+	}
+}
+
+// jittered returns interval randomized by ±jitter
+func jittered(interval time.Duration, jitter float64) time.Duration {
+	if jitter <= 0 {
+		return interval
+	}
+	if jitter > 1 {
+		jitter = 1
+	}
+	delta := jitter * float64(interval)
+	d := float64(interval) - delta + rand.Float64()*2*delta
+	if d < 1 {
+		d = 1
+	}
+	return time.Duration(d)
+}
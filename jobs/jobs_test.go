@@ -0,0 +1,138 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	resilience "github.com/gostratum/resiliencex"
+)
+
+func TestRunPeriodic(t *testing.T) {
+	exec := resilience.NewBuilder().WithName("job").Build()
+	ctx, cancel := context.WithTimeout(context.Background(), 55*time.Millisecond)
+	defer cancel()
+
+	var runs atomic.Int32
+	err := RunPeriodic(ctx, exec, 10*time.Millisecond, func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	})
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.GreaterOrEqual(t, runs.Load(), int32(3))
+}
+
+func TestRunClock(t *testing.T) {
+	clock := resilience.NewFakeClock(time.Unix(0, 0))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	runs := make(chan struct{}, 2)
+	done := make(chan error)
+	go func() {
+		done <- Run(ctx, resilience.NewBuilder().Build(), Config{Interval: time.Hour, Clock: clock}, func(ctx context.Context) error {
+			runs <- struct{}{}
+			return nil
+		})
+	}()
+
+	for range 2 {
+		require.NoError(t, clock.BlockUntil(ctx, 1))
+		clock.Advance(time.Hour)
+		<-runs
+	}
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestRunSkips(t *testing.T) {
+	t.Run("prevents overlapping runs", func(t *testing.T) {
+		exec := resilience.NewBuilder().Build()
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		var mu sync.Mutex
+		var skips []SkipReason
+		var concurrent, maxConcurrent atomic.Int32
+		Run(ctx, exec, Config{
+			Name:           "slow",
+			Interval:       5 * time.Millisecond,
+			RunImmediately: true,
+			OnSkip: func(name string, reason SkipReason) {
+				mu.Lock()
+				skips = append(skips, reason)
+				mu.Unlock()
+			},
+		}, func(ctx context.Context) error {
+			n := concurrent.Add(1)
+			if n > maxConcurrent.Load() {
+				maxConcurrent.Store(n)
+			}
+			time.Sleep(20 * time.Millisecond)
+			concurrent.Add(-1)
+			return nil
+		})
+
+		assert.Equal(t, int32(1), maxConcurrent.Load())
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Contains(t, skips, SkipOverlap)
+	})
+
+	t.Run("reports open circuit as skip", func(t *testing.T) {
+		exec := resilience.NewBuilder().
+			WithCircuitBreaker(resilience.CircuitBreakerConfig{
+				Name:             "job",
+				Timeout:          time.Minute,
+				FailureThreshold: 0.5,
+				MinRequests:      1,
+			}).
+			Build()
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+		defer cancel()
+
+		var mu sync.Mutex
+		var skips []SkipReason
+		var errs []error
+		Run(ctx, exec, Config{
+			Interval: 5 * time.Millisecond,
+			OnSkip: func(name string, reason SkipReason) {
+				mu.Lock()
+				skips = append(skips, reason)
+				mu.Unlock()
+			},
+			OnError: func(name string, err error) {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			},
+		}, func(ctx context.Context) error {
+			return errors.New("error")
+		})
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Len(t, errs, 1)
+		assert.Contains(t, skips, SkipCircuitOpen)
+	})
+
+	t.Run("rejects non-positive interval", func(t *testing.T) {
+		err := Run(context.Background(), resilience.NewBuilder().Build(), Config{}, func(ctx context.Context) error { return nil })
+		assert.Error(t, err)
+	})
+}
+
+func TestJittered(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		d := jittered(100*time.Millisecond, 0.2)
+		assert.GreaterOrEqual(t, d, 80*time.Millisecond)
+		assert.LessOrEqual(t, d, 120*time.Millisecond)
+	}
+	assert.Equal(t, time.Second, jittered(time.Second, 0))
+}
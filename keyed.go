@@ -0,0 +1,165 @@
+package resilience
+
+import (
+	"container/list"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultMaxBreakerKeys is the key bound used when none is given
+const DefaultMaxBreakerKeys = 1000
+
+type breakerKeyKey struct{}
+
+// WithBreakerKey returns a context whose calls use the keyed circuit
+// breaker for key, e.g. a downstream host or gRPC method
+func WithBreakerKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, breakerKeyKey{}, key)
+}
+
+// BreakerKeyFromContext returns the key set by WithBreakerKey. It is the
+// KeyFunc used when none is configured.
+func BreakerKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(breakerKeyKey{}).(string)
+	return key
+}
+
+// keyedCircuitBreaker implements the KeyedCircuitBreaker interface
+type keyedCircuitBreaker struct {
+	config   CircuitBreakerConfig
+	maxKeys  int
+	mu       sync.Mutex
+	order    *list.List               // most recently used first
+	breakers map[string]*list.Element // key -> element holding *keyedEntry
+	rejected *keyRejections
+	forced   bool // ForceOpen applies to breakers created later
+}
+
+// keyedEntry is a breaker tracked in LRU order
+type keyedEntry struct {
+	key     string
+	breaker *circuitBreaker
+}
+
+// NewKeyedCircuitBreaker creates breakers from config on demand, one per
+// key, keeping at most maxKeys and evicting the least recently used.
+// A non-positive maxKeys uses DefaultMaxBreakerKeys.
+func NewKeyedCircuitBreaker(config CircuitBreakerConfig, maxKeys int) KeyedCircuitBreaker {
+	if maxKeys <= 0 {
+		maxKeys = DefaultMaxBreakerKeys
+	}
+	return &keyedCircuitBreaker{
+		config:   config,
+		maxKeys:  maxKeys,
+		order:    list.New(),
+		breakers: make(map[string]*list.Element),
+		rejected: newKeyRejections(clockOrSystem(config.Clock).Now, maxKeys),
+	}
+}
+
+func (k *keyedCircuitBreaker) CircuitBreaker(key string) CircuitBreaker {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if elem, ok := k.breakers[key]; ok {
+		k.order.MoveToFront(elem)
+		return elem.Value.(*keyedEntry).breaker
+	}
+
+	config := k.config
+	if key != "" {
+		config.Name = k.config.Name + "/" + key
+	}
+	cb := NewCircuitBreaker(config).(*circuitBreaker)
+	groupReject := cb.onReject
+	cb.onReject = func() {
+		k.rejected.record(key)
+		if groupReject != nil {
+			groupReject()
+		}
+	}
+	if k.forced {
+		cb.ForceOpen()
+	}
+	entry := &keyedEntry{key: key, breaker: cb}
+	k.breakers[key] = k.order.PushFront(entry)
+
+	for k.order.Len() > k.maxKeys {
+		oldest := k.order.Back()
+		k.order.Remove(oldest)
+		evicted := oldest.Value.(*keyedEntry)
+		delete(k.breakers, evicted.key)
+		evicted.breaker.close()
+	}
+	return entry.breaker
+}
+
+func (k *keyedCircuitBreaker) Keys() []string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	keys := make([]string, 0, len(k.breakers))
+	for key := range k.breakers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (k *keyedCircuitBreaker) Range(fn func(key string, breaker CircuitBreaker) bool) {
+	for _, entry := range k.entries() {
+		if !fn(entry.key, entry.breaker) {
+			return
+		}
+	}
+}
+
+// entries returns the tracked breakers in key order without touching their
+// recency, for use outside k.mu
+func (k *keyedCircuitBreaker) entries() []*keyedEntry {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	entries := make([]*keyedEntry, 0, len(k.breakers))
+	for _, elem := range k.breakers {
+		entries = append(entries, elem.Value.(*keyedEntry))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	return entries
+}
+
+func (k *keyedCircuitBreaker) ForceOpen() {
+	k.mu.Lock()
+	k.forced = true
+	k.mu.Unlock()
+
+	for _, entry := range k.entries() {
+		entry.breaker.ForceOpen()
+	}
+}
+
+func (k *keyedCircuitBreaker) Reset() {
+	k.mu.Lock()
+	k.forced = false
+	k.mu.Unlock()
+
+	for _, entry := range k.entries() {
+		entry.breaker.Reset()
+	}
+}
+
+func (k *keyedCircuitBreaker) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.breakers)
+}
+
+func (k *keyedCircuitBreaker) TopRejected(n int, window time.Duration) []KeyRejections {
+	return k.rejected.top(n, window)
+}
+
+func (k *keyedCircuitBreaker) Name() string {
+	return k.config.Name
+}
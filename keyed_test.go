@@ -0,0 +1,172 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyedCircuitBreaker(t *testing.T) {
+	config := DefaultCircuitBreakerConfig()
+	config.Name = "upstream"
+
+	t.Run("creates one breaker per key", func(t *testing.T) {
+		keyed := NewKeyedCircuitBreaker(config, 10)
+
+		a := keyed.CircuitBreaker("a.example.com")
+		assert.Same(t, a, keyed.CircuitBreaker("a.example.com"))
+		assert.NotSame(t, a, keyed.CircuitBreaker("b.example.com"))
+		assert.Equal(t, "upstream/a.example.com", a.Name())
+		assert.Equal(t, "upstream", keyed.CircuitBreaker("").Name())
+		assert.Equal(t, []string{"", "a.example.com", "b.example.com"}, keyed.Keys())
+	})
+
+	t.Run("evicts the least recently used key", func(t *testing.T) {
+		keyed := NewKeyedCircuitBreaker(config, 2)
+
+		a := keyed.CircuitBreaker("a")
+		keyed.CircuitBreaker("b")
+		keyed.CircuitBreaker("a")
+		keyed.CircuitBreaker("c")
+
+		assert.Equal(t, 2, keyed.Len())
+		assert.Equal(t, []string{"a", "c"}, keyed.Keys())
+		assert.Same(t, a, keyed.CircuitBreaker("a"))
+	})
+
+	t.Run("ranges over breakers without touching their recency", func(t *testing.T) {
+		keyed := NewKeyedCircuitBreaker(config, 2)
+		keyed.CircuitBreaker("a")
+		keyed.CircuitBreaker("b")
+
+		var keys []string
+		keyed.Range(func(key string, cb CircuitBreaker) bool {
+			keys = append(keys, key)
+			assert.Equal(t, "upstream/"+key, cb.Name())
+			return true
+		})
+		assert.Equal(t, []string{"a", "b"}, keys)
+
+		// a is still the least recently used
+		keyed.CircuitBreaker("c")
+		assert.Equal(t, []string{"b", "c"}, keyed.Keys())
+	})
+
+	t.Run("forces open breakers created later until reset", func(t *testing.T) {
+		keyed := NewKeyedCircuitBreaker(config, 10)
+		a := keyed.CircuitBreaker("a")
+
+		keyed.ForceOpen()
+		assert.Equal(t, StateOpen, a.State())
+		assert.Equal(t, StateOpen, keyed.CircuitBreaker("b").State())
+
+		keyed.Reset()
+		assert.Equal(t, StateClosed, a.State())
+		assert.Equal(t, StateClosed, keyed.CircuitBreaker("c").State())
+	})
+
+	t.Run("unsubscribes evicted breakers from the coordinator", func(t *testing.T) {
+		group := NewGroupCoordinator(nil)
+		coordinated := config
+		coordinated.Coordinator = group
+		keyed := NewKeyedCircuitBreaker(coordinated, 2)
+
+		for i := 0; i < 50; i++ {
+			keyed.CircuitBreaker(fmt.Sprintf("key-%d", i))
+		}
+
+		group.mu.RLock()
+		defer group.mu.RUnlock()
+		assert.Len(t, group.handlers, 2)
+	})
+
+	t.Run("ranks keyed breakers in their group too", func(t *testing.T) {
+		group := NewGroupCoordinator(nil)
+		grouped := config
+		grouped.Coordinator = group
+		keyed := NewKeyedCircuitBreaker(grouped, 10)
+
+		cb := keyed.CircuitBreaker("a")
+		cb.ForceOpen()
+		assert.ErrorIs(t, cb.Execute(context.Background(), func(ctx context.Context) error { return nil }), ErrCircuitOpen)
+
+		assert.Equal(t, []KeyRejections{{Key: "a", Rejections: 1}}, keyed.TopRejected(5, time.Minute))
+		assert.Equal(t, []KeyRejections{{Key: "upstream/a", Rejections: 1}}, group.TopRejected(5, time.Minute))
+	})
+
+	t.Run("defaults the key bound", func(t *testing.T) {
+		keyed := NewKeyedCircuitBreaker(config, 0).(*keyedCircuitBreaker)
+		assert.Equal(t, DefaultMaxBreakerKeys, keyed.maxKeys)
+	})
+}
+
+func TestKeyedCircuitBreakerTopRejected(t *testing.T) {
+	ctx := context.Background()
+	ok := func(ctx context.Context) error { return nil }
+	clock := NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	config := DefaultCircuitBreakerConfig()
+	config.Name = "tenants"
+	config.Clock = clock
+	keyed := NewKeyedCircuitBreaker(config, 2)
+
+	reject := func(key string, n int) {
+		cb := keyed.CircuitBreaker(key)
+		cb.ForceOpen()
+		for i := 0; i < n; i++ {
+			assert.ErrorIs(t, cb.Execute(ctx, ok), ErrCircuitOpen)
+		}
+	}
+
+	reject("acme", 2)
+	assert.NoError(t, keyed.CircuitBreaker("globex").Execute(ctx, ok))
+	clock.Advance(10 * time.Minute)
+	reject("initech", 3)
+	_, err := keyed.CircuitBreaker("initech").Allow()
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	assert.Equal(t, []KeyRejections{{Key: "initech", Rejections: 4}, {Key: "acme", Rejections: 2}}, keyed.TopRejected(5, time.Hour))
+	assert.Equal(t, []KeyRejections{{Key: "initech", Rejections: 4}}, keyed.TopRejected(1, time.Hour))
+	assert.Equal(t, []KeyRejections{{Key: "initech", Rejections: 4}}, keyed.TopRejected(5, 5*time.Minute))
+
+	clock.Advance(time.Hour)
+	assert.Empty(t, keyed.TopRejected(5, time.Hour))
+}
+
+func TestBuilderWithKeyedCircuitBreaker(t *testing.T) {
+	config := DefaultCircuitBreakerConfig()
+	config.TripStrategy = TripStrategyConsecutive
+	config.ConsecutiveFailures = 1
+	failing := errors.New("unavailable")
+	ok := func(ctx context.Context) error { return nil }
+	fail := func(ctx context.Context) error { return failing }
+
+	t.Run("isolates keys from the context", func(t *testing.T) {
+		exec := NewBuilder().WithKeyedCircuitBreaker(config, 10, nil).Build()
+		hostA := WithBreakerKey(context.Background(), "a")
+		hostB := WithBreakerKey(context.Background(), "b")
+
+		assert.ErrorIs(t, exec.Execute(hostA, fail), failing)
+		assert.ErrorIs(t, exec.Execute(hostA, ok), ErrCircuitOpen)
+		assert.NoError(t, exec.Execute(hostB, ok))
+	})
+
+	t.Run("uses a custom key function", func(t *testing.T) {
+		type methodKey struct{}
+		keyFn := func(ctx context.Context) string {
+			method, _ := ctx.Value(methodKey{}).(string)
+			return method
+		}
+		exec := NewBuilder().WithKeyedCircuitBreaker(config, 10, keyFn).Build()
+		charge := context.WithValue(context.Background(), methodKey{}, "Charge")
+		refund := context.WithValue(context.Background(), methodKey{}, "Refund")
+
+		require.ErrorIs(t, exec.Execute(charge, fail), failing)
+		assert.ErrorIs(t, exec.Execute(charge, ok), ErrCircuitOpen)
+		assert.NoError(t, exec.Execute(refund, ok))
+	})
+}
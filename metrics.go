@@ -0,0 +1,106 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// LabelValueOther replaces label values beyond MetricsConfig.MaxLabelValues
+const LabelValueOther = "other"
+
+// executorMetrics reports an executor's calls to a MetricsRecorder
+type executorMetrics struct {
+	config MetricsConfig
+	guard  *labelGuard
+}
+
+// newExecutorMetrics creates executor metrics, filling zero limits from the defaults
+func newExecutorMetrics(config MetricsConfig) *executorMetrics {
+	if config.MaxLabelKeys <= 0 {
+		config.MaxLabelKeys = DefaultMetricsConfig().MaxLabelKeys
+	}
+	if config.MaxLabelValues <= 0 {
+		config.MaxLabelValues = DefaultMetricsConfig().MaxLabelValues
+	}
+	return &executorMetrics{
+		config: config,
+		guard:  newLabelGuard(config.MaxLabelKeys, config.MaxLabelValues),
+	}
+}
+
+// record reports a completed call that took duration
+func (m *executorMetrics) record(ctx context.Context, name string, duration time.Duration, err error) {
+	call := CallMetrics{
+		Name:     name,
+		Outcome:  callOutcome(err),
+		Duration: duration,
+	}
+	if m.config.LabelExtractor != nil {
+		call.Labels = m.guard.apply(m.config.LabelExtractor(ctx))
+	}
+	m.config.Recorder.RecordCall(call)
+}
+
+// callOutcome classifies an executor error
+func callOutcome(err error) Outcome {
+	switch {
+	case err == nil:
+		return OutcomeSuccess
+	case errors.Is(err, ErrCircuitOpen), errors.Is(err, ErrRateLimitExceeded), errors.Is(err, ErrBulkheadFull):
+		return OutcomeRejected
+	default:
+		return OutcomeFailure
+	}
+}
+
+// labelGuard bounds the label keys and values reported to metrics backends
+// so a label taken from request data cannot explode series cardinality
+type labelGuard struct {
+	maxKeys   int
+	maxValues int
+	mu        sync.Mutex
+	values    map[string]map[string]struct{} // key -> values seen
+}
+
+// newLabelGuard creates a guard admitting maxKeys keys and maxValues values per key
+func newLabelGuard(maxKeys, maxValues int) *labelGuard {
+	return &labelGuard{
+		maxKeys:   maxKeys,
+		maxValues: maxValues,
+		values:    make(map[string]map[string]struct{}),
+	}
+}
+
+// apply returns labels with unknown keys beyond the key limit dropped and
+// unknown values beyond the value limit replaced with LabelValueOther
+func (g *labelGuard) apply(labels map[string]string) map[string]string {
+	if len(labels) == 0 {
+		return nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	guarded := make(map[string]string, len(labels))
+	for key, value := range labels {
+		seen, ok := g.values[key]
+		if !ok {
+			if len(g.values) >= g.maxKeys {
+				continue
+			}
+			seen = make(map[string]struct{})
+			g.values[key] = seen
+		}
+		if _, ok := seen[value]; !ok {
+			if len(seen) >= g.maxValues {
+				value = LabelValueOther
+			} else {
+				seen[value] = struct{}{}
+			}
+		}
+		guarded[key] = value
+	}
+	return guarded
+}
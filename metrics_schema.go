@@ -0,0 +1,346 @@
+package resilience
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MetricType is the kind of a metric
+type MetricType string
+
+const (
+	// MetricCounter is a monotonically increasing counter
+	MetricCounter MetricType = "counter"
+
+	// MetricGauge is a value that can go up and down
+	MetricGauge MetricType = "gauge"
+
+	// MetricHistogram is a distribution of observations
+	MetricHistogram MetricType = "histogram"
+)
+
+// Standard metric names used by resilience metrics integrations
+const (
+	MetricCallsTotal                 = "resilience_calls_total"
+	MetricCallDuration               = "resilience_call_duration_seconds"
+	MetricCircuitBreakerState        = "resilience_circuit_breaker_state"
+	MetricCircuitBreakerTransitions  = "resilience_circuit_breaker_transitions_total"
+	MetricCircuitBreakerStateSeconds = "resilience_circuit_breaker_state_seconds_total"
+	MetricCircuitBreakerOpenings     = "resilience_circuit_breaker_openings"
+	MetricRetryAttemptsTotal         = "resilience_retry_attempts_total"
+	MetricRetryCallsTotal            = "resilience_retry_calls_total"
+	MetricRateLimiterRejectionsTotal = "resilience_rate_limiter_rejections_total"
+	MetricBulkheadAvailable          = "resilience_bulkhead_available"
+	MetricBulkheadRejectionsTotal    = "resilience_bulkhead_rejections_total"
+	MetricTimeoutsTotal              = "resilience_timeouts_total"
+	MetricTimeoutStragglersTotal     = "resilience_timeout_stragglers_total"
+)
+
+// Standard metric label names
+const (
+	LabelName    = "name"
+	LabelOutcome = "outcome"
+	LabelFrom    = "from"
+	LabelTo      = "to"
+	LabelState   = "state"
+)
+
+// MetricDescriptor describes a metric in the standard schema
+type MetricDescriptor struct {
+	// Name is the metric name
+	Name string `json:"name"`
+
+	// Type is the metric type
+	Type MetricType `json:"type"`
+
+	// Help describes the metric
+	Help string `json:"help"`
+
+	// Labels are the label names attached to the metric
+	Labels []string `json:"labels"`
+}
+
+// MetricsSchema returns the standard metric names, types and labels that
+// metrics integrations should emit and dashboards can rely on.
+// The name label holds the executor or pattern instance name.
+func MetricsSchema() []MetricDescriptor {
+	return []MetricDescriptor{
+		{
+			Name:   MetricCallsTotal,
+			Type:   MetricCounter,
+			Help:   "Executor calls by outcome (success, failure, rejected).",
+			Labels: []string{LabelName, LabelOutcome},
+		},
+		{
+			Name:   MetricCallDuration,
+			Type:   MetricHistogram,
+			Help:   "Executor call latency in seconds.",
+			Labels: []string{LabelName},
+		},
+		{
+			Name:   MetricCircuitBreakerState,
+			Type:   MetricGauge,
+			Help:   "Circuit breaker state (0 closed, 1 open, 2 half-open, 3 disabled, 4 degraded).",
+			Labels: []string{LabelName},
+		},
+		{
+			Name:   MetricCircuitBreakerTransitions,
+			Type:   MetricCounter,
+			Help:   "Circuit breaker state transitions.",
+			Labels: []string{LabelName, LabelFrom, LabelTo},
+		},
+		{
+			Name:   MetricCircuitBreakerStateSeconds,
+			Type:   MetricCounter,
+			Help:   "Seconds spent in each circuit breaker state, added when the state is left.",
+			Labels: []string{LabelName, LabelState},
+		},
+		{
+			Name:   MetricCircuitBreakerOpenings,
+			Type:   MetricGauge,
+			Help:   "Circuit breaker openings during the last metrics interval.",
+			Labels: []string{LabelName},
+		},
+		{
+			Name:   MetricRetryAttemptsTotal,
+			Type:   MetricCounter,
+			Help:   "Retry attempts after the first call.",
+			Labels: []string{LabelName},
+		},
+		{
+			Name:   MetricRetryCallsTotal,
+			Type:   MetricCounter,
+			Help:   "Retried calls by outcome (first_attempt, retried, exhausted, aborted).",
+			Labels: []string{LabelName, LabelOutcome},
+		},
+		{
+			Name:   MetricRateLimiterRejectionsTotal,
+			Type:   MetricCounter,
+			Help:   "Calls rejected by the rate limiter.",
+			Labels: []string{LabelName},
+		},
+		{
+			Name:   MetricBulkheadAvailable,
+			Type:   MetricGauge,
+			Help:   "Available bulkhead slots.",
+			Labels: []string{LabelName},
+		},
+		{
+			Name:   MetricBulkheadRejectionsTotal,
+			Type:   MetricCounter,
+			Help:   "Calls rejected because the bulkhead was full.",
+			Labels: []string{LabelName},
+		},
+		{
+			Name:   MetricTimeoutsTotal,
+			Type:   MetricCounter,
+			Help:   "Calls that exceeded their timeout.",
+			Labels: []string{LabelName},
+		},
+		{
+			Name:   MetricTimeoutStragglersTotal,
+			Type:   MetricCounter,
+			Help:   "Timed out functions still running past the straggler threshold.",
+			Labels: []string{LabelName},
+		},
+	}
+}
+
+// grafanaPanel is a dashboard panel definition
+type grafanaPanel struct {
+	title string
+	expr  string
+	unit  string
+}
+
+// patternLister is implemented by executors that know the patterns they
+// apply, so dashboards only show the panels those patterns feed
+type patternLister interface {
+	patterns() []Pattern
+}
+
+// allPatterns is every pattern, assumed for executors that do not list
+// theirs
+var allPatterns = []Pattern{
+	PatternCircuitBreaker, PatternRetry, PatternRateLimiter,
+	PatternBulkhead, PatternTimeout, PatternHedge,
+}
+
+// GrafanaDashboard generates a Grafana dashboard JSON model for the standard
+// metrics schema. The dashboard has a name variable listing the given
+// executors, call panels, and the panels of the patterns the executors are
+// configured with, using a Prometheus data source. Executors not built by
+// this package are assumed to apply every pattern.
+func GrafanaDashboard(title string, executors []Executor) ([]byte, error) {
+	if len(executors) == 0 {
+		return nil, fmt.Errorf("resilience: grafana dashboard requires at least one executor")
+	}
+
+	names := make([]string, 0, len(executors))
+	configured := make(map[Pattern]bool)
+	for _, e := range executors {
+		names = append(names, e.Name())
+		for _, p := range executorPatterns(e) {
+			configured[p] = true
+		}
+	}
+
+	selector := fmt.Sprintf(`%s=~"$%s"`, LabelName, LabelName)
+	panels := []grafanaPanel{
+		{"Calls by outcome", fmt.Sprintf(`sum by (%s, %s) (rate(%s{%s}[5m]))`, LabelName, LabelOutcome, MetricCallsTotal, selector), "reqps"},
+		{"Call latency p95", fmt.Sprintf(`histogram_quantile(0.95, sum by (%s, le) (rate(%s_bucket{%s}[5m])))`, LabelName, MetricCallDuration, selector), "s"},
+	}
+	if configured[PatternCircuitBreaker] {
+		panels = append(panels,
+			grafanaPanel{"Circuit breaker state", fmt.Sprintf(`max by (%s) (%s{%s})`, LabelName, MetricCircuitBreakerState, selector), "none"},
+			grafanaPanel{"Circuit breaker transitions", fmt.Sprintf(`sum by (%s, %s) (increase(%s{%s}[5m]))`, LabelName, LabelTo, MetricCircuitBreakerTransitions, selector), "short"},
+			grafanaPanel{"Circuit breaker time in state", fmt.Sprintf(`sum by (%s, %s) (increase(%s{%s}[5m]))`, LabelName, LabelState, MetricCircuitBreakerStateSeconds, selector), "s"},
+			grafanaPanel{"Circuit breaker openings", fmt.Sprintf(`max by (%s) (%s{%s})`, LabelName, MetricCircuitBreakerOpenings, selector), "short"},
+		)
+	}
+	if configured[PatternRetry] {
+		panels = append(panels,
+			grafanaPanel{"Retry attempts", fmt.Sprintf(`sum by (%s) (rate(%s{%s}[5m]))`, LabelName, MetricRetryAttemptsTotal, selector), "ops"},
+			grafanaPanel{"Retry outcomes", fmt.Sprintf(`sum by (%s, %s) (rate(%s{%s}[5m]))`, LabelName, LabelOutcome, MetricRetryCallsTotal, selector), "reqps"},
+		)
+	}
+	if configured[PatternRateLimiter] {
+		panels = append(panels,
+			grafanaPanel{"Rate limiter rejections", fmt.Sprintf(`sum by (%s) (rate(%s{%s}[5m]))`, LabelName, MetricRateLimiterRejectionsTotal, selector), "ops"},
+		)
+	}
+	if configured[PatternBulkhead] {
+		panels = append(panels,
+			grafanaPanel{"Bulkhead available slots", fmt.Sprintf(`min by (%s) (%s{%s})`, LabelName, MetricBulkheadAvailable, selector), "short"},
+			grafanaPanel{"Bulkhead rejections", fmt.Sprintf(`sum by (%s) (rate(%s{%s}[5m]))`, LabelName, MetricBulkheadRejectionsTotal, selector), "ops"},
+		)
+	}
+	if configured[PatternTimeout] {
+		panels = append(panels,
+			grafanaPanel{"Timeouts", fmt.Sprintf(`sum by (%s) (rate(%s{%s}[5m]))`, LabelName, MetricTimeoutsTotal, selector), "ops"},
+			grafanaPanel{"Timeout stragglers", fmt.Sprintf(`sum by (%s) (increase(%s{%s}[5m]))`, LabelName, MetricTimeoutStragglersTotal, selector), "short"},
+		)
+	}
+
+	panelModels := make([]map[string]any, 0, len(panels))
+	for i, p := range panels {
+		panelModels = append(panelModels, map[string]any{
+			"id":         i + 1,
+			"type":       "timeseries",
+			"title":      p.title,
+			"datasource": map[string]any{"type": "prometheus", "uid": "${datasource}"},
+			"gridPos":    map[string]any{"h": 8, "w": 12, "x": (i % 2) * 12, "y": (i / 2) * 8},
+			"fieldConfig": map[string]any{
+				"defaults":  map[string]any{"unit": p.unit},
+				"overrides": []any{},
+			},
+			"targets": []map[string]any{
+				{"refId": "A", "expr": p.expr, "legendFormat": "{{" + LabelName + "}}"},
+			},
+		})
+	}
+
+	options := make([]map[string]any, 0, len(names))
+	for _, e := range names {
+		options = append(options, map[string]any{"text": e, "value": e, "selected": true})
+	}
+
+	dashboard := map[string]any{
+		"title":         title,
+		"uid":           dashboardUID(title),
+		"schemaVersion": 39,
+		"tags":          []string{"resilience"},
+		"time":          map[string]any{"from": "now-6h", "to": "now"},
+		"panels":        panelModels,
+		"templating": map[string]any{
+			"list": []map[string]any{
+				{
+					"name":  "datasource",
+					"type":  "datasource",
+					"query": "prometheus",
+				},
+				{
+					"name":       LabelName,
+					"type":       "custom",
+					"multi":      true,
+					"includeAll": true,
+					"query":      strings.Join(names, ","),
+					"options":    options,
+					"current": map[string]any{
+						"text":  names,
+						"value": names,
+					},
+				},
+			},
+		},
+	}
+
+	return json.MarshalIndent(dashboard, "", "  ")
+}
+
+func (e *executor) patterns() []Pattern {
+	var patterns []Pattern
+	for _, p := range []struct {
+		pattern Pattern
+		has     bool
+	}{
+		{PatternCircuitBreaker, e.hasCircuitBreaker},
+		{PatternRetry, e.hasRetry},
+		{PatternRateLimiter, e.hasRateLimiter},
+		{PatternBulkhead, e.hasBulkhead},
+		{PatternTimeout, e.hasTimeout},
+		{PatternHedge, e.hasHedge},
+	} {
+		if p.has {
+			patterns = append(patterns, p.pattern)
+		}
+	}
+	return patterns
+}
+
+func (noopExecutor) patterns() []Pattern {
+	return nil
+}
+
+func (g *sloGuard) patterns() []Pattern {
+	return executorPatterns(g.executor)
+}
+
+func (e *cachedExecutor) patterns() []Pattern {
+	return executorPatterns(e.next)
+}
+
+func (c *canaryExecutor) patterns() []Pattern {
+	return append(executorPatterns(c.baseline), executorPatterns(c.candidate)...)
+}
+
+// executorPatterns returns the patterns e, or the executor it decorates,
+// lists, or every pattern
+func executorPatterns(e Executor) []Pattern {
+	for e != nil {
+		if lister, ok := e.(patternLister); ok {
+			return lister.patterns()
+		}
+		e = UnwrapExecutor(e)
+	}
+	return allPatterns
+}
+
+// dashboardUID derives a stable Grafana UID (max 40 chars) from a title
+func dashboardUID(title string) string {
+	uid := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		default:
+			return '-'
+		}
+	}, title)
+	if len(uid) > 40 {
+		uid = uid[:40]
+	}
+	return uid
+}
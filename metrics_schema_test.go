@@ -0,0 +1,94 @@
+package resilience
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsSchema(t *testing.T) {
+	schema := MetricsSchema()
+	assert.NotEmpty(t, schema)
+
+	seen := map[string]bool{}
+	for _, m := range schema {
+		assert.False(t, seen[m.Name], "duplicate metric %s", m.Name)
+		seen[m.Name] = true
+		assert.NotEmpty(t, m.Help)
+		assert.Contains(t, m.Labels, LabelName)
+	}
+	assert.True(t, seen[MetricCallsTotal])
+}
+
+func TestGrafanaDashboard(t *testing.T) {
+	panelTitles := func(t *testing.T, data []byte) []string {
+		var dashboard struct {
+			Panels []struct {
+				Title string `json:"title"`
+			} `json:"panels"`
+		}
+		require.NoError(t, json.Unmarshal(data, &dashboard))
+		titles := make([]string, 0, len(dashboard.Panels))
+		for _, p := range dashboard.Panels {
+			titles = append(titles, p.Title)
+		}
+		return titles
+	}
+
+	t.Run("generates dashboard for executors", func(t *testing.T) {
+		payments := NewBuilder().WithName("payments").
+			WithCircuitBreaker(DefaultCircuitBreakerConfig()).
+			WithRetry(DefaultRetryConfig()).
+			Build()
+		ledger := NewBuilder().WithName("ledger").
+			WithRateLimiter(DefaultRateLimiterConfig()).
+			Build()
+
+		data, err := GrafanaDashboard("Payments Resilience", []Executor{payments, ledger})
+		require.NoError(t, err)
+
+		var dashboard map[string]any
+		require.NoError(t, json.Unmarshal(data, &dashboard))
+		assert.Equal(t, "Payments Resilience", dashboard["title"])
+		assert.Equal(t, "payments-resilience", dashboard["uid"])
+
+		vars := dashboard["templating"].(map[string]any)["list"].([]any)
+		nameVar := vars[1].(map[string]any)
+		assert.Equal(t, "payments,ledger", nameVar["query"])
+
+		assert.Equal(t, []string{
+			"Calls by outcome",
+			"Call latency p95",
+			"Circuit breaker state",
+			"Circuit breaker transitions",
+			"Circuit breaker time in state",
+			"Circuit breaker openings",
+			"Retry attempts",
+			"Retry outcomes",
+			"Rate limiter rejections",
+		}, panelTitles(t, data))
+	})
+
+	t.Run("follows wrapped executors", func(t *testing.T) {
+		inner := NewBuilder().WithName("search").
+			WithBulkhead(DefaultBulkheadConfig()).
+			Build()
+		guarded := NewSLOGuard(inner, DefaultSLOConfig())
+
+		data, err := GrafanaDashboard("search", []Executor{guarded, Noop()})
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"Calls by outcome",
+			"Call latency p95",
+			"Bulkhead available slots",
+			"Bulkhead rejections",
+		}, panelTitles(t, data))
+	})
+
+	t.Run("requires executors", func(t *testing.T) {
+		_, err := GrafanaDashboard("empty", nil)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,130 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRecorder keeps recorded calls for assertions
+type memoryRecorder struct {
+	mu    sync.Mutex
+	calls []CallMetrics
+}
+
+func (r *memoryRecorder) RecordCall(call CallMetrics) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+type tenantKey struct{}
+
+func TestExecutorMetrics(t *testing.T) {
+	tenantLabels := func(ctx context.Context) map[string]string {
+		tenant, _ := ctx.Value(tenantKey{}).(string)
+		return map[string]string{"tenant": tenant}
+	}
+
+	t.Run("records outcomes", func(t *testing.T) {
+		recorder := &memoryRecorder{}
+		breaker := NewCircuitBreaker(DefaultCircuitBreakerConfig())
+		exec := NewBuilder().
+			WithName("payments").
+			WithSharedCircuitBreaker(breaker).
+			WithMetrics(MetricsConfig{Recorder: recorder}).
+			Build()
+		ctx := context.Background()
+
+		_ = exec.Execute(ctx, func(ctx context.Context) error { return nil })
+		_ = exec.Execute(ctx, func(ctx context.Context) error { return errors.New("boom") })
+		breaker.Trip()
+		_ = exec.Execute(ctx, func(ctx context.Context) error { return nil })
+
+		require.Len(t, recorder.calls, 3)
+		assert.Equal(t, "payments", recorder.calls[0].Name)
+		assert.Equal(t, OutcomeSuccess, recorder.calls[0].Outcome)
+		assert.Equal(t, OutcomeFailure, recorder.calls[1].Outcome)
+		assert.Equal(t, OutcomeRejected, recorder.calls[2].Outcome)
+		assert.Nil(t, recorder.calls[0].Labels)
+	})
+
+	t.Run("times calls on the builder's clock", func(t *testing.T) {
+		recorder := &memoryRecorder{}
+		clock := NewFakeClock(time.Unix(0, 0))
+		exec := NewBuilder().
+			WithMetrics(MetricsConfig{Recorder: recorder}).
+			WithClock(clock).
+			Build()
+
+		require.NoError(t, exec.Execute(context.Background(), func(ctx context.Context) error {
+			clock.Advance(3 * time.Second)
+			return nil
+		}))
+
+		require.Len(t, recorder.calls, 1)
+		assert.Equal(t, 3*time.Second, recorder.calls[0].Duration)
+	})
+
+	t.Run("adds labels from the context", func(t *testing.T) {
+		recorder := &memoryRecorder{}
+		exec := NewBuilder().
+			WithMetrics(MetricsConfig{Recorder: recorder, LabelExtractor: tenantLabels}).
+			Build()
+
+		ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+		require.NoError(t, exec.Execute(ctx, func(ctx context.Context) error { return nil }))
+
+		require.Len(t, recorder.calls, 1)
+		assert.Equal(t, map[string]string{"tenant": "acme"}, recorder.calls[0].Labels)
+	})
+
+	t.Run("caps label values", func(t *testing.T) {
+		recorder := &memoryRecorder{}
+		exec := NewBuilder().
+			WithMetrics(MetricsConfig{Recorder: recorder, LabelExtractor: tenantLabels, MaxLabelValues: 2}).
+			Build()
+
+		for _, tenant := range []string{"a", "b", "c", "a"} {
+			ctx := context.WithValue(context.Background(), tenantKey{}, tenant)
+			require.NoError(t, exec.Execute(ctx, func(ctx context.Context) error { return nil }))
+		}
+
+		var tenants []string
+		for _, call := range recorder.calls {
+			tenants = append(tenants, call.Labels["tenant"])
+		}
+		assert.Equal(t, []string{"a", "b", LabelValueOther, "a"}, tenants)
+	})
+}
+
+func TestLabelGuard(t *testing.T) {
+	t.Run("drops keys beyond the limit", func(t *testing.T) {
+		guard := newLabelGuard(2, 10)
+		assert.Equal(t, map[string]string{"tenant": "a"}, guard.apply(map[string]string{"tenant": "a"}))
+		assert.Equal(t, map[string]string{"route": "/x"}, guard.apply(map[string]string{"route": "/x"}))
+
+		labels := guard.apply(map[string]string{"tenant": "b", "user": "42"})
+		assert.Equal(t, map[string]string{"tenant": "b"}, labels)
+	})
+
+	t.Run("is safe for concurrent use", func(t *testing.T) {
+		guard := newLabelGuard(1, 50)
+		var wg sync.WaitGroup
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				guard.apply(map[string]string{"tenant": fmt.Sprint(i)})
+			}(i)
+		}
+		wg.Wait()
+		assert.Len(t, guard.values["tenant"], 50)
+	})
+}
@@ -0,0 +1,55 @@
+package resilience
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync/atomic"
+)
+
+// misuseKey is the context key of the innermost misuseFrame
+type misuseKey struct{}
+
+// misuseFrame marks a context as derived from an executor call, linking
+// to the frames of the calls it is nested in
+type misuseFrame struct {
+	executor *executor
+	parent   *misuseFrame
+	returned atomic.Bool
+}
+
+// checkMisuse reports misuse of the executor by a call with ctx and returns
+// ctx marked with the call's frame, to be marked returned when it is done
+func (e *executor) checkMisuse(ctx context.Context) (context.Context, *misuseFrame) {
+	if ctx.Err() != nil {
+		e.reportMisuse(MisuseCanceledContext, fmt.Sprintf(
+			"executor %q called with a context that is already done: %v", e.name, context.Cause(ctx)))
+	}
+
+	parent, _ := ctx.Value(misuseKey{}).(*misuseFrame)
+	for frame := parent; frame != nil; frame = frame.parent {
+		if frame.returned.Load() {
+			e.reportMisuse(MisuseStaleContext, fmt.Sprintf(
+				"executor %q called with a context from a call to executor %q that already returned", e.name, frame.executor.name))
+			break
+		}
+		if frame.executor == e {
+			e.reportMisuse(MisuseReentrant, fmt.Sprintf(
+				"executor %q called from inside its own call; nested calls can deadlock on its bulkhead or rate limiter", e.name))
+			break
+		}
+	}
+
+	call := &misuseFrame{executor: e, parent: parent}
+	return context.WithValue(ctx, misuseKey{}, call), call
+}
+
+// reportMisuse calls the OnMisuse hook with the caller's stack
+func (e *executor) reportMisuse(kind MisuseKind, message string) {
+	e.onMisuse(Misuse{
+		Executor: e.name,
+		Kind:     kind,
+		Message:  message,
+		Stack:    debug.Stack(),
+	})
+}
@@ -0,0 +1,87 @@
+package resilience
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMisuseDetection(t *testing.T) {
+	ok := func(ctx context.Context) error { return nil }
+	newExecutor := func(name string) (Executor, func() []Misuse) {
+		var mu sync.Mutex
+		var misuses []Misuse
+		exec := NewBuilder().
+			WithName(name).
+			WithMisuseDetection(func(misuse Misuse) {
+				mu.Lock()
+				defer mu.Unlock()
+				misuses = append(misuses, misuse)
+			}).
+			Build()
+		return exec, func() []Misuse {
+			mu.Lock()
+			defer mu.Unlock()
+			return misuses
+		}
+	}
+
+	t.Run("stays quiet for correct use", func(t *testing.T) {
+		outer, misuses := newExecutor("outer")
+		inner, innerMisuses := newExecutor("inner")
+
+		for i := 0; i < 2; i++ {
+			assert.NoError(t, outer.Execute(context.Background(), func(ctx context.Context) error {
+				return inner.Execute(ctx, ok)
+			}))
+		}
+		assert.Empty(t, misuses())
+		assert.Empty(t, innerMisuses())
+	})
+
+	t.Run("reports reentrant calls", func(t *testing.T) {
+		exec, misuses := newExecutor("payments")
+		other, _ := newExecutor("other")
+
+		assert.NoError(t, exec.Execute(context.Background(), func(ctx context.Context) error {
+			return other.Execute(ctx, func(ctx context.Context) error {
+				return exec.Execute(ctx, ok)
+			})
+		}))
+		if assert.Len(t, misuses(), 1) {
+			assert.Equal(t, MisuseReentrant, misuses()[0].Kind)
+			assert.Equal(t, "payments", misuses()[0].Executor)
+			assert.NotEmpty(t, misuses()[0].Stack)
+		}
+	})
+
+	t.Run("reports canceled contexts", func(t *testing.T) {
+		exec, misuses := newExecutor("payments")
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_ = exec.Execute(ctx, ok)
+		if assert.Len(t, misuses(), 1) {
+			assert.Equal(t, MisuseCanceledContext, misuses()[0].Kind)
+			assert.Contains(t, misuses()[0].Message, "context canceled")
+		}
+	})
+
+	t.Run("reports stale contexts", func(t *testing.T) {
+		outer, _ := newExecutor("outer")
+		inner, misuses := newExecutor("inner")
+
+		var captured context.Context
+		assert.NoError(t, outer.Execute(context.Background(), func(ctx context.Context) error {
+			captured = ctx
+			return nil
+		}))
+		assert.NoError(t, inner.Execute(captured, ok))
+		if assert.Len(t, misuses(), 1) {
+			assert.Equal(t, MisuseStaleContext, misuses()[0].Kind)
+			assert.Contains(t, misuses()[0].Message, `"outer"`)
+		}
+	})
+}
@@ -0,0 +1,265 @@
+package resilience
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// noopName is the name of every no-op pattern
+const noopName = "noop"
+
+// Noop returns an Executor that runs functions directly, for library code
+// that takes an optional Executor and calls it unconditionally
+func Noop() Executor {
+	return noopExecutor{}
+}
+
+// OrNoop returns executor, or Noop if it is nil
+func OrNoop(executor Executor) Executor {
+	if executor == nil {
+		return Noop()
+	}
+	return executor
+}
+
+// noopExecutor implements the Executor interface without any patterns
+type noopExecutor struct{}
+
+func (noopExecutor) Execute(ctx context.Context, fn func(context.Context) error, opts ...CallOption) error {
+	return fn(ctx)
+}
+
+func (noopExecutor) ExecuteWithResult(ctx context.Context, fn func(context.Context) (any, error), opts ...CallOption) (any, error) {
+	return fn(ctx)
+}
+
+func (noopExecutor) TryExecute(ctx context.Context, fn func(context.Context) error, opts ...CallOption) error {
+	return fn(ctx)
+}
+
+func (noopExecutor) Name() string {
+	return noopName
+}
+
+// NoopCircuitBreaker returns a CircuitBreaker that stays closed and admits
+// every call; its controls have no effect
+func NoopCircuitBreaker() CircuitBreaker {
+	return noopCircuitBreaker{}
+}
+
+// noopCircuitBreaker implements the CircuitBreaker interface
+type noopCircuitBreaker struct{}
+
+func (noopCircuitBreaker) Execute(ctx context.Context, fn func(context.Context) error) error {
+	return fn(ctx)
+}
+
+func (noopCircuitBreaker) Allow() (Permit, error) {
+	return noopPermit{}, nil
+}
+
+func (noopCircuitBreaker) AllowN(n int) (Permit, error) {
+	return noopPermit{}, nil
+}
+
+func (noopCircuitBreaker) State() CircuitState {
+	return StateClosed
+}
+
+func (noopCircuitBreaker) Reset()       {}
+func (noopCircuitBreaker) Trip()        {}
+func (noopCircuitBreaker) ForceOpen()   {}
+func (noopCircuitBreaker) ForceClosed() {}
+func (noopCircuitBreaker) Disable()     {}
+
+func (noopCircuitBreaker) Metrics() CircuitBreakerMetrics {
+	return CircuitBreakerMetrics{State: StateClosed}
+}
+
+func (noopCircuitBreaker) History() []Transition {
+	return nil
+}
+
+func (noopCircuitBreaker) Export() []byte {
+	return nil
+}
+
+func (noopCircuitBreaker) Import(data []byte) error {
+	return nil
+}
+
+func (noopCircuitBreaker) Name() string {
+	return noopName
+}
+
+// noopPermit implements the Permit interface, recording nothing
+type noopPermit struct{}
+
+func (noopPermit) RecordSuccess()                         {}
+func (noopPermit) RecordFailure()                         {}
+func (noopPermit) Record(err error)                       {}
+func (noopPermit) RecordBatch(successes, failures uint32) {}
+
+// NoopRetry returns a Retry that makes a single attempt
+func NoopRetry() Retry {
+	return noopRetry{}
+}
+
+// noopRetry implements the Retry interface
+type noopRetry struct{}
+
+func (noopRetry) Execute(ctx context.Context, fn func(context.Context) error) error {
+	return fn(ctx)
+}
+
+func (noopRetry) ExecuteWithResult(ctx context.Context, fn func(context.Context) (any, error)) (any, error) {
+	return fn(ctx)
+}
+
+func (noopRetry) Name() string {
+	return noopName
+}
+
+// NoopRateLimiter returns a RateLimiter without a limit
+func NoopRateLimiter() RateLimiter {
+	return noopRateLimiter{}
+}
+
+// noopRateLimiter implements the RateLimiter interface
+type noopRateLimiter struct{}
+
+func (noopRateLimiter) Allow() bool {
+	return true
+}
+
+func (noopRateLimiter) Wait(ctx context.Context) error {
+	return nil
+}
+
+func (noopRateLimiter) TryExecute(ctx context.Context, fn func(context.Context) error) error {
+	return fn(ctx)
+}
+
+func (noopRateLimiter) Acquire(ctx context.Context, n int) (Reservation, error) {
+	r := &noopReservation{}
+	r.remaining.Store(int64(max(n, 0)))
+	return r, nil
+}
+
+func (noopRateLimiter) Export() []byte {
+	return nil
+}
+
+func (noopRateLimiter) Import(data []byte) error {
+	return nil
+}
+
+func (noopRateLimiter) Stats() RateLimiterStats {
+	return RateLimiterStats{Tokens: math.Inf(1), SmoothedTokens: math.Inf(1), Rate: math.Inf(1)}
+}
+
+func (noopRateLimiter) ApplyQuota(quota Quota) {}
+
+func (noopRateLimiter) SetRateMultiplier(multiplier float64) error {
+	return nil
+}
+
+func (noopRateLimiter) Name() string {
+	return noopName
+}
+
+// noopReservation implements the Reservation interface for the tokens
+// reserved from a noopRateLimiter
+type noopReservation struct {
+	remaining atomic.Int64
+}
+
+func (r *noopReservation) Take() bool {
+	return r.remaining.Add(-1) >= 0
+}
+
+func (r *noopReservation) Remaining() int {
+	return int(max(r.remaining.Load(), 0))
+}
+
+func (r *noopReservation) Release() {
+	r.remaining.Store(0)
+}
+
+// NoopBulkhead returns a Bulkhead without a concurrency limit
+func NoopBulkhead() Bulkhead {
+	return noopBulkhead{}
+}
+
+// noopBulkhead implements the Bulkhead interface
+type noopBulkhead struct{}
+
+func (noopBulkhead) Execute(ctx context.Context, fn func(context.Context) error) error {
+	return fn(ctx)
+}
+
+func (noopBulkhead) TryExecute(ctx context.Context, fn func(context.Context) error) error {
+	return fn(ctx)
+}
+
+func (noopBulkhead) Available() int {
+	return math.MaxInt
+}
+
+func (noopBulkhead) Stats() BulkheadStats {
+	return BulkheadStats{Available: math.MaxInt, SmoothedAvailable: math.MaxInt}
+}
+
+func (noopBulkhead) Name() string {
+	return noopName
+}
+
+// NoopTimeout returns a Timeout that never expires
+func NoopTimeout() Timeout {
+	return noopTimeout{}
+}
+
+// noopTimeout implements the Timeout interface
+type noopTimeout struct{}
+
+func (noopTimeout) Execute(ctx context.Context, fn func(context.Context) error) error {
+	return fn(ctx)
+}
+
+func (noopTimeout) ExecuteWithResult(ctx context.Context, fn func(context.Context) (any, error)) (any, error) {
+	return fn(ctx)
+}
+
+func (noopTimeout) Duration() time.Duration {
+	return 0
+}
+
+func (noopTimeout) Stats() TimeoutStats {
+	return TimeoutStats{}
+}
+
+func (noopTimeout) Name() string {
+	return noopName
+}
+
+// NoopHedge returns a Hedge that never starts a second attempt
+func NoopHedge() Hedge {
+	return noopHedge{}
+}
+
+// noopHedge implements the Hedge interface
+type noopHedge struct{}
+
+func (noopHedge) ExecuteWithResult(ctx context.Context, fn func(context.Context) (any, error)) (any, error) {
+	return fn(ctx)
+}
+
+func (noopHedge) Delay() time.Duration {
+	return 0
+}
+
+func (noopHedge) Name() string {
+	return noopName
+}
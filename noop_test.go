@@ -0,0 +1,90 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNoop(t *testing.T) {
+	ctx := context.Background()
+	errBoom := errors.New("boom")
+	fail := func(ctx context.Context) error { return errBoom }
+	result := func(ctx context.Context) (any, error) { return 42, nil }
+
+	t.Run("executor runs functions directly", func(t *testing.T) {
+		exec := Noop()
+		assert.Equal(t, "noop", exec.Name())
+		assert.ErrorIs(t, exec.Execute(ctx, fail), errBoom)
+		assert.ErrorIs(t, exec.TryExecute(ctx, fail), errBoom)
+		v, err := exec.ExecuteWithResult(ctx, result)
+		assert.NoError(t, err)
+		assert.Equal(t, 42, v)
+	})
+
+	t.Run("replaces nil executors", func(t *testing.T) {
+		assert.Equal(t, Noop(), OrNoop(nil))
+		exec := NewBuilder().Build()
+		assert.Same(t, exec, OrNoop(exec))
+	})
+
+	t.Run("circuit breaker never opens", func(t *testing.T) {
+		cb := NoopCircuitBreaker()
+		for i := 0; i < 10; i++ {
+			assert.ErrorIs(t, cb.Execute(ctx, fail), errBoom)
+		}
+		cb.Trip()
+		cb.ForceOpen()
+		assert.Equal(t, StateClosed, cb.State())
+		p, err := cb.AllowN(5)
+		require.NoError(t, err)
+		p.RecordBatch(0, 5)
+		assert.Equal(t, CircuitBreakerMetrics{State: StateClosed}, cb.Metrics())
+		assert.NoError(t, cb.Import(cb.Export()))
+
+		exec := NewBuilder().WithSharedCircuitBreaker(cb).Build()
+		assert.ErrorIs(t, exec.Execute(ctx, fail), errBoom)
+	})
+
+	t.Run("retry makes one attempt", func(t *testing.T) {
+		attempts := 0
+		err := NoopRetry().Execute(ctx, func(ctx context.Context) error {
+			attempts++
+			return errBoom
+		})
+		assert.ErrorIs(t, err, errBoom)
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("rate limiter has no limit", func(t *testing.T) {
+		rl := NoopRateLimiter()
+		for i := 0; i < 100; i++ {
+			assert.True(t, rl.Allow())
+		}
+		assert.NoError(t, rl.Wait(ctx))
+		assert.ErrorIs(t, rl.TryExecute(ctx, fail), errBoom)
+
+		r, err := rl.Acquire(ctx, 2)
+		require.NoError(t, err)
+		assert.True(t, r.Take())
+		assert.Equal(t, 1, r.Remaining())
+		r.Release()
+		assert.False(t, r.Take())
+		assert.Equal(t, 0, r.Remaining())
+	})
+
+	t.Run("bulkhead, timeout and hedge run functions directly", func(t *testing.T) {
+		assert.ErrorIs(t, NoopBulkhead().Execute(ctx, fail), errBoom)
+		assert.ErrorIs(t, NoopBulkhead().TryExecute(ctx, fail), errBoom)
+		assert.Positive(t, NoopBulkhead().Available())
+		assert.ErrorIs(t, NoopTimeout().Execute(ctx, fail), errBoom)
+		assert.Zero(t, NoopTimeout().Duration())
+
+		v, err := NoopHedge().ExecuteWithResult(ctx, result)
+		assert.NoError(t, err)
+		assert.Equal(t, 42, v)
+	})
+}
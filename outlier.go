@@ -0,0 +1,274 @@
+package resilience
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// outlierDetector implements the OutlierDetector interface
+type outlierDetector struct {
+	config OutlierDetectionConfig
+	now    func() time.Time
+
+	mu        sync.Mutex
+	endpoints map[string]*outlierEndpoint
+	nextSweep time.Time
+}
+
+// outlierEndpoint is the state of one endpoint
+type outlierEndpoint struct {
+	successes           int // in the current interval
+	failures            int // in the current interval
+	consecutiveFailures int
+	ejections           int       // multiplies BaseEjectionTime
+	ejectedUntil        time.Time // zero while admitted
+}
+
+// outlierEjection is an ejection or readmission to report
+type outlierEjection struct {
+	endpoint string
+	ejected  bool
+}
+
+// NewOutlierDetector tracks the success rates of endpoints and ejects the
+// outliers for a growing period, filling zero values from the defaults
+func NewOutlierDetector(config OutlierDetectionConfig, endpoints ...string) OutlierDetector {
+	defaults := DefaultOutlierDetectionConfig()
+	if config.Name == "" {
+		config.Name = defaults.Name
+	}
+	if config.Interval <= 0 {
+		config.Interval = defaults.Interval
+	}
+	if config.ConsecutiveFailures == 0 {
+		config.ConsecutiveFailures = defaults.ConsecutiveFailures
+	}
+	if config.SuccessRateStdevFactor == 0 {
+		config.SuccessRateStdevFactor = defaults.SuccessRateStdevFactor
+	}
+	if config.SuccessRateMinHosts <= 0 {
+		config.SuccessRateMinHosts = defaults.SuccessRateMinHosts
+	}
+	if config.SuccessRateRequestVolume <= 0 {
+		config.SuccessRateRequestVolume = defaults.SuccessRateRequestVolume
+	}
+	if config.BaseEjectionTime <= 0 {
+		config.BaseEjectionTime = defaults.BaseEjectionTime
+	}
+	if config.MaxEjectionTime <= 0 {
+		config.MaxEjectionTime = defaults.MaxEjectionTime
+	}
+	config.MaxEjectionTime = max(config.MaxEjectionTime, config.BaseEjectionTime)
+	if config.MaxEjectionRatio <= 0 {
+		config.MaxEjectionRatio = defaults.MaxEjectionRatio
+	}
+
+	d := &outlierDetector{
+		config:    config,
+		now:       time.Now,
+		endpoints: make(map[string]*outlierEndpoint),
+	}
+	d.nextSweep = d.now().Add(config.Interval)
+	d.SetEndpoints(endpoints)
+	return d
+}
+
+func (d *outlierDetector) SetEndpoints(endpoints []string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	keep := make(map[string]bool, len(endpoints))
+	for _, endpoint := range endpoints {
+		keep[endpoint] = true
+		if d.endpoints[endpoint] == nil {
+			d.endpoints[endpoint] = &outlierEndpoint{}
+		}
+	}
+	for endpoint, e := range d.endpoints {
+		if keep[endpoint] {
+			continue
+		}
+		if !e.ejectedUntil.IsZero() && d.config.Breakers != nil {
+			d.config.Breakers.CircuitBreaker(endpoint).Reset()
+		}
+		delete(d.endpoints, endpoint)
+	}
+}
+
+func (d *outlierDetector) Record(endpoint string, err error) {
+	d.mu.Lock()
+	now := d.now()
+	events := d.sweep(now)
+
+	if e := d.endpoints[endpoint]; e != nil {
+		if err == nil {
+			e.successes++
+			e.consecutiveFailures = 0
+		} else {
+			e.failures++
+			e.consecutiveFailures++
+			if d.config.ConsecutiveFailures > 0 && e.consecutiveFailures >= d.config.ConsecutiveFailures && e.ejectedUntil.IsZero() {
+				events = d.eject(events, endpoint, e, now)
+			}
+		}
+	}
+	d.mu.Unlock()
+
+	d.notify(events)
+}
+
+func (d *outlierDetector) Healthy() []string {
+	d.mu.Lock()
+	events := d.sweep(d.now())
+
+	var open map[string]bool
+	if d.config.Breakers != nil {
+		open = make(map[string]bool)
+		d.config.Breakers.Range(func(key string, cb CircuitBreaker) bool {
+			if d.endpoints[key] != nil && cb.State() == StateOpen {
+				open[key] = true
+			}
+			return true
+		})
+	}
+
+	healthy := make([]string, 0, len(d.endpoints))
+	for endpoint, e := range d.endpoints {
+		if e.ejectedUntil.IsZero() && !open[endpoint] {
+			healthy = append(healthy, endpoint)
+		}
+	}
+	d.mu.Unlock()
+
+	d.notify(events)
+	sort.Strings(healthy)
+	return healthy
+}
+
+func (d *outlierDetector) Ejected() []string {
+	d.mu.Lock()
+	events := d.sweep(d.now())
+
+	var ejected []string
+	for endpoint, e := range d.endpoints {
+		if !e.ejectedUntil.IsZero() {
+			ejected = append(ejected, endpoint)
+		}
+	}
+	d.mu.Unlock()
+
+	d.notify(events)
+	sort.Strings(ejected)
+	return ejected
+}
+
+func (d *outlierDetector) Name() string {
+	return d.config.Name
+}
+
+// sweep readmits endpoints whose ejection has expired and, once per
+// Interval, ejects endpoints whose success rate is an outlier and starts a
+// new interval. The caller holds d.mu.
+func (d *outlierDetector) sweep(now time.Time) []outlierEjection {
+	var events []outlierEjection
+	for endpoint, e := range d.endpoints {
+		if !e.ejectedUntil.IsZero() && !now.Before(e.ejectedUntil) {
+			e.ejectedUntil = time.Time{}
+			if d.config.Breakers != nil {
+				d.config.Breakers.CircuitBreaker(endpoint).Reset()
+			}
+			events = append(events, outlierEjection{endpoint: endpoint})
+		}
+	}
+	if now.Before(d.nextSweep) {
+		return events
+	}
+
+	if d.config.SuccessRateStdevFactor > 0 {
+		events = d.ejectSuccessRateOutliers(events, now)
+	}
+	for _, e := range d.endpoints {
+		e.successes, e.failures = 0, 0
+		if e.ejectedUntil.IsZero() && e.ejections > 0 {
+			e.ejections--
+		}
+	}
+	d.nextSweep = now.Add(d.config.Interval)
+	return events
+}
+
+// ejectSuccessRateOutliers ejects endpoints whose success rate over the
+// interval is more than SuccessRateStdevFactor standard deviations below
+// the mean, once SuccessRateMinHosts endpoints have enough calls
+func (d *outlierDetector) ejectSuccessRateOutliers(events []outlierEjection, now time.Time) []outlierEjection {
+	rates := make(map[string]float64)
+	var sum float64
+	for endpoint, e := range d.endpoints {
+		calls := e.successes + e.failures
+		if e.ejectedUntil.IsZero() && calls >= d.config.SuccessRateRequestVolume {
+			rates[endpoint] = float64(e.successes) / float64(calls)
+			sum += rates[endpoint]
+		}
+	}
+	if len(rates) < d.config.SuccessRateMinHosts {
+		return events
+	}
+
+	mean := sum / float64(len(rates))
+	var variance float64
+	for _, rate := range rates {
+		variance += (rate - mean) * (rate - mean)
+	}
+	threshold := mean - d.config.SuccessRateStdevFactor*math.Sqrt(variance/float64(len(rates)))
+
+	// Eject the worst first in case MaxEjectionRatio stops the ejections
+	outliers := make([]string, 0, len(rates))
+	for endpoint, rate := range rates {
+		if rate < threshold {
+			outliers = append(outliers, endpoint)
+		}
+	}
+	sort.Slice(outliers, func(i, j int) bool {
+		return rates[outliers[i]] < rates[outliers[j]]
+	})
+	for _, endpoint := range outliers {
+		events = d.eject(events, endpoint, d.endpoints[endpoint], now)
+	}
+	return events
+}
+
+// eject ejects endpoint for BaseEjectionTime times its number of recent
+// ejections, unless MaxEjectionRatio of the endpoints are already ejected.
+// At least one endpoint can always be ejected. The caller holds d.mu.
+func (d *outlierDetector) eject(events []outlierEjection, endpoint string, e *outlierEndpoint, now time.Time) []outlierEjection {
+	ejected := 0
+	for _, other := range d.endpoints {
+		if !other.ejectedUntil.IsZero() {
+			ejected++
+		}
+	}
+	if ejected >= max(1, int(d.config.MaxEjectionRatio*float64(len(d.endpoints)))) {
+		return events
+	}
+
+	e.ejections++
+	duration := min(d.config.BaseEjectionTime*time.Duration(e.ejections), d.config.MaxEjectionTime)
+	e.ejectedUntil = now.Add(duration)
+	e.consecutiveFailures = 0
+	if d.config.Breakers != nil {
+		d.config.Breakers.CircuitBreaker(endpoint).ForceOpen()
+	}
+	return append(events, outlierEjection{endpoint: endpoint, ejected: true})
+}
+
+// notify reports ejections and readmissions to OnEjection
+func (d *outlierDetector) notify(events []outlierEjection) {
+	if d.config.OnEjection == nil {
+		return
+	}
+	for _, event := range events {
+		d.config.OnEjection(d.config.Name, event.endpoint, event.ejected)
+	}
+}
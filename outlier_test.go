@@ -0,0 +1,150 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutlierDetector(t *testing.T) {
+	errBoom := errors.New("boom")
+	endpoints := []string{"a", "b", "c", "d", "e"}
+	newDetector := func(config OutlierDetectionConfig) (*outlierDetector, *time.Time) {
+		d := NewOutlierDetector(config, endpoints...).(*outlierDetector)
+		clock := time.Now()
+		d.now = func() time.Time { return clock }
+		d.nextSweep = clock.Add(d.config.Interval)
+		return d, &clock
+	}
+
+	t.Run("ejects after consecutive failures and readmits later", func(t *testing.T) {
+		var events []string
+		d, clock := newDetector(OutlierDetectionConfig{
+			ConsecutiveFailures: 3,
+			MaxEjectionRatio:    0.5,
+			OnEjection: func(name, endpoint string, ejected bool) {
+				events = append(events, fmt.Sprintf("%s %s %v", name, endpoint, ejected))
+			},
+		})
+
+		d.Record("a", errBoom)
+		d.Record("a", errBoom)
+		d.Record("a", nil)
+		d.Record("a", errBoom)
+		d.Record("a", errBoom)
+		assert.Empty(t, d.Ejected(), "a success resets the streak")
+
+		d.Record("a", errBoom)
+		assert.Equal(t, []string{"a"}, d.Ejected())
+		assert.Equal(t, []string{"b", "c", "d", "e"}, d.Healthy())
+
+		*clock = clock.Add(30 * time.Second)
+		assert.Empty(t, d.Ejected())
+		assert.Equal(t, endpoints, d.Healthy())
+		assert.Equal(t, []string{"default a true", "default a false"}, events)
+	})
+
+	t.Run("ejects repeat offenders for longer", func(t *testing.T) {
+		d, clock := newDetector(OutlierDetectionConfig{
+			ConsecutiveFailures: 1,
+			Interval:            time.Hour,
+			BaseEjectionTime:    time.Minute,
+			MaxEjectionTime:     90 * time.Second,
+		})
+
+		d.Record("a", errBoom)
+		*clock = clock.Add(time.Minute)
+		require.Empty(t, d.Ejected())
+
+		d.Record("a", errBoom)
+		*clock = clock.Add(time.Minute)
+		assert.Equal(t, []string{"a"}, d.Ejected(), "second ejection lasts longer")
+		*clock = clock.Add(30 * time.Second)
+		assert.Empty(t, d.Ejected(), "capped at MaxEjectionTime")
+	})
+
+	t.Run("ejects success rate outliers", func(t *testing.T) {
+		d, clock := newDetector(OutlierDetectionConfig{
+			ConsecutiveFailures:      -1,
+			SuccessRateRequestVolume: 10,
+		})
+
+		for _, endpoint := range endpoints {
+			for i := 0; i < 10; i++ {
+				var err error
+				if endpoint == "c" && i%2 == 0 {
+					err = errBoom
+				}
+				d.Record(endpoint, err)
+			}
+		}
+		assert.Empty(t, d.Ejected(), "compared once the interval ends")
+
+		*clock = clock.Add(10 * time.Second)
+		assert.Equal(t, []string{"c"}, d.Ejected())
+	})
+
+	t.Run("needs enough endpoints with enough calls", func(t *testing.T) {
+		d, clock := newDetector(OutlierDetectionConfig{
+			ConsecutiveFailures:      -1,
+			SuccessRateRequestVolume: 10,
+		})
+
+		for _, endpoint := range endpoints[:4] {
+			for i := 0; i < 10; i++ {
+				var err error
+				if endpoint == "c" {
+					err = errBoom
+				}
+				d.Record(endpoint, err)
+			}
+		}
+		*clock = clock.Add(10 * time.Second)
+		assert.Empty(t, d.Ejected())
+	})
+
+	t.Run("bounds the share of ejected endpoints", func(t *testing.T) {
+		d, _ := newDetector(OutlierDetectionConfig{ConsecutiveFailures: 1, MaxEjectionRatio: 0.4})
+
+		for _, endpoint := range endpoints {
+			d.Record(endpoint, errBoom)
+		}
+		assert.Equal(t, []string{"a", "b"}, d.Ejected())
+	})
+
+	t.Run("ignores unknown endpoints and keeps state across updates", func(t *testing.T) {
+		d, _ := newDetector(OutlierDetectionConfig{ConsecutiveFailures: 1})
+
+		d.Record("z", errBoom)
+		d.Record("a", errBoom)
+		d.SetEndpoints([]string{"a", "f"})
+		assert.Equal(t, []string{"a"}, d.Ejected())
+		assert.Equal(t, []string{"f"}, d.Healthy())
+	})
+
+	t.Run("drives keyed circuit breakers", func(t *testing.T) {
+		breakers := NewKeyedCircuitBreaker(DefaultCircuitBreakerConfig(), 0)
+		d, clock := newDetector(OutlierDetectionConfig{ConsecutiveFailures: 1, Breakers: breakers})
+
+		executor := NewBuilder().WithSharedKeyedCircuitBreaker(breakers, nil).Build()
+		ctx := WithBreakerKey(context.Background(), "a")
+		ok := func(ctx context.Context) error { return nil }
+
+		d.Record("a", errBoom)
+		assert.Equal(t, StateOpen, breakers.CircuitBreaker("a").State())
+		assert.ErrorIs(t, executor.Execute(ctx, ok), ErrCircuitOpen)
+
+		breakers.CircuitBreaker("b").ForceOpen()
+		assert.Equal(t, []string{"c", "d", "e"}, d.Healthy())
+
+		*clock = clock.Add(30 * time.Second)
+		assert.Equal(t, []string{"a", "c", "d", "e"}, d.Healthy())
+		assert.Equal(t, StateClosed, breakers.CircuitBreaker("a").State())
+		assert.NoError(t, executor.Execute(ctx, ok))
+	})
+}
@@ -0,0 +1,104 @@
+package resilience
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RedactedValue replaces the values of PayloadSamplingConfig.RedactKeys
+const RedactedValue = "[REDACTED]"
+
+// payloadSamplerBuckets is the number of buckets in the failure rate window
+const payloadSamplerBuckets = 10
+
+// payloadSampler captures request metadata while an executor's failure rate
+// exceeds the threshold. Healthy calls cost one window update.
+type payloadSampler struct {
+	config PayloadSamplingConfig
+	name   string
+	redact map[string]bool
+	mu     sync.Mutex
+	window *rollingWindow
+	now    func() time.Time
+}
+
+// newPayloadSampler creates a sampler, filling zero values from the defaults
+func newPayloadSampler(config PayloadSamplingConfig, name string, clock Clock) *payloadSampler {
+	defaults := DefaultPayloadSamplingConfig()
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = defaults.FailureThreshold
+	}
+	if config.Window <= 0 {
+		config.Window = defaults.Window
+	}
+	if config.MinCalls <= 0 {
+		config.MinCalls = defaults.MinCalls
+	}
+	if config.SampleRate <= 0 {
+		config.SampleRate = defaults.SampleRate
+	}
+
+	redact := make(map[string]bool, len(config.RedactKeys))
+	for _, key := range config.RedactKeys {
+		redact[strings.ToLower(key)] = true
+	}
+
+	return &payloadSampler{
+		config: config,
+		name:   name,
+		redact: redact,
+		window: newRollingWindow(config.Window, payloadSamplerBuckets, clock.Now()),
+		now:    clock.Now,
+	}
+}
+
+// observe records the outcome of a call started at start and samples its
+// metadata while the failure rate is above the threshold
+func (s *payloadSampler) observe(ctx context.Context, start time.Time, err error) {
+	now := s.now()
+
+	s.mu.Lock()
+	s.window.record(now, err == nil)
+	successes, failures := s.window.totals(now)
+	s.mu.Unlock()
+
+	calls := successes + failures
+	if calls < uint64(s.config.MinCalls) {
+		return
+	}
+	rate := float64(failures) / float64(calls)
+	if rate <= s.config.FailureThreshold || rand.Float64() >= s.config.SampleRate {
+		return
+	}
+
+	sample := PayloadSample{
+		Executor:    s.name,
+		Time:        start,
+		Duration:    now.Sub(start),
+		FailureRate: rate,
+		Metadata:    s.redacted(s.config.Extractor(ctx)),
+	}
+	if err != nil {
+		sample.Error = err.Error()
+	}
+	s.config.OnSample(sample)
+}
+
+// redacted returns metadata with the values of RedactKeys masked, leaving
+// the extractor's map untouched
+func (s *payloadSampler) redacted(metadata map[string]string) map[string]string {
+	if len(s.redact) == 0 {
+		return metadata
+	}
+	masked := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		if s.redact[strings.ToLower(key)] {
+			value = RedactedValue
+		}
+		masked[key] = value
+	}
+	return masked
+}
@@ -0,0 +1,76 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPayloadSampling(t *testing.T) {
+	ctx := context.Background()
+	fail := func(ctx context.Context) error { return errors.New("boom") }
+	succeed := func(ctx context.Context) error { return nil }
+
+	build := func(extracted *int, samples *[]PayloadSample) Executor {
+		return NewBuilder().
+			WithName("payments").
+			WithPayloadSampling(PayloadSamplingConfig{
+				FailureThreshold: 0.5,
+				MinCalls:         4,
+				SampleRate:       1,
+				RedactKeys:       []string{"Authorization"},
+				Extractor: func(ctx context.Context) map[string]string {
+					*extracted++
+					return map[string]string{"route": "/charge", "authorization": "Bearer secret"}
+				},
+				OnSample: func(sample PayloadSample) {
+					*samples = append(*samples, sample)
+				},
+			}).
+			Build()
+	}
+
+	t.Run("does not extract while healthy", func(t *testing.T) {
+		var extracted int
+		var samples []PayloadSample
+		executor := build(&extracted, &samples)
+
+		for i := 0; i < 10; i++ {
+			_ = executor.Execute(ctx, succeed)
+		}
+		_ = executor.Execute(ctx, fail)
+		assert.Zero(t, extracted)
+		assert.Empty(t, samples)
+	})
+
+	t.Run("samples redacted metadata above the threshold", func(t *testing.T) {
+		var extracted int
+		var samples []PayloadSample
+		executor := build(&extracted, &samples)
+
+		for i := 0; i < 3; i++ {
+			_ = executor.Execute(ctx, fail)
+		}
+		assert.Empty(t, samples, "below MinCalls")
+
+		_ = executor.Execute(ctx, fail)
+		require.Len(t, samples, 1)
+		sample := samples[0]
+		assert.Equal(t, "payments", sample.Executor)
+		assert.Equal(t, "boom", sample.Error)
+		assert.Equal(t, 1.0, sample.FailureRate)
+		assert.Equal(t, map[string]string{"route": "/charge", "authorization": RedactedValue}, sample.Metadata)
+
+		_ = executor.Execute(ctx, succeed)
+		require.Len(t, samples, 2)
+		assert.Empty(t, samples[1].Error)
+	})
+
+	t.Run("requires an extractor and a callback", func(t *testing.T) {
+		e := NewBuilder().WithPayloadSampling(PayloadSamplingConfig{}).Build()
+		assert.Nil(t, e.(*executor).sampler)
+	})
+}
@@ -0,0 +1,145 @@
+package resilience
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// tokenSharer is a rate limiter whose tokens can be lent within a
+// QuotaSharingGroup
+type tokenSharer interface {
+	RateLimiter
+
+	// tryTake takes a token if at least reserve tokens remain after it,
+	// without reporting a rejection
+	tryTake(reserve float64) bool
+
+	// burstSize returns the bucket capacity
+	burstSize() float64
+}
+
+// quotaSharingGroup implements the QuotaSharingGroup interface
+type quotaSharingGroup struct {
+	name     string
+	loans    []*quotaLoan
+	limiters map[string]*sharingLimiter
+}
+
+// quotaLoan is a configured loan and the tokens lent under it
+type quotaLoan struct {
+	config  QuotaLoan
+	lender  tokenSharer
+	reserve float64 // tokens the lender always keeps
+	lent    atomic.Uint64
+}
+
+// NewQuotaSharingGroup lets the rate limiters of executors, keyed by name,
+// borrow tokens from each other as config.Loans allow. Limiters must come
+// from NewRateLimiter or a RateLimiterGroup.
+func NewQuotaSharingGroup(config QuotaSharingConfig, limiters map[string]RateLimiter) (QuotaSharingGroup, error) {
+	sharers := make(map[string]tokenSharer, len(limiters))
+	for name, limiter := range limiters {
+		sharer, ok := limiter.(tokenSharer)
+		if !ok {
+			return nil, fmt.Errorf("resilience: rate limiter %q cannot share quota", name)
+		}
+		sharers[name] = sharer
+	}
+
+	g := &quotaSharingGroup{name: config.Name, limiters: make(map[string]*sharingLimiter, len(sharers))}
+	for name, sharer := range sharers {
+		g.limiters[name] = &sharingLimiter{tokenSharer: sharer}
+	}
+	for _, loan := range config.Loans {
+		lender, ok := sharers[loan.From]
+		if !ok {
+			return nil, fmt.Errorf("resilience: quota loan from unknown member %q", loan.From)
+		}
+		borrower, ok := g.limiters[loan.To]
+		if !ok {
+			return nil, fmt.Errorf("resilience: quota loan to unknown member %q", loan.To)
+		}
+		if loan.From == loan.To {
+			return nil, fmt.Errorf("resilience: quota loan from %q to itself", loan.From)
+		}
+		if loan.Ratio <= 0 || loan.Ratio > 1 {
+			return nil, fmt.Errorf("resilience: invalid quota loan ratio %v", loan.Ratio)
+		}
+
+		l := &quotaLoan{
+			config:  loan,
+			lender:  lender,
+			reserve: (1 - loan.Ratio) * lender.burstSize(),
+		}
+		g.loans = append(g.loans, l)
+		borrower.loans = append(borrower.loans, l)
+	}
+	return g, nil
+}
+
+func (g *quotaSharingGroup) RateLimiter(name string) RateLimiter {
+	if l, ok := g.limiters[name]; ok {
+		return l
+	}
+	return nil
+}
+
+func (g *quotaSharingGroup) Loans() []QuotaLoanStats {
+	stats := make([]QuotaLoanStats, len(g.loans))
+	for i, loan := range g.loans {
+		stats[i] = QuotaLoanStats{
+			From:  loan.config.From,
+			To:    loan.config.To,
+			Ratio: loan.config.Ratio,
+			Lent:  loan.lent.Load(),
+		}
+	}
+	return stats
+}
+
+func (g *quotaSharingGroup) Name() string {
+	return g.name
+}
+
+// sharingLimiter is a member's limiter that borrows a token from its
+// lenders, in configuration order, when its own bucket is empty
+type sharingLimiter struct {
+	tokenSharer
+	loans []*quotaLoan
+}
+
+func (s *sharingLimiter) Allow() bool {
+	if s.tryTake(0) || s.borrow() {
+		return true
+	}
+	// Reports the rejection, unless a token came in meanwhile
+	return s.tokenSharer.Allow()
+}
+
+// borrow takes a token from the first lender with tokens above its reserve
+func (s *sharingLimiter) borrow() bool {
+	for _, loan := range s.loans {
+		if loan.lender.tryTake(loan.reserve) {
+			loan.lent.Add(1)
+			return true
+		}
+	}
+	return false
+}
+
+func (s *sharingLimiter) TryExecute(ctx context.Context, fn func(context.Context) error) error {
+	if !s.Allow() {
+		return ErrRateLimitExceeded
+	}
+	return fn(ctx)
+}
+
+// Wait borrows a token if the limiter's own are exhausted, and otherwise
+// waits for its own bucket to refill
+func (s *sharingLimiter) Wait(ctx context.Context) error {
+	if s.tryTake(0) || s.borrow() {
+		return nil
+	}
+	return s.tokenSharer.Wait(ctx)
+}
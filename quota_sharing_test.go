@@ -0,0 +1,113 @@
+package resilience
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaSharingGroup(t *testing.T) {
+	newGroup := func(t *testing.T, ratio float64) (QuotaSharingGroup, RateLimiter, *int) {
+		clock := NewFakeClock(time.Now())
+		rejections := 0
+		batch := NewRateLimiter(RateLimiterConfig{Name: "batch", Rate: 1, Burst: 10, Clock: clock})
+		interactive := NewRateLimiter(RateLimiterConfig{
+			Name:        "interactive",
+			Rate:        1,
+			Burst:       2,
+			Clock:       clock,
+			OnRateLimit: func(name string) { rejections++ },
+		})
+		group, err := NewQuotaSharingGroup(QuotaSharingConfig{
+			Name:  "api",
+			Loans: []QuotaLoan{{From: "batch", To: "interactive", Ratio: ratio}},
+		}, map[string]RateLimiter{"batch": batch, "interactive": interactive})
+		require.NoError(t, err)
+		return group, batch, &rejections
+	}
+
+	t.Run("borrows from the lender above its reserve", func(t *testing.T) {
+		group, batch, rejections := newGroup(t, 0.3)
+		interactive := group.RateLimiter("interactive")
+
+		for i := 0; i < 5; i++ {
+			assert.True(t, interactive.Allow(), "call %d", i)
+		}
+		assert.False(t, interactive.Allow())
+		assert.Equal(t, 1, *rejections)
+
+		assert.InDelta(t, 7, batch.Stats().Tokens, 0.01)
+		assert.Equal(t, []QuotaLoanStats{{From: "batch", To: "interactive", Ratio: 0.3, Lent: 3}}, group.Loans())
+	})
+
+	t.Run("lender reclaims its quota", func(t *testing.T) {
+		group, _, _ := newGroup(t, 0.5)
+		batch := group.RateLimiter("batch")
+		interactive := group.RateLimiter("interactive")
+
+		for i := 0; i < 5; i++ {
+			assert.True(t, batch.Allow())
+		}
+		assert.True(t, interactive.Allow())
+		assert.True(t, interactive.Allow())
+		assert.False(t, interactive.Allow(), "batch is down to its reserve")
+		assert.True(t, batch.Allow(), "batch keeps its reserve")
+		assert.Zero(t, group.Loans()[0].Lent)
+	})
+
+	t.Run("lends one way only", func(t *testing.T) {
+		group, _, _ := newGroup(t, 1)
+		interactive := group.RateLimiter("interactive")
+		batch := group.RateLimiter("batch")
+
+		assert.True(t, interactive.Allow())
+		assert.True(t, interactive.Allow())
+		for i := 0; i < 10; i++ {
+			assert.True(t, batch.Allow())
+		}
+		assert.False(t, batch.Allow())
+		assert.ErrorIs(t, batch.TryExecute(context.Background(), func(ctx context.Context) error { return nil }), ErrRateLimitExceeded)
+	})
+
+	t.Run("shares group limiters", func(t *testing.T) {
+		limiters, err := NewRateLimiterGroup(RateLimiterGroupConfig{Limiter: RateLimiterConfig{Rate: 1, Burst: 4}})
+		require.NoError(t, err)
+		defer limiters.Close(context.Background())
+
+		group, err := NewQuotaSharingGroup(QuotaSharingConfig{
+			Loans: []QuotaLoan{{From: "a", To: "b", Ratio: 0.5}},
+		}, map[string]RateLimiter{"a": limiters.RateLimiter("a"), "b": limiters.RateLimiter("b")})
+		require.NoError(t, err)
+
+		b := group.RateLimiter("b")
+		for i := 0; i < 6; i++ {
+			assert.True(t, b.Allow(), "call %d", i)
+		}
+		assert.False(t, b.Allow())
+	})
+
+	t.Run("validates loans", func(t *testing.T) {
+		limiters := map[string]RateLimiter{
+			"a": NewRateLimiter(RateLimiterConfig{Name: "a"}),
+			"b": NewRateLimiter(RateLimiterConfig{Name: "b"}),
+		}
+		for _, loan := range []QuotaLoan{
+			{From: "a", To: "c", Ratio: 0.5},
+			{From: "c", To: "a", Ratio: 0.5},
+			{From: "a", To: "a", Ratio: 0.5},
+			{From: "a", To: "b", Ratio: 0},
+			{From: "a", To: "b", Ratio: 1.5},
+		} {
+			_, err := NewQuotaSharingGroup(QuotaSharingConfig{Loans: []QuotaLoan{loan}}, limiters)
+			assert.Error(t, err, "%+v", loan)
+		}
+
+		limiters["noop"] = NoopRateLimiter()
+		_, err := NewQuotaSharingGroup(QuotaSharingConfig{}, limiters)
+		assert.Error(t, err)
+		assert.Nil(t, (&quotaSharingGroup{}).RateLimiter("unknown"))
+	})
+}
@@ -2,16 +2,24 @@ package resilience
 
 import (
 	"context"
+	"fmt"
+	"math"
 	"sync"
 	"time"
 )
 
 // rateLimiter implements the RateLimiter interface using token bucket algorithm
 type rateLimiter struct {
-	config   RateLimiterConfig
-	mu       sync.Mutex
-	tokens   float64
-	lastTime time.Time
+	config     RateLimiterConfig
+	mu         sync.Mutex
+	rate       float64 // config.Rate scaled by multiplier and share
+	multiplier float64 // set by SetRateMultiplier
+	share      float64 // share of a fleet rate; 1 without usage sharing
+	tokens     float64
+	lastTime   time.Time
+	gauge      *smoothedGauge
+	clock      Clock
+	sharing    *usageSharing // nil without usage sharing
 }
 
 // NewRateLimiter creates a new rate limiter
@@ -22,12 +30,30 @@ func NewRateLimiter(config RateLimiterConfig) RateLimiter {
 	if config.Burst == 0 {
 		config.Burst = DefaultRateLimiterConfig().Burst
 	}
+	if config.ReservationTTL == 0 {
+		config.ReservationTTL = DefaultRateLimiterConfig().ReservationTTL
+	}
+	if config.StatsSmoothing == 0 {
+		config.StatsSmoothing = DefaultRateLimiterConfig().StatsSmoothing
+	}
+	if config.UsageInterval <= 0 {
+		config.UsageInterval = DefaultRateLimiterConfig().UsageInterval
+	}
 
-	return &rateLimiter{
-		config:   config,
-		tokens:   float64(config.Burst),
-		lastTime: time.Now(),
+	clock := clockOrSystem(config.Clock)
+	now := clock.Now()
+	rl := &rateLimiter{
+		config:     config,
+		rate:       config.Rate,
+		multiplier: 1,
+		share:      1,
+		tokens:     float64(config.Burst),
+		lastTime:   now,
+		gauge:      newSmoothedGauge(config.StatsSmoothing, float64(config.Burst), now),
+		clock:      clock,
 	}
+	rl.startUsageSharing(now)
+	return rl
 }
 
 func (rl *rateLimiter) Name() string {
@@ -35,16 +61,27 @@ func (rl *rateLimiter) Name() string {
 }
 
 func (rl *rateLimiter) Allow() bool {
+	return rl.allow(true)
+}
+
+// allow takes a token if one is available. Rejections count as demand only
+// when the call is dropped; a waiting call counts once it gets its token.
+func (rl *rateLimiter) allow(dropped bool) bool {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
-	now := time.Now()
+	now := rl.clock.Now()
 	rl.refillTokens(now)
 
 	if rl.tokens >= 1.0 {
 		rl.tokens--
+		rl.gauge.set(now, rl.tokens)
+		rl.countDemand(now, 1)
 		return true
 	}
+	if dropped {
+		rl.countDemand(now, 1)
+	}
 
 	// Call rate limit callback
 	if rl.config.OnRateLimit != nil {
@@ -54,9 +91,16 @@ func (rl *rateLimiter) Allow() bool {
 	return false
 }
 
+func (rl *rateLimiter) TryExecute(ctx context.Context, fn func(context.Context) error) error {
+	if !rl.Allow() {
+		return ErrRateLimitExceeded
+	}
+	return fn(ctx)
+}
+
 func (rl *rateLimiter) Wait(ctx context.Context) error {
 	for {
-		if rl.Allow() {
+		if rl.allow(false) {
 			return nil
 		}
 
@@ -64,21 +108,229 @@ func (rl *rateLimiter) Wait(ctx context.Context) error {
 		waitTime := rl.nextTokenDuration()
 
 		// Wait or context cancellation
-		select {
-		case <-time.After(waitTime):
-			// Try again
-		case <-ctx.Done():
-			return ctx.Err()
+		if err := sleep(ctx, waitTime, rl.config.TimerWheel, rl.clock); err != nil {
+			return err
 		}
 	}
 }
 
+func (rl *rateLimiter) Acquire(ctx context.Context, n int) (Reservation, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("resilience: invalid token count %d", n)
+	}
+	if n > rl.config.Burst {
+		return nil, fmt.Errorf("%w: cannot acquire %d tokens with burst %d", ErrRateLimitExceeded, n, rl.config.Burst)
+	}
+
+	for {
+		waitTime, ok := rl.tryAcquire(n)
+		if ok {
+			return newReservation(rl, n, rl.clock, rl.config.ReservationTTL), nil
+		}
+
+		// Wait or context cancellation
+		if err := sleep(ctx, waitTime, rl.config.TimerWheel, rl.clock); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// tryTake takes a token if at least reserve tokens remain after it,
+// without reporting a rejection
+func (rl *rateLimiter) tryTake(reserve float64) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.clock.Now()
+	rl.refillTokens(now)
+	if rl.tokens-1 < reserve {
+		return false
+	}
+	rl.tokens--
+	rl.gauge.set(now, rl.tokens)
+	rl.countDemand(now, 1)
+	return true
+}
+
+// burstSize returns the bucket capacity
+func (rl *rateLimiter) burstSize() float64 {
+	return float64(rl.config.Burst)
+}
+
+// tryAcquire takes n tokens if available, otherwise returns the time until they are
+func (rl *rateLimiter) tryAcquire(n int) (time.Duration, bool) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.clock.Now()
+	rl.refillTokens(now)
+
+	needed := float64(n) - rl.tokens
+	if needed <= 0 {
+		rl.tokens -= float64(n)
+		rl.gauge.set(now, rl.tokens)
+		rl.countDemand(now, float64(n))
+		return 0, true
+	}
+
+	seconds := needed / rl.rate
+	return rl.pausedFor(now) + time.Duration(seconds*float64(time.Second)), false
+}
+
+// pausedFor returns how long refilling remains paused by a server quota
+func (rl *rateLimiter) pausedFor(now time.Time) time.Duration {
+	if now.Before(rl.lastTime) {
+		return rl.lastTime.Sub(now)
+	}
+	return 0
+}
+
+// returnTokens gives back reserved tokens that were not consumed
+func (rl *rateLimiter) returnTokens(n int) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.clock.Now()
+	rl.refillTokens(now)
+	rl.tokens += float64(n)
+	if rl.tokens > float64(rl.config.Burst) {
+		rl.tokens = float64(rl.config.Burst)
+	}
+	rl.gauge.set(now, rl.tokens)
+}
+
+func (rl *rateLimiter) Stats() RateLimiterStats {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.clock.Now()
+	rl.refillTokens(now)
+
+	return RateLimiterStats{
+		Tokens:         rl.tokens,
+		SmoothedTokens: rl.gauge.set(now, rl.tokens),
+		Rate:           rl.rate,
+	}
+}
+
+// tokenReturner is a limiter taking back unconsumed reserved tokens
+type tokenReturner interface {
+	returnTokens(n int)
+}
+
+// reservation implements the Reservation interface
+type reservation struct {
+	limiter   tokenReturner
+	mu        sync.Mutex
+	remaining int
+	closed    bool
+	expiry    Timer
+}
+
+// newReservation holds n tokens of limiter, returning those unconsumed
+// after ttl on clock
+func newReservation(limiter tokenReturner, n int, clock Clock, ttl time.Duration) *reservation {
+	r := &reservation{
+		limiter:   limiter,
+		remaining: n,
+	}
+	r.mu.Lock()
+	r.expiry = clock.AfterFunc(ttl, r.Release)
+	r.mu.Unlock()
+	return r
+}
+
+func (r *reservation) Take() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed || r.remaining == 0 {
+		return false
+	}
+	r.remaining--
+	return true
+}
+
+func (r *reservation) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.remaining
+}
+
+func (r *reservation) Release() {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return
+	}
+	r.closed = true
+	unused := r.remaining
+	r.remaining = 0
+	r.expiry.Stop()
+	r.mu.Unlock()
+
+	if unused > 0 {
+		r.limiter.returnTokens(unused)
+	}
+}
+
+// ApplyQuota caps the available tokens at the server's remaining quota.
+// When the quota is exhausted, refilling pauses until the window resets.
+func (rl *rateLimiter) ApplyQuota(quota Quota) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.clock.Now()
+	rl.refillTokens(now)
+
+	remaining := float64(quota.Remaining)
+	if remaining < 0 {
+		remaining = 0
+	}
+	if rl.tokens > remaining {
+		rl.tokens = remaining
+	}
+	if remaining == 0 && quota.Reset > 0 {
+		// No refill until the server window resets
+		rl.lastTime = now.Add(quota.Reset)
+	}
+	rl.gauge.set(now, rl.tokens)
+}
+
+// SetRateMultiplier scales the configured rate, e.g. to 0.5 to halve the
+// load on a struggling dependency; 1 restores the configured rate
+func (rl *rateLimiter) SetRateMultiplier(multiplier float64) error {
+	if multiplier <= 0 || math.IsInf(multiplier, 0) || math.IsNaN(multiplier) {
+		return fmt.Errorf("resilience: invalid rate multiplier %v", multiplier)
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	// Tokens accrued so far refill at the old rate
+	rl.refillTokens(rl.clock.Now())
+	rl.multiplier = multiplier
+	rl.applyRate()
+	return nil
+}
+
+// applyRate recomputes the refill rate from the configured rate, the
+// multiplier and the fleet share; callers hold rl.mu
+func (rl *rateLimiter) applyRate() {
+	rl.rate = rl.config.Rate * rl.multiplier * rl.share
+}
+
 func (rl *rateLimiter) refillTokens(now time.Time) {
+	// Refilling is paused until lastTime by an exhausted quota
+	if now.Before(rl.lastTime) {
+		return
+	}
+
 	elapsed := now.Sub(rl.lastTime)
 	rl.lastTime = now
 
 	// Add tokens based on elapsed time and rate
-	tokensToAdd := rl.config.Rate * elapsed.Seconds()
+	tokensToAdd := rl.rate * elapsed.Seconds()
 	rl.tokens += tokensToAdd
 
 	// Cap at burst limit
@@ -98,6 +350,6 @@ func (rl *rateLimiter) nextTokenDuration() time.Duration {
 	}
 
 	// Time = tokens / rate
-	seconds := tokensNeeded / rl.config.Rate
-	return time.Duration(seconds * float64(time.Second))
+	seconds := tokensNeeded / rl.rate
+	return rl.pausedFor(rl.clock.Now()) + time.Duration(seconds*float64(time.Second))
 }
@@ -0,0 +1,451 @@
+package resilience
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// tokenScale is the fixed-point precision of group limiter tokens
+const tokenScale = 1000
+
+// groupSweepInterval is how often the refill loop visits every limiter
+const groupSweepInterval = time.Second
+
+// rateLimiterGroup implements the RateLimiterGroup interface
+type rateLimiterGroup struct {
+	config   RateLimiterGroupConfig
+	clock    Clock
+	start    time.Time
+	epoch    atomic.Uint32 // refill intervals since start
+	mu       sync.RWMutex
+	limiters map[string]*groupLimiter
+	rejected *keyRejections
+	ctx      context.Context
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewRateLimiterGroup creates rate limiters from config on demand, one per
+// key, and starts the loop refilling them; Close stops it
+func NewRateLimiterGroup(config RateLimiterGroupConfig) (RateLimiterGroup, error) {
+	defaults := DefaultRateLimiterGroupConfig()
+	if config.Limiter.Rate == 0 {
+		config.Limiter.Rate = defaults.Limiter.Rate
+	}
+	if config.Limiter.Burst == 0 {
+		config.Limiter.Burst = defaults.Limiter.Burst
+	}
+	if config.Limiter.ReservationTTL == 0 {
+		config.Limiter.ReservationTTL = defaults.Limiter.ReservationTTL
+	}
+	if config.Limiter.StatsSmoothing == 0 {
+		config.Limiter.StatsSmoothing = defaults.Limiter.StatsSmoothing
+	}
+	if config.RefillInterval <= 0 {
+		config.RefillInterval = defaults.RefillInterval
+	}
+	if config.Limiter.Rate < 0 || math.IsInf(config.Limiter.Rate, 0) || math.IsNaN(config.Limiter.Rate) {
+		return nil, fmt.Errorf("resilience: invalid rate %v", config.Limiter.Rate)
+	}
+	if config.Limiter.Burst < 0 || uint64(config.Limiter.Burst)*tokenScale > math.MaxUint32 {
+		return nil, fmt.Errorf("resilience: invalid burst %d for a rate limiter group", config.Limiter.Burst)
+	}
+
+	clock := clockOrSystem(config.Limiter.Clock)
+	ctx, cancel := context.WithCancel(context.Background())
+	g := &rateLimiterGroup{
+		config:   config,
+		clock:    clock,
+		start:    clock.Now(),
+		limiters: make(map[string]*groupLimiter),
+		rejected: newKeyRejections(clock.Now, maxRankedKeys),
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	go g.loop()
+	return g, nil
+}
+
+func (g *rateLimiterGroup) RateLimiter(key string) RateLimiter {
+	g.mu.RLock()
+	l, ok := g.limiters[key]
+	g.mu.RUnlock()
+	if ok {
+		return l
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if l, ok := g.limiters[key]; ok {
+		return l
+	}
+	l = g.newLimiter(key)
+	g.limiters[key] = l
+	return l
+}
+
+// newLimiter creates a limiter for key with a full bucket
+func (g *rateLimiterGroup) newLimiter(key string) *groupLimiter {
+	name := g.config.Limiter.Name
+	if key != "" {
+		name += "/" + key
+	}
+	l := &groupLimiter{
+		group: g,
+		key:   key,
+		name:  name,
+		burst: uint64(g.config.Limiter.Burst) * tokenScale,
+		gauge: newSmoothedGauge(g.config.Limiter.StatsSmoothing, float64(g.config.Limiter.Burst), g.clock.Now()),
+	}
+	epoch := g.epoch.Load()
+	l.state.Store(packTokens(epoch, uint32(l.burst)))
+	l.fullSince.Store(epoch)
+	l.setRate(g.config.Limiter.Rate)
+	return l
+}
+
+func (g *rateLimiterGroup) Keys() []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	keys := make([]string, 0, len(g.limiters))
+	for key := range g.limiters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (g *rateLimiterGroup) Len() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.limiters)
+}
+
+func (g *rateLimiterGroup) TopRejected(k int, window time.Duration) []KeyRejections {
+	return g.rejected.top(k, window)
+}
+
+func (g *rateLimiterGroup) Stats() RateLimiterGroupStats {
+	return RateLimiterGroupStats{
+		Keys:        g.Len(),
+		TopRejected: g.TopRejected(statsTopKeys, statsRejectionWindow),
+	}
+}
+
+func (g *rateLimiterGroup) Name() string {
+	return g.config.Limiter.Name
+}
+
+func (g *rateLimiterGroup) Close(ctx context.Context) error {
+	g.cancel()
+	select {
+	case <-g.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// loop advances the epoch every RefillInterval and sweeps the limiters
+// until Close
+func (g *rateLimiterGroup) loop() {
+	defer close(g.done)
+	timer := g.clock.NewTimer(g.config.RefillInterval)
+	defer timer.Stop()
+
+	lastSweep := g.start
+	for {
+		select {
+		case <-g.ctx.Done():
+			return
+		case <-timer.C():
+			now := g.clock.Now()
+			g.epoch.Store(g.epochAt(now))
+			if now.Sub(lastSweep) >= groupSweepInterval {
+				g.sweep()
+				lastSweep = now
+			}
+			timer.Reset(g.config.RefillInterval)
+		}
+	}
+}
+
+// epochAt returns the refill epoch at t
+func (g *rateLimiterGroup) epochAt(t time.Time) uint32 {
+	return uint32(t.Sub(g.start) / g.config.RefillInterval)
+}
+
+// sweep brings every limiter's refill epoch up to date, so none falls too
+// far behind for the epoch arithmetic, and drops those idle with a full
+// bucket for IdleTimeout
+func (g *rateLimiterGroup) sweep() {
+	epoch := g.epoch.Load()
+	idleEpochs := uint32(g.config.IdleTimeout / g.config.RefillInterval)
+
+	var idle []string
+	g.mu.RLock()
+	for key, l := range g.limiters {
+		if l.refresh(epoch) && g.config.IdleTimeout > 0 && epoch-l.fullSince.Load() >= idleEpochs {
+			idle = append(idle, key)
+		}
+	}
+	g.mu.RUnlock()
+	if len(idle) == 0 {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, key := range idle {
+		if l, ok := g.limiters[key]; ok && l.full() && epoch-l.fullSince.Load() >= idleEpochs {
+			delete(g.limiters, key)
+		}
+	}
+}
+
+// groupLimiter implements the RateLimiter interface with tokens refilled
+// by its group's epoch instead of the clock. Its state packs the epoch it
+// was last refilled at with its tokens in 1/tokenScale units, so a call
+// takes tokens with one compare-and-swap and no lock or clock read.
+type groupLimiter struct {
+	group     *rateLimiterGroup
+	key       string
+	name      string
+	burst     uint64        // scaled
+	state     atomic.Uint64 // refill epoch << 32 | scaled tokens
+	perEpoch  atomic.Uint64 // float64 bits of scaled tokens added per epoch
+	fullSince atomic.Uint32 // epoch the sweep last saw the bucket below full
+	gauge     *smoothedGauge
+}
+
+// packTokens packs a refill epoch and scaled tokens into a limiter state
+func packTokens(epoch, tokens uint32) uint64 {
+	return uint64(epoch)<<32 | uint64(tokens)
+}
+
+// unpackTokens splits a limiter state into its refill epoch and tokens
+func unpackTokens(state uint64) (epoch, tokens uint32) {
+	return uint32(state >> 32), uint32(state)
+}
+
+// setRate sets the refill rate in tokens per second
+func (l *groupLimiter) setRate(rate float64) {
+	perEpoch := rate * l.group.config.RefillInterval.Seconds() * tokenScale
+	l.perEpoch.Store(math.Float64bits(perEpoch))
+}
+
+// refill returns state with the tokens accrued by epoch. A refill epoch
+// ahead of the group's is a refill paused by an exhausted quota.
+func (l *groupLimiter) refill(state uint64, epoch uint32) uint64 {
+	last, tokens := unpackTokens(state)
+	elapsed := int32(epoch - last)
+	if elapsed <= 0 {
+		return state
+	}
+	if uint64(tokens) >= l.burst {
+		return packTokens(epoch, tokens)
+	}
+
+	// Fractions of a scaled token keep accruing from the last refill
+	add := uint64(float64(elapsed) * math.Float64frombits(l.perEpoch.Load()))
+	if add == 0 {
+		return state
+	}
+	return packTokens(epoch, uint32(min(uint64(tokens)+add, l.burst)))
+}
+
+// update applies fn to the refilled state until it is stored
+func (l *groupLimiter) update(fn func(epoch, tokens uint32) (uint64, bool)) bool {
+	epoch := l.group.epoch.Load()
+	for {
+		old := l.state.Load()
+		state, ok := fn(unpackTokens(l.refill(old, epoch)))
+		if !ok {
+			return false
+		}
+		if state == old || l.state.CompareAndSwap(old, state) {
+			return true
+		}
+	}
+}
+
+// take removes n scaled tokens if available
+func (l *groupLimiter) take(n uint64) bool {
+	return l.update(func(epoch, tokens uint32) (uint64, bool) {
+		if uint64(tokens) < n {
+			return 0, false
+		}
+		return packTokens(epoch, tokens-uint32(n)), true
+	})
+}
+
+// tryTake takes a token if at least reserve tokens remain after it,
+// without reporting a rejection
+func (l *groupLimiter) tryTake(reserve float64) bool {
+	needed := uint64(math.Ceil(max(reserve, 0)*tokenScale)) + tokenScale
+	return l.update(func(epoch, tokens uint32) (uint64, bool) {
+		if uint64(tokens) < needed {
+			return 0, false
+		}
+		return packTokens(epoch, tokens-tokenScale), true
+	})
+}
+
+// burstSize returns the bucket capacity
+func (l *groupLimiter) burstSize() float64 {
+	return float64(l.group.config.Limiter.Burst)
+}
+
+// refresh stores the tokens accrued by epoch and tracks since when the
+// bucket has been full, reporting whether it is
+func (l *groupLimiter) refresh(epoch uint32) bool {
+	var tokens uint32
+	l.update(func(last, t uint32) (uint64, bool) {
+		tokens = t
+		return packTokens(last, t), true
+	})
+	if uint64(tokens) < l.burst {
+		l.fullSince.Store(epoch)
+		return false
+	}
+	return true
+}
+
+// full reports whether the bucket is full
+func (l *groupLimiter) full() bool {
+	_, tokens := unpackTokens(l.refill(l.state.Load(), l.group.epoch.Load()))
+	return uint64(tokens) >= l.burst
+}
+
+// waitFor returns the time until n scaled tokens are available
+func (l *groupLimiter) waitFor(n uint64) time.Duration {
+	epoch := l.group.epoch.Load()
+	last, tokens := unpackTokens(l.refill(l.state.Load(), epoch))
+	if uint64(tokens) >= n {
+		return 0
+	}
+
+	epochs := 0.0
+	if paused := int32(last - epoch); paused > 0 {
+		epochs = float64(paused)
+	}
+	perEpoch := math.Float64frombits(l.perEpoch.Load())
+	epochs += math.Ceil(float64(n-uint64(tokens)) / perEpoch)
+	return time.Duration(epochs * float64(l.group.config.RefillInterval))
+}
+
+func (l *groupLimiter) Name() string {
+	return l.name
+}
+
+func (l *groupLimiter) Allow() bool {
+	return l.allow(true)
+}
+
+// allow takes a token if one is available. Rejections are ranked only when
+// the call is dropped, not for each retry of a waiting call.
+func (l *groupLimiter) allow(dropped bool) bool {
+	if l.take(tokenScale) {
+		return true
+	}
+	if dropped {
+		l.group.rejected.record(l.key)
+	}
+	if l.group.config.Limiter.OnRateLimit != nil {
+		l.group.config.Limiter.OnRateLimit(l.name)
+	}
+	return false
+}
+
+func (l *groupLimiter) TryExecute(ctx context.Context, fn func(context.Context) error) error {
+	if !l.Allow() {
+		return ErrRateLimitExceeded
+	}
+	return fn(ctx)
+}
+
+func (l *groupLimiter) Wait(ctx context.Context) error {
+	for {
+		if l.allow(false) {
+			return nil
+		}
+		if err := sleep(ctx, l.waitFor(tokenScale), l.group.config.Limiter.TimerWheel, l.group.clock); err != nil {
+			return err
+		}
+	}
+}
+
+func (l *groupLimiter) Acquire(ctx context.Context, n int) (Reservation, error) {
+	config := l.group.config.Limiter
+	if n <= 0 {
+		return nil, fmt.Errorf("resilience: invalid token count %d", n)
+	}
+	if n > config.Burst {
+		return nil, fmt.Errorf("%w: cannot acquire %d tokens with burst %d", ErrRateLimitExceeded, n, config.Burst)
+	}
+
+	scaled := uint64(n) * tokenScale
+	for !l.take(scaled) {
+		if err := sleep(ctx, l.waitFor(scaled), config.TimerWheel, l.group.clock); err != nil {
+			return nil, err
+		}
+	}
+	return newReservation(l, n, l.group.clock, config.ReservationTTL), nil
+}
+
+// returnTokens gives back reserved tokens that were not consumed
+func (l *groupLimiter) returnTokens(n int) {
+	l.update(func(epoch, tokens uint32) (uint64, bool) {
+		return packTokens(epoch, uint32(min(uint64(tokens)+uint64(n)*tokenScale, l.burst))), true
+	})
+}
+
+// Stats returns the available tokens. Group limiters keep no gauge on the
+// hot path, so the smoothed value averages the tokens seen by Stats calls.
+func (l *groupLimiter) Stats() RateLimiterStats {
+	_, tokens := unpackTokens(l.refill(l.state.Load(), l.group.epoch.Load()))
+	available := float64(tokens) / tokenScale
+	perEpoch := math.Float64frombits(l.perEpoch.Load())
+	return RateLimiterStats{
+		Tokens:         available,
+		SmoothedTokens: l.gauge.set(l.group.clock.Now(), available),
+		Rate:           perEpoch / tokenScale / l.group.config.RefillInterval.Seconds(),
+	}
+}
+
+// ApplyQuota caps the available tokens at the server's remaining quota.
+// When the quota is exhausted, refilling pauses until the window resets.
+func (l *groupLimiter) ApplyQuota(quota Quota) {
+	remaining := uint64(max(quota.Remaining, 0)) * tokenScale
+	interval := l.group.config.RefillInterval
+	l.update(func(epoch, tokens uint32) (uint64, bool) {
+		tokens = uint32(min(uint64(tokens), remaining))
+		if remaining == 0 && quota.Reset > 0 {
+			// No refill until the server window resets
+			epoch = l.group.epoch.Load() + uint32((quota.Reset+interval-1)/interval)
+		}
+		return packTokens(epoch, tokens), true
+	})
+}
+
+// SetRateMultiplier scales the configured rate, e.g. to 0.5 to halve the
+// load on a struggling dependency; 1 restores the configured rate
+func (l *groupLimiter) SetRateMultiplier(multiplier float64) error {
+	if multiplier <= 0 || math.IsInf(multiplier, 0) || math.IsNaN(multiplier) {
+		return fmt.Errorf("resilience: invalid rate multiplier %v", multiplier)
+	}
+
+	// Tokens accrued so far refill at the old rate
+	l.update(func(epoch, tokens uint32) (uint64, bool) {
+		return packTokens(epoch, tokens), true
+	})
+	l.setRate(l.group.config.Limiter.Rate * multiplier)
+	return nil
+}
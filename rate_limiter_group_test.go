@@ -0,0 +1,220 @@
+package resilience
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiterGroup(t *testing.T) {
+	ctx := context.Background()
+	newGroup := func(t *testing.T, config RateLimiterGroupConfig) (RateLimiterGroup, *FakeClock) {
+		clock := NewFakeClock(time.Now())
+		config.Limiter.Name = "api"
+		config.Limiter.Clock = clock
+		config.RefillInterval = 10 * time.Millisecond
+		group, err := NewRateLimiterGroup(config)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = group.Close(ctx) })
+		return group, clock
+	}
+	// advance moves the clock by d and waits for the refill loop to catch up
+	advance := func(t *testing.T, clock *FakeClock, d time.Duration) {
+		clock.Advance(d)
+		require.NoError(t, clock.BlockUntil(ctx, 1))
+	}
+
+	t.Run("limits each key independently", func(t *testing.T) {
+		group, _ := newGroup(t, RateLimiterGroupConfig{Limiter: RateLimiterConfig{Rate: 10, Burst: 2}})
+
+		a := group.RateLimiter("a")
+		assert.True(t, a.Allow())
+		assert.True(t, a.Allow())
+		assert.False(t, a.Allow())
+		assert.True(t, group.RateLimiter("b").Allow())
+
+		assert.Same(t, a, group.RateLimiter("a"))
+		assert.Equal(t, "api/a", a.Name())
+		assert.Equal(t, []string{"a", "b"}, group.Keys())
+		assert.Equal(t, 2, group.Len())
+	})
+
+	t.Run("refills from the shared epoch", func(t *testing.T) {
+		group, clock := newGroup(t, RateLimiterGroupConfig{Limiter: RateLimiterConfig{Rate: 10, Burst: 1}})
+		limiter := group.RateLimiter("a")
+		require.NoError(t, clock.BlockUntil(ctx, 1))
+
+		assert.True(t, limiter.Allow())
+		advance(t, clock, 50*time.Millisecond)
+		assert.False(t, limiter.Allow())
+		assert.InDelta(t, 0.5, limiter.Stats().Tokens, 0.01)
+
+		advance(t, clock, 50*time.Millisecond)
+		assert.True(t, limiter.Allow())
+		assert.False(t, limiter.Allow())
+	})
+
+	t.Run("drops limiters idle with a full bucket", func(t *testing.T) {
+		group, clock := newGroup(t, RateLimiterGroupConfig{
+			Limiter:     RateLimiterConfig{Rate: 0.1, Burst: 1},
+			IdleTimeout: 2 * time.Second,
+		})
+		group.RateLimiter("idle")
+		assert.True(t, group.RateLimiter("busy").Allow())
+		require.NoError(t, clock.BlockUntil(ctx, 1))
+
+		advance(t, clock, time.Second)
+		assert.Equal(t, []string{"busy", "idle"}, group.Keys())
+		advance(t, clock, time.Second)
+		assert.Equal(t, []string{"busy"}, group.Keys())
+	})
+
+	t.Run("pauses refills for an exhausted quota", func(t *testing.T) {
+		group, clock := newGroup(t, RateLimiterGroupConfig{Limiter: RateLimiterConfig{Rate: 100, Burst: 5}})
+		limiter := group.RateLimiter("a")
+		require.NoError(t, clock.BlockUntil(ctx, 1))
+
+		limiter.ApplyQuota(Quota{Remaining: 0, Reset: time.Second})
+		assert.False(t, limiter.Allow())
+		advance(t, clock, 500*time.Millisecond)
+		assert.False(t, limiter.Allow())
+		advance(t, clock, 600*time.Millisecond)
+		assert.True(t, limiter.Allow())
+	})
+
+	t.Run("scales the rate", func(t *testing.T) {
+		group, clock := newGroup(t, RateLimiterGroupConfig{Limiter: RateLimiterConfig{Rate: 10, Burst: 1}})
+		limiter := group.RateLimiter("a")
+		require.NoError(t, clock.BlockUntil(ctx, 1))
+
+		assert.True(t, limiter.Allow())
+		require.NoError(t, limiter.SetRateMultiplier(0.5))
+		advance(t, clock, 100*time.Millisecond)
+		assert.False(t, limiter.Allow())
+		advance(t, clock, 100*time.Millisecond)
+		assert.True(t, limiter.Allow())
+		assert.Error(t, limiter.SetRateMultiplier(0))
+	})
+
+	t.Run("transfers state to and from standalone limiters", func(t *testing.T) {
+		group, _ := newGroup(t, RateLimiterGroupConfig{Limiter: RateLimiterConfig{Rate: 1, Burst: 5}})
+		limiter := group.RateLimiter("a")
+		for i := 0; i < 3; i++ {
+			assert.True(t, limiter.Allow())
+		}
+
+		standalone := NewRateLimiter(RateLimiterConfig{Name: "api/a", Rate: 1, Burst: 5})
+		require.NoError(t, standalone.Import(limiter.Export()))
+		assert.InDelta(t, 2, standalone.Stats().Tokens, 0.1)
+
+		assert.ErrorIs(t, group.RateLimiter("b").Import(standalone.Export()), ErrInvalidState)
+	})
+
+	t.Run("acquires and returns reserved tokens", func(t *testing.T) {
+		group, err := NewRateLimiterGroup(RateLimiterGroupConfig{Limiter: RateLimiterConfig{Name: "api", Rate: 1, Burst: 3}})
+		require.NoError(t, err)
+		defer group.Close(ctx)
+		limiter := group.RateLimiter("a")
+
+		reservation, err := limiter.Acquire(ctx, 3)
+		require.NoError(t, err)
+		assert.False(t, limiter.Allow())
+		assert.True(t, reservation.Take())
+		reservation.Release()
+		assert.True(t, limiter.Allow())
+		assert.True(t, limiter.Allow())
+
+		_, err = limiter.Acquire(ctx, 4)
+		assert.ErrorIs(t, err, ErrRateLimitExceeded)
+	})
+
+	t.Run("waits for the next token", func(t *testing.T) {
+		group, err := NewRateLimiterGroup(RateLimiterGroupConfig{
+			Limiter:        RateLimiterConfig{Name: "api", Rate: 100, Burst: 1},
+			RefillInterval: time.Millisecond,
+		})
+		require.NoError(t, err)
+		defer group.Close(ctx)
+		limiter := group.RateLimiter("a")
+
+		assert.True(t, limiter.Allow())
+		waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		assert.NoError(t, limiter.Wait(waitCtx))
+	})
+
+	t.Run("ranks keys by dropped calls", func(t *testing.T) {
+		group, clock := newGroup(t, RateLimiterGroupConfig{Limiter: RateLimiterConfig{Rate: 0.001, Burst: 1}})
+		reject := func(key string, n int) {
+			limiter := group.RateLimiter(key)
+			limiter.Allow()
+			for i := 0; i < n; i++ {
+				assert.ErrorIs(t, limiter.TryExecute(ctx, func(ctx context.Context) error { return nil }), ErrRateLimitExceeded)
+			}
+		}
+
+		reject("acme", 2)
+		reject("globex", 0)
+		advance(t, clock, 10*time.Minute)
+		reject("initech", 3)
+		waitCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		assert.Error(t, group.RateLimiter("initech").Wait(waitCtx))
+
+		assert.Equal(t, []KeyRejections{{Key: "initech", Rejections: 3}, {Key: "acme", Rejections: 2}}, group.TopRejected(5, time.Hour))
+		assert.Equal(t, RateLimiterGroupStats{
+			Keys:        3,
+			TopRejected: []KeyRejections{{Key: "initech", Rejections: 3}},
+		}, group.Stats())
+	})
+
+	t.Run("rejects bursts beyond the token range", func(t *testing.T) {
+		_, err := NewRateLimiterGroup(RateLimiterGroupConfig{Limiter: RateLimiterConfig{Burst: 1 << 30}})
+		assert.Error(t, err)
+	})
+}
+
+// BenchmarkRateLimiterKeys compares Allow across many keyed limiters, each
+// refilling on its own clock reads under lock, with a group sharing one
+// refill loop
+func BenchmarkRateLimiterKeys(b *testing.B) {
+	const keys = 10000
+	config := RateLimiterConfig{Name: "bench", Rate: 1e6, Burst: 1000}
+
+	run := func(b *testing.B, limiters []RateLimiter) {
+		var next atomic.Uint64
+		b.ReportAllocs()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			i := next.Add(7919)
+			for pb.Next() {
+				i++
+				limiters[i%keys].Allow()
+			}
+		})
+	}
+
+	b.Run("standalone", func(b *testing.B) {
+		limiters := make([]RateLimiter, keys)
+		for i := range limiters {
+			limiters[i] = NewRateLimiter(config)
+		}
+		run(b, limiters)
+	})
+
+	b.Run("group", func(b *testing.B) {
+		group, err := NewRateLimiterGroup(RateLimiterGroupConfig{Limiter: config})
+		require.NoError(b, err)
+		defer group.Close(context.Background())
+		limiters := make([]RateLimiter, keys)
+		for i := range limiters {
+			limiters[i] = group.RateLimiter(fmt.Sprint(i))
+		}
+		run(b, limiters)
+	})
+}
@@ -2,10 +2,14 @@ package resilience
 
 import (
 	"context"
+	"math"
+	"runtime"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRateLimiterBasics(t *testing.T) {
@@ -40,4 +44,274 @@ func TestRateLimiterBasics(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Greater(t, duration, 5*time.Millisecond)
 	})
+
+	t.Run("TryExecute rejects instead of waiting", func(t *testing.T) {
+		rl := NewRateLimiter(RateLimiterConfig{Name: "test", Rate: 1, Burst: 1})
+		ctx := context.Background()
+		calls := 0
+		fn := func(ctx context.Context) error {
+			calls++
+			return nil
+		}
+
+		assert.NoError(t, rl.TryExecute(ctx, fn))
+		assert.ErrorIs(t, rl.TryExecute(ctx, fn), ErrRateLimitExceeded)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestRateLimiterAcquire(t *testing.T) {
+	t.Run("reserves tokens for later use", func(t *testing.T) {
+		rl := NewRateLimiter(RateLimiterConfig{Name: "test", Rate: 1, Burst: 5})
+
+		res, err := rl.Acquire(context.Background(), 3)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, res.Remaining())
+
+		assert.True(t, rl.Allow())
+		assert.True(t, rl.Allow())
+		assert.False(t, rl.Allow())
+
+		assert.True(t, res.Take())
+		assert.Equal(t, 2, res.Remaining())
+	})
+
+	t.Run("release returns unused tokens", func(t *testing.T) {
+		rl := NewRateLimiter(RateLimiterConfig{Name: "test", Rate: 1, Burst: 3})
+
+		res, err := rl.Acquire(context.Background(), 3)
+		assert.NoError(t, err)
+		assert.False(t, rl.Allow())
+
+		res.Take()
+		res.Release()
+		assert.False(t, res.Take())
+		assert.True(t, rl.Allow())
+		assert.True(t, rl.Allow())
+		assert.False(t, rl.Allow())
+	})
+
+	t.Run("expired reservations return tokens", func(t *testing.T) {
+		rl := NewRateLimiter(RateLimiterConfig{Name: "test", Rate: 1, Burst: 2, ReservationTTL: 10 * time.Millisecond})
+
+		res, err := rl.Acquire(context.Background(), 2)
+		assert.NoError(t, err)
+
+		assert.Eventually(t, func() bool { return res.Remaining() == 0 }, time.Second, time.Millisecond)
+		assert.False(t, res.Take())
+		assert.True(t, rl.Allow())
+	})
+
+	t.Run("waits for tokens", func(t *testing.T) {
+		rl := NewRateLimiter(RateLimiterConfig{Name: "test", Rate: 100, Burst: 2})
+		rl.Acquire(context.Background(), 2)
+
+		start := time.Now()
+		_, err := rl.Acquire(context.Background(), 2)
+		assert.NoError(t, err)
+		assert.Greater(t, time.Since(start), 10*time.Millisecond)
+	})
+
+	t.Run("rejects requests above burst", func(t *testing.T) {
+		rl := NewRateLimiter(RateLimiterConfig{Name: "test", Rate: 1, Burst: 2})
+		_, err := rl.Acquire(context.Background(), 3)
+		assert.ErrorIs(t, err, ErrRateLimitExceeded)
+	})
+}
+
+func TestRateLimiterStats(t *testing.T) {
+	t.Run("smoothed tokens lag raw tokens", func(t *testing.T) {
+		rl := NewRateLimiter(RateLimiterConfig{
+			Name:           "test",
+			Rate:           0.001,
+			Burst:          10,
+			StatsSmoothing: time.Hour,
+		})
+
+		for i := 0; i < 10; i++ {
+			assert.True(t, rl.Allow())
+		}
+
+		stats := rl.Stats()
+		assert.Less(t, stats.Tokens, 0.1)
+		assert.Greater(t, stats.SmoothedTokens, 9.9)
+	})
+}
+
+func TestRateLimiterApplyQuota(t *testing.T) {
+	t.Run("caps tokens at the remaining quota", func(t *testing.T) {
+		rl := NewRateLimiter(RateLimiterConfig{Name: "test", Rate: 0.001, Burst: 10})
+		rl.ApplyQuota(Quota{Limit: 10, Remaining: 2, Reset: time.Minute})
+
+		assert.True(t, rl.Allow())
+		assert.True(t, rl.Allow())
+		assert.False(t, rl.Allow())
+	})
+
+	t.Run("pauses refill until an exhausted window resets", func(t *testing.T) {
+		clock := NewFakeClock(time.Now())
+		rl := NewRateLimiter(RateLimiterConfig{Name: "test", Rate: 1000, Burst: 10, Clock: clock})
+		rl.ApplyQuota(Quota{Remaining: 0, Reset: 50 * time.Millisecond})
+
+		clock.Advance(10 * time.Millisecond)
+		assert.False(t, rl.Allow())
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		waited := make(chan error, 1)
+		go func() { waited <- rl.Wait(ctx) }()
+
+		require.NoError(t, clock.BlockUntil(ctx, 1))
+		clock.Advance(39 * time.Millisecond)
+		select {
+		case <-waited:
+			t.Fatal("Wait returned before the window reset")
+		default:
+		}
+		clock.Advance(2 * time.Millisecond)
+		assert.NoError(t, <-waited)
+	})
+}
+
+func TestRateLimiterSetRateMultiplier(t *testing.T) {
+	t.Run("scales the refill rate", func(t *testing.T) {
+		clock := NewFakeClock(time.Now())
+		rl := NewRateLimiter(RateLimiterConfig{Name: "test", Rate: 100, Burst: 1, Clock: clock})
+		require.True(t, rl.Allow())
+
+		require.NoError(t, rl.SetRateMultiplier(0.01))
+		clock.Advance(30 * time.Millisecond)
+		assert.False(t, rl.Allow())
+
+		require.NoError(t, rl.SetRateMultiplier(1))
+		clock.Advance(30 * time.Millisecond)
+		assert.True(t, rl.Allow())
+	})
+
+	t.Run("rejects non-positive multipliers", func(t *testing.T) {
+		rl := NewRateLimiter(RateLimiterConfig{Name: "test", Rate: 100, Burst: 1})
+		assert.Error(t, rl.SetRateMultiplier(0))
+		assert.Error(t, rl.SetRateMultiplier(-1))
+	})
+}
+
+// subscriptionCounter counts the live usage subscriptions of a coordinator
+type subscriptionCounter struct {
+	UsageCoordinator
+	subscribed atomic.Int32
+}
+
+func (c *subscriptionCounter) SubscribeUsage(handler func(RateUsage)) func() {
+	c.subscribed.Add(1)
+	c.UsageCoordinator.SubscribeUsage(handler)
+	return func() { c.subscribed.Add(-1) }
+}
+
+func TestRateLimiterShareUsage(t *testing.T) {
+	t.Run("splits the fleet rate by demand", func(t *testing.T) {
+		hub := &memoryHub{}
+		clock := NewFakeClock(time.Now())
+		config := RateLimiterConfig{
+			Name:          "search",
+			Rate:          100,
+			Burst:         1000,
+			Clock:         clock,
+			ShareUsage:    true,
+			UsageInterval: time.Second,
+		}
+		configA, configB := config, config
+		configA.Coordinator = hub.join("a")
+		configB.Coordinator = hub.join("b")
+		a := NewRateLimiter(configA)
+		b := NewRateLimiter(configB)
+
+		for i := 0; i < 90; i++ {
+			require.True(t, a.Allow())
+		}
+		for i := 0; i < 10; i++ {
+			require.True(t, b.Allow())
+		}
+		clock.Advance(time.Second)
+		require.True(t, a.Allow())
+		require.True(t, b.Allow())
+
+		// a asks for 91/s and b for 11/s: each gets 5/s, b its remaining
+		// 6/s and a the other 79/s
+		assert.Eventually(t, func() bool {
+			return math.Abs(a.Stats().Rate-84) < 0.01 && math.Abs(b.Stats().Rate-16) < 0.01
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("unsubscribes limiters no longer used", func(t *testing.T) {
+		coordinator := &subscriptionCounter{UsageCoordinator: (&memoryHub{}).join("a")}
+		func() {
+			NewRateLimiter(RateLimiterConfig{Name: "search", Rate: 100, Burst: 10, ShareUsage: true, Coordinator: coordinator})
+		}()
+		require.Equal(t, int32(1), coordinator.subscribed.Load())
+
+		assert.Eventually(t, func() bool {
+			runtime.GC()
+			return coordinator.subscribed.Load() == 0
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("keeps the whole rate without peers", func(t *testing.T) {
+		hub := &memoryHub{}
+		clock := NewFakeClock(time.Now())
+		rl := NewRateLimiter(RateLimiterConfig{
+			Name:        "search",
+			Rate:        100,
+			Clock:       clock,
+			ShareUsage:  true,
+			Coordinator: hub.join("a"),
+		})
+
+		require.True(t, rl.Allow())
+		clock.Advance(time.Second)
+		require.True(t, rl.Allow())
+		assert.Equal(t, 100.0, rl.Stats().Rate)
+	})
+
+	t.Run("drops peers that stop reporting", func(t *testing.T) {
+		hub := &memoryHub{}
+		clock := NewFakeClock(time.Now())
+		rl := NewRateLimiter(RateLimiterConfig{
+			Name:        "search",
+			Rate:        100,
+			Clock:       clock,
+			ShareUsage:  true,
+			Coordinator: hub.join("a"),
+		})
+
+		peer := hub.join("b")
+		require.NoError(t, peer.PublishUsage(context.Background(), RateUsage{Limiter: "search", Demand: 500}))
+		assert.InDelta(t, 5, rl.Stats().Rate, 0.01)
+		require.NoError(t, peer.PublishUsage(context.Background(), RateUsage{Limiter: "other", Demand: 500}))
+		assert.InDelta(t, 5, rl.Stats().Rate, 0.01)
+
+		clock.Advance(4 * time.Second)
+		require.True(t, rl.Allow())
+		assert.Equal(t, 100.0, rl.Stats().Rate)
+	})
+}
+
+func TestFleetShare(t *testing.T) {
+	tests := []struct {
+		name   string
+		demand float64
+		peers  []float64
+		want   float64
+	}{
+		{name: "alone", demand: 10, want: 1},
+		{name: "idle fleet", demand: 0, peers: []float64{0, 0, 0}, want: 0.25},
+		{name: "demand below an even split", demand: 10, peers: []float64{500}, want: 0.15},
+		{name: "busiest instance", demand: 500, peers: []float64{10}, want: 0.85},
+		{name: "everyone saturated", demand: 500, peers: []float64{500}, want: 0.5},
+		{name: "spare rate", demand: 10, peers: []float64{20}, want: 0.45},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, fleetShare(100, tt.demand, tt.peers), 1e-9)
+		})
+	}
 }
@@ -0,0 +1,186 @@
+package resilience
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"sort"
+	"time"
+	"weak"
+)
+
+// RateUsage is a rate limiter's recent demand shared between instances
+type RateUsage struct {
+	// Limiter is the name of the rate limiter
+	Limiter string `json:"limiter"`
+
+	// Origin identifies the instance that published the usage
+	Origin string `json:"origin"`
+
+	// Demand is the calls per second the instance asked for over the last
+	// usage interval, admitted or rejected
+	Demand float64 `json:"demand"`
+
+	// Time is when the usage was measured
+	Time time.Time `json:"time"`
+}
+
+// UsageCoordinator is a Coordinator that also shares rate limiter usage
+// between instances, so a limiter with ShareUsage enforces its rate across
+// the fleet and each instance refills at a share following its demand
+type UsageCoordinator interface {
+	Coordinator
+
+	// PublishUsage announces the local demand of a limiter to other
+	// instances
+	PublishUsage(ctx context.Context, usage RateUsage) error
+
+	// SubscribeUsage registers a handler for usage published by other
+	// instances and returns a function that removes it
+	SubscribeUsage(handler func(RateUsage)) (unsubscribe func())
+}
+
+const (
+	// usageFloor is the share of a fleet rate split evenly between
+	// instances whatever their demand, so an idle instance can still start
+	// sending before its next usage report
+	usageFloor = 0.1
+
+	// usageExpiry is how many usage intervals a peer stays in the split
+	// after its last report
+	usageExpiry = 3
+)
+
+// usageSharing exchanges a rate limiter's demand with a UsageCoordinator.
+// Demand is counted over each usage interval; at the end of one it is
+// published and the limiter's share of the fleet rate is recomputed from
+// the latest demand of every live peer.
+type usageSharing struct {
+	coordinator UsageCoordinator
+	interval    time.Duration
+	start       time.Time // start of the current interval
+	requested   float64   // tokens asked for since start
+	demand      float64   // local demand over the last interval
+	peers       map[string]peerUsage
+}
+
+// peerUsage is the latest demand reported by another instance
+type peerUsage struct {
+	demand float64
+	seen   time.Time // local time of the report
+}
+
+// startUsageSharing subscribes the limiter to its peers' usage when
+// ShareUsage is set and the Coordinator shares usage. The subscription only
+// holds the limiter weakly and is removed once the limiter is collected, so
+// limiters no longer used do not pile up on a long-lived Coordinator.
+func (rl *rateLimiter) startUsageSharing(now time.Time) {
+	coordinator, ok := rl.config.Coordinator.(UsageCoordinator)
+	if !rl.config.ShareUsage || !ok {
+		return
+	}
+	rl.sharing = &usageSharing{
+		coordinator: coordinator,
+		interval:    rl.config.UsageInterval,
+		start:       now,
+		peers:       make(map[string]peerUsage),
+	}
+	limiter := weak.Make(rl)
+	unsubscribe := coordinator.SubscribeUsage(func(usage RateUsage) {
+		if rl := limiter.Value(); rl != nil {
+			rl.onPeerUsage(usage)
+		}
+	})
+	runtime.AddCleanup(rl, func(unsubscribe func()) { unsubscribe() }, unsubscribe)
+}
+
+// onPeerUsage records the demand another instance reported and rebalances
+// the fleet share
+func (rl *rateLimiter) onPeerUsage(usage RateUsage) {
+	if usage.Limiter != rl.config.Name || usage.Demand < 0 || math.IsNaN(usage.Demand) {
+		return
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	now := rl.clock.Now()
+	rl.sharing.peers[usage.Origin] = peerUsage{demand: usage.Demand, seen: now}
+	rl.rebalance(now)
+}
+
+// countDemand adds n requested tokens to the current usage interval,
+// publishing it and rebalancing the fleet share once the interval ends;
+// callers hold rl.mu
+func (rl *rateLimiter) countDemand(now time.Time, n float64) {
+	s := rl.sharing
+	if s == nil {
+		return
+	}
+	s.requested += n
+
+	elapsed := now.Sub(s.start)
+	if elapsed < s.interval {
+		return
+	}
+	s.demand = s.requested / elapsed.Seconds()
+	s.start = now
+	s.requested = 0
+
+	usage := RateUsage{Limiter: rl.config.Name, Demand: s.demand, Time: now}
+	go func() {
+		// A lost report only drops this instance from the split until the
+		// next one
+		ctx, cancel := context.WithTimeout(context.Background(), s.interval)
+		defer cancel()
+		_ = s.coordinator.PublishUsage(ctx, usage)
+	}()
+	rl.rebalance(now)
+}
+
+// rebalance recomputes the limiter's share of the fleet rate from the
+// latest local and live peer demand; callers hold rl.mu
+func (rl *rateLimiter) rebalance(now time.Time) {
+	s := rl.sharing
+	peers := make([]float64, 0, len(s.peers))
+	for origin, peer := range s.peers {
+		if now.Sub(peer.seen) > usageExpiry*s.interval {
+			delete(s.peers, origin)
+			continue
+		}
+		peers = append(peers, peer.demand)
+	}
+
+	// Tokens accrued so far refill at the old share
+	rl.refillTokens(now)
+	rl.share = fleetShare(rl.config.Rate, s.demand, peers)
+	rl.applyRate()
+}
+
+// fleetShare returns the share of rate for an instance with demand when
+// its peers have the given demands. Beyond an even floor, the rate is
+// water-filled: instances asking for less than an even split get what they
+// ask for and the rest divide what is left, so the busiest instances get
+// the largest shares without starving the others.
+func fleetShare(rate, demand float64, peers []float64) float64 {
+	n := float64(len(peers) + 1)
+	if rate <= 0 {
+		return 1 / n
+	}
+
+	demands := append(peers, demand)
+	sort.Float64s(demands)
+	pool := rate * (1 - usageFloor)
+	level := math.Inf(1)
+	for i, d := range demands {
+		remaining := n - float64(i)
+		if d*remaining >= pool {
+			level = pool / remaining
+			pool = 0
+			break
+		}
+		pool -= d
+	}
+
+	allocated := rate*usageFloor/n + min(demand, level) + pool/n
+	return allocated / rate
+}
@@ -0,0 +1,193 @@
+// Package redis provides a Redis coordination backend that shares circuit
+// breaker transitions over pub/sub and aggregates failure counts in hashes,
+// so a downstream outage trips every instance within one sync round trip.
+//
+// Import the package for its side effect to make the "redis" backend
+// available to the resilience module:
+//
+//	import _ "github.com/gostratum/resiliencex/redis"
+//
+// The first peer is the Redis address. When Redis is unreachable, breakers
+// keep deciding on their local counts.
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	resilience "github.com/gostratum/resiliencex"
+)
+
+// Backend is the coordination backend name
+const Backend = "redis"
+
+const (
+	defaultAddr      = "localhost:6379"
+	defaultChannel   = "resilience:breakers"
+	defaultKeyPrefix = "resilience:counts:"
+)
+
+func init() {
+	resilience.RegisterCoordinator(Backend, New)
+}
+
+// coordinator implements resilience.CountCoordinator on top of Redis
+type coordinator struct {
+	client    *goredis.Client
+	pubsub    *goredis.PubSub
+	channel   string
+	keyPrefix string
+	origin    string
+	mu        sync.RWMutex
+	handlers  map[int]func(resilience.CoordinationEvent)
+	nextID    int
+	done      chan struct{}
+}
+
+// New creates a Redis coordinator connected to the first configured peer.
+// Options: "password", "db", "channel" (default "resilience:breakers") and
+// "key_prefix" (default "resilience:counts:"). Connection failures are not
+// fatal; the client reconnects in the background.
+func New(config resilience.CoordinationConfig) (resilience.Coordinator, error) {
+	options := &goredis.Options{
+		Addr:     defaultAddr,
+		Password: config.Options["password"],
+	}
+	if len(config.Peers) > 0 {
+		options.Addr = config.Peers[0]
+	}
+	if v, ok := config.Options["db"]; ok {
+		db, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid db %q: %w", v, err)
+		}
+		options.DB = db
+	}
+
+	c := &coordinator{
+		client:    goredis.NewClient(options),
+		channel:   defaultChannel,
+		keyPrefix: defaultKeyPrefix,
+		origin:    config.NodeName,
+		handlers:  make(map[int]func(resilience.CoordinationEvent)),
+		done:      make(chan struct{}),
+	}
+	if v := config.Options["channel"]; v != "" {
+		c.channel = v
+	}
+	if v := config.Options["key_prefix"]; v != "" {
+		c.keyPrefix = v
+	}
+	if c.origin == "" {
+		host, _ := os.Hostname()
+		c.origin = host + "-" + strconv.Itoa(os.Getpid())
+	}
+
+	c.pubsub = c.client.Subscribe(context.Background(), c.channel)
+	go c.receive()
+	return c, nil
+}
+
+// Publish sends the event to every subscribed instance
+func (c *coordinator) Publish(ctx context.Context, event resilience.CoordinationEvent) error {
+	if event.Origin == "" {
+		event.Origin = c.origin
+	}
+
+	msg, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("redis: encode event: %w", err)
+	}
+	if err := c.client.Publish(ctx, c.channel, msg).Err(); err != nil {
+		return fmt.Errorf("redis: publish: %w", err)
+	}
+	return nil
+}
+
+func (c *coordinator) Subscribe(handler func(resilience.CoordinationEvent)) func() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := c.nextID
+	c.nextID++
+	c.handlers[id] = handler
+
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		delete(c.handlers, id)
+	}
+}
+
+// AddCounts increments the breaker's hash for the window and returns the
+// totals; the hash expires two intervals after the window starts
+func (c *coordinator) AddCounts(ctx context.Context, breaker string, window time.Time, interval time.Duration, delta resilience.SharedCounts) (resilience.SharedCounts, error) {
+	key := c.keyPrefix + breaker + ":" + strconv.FormatInt(window.UnixMilli(), 10)
+
+	pipe := c.client.TxPipeline()
+	requests := pipe.HIncrBy(ctx, key, "requests", int64(delta.Requests))
+	failures := pipe.HIncrBy(ctx, key, "failures", int64(delta.Failures))
+	pipe.PExpireAt(ctx, key, window.Add(2*interval))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return resilience.SharedCounts{}, fmt.Errorf("redis: add counts: %w", err)
+	}
+
+	return resilience.SharedCounts{
+		Requests: uint64(requests.Val()),
+		Failures: uint64(failures.Val()),
+	}, nil
+}
+
+func (c *coordinator) Close() error {
+	close(c.done)
+	if err := c.pubsub.Close(); err != nil {
+		c.client.Close()
+		return fmt.Errorf("redis: close subscription: %w", err)
+	}
+	return c.client.Close()
+}
+
+// receive delivers events published by other instances
+func (c *coordinator) receive() {
+	messages := c.pubsub.Channel()
+	for {
+		select {
+		case <-c.done:
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			c.deliver([]byte(msg.Payload))
+		}
+	}
+}
+
+func (c *coordinator) deliver(msg []byte) {
+	var event resilience.CoordinationEvent
+	if err := json.Unmarshal(msg, &event); err != nil {
+		return
+	}
+	// Pub/sub echoes our own events back
+	if event.Origin == c.origin {
+		return
+	}
+
+	c.mu.RLock()
+	handlers := make([]func(resilience.CoordinationEvent), 0, len(c.handlers))
+	for _, h := range c.handlers {
+		handlers = append(handlers, h)
+	}
+	c.mu.RUnlock()
+
+	for _, h := range handlers {
+		h(event)
+	}
+}
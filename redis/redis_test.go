@@ -0,0 +1,108 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	resilience "github.com/gostratum/resiliencex"
+)
+
+func newTestCoordinator(t *testing.T, addr, name string) *coordinator {
+	t.Helper()
+	c, err := resilience.NewCoordinator(resilience.CoordinationConfig{
+		Backend:  Backend,
+		NodeName: name,
+		Peers:    []string{addr},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+	return c.(*coordinator)
+}
+
+func breakerConfig(coordinator resilience.Coordinator) resilience.CircuitBreakerConfig {
+	return resilience.CircuitBreakerConfig{
+		Name:             "payments",
+		Interval:         time.Minute,
+		Timeout:          time.Minute,
+		FailureThreshold: 0.5,
+		MinRequests:      4,
+		Coordinator:      coordinator,
+	}
+}
+
+func failN(cb resilience.CircuitBreaker, n int) {
+	for i := 0; i < n; i++ {
+		cb.Execute(context.Background(), func(ctx context.Context) error { return errors.New("error") })
+	}
+}
+
+func TestRedisSharesState(t *testing.T) {
+	server := miniredis.RunT(t)
+
+	t.Run("shares trips and recoveries", func(t *testing.T) {
+		a := newTestCoordinator(t, server.Addr(), "a")
+		b := newTestCoordinator(t, server.Addr(), "b")
+		require.Eventually(t, func() bool {
+			return len(server.PubSubChannels("")) == 1 && server.PubSubNumSub(defaultChannel)[defaultChannel] == 2
+		}, 5*time.Second, 10*time.Millisecond)
+
+		cbA := resilience.NewCircuitBreaker(breakerConfig(a))
+		cbB := resilience.NewCircuitBreaker(breakerConfig(b))
+
+		cbA.Trip()
+		require.Eventually(t, func() bool {
+			return cbB.State() == resilience.StateOpen
+		}, 5*time.Second, 10*time.Millisecond)
+
+		cbA.Reset()
+		assert.Eventually(t, func() bool {
+			return cbB.State() == resilience.StateClosed
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("aggregates failure counts", func(t *testing.T) {
+		a := newTestCoordinator(t, server.Addr(), "c")
+		b := newTestCoordinator(t, server.Addr(), "d")
+		configA, configB := breakerConfig(a), breakerConfig(b)
+		configA.Name, configB.Name = "inventory", "inventory"
+		cbA := resilience.NewCircuitBreaker(configA)
+		cbB := resilience.NewCircuitBreaker(configB)
+
+		failN(cbA, 2)
+		failN(cbB, 2)
+
+		assert.Eventually(t, func() bool {
+			return cbA.State() == resilience.StateOpen && cbB.State() == resilience.StateOpen
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestRedisUnreachable(t *testing.T) {
+	server := miniredis.RunT(t)
+	addr := server.Addr()
+	server.Close()
+
+	c := newTestCoordinator(t, addr, "a")
+	_, err := c.AddCounts(context.Background(), "payments", time.Now(), time.Minute, resilience.SharedCounts{Requests: 1})
+	assert.Error(t, err)
+
+	cb := resilience.NewCircuitBreaker(breakerConfig(c))
+	failN(cb, 3)
+	assert.Equal(t, resilience.StateClosed, cb.State())
+	failN(cb, 1)
+	assert.Equal(t, resilience.StateOpen, cb.State())
+}
+
+func TestRedisInvalidDB(t *testing.T) {
+	_, err := New(resilience.CoordinationConfig{
+		Backend: Backend,
+		Options: map[string]string{"db": "primary"},
+	})
+	assert.Error(t, err)
+}
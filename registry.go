@@ -0,0 +1,305 @@
+package resilience
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ExecutorFactory builds the executor for a name
+type ExecutorFactory func(name string) Executor
+
+// DefaultExecutorName is the executor Registry.FromContext uses for
+// contexts without a name set by WithExecutorName
+const DefaultExecutorName = "default"
+
+type executorNameKey struct{}
+
+// WithExecutorName returns a context whose calls through
+// Registry.FromContext use the executor for name, so middleware can choose
+// a policy per route or tenant tier without call sites knowing about it
+func WithExecutorName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, executorNameKey{}, name)
+}
+
+// ExecutorNameFromContext returns the name set by WithExecutorName, or
+// DefaultExecutorName
+func ExecutorNameFromContext(ctx context.Context) string {
+	if name, _ := ctx.Value(executorNameKey{}).(string); name != "" {
+		return name
+	}
+	return DefaultExecutorName
+}
+
+// registry implements the Registry interface
+type registry struct {
+	factory   ExecutorFactory
+	executors sync.Map // name -> Executor
+	health    sync.Map // name -> *healthTracker
+	scorer    HealthScorer
+	now       func() time.Time
+	mu        sync.Mutex
+}
+
+// NewRegistry creates a registry that builds missing executors with factory.
+// A nil factory uses DefaultExecutorFactory.
+func NewRegistry(factory ExecutorFactory) Registry {
+	return NewRegistryWithClock(factory, nil)
+}
+
+// NewRegistryWithClock is NewRegistry timing health scores on clock; nil
+// uses the system clock. Executors built by factory keep their own clocks.
+func NewRegistryWithClock(factory ExecutorFactory, clock Clock) Registry {
+	if factory == nil {
+		factory = DefaultExecutorFactory
+	}
+	return &registry{factory: factory, now: clockOrSystem(clock).Now}
+}
+
+// DefaultExecutorFactory builds an executor with a default circuit breaker
+// and retry, both named after the executor
+func DefaultExecutorFactory(name string) Executor {
+	cbConfig := DefaultCircuitBreakerConfig()
+	cbConfig.Name = name
+	retryConfig := DefaultRetryConfig()
+	retryConfig.Name = name
+
+	return NewBuilder().
+		WithName(name).
+		WithCircuitBreaker(cbConfig).
+		WithRetry(retryConfig).
+		Build()
+}
+
+func (r *registry) Executor(name string) Executor {
+	if e, ok := r.executors.Load(name); ok {
+		return e.(Executor)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if e, ok := r.executors.Load(name); ok {
+		return e.(Executor)
+	}
+	e := r.factory(name)
+	r.executors.Store(name, e)
+	return e
+}
+
+func (r *registry) FromContext(ctx context.Context) Executor {
+	return r.Executor(ExecutorNameFromContext(ctx))
+}
+
+func (r *registry) Register(name string, executor Executor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.executors.Store(name, executor)
+}
+
+func (r *registry) Names() []string {
+	var names []string
+	r.executors.Range(func(key, _ any) bool {
+		names = append(names, key.(string))
+		return true
+	})
+	sort.Strings(names)
+	return names
+}
+
+func (r *registry) ResetAll() {
+	for _, name := range r.Names() {
+		if keyed := executorKeyedBreakers(r.load(name)); keyed != nil {
+			keyed.Reset()
+			continue
+		}
+		for _, cb := range r.breakers(name) {
+			cb.Reset()
+		}
+	}
+}
+
+func (r *registry) ForceOpenMatching(pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("resilience: invalid executor pattern %q: %w", pattern, err)
+	}
+
+	var matched []string
+	for _, name := range r.Names() {
+		if ok, _ := path.Match(pattern, name); !ok {
+			continue
+		}
+		matched = append(matched, name)
+		// Keys created later start forced open as well
+		if keyed := executorKeyedBreakers(r.load(name)); keyed != nil {
+			keyed.ForceOpen()
+			continue
+		}
+		for _, cb := range r.breakers(name) {
+			cb.ForceOpen()
+		}
+	}
+	return matched, nil
+}
+
+func (r *registry) SetRateMultiplier(multiplier float64) error {
+	if multiplier <= 0 || math.IsInf(multiplier, 0) || math.IsNaN(multiplier) {
+		return fmt.Errorf("resilience: invalid rate multiplier %v", multiplier)
+	}
+	for _, name := range r.Names() {
+		if rl := ExecutorRateLimiter(r.load(name)); rl != nil {
+			if err := rl.SetRateMultiplier(multiplier); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// load returns the registered executor for name, or nil
+func (r *registry) load(name string) Executor {
+	e, _ := r.executors.Load(name)
+	executor, _ := e.(Executor)
+	return executor
+}
+
+// breakers returns the circuit breakers of the executor for name
+func (r *registry) breakers(name string) []CircuitBreaker {
+	return executorBreakers(r.load(name))
+}
+
+// executorBreakers returns the circuit breakers of x. Only
+// executors built by a Builder expose their breakers, also through
+// decorators that unwrap to them; keyed breakers contribute one breaker per
+// tracked key.
+func executorBreakers(x Executor) []CircuitBreaker {
+	e := builtExecutor(x)
+	if e == nil {
+		return nil
+	}
+	if e.keyedBreakers != nil {
+		var breakers []CircuitBreaker
+		e.keyedBreakers.Range(func(key string, cb CircuitBreaker) bool {
+			breakers = append(breakers, cb)
+			return true
+		})
+		return breakers
+	}
+	if e.circuitBreaker != nil {
+		return []CircuitBreaker{e.circuitBreaker}
+	}
+	return nil
+}
+
+// executorKeyedBreakers returns the keyed circuit breakers of x, or nil
+func executorKeyedBreakers(x Executor) KeyedCircuitBreaker {
+	if e := builtExecutor(x); e != nil {
+		return e.keyedBreakers
+	}
+	return nil
+}
+
+// ExecutorRateLimiter returns the rate limiter x applies, looking through
+// decorators that implement Unwrap() Executor, or nil if x was not built by
+// a Builder or has no rate limiter
+func ExecutorRateLimiter(x Executor) RateLimiter {
+	if e := builtExecutor(x); e != nil && e.hasRateLimiter {
+		return e.rateLimiter
+	}
+	return nil
+}
+
+// UnwrapExecutor returns the executor x decorates, or nil. Decorators that
+// run calls through another executor, such as chaos schedules, implement
+// Unwrap() Executor so registries and Warm reach the patterns beneath.
+func UnwrapExecutor(x Executor) Executor {
+	if u, ok := x.(interface{ Unwrap() Executor }); ok {
+		return u.Unwrap()
+	}
+	return nil
+}
+
+// builtExecutor returns the Builder executor x is or decorates, or nil
+func builtExecutor(x Executor) *executor {
+	for x != nil {
+		if e, ok := x.(*executor); ok {
+			return e
+		}
+		x = UnwrapExecutor(x)
+	}
+	return nil
+}
+
+// circuitBreakerRegistry implements the CircuitBreakerRegistry interface
+type circuitBreakerRegistry struct {
+	defaults CircuitBreakerConfig
+	breakers sync.Map // name -> CircuitBreaker
+	mu       sync.Mutex
+}
+
+// NewCircuitBreakerRegistry creates a registry that builds missing breakers
+// from defaults with the requested name
+func NewCircuitBreakerRegistry(defaults CircuitBreakerConfig) CircuitBreakerRegistry {
+	return &circuitBreakerRegistry{defaults: defaults}
+}
+
+func (r *circuitBreakerRegistry) CircuitBreaker(name string) CircuitBreaker {
+	if cb, ok := r.breakers.Load(name); ok {
+		return cb.(CircuitBreaker)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cb, ok := r.breakers.Load(name); ok {
+		return cb.(CircuitBreaker)
+	}
+	config := r.defaults
+	config.Name = name
+	cb := NewCircuitBreaker(config)
+	r.breakers.Store(name, cb)
+	return cb
+}
+
+func (r *circuitBreakerRegistry) Register(name string, breaker CircuitBreaker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.breakers.Store(name, breaker)
+}
+
+func (r *circuitBreakerRegistry) Names() []string {
+	var names []string
+	r.breakers.Range(func(key, _ any) bool {
+		names = append(names, key.(string))
+		return true
+	})
+	sort.Strings(names)
+	return names
+}
+
+var (
+	defaultRegistryOnce sync.Once
+	defaultRegistry     Registry
+)
+
+// DefaultRegistry returns the process-wide registry used by Protect
+func DefaultRegistry() Registry {
+	defaultRegistryOnce.Do(func() {
+		defaultRegistry = NewRegistry(nil)
+	})
+	return defaultRegistry
+}
+
+// Protect wraps fn with the named executor from DefaultRegistry, creating a
+// circuit breaker and retry for the name on first use. It is a quick way to
+// adopt resilience patterns without wiring a Builder; calls sharing a name
+// share one breaker.
+func Protect(name string, fn func(context.Context) error) func(context.Context) error {
+	return func(ctx context.Context) error {
+		return DefaultRegistry().Executor(name).Execute(ctx, fn)
+	}
+}
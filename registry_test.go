@@ -0,0 +1,180 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Run("returns the same executor for a name", func(t *testing.T) {
+		r := NewRegistry(nil)
+
+		a := r.Executor("payments")
+		assert.Same(t, a, r.Executor("payments"))
+		assert.NotSame(t, a, r.Executor("ledger"))
+		assert.Equal(t, "payments", a.Name())
+		assert.Equal(t, []string{"ledger", "payments"}, r.Names())
+	})
+
+	t.Run("creates each executor once under concurrency", func(t *testing.T) {
+		created := 0
+		var mu sync.Mutex
+		r := NewRegistry(func(name string) Executor {
+			mu.Lock()
+			created++
+			mu.Unlock()
+			return NewBuilder().WithName(name).Build()
+		})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				r.Executor("shared")
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, 1, created)
+	})
+
+	t.Run("selects the executor named in the context", func(t *testing.T) {
+		r := NewRegistry(nil)
+		ctx := context.Background()
+
+		assert.Same(t, r.Executor(DefaultExecutorName), r.FromContext(ctx))
+		assert.Same(t, r.Executor("gold"), r.FromContext(WithExecutorName(ctx, "gold")))
+		assert.Equal(t, "gold", ExecutorNameFromContext(WithExecutorName(ctx, "gold")))
+		assert.Equal(t, DefaultExecutorName, ExecutorNameFromContext(WithExecutorName(ctx, "")))
+	})
+
+	t.Run("register replaces executor", func(t *testing.T) {
+		r := NewRegistry(nil)
+		custom := NewBuilder().WithName("custom").Build()
+		r.Register("payments", custom)
+		assert.Same(t, custom, r.Executor("payments"))
+	})
+}
+
+func TestProtect(t *testing.T) {
+	attempts := 0
+	fn := Protect("protect-test", func(ctx context.Context) error {
+		attempts++
+		if attempts < 2 {
+			return errors.New("temporary")
+		}
+		return nil
+	})
+
+	assert.NoError(t, fn(context.Background()))
+	assert.Equal(t, 2, attempts)
+	assert.Contains(t, DefaultRegistry().Names(), "protect-test")
+}
+
+func TestCircuitBreakerRegistry(t *testing.T) {
+	t.Run("returns the same breaker for a name", func(t *testing.T) {
+		defaults := DefaultCircuitBreakerConfig()
+		defaults.MinRequests = 3
+		r := NewCircuitBreakerRegistry(defaults)
+
+		a := r.CircuitBreaker("payments")
+		assert.Same(t, a, r.CircuitBreaker("payments"))
+		assert.NotSame(t, a, r.CircuitBreaker("ledger"))
+		assert.Equal(t, "payments", a.Name())
+		assert.Equal(t, uint32(3), a.(*circuitBreaker).config.MinRequests)
+		assert.Equal(t, []string{"ledger", "payments"}, r.Names())
+	})
+
+	t.Run("executors sharing a breaker trip together", func(t *testing.T) {
+		r := NewCircuitBreakerRegistry(CircuitBreakerConfig{
+			TripStrategy:        TripStrategyConsecutive,
+			ConsecutiveFailures: 2,
+		})
+		checkout := NewBuilder().WithSharedCircuitBreaker(r.CircuitBreaker("payments")).Build()
+		refunds := NewBuilder().WithSharedCircuitBreaker(r.CircuitBreaker("payments")).Build()
+		ctx := context.Background()
+
+		_ = checkout.Execute(ctx, func(ctx context.Context) error { return errors.New("down") })
+		_ = checkout.Execute(ctx, func(ctx context.Context) error { return errors.New("down") })
+
+		err := refunds.Execute(ctx, func(ctx context.Context) error { return nil })
+		assert.ErrorIs(t, err, ErrCircuitOpen)
+	})
+}
+
+func TestRegistryBulkOperations(t *testing.T) {
+	newRegistry := func() Registry {
+		r := NewRegistry(func(name string) Executor {
+			cbConfig := DefaultCircuitBreakerConfig()
+			cbConfig.Name = name
+			return NewBuilder().
+				WithName(name).
+				WithCircuitBreaker(cbConfig).
+				WithRateLimiter(RateLimiterConfig{Name: name, Rate: 100, Burst: 1}).
+				Build()
+		})
+		for _, name := range []string{"payments-api", "payments-ledger", "search"} {
+			r.Executor(name)
+		}
+		return r
+	}
+	ctx := context.Background()
+	ok := func(ctx context.Context) error { return nil }
+
+	t.Run("forces open matching executors and resets all", func(t *testing.T) {
+		r := newRegistry()
+
+		matched, err := r.ForceOpenMatching("payments-*")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"payments-api", "payments-ledger"}, matched)
+		assert.ErrorIs(t, r.Executor("payments-api").Execute(ctx, ok), ErrCircuitOpen)
+		assert.ErrorIs(t, r.Executor("payments-ledger").Execute(ctx, ok), ErrCircuitOpen)
+		assert.NoError(t, r.Executor("search").Execute(ctx, ok))
+
+		r.ResetAll()
+		assert.NoError(t, r.Executor("payments-api").Execute(ctx, ok))
+	})
+
+	t.Run("forces open keys created later", func(t *testing.T) {
+		r := NewRegistry(func(name string) Executor {
+			cbConfig := DefaultCircuitBreakerConfig()
+			cbConfig.Name = name
+			return NewBuilder().
+				WithName(name).
+				WithKeyedCircuitBreaker(cbConfig, 10, nil).
+				Build()
+		})
+		exec := r.Executor("payments-api")
+		require.NoError(t, exec.Execute(WithBreakerKey(ctx, "a"), ok))
+
+		_, err := r.ForceOpenMatching("payments-*")
+		require.NoError(t, err)
+		assert.ErrorIs(t, exec.Execute(WithBreakerKey(ctx, "a"), ok), ErrCircuitOpen)
+		assert.ErrorIs(t, exec.Execute(WithBreakerKey(ctx, "b"), ok), ErrCircuitOpen)
+
+		r.ResetAll()
+		assert.NoError(t, exec.Execute(WithBreakerKey(ctx, "c"), ok))
+	})
+
+	t.Run("rejects invalid patterns", func(t *testing.T) {
+		_, err := newRegistry().ForceOpenMatching("[")
+		assert.Error(t, err)
+	})
+
+	t.Run("scales every rate limiter", func(t *testing.T) {
+		r := newRegistry()
+		require.NoError(t, r.SetRateMultiplier(0.01))
+		assert.Error(t, r.SetRateMultiplier(0))
+
+		limiter := r.Executor("search").(*executor).rateLimiter
+		require.True(t, limiter.Allow())
+		time.Sleep(30 * time.Millisecond)
+		assert.False(t, limiter.Allow())
+	})
+}
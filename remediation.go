@@ -0,0 +1,204 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// remediator implements the Remediator interface
+type remediator struct {
+	config RemediationConfig
+	now    func() time.Time
+
+	mu    sync.Mutex
+	rules []*remediationRule
+
+	ctx    context.Context // canceled by Close
+	cancel context.CancelFunc
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// remediationRule tracks a registered remediation; guarded by remediator.mu
+type remediationRule struct {
+	Remediation
+	since   time.Time // zero while the condition doesn't hold
+	runs    int       // runs since the condition started holding
+	lastRun time.Time
+	running bool
+}
+
+// NewRemediator creates a remediator that evaluates registered conditions
+// every CheckInterval until Close
+func NewRemediator(config RemediationConfig) Remediator {
+	defaults := DefaultRemediationConfig()
+	if config.CheckInterval <= 0 {
+		config.CheckInterval = defaults.CheckInterval
+	}
+	if config.Cooldown <= 0 {
+		config.Cooldown = defaults.Cooldown
+	}
+	if config.ActionTimeout <= 0 {
+		config.ActionTimeout = defaults.ActionTimeout
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &remediator{
+		config: config,
+		now:    time.Now,
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go r.loop()
+	return r
+}
+
+func (r *remediator) Register(remediation Remediation) error {
+	if remediation.Name == "" {
+		return errors.New("resilience: remediation requires a name")
+	}
+	if remediation.Condition == nil || remediation.Action == nil {
+		return fmt.Errorf("resilience: remediation %q requires a condition and an action", remediation.Name)
+	}
+	if remediation.Cooldown <= 0 {
+		remediation.Cooldown = r.config.Cooldown
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rule := range r.rules {
+		if rule.Name == remediation.Name {
+			return fmt.Errorf("resilience: remediation %q already registered", remediation.Name)
+		}
+	}
+	r.rules = append(r.rules, &remediationRule{Remediation: remediation})
+	return nil
+}
+
+func (r *remediator) Close(ctx context.Context) error {
+	r.cancel()
+	<-r.done
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// loop evaluates conditions until Close
+func (r *remediator) loop() {
+	defer close(r.done)
+	ticker := time.NewTicker(r.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+			r.check()
+		}
+	}
+}
+
+// check evaluates every condition once and starts the actions that are due
+func (r *remediator) check() {
+	r.mu.Lock()
+	rules := append([]*remediationRule(nil), r.rules...)
+	r.mu.Unlock()
+
+	for _, rule := range rules {
+		// Conditions may take locks of their own, so evaluate them unlocked
+		holds := rule.Condition()
+		now := r.now()
+
+		r.mu.Lock()
+		event, due := rule.observe(now, holds)
+		if due {
+			rule.running = true
+			r.wg.Add(1)
+		}
+		r.mu.Unlock()
+
+		if due {
+			go r.run(rule, event)
+		}
+	}
+}
+
+// observe updates the rule with the condition's value at now and reports
+// whether the action is due
+func (rule *remediationRule) observe(now time.Time, holds bool) (RemediationEvent, bool) {
+	if !holds {
+		rule.since = time.Time{}
+		rule.runs = 0
+		return RemediationEvent{}, false
+	}
+	if rule.since.IsZero() {
+		rule.since = now
+	}
+	if rule.running || now.Sub(rule.since) < rule.For {
+		return RemediationEvent{}, false
+	}
+	if !rule.lastRun.IsZero() && now.Sub(rule.lastRun) < rule.Cooldown {
+		return RemediationEvent{}, false
+	}
+
+	rule.lastRun = now
+	rule.runs++
+	return RemediationEvent{
+		Remediation: rule.Name,
+		Since:       rule.since,
+		Time:        now,
+		Run:         rule.runs,
+	}, true
+}
+
+// run performs the rule's action and reports the result
+func (r *remediator) run(rule *remediationRule, event RemediationEvent) {
+	defer r.wg.Done()
+
+	ctx, cancel := context.WithTimeout(r.ctx, r.config.ActionTimeout)
+	err := rule.Action(ctx, event)
+	cancel()
+
+	r.mu.Lock()
+	rule.running = false
+	r.mu.Unlock()
+
+	if r.config.OnRemediation != nil {
+		r.config.OnRemediation(event, err)
+	}
+}
+
+// BreakerOpen holds while the circuit breaker is open
+func BreakerOpen(cb CircuitBreaker) RemediationCondition {
+	return func() bool {
+		return cb.State() == StateOpen
+	}
+}
+
+// RateLimiterSaturated holds while the rate limiter has no tokens available
+func RateLimiterSaturated(rl RateLimiter) RemediationCondition {
+	return func() bool {
+		return rl.Stats().Tokens < 1
+	}
+}
+
+// BulkheadSaturated holds while every bulkhead slot is in use
+func BulkheadSaturated(b Bulkhead) RemediationCondition {
+	return func() bool {
+		return b.Available() == 0
+	}
+}
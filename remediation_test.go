@@ -0,0 +1,189 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRemediator returns a remediator whose checks are driven by the test
+func newTestRemediator(t *testing.T, config RemediationConfig) (*remediator, *time.Time) {
+	t.Helper()
+	config.CheckInterval = time.Hour
+	r := NewRemediator(config).(*remediator)
+	clock := time.Unix(1000, 0)
+	r.now = func() time.Time { return clock }
+	t.Cleanup(func() { r.Close(context.Background()) })
+	return r, &clock
+}
+
+func TestRemediator(t *testing.T) {
+	t.Run("runs after the condition holds for the duration", func(t *testing.T) {
+		events := make(chan RemediationEvent, 10)
+		r, clock := newTestRemediator(t, RemediationConfig{})
+		cb := NewCircuitBreaker(CircuitBreakerConfig{Name: "payments", Timeout: time.Hour})
+		require.NoError(t, r.Register(Remediation{
+			Name:      "restart-pool",
+			Condition: BreakerOpen(cb),
+			For:       time.Minute,
+			Action: func(ctx context.Context, event RemediationEvent) error {
+				events <- event
+				return nil
+			},
+		}))
+
+		r.check()
+		cb.Trip()
+		start := *clock
+		r.check()
+		*clock = clock.Add(30 * time.Second)
+		r.check()
+		assert.Empty(t, events)
+
+		*clock = clock.Add(30 * time.Second)
+		r.check()
+		event := <-events
+		assert.Equal(t, "restart-pool", event.Remediation)
+		assert.Equal(t, start, event.Since)
+		assert.Equal(t, *clock, event.Time)
+		assert.Equal(t, 1, event.Run)
+	})
+
+	t.Run("rate limits the action with the cooldown", func(t *testing.T) {
+		var runs atomic.Int32
+		var holds atomic.Bool
+		holds.Store(true)
+		r, clock := newTestRemediator(t, RemediationConfig{Cooldown: 10 * time.Minute})
+		require.NoError(t, r.Register(Remediation{
+			Name:      "flush-dns",
+			Condition: holds.Load,
+			Action: func(ctx context.Context, event RemediationEvent) error {
+				runs.Add(1)
+				return nil
+			},
+		}))
+
+		check := func() {
+			r.check()
+			r.wg.Wait()
+		}
+		check()
+		*clock = clock.Add(5 * time.Minute)
+		check()
+		assert.Equal(t, int32(1), runs.Load())
+
+		// A flapping condition doesn't bypass the cooldown
+		holds.Store(false)
+		check()
+		holds.Store(true)
+		check()
+		assert.Equal(t, int32(1), runs.Load())
+
+		*clock = clock.Add(5 * time.Minute)
+		check()
+		assert.Equal(t, int32(2), runs.Load())
+	})
+
+	t.Run("counts runs per episode and reports results", func(t *testing.T) {
+		results := make(chan error, 10)
+		runs := make(chan int, 10)
+		r, clock := newTestRemediator(t, RemediationConfig{
+			OnRemediation: func(event RemediationEvent, err error) {
+				runs <- event.Run
+				results <- err
+			},
+		})
+		actionErr := errors.New("restart failed")
+		require.NoError(t, r.Register(Remediation{
+			Name:      "restart-pool",
+			Condition: func() bool { return true },
+			Cooldown:  time.Minute,
+			Action: func(ctx context.Context, event RemediationEvent) error {
+				return actionErr
+			},
+		}))
+
+		r.check()
+		assert.Equal(t, 1, <-runs)
+		assert.ErrorIs(t, <-results, actionErr)
+		*clock = clock.Add(time.Minute)
+		r.check()
+		assert.Equal(t, 2, <-runs)
+	})
+
+	t.Run("does not overlap runs", func(t *testing.T) {
+		release := make(chan struct{})
+		var runs atomic.Int32
+		r, clock := newTestRemediator(t, RemediationConfig{Cooldown: time.Second})
+		require.NoError(t, r.Register(Remediation{
+			Name:      "page",
+			Condition: func() bool { return true },
+			Action: func(ctx context.Context, event RemediationEvent) error {
+				runs.Add(1)
+				<-release
+				return nil
+			},
+		}))
+
+		r.check()
+		*clock = clock.Add(time.Minute)
+		r.check()
+		close(release)
+		r.wg.Wait()
+		assert.Equal(t, int32(1), runs.Load())
+	})
+
+	t.Run("rejects invalid registrations", func(t *testing.T) {
+		r, _ := newTestRemediator(t, RemediationConfig{})
+		noop := func(ctx context.Context, event RemediationEvent) error { return nil }
+		always := func() bool { return true }
+
+		assert.Error(t, r.Register(Remediation{Condition: always, Action: noop}))
+		assert.Error(t, r.Register(Remediation{Name: "a", Action: noop}))
+		require.NoError(t, r.Register(Remediation{Name: "a", Condition: always, Action: noop}))
+		assert.Error(t, r.Register(Remediation{Name: "a", Condition: always, Action: noop}))
+	})
+
+	t.Run("close cancels running actions", func(t *testing.T) {
+		started := make(chan struct{})
+		r := NewRemediator(RemediationConfig{CheckInterval: time.Millisecond})
+		require.NoError(t, r.Register(Remediation{
+			Name:      "page",
+			Condition: func() bool { return true },
+			Action: func(ctx context.Context, event RemediationEvent) error {
+				close(started)
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		}))
+
+		<-started
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		assert.NoError(t, r.Close(ctx))
+	})
+}
+
+func TestRemediationConditions(t *testing.T) {
+	rl := NewRateLimiter(RateLimiterConfig{Name: "test", Rate: 0.001, Burst: 1})
+	saturated := RateLimiterSaturated(rl)
+	assert.False(t, saturated())
+	require.True(t, rl.Allow())
+	assert.True(t, saturated())
+
+	b := NewBulkhead(BulkheadConfig{Name: "test", MaxConcurrent: 1})
+	full := BulkheadSaturated(b)
+	assert.False(t, full())
+	release := make(chan struct{})
+	go b.Execute(context.Background(), func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	assert.Eventually(t, full, time.Second, time.Millisecond)
+	close(release)
+}
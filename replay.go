@@ -0,0 +1,178 @@
+package resilience
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Outcome is the result of a call as seen by the circuit breaker
+type Outcome string
+
+const (
+	// OutcomeSuccess means the call succeeded
+	OutcomeSuccess Outcome = "success"
+
+	// OutcomeFailure means the call failed
+	OutcomeFailure Outcome = "failure"
+
+	// OutcomeRejected means the call was rejected without running
+	OutcomeRejected Outcome = "rejected"
+
+	// OutcomeTimeout means the call failed by timing out
+	OutcomeTimeout Outcome = "timeout"
+)
+
+// Event is a recorded executor call
+type Event struct {
+	// Time is when the call started
+	Time time.Time `json:"time"`
+
+	// Executor is the executor name
+	Executor string `json:"executor"`
+
+	// Outcome is the call result
+	Outcome Outcome `json:"outcome"`
+
+	// Duration is how long the call took
+	Duration time.Duration `json:"duration"`
+
+	// Error is the error message for failed calls
+	Error string `json:"error,omitempty"`
+}
+
+// Transition is a circuit breaker state change
+type Transition struct {
+	// Time is when the transition happened
+	Time time.Time `json:"time"`
+
+	// From is the previous state
+	From CircuitState `json:"from"`
+
+	// To is the new state
+	To CircuitState `json:"to"`
+
+	// Counts are the breaker counts when the transition happened; set in
+	// CircuitBreaker.History, zero in Replay results
+	Counts Counts `json:"counts"`
+}
+
+// EventRecorder writes executor events as JSON lines
+type EventRecorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// NewEventRecorder creates a recorder that writes events to w
+func NewEventRecorder(w io.Writer) *EventRecorder {
+	return &EventRecorder{enc: json.NewEncoder(w)}
+}
+
+// Record writes an event; write errors are available from Err
+func (r *EventRecorder) Record(event Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return
+	}
+	r.err = r.enc.Encode(event)
+}
+
+// Err returns the first write error, if any
+func (r *EventRecorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// ReadEvents reads events written by an EventRecorder
+func ReadEvents(r io.Reader) ([]Event, error) {
+	var events []Event
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("resilience: read events: line %d: %w", line, err)
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("resilience: read events: %w", err)
+	}
+	return events, nil
+}
+
+// Replay runs recorded events through a new circuit breaker on virtual time
+// and returns the state transitions it makes. Calls are replayed one at a
+// time in start order, so the result is deterministic for a given input.
+// Rejected events carry no outcome and are skipped. The breaker runs on a
+// FakeClock starting at the first event, replacing config.Clock.
+func Replay(events []Event, config CircuitBreakerConfig) ([]Transition, error) {
+	if len(events) == 0 {
+		return nil, errors.New("resilience: replay: no events")
+	}
+
+	sorted := make([]Event, 0, len(events))
+	for _, e := range events {
+		if e.Outcome != OutcomeRejected {
+			sorted = append(sorted, e)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time.Before(sorted[j].Time)
+	})
+
+	// The breaker runs on a fake clock from the first event, so warmup,
+	// windows and reports follow recorded time rather than the replay's
+	start := events[0].Time
+	if len(sorted) > 0 {
+		start = sorted[0].Time
+	}
+	clock := NewFakeClock(start)
+
+	var transitions []Transition
+	onStateChange := config.OnStateChange
+	config.OnStateChange = func(name string, from, to CircuitState) {
+		transitions = append(transitions, Transition{Time: clock.Now(), From: from, To: to})
+		if onStateChange != nil {
+			onStateChange(name, from, to)
+		}
+	}
+	config.SyncStateChange = true
+	config.Coordinator = nil
+	config.Clock = clock
+
+	cb := NewCircuitBreaker(config).(*circuitBreaker)
+	for _, e := range sorted {
+		advanceTo(clock, e.Time)
+		// Recorded calls ran, so they pass a degraded breaker
+		generation, err := cb.beforeRequest(true, 1)
+		if err != nil {
+			continue
+		}
+		advanceTo(clock, e.Time.Add(e.Duration))
+		cb.afterRequest(generation, e.Outcome, 1, 1)
+	}
+
+	return transitions, nil
+}
+
+// advanceTo moves clock forward to t; calls overlapping an earlier one's
+// end are recorded at that end, as the clock cannot go back
+func advanceTo(clock *FakeClock, t time.Time) {
+	if d := t.Sub(clock.Now()); d > 0 {
+		clock.Advance(d)
+	}
+}
@@ -0,0 +1,135 @@
+package resilience
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventRecorder(t *testing.T) {
+	var buf bytes.Buffer
+	config := CircuitBreakerConfig{
+		Name:             "test",
+		Interval:         time.Minute,
+		Timeout:          time.Minute,
+		FailureThreshold: 0.5,
+		MinRequests:      2,
+	}
+	recorder := NewEventRecorder(&buf)
+	executor := NewBuilder().
+		WithName("recorded").
+		WithCircuitBreaker(config).
+		WithEventRecorder(recorder).
+		Build()
+	ctx := context.Background()
+
+	executor.Execute(ctx, func(ctx context.Context) error { return nil })
+	executor.Execute(ctx, func(ctx context.Context) error { return errors.New("boom") })
+	executor.Execute(ctx, func(ctx context.Context) error { return errors.New("boom") })
+	executor.Execute(ctx, func(ctx context.Context) error { return nil })
+	require.NoError(t, recorder.Err())
+
+	events, err := ReadEvents(&buf)
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+	assert.Equal(t, "recorded", events[0].Executor)
+	assert.Equal(t, OutcomeSuccess, events[0].Outcome)
+	assert.Equal(t, OutcomeFailure, events[1].Outcome)
+	assert.Equal(t, "boom", events[1].Error)
+	assert.Equal(t, OutcomeRejected, events[3].Outcome)
+
+	transitions, err := Replay(events, config)
+	require.NoError(t, err)
+	require.Len(t, transitions, 1)
+	assert.Equal(t, StateClosed, transitions[0].From)
+	assert.Equal(t, StateOpen, transitions[0].To)
+}
+
+func TestReplay(t *testing.T) {
+	t.Run("reproduces transitions on virtual time", func(t *testing.T) {
+		base := time.Date(2025, 1, 1, 3, 0, 0, 0, time.UTC)
+		events := []Event{
+			{Time: base, Outcome: OutcomeFailure},
+			{Time: base.Add(time.Second), Outcome: OutcomeFailure},
+			{Time: base.Add(2 * time.Second), Outcome: OutcomeSuccess},
+			{Time: base.Add(time.Minute), Outcome: OutcomeSuccess},
+		}
+		config := CircuitBreakerConfig{
+			Name:             "test",
+			MaxRequests:      1,
+			Interval:         time.Hour,
+			Timeout:          30 * time.Second,
+			FailureThreshold: 0.5,
+			MinRequests:      2,
+		}
+
+		transitions, err := Replay(events, config)
+		require.NoError(t, err)
+		assert.Equal(t, []Transition{
+			{Time: base.Add(time.Second), From: StateClosed, To: StateOpen},
+			{Time: base.Add(time.Minute), From: StateOpen, To: StateHalfOpen},
+			{Time: base.Add(time.Minute), From: StateHalfOpen, To: StateClosed},
+		}, transitions)
+
+		again, err := Replay(events, config)
+		require.NoError(t, err)
+		assert.Equal(t, transitions, again)
+	})
+
+	t.Run("ends warmup on recorded time", func(t *testing.T) {
+		base := time.Date(2025, 1, 1, 3, 0, 0, 0, time.UTC)
+		var events []Event
+		for i := 0; i < 12; i++ {
+			events = append(events, Event{Time: base.Add(time.Duration(i) * 10 * time.Second), Outcome: OutcomeFailure})
+		}
+		config := CircuitBreakerConfig{
+			Name:             "test",
+			Interval:         time.Hour,
+			Timeout:          time.Hour,
+			FailureThreshold: 0.5,
+			MinRequests:      2,
+			WarmupPeriod:     time.Minute,
+		}
+
+		transitions, err := Replay(events, config)
+		require.NoError(t, err)
+		require.Len(t, transitions, 1)
+		assert.Equal(t, Transition{Time: base.Add(time.Minute), From: StateClosed, To: StateOpen}, transitions[0])
+	})
+
+	t.Run("expires sliding window counts on recorded time", func(t *testing.T) {
+		base := time.Date(2025, 1, 1, 3, 0, 0, 0, time.UTC)
+		var events []Event
+		for i := 0; i < 12; i++ {
+			events = append(events, Event{Time: base.Add(time.Duration(i) * 2 * time.Minute), Outcome: OutcomeFailure})
+		}
+		config := CircuitBreakerConfig{
+			Name:             "test",
+			Interval:         time.Minute,
+			Timeout:          time.Second,
+			FailureThreshold: 0.5,
+			MinRequests:      2,
+			WindowType:       WindowTypeSliding,
+		}
+
+		transitions, err := Replay(events, config)
+		require.NoError(t, err)
+		assert.Empty(t, transitions)
+	})
+
+	t.Run("requires events", func(t *testing.T) {
+		_, err := Replay(nil, DefaultCircuitBreakerConfig())
+		assert.Error(t, err)
+	})
+
+	t.Run("reports malformed input", func(t *testing.T) {
+		_, err := ReadEvents(strings.NewReader("{\"outcome\":\"success\"}\nnot json\n"))
+		assert.ErrorContains(t, err, "line 2")
+	})
+}
@@ -3,6 +3,7 @@ package resilience
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 )
 
@@ -10,7 +11,8 @@ var (
 	// ErrCircuitOpen is returned when the circuit breaker is open
 	ErrCircuitOpen = errors.New("resilience: circuit breaker is open")
 
-	// ErrMaxRetriesExceeded is returned when max retries are exceeded
+	// ErrMaxRetriesExceeded is matched by the *RetryExhaustedError returned
+	// when retries are exhausted
 	ErrMaxRetriesExceeded = errors.New("resilience: max retries exceeded")
 
 	// ErrRateLimitExceeded is returned when rate limit is exceeded
@@ -19,17 +21,143 @@ var (
 	// ErrBulkheadFull is returned when bulkhead is at capacity
 	ErrBulkheadFull = errors.New("resilience: bulkhead at capacity")
 
+	// ErrQueueTimeout is returned when a queued call waits longer than
+	// BulkheadConfig.MaxQueueWait for a slot
+	ErrQueueTimeout = errors.New("resilience: bulkhead queue wait exceeded")
+
 	// ErrTimeout is returned when operation times out
 	ErrTimeout = errors.New("resilience: operation timed out")
+
+	// ErrInvalidState is returned when imported state cannot be applied
+	ErrInvalidState = errors.New("resilience: invalid exported state")
+
+	// ErrNoCircuitBreaker is returned when an executor has no circuit breaker
+	ErrNoCircuitBreaker = errors.New("resilience: executor has no circuit breaker")
+
+	// ErrRetryQueueFull is returned by AsyncExecutor.Go when the queue is at capacity
+	ErrRetryQueueFull = errors.New("resilience: retry queue at capacity")
+
+	// ErrExecutorClosed is returned by AsyncExecutor.Go after Close
+	ErrExecutorClosed = errors.New("resilience: executor closed")
+
+	// ErrResultRejected is the error of an attempt that succeeded but whose
+	// result ShouldRetryResult asked to retry
+	ErrResultRejected = errors.New("resilience: result rejected for retry")
+
+	// ErrBackoffExceedsDeadline is returned when retrying would mean
+	// waiting past the context's deadline
+	ErrBackoffExceedsDeadline = errors.New("resilience: retry backoff would exceed deadline")
 )
 
+// CircuitOpenError is returned when a circuit breaker rejects a call. It
+// matches ErrCircuitOpen with errors.Is.
+type CircuitOpenError struct {
+	// Breaker is the name of the rejecting breaker
+	Breaker string
+
+	// State is the breaker state at rejection: open, degraded when shedding
+	// best-effort calls, or half-open or closed when shedding a share of
+	// calls
+	State CircuitState
+
+	// OpenedAt is when the breaker last opened; zero if it never has
+	OpenedAt time.Time
+
+	// RetryAfter estimates the time until the breaker tries half-open;
+	// zero when unknown, such as when forced open or shedding
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	if e.State == StateDegraded {
+		return fmt.Sprintf("resilience: circuit breaker %q is degraded", e.Breaker)
+	}
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("resilience: circuit breaker %q is open (retry after %s)", e.Breaker, e.RetryAfter.Round(time.Millisecond))
+	}
+	return fmt.Sprintf("resilience: circuit breaker %q is open", e.Breaker)
+}
+
+// Is reports whether target is ErrCircuitOpen
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// BackoffDeadlineError is returned when a retry gives up because its
+// backoff would end past the context's deadline. It matches
+// ErrBackoffExceedsDeadline and context.DeadlineExceeded with errors.Is and
+// unwraps to the last attempt's error.
+type BackoffDeadlineError struct {
+	// Delay is the backoff that was not waited
+	Delay time.Duration
+
+	// Remaining is the time that was left until the deadline
+	Remaining time.Duration
+
+	// Err is the error of the last attempt
+	Err error
+}
+
+func (e *BackoffDeadlineError) Error() string {
+	return fmt.Sprintf("resilience: retry backoff %s would exceed deadline in %s: %v",
+		e.Delay.Round(time.Millisecond), e.Remaining.Round(time.Millisecond), e.Err)
+}
+
+// Is reports whether target is ErrBackoffExceedsDeadline or
+// context.DeadlineExceeded
+func (e *BackoffDeadlineError) Is(target error) bool {
+	return target == ErrBackoffExceedsDeadline || target == context.DeadlineExceeded
+}
+
+// Unwrap returns the error of the last attempt
+func (e *BackoffDeadlineError) Unwrap() error {
+	return e.Err
+}
+
+// RetryExhaustedError is returned when a retry gives up after its last
+// allowed attempt or when its MaxElapsedTime budget would be exceeded. It
+// matches ErrMaxRetriesExceeded with errors.Is and unwraps to the last
+// attempt's error.
+type RetryExhaustedError struct {
+	// Attempts is the number of attempts made
+	Attempts int
+
+	// Elapsed is the time from the first attempt until giving up
+	Elapsed time.Duration
+
+	// Errors are the errors of every attempt, in order
+	Errors []error
+
+	// Err is the error of the last attempt
+	Err error
+}
+
+func (e *RetryExhaustedError) Error() string {
+	return fmt.Sprintf("resilience: retry gave up after %d attempts in %s: %v",
+		e.Attempts, e.Elapsed.Round(time.Millisecond), e.Err)
+}
+
+// Is reports whether target is ErrMaxRetriesExceeded
+func (e *RetryExhaustedError) Is(target error) bool {
+	return target == ErrMaxRetriesExceeded
+}
+
+// Unwrap returns the error of the last attempt
+func (e *RetryExhaustedError) Unwrap() error {
+	return e.Err
+}
+
 // Executor executes functions with resilience patterns applied
 type Executor interface {
 	// Execute runs the function with configured resilience patterns
-	Execute(ctx context.Context, fn func(context.Context) error) error
+	Execute(ctx context.Context, fn func(context.Context) error, opts ...CallOption) error
 
 	// ExecuteWithResult runs the function and returns a result
-	ExecuteWithResult(ctx context.Context, fn func(context.Context) (any, error)) (any, error)
+	ExecuteWithResult(ctx context.Context, fn func(context.Context) (any, error), opts ...CallOption) (any, error)
+
+	// TryExecute runs the function like Execute with NoWait: where a
+	// pattern would block, the call is rejected immediately instead
+	TryExecute(ctx context.Context, fn func(context.Context) error, opts ...CallOption) error
 
 	// Name returns the executor name
 	Name() string
@@ -40,16 +168,149 @@ type CircuitBreaker interface {
 	// Execute runs the function if the circuit is closed
 	Execute(ctx context.Context, fn func(context.Context) error) error
 
+	// Allow admits a call whose work can't be wrapped in a closure, such as
+	// an async callback or a streaming RPC. It returns a *CircuitOpenError
+	// matching ErrCircuitOpen when
+	// the call is rejected; otherwise the outcome must be recorded on the
+	// returned Permit.
+	Allow() (Permit, error)
+
+	// AllowN is Allow for a batch of n logical operations, checked before
+	// starting expensive work. The batch is admitted only if all n would
+	// be: in half-open state it takes n of the MaxRequests trials, and
+	// while a share of calls passes it is as likely to pass as n calls in
+	// a row. RecordSuccess and RecordFailure on the permit count all n;
+	// RecordBatch records the aggregate outcome. n must be 1 to 65535.
+	AllowN(n int) (Permit, error)
+
 	// State returns the current circuit state
 	State() CircuitState
 
-	// Reset manually resets the circuit to closed state
+	// Reset manually resets the circuit to closed state and clears any
+	// state forced by ForceOpen, ForceClosed or Disable
 	Reset()
 
+	// Trip opens the circuit as if the trip condition was met; it moves to
+	// half-open after the configured timeout as usual
+	Trip()
+
+	// ForceOpen opens the circuit and keeps it open until Reset
+	ForceOpen()
+
+	// ForceClosed closes the circuit and keeps it closed until Reset
+	ForceClosed()
+
+	// Disable moves the circuit to StateDisabled until Reset: calls are
+	// counted but never rejected
+	Disable()
+
+	// Metrics returns a snapshot of the breaker counts and state
+	Metrics() CircuitBreakerMetrics
+
+	// History returns the last CircuitBreakerConfig.HistorySize
+	// transitions, oldest first, with the counts that triggered them
+	History() []Transition
+
+	// Export serializes the breaker state for transfer to another instance
+	Export() []byte
+
+	// Import restores breaker state produced by Export
+	Import(data []byte) error
+
 	// Name returns the circuit breaker name
 	Name() string
 }
 
+// CircuitBreakerMetrics is a point-in-time snapshot of circuit breaker
+// statistics. Counts cover the current generation (or the sliding window)
+// that trip decisions are based on.
+type CircuitBreakerMetrics struct {
+	// State is the current circuit state
+	State CircuitState
+
+	// Requests is the number of requests counted
+	Requests uint32
+
+	// Successes is the number of successful requests
+	Successes uint32
+
+	// Failures is the number of failed requests
+	Failures uint32
+
+	// Timeouts is the number of timed out requests counted apart from
+	// Failures in separate timeout mode
+	Timeouts uint32
+
+	// ConsecutiveSuccesses is the number of successes in a row
+	ConsecutiveSuccesses uint32
+
+	// ConsecutiveFailures is the number of failures in a row
+	ConsecutiveFailures uint32
+
+	// StateSince is when the current state was entered
+	StateSince time.Time
+
+	// TimeInState is how long the breaker has been in the current state
+	TimeInState time.Duration
+
+	// OpenDuration is how long the last opening lasts before half-open,
+	// including MinOpenDuration and flap damping
+	OpenDuration time.Duration
+
+	// Forced reports whether the state is pinned by ForceOpen, ForceClosed
+	// or Disable
+	Forced bool
+
+	// FailureThreshold is the failure ratio that currently trips the
+	// circuit; with the adaptive strategy it follows the learned baseline
+	FailureThreshold float64
+
+	// LatencyP50, LatencyP95 and LatencyP99 are percentiles of the latency
+	// of admitted calls over the last one to two Intervals
+	LatencyP50 time.Duration
+	LatencyP95 time.Duration
+	LatencyP99 time.Duration
+}
+
+// Counts are the circuit breaker statistics a TripPolicy decides on
+type Counts struct {
+	// Requests is the number of requests counted
+	Requests uint32
+
+	// Successes is the number of successful requests
+	Successes uint32
+
+	// Failures is the number of failed requests
+	Failures uint32
+
+	// Timeouts is the number of timed out requests counted apart from
+	// Failures in separate timeout mode
+	Timeouts uint32
+
+	// ConsecutiveSuccesses is the number of successes in a row
+	ConsecutiveSuccesses uint32
+
+	// ConsecutiveFailures is the number of failures in a row
+	ConsecutiveFailures uint32
+}
+
+// TripPolicy decides when a circuit breaker trips, replacing TripStrategy
+// and TripWhen, e.g. to trip on an error budget or by time of day
+type TripPolicy interface {
+	// ShouldTrip reports whether counts gathered over window trip the
+	// circuit. It is called under the breaker's lock after each failure
+	// and must not block.
+	ShouldTrip(counts Counts, window time.Duration) bool
+}
+
+// TripPolicyFunc adapts a function to the TripPolicy interface
+type TripPolicyFunc func(counts Counts, window time.Duration) bool
+
+// ShouldTrip calls f(counts, window)
+func (f TripPolicyFunc) ShouldTrip(counts Counts, window time.Duration) bool {
+	return f(counts, window)
+}
+
 // CircuitState represents the circuit breaker state
 type CircuitState int
 
@@ -62,6 +323,13 @@ const (
 
 	// StateHalfOpen means the circuit is testing if service recovered
 	StateHalfOpen
+
+	// StateDisabled means the circuit counts calls but never rejects them
+	StateDisabled
+
+	// StateDegraded means the failure rate reached DegradedThreshold: only
+	// calls marked WithHighPriority pass, best-effort calls are rejected
+	StateDegraded
 )
 
 // String returns the string representation of the circuit state
@@ -73,6 +341,10 @@ func (s CircuitState) String() string {
 		return "open"
 	case StateHalfOpen:
 		return "half-open"
+	case StateDisabled:
+		return "disabled"
+	case StateDegraded:
+		return "degraded"
 	default:
 		return "unknown"
 	}
@@ -83,6 +355,10 @@ type Retry interface {
 	// Execute runs the function with retry logic
 	Execute(ctx context.Context, fn func(context.Context) error) error
 
+	// ExecuteWithResult runs the function with retry logic and returns the
+	// last attempt's result
+	ExecuteWithResult(ctx context.Context, fn func(context.Context) (any, error)) (any, error)
+
 	// Name returns the retry name
 	Name() string
 }
@@ -95,23 +371,325 @@ type RateLimiter interface {
 	// Wait blocks until the operation is allowed or context is done
 	Wait(ctx context.Context) error
 
+	// TryExecute runs the function if a token is available now, returning
+	// ErrRateLimitExceeded otherwise
+	TryExecute(ctx context.Context, fn func(context.Context) error) error
+
+	// Acquire blocks until n tokens are reserved or context is done
+	Acquire(ctx context.Context, n int) (Reservation, error)
+
+	// Export serializes the limiter state for transfer to another instance
+	Export() []byte
+
+	// Import restores limiter state produced by Export
+	Import(data []byte) error
+
+	// Stats returns raw and smoothed limiter gauges
+	Stats() RateLimiterStats
+
+	// ApplyQuota aligns the limiter with a quota advertised by the server
+	ApplyQuota(quota Quota)
+
+	// SetRateMultiplier scales the configured rate; 1 restores it
+	SetRateMultiplier(multiplier float64) error
+
 	// Name returns the rate limiter name
 	Name() string
 }
 
+// RateLimiterGroup fans one rate limiter configuration out into limiters
+// per key, such as a tenant or client address. One loop advances a shared
+// refill epoch that the limiters compute their tokens from, so with many
+// keys a call costs a compare-and-swap instead of a clock read under lock.
+type RateLimiterGroup interface {
+	// RateLimiter returns the limiter for key, creating it on first use
+	// with a full bucket. Limiters idle with a full bucket for IdleTimeout
+	// are dropped, which loses nothing as they come back full.
+	RateLimiter(key string) RateLimiter
+
+	// Keys returns the tracked keys in sorted order
+	Keys() []string
+
+	// Len returns the number of tracked keys
+	Len() int
+
+	// TopRejected returns up to k keys whose limiters rejected the most
+	// calls within window, most rejected first. window is rounded up to
+	// whole minutes and covers at most the last hour.
+	TopRejected(k int, window time.Duration) []KeyRejections
+
+	// Stats returns the number of keys and the most rejected ones
+	Stats() RateLimiterGroupStats
+
+	// Name returns the base limiter name
+	Name() string
+
+	// Close stops the refill loop; limiters stop refilling
+	Close(ctx context.Context) error
+}
+
+// RateLimiterGroupStats describes a rate limiter group
+type RateLimiterGroupStats struct {
+	// Keys is the number of tracked keys
+	Keys int
+
+	// TopRejected is up to 10 keys whose limiters rejected the most calls
+	// over the last 5 minutes, most rejected first
+	TopRejected []KeyRejections
+}
+
+// QuotaSharingGroup lets executors lend unused rate limiter tokens to each
+// other, e.g. batch traffic to interactive traffic during a spike. A lender
+// only lends tokens above its reserve, so its own traffic reclaims the
+// quota as soon as it needs it.
+type QuotaSharingGroup interface {
+	// RateLimiter returns the member's limiter, borrowing from its lenders
+	// when its own tokens run out; nil for unknown members. Use it with
+	// Builder.WithSharedRateLimiter.
+	RateLimiter(name string) RateLimiter
+
+	// Loans returns the configured loans and the tokens lent under each
+	Loans() []QuotaLoanStats
+
+	// Name returns the group name
+	Name() string
+}
+
+// QuotaLoanStats counts the tokens lent under a QuotaLoan
+type QuotaLoanStats struct {
+	// From is the lending member
+	From string
+
+	// To is the borrowing member
+	To string
+
+	// Ratio is the share of the lender's burst it may lend
+	Ratio float64
+
+	// Lent is the number of tokens lent so far
+	Lent uint64
+}
+
+// Quota is a server-advertised rate limit window, e.g. from RateLimit
+// response headers
+type Quota struct {
+	// Limit is the number of requests allowed per window (0 if unknown)
+	Limit int
+
+	// Remaining is the number of requests left in the current window
+	Remaining int
+
+	// Reset is the time until the window resets
+	Reset time.Duration
+}
+
+// AnomalyKind identifies the heuristic that detected an anomaly
+type AnomalyKind string
+
+const (
+	// AnomalyFlapping means the circuit breaker opened too often
+	AnomalyFlapping AnomalyKind = "flapping"
+
+	// AnomalyRetryAmplification means retries multiplied downstream load
+	AnomalyRetryAmplification AnomalyKind = "retry_amplification"
+
+	// AnomalySaturation means the bulkhead stayed full
+	AnomalySaturation AnomalyKind = "saturation"
+
+	// AnomalyPanic means a wrapped function panicked
+	AnomalyPanic AnomalyKind = "panic"
+)
+
+// Anomaly describes unhealthy executor behavior worth alerting on
+type Anomaly struct {
+	// Executor is the name of the executor
+	Executor string
+
+	// Kind is the heuristic that fired
+	Kind AnomalyKind
+
+	// Value is the observed value: openings, attempts per call or
+	// seconds saturated
+	Value float64
+
+	// Threshold is the configured limit Value exceeded
+	Threshold float64
+
+	// Time is when the anomaly was detected
+	Time time.Time
+
+	// Message is a human readable description
+	Message string
+
+	// Panic is the recovered value for AnomalyPanic
+	Panic any
+
+	// Stack is the panicking goroutine's stack for AnomalyPanic
+	Stack []byte
+}
+
+// MisuseKind identifies a detected misuse of an executor
+type MisuseKind string
+
+const (
+	// MisuseReentrant means the wrapped function called its own executor,
+	// which deadlocks once the nested calls exhaust a bulkhead or rate limit
+	MisuseReentrant MisuseKind = "reentrant"
+
+	// MisuseCanceledContext means the call's context was already done
+	MisuseCanceledContext MisuseKind = "canceled_context"
+
+	// MisuseStaleContext means the call's context came from an executor
+	// call that had already returned, e.g. one captured by a closure
+	MisuseStaleContext MisuseKind = "stale_context"
+)
+
+// Misuse describes an executor call that is likely a bug
+type Misuse struct {
+	// Executor is the name of the executor called
+	Executor string
+
+	// Kind is the misuse detected
+	Kind MisuseKind
+
+	// Message is a human readable description
+	Message string
+
+	// Stack is the calling goroutine's stack
+	Stack []byte
+}
+
+// Advisory suggests tuning a setting that has no effect on observed traffic
+type Advisory struct {
+	// Executor is the name of the executor
+	Executor string
+
+	// Pattern is the pattern the setting belongs to
+	Pattern Pattern
+
+	// Setting is the configuration key, e.g. "timeout.duration"
+	Setting string
+
+	// Message describes the mismatch and the suggested change
+	Message string
+}
+
+// Attempt identifies one attempt of an executor call
+type Attempt struct {
+	// Executor is the name of the executor
+	Executor string
+
+	// Retry is the retry number, 0 for the first attempt
+	Retry int
+
+	// MaxAttempts is the number of attempts the retry allows, 0 outside a
+	// retry
+	MaxAttempts int
+
+	// LastErr is the error of the previous attempt, nil for the first
+	LastErr error
+
+	// Hedge is the hedge number, 0 for the original attempt
+	Hedge int
+}
+
+// Permit is a call admitted by CircuitBreaker.Allow. Only the first
+// recorded outcome counts, and outcomes of calls admitted before the
+// breaker changed state are ignored.
+type Permit interface {
+	// RecordSuccess records that the call succeeded
+	RecordSuccess()
+
+	// RecordFailure records that the call failed
+	RecordFailure()
+
+	// Record classifies err as Execute does, with IsFailure and TimeoutMode
+	Record(err error)
+
+	// RecordBatch records the outcome of a batch: successes successful and
+	// failures failed operations, at most the batch size in all; outcomes
+	// beyond it are dropped, failures last. Operations without an outcome
+	// free their slots. In half-open state the trials fail if any
+	// operation failed.
+	RecordBatch(successes, failures uint32)
+}
+
+// Reservation holds rate limiter tokens acquired ahead of use
+type Reservation interface {
+	// Take consumes one reserved token; it returns false when the
+	// reservation is exhausted, released or expired
+	Take() bool
+
+	// Remaining returns the number of unconsumed tokens
+	Remaining() int
+
+	// Release returns unconsumed tokens to the rate limiter
+	Release()
+}
+
 // Bulkhead limits concurrent operations
 type Bulkhead interface {
 	// Execute runs the function if capacity is available
 	Execute(ctx context.Context, fn func(context.Context) error) error
 
+	// TryExecute runs the function if a slot is free now, returning
+	// ErrBulkheadFull instead of queuing
+	TryExecute(ctx context.Context, fn func(context.Context) error) error
+
 	// Available returns the number of available slots
 	Available() int
 
+	// Stats returns raw and smoothed bulkhead gauges
+	Stats() BulkheadStats
+
 	// Name returns the bulkhead name
 	Name() string
 }
 
-// Timeout wraps operations with a timeout
+// BulkheadStats is a snapshot of bulkhead gauges. Smoothed values are
+// exponential moving averages over BulkheadConfig.StatsSmoothing, suited
+// for alerting without flapping on short spikes.
+type BulkheadStats struct {
+	// Available is the number of free slots
+	Available int
+
+	// InFlight is the number of running operations
+	InFlight int
+
+	// Queued is the number of operations waiting for a slot
+	Queued int
+
+	// SmoothedAvailable is the smoothed number of free slots
+	SmoothedAvailable float64
+
+	// SmoothedInFlight is the smoothed number of running operations
+	SmoothedInFlight float64
+
+	// QueueTimeouts is the number of calls that gave up waiting for a slot
+	// after MaxQueueWait
+	QueueTimeouts uint64
+}
+
+// RateLimiterStats is a snapshot of rate limiter gauges. The smoothed value
+// is an exponential moving average over RateLimiterConfig.StatsSmoothing.
+type RateLimiterStats struct {
+	// Tokens is the number of tokens currently available
+	Tokens float64
+
+	// SmoothedTokens is the smoothed number of available tokens
+	SmoothedTokens float64
+
+	// Rate is the current refill rate in tokens per second, after
+	// SetRateMultiplier and, with ShareUsage, this instance's share
+	Rate float64
+}
+
+// Timeout wraps operations with a timeout. The function runs on its own
+// goroutine with a context canceled at expiry; Execute returns then without
+// waiting for it. A result arriving before expiry is always returned, even
+// when it races the deadline; one arriving after is a late result, never
+// returned but counted in Stats and reported to TimeoutConfig.OnLateResult.
+// Functions should return promptly once their context is done.
 type Timeout interface {
 	// Execute runs the function with a timeout
 	Execute(ctx context.Context, fn func(context.Context) error) error
@@ -119,34 +697,728 @@ type Timeout interface {
 	// ExecuteWithResult runs the function with a timeout and returns result
 	ExecuteWithResult(ctx context.Context, fn func(context.Context) (any, error)) (any, error)
 
+	// Duration returns the timeout duration
+	Duration() time.Duration
+
+	// Stats returns timeout and late result counters
+	Stats() TimeoutStats
+
 	// Name returns the timeout name
 	Name() string
 }
 
+// TimeoutStats counts timed out calls and what became of them. Calls still
+// running are Timeouts less LateSuccesses and LateFailures and the late
+// results that only reported the canceled context.
+type TimeoutStats struct {
+	// Timeouts is the number of calls that timed out
+	Timeouts uint64
+
+	// LateSuccesses is the number of timed out calls that went on to
+	// succeed, a sign the timeout is too aggressive
+	LateSuccesses uint64
+
+	// LateFailures is the number of timed out calls that went on to fail
+	// with an error other than their canceled context's
+	LateFailures uint64
+}
+
+// LateResult is the outcome of a call a timeout stopped waiting for
+type LateResult struct {
+	// Err is the error the function returned; nil for a late success
+	Err error
+
+	// Late is how long after the call was abandoned it returned
+	Late time.Duration
+
+	// TimedOut is false when the call was abandoned because the caller's
+	// context was done rather than the timeout expiring
+	TimedOut bool
+}
+
+// Clock tells time for the patterns, executors, registries, timer wheels,
+// SLO guards, snapshots, chaos schedules and jobs, so tests can drive them
+// with a FakeClock instead of sleeping. A nil Clock in a config uses the
+// system clock. The degradation cache, canaries, outlier detection,
+// remediation, the async retry queue and coordination rejections always
+// read the system clock.
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+
+	// After waits for d, then sends the current time on the channel
+	After(d time.Duration) <-chan time.Time
+
+	// NewTimer creates a timer firing after d
+	NewTimer(d time.Duration) Timer
+
+	// AfterFunc calls f after d
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a Clock's timer, like time.Timer
+type Timer interface {
+	// C returns the channel the time is sent on when the timer fires; it
+	// is nil for AfterFunc timers
+	C() <-chan time.Time
+
+	// Stop prevents the timer from firing, reporting whether it was pending
+	Stop() bool
+
+	// Reset reschedules the timer to fire after d, reporting whether it
+	// was pending
+	Reset(d time.Duration) bool
+}
+
+// Hedge races duplicate attempts against slow calls, returning the first
+// success
+type Hedge interface {
+	// ExecuteWithResult runs fn, starting another attempt each time the
+	// hedge delay passes without a result
+	ExecuteWithResult(ctx context.Context, fn func(context.Context) (any, error)) (any, error)
+
+	// Delay returns the current hedge trigger delay
+	Delay() time.Duration
+
+	// Name returns the hedge name
+	Name() string
+}
+
 // Builder builds an Executor with multiple resilience patterns
 type Builder interface {
 	// WithCircuitBreaker adds circuit breaker pattern
 	WithCircuitBreaker(config CircuitBreakerConfig) Builder
 
+	// WithKeyedCircuitBreaker adds per-key circuit breakers built from
+	// config, keyed by key (BreakerKeyFromContext if nil) and bounded by maxKeys
+	WithKeyedCircuitBreaker(config CircuitBreakerConfig, maxKeys int, key KeyFunc) Builder
+
+	// WithSharedCircuitBreaker adds an existing circuit breaker, e.g. one
+	// from a CircuitBreakerRegistry shared with other executors
+	WithSharedCircuitBreaker(breaker CircuitBreaker) Builder
+
+	// WithSharedKeyedCircuitBreaker adds existing per-key circuit breakers,
+	// e.g. ones driven by an OutlierDetector, keyed by key
+	// (BreakerKeyFromContext if nil)
+	WithSharedKeyedCircuitBreaker(breakers KeyedCircuitBreaker, key KeyFunc) Builder
+
 	// WithRetry adds retry pattern
 	WithRetry(config RetryConfig) Builder
 
 	// WithRateLimiter adds rate limiter pattern
 	WithRateLimiter(config RateLimiterConfig) Builder
 
+	// WithSharedRateLimiter adds an existing rate limiter, e.g. one also
+	// fed server quotas by an HTTP transport
+	WithSharedRateLimiter(limiter RateLimiter) Builder
+
 	// WithBulkhead adds bulkhead pattern
 	WithBulkhead(config BulkheadConfig) Builder
 
 	// WithTimeout adds timeout pattern
 	WithTimeout(duration time.Duration) Builder
 
+	// WithTimeoutConfig adds timeout pattern with straggler detection settings
+	WithTimeoutConfig(config TimeoutConfig) Builder
+
+	// WithHedge adds hedging pattern
+	WithHedge(config HedgeConfig) Builder
+
 	// WithName sets the executor name
 	WithName(name string) Builder
 
+	// WithFlagProvider enables runtime feature flags for patterns;
+	// flag values are cached for ttl (DefaultFlagTTL when zero)
+	WithFlagProvider(provider FlagProvider, ttl time.Duration) Builder
+
+	// WithEventRecorder records call outcomes as seen by the circuit breaker
+	WithEventRecorder(recorder *EventRecorder) Builder
+
+	// WithMetrics reports call metrics to config.Recorder
+	WithMetrics(config MetricsConfig) Builder
+
+	// WithConfigAdvisor reports settings that are far from observed
+	// behavior to config.OnAdvisory
+	WithConfigAdvisor(config AdvisorConfig) Builder
+
+	// WithAnomalyDetection reports flapping, retry amplification, sustained
+	// saturation and panics to config.OnAnomaly
+	WithAnomalyDetection(config AnomalyConfig) Builder
+
+	// WithAttemptHook derives the context of each retried or hedged
+	// attempt with hook, e.g. to tag downstream requests
+	WithAttemptHook(hook AttemptHook) Builder
+
+	// WithAttemptCanceledHook calls hook when an attempt's context is
+	// canceled while it runs, so attempt-scoped resources can be released
+	WithAttemptCanceledHook(hook OnAttemptCanceled) Builder
+
+	// WithTargetSelector picks the target of each attempt with selector and
+	// passes it in the attempt's context, read with TargetFromContext, so
+	// retries and hedges can go to another replica than earlier attempts
+	WithTargetSelector(selector TargetSelector) Builder
+
+	// WithMisuseDetection reports reentrant calls and calls with canceled
+	// or stale contexts to onMisuse; intended for development and tests
+	WithMisuseDetection(onMisuse OnMisuse) Builder
+
+	// WithPayloadSampling captures request metadata with config.Extractor
+	// while the executor's failure rate exceeds config.FailureThreshold
+	WithPayloadSampling(config PayloadSamplingConfig) Builder
+
+	// WithClock times the executor's metrics, events, samples, flag TTLs,
+	// advisories and anomalies on clock; patterns read their own config
+	// Clock
+	WithClock(clock Clock) Builder
+
 	// Build creates the executor
 	Build() Executor
 }
 
+// Registry creates and caches executors by name
+type Registry interface {
+	// Executor returns the executor for name, creating it on first use
+	Executor(name string) Executor
+
+	// FromContext returns the executor named by WithExecutorName in ctx,
+	// or the DefaultExecutorName executor
+	FromContext(ctx context.Context) Executor
+
+	// Register adds or replaces the executor for name
+	Register(name string, executor Executor)
+
+	// Names returns the registered executor names in sorted order
+	Names() []string
+
+	// ResetAll resets the circuit breakers of every executor, clearing
+	// states forced by ForceOpen, ForceClosed or Disable
+	ResetAll()
+
+	// ForceOpenMatching forces open the circuit breakers of the executors
+	// whose names match the path.Match pattern, e.g. "payments-*", and
+	// returns their names
+	ForceOpenMatching(pattern string) ([]string, error)
+
+	// SetRateMultiplier scales the rate limits of every executor, e.g. to
+	// 0.5 to halve outbound load during an incident; 1 restores them
+	SetRateMultiplier(multiplier float64) error
+
+	// HealthScore combines the breaker state, failure rate, latency trend
+	// and rejection rate of the executor for name into a score from 0
+	// (unavailable) to 1 (healthy). Rejections are counted since the
+	// previous call for the name. Unregistered names score 0.
+	HealthScore(name string) float64
+
+	// SetHealthScorer replaces the scoring function of HealthScore; nil
+	// restores DefaultHealthScorer
+	SetHealthScorer(scorer HealthScorer)
+}
+
+// HealthSignals are the inputs a HealthScorer combines for one executor
+type HealthSignals struct {
+	// State is the circuit state; with keyed breakers, the worst state
+	// among the keys
+	State CircuitState
+
+	// FailureRate is the share of counted calls that failed or timed out
+	FailureRate float64
+
+	// LatencyP95 is the 95th percentile latency of admitted calls
+	LatencyP95 time.Duration
+
+	// LatencyTrend is LatencyP95 relative to its average over the last
+	// minutes of evaluations; above 1 means latency is rising
+	LatencyTrend float64
+
+	// RejectionRate is the share of calls rejected by the circuit breaker,
+	// bulkhead or rate limiter since the previous evaluation
+	RejectionRate float64
+}
+
+// HealthScorer combines health signals into a score from 0 to 1
+type HealthScorer func(signals HealthSignals) float64
+
+// MetricsSnapshotter periodically writes snapshots of a registry's
+// executors to a SnapshotSink, for environments without a metrics stack
+type MetricsSnapshotter interface {
+	// Snapshot returns the current snapshot without writing it
+	Snapshot() MetricsSnapshot
+
+	// Close stops the snapshotter and writes a final snapshot
+	Close(ctx context.Context) error
+}
+
+// SnapshotSink persists metrics snapshots, e.g. to files, an object store
+// or statsd
+type SnapshotSink interface {
+	// WriteSnapshot persists snapshot
+	WriteSnapshot(ctx context.Context, snapshot MetricsSnapshot) error
+}
+
+// MetricsSnapshot is a compact summary of every executor in a registry
+type MetricsSnapshot struct {
+	// Time is when the snapshot was taken
+	Time time.Time `json:"time"`
+
+	// Executors are the executors in name order
+	Executors []ExecutorSnapshot `json:"executors"`
+}
+
+// ExecutorSnapshot summarizes one executor. Call counts are cumulative;
+// breaker counts cover the current breaker interval. Keyed breakers report
+// the worst state, summed counts and the slowest latency among their keys.
+type ExecutorSnapshot struct {
+	// Name is the executor name
+	Name string `json:"name"`
+
+	// State is the circuit state; closed without a breaker
+	State CircuitState `json:"state"`
+
+	// Calls is the number of calls made through the executor
+	Calls uint64 `json:"calls"`
+
+	// Rejected is the number of calls rejected by its patterns
+	Rejected uint64 `json:"rejected"`
+
+	// Requests is the number of requests the breaker counted
+	Requests uint32 `json:"requests"`
+
+	// Failures is the number of failed or timed out requests the breaker
+	// counted
+	Failures uint32 `json:"failures"`
+
+	// LatencyP95 is the breaker's 95th percentile call latency
+	LatencyP95 time.Duration `json:"latency_p95"`
+
+	// LateSuccesses is the number of calls that succeeded after its timeout
+	// gave up on them
+	LateSuccesses uint64 `json:"late_successes"`
+}
+
+// CircuitBreakerRegistry creates and caches circuit breakers by name so
+// callers of the same dependency share one breaker
+type CircuitBreakerRegistry interface {
+	// CircuitBreaker returns the breaker for name, creating it on first use
+	CircuitBreaker(name string) CircuitBreaker
+
+	// Register adds or replaces the breaker for name
+	Register(name string, breaker CircuitBreaker)
+
+	// Names returns the registered breaker names in sorted order
+	Names() []string
+}
+
+// KeyedCircuitBreaker fans one breaker configuration out into independent
+// breakers per key, such as a downstream host or endpoint
+type KeyedCircuitBreaker interface {
+	// CircuitBreaker returns the breaker for key, creating it on first use.
+	// Least recently used breakers are evicted beyond the key bound, so an
+	// evicted key starts again closed.
+	CircuitBreaker(key string) CircuitBreaker
+
+	// Keys returns the tracked keys in sorted order
+	Keys() []string
+
+	// Range calls fn for each tracked breaker in key order until fn
+	// returns false, without creating keys or changing which are evicted
+	// next
+	Range(fn func(key string, breaker CircuitBreaker) bool)
+
+	// ForceOpen forces open every tracked breaker and those created later,
+	// until Reset
+	ForceOpen()
+
+	// Reset resets every tracked breaker and clears ForceOpen
+	Reset()
+
+	// Len returns the number of tracked keys
+	Len() int
+
+	// TopRejected returns up to k keys whose breakers rejected the most
+	// calls within window, most rejected first. window is rounded up to
+	// whole minutes and covers at most the last hour.
+	TopRejected(k int, window time.Duration) []KeyRejections
+
+	// Name returns the base breaker name
+	Name() string
+}
+
+// BreakerGroupStats describes the breakers sharing a GroupCoordinator
+type BreakerGroupStats struct {
+	// Members is the number of subscribed breakers
+	Members int
+
+	// TopRejected is up to 10 breakers that rejected the most calls over
+	// the last 5 minutes, most rejected first
+	TopRejected []KeyRejections
+}
+
+// KeyRejections is the number of calls rejected for one key
+type KeyRejections struct {
+	// Key is the key the calls were made with
+	Key string
+
+	// Rejections is the number of calls rejected
+	Rejections uint64
+}
+
+// OutlierDetector tracks the success rates of a set of endpoints, e.g. the
+// hosts behind a client-side load balancer, and ejects those failing
+// consecutively or far more often than the rest for a growing period
+type OutlierDetector interface {
+	// SetEndpoints replaces the endpoint set, keeping the state of
+	// endpoints already tracked
+	SetEndpoints(endpoints []string)
+
+	// Record records the outcome of a call to endpoint; unknown endpoints
+	// are ignored
+	Record(endpoint string, err error)
+
+	// Healthy returns the endpoints to balance over in sorted order: those
+	// not ejected and whose keyed breaker, if any, is not open
+	Healthy() []string
+
+	// Ejected returns the ejected endpoints in sorted order
+	Ejected() []string
+
+	// Name returns the detector name
+	Name() string
+}
+
+// MetricsRecorder receives executor call metrics for export, e.g. as the
+// MetricCallsTotal and MetricCallDuration metrics of MetricsSchema
+type MetricsRecorder interface {
+	// RecordCall records a completed executor call
+	RecordCall(call CallMetrics)
+}
+
+// RetryOutcome is how a call run through a retry ended
+type RetryOutcome string
+
+const (
+	// RetryOutcomeFirstAttempt means the call succeeded on its first attempt
+	RetryOutcomeFirstAttempt RetryOutcome = "first_attempt"
+
+	// RetryOutcomeRetried means the call succeeded after one or more retries
+	RetryOutcomeRetried RetryOutcome = "retried"
+
+	// RetryOutcomeExhausted means the call failed after its last allowed
+	// attempt or when MaxElapsedTime ran out
+	RetryOutcomeExhausted RetryOutcome = "exhausted"
+
+	// RetryOutcomeAborted means the call failed with attempts left: the
+	// error was not retryable, the downstream asked to give up, or the
+	// context ended or would have during the backoff
+	RetryOutcomeAborted RetryOutcome = "aborted"
+)
+
+// RetryMetricsRecorder receives retry outcomes for export, e.g. as the
+// MetricRetryCallsTotal and MetricRetryAttemptsTotal metrics of MetricsSchema
+type RetryMetricsRecorder interface {
+	// RecordRetry records a completed call of the named retry with the
+	// number of attempts it made
+	RecordRetry(name string, outcome RetryOutcome, attempts int)
+}
+
+// BreakerMetricsRecorder receives circuit breaker state metrics for export.
+// Methods are called with the breaker's lock held, so they must not block
+// or call back into the breaker.
+type BreakerMetricsRecorder interface {
+	// RecordStateDuration records the time spent in state when the breaker
+	// leaves it
+	RecordStateDuration(name string, state CircuitState, d time.Duration)
+
+	// RecordOpenings records the number of transitions to open during an
+	// interval once it ends
+	RecordOpenings(name string, openings int, interval time.Duration)
+}
+
+// CallMetrics describes a completed executor call
+type CallMetrics struct {
+	// Name is the executor name
+	Name string
+
+	// Outcome is success, failure, or rejected by a pattern
+	Outcome Outcome
+
+	// Duration is how long the call took, including waits
+	Duration time.Duration
+
+	// Labels are the extra labels returned by the LabelExtractor, after
+	// cardinality limits; nil without an extractor
+	Labels map[string]string
+}
+
+// LabelExtractor returns extra metric labels for a call, such as a tenant
+// or route taken from the context
+type LabelExtractor func(ctx context.Context) map[string]string
+
+// PayloadExtractor returns metadata describing the request in ctx, such as
+// the route, tenant or payload size. It must leave out or redact personal
+// data; PayloadSamplingConfig.RedactKeys masks known keys on top.
+type PayloadExtractor func(ctx context.Context) map[string]string
+
+// PayloadSample is request metadata captured while an executor's failure
+// rate exceeds PayloadSamplingConfig.FailureThreshold
+type PayloadSample struct {
+	// Executor is the name of the executor
+	Executor string
+
+	// Time is when the call started
+	Time time.Time
+
+	// Duration is how long the call took
+	Duration time.Duration
+
+	// Error is the call error, empty on success
+	Error string
+
+	// FailureRate is the executor's failure rate when the call completed
+	FailureRate float64
+
+	// Metadata is what the PayloadExtractor returned, after redaction
+	Metadata map[string]string
+}
+
+// KeyFunc extracts the circuit breaker key for a call
+type KeyFunc func(ctx context.Context) string
+
+// DegradationCache keeps the results of successful calls so they can answer
+// the same calls while the dependency is failing
+type DegradationCache interface {
+	// Wrap returns an executor that caches results of calls made with the
+	// CacheKey option and serves them when those calls fail. Executors
+	// wrapped by one cache share its entries, so writes through one can
+	// invalidate or refresh reads through another.
+	Wrap(executor Executor) Executor
+
+	// Get returns the cached value for key
+	Get(key string) (any, bool)
+
+	// Refresh stores value for key
+	Refresh(key string, value any)
+
+	// Invalidate removes the entries for keys
+	Invalidate(keys ...string)
+}
+
+// AsyncExecutor is an Executor that also runs fire-and-forget calls, retrying
+// failed ones in the background through a bounded queue
+type AsyncExecutor interface {
+	Executor
+
+	// Go runs fn in the background under the wrapped executor. Failed calls
+	// are retried with backoff until MaxAttempts, then dead-lettered. The
+	// call keeps ctx's values but not its cancellation; id identifies the
+	// call to the QueueStore and dead-letter callback.
+	Go(ctx context.Context, id string, fn func(context.Context) error) error
+
+	// Restore re-enqueues a call loaded from a QueueStore, keeping its
+	// attempt count and waiting until its NextAttempt
+	Restore(ctx context.Context, call QueuedCall, fn func(context.Context) error) error
+
+	// Pending returns the number of calls running or waiting to be retried
+	Pending() int
+
+	// Close stops accepting calls and retrying, leaving queued calls in the
+	// QueueStore, and waits for in-flight attempts until ctx is done
+	Close(ctx context.Context) error
+}
+
+// QueuedCall is the persisted state of a call in an AsyncExecutor's queue
+type QueuedCall struct {
+	// ID identifies the call, as passed to Go
+	ID string `json:"id"`
+
+	// Attempts is the number of attempts made so far
+	Attempts int `json:"attempts"`
+
+	// NextAttempt is when the call is next attempted
+	NextAttempt time.Time `json:"next_attempt"`
+
+	// LastError is the error of the last attempt
+	LastError string `json:"last_error,omitempty"`
+}
+
+// QueueStore persists an AsyncExecutor's queued calls so they can be
+// restored after a restart. Calls are saved when enqueued and after each
+// failed attempt, and deleted once they succeed or are dead-lettered.
+type QueueStore interface {
+	// Save stores or replaces the call
+	Save(call QueuedCall) error
+
+	// Delete removes the call
+	Delete(id string) error
+}
+
+// Remediator runs runbook steps, such as restarting a connection pool or
+// paging, when a condition like an open breaker persists
+type Remediator interface {
+	// Register adds a remediation; names must be unique
+	Register(remediation Remediation) error
+
+	// Close stops checking conditions and waits for running actions until
+	// ctx is done
+	Close(ctx context.Context) error
+}
+
+// Remediation is a runbook step run once its condition has held for a while
+type Remediation struct {
+	// Name identifies the remediation in events
+	Name string
+
+	// Condition reports whether the trigger currently holds
+	Condition RemediationCondition
+
+	// For is how long Condition must hold before Action runs
+	For time.Duration
+
+	// Cooldown is the minimum time between runs of Action, so a condition
+	// that persists or flaps doesn't run it repeatedly; zero uses
+	// RemediationConfig.Cooldown
+	Cooldown time.Duration
+
+	// Action performs the step
+	Action RemediationAction
+}
+
+// RemediationEvent describes a run of a remediation
+type RemediationEvent struct {
+	// Remediation is the remediation name
+	Remediation string
+
+	// Since is when the condition started holding
+	Since time.Time
+
+	// Time is when the action was started
+	Time time.Time
+
+	// Run counts the runs since the condition started holding, from 1
+	Run int
+}
+
+// SLOGuard is an Executor that tracks error budget burn against an SLO
+type SLOGuard interface {
+	Executor
+
+	// Status returns a snapshot of the error budget
+	Status() SLOStatus
+}
+
+// SLOStatus is a snapshot of an SLOGuard's error budget
+type SLOStatus struct {
+	// Objective is the configured success ratio target
+	Objective float64
+
+	// Requests is the number of calls observed within the window
+	Requests uint64
+
+	// Failures is the number of failed calls observed within the window
+	Failures uint64
+
+	// BudgetRemaining is the fraction of the error budget left (may be negative)
+	BudgetRemaining float64
+
+	// BurnRate is the budget consumption rate over the burn rate window
+	BurnRate float64
+
+	// Tightened reports whether retries are currently suspended
+	Tightened bool
+}
+
+// CanaryExecutor is an Executor that routes a fraction of calls through a
+// candidate executor and rolls back to the baseline if the candidate's
+// outcomes degrade
+type CanaryExecutor interface {
+	Executor
+
+	// Stats returns a snapshot of both arms over the comparison window
+	Stats() CanaryStats
+
+	// Reset clears the observed outcomes and resumes routing calls to the
+	// candidate after a rollback
+	Reset()
+}
+
+// CanaryStats is a snapshot of a CanaryExecutor's comparison
+type CanaryStats struct {
+	// Baseline covers calls run by the baseline executor
+	Baseline CanaryArmStats
+
+	// Candidate covers calls run by the candidate executor
+	Candidate CanaryArmStats
+
+	// RolledBack reports whether calls stopped going to the candidate
+	RolledBack bool
+
+	// RolledBackAt is when the candidate was rolled back
+	RolledBackAt time.Time
+}
+
+// CanaryArmStats summarizes one executor's calls within the window
+type CanaryArmStats struct {
+	// Calls is the number of calls observed
+	Calls uint64
+
+	// Failures is the number of calls that returned an error
+	Failures uint64
+
+	// FailureRate is Failures over Calls; zero without calls
+	FailureRate float64
+
+	// LatencyP95 is the 95th percentile call latency
+	LatencyP95 time.Duration
+}
+
+// ShadowCircuitBreaker is a CircuitBreaker that also evaluates a candidate
+// configuration against the same calls, without letting it reject any
+type ShadowCircuitBreaker interface {
+	CircuitBreaker
+
+	// Shadow returns the breaker built from the candidate configuration
+	Shadow() CircuitBreaker
+
+	// ShadowStats returns the admission decisions compared so far
+	ShadowStats() ShadowStats
+}
+
+// ShadowStats counts the admission decisions of a ShadowCircuitBreaker
+type ShadowStats struct {
+	// Calls is the number of admission decisions compared
+	Calls uint64
+
+	// WouldReject is the number of calls the active breaker admitted that
+	// the shadow would have rejected
+	WouldReject uint64
+
+	// WouldAdmit is the number of calls the active breaker rejected that
+	// the shadow would have admitted
+	WouldAdmit uint64
+}
+
+// ShadowDisagreement describes the active and shadow breakers starting to
+// disagree on whether to admit calls
+type ShadowDisagreement struct {
+	// Name is the name of the active breaker
+	Name string
+
+	// ActiveState is the state of the active breaker
+	ActiveState CircuitState
+
+	// ShadowState is the state of the shadow breaker
+	ShadowState CircuitState
+
+	// ShadowAdmitted reports whether the shadow would have admitted the
+	// call the active breaker rejected, rather than the other way around
+	ShadowAdmitted bool
+
+	// Time is when the disagreement was observed
+	Time time.Time
+
+	// Stats are the decisions compared so far, including this one
+	Stats ShadowStats
+}
+
 // BackoffStrategy defines how to calculate backoff delays
 type BackoffStrategy interface {
 	// Next returns the next backoff duration
@@ -156,14 +1428,103 @@ type BackoffStrategy interface {
 // ShouldRetry determines if an error should trigger a retry
 type ShouldRetry func(error) bool
 
+// ShouldRetryResult determines from an attempt's result and error whether
+// the attempt should be retried, e.g. to retry a 503 response body that is
+// not a Go error
+type ShouldRetryResult func(result any, err error) bool
+
+// RetryAfterFunc extracts a server-provided retry delay from err, such as
+// an HTTP Retry-After header or a gRPC RetryInfo detail, reporting false
+// when err carries none
+type RetryAfterFunc func(err error) (time.Duration, bool)
+
+// IsFailure determines if an error counts as a failure for the circuit breaker
+type IsFailure func(error) bool
+
 // OnStateChange is called when circuit breaker state changes
 type OnStateChange func(name string, from, to CircuitState)
 
+// HealthProbe checks whether a dependency has recovered, e.g. with a cheap
+// ping, so an open circuit breaker can try half-open without a user request
+type HealthProbe func(ctx context.Context) error
+
+// AttemptHook returns the context for a retried or hedged attempt, derived
+// from the attempt's ctx
+type AttemptHook func(ctx context.Context, attempt Attempt) context.Context
+
+// OnAttemptCanceled is called on its own goroutine when an attempt's
+// context is canceled while the attempt runs: a hedge lost to another
+// attempt, a timeout expired or the caller gave up. ctx is the attempt's
+// context, carrying values set by the AttemptHook.
+type OnAttemptCanceled func(ctx context.Context, attempt Attempt)
+
+// OnMisuse is called when an executor detects that it is misused
+type OnMisuse func(misuse Misuse)
+
+// TargetSelector returns the endpoint or replica an attempt should use;
+// tried lists the targets of the call's earlier attempts in order
+type TargetSelector func(ctx context.Context, attempt Attempt, tried []string) string
+
 // OnRetry is called before each retry attempt
 type OnRetry func(attempt int, err error)
 
+// OnGiveUp is called when a retry returns an error, with the attempts made
+// and the time since the first one began
+type OnGiveUp func(attempts int, elapsed time.Duration, err error)
+
 // OnRateLimit is called when rate limit is exceeded
 type OnRateLimit func(name string)
 
 // OnBulkheadFull is called when bulkhead is at capacity
 type OnBulkheadFull func(name string)
+
+// OnStraggler is called when a function wrapped by a timeout is still running
+// elapsed after its context was canceled; stack is nil unless captured
+type OnStraggler func(name string, elapsed time.Duration, stack []byte)
+
+// OnLateResult is called when a function wrapped by a timeout returns after
+// the call was abandoned
+type OnLateResult func(name string, result LateResult)
+
+// OnAdvisory is called when configuration looks stale for observed traffic
+type OnAdvisory func(advisory Advisory)
+
+// OnServeStale is called when a cached entry answers a failed call
+type OnServeStale func(key string, age time.Duration, err error)
+
+// OnDeadLetter is called when an async call exhausts its attempts or fails
+// with an error that should not be retried
+type OnDeadLetter func(call QueuedCall, err error)
+
+// OnHedge is called when a hedged attempt is started
+type OnHedge func(name string, attempt int, delay time.Duration)
+
+// OnAnomaly is called when an executor's anomaly heuristics fire
+type OnAnomaly func(anomaly Anomaly)
+
+// OnPayloadSample is called with each captured PayloadSample
+type OnPayloadSample func(sample PayloadSample)
+
+// RemediationCondition reports whether a remediation's trigger holds
+type RemediationCondition func() bool
+
+// RemediationAction performs a remediation; ctx expires after
+// RemediationConfig.ActionTimeout
+type RemediationAction func(ctx context.Context, event RemediationEvent) error
+
+// OnRemediation is called when a remediation action returns
+type OnRemediation func(event RemediationEvent, err error)
+
+// OnCanaryRollback is called when a canary's candidate is rolled back
+type OnCanaryRollback func(name string, stats CanaryStats)
+
+// OnShadowDisagreement is called when a shadow breaker starts disagreeing
+// with the active breaker, once per run of disagreeing calls
+type OnShadowDisagreement func(disagreement ShadowDisagreement)
+
+// OnOutlierEjection is called when an OutlierDetector ejects an endpoint
+// (ejected true) or readmits it
+type OnOutlierEjection func(name, endpoint string, ejected bool)
+
+// OnBurnRateChange is called when an SLOGuard starts or stops tightening
+type OnBurnRateChange func(name string, burnRate float64, tightened bool)
@@ -1,11 +1,50 @@
 package resilience
 
 import (
+	"go/parser"
+	gotoken "go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// TestStandardLibraryOnly keeps the core package free of dependencies;
+// integrations belong in sub-modules such as resiliencefx. Packages of
+// this module, such as cache, are allowed.
+func TestStandardLibraryOnly(t *testing.T) {
+	const module = "github.com/gostratum/resiliencex/"
+
+	files, err := filepath.Glob("*.go")
+	require.NoError(t, err)
+
+	fset := gotoken.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		src, err := os.ReadFile(file)
+		require.NoError(t, err)
+		f, err := parser.ParseFile(fset, file, src, parser.ImportsOnly)
+		require.NoError(t, err)
+
+		for _, spec := range f.Imports {
+			path, _ := strconv.Unquote(spec.Path.Value)
+			if dir, ok := strings.CutPrefix(path, module); ok {
+				_, err := os.Stat(filepath.Join(dir, "go.mod"))
+				assert.True(t, os.IsNotExist(err), "%s imports sub-module %s", file, path)
+				continue
+			}
+			first, _, _ := strings.Cut(path, "/")
+			assert.NotContains(t, first, ".", "%s imports %s", file, path)
+		}
+	}
+}
+
 func TestCircuitStateString(t *testing.T) {
 	tests := []struct {
 		state    CircuitState
@@ -14,6 +53,7 @@ func TestCircuitStateString(t *testing.T) {
 		{StateClosed, "closed"},
 		{StateOpen, "open"},
 		{StateHalfOpen, "half-open"},
+		{StateDegraded, "degraded"},
 		{CircuitState(99), "unknown"},
 	}
 
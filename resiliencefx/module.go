@@ -0,0 +1,297 @@
+// Package resiliencefx provides the resilience module for fx, configured
+// through core/configx and logging through core/logx. It lives in its own
+// module so the core resilience package has no dependencies.
+package resiliencefx
+
+import (
+	"context"
+	"time"
+
+	"github.com/gostratum/core/configx"
+	"github.com/gostratum/core/logx"
+	"go.uber.org/fx"
+
+	resilience "github.com/gostratum/resiliencex"
+)
+
+// Module provides the resilience module for fx
+func Module() fx.Option {
+	return fx.Module("resiliencex",
+		fx.Provide(
+			NewConfig,
+			NewProvider,
+		),
+	)
+}
+
+// NewConfig creates a resilience.Config from the configuration loader,
+// starting from resilience.DefaultConfig
+func NewConfig(loader configx.Loader) (resilience.Config, error) {
+	cfg := resilience.DefaultConfig()
+
+	// Bind configuration
+	if err := loader.Bind(&cfg); err != nil {
+		return cfg, err
+	}
+
+	if err := cfg.CircuitBreaker.Validate(); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+// Params contains dependencies for the resilience provider
+type Params struct {
+	fx.In
+
+	Config         configx.Loader
+	Logger         logx.Logger
+	Lifecycle      fx.Lifecycle               `optional:"true"`
+	Metrics        resilience.MetricsRecorder `optional:"true"`
+	LabelExtractor resilience.LabelExtractor  `optional:"true"`
+
+	// BreakerListeners are the OnStateChange listeners contributed to the
+	// resilience.breaker_listeners value group, see AsBreakerListener
+	BreakerListeners []resilience.OnStateChange `group:"resilience.breaker_listeners"`
+}
+
+// AsBreakerListener annotates a constructor returning a
+// resilience.OnStateChange so its listener joins the
+// resilience.breaker_listeners value group. Every breaker in the module's
+// registry reports state changes to all listeners in the group, letting
+// metrics, logging and alerting modules each contribute their own:
+//
+//	fx.Provide(resiliencefx.AsBreakerListener(newBreakerAlerts))
+func AsBreakerListener(constructor any) any {
+	return fx.Annotate(constructor, fx.ResultTags(`group:"resilience.breaker_listeners"`))
+}
+
+// PolicyFingerprint is the resilience.Config fingerprint of the effective
+// policies, for stats endpoints that let fleet tooling compare replicas
+type PolicyFingerprint string
+
+// Result contains the resilience provider outputs
+type Result struct {
+	fx.Out
+
+	Builder         resilience.Builder
+	CircuitBreakers resilience.CircuitBreakerRegistry
+	Fingerprint     PolicyFingerprint
+}
+
+// NewProvider creates a new resilience provider
+func NewProvider(params Params) (Result, error) {
+	cfg, err := NewConfig(params.Config)
+	if err != nil {
+		return Result{}, err
+	}
+
+	params.Logger.Info("Initializing resilience module",
+		logx.String("circuit_breaker", cfg.CircuitBreaker.Name),
+		logx.String("retry", cfg.Retry.Name),
+		logx.String("rate_limiter", cfg.RateLimiter.Name),
+		logx.String("bulkhead", cfg.Bulkhead.Name),
+	)
+
+	fingerprint := PolicyFingerprint(cfg.Fingerprint())
+	params.Logger.Info("Resilience policy",
+		logx.String("fingerprint", string(fingerprint)),
+		logx.Any("policy", cfg.Policy()),
+	)
+
+	// Create coordinator if a backend is selected
+	coordinator, err := resilience.NewCoordinator(cfg.Coordination)
+	if err != nil {
+		return Result{}, err
+	}
+	if coordinator != nil {
+		cfg.CircuitBreaker.Coordinator = coordinator
+		cfg.RateLimiter.Coordinator = coordinator
+		params.Logger.Info("Coordination enabled",
+			logx.String("backend", cfg.Coordination.Backend),
+		)
+		if params.Lifecycle != nil {
+			params.Lifecycle.Append(fx.Hook{
+				OnStop: func(context.Context) error {
+					return coordinator.Close()
+				},
+			})
+		}
+	}
+
+	// Deliver state changes to every contributed listener
+	if listener := breakerListeners(cfg.CircuitBreaker.OnStateChange, params.BreakerListeners); listener != nil {
+		cfg.CircuitBreaker.OnStateChange = listener
+		params.Logger.Info("Circuit breaker listeners registered",
+			logx.Int("count", len(params.BreakerListeners)),
+		)
+	}
+
+	// Breakers are shared by name across everything using the module
+	breakers := resilience.NewCircuitBreakerRegistry(cfg.CircuitBreaker)
+
+	// Create builder with default configuration
+	builder := resilience.NewBuilder().WithName("default-executor")
+
+	// Add circuit breaker if enabled
+	if cfg.CircuitBreaker.Enabled {
+		builder = builder.WithSharedCircuitBreaker(breakers.CircuitBreaker(cfg.CircuitBreaker.Name))
+		params.Logger.Info("Circuit breaker enabled",
+			logx.String("name", cfg.CircuitBreaker.Name),
+			logx.Float64("failure_threshold", cfg.CircuitBreaker.FailureThreshold),
+		)
+	}
+
+	// Add retry if enabled
+	if cfg.Retry.Enabled {
+		builder = builder.WithRetry(cfg.Retry)
+		params.Logger.Info("Retry enabled",
+			logx.String("name", cfg.Retry.Name),
+			logx.Int("max_attempts", cfg.Retry.MaxAttempts),
+		)
+	}
+
+	// Add rate limiter if enabled
+	if cfg.RateLimiter.Enabled {
+		builder = builder.WithRateLimiter(cfg.RateLimiter)
+		params.Logger.Info("Rate limiter enabled",
+			logx.String("name", cfg.RateLimiter.Name),
+			logx.Float64("rate", cfg.RateLimiter.Rate),
+		)
+	}
+
+	// Add bulkhead if enabled
+	if cfg.Bulkhead.Enabled {
+		builder = builder.WithBulkhead(cfg.Bulkhead)
+		params.Logger.Info("Bulkhead enabled",
+			logx.String("name", cfg.Bulkhead.Name),
+			logx.Int("max_concurrent", cfg.Bulkhead.MaxConcurrent),
+		)
+	}
+
+	// Add timeout if enabled
+	if cfg.Timeout.Enabled {
+		if cfg.Timeout.OnStraggler == nil {
+			cfg.Timeout.OnStraggler = logStraggler(params.Logger)
+		}
+		builder = builder.WithTimeoutConfig(cfg.Timeout)
+		params.Logger.Info("Timeout enabled",
+			logx.Duration("duration", cfg.Timeout.Duration),
+			logx.Duration("straggler_threshold", cfg.Timeout.StragglerThreshold),
+		)
+	}
+
+	// Add hedging if enabled
+	if cfg.Hedge.Enabled {
+		builder = builder.WithHedge(cfg.Hedge)
+		params.Logger.Info("Hedging enabled",
+			logx.String("name", cfg.Hedge.Name),
+			logx.Int("max_hedges", cfg.Hedge.MaxHedges),
+			logx.Float64("percentile", cfg.Hedge.Percentile),
+		)
+	}
+
+	// Report call metrics if a recorder is provided
+	if params.Metrics != nil {
+		cfg.Metrics.Recorder = params.Metrics
+		cfg.Metrics.LabelExtractor = params.LabelExtractor
+		builder = builder.WithMetrics(cfg.Metrics)
+		params.Logger.Info("Metrics enabled",
+			logx.Int("max_label_keys", cfg.Metrics.MaxLabelKeys),
+			logx.Int("max_label_values", cfg.Metrics.MaxLabelValues),
+		)
+	}
+
+	// Add configuration advisories if enabled
+	if cfg.Advisor.Enabled {
+		if cfg.Advisor.OnAdvisory == nil {
+			cfg.Advisor.OnAdvisory = logAdvisory(params.Logger)
+		}
+		builder = builder.WithConfigAdvisor(cfg.Advisor)
+	}
+
+	// Add anomaly detection if enabled
+	if cfg.Anomaly.Enabled {
+		if cfg.Anomaly.OnAnomaly == nil {
+			cfg.Anomaly.OnAnomaly = logAnomaly(params.Logger)
+		}
+		builder = builder.WithAnomalyDetection(cfg.Anomaly)
+		params.Logger.Info("Anomaly detection enabled",
+			logx.Int("flap_threshold", cfg.Anomaly.FlapThreshold),
+			logx.Float64("retry_amplification", cfg.Anomaly.RetryAmplification),
+		)
+	}
+
+	return Result{
+		Builder:         builder,
+		CircuitBreakers: breakers,
+		Fingerprint:     fingerprint,
+	}, nil
+}
+
+// breakerListeners combines the configured OnStateChange with the
+// contributed listeners, calling them in order; nil if there are none
+func breakerListeners(configured resilience.OnStateChange, contributed []resilience.OnStateChange) resilience.OnStateChange {
+	var listeners []resilience.OnStateChange
+	if configured != nil {
+		listeners = append(listeners, configured)
+	}
+	for _, listener := range contributed {
+		if listener != nil {
+			listeners = append(listeners, listener)
+		}
+	}
+
+	switch len(listeners) {
+	case 0:
+		return nil
+	case 1:
+		return listeners[0]
+	}
+	return func(name string, from, to resilience.CircuitState) {
+		for _, listener := range listeners {
+			listener(name, from, to)
+		}
+	}
+}
+
+// logStraggler logs wrapped functions that ignore context cancellation
+func logStraggler(logger logx.Logger) resilience.OnStraggler {
+	return func(name string, elapsed time.Duration, stack []byte) {
+		fields := []logx.Field{
+			logx.String("name", name),
+			logx.Duration("elapsed", elapsed),
+		}
+		if stack != nil {
+			fields = append(fields, logx.String("stack", string(stack)))
+		}
+		logger.Warn("Function still running after context cancellation", fields...)
+	}
+}
+
+// logAdvisory logs stale configuration advisories
+func logAdvisory(logger logx.Logger) resilience.OnAdvisory {
+	return func(advisory resilience.Advisory) {
+		logger.Info("Resilience config advisory: "+advisory.Message,
+			logx.String("executor", advisory.Executor),
+			logx.String("setting", advisory.Setting),
+		)
+	}
+}
+
+// logAnomaly logs detected anomalies
+func logAnomaly(logger logx.Logger) resilience.OnAnomaly {
+	return func(anomaly resilience.Anomaly) {
+		fields := []logx.Field{
+			logx.String("executor", anomaly.Executor),
+			logx.String("kind", string(anomaly.Kind)),
+			logx.Float64("value", anomaly.Value),
+			logx.Float64("threshold", anomaly.Threshold),
+		}
+		if anomaly.Stack != nil {
+			fields = append(fields, logx.String("stack", string(anomaly.Stack)))
+		}
+		logger.Warn("Resilience anomaly: "+anomaly.Message, fields...)
+	}
+}
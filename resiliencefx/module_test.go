@@ -0,0 +1,158 @@
+package resiliencefx
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gostratum/core/configx"
+	"github.com/gostratum/core/logx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+
+	resilience "github.com/gostratum/resiliencex"
+	"github.com/gostratum/resiliencex/chaos"
+)
+
+func TestModule(t *testing.T) {
+	t.Run("module is defined", func(t *testing.T) {
+		assert.NotNil(t, Module)
+	})
+}
+
+func TestNewConfig(t *testing.T) {
+	t.Run("binds over the defaults", func(t *testing.T) {
+		loader, err := configx.NewWithReader(strings.NewReader(`
+resilience:
+  circuit_breaker:
+    failure_threshold: 0.8
+  timeout:
+    duration: 5s
+`))
+		require.NoError(t, err)
+
+		cfg, err := NewConfig(loader)
+		require.NoError(t, err)
+		assert.Equal(t, 0.8, cfg.CircuitBreaker.FailureThreshold)
+		assert.Equal(t, 5*time.Second, cfg.Timeout.Duration)
+		assert.Equal(t, resilience.DefaultRetryConfig().MaxAttempts, cfg.Retry.MaxAttempts)
+	})
+
+	t.Run("rejects invalid breaker configuration", func(t *testing.T) {
+		loader, err := configx.NewWithReader(strings.NewReader(`
+resilience:
+  circuit_breaker:
+    trip_strategy: sometimes
+`))
+		require.NoError(t, err)
+
+		_, err = NewConfig(loader)
+		assert.Error(t, err)
+	})
+}
+
+func TestChaosConfig(t *testing.T) {
+	loader, err := configx.NewWithReader(strings.NewReader(`
+resilience:
+  chaos:
+    scenarios:
+      - name: payments-outage
+        executor: payments
+        fault: open_breaker
+        start: 2026-03-01T10:00:00Z
+        duration: 15m
+      - name: half-rate
+        executor: payments
+        fault: rate_limit
+        duration: 1h
+        ratio: 0.5
+`))
+	require.NoError(t, err)
+
+	var cfg chaos.Config
+	require.NoError(t, loader.Bind(&cfg))
+	assert.Equal(t, []chaos.Scenario{
+		{
+			Name:     "payments-outage",
+			Executor: "payments",
+			Fault:    chaos.FaultOpenBreaker,
+			Start:    time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC),
+			Duration: 15 * time.Minute,
+		},
+		{
+			Name:     "half-rate",
+			Executor: "payments",
+			Fault:    chaos.FaultRateLimit,
+			Duration: time.Hour,
+			Ratio:    0.5,
+		},
+	}, cfg.Scenarios)
+
+	_, err = chaos.NewSchedule(cfg)
+	assert.NoError(t, err)
+}
+
+func TestNewProvider(t *testing.T) {
+	loader, err := configx.NewWithReader(strings.NewReader("resilience: {}\n"))
+	require.NoError(t, err)
+
+	result, err := NewProvider(Params{Config: loader, Logger: logx.NewNoopLogger()})
+	require.NoError(t, err)
+	assert.NotNil(t, result.Builder)
+	assert.Same(t, result.CircuitBreakers.CircuitBreaker("default"), result.CircuitBreakers.CircuitBreaker("default"))
+	assert.Equal(t, PolicyFingerprint(resilience.DefaultConfig().Fingerprint()), result.Fingerprint)
+}
+
+func TestBreakerListeners(t *testing.T) {
+	newLoader := func(t *testing.T) configx.Loader {
+		loader, err := configx.NewWithReader(strings.NewReader(`
+resilience:
+  circuit_breaker:
+    sync_state_change: true
+`))
+		require.NoError(t, err)
+		return loader
+	}
+
+	t.Run("calls every listener in the group", func(t *testing.T) {
+		var events []string
+		listener := func(prefix string) func() resilience.OnStateChange {
+			return func() resilience.OnStateChange {
+				return func(name string, from, to resilience.CircuitState) {
+					events = append(events, fmt.Sprintf("%s %s %s->%s", prefix, name, from, to))
+				}
+			}
+		}
+
+		var breakers resilience.CircuitBreakerRegistry
+		app := fxtest.New(t,
+			fx.Supply(fx.Annotate(newLoader(t), fx.As(new(configx.Loader)))),
+			fx.Supply(fx.Annotate(logx.NewNoopLogger(), fx.As(new(logx.Logger)))),
+			Module(),
+			fx.Provide(
+				AsBreakerListener(listener("metrics")),
+				AsBreakerListener(listener("alerts")),
+			),
+			fx.Populate(&breakers),
+		)
+		app.RequireStart()
+		defer app.RequireStop()
+
+		breakers.CircuitBreaker("payments").Trip()
+		assert.ElementsMatch(t, []string{
+			"metrics payments closed->open",
+			"alerts payments closed->open",
+		}, events)
+	})
+
+	t.Run("works without listeners", func(t *testing.T) {
+		result, err := NewProvider(Params{Config: newLoader(t), Logger: logx.NewNoopLogger()})
+		require.NoError(t, err)
+		breaker := result.CircuitBreakers.CircuitBreaker("payments")
+		breaker.Trip()
+		assert.Equal(t, resilience.StateOpen, breaker.State())
+	})
+}
@@ -0,0 +1,128 @@
+// Package resiliencegobreaker helps teams migrate from sony/gobreaker.
+//
+// FromGobreakerSettings turns gobreaker.Settings into a
+// resilience.CircuitBreakerConfig that trips and recovers the way gobreaker
+// would: the same ReadyToTrip decisions on the same counts, the same
+// half-open trial and open timeout, and every error counted unless
+// IsSuccessful says otherwise. Error maps rejections back to gobreaker's
+// errors for call sites that still check them.
+package resiliencegobreaker
+
+import (
+	"errors"
+	"time"
+
+	"github.com/sony/gobreaker"
+
+	resilience "github.com/gostratum/resiliencex"
+)
+
+const (
+	// DefaultTimeout is gobreaker's open state period when Settings.Timeout
+	// is not positive
+	DefaultTimeout = 60 * time.Second
+
+	// DefaultConsecutiveFailures is the number of failures in a row above
+	// which gobreaker's default ReadyToTrip trips
+	DefaultConsecutiveFailures = 5
+
+	// neverReset stands in for gobreaker's non-positive Interval, which
+	// keeps closed state counts until the circuit changes state
+	neverReset = 100 * 365 * 24 * time.Hour
+)
+
+// FromGobreakerSettings converts gobreaker settings to a circuit breaker
+// configuration with gobreaker's semantics: defaults are gobreaker's,
+// ReadyToTrip becomes the TripPolicy, caller cancellations count as
+// failures, and OnStateChange is called inline with gobreaker states.
+func FromGobreakerSettings(st gobreaker.Settings) resilience.CircuitBreakerConfig {
+	config := resilience.CircuitBreakerConfig{
+		Enabled:                 true,
+		Name:                    st.Name,
+		MaxRequests:             st.MaxRequests,
+		Interval:                st.Interval,
+		WindowType:              resilience.WindowTypeFixed,
+		Timeout:                 st.Timeout,
+		TripMode:                resilience.TripModeBinary,
+		CountCallerCancellation: true,
+		SyncStateChange:         true,
+	}
+	if config.MaxRequests == 0 {
+		config.MaxRequests = 1
+	}
+	config.HalfOpenSuccessThreshold = config.MaxRequests
+	if config.Interval <= 0 {
+		config.Interval = neverReset
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+
+	readyToTrip := st.ReadyToTrip
+	if readyToTrip == nil {
+		readyToTrip = func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures > DefaultConsecutiveFailures
+		}
+	}
+	config.TripPolicy = resilience.TripPolicyFunc(func(counts resilience.Counts, window time.Duration) bool {
+		return readyToTrip(Counts(counts))
+	})
+
+	if st.IsSuccessful != nil {
+		isSuccessful := st.IsSuccessful
+		config.IsFailure = func(err error) bool { return !isSuccessful(err) }
+	}
+
+	if st.OnStateChange != nil {
+		onStateChange := st.OnStateChange
+		config.OnStateChange = func(name string, from, to resilience.CircuitState) {
+			gbFrom, okFrom := State(from)
+			gbTo, okTo := State(to)
+			if okFrom && okTo {
+				onStateChange(name, gbFrom, gbTo)
+			}
+		}
+	}
+
+	return config
+}
+
+// Counts converts circuit breaker statistics to gobreaker counts
+func Counts(counts resilience.Counts) gobreaker.Counts {
+	return gobreaker.Counts{
+		Requests:             counts.Requests,
+		TotalSuccesses:       counts.Successes,
+		TotalFailures:        counts.Failures,
+		ConsecutiveSuccesses: counts.ConsecutiveSuccesses,
+		ConsecutiveFailures:  counts.ConsecutiveFailures,
+	}
+}
+
+// State converts a circuit state to its gobreaker equivalent, reporting
+// false for states gobreaker lacks, such as disabled and degraded
+func State(state resilience.CircuitState) (gobreaker.State, bool) {
+	switch state {
+	case resilience.StateClosed:
+		return gobreaker.StateClosed, true
+	case resilience.StateHalfOpen:
+		return gobreaker.StateHalfOpen, true
+	case resilience.StateOpen:
+		return gobreaker.StateOpen, true
+	default:
+		return 0, false
+	}
+}
+
+// Error maps a circuit breaker rejection to the error gobreaker returns
+// for it: gobreaker.ErrTooManyRequests when half-open trials are used up,
+// gobreaker.ErrOpenState otherwise. Other errors are returned unchanged.
+func Error(err error) error {
+	var openErr *resilience.CircuitOpenError
+	if errors.As(err, &openErr) && openErr.State == resilience.StateHalfOpen {
+		return gobreaker.ErrTooManyRequests
+	}
+	if errors.Is(err, resilience.ErrCircuitOpen) {
+		return gobreaker.ErrOpenState
+	}
+	return err
+}
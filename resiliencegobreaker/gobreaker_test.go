@@ -0,0 +1,81 @@
+package resiliencegobreaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sony/gobreaker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	resilience "github.com/gostratum/resiliencex"
+)
+
+func TestFromGobreakerSettings(t *testing.T) {
+	t.Run("applies gobreaker defaults", func(t *testing.T) {
+		config := FromGobreakerSettings(gobreaker.Settings{Name: "payments"})
+
+		assert.Equal(t, "payments", config.Name)
+		assert.Equal(t, uint32(1), config.MaxRequests)
+		assert.Equal(t, uint32(1), config.HalfOpenSuccessThreshold)
+		assert.Equal(t, DefaultTimeout, config.Timeout)
+		assert.True(t, config.CountCallerCancellation)
+		assert.False(t, config.TripPolicy.ShouldTrip(resilience.Counts{ConsecutiveFailures: 5}, config.Interval))
+		assert.True(t, config.TripPolicy.ShouldTrip(resilience.Counts{ConsecutiveFailures: 6}, config.Interval))
+	})
+
+	t.Run("trips and recovers like gobreaker", func(t *testing.T) {
+		failure := errors.New("failure")
+		ignored := errors.New("ignored")
+		var gbChanges, ourChanges []string
+		settings := func(changes *[]string) gobreaker.Settings {
+			return gobreaker.Settings{
+				Name:        "orders",
+				MaxRequests: 2,
+				Timeout:     20 * time.Millisecond,
+				ReadyToTrip: func(counts gobreaker.Counts) bool {
+					return counts.Requests >= 4 && counts.TotalFailures*2 >= counts.Requests
+				},
+				IsSuccessful: func(err error) bool { return err == nil || errors.Is(err, ignored) },
+				OnStateChange: func(name string, from, to gobreaker.State) {
+					*changes = append(*changes, from.String()+"->"+to.String())
+				},
+			}
+		}
+		gb := gobreaker.NewCircuitBreaker(settings(&gbChanges))
+		cb := resilience.NewCircuitBreaker(FromGobreakerSettings(settings(&ourChanges)))
+
+		step := func(err error) {
+			t.Helper()
+			_, gbErr := gb.Execute(func() (any, error) { return nil, err })
+			ourErr := Error(cb.Execute(context.Background(), func(context.Context) error { return err }))
+			assert.Equal(t, gbErr, ourErr)
+			gbState, _ := State(cb.State())
+			assert.Equal(t, gb.State(), gbState)
+		}
+
+		for _, err := range []error{nil, ignored, failure, nil, failure, failure, nil} {
+			step(err)
+		}
+		time.Sleep(30 * time.Millisecond)
+		for _, err := range []error{nil, failure, nil} {
+			step(err)
+		}
+		time.Sleep(30 * time.Millisecond)
+		for _, err := range []error{nil, nil, nil} {
+			step(err)
+		}
+
+		require.NotEmpty(t, gbChanges)
+		assert.Equal(t, gbChanges, ourChanges)
+	})
+}
+
+func TestError(t *testing.T) {
+	assert.Equal(t, gobreaker.ErrOpenState, Error(&resilience.CircuitOpenError{State: resilience.StateOpen}))
+	assert.Equal(t, gobreaker.ErrTooManyRequests, Error(&resilience.CircuitOpenError{State: resilience.StateHalfOpen}))
+	assert.Equal(t, assert.AnError, Error(assert.AnError))
+	assert.NoError(t, Error(nil))
+}
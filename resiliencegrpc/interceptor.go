@@ -0,0 +1,78 @@
+// Package resiliencegrpc applies resilience executors to gRPC clients.
+//
+// It lives in its own module so the core package does not depend on gRPC.
+package resiliencegrpc
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	resilience "github.com/gostratum/resiliencex"
+)
+
+const (
+	// PushbackTrailer is the standard gRPC retry pushback trailer (gRFC A6);
+	// a negative or malformed value means the client must not retry
+	PushbackTrailer = "grpc-retry-pushback-ms"
+
+	// GiveUpTrailer lets servers explicitly ask clients not to retry.
+	// Any value other than "false" or "0" signals give-up.
+	GiveUpTrailer = "x-resilience-no-retry"
+)
+
+// UnaryClientInterceptor executes unary calls under the executor and stops
+// retrying when the server pushes back through the call trailers
+func UnaryClientInterceptor(executor resilience.Executor) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return executor.Execute(ctx, func(ctx context.Context) error {
+			var trailer metadata.MD
+			callOpts := append(opts[:len(opts):len(opts)], grpc.Trailer(&trailer))
+
+			err := invoker(ctx, method, req, reply, cc, callOpts...)
+			if err != nil && ShouldGiveUp(trailer) {
+				resilience.GiveUp(ctx)
+			}
+			return err
+		})
+	}
+}
+
+// ShouldGiveUp reports whether the trailer asks the client not to retry
+func ShouldGiveUp(trailer metadata.MD) bool {
+	if values := trailer.Get(GiveUpTrailer); len(values) > 0 {
+		v := strings.TrimSpace(values[0])
+		if v != "" && v != "false" && v != "0" {
+			return true
+		}
+	}
+
+	if values := trailer.Get(PushbackTrailer); len(values) > 0 {
+		ms, err := strconv.Atoi(strings.TrimSpace(values[0]))
+		return err != nil || ms < 0
+	}
+
+	return false
+}
+
+// RetryAfter reads the delay of a RetryInfo detail in a gRPC status error.
+// Set it as RetryConfig.RetryAfterFunc of the interceptor's executor so
+// retries wait as long as the server asks.
+func RetryAfter(err error) (time.Duration, bool) {
+	st, ok := status.FromError(err)
+	if !ok {
+		return 0, false
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok && info.GetRetryDelay() != nil {
+			return max(info.GetRetryDelay().AsDuration(), 0), true
+		}
+	}
+	return 0, false
+}
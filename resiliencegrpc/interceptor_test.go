@@ -0,0 +1,109 @@
+package resiliencegrpc
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	resilience "github.com/gostratum/resiliencex"
+)
+
+// healthServer fails every check, optionally pushing back
+type healthServer struct {
+	healthpb.UnimplementedHealthServer
+	calls    atomic.Int32
+	pushback string
+}
+
+func (s *healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	s.calls.Add(1)
+	if s.pushback != "" {
+		grpc.SetTrailer(ctx, metadata.Pairs(PushbackTrailer, s.pushback))
+	}
+	return nil, status.Error(codes.Unavailable, "unavailable")
+}
+
+func dial(t *testing.T, srv *healthServer) healthpb.HealthClient {
+	t.Helper()
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, srv)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	executor := resilience.NewBuilder().
+		WithRetry(resilience.RetryConfig{Name: "grpc", MaxAttempts: 3, InitialInterval: time.Millisecond}).
+		Build()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor(executor)),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return healthpb.NewHealthClient(conn)
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	t.Run("retries failed calls", func(t *testing.T) {
+		srv := &healthServer{}
+		_, err := dial(t, srv).Check(context.Background(), &healthpb.HealthCheckRequest{})
+
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		assert.Equal(t, int32(3), srv.calls.Load())
+	})
+
+	t.Run("stops on negative pushback", func(t *testing.T) {
+		srv := &healthServer{pushback: "-1"}
+		_, err := dial(t, srv).Check(context.Background(), &healthpb.HealthCheckRequest{})
+
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		assert.Equal(t, int32(1), srv.calls.Load())
+	})
+}
+
+func TestShouldGiveUp(t *testing.T) {
+	assert.False(t, ShouldGiveUp(nil))
+	assert.False(t, ShouldGiveUp(metadata.Pairs(PushbackTrailer, "100")))
+	assert.True(t, ShouldGiveUp(metadata.Pairs(PushbackTrailer, "-1")))
+	assert.True(t, ShouldGiveUp(metadata.Pairs(PushbackTrailer, "soon")))
+	assert.True(t, ShouldGiveUp(metadata.Pairs(GiveUpTrailer, "true")))
+	assert.False(t, ShouldGiveUp(metadata.Pairs(GiveUpTrailer, "false")))
+}
+
+func TestRetryAfter(t *testing.T) {
+	t.Run("reads the RetryInfo delay", func(t *testing.T) {
+		st, err := status.New(codes.ResourceExhausted, "slow down").WithDetails(&errdetails.RetryInfo{
+			RetryDelay: durationpb.New(3 * time.Second),
+		})
+		require.NoError(t, err)
+
+		delay, ok := RetryAfter(st.Err())
+		assert.True(t, ok)
+		assert.Equal(t, 3*time.Second, delay)
+	})
+
+	t.Run("ignores errors without RetryInfo", func(t *testing.T) {
+		_, ok := RetryAfter(status.Error(codes.Unavailable, "unavailable"))
+		assert.False(t, ok)
+		_, ok = RetryAfter(assert.AnError)
+		assert.False(t, ok)
+	})
+}
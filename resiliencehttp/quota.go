@@ -0,0 +1,101 @@
+package resiliencehttp
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	resilience "github.com/gostratum/resiliencex"
+)
+
+// Standard rate limit response headers (IETF httpapi RateLimit fields)
+const (
+	RateLimitHeader          = "RateLimit"
+	RateLimitPolicyHeader    = "RateLimit-Policy"
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+)
+
+// ParseQuota reads the server's rate limit quota from response headers.
+// It understands the combined RateLimit field, both as
+// `limit=100, remaining=50, reset=30` and as `"policy";r=50;t=30` with the
+// limit from RateLimit-Policy `q`, and the separate RateLimit-Limit,
+// RateLimit-Remaining and RateLimit-Reset fields. Reset is in seconds.
+// It returns false when no remaining quota is advertised.
+func ParseQuota(h http.Header) (resilience.Quota, bool) {
+	if v := h.Get(RateLimitHeader); v != "" {
+		params := fieldParams(v)
+		if remaining, ok := intParam(params, "remaining", "r"); ok {
+			quota := resilience.Quota{Remaining: remaining}
+			if limit, ok := intParam(params, "limit"); ok {
+				quota.Limit = limit
+			} else if limit, ok := intParam(fieldParams(h.Get(RateLimitPolicyHeader)), "q"); ok {
+				quota.Limit = limit
+			}
+			if reset, ok := intParam(params, "reset", "t"); ok {
+				quota.Reset = time.Duration(reset) * time.Second
+			}
+			return quota, true
+		}
+	}
+
+	remaining, ok := leadingInt(h.Get(RateLimitRemainingHeader))
+	if !ok {
+		return resilience.Quota{}, false
+	}
+	quota := resilience.Quota{Remaining: remaining}
+	if limit, ok := leadingInt(h.Get(RateLimitLimitHeader)); ok {
+		quota.Limit = limit
+	}
+	if reset, ok := leadingInt(h.Get(RateLimitResetHeader)); ok {
+		quota.Reset = time.Duration(reset) * time.Second
+	}
+	return quota, true
+}
+
+// fieldParams collects the key=value pairs of a RateLimit field. Members
+// with parameters (`"policy";r=50;t=30`) use the first member's parameters;
+// otherwise every member is a pair (`limit=100, remaining=50, reset=30`).
+func fieldParams(v string) map[string]string {
+	params := map[string]string{}
+	parts := strings.Split(v, ",")
+	if first, _, _ := strings.Cut(v, ","); strings.Contains(first, ";") {
+		parts = strings.Split(first, ";")
+	}
+	for _, part := range parts {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		params[strings.ToLower(strings.TrimSpace(key))] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+	return params
+}
+
+// intParam returns the first of keys present in params as a non-negative int
+func intParam(params map[string]string, keys ...string) (int, bool) {
+	for _, key := range keys {
+		if v, ok := params[key]; ok {
+			n, err := strconv.Atoi(v)
+			if err == nil && n >= 0 {
+				return n, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// leadingInt parses the integer before any list or parameter separator,
+// e.g. "100, 100;w=60" gives 100
+func leadingInt(v string) (int, bool) {
+	if i := strings.IndexAny(v, ",;"); i >= 0 {
+		v = v[:i]
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,81 @@
+package resiliencehttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	resilience "github.com/gostratum/resiliencex"
+)
+
+func TestParseQuota(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   resilience.Quota
+		ok     bool
+	}{
+		{
+			name: "separate fields",
+			header: http.Header{
+				"Ratelimit-Limit":     {"100, 100;w=60"},
+				"Ratelimit-Remaining": {"42"},
+				"Ratelimit-Reset":     {"30"},
+			},
+			want: resilience.Quota{Limit: 100, Remaining: 42, Reset: 30 * time.Second},
+			ok:   true,
+		},
+		{
+			name:   "combined field",
+			header: http.Header{"Ratelimit": {"limit=100, remaining=0, reset=12"}},
+			want:   resilience.Quota{Limit: 100, Remaining: 0, Reset: 12 * time.Second},
+			ok:     true,
+		},
+		{
+			name: "structured field with policy",
+			header: http.Header{
+				"Ratelimit":        {`"default";r=7;t=5`},
+				"Ratelimit-Policy": {`"default";q=50;w=60`},
+			},
+			want: resilience.Quota{Limit: 50, Remaining: 7, Reset: 5 * time.Second},
+			ok:   true,
+		},
+		{
+			name:   "no quota",
+			header: http.Header{"Ratelimit-Limit": {"100"}},
+			ok:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseQuota(tt.header)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTransportQuota(t *testing.T) {
+	t.Run("exhausted server quota blocks the shared limiter", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(RateLimitHeader, "limit=10, remaining=0, reset=60")
+		}))
+		defer server.Close()
+
+		limiter := resilience.NewRateLimiter(resilience.RateLimiterConfig{Name: "api", Rate: 100, Burst: 10})
+		exec := resilience.NewBuilder().WithSharedRateLimiter(limiter).Build()
+		client := &http.Client{Transport: &Transport{Executor: exec, RateLimiter: limiter}}
+
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		assert.False(t, limiter.Allow())
+		assert.Equal(t, 0.0, limiter.Stats().Tokens)
+	})
+}
@@ -0,0 +1,43 @@
+package resiliencehttp
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryAfterHeader is the header servers send with 429 and 503 responses
+// to say when to retry
+const RetryAfterHeader = "Retry-After"
+
+// ParseRetryAfter reads the Retry-After header, given either in seconds or
+// as an HTTP date, which is taken relative to now
+func ParseRetryAfter(h http.Header, now time.Time) (time.Duration, bool) {
+	v := strings.TrimSpace(h.Get(RetryAfterHeader))
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(v); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// RetryAfter reads Retry-After from the response of a StatusError. Set it
+// as RetryConfig.RetryAfterFunc of a Transport's executor so retries wait
+// as long as the server asks.
+func RetryAfter(err error) (time.Duration, bool) {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return 0, false
+	}
+	return ParseRetryAfter(statusErr.Response.Header, time.Now())
+}
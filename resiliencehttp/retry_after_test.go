@@ -0,0 +1,75 @@
+package resiliencehttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	resilience "github.com/gostratum/resiliencex"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{name: "seconds", value: "120", want: 2 * time.Minute, ok: true},
+		{name: "http date", value: now.Add(30 * time.Second).Format(http.TimeFormat), want: 30 * time.Second, ok: true},
+		{name: "past date", value: now.Add(-time.Minute).Format(http.TimeFormat), want: 0, ok: true},
+		{name: "negative", value: "-1"},
+		{name: "malformed", value: "soon"},
+		{name: "missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.value != "" {
+				header.Set(RetryAfterHeader, tt.value)
+			}
+			got, ok := ParseRetryAfter(header, now)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTransportRetryAfter(t *testing.T) {
+	t.Run("retries when the server asks", func(t *testing.T) {
+		var times []time.Time
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			times = append(times, time.Now())
+			if len(times) == 1 {
+				w.Header().Set(RetryAfterHeader, "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+			}
+		}))
+		defer server.Close()
+
+		exec := resilience.NewBuilder().WithRetry(resilience.RetryConfig{
+			MaxAttempts:     2,
+			InitialInterval: time.Millisecond,
+			RetryAfterFunc:  RetryAfter,
+		}).Build()
+		client := &http.Client{Transport: NewTransport(nil, exec)}
+
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Len(t, times, 2)
+		assert.GreaterOrEqual(t, times[1].Sub(times[0]), time.Second)
+	})
+
+	t.Run("ignores other errors", func(t *testing.T) {
+		_, ok := RetryAfter(assert.AnError)
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,151 @@
+// Package resiliencehttp applies resilience executors to HTTP clients.
+package resiliencehttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	resilience "github.com/gostratum/resiliencex"
+)
+
+// GiveUpHeader is the response header servers set to tell clients not to
+// retry a request. Any value other than "false" or "0" signals give-up.
+const GiveUpHeader = "X-Resilience-No-Retry"
+
+// StatusError reports a response that was classified as a failure
+type StatusError struct {
+	// Response is the failed response; its body is closed before a retry
+	Response *http.Response
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("resiliencehttp: %s %s: %s", e.Response.Request.Method, e.Response.Request.URL.Redacted(), e.Response.Status)
+}
+
+// Transport is an http.RoundTripper that sends requests under an Executor.
+// Failed responses are returned to the caller as responses (not errors)
+// once the executor gives up, matching net/http semantics.
+type Transport struct {
+	// Base is the underlying transport (http.DefaultTransport when nil)
+	Base http.RoundTripper
+
+	// Executor applies the resilience patterns
+	Executor resilience.Executor
+
+	// IsFailure classifies responses; the default treats 429 and 5xx as failures
+	IsFailure func(*http.Response) bool
+
+	// ShouldGiveUp reports whether the server asked not to retry; the
+	// default checks GiveUpHeader
+	ShouldGiveUp func(*http.Response) bool
+
+	// RateLimiter, when set, is fed the quota from RateLimit response
+	// headers; share it with Executor via Builder.WithSharedRateLimiter so
+	// client-side limiting follows the server's windows
+	RateLimiter resilience.RateLimiter
+}
+
+// NewTransport creates a Transport with default classification
+func NewTransport(base http.RoundTripper, executor resilience.Executor) *Transport {
+	return &Transport{Base: base, Executor: executor}
+}
+
+// DefaultIsFailure treats 429 Too Many Requests and 5xx responses as failures
+func DefaultIsFailure(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// DefaultShouldGiveUp reports whether the response carries GiveUpHeader
+func DefaultShouldGiveUp(resp *http.Response) bool {
+	v := strings.TrimSpace(resp.Header.Get(GiveUpHeader))
+	return v != "" && v != "false" && v != "0"
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	isFailure := t.IsFailure
+	if isFailure == nil {
+		isFailure = DefaultIsFailure
+	}
+	shouldGiveUp := t.ShouldGiveUp
+	if shouldGiveUp == nil {
+		shouldGiveUp = DefaultShouldGiveUp
+	}
+
+	var last *http.Response
+	attempt := 0
+	result, err := t.Executor.ExecuteWithResult(req.Context(), func(ctx context.Context) (any, error) {
+		// Release the previous failed response before retrying
+		if last != nil {
+			drainAndClose(last.Body)
+			last = nil
+		}
+
+		attemptReq, err := rewind(req.WithContext(ctx), attempt)
+		attempt++
+		if err != nil {
+			resilience.GiveUp(ctx)
+			return nil, err
+		}
+
+		resp, err := base.RoundTrip(attemptReq)
+		if err != nil {
+			return nil, err
+		}
+		if t.RateLimiter != nil {
+			if quota, ok := ParseQuota(resp.Header); ok {
+				t.RateLimiter.ApplyQuota(quota)
+			}
+		}
+		if shouldGiveUp(resp) {
+			resilience.GiveUp(ctx)
+		}
+		if isFailure(resp) {
+			last = resp
+			return resp, &StatusError{Response: resp}
+		}
+		return resp, nil
+	})
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Response, nil
+	}
+	if err != nil {
+		if last != nil {
+			drainAndClose(last.Body)
+		}
+		return nil, err
+	}
+	return result.(*http.Response), nil
+}
+
+// rewind returns a request whose body can be sent again for retries
+func rewind(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 0 || req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	if req.GetBody == nil {
+		return nil, errors.New("resiliencehttp: request body cannot be retried (GetBody is nil)")
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("resiliencehttp: rewind body: %w", err)
+	}
+	clone := req.Clone(req.Context())
+	clone.Body = body
+	return clone, nil
+}
+
+func drainAndClose(body io.ReadCloser) {
+	io.Copy(io.Discard, io.LimitReader(body, 4096))
+	body.Close()
+}
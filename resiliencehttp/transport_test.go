@@ -0,0 +1,110 @@
+package resiliencehttp
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	resilience "github.com/gostratum/resiliencex"
+)
+
+func newRetryExecutor() resilience.Executor {
+	return resilience.NewBuilder().
+		WithRetry(resilience.RetryConfig{Name: "http", MaxAttempts: 3, InitialInterval: time.Millisecond}).
+		Build()
+}
+
+func TestTransport(t *testing.T) {
+	t.Run("retries failed responses", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			assert.Equal(t, "payload", string(body))
+			if calls.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte("ok"))
+		}))
+		defer server.Close()
+
+		client := &http.Client{Transport: NewTransport(nil, newRetryExecutor())}
+		resp, err := client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "ok", string(body))
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("returns last failed response when retries are exhausted", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte("bad gateway"))
+		}))
+		defer server.Close()
+
+		client := &http.Client{Transport: NewTransport(nil, newRetryExecutor())}
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+		assert.Equal(t, "bad gateway", string(body))
+	})
+
+	t.Run("honors give-up header", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.Header().Set(GiveUpHeader, "true")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		client := &http.Client{Transport: NewTransport(nil, newRetryExecutor())}
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("does not retry successful client errors", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		client := &http.Client{Transport: NewTransport(nil, newRetryExecutor())}
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+}
+
+func TestDefaultShouldGiveUp(t *testing.T) {
+	for value, expected := range map[string]bool{"": false, "false": false, "0": false, "true": true, "1": true} {
+		resp := &http.Response{Header: http.Header{}}
+		if value != "" {
+			resp.Header.Set(GiveUpHeader, value)
+		}
+		assert.Equal(t, expected, DefaultShouldGiveUp(resp), value)
+	}
+}
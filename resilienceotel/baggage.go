@@ -0,0 +1,65 @@
+// Package resilienceotel tags retried and hedged attempts with OpenTelemetry
+// baggage, so downstream traces can be filtered to duplicated work, and
+// exports resilience metrics as OpenTelemetry instruments following the
+// standard metrics schema.
+//
+// It lives in its own module so the core package does not depend on
+// OpenTelemetry.
+package resilienceotel
+
+import (
+	"context"
+	"strconv"
+
+	"go.opentelemetry.io/otel/baggage"
+
+	resilience "github.com/gostratum/resiliencex"
+)
+
+// Baggage member keys set on retried and hedged attempts
+const (
+	// KeyExecutor holds the executor name
+	KeyExecutor = "resilience.executor"
+
+	// KeyRetry holds the retry number, from 1
+	KeyRetry = "resilience.retry"
+
+	// KeyHedge holds the hedge number, from 1
+	KeyHedge = "resilience.hedge"
+)
+
+// BaggageHook returns an AttemptHook that adds the executor name and the
+// retry and hedge numbers of an attempt to the context's baggage. Members
+// are only added to retries and hedges, and a number only when non-zero, so
+// first attempts propagate the caller's baggage unchanged. Install it with
+// Builder.WithAttemptHook.
+func BaggageHook() resilience.AttemptHook {
+	return func(ctx context.Context, attempt resilience.Attempt) context.Context {
+		bag := baggage.FromContext(ctx)
+		bag = setMember(bag, KeyExecutor, attempt.Executor)
+		if attempt.Retry > 0 {
+			bag = setMember(bag, KeyRetry, strconv.Itoa(attempt.Retry))
+		}
+		if attempt.Hedge > 0 {
+			bag = setMember(bag, KeyHedge, strconv.Itoa(attempt.Hedge))
+		}
+		return baggage.ContextWithBaggage(ctx, bag)
+	}
+}
+
+// setMember returns bag with key set to value; invalid members and baggage
+// over the W3C size limits leave bag unchanged
+func setMember(bag baggage.Baggage, key, value string) baggage.Baggage {
+	if value == "" {
+		return bag
+	}
+	member, err := baggage.NewMemberRaw(key, value)
+	if err != nil {
+		return bag
+	}
+	next, err := bag.SetMember(member)
+	if err != nil {
+		return bag
+	}
+	return next
+}
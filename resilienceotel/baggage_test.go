@@ -0,0 +1,52 @@
+package resilienceotel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/baggage"
+
+	resilience "github.com/gostratum/resiliencex"
+)
+
+func TestBaggageHook(t *testing.T) {
+	t.Run("tags retried attempts", func(t *testing.T) {
+		caller, _ := baggage.NewMemberRaw("tenant", "acme")
+		bag, _ := baggage.New(caller)
+		ctx := baggage.ContextWithBaggage(context.Background(), bag)
+
+		exec := resilience.NewBuilder().
+			WithName("payments").
+			WithRetry(resilience.RetryConfig{MaxAttempts: 2, InitialInterval: time.Millisecond}).
+			WithAttemptHook(BaggageHook()).
+			Build()
+
+		var seen []baggage.Baggage
+		_ = exec.Execute(ctx, func(ctx context.Context) error {
+			seen = append(seen, baggage.FromContext(ctx))
+			return errors.New("error")
+		})
+
+		assert.Len(t, seen, 2)
+		assert.Equal(t, "acme", seen[0].Member("tenant").Value())
+		assert.Empty(t, seen[0].Member(KeyRetry).Value())
+		assert.Empty(t, seen[0].Member(KeyExecutor).Value())
+
+		assert.Equal(t, "acme", seen[1].Member("tenant").Value())
+		assert.Equal(t, "payments", seen[1].Member(KeyExecutor).Value())
+		assert.Equal(t, "1", seen[1].Member(KeyRetry).Value())
+		assert.Empty(t, seen[1].Member(KeyHedge).Value())
+	})
+
+	t.Run("tags hedged attempts", func(t *testing.T) {
+		ctx := BaggageHook()(context.Background(), resilience.Attempt{Executor: "search", Hedge: 2})
+
+		bag := baggage.FromContext(ctx)
+		assert.Equal(t, "search", bag.Member(KeyExecutor).Value())
+		assert.Equal(t, "2", bag.Member(KeyHedge).Value())
+		assert.Empty(t, bag.Member(KeyRetry).Value())
+	})
+}
@@ -0,0 +1,110 @@
+package resilienceotel
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	resilience "github.com/gostratum/resiliencex"
+)
+
+// MetricsRecorder exports executor, retry and circuit breaker metrics as
+// OpenTelemetry instruments named and labelled after resilience.MetricsSchema,
+// so dashboards from resilience.GrafanaDashboard work on its output. It
+// implements resilience.MetricsRecorder, resilience.RetryMetricsRecorder and
+// resilience.BreakerMetricsRecorder; StateChange can be installed as a
+// breaker's OnStateChange to export its state and transitions.
+type MetricsRecorder struct {
+	calls         metric.Int64Counter
+	duration      metric.Float64Histogram
+	state         metric.Int64Gauge
+	transitions   metric.Int64Counter
+	stateSeconds  metric.Float64Counter
+	openings      metric.Int64Gauge
+	retryAttempts metric.Int64Counter
+	retryCalls    metric.Int64Counter
+}
+
+// NewMetricsRecorder creates the schema instruments on meter
+func NewMetricsRecorder(meter metric.Meter) (*MetricsRecorder, error) {
+	help := make(map[string]string)
+	for _, m := range resilience.MetricsSchema() {
+		help[m.Name] = m.Help
+	}
+
+	var r MetricsRecorder
+	var errs [8]error
+	r.calls, errs[0] = meter.Int64Counter(resilience.MetricCallsTotal,
+		metric.WithDescription(help[resilience.MetricCallsTotal]))
+	r.duration, errs[1] = meter.Float64Histogram(resilience.MetricCallDuration,
+		metric.WithDescription(help[resilience.MetricCallDuration]), metric.WithUnit("s"))
+	r.state, errs[2] = meter.Int64Gauge(resilience.MetricCircuitBreakerState,
+		metric.WithDescription(help[resilience.MetricCircuitBreakerState]))
+	r.transitions, errs[3] = meter.Int64Counter(resilience.MetricCircuitBreakerTransitions,
+		metric.WithDescription(help[resilience.MetricCircuitBreakerTransitions]))
+	r.stateSeconds, errs[4] = meter.Float64Counter(resilience.MetricCircuitBreakerStateSeconds,
+		metric.WithDescription(help[resilience.MetricCircuitBreakerStateSeconds]), metric.WithUnit("s"))
+	r.openings, errs[5] = meter.Int64Gauge(resilience.MetricCircuitBreakerOpenings,
+		metric.WithDescription(help[resilience.MetricCircuitBreakerOpenings]))
+	r.retryAttempts, errs[6] = meter.Int64Counter(resilience.MetricRetryAttemptsTotal,
+		metric.WithDescription(help[resilience.MetricRetryAttemptsTotal]))
+	r.retryCalls, errs[7] = meter.Int64Counter(resilience.MetricRetryCallsTotal,
+		metric.WithDescription(help[resilience.MetricRetryCallsTotal]))
+	for _, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("resilienceotel: create instrument: %w", err)
+		}
+	}
+	return &r, nil
+}
+
+// RecordCall records a call's outcome and latency; extra labels from a
+// LabelExtractor are added as attributes
+func (r *MetricsRecorder) RecordCall(call resilience.CallMetrics) {
+	attrs := make([]attribute.KeyValue, 0, len(call.Labels)+2)
+	attrs = append(attrs, attribute.String(resilience.LabelName, call.Name))
+	for k, v := range call.Labels {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+	r.duration.Record(context.Background(), call.Duration.Seconds(), metric.WithAttributes(attrs...))
+
+	attrs = append(attrs, attribute.String(resilience.LabelOutcome, string(call.Outcome)))
+	r.calls.Add(context.Background(), 1, metric.WithAttributes(attrs...))
+}
+
+// RecordRetry records a retried call's outcome and its attempts after the
+// first
+func (r *MetricsRecorder) RecordRetry(name string, outcome resilience.RetryOutcome, attempts int) {
+	nameAttr := attribute.String(resilience.LabelName, name)
+	r.retryCalls.Add(context.Background(), 1, metric.WithAttributes(
+		nameAttr, attribute.String(resilience.LabelOutcome, string(outcome))))
+	if attempts > 1 {
+		r.retryAttempts.Add(context.Background(), int64(attempts-1), metric.WithAttributes(nameAttr))
+	}
+}
+
+// RecordStateDuration adds the time a breaker spent in state
+func (r *MetricsRecorder) RecordStateDuration(name string, state resilience.CircuitState, d time.Duration) {
+	r.stateSeconds.Add(context.Background(), d.Seconds(), metric.WithAttributes(
+		attribute.String(resilience.LabelName, name),
+		attribute.String(resilience.LabelState, state.String())))
+}
+
+// RecordOpenings records a breaker's openings during the last interval
+func (r *MetricsRecorder) RecordOpenings(name string, openings int, interval time.Duration) {
+	r.openings.Record(context.Background(), int64(openings), metric.WithAttributes(
+		attribute.String(resilience.LabelName, name)))
+}
+
+// StateChange records a breaker transition and its new state; it has the
+// signature of resilience.OnStateChange
+func (r *MetricsRecorder) StateChange(name string, from, to resilience.CircuitState) {
+	nameAttr := attribute.String(resilience.LabelName, name)
+	r.state.Record(context.Background(), int64(to), metric.WithAttributes(nameAttr))
+	r.transitions.Add(context.Background(), 1, metric.WithAttributes(nameAttr,
+		attribute.String(resilience.LabelFrom, from.String()),
+		attribute.String(resilience.LabelTo, to.String())))
+}
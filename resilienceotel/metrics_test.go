@@ -0,0 +1,104 @@
+package resilienceotel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	resilience "github.com/gostratum/resiliencex"
+)
+
+func TestMetricsRecorder(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	recorder, err := NewMetricsRecorder(provider.Meter("resilience"))
+	require.NoError(t, err)
+
+	breakerConfig := resilience.DefaultCircuitBreakerConfig()
+	breakerConfig.Name = "payments"
+	breakerConfig.MinRequests = 1
+	breakerConfig.FailureThreshold = 0.5
+	breakerConfig.MetricsRecorder = recorder
+	breakerConfig.OnStateChange = recorder.StateChange
+	breakerConfig.SyncStateChange = true
+	exec := resilience.NewBuilder().
+		WithName("payments").
+		WithCircuitBreaker(breakerConfig).
+		WithRetry(resilience.RetryConfig{Name: "payments", MaxAttempts: 2, InitialInterval: time.Millisecond, MetricsRecorder: recorder}).
+		WithMetrics(resilience.MetricsConfig{Recorder: recorder}).
+		Build()
+	_ = exec.Execute(context.Background(), func(context.Context) error {
+		return errors.New("error")
+	})
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+
+	schema := make(map[string]resilience.MetricDescriptor)
+	for _, m := range resilience.MetricsSchema() {
+		schema[m.Name] = m
+	}
+	seen := make(map[string]bool)
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		descriptor, ok := schema[m.Name]
+		if !assert.True(t, ok, "metric %s is not in the schema", m.Name) {
+			continue
+		}
+		seen[m.Name] = true
+		assert.Equal(t, descriptor.Help, m.Description)
+		for _, keys := range attributeKeys(m.Data) {
+			assert.ElementsMatch(t, descriptor.Labels, keys, "labels of %s", m.Name)
+		}
+	}
+	for _, name := range []string{
+		resilience.MetricCallsTotal,
+		resilience.MetricCallDuration,
+		resilience.MetricCircuitBreakerState,
+		resilience.MetricCircuitBreakerTransitions,
+		resilience.MetricRetryAttemptsTotal,
+		resilience.MetricRetryCallsTotal,
+	} {
+		assert.True(t, seen[name], "metric %s not recorded", name)
+	}
+}
+
+// attributeKeys returns the attribute keys of every data point
+func attributeKeys(data metricdata.Aggregation) [][]string {
+	var sets []attribute.Set
+	switch d := data.(type) {
+	case metricdata.Sum[int64]:
+		for _, p := range d.DataPoints {
+			sets = append(sets, p.Attributes)
+		}
+	case metricdata.Sum[float64]:
+		for _, p := range d.DataPoints {
+			sets = append(sets, p.Attributes)
+		}
+	case metricdata.Gauge[int64]:
+		for _, p := range d.DataPoints {
+			sets = append(sets, p.Attributes)
+		}
+	case metricdata.Histogram[float64]:
+		for _, p := range d.DataPoints {
+			sets = append(sets, p.Attributes)
+		}
+	}
+
+	keys := make([][]string, 0, len(sets))
+	for _, set := range sets {
+		var k []string
+		for _, kv := range set.ToSlice() {
+			k = append(k, string(kv.Key))
+		}
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -2,6 +2,7 @@ package resilience
 
 import (
 	"context"
+	"errors"
 	"math/rand"
 	"time"
 )
@@ -29,6 +30,9 @@ func NewRetry(config RetryConfig) Retry {
 	if config.RandomizationFactor == 0 {
 		config.RandomizationFactor = DefaultRetryConfig().RandomizationFactor
 	}
+	if config.ShouldRetry == nil {
+		config.ShouldRetry = DefaultShouldRetry
+	}
 
 	return &retry{
 		config: config,
@@ -46,22 +50,78 @@ func (r *retry) Name() string {
 }
 
 func (r *retry) Execute(ctx context.Context, fn func(context.Context) error) error {
+	_, err := r.ExecuteWithResult(ctx, func(ctx context.Context) (any, error) {
+		return nil, fn(ctx)
+	})
+	return err
+}
+
+func (r *retry) ExecuteWithResult(ctx context.Context, fn func(context.Context) (any, error)) (any, error) {
+	clock := clockOrSystem(r.config.Clock)
+	start := clock.Now()
+	result, attempts, err := r.run(ctx, fn, clock, start)
+	if r.config.OnGiveUp != nil || r.config.MetricsRecorder != nil {
+		r.report(attempts, clock.Now().Sub(start), err)
+	}
+	return result, err
+}
+
+// report passes the outcome of a call to OnGiveUp and the MetricsRecorder
+func (r *retry) report(attempts int, elapsed time.Duration, err error) {
+	if err != nil && r.config.OnGiveUp != nil {
+		r.config.OnGiveUp(attempts, elapsed, err)
+	}
+	if r.config.MetricsRecorder == nil {
+		return
+	}
+	var outcome RetryOutcome
+	_, exhausted := err.(*RetryExhaustedError)
+	switch {
+	case err == nil && attempts <= 1:
+		outcome = RetryOutcomeFirstAttempt
+	case err == nil:
+		outcome = RetryOutcomeRetried
+	case exhausted:
+		outcome = RetryOutcomeExhausted
+	default:
+		outcome = RetryOutcomeAborted
+	}
+	r.config.MetricsRecorder.RecordRetry(r.config.Name, outcome, attempts)
+}
+
+// run makes the attempts of a call that started at start, returning the
+// last result, the number of attempts made and the error to return
+func (r *retry) run(ctx context.Context, fn func(context.Context) (any, error), clock Clock, start time.Time) (any, int, error) {
+	var result any
 	var lastErr error
+	var errs []error
+	ctx, giveUp := withGiveUpSignal(ctx)
 
 	for attempt := 0; attempt < r.config.MaxAttempts; attempt++ {
 		// Execute the function
-		err := fn(ctx)
+		var err error
+		result, err = r.attempt(withRetryAttempt(ctx, attempt, r.config.MaxAttempts, lastErr), attempt, clock, fn)
+		retryable := r.retryable(result, err)
 
 		// Success - no retry needed
+		if err == nil && !retryable {
+			return result, attempt + 1, nil
+		}
 		if err == nil {
-			return nil
+			err = ErrResultRejected
 		}
 
 		lastErr = err
+		errs = append(errs, err)
+
+		// Downstream asked us to stop retrying
+		if giveUp.isSet() {
+			return result, attempt + 1, err
+		}
 
 		// Check if we should retry this error
-		if r.config.ShouldRetry != nil && !r.config.ShouldRetry(err) {
-			return err
+		if !retryable {
+			return result, attempt + 1, err
 		}
 
 		// Check if this was the last attempt
@@ -69,24 +129,92 @@ func (r *retry) Execute(ctx context.Context, fn func(context.Context) error) err
 			break
 		}
 
+		// Calculate backoff delay, unless the server said when to retry
+		delay := r.backoff.Next(attempt)
+		if r.config.RetryAfterFunc != nil {
+			if after, ok := r.config.RetryAfterFunc(err); ok {
+				delay = max(after, 0)
+			}
+		}
+
+		// Give up when waiting would exceed the elapsed time budget
+		if r.config.MaxElapsedTime > 0 && clock.Now().Sub(start)+delay > r.config.MaxElapsedTime {
+			break
+		}
+
+		// Fail fast rather than sleep past the deadline
+		if deadline, ok := ctx.Deadline(); ok {
+			if remaining := deadline.Sub(clock.Now()); delay >= remaining {
+				return result, attempt + 1, &BackoffDeadlineError{Delay: delay, Remaining: max(remaining, 0), Err: err}
+			}
+		}
+
 		// Call retry callback
 		if r.config.OnRetry != nil {
 			r.config.OnRetry(attempt+1, err)
 		}
 
-		// Calculate backoff delay
-		delay := r.backoff.Next(attempt)
-
 		// Wait for backoff or context cancellation
-		select {
-		case <-time.After(delay):
-			// Continue to next attempt
-		case <-ctx.Done():
-			return ctx.Err()
+		if err := sleep(ctx, delay, r.config.TimerWheel, r.config.Clock); err != nil {
+			return result, attempt + 1, err
 		}
 	}
 
-	return lastErr
+	if lastErr == nil {
+		return result, len(errs), nil
+	}
+	return result, len(errs), &RetryExhaustedError{
+		Attempts: len(errs),
+		Elapsed:  clock.Now().Sub(start),
+		Errors:   errs,
+		Err:      lastErr,
+	}
+}
+
+// retryable reports whether an attempt with result and err should be
+// retried
+func (r *retry) retryable(result any, err error) bool {
+	if r.config.ShouldRetryResult != nil {
+		return r.config.ShouldRetryResult(result, err)
+	}
+	return err != nil && r.config.ShouldRetry(err)
+}
+
+// attempt runs fn once, bounded by AttemptTimeout when set. An attempt
+// that gives up because its own timeout expired fails with ErrTimeout,
+// leaving the caller's context free for the next attempt.
+func (r *retry) attempt(ctx context.Context, attempt int, clock Clock, fn func(context.Context) (any, error)) (any, error) {
+	if attempt == 0 {
+		firstCtx, cancel := r.firstAttemptContext(ctx, clock)
+		defer cancel()
+		ctx = firstCtx
+	}
+	if r.config.AttemptTimeout <= 0 {
+		return fn(ctx)
+	}
+
+	attemptCtx, cancel := withTimeout(ctx, clock, r.config.AttemptTimeout)
+	defer cancel()
+	result, err := fn(attemptCtx)
+	if err != nil && contextError(err) && context.Cause(attemptCtx) == ErrTimeout {
+		return result, ErrTimeout
+	}
+	return result, err
+}
+
+// firstAttemptContext returns the context for the first attempt: ctx, or
+// with MinFirstAttempt set and ctx's deadline nearer, a context detached
+// from ctx's cancellation that expires after MinFirstAttempt
+func (r *retry) firstAttemptContext(ctx context.Context, clock Clock) (context.Context, context.CancelFunc) {
+	guarantee := r.config.MinFirstAttempt
+	if guarantee <= 0 {
+		return ctx, func() {}
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok || deadline.Sub(clock.Now()) >= guarantee || errors.Is(ctx.Err(), context.Canceled) {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(context.WithoutCancel(ctx), guarantee)
 }
 
 // exponentialBackoff implements exponential backoff with jitter
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewRetry(t *testing.T) {
@@ -103,7 +104,8 @@ func TestRetryExecution(t *testing.T) {
 		})
 
 		assert.Error(t, err)
-		assert.Equal(t, testErr, err)
+		assert.ErrorIs(t, err, testErr)
+		assert.ErrorIs(t, err, ErrMaxRetriesExceeded)
 		assert.Equal(t, 3, attempts)
 	})
 
@@ -127,7 +129,7 @@ func TestRetryExecution(t *testing.T) {
 		})
 
 		assert.Error(t, err)
-		assert.Equal(t, context.DeadlineExceeded, err)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
 		assert.Less(t, attempts, 10) // Should not reach max attempts
 	})
 
@@ -191,7 +193,7 @@ func TestRetryExecution(t *testing.T) {
 		})
 
 		assert.Error(t, err)
-		assert.Equal(t, temporaryErr, err)
+		assert.ErrorIs(t, err, temporaryErr)
 		assert.Equal(t, 5, attempts) // All attempts
 	})
 }
@@ -247,3 +249,519 @@ func TestDefaultRetryConfig(t *testing.T) {
 		assert.Equal(t, 0.5, config.RandomizationFactor)
 	})
 }
+
+func TestRetryGiveUp(t *testing.T) {
+	t.Run("stops retrying when downstream gives up", func(t *testing.T) {
+		retry := NewRetry(RetryConfig{Name: "test", MaxAttempts: 5, InitialInterval: time.Millisecond})
+
+		attempts := 0
+		testErr := errors.New("do not retry")
+		err := retry.Execute(context.Background(), func(ctx context.Context) error {
+			attempts++
+			GiveUp(ctx)
+			return testErr
+		})
+
+		assert.Equal(t, testErr, err)
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("stops enclosing retries", func(t *testing.T) {
+		outer := NewRetry(RetryConfig{Name: "outer", MaxAttempts: 3, InitialInterval: time.Millisecond})
+		inner := NewRetry(RetryConfig{Name: "inner", MaxAttempts: 3, InitialInterval: time.Millisecond})
+
+		attempts := 0
+		outer.Execute(context.Background(), func(ctx context.Context) error {
+			return inner.Execute(ctx, func(ctx context.Context) error {
+				attempts++
+				GiveUp(ctx)
+				return errors.New("error")
+			})
+		})
+
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("is a no-op outside retries", func(t *testing.T) {
+		assert.NotPanics(t, func() { GiveUp(context.Background()) })
+	})
+}
+
+func TestRetryMaxElapsedTime(t *testing.T) {
+	ctx := context.Background()
+	testErr := errors.New("error")
+
+	t.Run("gives up before MaxAttempts when the budget runs out", func(t *testing.T) {
+		clock := NewFakeClock(time.Now())
+		retry := NewRetry(RetryConfig{
+			Name:                "test",
+			MaxAttempts:         10,
+			InitialInterval:     time.Second,
+			Multiplier:          1,
+			RandomizationFactor: 0.01,
+			MaxElapsedTime:      3500 * time.Millisecond,
+			Clock:               clock,
+		})
+
+		attempts := 0
+		done := make(chan error, 1)
+		go func() {
+			done <- retry.Execute(ctx, func(ctx context.Context) error {
+				attempts++
+				return testErr
+			})
+		}()
+
+		// The fourth backoff would end past the budget
+		waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		for i := 0; i < 3; i++ {
+			require.NoError(t, clock.BlockUntil(waitCtx, 1))
+			clock.Advance(1010 * time.Millisecond)
+		}
+		assert.ErrorIs(t, <-done, testErr)
+		assert.Equal(t, 4, attempts)
+	})
+
+	t.Run("counts time spent in attempts", func(t *testing.T) {
+		clock := NewFakeClock(time.Now())
+		var retries []int
+		retry := NewRetry(RetryConfig{
+			Name:            "test",
+			MaxAttempts:     10,
+			InitialInterval: time.Millisecond,
+			MaxElapsedTime:  time.Minute,
+			Clock:           clock,
+			OnRetry:         func(attempt int, err error) { retries = append(retries, attempt) },
+		})
+
+		attempts := 0
+		err := retry.Execute(ctx, func(ctx context.Context) error {
+			attempts++
+			clock.Advance(time.Minute)
+			return testErr
+		})
+		assert.ErrorIs(t, err, testErr)
+		assert.Equal(t, 1, attempts)
+		assert.Empty(t, retries, "OnRetry is not called for a retry given up")
+	})
+}
+
+func TestRetryDeadlineAwareBackoff(t *testing.T) {
+	testErr := errors.New("error")
+
+	t.Run("fails fast instead of sleeping past the deadline", func(t *testing.T) {
+		retry := NewRetry(RetryConfig{Name: "test", MaxAttempts: 3, InitialInterval: time.Hour, MaxInterval: time.Hour})
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		attempts := 0
+		start := time.Now()
+		err := retry.Execute(ctx, func(ctx context.Context) error {
+			attempts++
+			return testErr
+		})
+
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, 1, attempts)
+		assert.ErrorIs(t, err, ErrBackoffExceedsDeadline)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.ErrorIs(t, err, testErr)
+
+		var deadlineErr *BackoffDeadlineError
+		require.ErrorAs(t, err, &deadlineErr)
+		assert.Greater(t, deadlineErr.Delay, deadlineErr.Remaining)
+		assert.Greater(t, deadlineErr.Remaining, 59*time.Second)
+	})
+
+	t.Run("measures the remaining time on the clock", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+		defer cancel()
+		deadline, _ := ctx.Deadline()
+		clock := NewFakeClock(deadline.Add(-time.Second))
+		retry := NewRetry(RetryConfig{Name: "test", MaxAttempts: 3, InitialInterval: 10 * time.Second, MaxInterval: 10 * time.Second, Clock: clock})
+
+		err := retry.Execute(ctx, func(ctx context.Context) error {
+			return testErr
+		})
+
+		var deadlineErr *BackoffDeadlineError
+		require.ErrorAs(t, err, &deadlineErr)
+		assert.Equal(t, time.Second, deadlineErr.Remaining)
+	})
+
+	t.Run("retries when the backoff fits", func(t *testing.T) {
+		retry := NewRetry(RetryConfig{Name: "test", MaxAttempts: 3, InitialInterval: time.Millisecond})
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		attempts := 0
+		err := retry.Execute(ctx, func(ctx context.Context) error {
+			attempts++
+			return testErr
+		})
+		assert.ErrorIs(t, err, testErr)
+		assert.Equal(t, 3, attempts)
+	})
+}
+
+func TestRetryMinFirstAttempt(t *testing.T) {
+	expired := func(t *testing.T) context.Context {
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		t.Cleanup(cancel)
+		return ctx
+	}
+
+	t.Run("runs the first attempt past an expired deadline", func(t *testing.T) {
+		retry := NewRetry(RetryConfig{Name: "test", MaxAttempts: 3, InitialInterval: time.Millisecond, MinFirstAttempt: 50 * time.Millisecond})
+
+		attempts := 0
+		err := retry.Execute(expired(t), func(ctx context.Context) error {
+			attempts++
+			require.NoError(t, ctx.Err())
+			deadline, ok := ctx.Deadline()
+			require.True(t, ok)
+			assert.WithinDuration(t, time.Now().Add(50*time.Millisecond), deadline, 20*time.Millisecond)
+			return errors.New("error")
+		})
+
+		// The retry backoff waits on the caller's context again
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("keeps context values", func(t *testing.T) {
+		type key struct{}
+		retry := NewRetry(RetryConfig{Name: "test", MinFirstAttempt: time.Second})
+		ctx := context.WithValue(expired(t), key{}, "v")
+
+		err := retry.Execute(ctx, func(ctx context.Context) error {
+			assert.Equal(t, "v", ctx.Value(key{}))
+			return nil
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("leaves contexts with enough time or canceled alone", func(t *testing.T) {
+		retry := NewRetry(RetryConfig{Name: "test", MinFirstAttempt: time.Second})
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		retry.Execute(ctx, func(attemptCtx context.Context) error {
+			deadline, _ := ctx.Deadline()
+			attemptDeadline, _ := attemptCtx.Deadline()
+			assert.Equal(t, deadline, attemptDeadline)
+			return nil
+		})
+
+		canceled, cancel := context.WithTimeout(context.Background(), time.Minute)
+		cancel()
+		retry.Execute(canceled, func(attemptCtx context.Context) error {
+			assert.ErrorIs(t, attemptCtx.Err(), context.Canceled)
+			return nil
+		})
+	})
+
+	t.Run("is off by default", func(t *testing.T) {
+		retry := NewRetry(RetryConfig{Name: "test", MaxAttempts: 1})
+		retry.Execute(expired(t), func(ctx context.Context) error {
+			assert.ErrorIs(t, ctx.Err(), context.DeadlineExceeded)
+			return nil
+		})
+	})
+}
+
+func TestRetryAfterFunc(t *testing.T) {
+	ctx := context.Background()
+	throttled := errors.New("throttled")
+	retryAfter := func(err error) (time.Duration, bool) {
+		if errors.Is(err, throttled) {
+			return 5 * time.Second, true
+		}
+		return 0, false
+	}
+
+	t.Run("waits as long as the server asks", func(t *testing.T) {
+		clock := NewFakeClock(time.Now())
+		retry := NewRetry(RetryConfig{
+			MaxAttempts:     2,
+			InitialInterval: time.Millisecond,
+			RetryAfterFunc:  retryAfter,
+			Clock:           clock,
+		})
+
+		done := make(chan error, 1)
+		attempts := 0
+		go func() {
+			done <- retry.Execute(ctx, func(ctx context.Context) error {
+				attempts++
+				if attempts == 1 {
+					return throttled
+				}
+				return nil
+			})
+		}()
+
+		waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		require.NoError(t, clock.BlockUntil(waitCtx, 1))
+		clock.Advance(4 * time.Second)
+		select {
+		case <-done:
+			t.Fatal("retried before the server's delay")
+		default:
+		}
+		clock.Advance(time.Second)
+		assert.NoError(t, <-done)
+		assert.Equal(t, 2, attempts)
+	})
+
+	t.Run("keeps the backoff without a hint", func(t *testing.T) {
+		retry := NewRetry(RetryConfig{
+			MaxAttempts:     2,
+			InitialInterval: time.Millisecond,
+			RetryAfterFunc:  retryAfter,
+		})
+		attempts := 0
+		err := retry.Execute(ctx, func(ctx context.Context) error {
+			attempts++
+			return errors.New("error")
+		})
+		assert.Error(t, err)
+		assert.Equal(t, 2, attempts)
+	})
+
+	t.Run("fails fast when the hint exceeds the deadline", func(t *testing.T) {
+		retry := NewRetry(RetryConfig{MaxAttempts: 2, RetryAfterFunc: retryAfter})
+		deadlineCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+
+		err := retry.Execute(deadlineCtx, func(ctx context.Context) error { return throttled })
+		var deadlineErr *BackoffDeadlineError
+		require.ErrorAs(t, err, &deadlineErr)
+		assert.Equal(t, 5*time.Second, deadlineErr.Delay)
+	})
+}
+
+func TestRetryAttemptTimeout(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("retries an attempt that hangs", func(t *testing.T) {
+		retry := NewRetry(RetryConfig{
+			MaxAttempts:     2,
+			InitialInterval: time.Millisecond,
+			AttemptTimeout:  20 * time.Millisecond,
+		})
+		attempts := 0
+		err := retry.Execute(ctx, func(ctx context.Context) error {
+			attempts++
+			if attempts == 1 {
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, attempts)
+	})
+
+	t.Run("fails with ErrTimeout when every attempt times out", func(t *testing.T) {
+		clock := NewFakeClock(time.Now())
+		retry := NewRetry(RetryConfig{
+			MaxAttempts:    1,
+			AttemptTimeout: time.Second,
+			Clock:          clock,
+		})
+		done := make(chan error, 1)
+		go func() {
+			done <- retry.Execute(ctx, func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			})
+		}()
+
+		waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		require.NoError(t, clock.BlockUntil(waitCtx, 1))
+		clock.Advance(time.Second)
+		assert.ErrorIs(t, <-done, ErrTimeout)
+	})
+
+	t.Run("keeps the caller's cancellation", func(t *testing.T) {
+		retry := NewRetry(RetryConfig{MaxAttempts: 3, AttemptTimeout: time.Minute})
+		callCtx, cancel := context.WithCancel(ctx)
+		attempts := 0
+		err := retry.Execute(callCtx, func(ctx context.Context) error {
+			attempts++
+			cancel()
+			return ctx.Err()
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.NotErrorIs(t, err, ErrTimeout)
+		assert.Equal(t, 1, attempts)
+	})
+}
+
+func TestRetryAttemptFromContext(t *testing.T) {
+	failure := errors.New("failure")
+	retry := NewRetry(RetryConfig{MaxAttempts: 3, InitialInterval: time.Millisecond})
+
+	var attempts []Attempt
+	err := retry.Execute(context.Background(), func(ctx context.Context) error {
+		attempts = append(attempts, AttemptFromContext(ctx))
+		if len(attempts) < 3 {
+			return failure
+		}
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []Attempt{
+		{Retry: 0, MaxAttempts: 3},
+		{Retry: 1, MaxAttempts: 3, LastErr: failure},
+		{Retry: 2, MaxAttempts: 3, LastErr: failure},
+	}, attempts)
+	assert.Equal(t, Attempt{}, AttemptFromContext(context.Background()))
+}
+
+func TestRetryExhaustedError(t *testing.T) {
+	ctx := context.Background()
+	errs := []error{errors.New("first"), errors.New("second"), errors.New("third")}
+	retry := NewRetry(RetryConfig{MaxAttempts: 3, InitialInterval: time.Millisecond})
+
+	attempts := 0
+	err := retry.Execute(ctx, func(ctx context.Context) error {
+		attempts++
+		return errs[attempts-1]
+	})
+
+	var exhausted *RetryExhaustedError
+	require.ErrorAs(t, err, &exhausted)
+	assert.Equal(t, 3, exhausted.Attempts)
+	assert.Equal(t, errs, exhausted.Errors)
+	assert.Positive(t, exhausted.Elapsed)
+	assert.ErrorIs(t, err, ErrMaxRetriesExceeded)
+	assert.ErrorIs(t, err, errs[2])
+	assert.NotErrorIs(t, err, errs[0])
+
+	t.Run("not returned for errors that are not retried", func(t *testing.T) {
+		permanent := errors.New("permanent")
+		retry := NewRetry(RetryConfig{
+			MaxAttempts: 3,
+			ShouldRetry: func(err error) bool { return !errors.Is(err, permanent) },
+		})
+		err := retry.Execute(ctx, func(ctx context.Context) error { return permanent })
+		assert.Equal(t, permanent, err)
+		assert.NotErrorIs(t, err, ErrMaxRetriesExceeded)
+	})
+}
+
+func TestRetryShouldRetryResult(t *testing.T) {
+	ctx := context.Background()
+	type page struct{ items []string }
+	emptyPage := func(result any, err error) bool {
+		p, _ := result.(page)
+		return err != nil || len(p.items) == 0
+	}
+
+	t.Run("retries logically failed results", func(t *testing.T) {
+		executor := NewBuilder().WithRetry(RetryConfig{
+			MaxAttempts:       3,
+			InitialInterval:   time.Millisecond,
+			ShouldRetryResult: emptyPage,
+		}).Build()
+		attempts := 0
+		result, err := executor.ExecuteWithResult(ctx, func(ctx context.Context) (any, error) {
+			attempts++
+			if attempts < 2 {
+				return page{}, nil
+			}
+			return page{items: []string{"a"}}, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, page{items: []string{"a"}}, result)
+		assert.Equal(t, 2, attempts)
+	})
+
+	t.Run("returns the last result when retries run out", func(t *testing.T) {
+		retry := NewRetry(RetryConfig{MaxAttempts: 2, InitialInterval: time.Millisecond, ShouldRetryResult: emptyPage})
+		result, err := retry.ExecuteWithResult(ctx, func(ctx context.Context) (any, error) {
+			return page{}, nil
+		})
+		assert.Equal(t, page{}, result)
+		assert.ErrorIs(t, err, ErrResultRejected)
+		assert.ErrorIs(t, err, ErrMaxRetriesExceeded)
+	})
+
+	t.Run("typed predicate", func(t *testing.T) {
+		retry := NewRetry(RetryConfig{
+			MaxAttempts:     3,
+			InitialInterval: time.Millisecond,
+			ShouldRetryResult: RetryResultOf(func(status int, err error) bool {
+				return err != nil || status == 503
+			}),
+		})
+		statuses := []int{503, 503, 200}
+		attempts := 0
+		status, err := RetryDo(ctx, retry, func(ctx context.Context) (int, error) {
+			attempts++
+			return statuses[attempts-1], nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 200, status)
+		assert.Equal(t, 3, attempts)
+	})
+}
+
+// retryRecorder collects retry outcomes
+type retryRecorder struct {
+	outcomes []RetryOutcome
+	attempts []int
+}
+
+func (r *retryRecorder) RecordRetry(name string, outcome RetryOutcome, attempts int) {
+	r.outcomes = append(r.outcomes, outcome)
+	r.attempts = append(r.attempts, attempts)
+}
+
+func TestRetryOutcomes(t *testing.T) {
+	ctx := context.Background()
+	failure := errors.New("failure")
+	recorder := &retryRecorder{}
+	var gaveUp []error
+	retry := NewRetry(RetryConfig{
+		Name:            "payments",
+		MaxAttempts:     3,
+		InitialInterval: time.Millisecond,
+		MetricsRecorder: recorder,
+		OnGiveUp: func(attempts int, elapsed time.Duration, err error) {
+			gaveUp = append(gaveUp, err)
+		},
+	})
+	failFirst := func(n int) func(context.Context) error {
+		attempts := 0
+		return func(ctx context.Context) error {
+			attempts++
+			if attempts <= n {
+				return failure
+			}
+			return nil
+		}
+	}
+
+	require.NoError(t, retry.Execute(ctx, failFirst(0)))
+	require.NoError(t, retry.Execute(ctx, failFirst(2)))
+	require.Error(t, retry.Execute(ctx, failFirst(3)))
+	require.Error(t, retry.Execute(ctx, func(ctx context.Context) error { return Permanent(failure) }))
+
+	assert.Equal(t, []RetryOutcome{
+		RetryOutcomeFirstAttempt,
+		RetryOutcomeRetried,
+		RetryOutcomeExhausted,
+		RetryOutcomeAborted,
+	}, recorder.outcomes)
+	assert.Equal(t, []int{1, 3, 3, 1}, recorder.attempts)
+	require.Len(t, gaveUp, 2)
+	assert.ErrorIs(t, gaveUp[0], ErrMaxRetriesExceeded)
+	assert.True(t, IsPermanent(gaveUp[1]))
+}
@@ -0,0 +1,82 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+)
+
+// permanentError marks an error that must not be retried
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// transientError marks an error that may be retried
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string {
+	return e.err.Error()
+}
+
+func (e *transientError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks err as not worth retrying, e.g. a validation failure;
+// DefaultShouldRetry stops on it. The mark is kept through wrapping and the
+// error still matches err with errors.Is. Permanent(nil) is nil.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Transient marks err as worth retrying even where DefaultShouldRetry would
+// stop, e.g. a context.DeadlineExceeded from a call's own internal timeout.
+// Transient(nil) is nil.
+func Transient(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &transientError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// IsTransient reports whether err was marked with Transient
+func IsTransient(err error) bool {
+	var transient *transientError
+	return errors.As(err, &transient)
+}
+
+// DefaultShouldRetry is the ShouldRetry used when RetryConfig.ShouldRetry is
+// nil. It retries every error except those marked Permanent, context
+// cancellations and expiries, and ErrCircuitOpen; errors marked Transient
+// are always retried.
+func DefaultShouldRetry(err error) bool {
+	switch {
+	case IsTransient(err):
+		return true
+	case IsPermanent(err),
+		errors.Is(err, context.Canceled),
+		errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, ErrCircuitOpen):
+		return false
+	default:
+		return true
+	}
+}
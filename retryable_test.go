@@ -0,0 +1,60 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorMarkers(t *testing.T) {
+	base := errors.New("invalid request")
+
+	permanent := fmt.Errorf("charge: %w", Permanent(base))
+	assert.True(t, IsPermanent(permanent))
+	assert.False(t, IsTransient(permanent))
+	assert.ErrorIs(t, permanent, base)
+	assert.Equal(t, "charge: invalid request", permanent.Error())
+
+	transient := Transient(context.DeadlineExceeded)
+	assert.True(t, IsTransient(transient))
+	assert.ErrorIs(t, transient, context.DeadlineExceeded)
+
+	assert.NoError(t, Permanent(nil))
+	assert.NoError(t, Transient(nil))
+}
+
+func TestDefaultShouldRetry(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "plain error", err: errors.New("unavailable"), want: true},
+		{name: "timeout", err: ErrTimeout, want: true},
+		{name: "permanent", err: Permanent(errors.New("invalid")), want: false},
+		{name: "canceled", err: context.Canceled, want: false},
+		{name: "deadline", err: fmt.Errorf("call: %w", context.DeadlineExceeded), want: false},
+		{name: "circuit open", err: &CircuitOpenError{Breaker: "payments"}, want: false},
+		{name: "transient deadline", err: Transient(context.DeadlineExceeded), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DefaultShouldRetry(tt.err))
+		})
+	}
+
+	t.Run("used when ShouldRetry is nil", func(t *testing.T) {
+		retry := NewRetry(RetryConfig{MaxAttempts: 3, InitialInterval: time.Millisecond})
+		attempts := 0
+		err := retry.Execute(context.Background(), func(ctx context.Context) error {
+			attempts++
+			return Permanent(errors.New("invalid"))
+		})
+		assert.True(t, IsPermanent(err))
+		assert.Equal(t, 1, attempts)
+	})
+}
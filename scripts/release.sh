@@ -10,6 +10,10 @@ MODULE_DIR="$(cd "$SCRIPT_DIR/.." && pwd)"
 
 cd "$MODULE_DIR"
 
+# Integration sub-modules, tagged <module>/vX.Y.Z with the core version they
+# require; examples is pinned but never tagged
+MODULES="gossip redis resiliencefx resiliencegrpc resiliencegobreaker resilienceotel"
+
 echo "🚀 Starting release process (type: $BUMP_TYPE)..."
 echo "   Module: dbx"
 echo "   Dry run: $DRY_RUN"
@@ -58,13 +62,21 @@ echo "   📈 New version: v$NEW_VERSION"
 echo "📝 Step 7/8: Updating CHANGELOG..."
 ./scripts/update-changelog.sh "$NEW_VERSION"
 
+# Pin the sub-modules to the version being tagged
+for m in $MODULES examples; do
+    (cd "$m" && go mod edit -require="github.com/gostratum/resiliencex@v$NEW_VERSION")
+done
+(cd examples && go mod edit \
+    -require="github.com/gostratum/resiliencex/resiliencefx@v$NEW_VERSION" \
+    -require="github.com/gostratum/resiliencex/resiliencegrpc@v$NEW_VERSION")
+
 # 8. Commit and tag (if not dry run and in git repo)
 if [ "$DRY_RUN" = "false" ]; then
     if git rev-parse --git-dir > /dev/null 2>&1; then
         echo "💾 Step 8/8: Committing and tagging..."
         
         # Add changed files
-        git add .version go.mod go.sum CHANGELOG.md
+        git add .version go.mod go.sum CHANGELOG.md */go.mod
         
         # Commit
         git commit -m "chore(release): bump version to v$NEW_VERSION
@@ -79,6 +91,11 @@ This release was automated via scripts/release.sh"
         git tag -a "v$NEW_VERSION" -m "Release v$NEW_VERSION
 
 See CHANGELOG.md for details."
+
+        # Tag the sub-modules after the core version they require
+        for m in $MODULES; do
+            git tag -a "$m/v$NEW_VERSION" -m "Release $m v$NEW_VERSION"
+        done
         
         echo ""
         echo "✅ Release v$NEW_VERSION completed successfully!"
@@ -90,6 +107,7 @@ See CHANGELOG.md for details."
         echo "   2. Push to remote:"
         echo "      git push origin main"
         echo "      git push origin v$NEW_VERSION"
+        echo "      git push origin $(for m in $MODULES; do printf '%s ' "$m/v$NEW_VERSION"; done)"
         echo ""
         echo "   3. Create GitHub release (if applicable):"
         echo "      https://github.com/gostratum/dbx/releases/new?tag=v$NEW_VERSION"
@@ -117,5 +135,5 @@ else
     echo "   make release TYPE=$BUMP_TYPE"
     echo ""
     echo "⚠️  Rolling back changes..."
-    git checkout .version go.mod go.sum CHANGELOG.md 2>/dev/null || true
+    git checkout .version go.mod go.sum CHANGELOG.md */go.mod 2>/dev/null || true
 fi
@@ -0,0 +1,165 @@
+package resilience
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Decisions of a shadowCircuitBreaker comparison
+const (
+	shadowAgreed int32 = iota
+	shadowWouldReject
+	shadowWouldAdmit
+)
+
+// shadowCircuitBreaker implements the ShadowCircuitBreaker interface. The
+// embedded active breaker decides; the shadow sees the same admissions and
+// outcomes so its state follows the traffic as if it were in charge.
+type shadowCircuitBreaker struct {
+	CircuitBreaker
+	shadow         *circuitBreaker
+	onDisagreement OnShadowDisagreement
+
+	calls       atomic.Uint64
+	wouldReject atomic.Uint64
+	wouldAdmit  atomic.Uint64
+	last        atomic.Int32 // decision of the previous comparison
+}
+
+// NewShadowCircuitBreaker wraps active with a shadow breaker configured by
+// candidate, to tune thresholds against live traffic before switching.
+// Calls are admitted and rejected by active only; onDisagreement (may be
+// nil) is called when the shadow starts deciding differently. The shadow
+// is local: candidate's Coordinator and GroupName are ignored, and its
+// Name defaults to active's.
+func NewShadowCircuitBreaker(active CircuitBreaker, candidate CircuitBreakerConfig, onDisagreement OnShadowDisagreement) ShadowCircuitBreaker {
+	if candidate.Name == "" {
+		candidate.Name = active.Name()
+	}
+	candidate.Coordinator = nil
+	candidate.GroupName = ""
+
+	return &shadowCircuitBreaker{
+		CircuitBreaker: active,
+		shadow:         NewCircuitBreaker(candidate).(*circuitBreaker),
+		onDisagreement: onDisagreement,
+	}
+}
+
+func (s *shadowCircuitBreaker) Execute(ctx context.Context, fn func(context.Context) error) error {
+	sp, shadowErr := s.shadow.allow(IsHighPriority(ctx), 1)
+	sp.weight = callWeight(ctx)
+
+	ran := false
+	var result error
+	err := s.CircuitBreaker.Execute(ctx, func(ctx context.Context) error {
+		ran = true
+		result = fn(ctx)
+		return result
+	})
+	s.compare(ran, shadowErr == nil)
+
+	if shadowErr == nil {
+		if !ran || s.shadow.callerCanceled(ctx, result) {
+			sp.release()
+		} else {
+			sp.Record(result)
+		}
+	}
+	return err
+}
+
+func (s *shadowCircuitBreaker) Allow() (Permit, error) {
+	return s.allowN(1)
+}
+
+func (s *shadowCircuitBreaker) AllowN(n int) (Permit, error) {
+	return s.allowN(n)
+}
+
+// allowN admits a batch of n through the active breaker, carrying the
+// shadow's admission along on the returned permit
+func (s *shadowCircuitBreaker) allowN(n int) (Permit, error) {
+	if n <= 0 {
+		return s.CircuitBreaker.AllowN(n)
+	}
+	sp, shadowErr := s.shadow.AllowN(n)
+	p, err := s.CircuitBreaker.AllowN(n)
+	s.compare(err == nil, shadowErr == nil)
+
+	switch {
+	case err != nil:
+		if shadowErr == nil {
+			sp.(*permit).release()
+		}
+		return nil, err
+	case shadowErr != nil:
+		return p, nil
+	}
+	return &shadowPermit{Permit: p, shadow: sp}, nil
+}
+
+func (s *shadowCircuitBreaker) Shadow() CircuitBreaker {
+	return s.shadow
+}
+
+func (s *shadowCircuitBreaker) ShadowStats() ShadowStats {
+	return ShadowStats{
+		Calls:       s.calls.Load(),
+		WouldReject: s.wouldReject.Load(),
+		WouldAdmit:  s.wouldAdmit.Load(),
+	}
+}
+
+// compare counts the admission decisions of the active and shadow breakers
+// for one call and reports the start of a disagreement
+func (s *shadowCircuitBreaker) compare(admitted, shadowAdmitted bool) {
+	s.calls.Add(1)
+	decision := shadowAgreed
+	switch {
+	case admitted && !shadowAdmitted:
+		decision = shadowWouldReject
+		s.wouldReject.Add(1)
+	case !admitted && shadowAdmitted:
+		decision = shadowWouldAdmit
+		s.wouldAdmit.Add(1)
+	}
+
+	if s.last.Swap(decision) == decision || decision == shadowAgreed || s.onDisagreement == nil {
+		return
+	}
+	s.onDisagreement(ShadowDisagreement{
+		Name:           s.Name(),
+		ActiveState:    s.State(),
+		ShadowState:    s.shadow.State(),
+		ShadowAdmitted: decision == shadowWouldAdmit,
+		Time:           s.shadow.now(),
+		Stats:          s.ShadowStats(),
+	})
+}
+
+// shadowPermit records outcomes on both the active and the shadow permit
+type shadowPermit struct {
+	Permit
+	shadow Permit
+}
+
+func (p *shadowPermit) RecordSuccess() {
+	p.Permit.RecordSuccess()
+	p.shadow.RecordSuccess()
+}
+
+func (p *shadowPermit) RecordFailure() {
+	p.Permit.RecordFailure()
+	p.shadow.RecordFailure()
+}
+
+func (p *shadowPermit) Record(err error) {
+	p.Permit.Record(err)
+	p.shadow.Record(err)
+}
+
+func (p *shadowPermit) RecordBatch(successes, failures uint32) {
+	p.Permit.RecordBatch(successes, failures)
+	p.shadow.RecordBatch(successes, failures)
+}
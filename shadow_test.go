@@ -0,0 +1,92 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShadowCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	errBoom := errors.New("boom")
+	fail := func(ctx context.Context) error { return errBoom }
+	ok := func(ctx context.Context) error { return nil }
+	config := func(threshold float64) CircuitBreakerConfig {
+		return CircuitBreakerConfig{
+			Name:             "payments",
+			TripStrategy:     TripStrategyRatio,
+			FailureThreshold: threshold,
+			MinRequests:      4,
+			Interval:         time.Minute,
+			Timeout:          time.Minute,
+		}
+	}
+
+	t.Run("reports calls a stricter candidate would reject", func(t *testing.T) {
+		var disagreements []ShadowDisagreement
+		cb := NewShadowCircuitBreaker(NewCircuitBreaker(config(0.9)), config(0.5), func(d ShadowDisagreement) {
+			disagreements = append(disagreements, d)
+		})
+
+		assert.NoError(t, cb.Execute(ctx, ok))
+		for i := 0; i < 3; i++ {
+			assert.ErrorIs(t, cb.Execute(ctx, fail), errBoom)
+		}
+		assert.Equal(t, StateClosed, cb.State())
+		assert.Equal(t, StateOpen, cb.Shadow().State())
+
+		// Still admitted by the active breaker, and failing again
+		assert.ErrorIs(t, cb.Execute(ctx, fail), errBoom)
+		assert.NoError(t, cb.Execute(ctx, ok))
+		assert.Equal(t, ShadowStats{Calls: 6, WouldReject: 2}, cb.ShadowStats())
+
+		require.Len(t, disagreements, 1, "reported once per run of disagreements")
+		assert.Equal(t, ShadowDisagreement{
+			Name:        "payments",
+			ActiveState: StateClosed,
+			ShadowState: StateOpen,
+			Time:        disagreements[0].Time,
+			Stats:       ShadowStats{Calls: 5, WouldReject: 1},
+		}, disagreements[0])
+	})
+
+	t.Run("reports calls a laxer candidate would admit", func(t *testing.T) {
+		var disagreements []ShadowDisagreement
+		cb := NewShadowCircuitBreaker(NewCircuitBreaker(config(0.5)), config(0.9), func(d ShadowDisagreement) {
+			disagreements = append(disagreements, d)
+		})
+
+		assert.NoError(t, cb.Execute(ctx, ok))
+		for i := 0; i < 3; i++ {
+			assert.ErrorIs(t, cb.Execute(ctx, fail), errBoom)
+		}
+		assert.ErrorIs(t, cb.Execute(ctx, ok), ErrCircuitOpen)
+		assert.Equal(t, StateClosed, cb.Shadow().State())
+		assert.Equal(t, ShadowStats{Calls: 5, WouldAdmit: 1}, cb.ShadowStats())
+		require.Len(t, disagreements, 1)
+		assert.True(t, disagreements[0].ShadowAdmitted)
+		assert.Equal(t, uint32(4), cb.Shadow().Metrics().Requests, "rejected calls are not counted by the shadow")
+	})
+
+	t.Run("records permits on both breakers", func(t *testing.T) {
+		cb := NewShadowCircuitBreaker(NewCircuitBreaker(config(0.9)), config(0.5), nil)
+
+		p, err := cb.AllowN(4)
+		require.NoError(t, err)
+		p.RecordBatch(1, 3)
+		assert.Equal(t, StateClosed, cb.State())
+		assert.Equal(t, StateOpen, cb.Shadow().State())
+
+		p, err = cb.Allow()
+		require.NoError(t, err)
+		p.RecordSuccess()
+		assert.Equal(t, ShadowStats{Calls: 2, WouldReject: 1}, cb.ShadowStats())
+
+		_, err = cb.AllowN(0)
+		assert.Error(t, err)
+	})
+}
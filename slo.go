@@ -0,0 +1,145 @@
+package resilience
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sloWindowBuckets is the number of buckets used by SLO windows
+const sloWindowBuckets = 60
+
+// sloGuard implements the SLOGuard interface
+type sloGuard struct {
+	executor  Executor
+	config    SLOConfig
+	mu        sync.Mutex
+	budget    *rollingWindow
+	burn      *rollingWindow
+	clock     Clock
+	tightened bool
+}
+
+// NewSLOGuard wraps an executor with error budget tracking.
+// While the burn rate exceeds the configured threshold, retries of the
+// wrapped executor are suspended so failing calls don't amplify load.
+func NewSLOGuard(executor Executor, config SLOConfig) SLOGuard {
+	if config.Name == "" {
+		config.Name = executor.Name()
+	}
+	if config.Objective <= 0 || config.Objective >= 1 {
+		config.Objective = DefaultSLOConfig().Objective
+	}
+	if config.Window == 0 {
+		config.Window = DefaultSLOConfig().Window
+	}
+	if config.BurnRateWindow == 0 {
+		config.BurnRateWindow = DefaultSLOConfig().BurnRateWindow
+	}
+	if config.BurnRateThreshold == 0 {
+		config.BurnRateThreshold = DefaultSLOConfig().BurnRateThreshold
+	}
+	if config.MinRequests == 0 {
+		config.MinRequests = DefaultSLOConfig().MinRequests
+	}
+
+	clock := clockOrSystem(config.Clock)
+	now := clock.Now()
+	return &sloGuard{
+		executor: executor,
+		config:   config,
+		budget:   newRollingWindow(config.Window, sloWindowBuckets, now),
+		burn:     newRollingWindow(config.BurnRateWindow, sloWindowBuckets, now),
+		clock:    clock,
+	}
+}
+
+func (g *sloGuard) Name() string {
+	return g.config.Name
+}
+
+func (g *sloGuard) Execute(ctx context.Context, fn func(context.Context) error, opts ...CallOption) error {
+	_, err := g.ExecuteWithResult(ctx, func(ctx context.Context) (any, error) {
+		return nil, fn(ctx)
+	}, opts...)
+	return err
+}
+
+func (g *sloGuard) TryExecute(ctx context.Context, fn func(context.Context) error, opts ...CallOption) error {
+	return g.Execute(ctx, fn, append(opts, NoWait())...)
+}
+
+func (g *sloGuard) ExecuteWithResult(ctx context.Context, fn func(context.Context) (any, error), opts ...CallOption) (any, error) {
+	if g.isTightened() {
+		ctx = WithDisabledPatterns(ctx, PatternRetry)
+	}
+
+	result, err := g.executor.ExecuteWithResult(ctx, fn, opts...)
+	g.record(err == nil)
+
+	return result, err
+}
+
+func (g *sloGuard) Status() SLOStatus {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.clock.Now()
+	successes, failures := g.budget.totals(now)
+
+	return SLOStatus{
+		Objective:       g.config.Objective,
+		Requests:        successes + failures,
+		Failures:        failures,
+		BudgetRemaining: g.budgetRemaining(successes, failures),
+		BurnRate:        g.burnRate(now),
+		Tightened:       g.tightened,
+	}
+}
+
+func (g *sloGuard) isTightened() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.tightened
+}
+
+func (g *sloGuard) record(success bool) {
+	g.mu.Lock()
+
+	now := g.clock.Now()
+	g.budget.record(now, success)
+	g.burn.record(now, success)
+
+	successes, failures := g.burn.totals(now)
+	burnRate := g.burnRate(now)
+	tightened := successes+failures >= uint64(g.config.MinRequests) && burnRate > g.config.BurnRateThreshold
+	changed := tightened != g.tightened
+	g.tightened = tightened
+
+	g.mu.Unlock()
+
+	if changed && g.config.OnBurnRateChange != nil {
+		g.config.OnBurnRateChange(g.config.Name, burnRate, tightened)
+	}
+}
+
+// budgetRemaining returns the unspent fraction of the error budget
+func (g *sloGuard) budgetRemaining(successes, failures uint64) float64 {
+	requests := successes + failures
+	if requests == 0 {
+		return 1
+	}
+	allowed := (1 - g.config.Objective) * float64(requests)
+	return 1 - float64(failures)/allowed
+}
+
+// burnRate returns the observed error rate relative to the allowed error rate
+func (g *sloGuard) burnRate(now time.Time) float64 {
+	successes, failures := g.burn.totals(now)
+	requests := successes + failures
+	if requests == 0 {
+		return 0
+	}
+	errorRate := float64(failures) / float64(requests)
+	return errorRate / (1 - g.config.Objective)
+}
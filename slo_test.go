@@ -0,0 +1,88 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSLOGuard(t *testing.T) {
+	t.Run("tracks error budget", func(t *testing.T) {
+		guard := NewSLOGuard(NewBuilder().WithName("api").Build(), SLOConfig{Objective: 0.9})
+		ctx := context.Background()
+
+		for i := 0; i < 19; i++ {
+			guard.Execute(ctx, func(ctx context.Context) error { return nil })
+		}
+		guard.Execute(ctx, func(ctx context.Context) error { return errors.New("error") })
+
+		status := guard.Status()
+		assert.Equal(t, "api", guard.Name())
+		assert.Equal(t, uint64(20), status.Requests)
+		assert.Equal(t, uint64(1), status.Failures)
+		assert.InDelta(t, 0.5, status.BudgetRemaining, 0.0001)
+		assert.InDelta(t, 0.5, status.BurnRate, 0.0001)
+		assert.False(t, status.Tightened)
+	})
+
+	t.Run("suspends retries when burn rate is high", func(t *testing.T) {
+		var changes []bool
+		executor := NewBuilder().
+			WithRetry(RetryConfig{Name: "test", MaxAttempts: 3, InitialInterval: time.Millisecond}).
+			Build()
+		guard := NewSLOGuard(executor, SLOConfig{
+			Name:              "api",
+			Objective:         0.99,
+			BurnRateThreshold: 2,
+			MinRequests:       1,
+			OnBurnRateChange: func(name string, burnRate float64, tightened bool) {
+				changes = append(changes, tightened)
+			},
+		})
+		ctx := context.Background()
+
+		attempts := 0
+		guard.Execute(ctx, func(ctx context.Context) error {
+			attempts++
+			return errors.New("error")
+		})
+		assert.Equal(t, 3, attempts)
+		assert.True(t, guard.Status().Tightened)
+
+		attempts = 0
+		guard.Execute(ctx, func(ctx context.Context) error {
+			attempts++
+			return errors.New("error")
+		})
+		assert.Equal(t, 1, attempts)
+		assert.Equal(t, []bool{true}, changes)
+	})
+
+	t.Run("waits for enough calls before tightening", func(t *testing.T) {
+		clock := NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+		guard := NewSLOGuard(NewBuilder().WithName("api").Build(), SLOConfig{
+			Objective:      0.99,
+			BurnRateWindow: time.Minute,
+			Clock:          clock,
+		})
+		ctx := context.Background()
+		fail := func(ctx context.Context) error { return errors.New("error") }
+
+		for i := 0; i < 9; i++ {
+			guard.Execute(ctx, fail)
+		}
+		assert.False(t, guard.Status().Tightened)
+		guard.Execute(ctx, fail)
+		assert.True(t, guard.Status().Tightened)
+
+		// The failures leave the burn rate window on the guard's clock
+		clock.Advance(2 * time.Minute)
+		guard.Execute(ctx, fail)
+		status := guard.Status()
+		assert.False(t, status.Tightened)
+		assert.Equal(t, uint64(11), status.Requests)
+	})
+}
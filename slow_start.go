@@ -0,0 +1,22 @@
+package resilience
+
+import "time"
+
+// slowStartShare returns the share of calls admitted at now while slow
+// start is in progress, and false once it has ended, restoring the fast
+// path. The caller holds cb.mu.
+func (cb *circuitBreaker) slowStartShare(now time.Time) (float64, bool) {
+	if cb.slowUntil.IsZero() {
+		return 1, false
+	}
+	remaining := cb.slowUntil.Sub(now)
+	if remaining <= 0 {
+		cb.slowUntil = time.Time{}
+		cb.gen.Load().fast.Store(cb.fastEligible())
+		return 1, false
+	}
+
+	progress := 1 - float64(remaining)/float64(cb.config.SlowStartDuration)
+	minShare := cb.config.SlowStartMinShare
+	return minShare + (1-minShare)*progress, true
+}
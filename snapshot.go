@@ -0,0 +1,101 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// metricsSnapshotter implements the MetricsSnapshotter interface
+type metricsSnapshotter struct {
+	registry Registry
+	config   SnapshotConfig
+	clock    Clock
+
+	ctx       context.Context // canceled by Close
+	cancel    context.CancelFunc
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewMetricsSnapshotter writes a snapshot of registry's executors to
+// config.Sink every Interval until Close
+func NewMetricsSnapshotter(registry Registry, config SnapshotConfig) (MetricsSnapshotter, error) {
+	if config.Sink == nil {
+		return nil, errors.New("resilience: metrics snapshots require a sink")
+	}
+	if config.Interval <= 0 {
+		config.Interval = DefaultSnapshotConfig().Interval
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &metricsSnapshotter{
+		registry: registry,
+		config:   config,
+		clock:    clockOrSystem(config.Clock),
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	go s.loop()
+	return s, nil
+}
+
+func (s *metricsSnapshotter) Snapshot() MetricsSnapshot {
+	snapshot := MetricsSnapshot{Time: s.clock.Now()}
+	for _, name := range s.registry.Names() {
+		snapshot.Executors = append(snapshot.Executors, executorSnapshot(name, s.registry.Executor(name)))
+	}
+	return snapshot
+}
+
+func (s *metricsSnapshotter) Close(ctx context.Context) error {
+	var err error
+	s.closeOnce.Do(func() {
+		s.cancel()
+		<-s.done
+		err = s.config.Sink.WriteSnapshot(ctx, s.Snapshot())
+	})
+	return err
+}
+
+// loop writes snapshots until Close
+func (s *metricsSnapshotter) loop() {
+	defer close(s.done)
+	timer := s.clock.NewTimer(s.config.Interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-timer.C():
+			if err := s.config.Sink.WriteSnapshot(s.ctx, s.Snapshot()); err != nil && s.config.OnError != nil {
+				s.config.OnError(err)
+			}
+			timer.Reset(s.config.Interval)
+		}
+	}
+}
+
+// executorSnapshot summarizes executor e registered as name
+func executorSnapshot(name string, e Executor) ExecutorSnapshot {
+	snapshot := ExecutorSnapshot{Name: name, State: StateClosed}
+	if ex, ok := e.(*executor); ok {
+		snapshot.Calls = ex.counters.calls.Load()
+		snapshot.Rejected = ex.counters.rejected.Load()
+		if ex.hasTimeout {
+			snapshot.LateSuccesses = ex.timeout.Stats().LateSuccesses
+		}
+	}
+	for _, cb := range executorBreakers(e) {
+		m := cb.Metrics()
+		if healthRank(m.State) > healthRank(snapshot.State) {
+			snapshot.State = m.State
+		}
+		snapshot.Requests += m.Requests
+		snapshot.Failures += m.Failures + m.Timeouts
+		snapshot.LatencyP95 = max(snapshot.LatencyP95, m.LatencyP95)
+	}
+	return snapshot
+}
@@ -0,0 +1,236 @@
+package resilience
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// snapshotFileTime is the layout of the start time in snapshot file names;
+// names sort in the order the files were started
+const snapshotFileTime = "20060102T150405.000000000Z"
+
+// FileSnapshotSink writes snapshots as JSON lines to files in a directory,
+// starting a new file every RotateInterval or beyond MaxFileSize and
+// deleting files beyond MaxFiles or older than MaxAge
+type FileSnapshotSink struct {
+	config  FileSnapshotSinkConfig
+	clock   Clock
+	mu      sync.Mutex
+	file    *os.File
+	started time.Time // when file was started
+	size    int64
+}
+
+// NewFileSnapshotSink creates a sink writing to config.Dir, creating the
+// directory if needed and filling zero values from the defaults
+func NewFileSnapshotSink(config FileSnapshotSinkConfig) (*FileSnapshotSink, error) {
+	defaults := DefaultFileSnapshotSinkConfig()
+	if config.Dir == "" {
+		return nil, errors.New("resilience: snapshot files require a directory")
+	}
+	if config.Prefix == "" {
+		config.Prefix = defaults.Prefix
+	}
+	if config.RotateInterval <= 0 {
+		config.RotateInterval = defaults.RotateInterval
+	}
+	if config.MaxFileSize <= 0 {
+		config.MaxFileSize = defaults.MaxFileSize
+	}
+	if config.MaxFiles <= 0 {
+		config.MaxFiles = defaults.MaxFiles
+	}
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("resilience: create snapshot directory: %w", err)
+	}
+	return &FileSnapshotSink{config: config, clock: clockOrSystem(config.Clock)}, nil
+}
+
+func (s *FileSnapshotSink) WriteSnapshot(ctx context.Context, snapshot MetricsSnapshot) error {
+	line, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("resilience: encode snapshot: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	if s.file == nil || now.Sub(s.started) >= s.config.RotateInterval || s.size+int64(len(line)) > s.config.MaxFileSize {
+		if err := s.rotate(now); err != nil {
+			return err
+		}
+	}
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("resilience: write snapshot: %w", err)
+	}
+	return nil
+}
+
+// Close closes the current file
+func (s *FileSnapshotSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// rotate starts a new file and deletes the files past retention. The
+// caller holds s.mu.
+func (s *FileSnapshotSink) rotate(now time.Time) error {
+	if s.file != nil {
+		if err := s.file.Close(); err != nil {
+			return fmt.Errorf("resilience: close snapshot file: %w", err)
+		}
+		s.file = nil
+	}
+
+	name := s.config.Prefix + "-" + now.UTC().Format(snapshotFileTime) + ".jsonl"
+	file, err := os.OpenFile(filepath.Join(s.config.Dir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("resilience: create snapshot file: %w", err)
+	}
+	s.file, s.started, s.size = file, now, 0
+	return s.prune(now, name)
+}
+
+// prune deletes files beyond MaxFiles and older than MaxAge, never the
+// current file
+func (s *FileSnapshotSink) prune(now time.Time, current string) error {
+	entries, err := os.ReadDir(s.config.Dir)
+	if err != nil {
+		return fmt.Errorf("resilience: list snapshot files: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if _, ok := s.fileTime(entry.Name()); ok && entry.Name() != current {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+
+	for i, name := range names {
+		started, _ := s.fileTime(name)
+		expired := s.config.MaxAge > 0 && now.Sub(started) > s.config.MaxAge
+		if i+1 < s.config.MaxFiles && !expired {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.config.Dir, name)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("resilience: delete snapshot file: %w", err)
+		}
+	}
+	return nil
+}
+
+// fileTime returns when the snapshot file name was started, reporting
+// whether name is a snapshot file of this sink
+func (s *FileSnapshotSink) fileTime(name string) (time.Time, bool) {
+	stamp, ok := strings.CutPrefix(name, s.config.Prefix+"-")
+	if !ok {
+		return time.Time{}, false
+	}
+	stamp, ok = strings.CutSuffix(stamp, ".jsonl")
+	if !ok {
+		return time.Time{}, false
+	}
+	started, err := time.Parse(snapshotFileTime, stamp)
+	return started, err == nil
+}
+
+// statsdMaxPacket keeps statsd packets within a typical network MTU
+const statsdMaxPacket = 1432
+
+// StatsdSnapshotSink sends snapshots as statsd gauges over UDP, named
+// <prefix>.<executor>.<metric>: calls, rejected, requests, failures,
+// latency_p95_ms and circuit_state (0 closed, 1 degraded, 2 half-open,
+// 3 open)
+type StatsdSnapshotSink struct {
+	prefix string // empty or ending with a dot
+	conn   net.Conn
+}
+
+// NewStatsdSnapshotSink creates a sink sending to the statsd server at addr
+func NewStatsdSnapshotSink(addr, prefix string) (*StatsdSnapshotSink, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("resilience: dial statsd: %w", err)
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, ".") {
+		prefix += "."
+	}
+	return &StatsdSnapshotSink{prefix: prefix, conn: conn}, nil
+}
+
+func (s *StatsdSnapshotSink) WriteSnapshot(ctx context.Context, snapshot MetricsSnapshot) error {
+	var packet bytes.Buffer
+	flush := func() error {
+		if packet.Len() == 0 {
+			return nil
+		}
+		_, err := s.conn.Write(bytes.TrimSuffix(packet.Bytes(), []byte("\n")))
+		packet.Reset()
+		if err != nil {
+			return fmt.Errorf("resilience: send statsd snapshot: %w", err)
+		}
+		return nil
+	}
+
+	for _, e := range snapshot.Executors {
+		gauges := []struct {
+			name  string
+			value float64
+		}{
+			{"calls", float64(e.Calls)},
+			{"rejected", float64(e.Rejected)},
+			{"requests", float64(e.Requests)},
+			{"failures", float64(e.Failures)},
+			{"latency_p95_ms", float64(e.LatencyP95) / float64(time.Millisecond)},
+			{"late_successes", float64(e.LateSuccesses)},
+			{"circuit_state", float64(healthRank(e.State))},
+		}
+		for _, g := range gauges {
+			line := fmt.Sprintf("%s%s.%s:%g|g\n", s.prefix, statsdName(e.Name), g.name, g.value)
+			if packet.Len()+len(line) > statsdMaxPacket {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			packet.WriteString(line)
+		}
+	}
+	return flush()
+}
+
+// Close closes the connection
+func (s *StatsdSnapshotSink) Close() error {
+	return s.conn.Close()
+}
+
+// statsdName replaces the characters with a meaning in the statsd protocol
+func statsdName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '|', '@', ' ', '\n':
+			return '_'
+		}
+		return r
+	}, name)
+}
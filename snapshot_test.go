@@ -0,0 +1,197 @@
+package resilience
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSink collects the snapshots written to it
+type recordingSink struct {
+	mu        sync.Mutex
+	snapshots []MetricsSnapshot
+	err       error
+}
+
+func (s *recordingSink) WriteSnapshot(ctx context.Context, snapshot MetricsSnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshots = append(s.snapshots, snapshot)
+	return s.err
+}
+
+func (s *recordingSink) written() []MetricsSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]MetricsSnapshot(nil), s.snapshots...)
+}
+
+func TestMetricsSnapshotter(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("writes every interval and on close", func(t *testing.T) {
+		registry := NewRegistry(nil)
+		payments := registry.Executor("payments")
+		assert.NoError(t, payments.Execute(ctx, func(ctx context.Context) error { return nil }))
+		registry.Register("plain", NewBuilder().WithName("plain").Build())
+
+		clock := NewFakeClock(start)
+		sink := &recordingSink{}
+		snapshotter, err := NewMetricsSnapshotter(registry, SnapshotConfig{Interval: time.Minute, Sink: sink, Clock: clock})
+		require.NoError(t, err)
+
+		waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		require.NoError(t, clock.BlockUntil(waitCtx, 1))
+		clock.Advance(time.Minute)
+		require.NoError(t, clock.BlockUntil(waitCtx, 1))
+		require.NoError(t, snapshotter.Close(ctx))
+
+		snapshots := sink.written()
+		require.Len(t, snapshots, 2)
+		assert.Equal(t, start.Add(time.Minute), snapshots[0].Time)
+		snapshots[0].Executors[0].LatencyP95 = 0
+		assert.Equal(t, []ExecutorSnapshot{
+			{Name: "payments", State: StateClosed, Calls: 1, Requests: 1},
+			{Name: "plain", State: StateClosed},
+		}, snapshots[0].Executors)
+	})
+
+	t.Run("reports sink errors", func(t *testing.T) {
+		clock := NewFakeClock(start)
+		errs := make(chan error, 1)
+		sink := &recordingSink{err: errors.New("disk full")}
+		snapshotter, err := NewMetricsSnapshotter(NewRegistry(nil), SnapshotConfig{
+			Sink:    sink,
+			Clock:   clock,
+			OnError: func(err error) { errs <- err },
+		})
+		require.NoError(t, err)
+
+		waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		require.NoError(t, clock.BlockUntil(waitCtx, 1))
+		clock.Advance(time.Minute)
+		assert.EqualError(t, <-errs, "disk full")
+		assert.EqualError(t, snapshotter.Close(ctx), "disk full")
+	})
+
+	t.Run("requires a sink", func(t *testing.T) {
+		_, err := NewMetricsSnapshotter(NewRegistry(nil), SnapshotConfig{})
+		assert.Error(t, err)
+	})
+}
+
+func TestFileSnapshotSink(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	snapshot := MetricsSnapshot{Time: start, Executors: []ExecutorSnapshot{{Name: "payments", Calls: 3}}}
+	files := func(t *testing.T, dir string) []string {
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		return names
+	}
+
+	t.Run("writes json lines", func(t *testing.T) {
+		dir := t.TempDir()
+		sink, err := NewFileSnapshotSink(FileSnapshotSinkConfig{Dir: dir, Clock: NewFakeClock(start)})
+		require.NoError(t, err)
+		require.NoError(t, sink.WriteSnapshot(ctx, snapshot))
+		require.NoError(t, sink.WriteSnapshot(ctx, snapshot))
+		require.NoError(t, sink.Close())
+
+		names := files(t, dir)
+		require.Equal(t, []string{"resilience-metrics-20260101T000000.000000000Z.jsonl"}, names)
+		f, err := os.Open(filepath.Join(dir, names[0]))
+		require.NoError(t, err)
+		defer f.Close()
+
+		var lines int
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var decoded MetricsSnapshot
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &decoded))
+			assert.Equal(t, snapshot, decoded)
+			lines++
+		}
+		assert.Equal(t, 2, lines)
+	})
+
+	t.Run("rotates by interval and keeps MaxFiles", func(t *testing.T) {
+		dir := t.TempDir()
+		clock := NewFakeClock(start)
+		sink, err := NewFileSnapshotSink(FileSnapshotSinkConfig{Dir: dir, Prefix: "m", RotateInterval: time.Hour, MaxFiles: 2, Clock: clock})
+		require.NoError(t, err)
+		defer sink.Close()
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, sink.WriteSnapshot(ctx, snapshot))
+			clock.Advance(time.Hour)
+		}
+		assert.Equal(t, []string{"m-20260101T010000.000000000Z.jsonl", "m-20260101T020000.000000000Z.jsonl"}, files(t, dir))
+	})
+
+	t.Run("rotates by size and deletes by age", func(t *testing.T) {
+		dir := t.TempDir()
+		clock := NewFakeClock(start)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "unrelated.txt"), nil, 0o644))
+		sink, err := NewFileSnapshotSink(FileSnapshotSinkConfig{Dir: dir, Prefix: "m", MaxFileSize: 1, MaxAge: 90 * time.Minute, Clock: clock})
+		require.NoError(t, err)
+		defer sink.Close()
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, sink.WriteSnapshot(ctx, snapshot))
+			clock.Advance(time.Hour)
+		}
+		assert.Equal(t, []string{"m-20260101T010000.000000000Z.jsonl", "m-20260101T020000.000000000Z.jsonl", "unrelated.txt"}, files(t, dir))
+	})
+}
+
+func TestStatsdSnapshotSink(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	sink, err := NewStatsdSnapshotSink(conn.LocalAddr().String(), "svc")
+	require.NoError(t, err)
+	defer sink.Close()
+
+	require.NoError(t, sink.WriteSnapshot(context.Background(), MetricsSnapshot{Executors: []ExecutorSnapshot{{
+		Name:          "payments:eu",
+		State:         StateOpen,
+		Calls:         10,
+		Rejected:      4,
+		LatencyP95:    250 * time.Millisecond,
+		LateSuccesses: 2,
+	}}}))
+
+	buf := make([]byte, statsdMaxPacket)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"svc.payments_eu.calls:10|g",
+		"svc.payments_eu.rejected:4|g",
+		"svc.payments_eu.requests:0|g",
+		"svc.payments_eu.failures:0|g",
+		"svc.payments_eu.latency_p95_ms:250|g",
+		"svc.payments_eu.late_successes:2|g",
+		"svc.payments_eu.circuit_state:3|g",
+	}, strings.Split(string(buf[:n]), "\n"))
+}
@@ -0,0 +1,214 @@
+package resilience
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// exportVersion is the format version of exported state
+const exportVersion = 1
+
+// exportedBreaker is the serialized form of circuit breaker state
+type exportedBreaker struct {
+	Version        int              `json:"version"`
+	Kind           string           `json:"kind"`
+	Name           string           `json:"name"`
+	State          CircuitState     `json:"state"`
+	StateTime      time.Time        `json:"state_time"`
+	Requests       uint32           `json:"requests"`
+	TotalSuccesses uint32           `json:"total_successes"`
+	TotalFailures  uint32           `json:"total_failures"`
+	ConsecSuccess  uint32           `json:"consecutive_successes"`
+	ConsecFailures uint32           `json:"consecutive_failures"`
+	TotalTimeouts  uint32           `json:"total_timeouts,omitempty"`
+	Since          time.Time        `json:"since,omitzero"`
+	OpenFor        time.Duration    `json:"open_for,omitempty"`
+	Forced         bool             `json:"forced,omitempty"`
+	Window         []exportedBucket `json:"window,omitempty"`
+	TimeoutWindow  []exportedBucket `json:"timeout_window,omitempty"`
+	BaselineWindow []exportedBucket `json:"baseline_window,omitempty"`
+}
+
+// exportedBucket is the serialized form of a sliding window bucket
+type exportedBucket struct {
+	Start     time.Time `json:"start"`
+	Successes uint64    `json:"successes,omitempty"`
+	Failures  uint64    `json:"failures,omitempty"`
+}
+
+// exportBuckets converts window buckets to their serialized form
+func exportBuckets(buckets []bucketCounts) []exportedBucket {
+	if len(buckets) == 0 {
+		return nil
+	}
+	out := make([]exportedBucket, len(buckets))
+	for i, b := range buckets {
+		out[i] = exportedBucket{Start: b.start, Successes: b.successes, Failures: b.failures}
+	}
+	return out
+}
+
+// importBuckets converts serialized buckets back to window buckets
+func importBuckets(buckets []exportedBucket) []bucketCounts {
+	if len(buckets) == 0 {
+		return nil
+	}
+	out := make([]bucketCounts, len(buckets))
+	for i, b := range buckets {
+		out[i] = bucketCounts{start: b.Start, successes: b.Successes, failures: b.Failures}
+	}
+	return out
+}
+
+// exportedRateLimiter is the serialized form of rate limiter state
+type exportedRateLimiter struct {
+	Version  int       `json:"version"`
+	Kind     string    `json:"kind"`
+	Name     string    `json:"name"`
+	Tokens   float64   `json:"tokens"`
+	LastTime time.Time `json:"last_time"`
+}
+
+func (cb *circuitBreaker) Export() []byte {
+	s := cb.snapshot()
+	data, _ := json.Marshal(exportedBreaker{
+		Version:        exportVersion,
+		Kind:           "circuit_breaker",
+		Name:           cb.config.Name,
+		State:          s.state,
+		StateTime:      s.stateTime,
+		Requests:       s.counts.requests,
+		TotalSuccesses: s.counts.totalSuccesses,
+		TotalFailures:  s.counts.totalFailures,
+		ConsecSuccess:  s.counts.consecSuccess,
+		ConsecFailures: s.counts.consecFailures,
+		TotalTimeouts:  s.counts.totalTimeouts,
+		Since:          s.since,
+		OpenFor:        s.openFor,
+		Forced:         s.forced,
+		Window:         exportBuckets(s.window),
+		TimeoutWindow:  exportBuckets(s.timeouts),
+		BaselineWindow: exportBuckets(s.baseline),
+	})
+	return data
+}
+
+func (cb *circuitBreaker) Import(data []byte) error {
+	var e exportedBreaker
+	if err := json.Unmarshal(data, &e); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidState, err)
+	}
+	if e.Version != exportVersion || e.Kind != "circuit_breaker" {
+		return fmt.Errorf("%w: unsupported %s state version %d", ErrInvalidState, e.Kind, e.Version)
+	}
+	if e.Name != cb.config.Name {
+		return fmt.Errorf("%w: state for breaker %q imported into %q", ErrInvalidState, e.Name, cb.config.Name)
+	}
+	if e.State < StateClosed || e.State > StateDegraded {
+		return fmt.Errorf("%w: unknown state %d", ErrInvalidState, e.State)
+	}
+
+	cb.restore(breakerSnapshot{
+		state:     e.State,
+		stateTime: e.StateTime,
+		since:     e.Since,
+		openFor:   e.OpenFor,
+		forced:    e.Forced,
+		window:    importBuckets(e.Window),
+		timeouts:  importBuckets(e.TimeoutWindow),
+		baseline:  importBuckets(e.BaselineWindow),
+		counts: counts{
+			requests:       e.Requests,
+			totalSuccesses: e.TotalSuccesses,
+			totalFailures:  e.TotalFailures,
+			consecSuccess:  e.ConsecSuccess,
+			consecFailures: e.ConsecFailures,
+			totalTimeouts:  e.TotalTimeouts,
+		},
+	})
+	return nil
+}
+
+func (rl *rateLimiter) Export() []byte {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	data, _ := json.Marshal(exportedRateLimiter{
+		Version:  exportVersion,
+		Kind:     "rate_limiter",
+		Name:     rl.config.Name,
+		Tokens:   rl.tokens,
+		LastTime: rl.lastTime,
+	})
+	return data
+}
+
+func (rl *rateLimiter) Import(data []byte) error {
+	var e exportedRateLimiter
+	if err := json.Unmarshal(data, &e); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidState, err)
+	}
+	if e.Version != exportVersion || e.Kind != "rate_limiter" {
+		return fmt.Errorf("%w: unsupported %s state version %d", ErrInvalidState, e.Kind, e.Version)
+	}
+	if e.Name != rl.config.Name {
+		return fmt.Errorf("%w: state for rate limiter %q imported into %q", ErrInvalidState, e.Name, rl.config.Name)
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.tokens = min(max(e.Tokens, 0), float64(rl.config.Burst))
+
+	// A refill time ahead of this instance's clock, whether from clock skew
+	// or a paused quota, would stall refills here; the next quota response
+	// pauses them again
+	now := rl.clock.Now()
+	rl.lastTime = e.LastTime
+	if rl.lastTime.After(now) {
+		rl.lastTime = now
+	}
+	rl.refillTokens(now)
+	rl.gauge.set(now, rl.tokens)
+	return nil
+}
+
+func (l *groupLimiter) Export() []byte {
+	g := l.group
+	last, tokens := unpackTokens(l.refill(l.state.Load(), g.epoch.Load()))
+	data, _ := json.Marshal(exportedRateLimiter{
+		Version:  exportVersion,
+		Kind:     "rate_limiter",
+		Name:     l.name,
+		Tokens:   float64(tokens) / tokenScale,
+		LastTime: g.start.Add(time.Duration(last) * g.config.RefillInterval),
+	})
+	return data
+}
+
+// Import restores state exported by any rate limiter of the same name
+func (l *groupLimiter) Import(data []byte) error {
+	var e exportedRateLimiter
+	if err := json.Unmarshal(data, &e); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidState, err)
+	}
+	if e.Version != exportVersion || e.Kind != "rate_limiter" {
+		return fmt.Errorf("%w: unsupported %s state version %d", ErrInvalidState, e.Kind, e.Version)
+	}
+	if e.Name != l.name {
+		return fmt.Errorf("%w: state for rate limiter %q imported into %q", ErrInvalidState, e.Name, l.name)
+	}
+
+	// Refill epochs stay within a quarter of the epoch range of the
+	// current one, beyond which a bucket would be full anyway. As for a
+	// standalone limiter, a refill time ahead of the group's is not carried
+	// over.
+	g := l.group
+	epoch := g.epoch.Load()
+	offset := e.LastTime.Sub(g.start.Add(time.Duration(epoch)*g.config.RefillInterval)) / g.config.RefillInterval
+	offset = min(max(offset, -1<<30), 0)
+	tokens := uint32(min(max(e.Tokens, 0)*tokenScale, float64(l.burst)))
+	l.state.Store(l.refill(packTokens(epoch+uint32(int32(offset)), tokens), epoch))
+	return nil
+}
@@ -0,0 +1,148 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreakerExportImport(t *testing.T) {
+	config := CircuitBreakerConfig{
+		Name:             "payments",
+		Interval:         time.Minute,
+		Timeout:          time.Minute,
+		FailureThreshold: 0.5,
+		MinRequests:      2,
+	}
+
+	t.Run("transfers open state", func(t *testing.T) {
+		old := NewCircuitBreaker(config)
+		ctx := context.Background()
+		old.Execute(ctx, func(ctx context.Context) error { return errors.New("error") })
+		old.Execute(ctx, func(ctx context.Context) error { return errors.New("error") })
+
+		replacement := NewCircuitBreaker(config)
+		assert.NoError(t, replacement.Import(old.Export()))
+		assert.Equal(t, StateOpen, replacement.State())
+	})
+
+	t.Run("keeps the time in state and opening length without recording a transition", func(t *testing.T) {
+		clock := NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+		damped := config
+		damped.Clock = clock
+		damped.OpenFlapThreshold = 1
+		damped.OpenFlapWindow = time.Hour
+		damped.OpenFlapBackoff = 2
+		old := NewCircuitBreaker(damped)
+		old.Trip()
+		clock.Advance(2 * time.Minute)
+		old.Reset()
+		old.Trip()
+		opened := clock.Now()
+		clock.Advance(30 * time.Second)
+
+		metrics := &stateMetrics{durations: map[CircuitState]time.Duration{}}
+		restored := damped
+		restored.MetricsRecorder = metrics
+		replacement := NewCircuitBreaker(restored)
+		assert.NoError(t, replacement.Import(old.Export()))
+
+		m := replacement.Metrics()
+		assert.Equal(t, StateOpen, m.State)
+		assert.Equal(t, opened, m.StateSince)
+		assert.Equal(t, old.Metrics().OpenDuration, m.OpenDuration)
+		assert.Greater(t, m.OpenDuration, time.Minute)
+		assert.Empty(t, replacement.History())
+		assert.Empty(t, metrics.durations)
+	})
+
+	t.Run("transfers sliding window outcomes until they expire", func(t *testing.T) {
+		clock := NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+		sliding := config
+		sliding.Clock = clock
+		sliding.Interval = 10 * time.Second
+		sliding.MinRequests = 4
+		sliding.WindowType = WindowTypeSliding
+		sliding.WindowBuckets = 10
+		fail := func(ctx context.Context) error { return errors.New("error") }
+
+		old := NewCircuitBreaker(sliding)
+		for i := 0; i < 3; i++ {
+			_ = old.Execute(context.Background(), fail)
+		}
+		state := old.Export()
+		clock.Advance(5 * time.Second)
+
+		replacement := NewCircuitBreaker(sliding)
+		assert.NoError(t, replacement.Import(state))
+		_ = replacement.Execute(context.Background(), fail)
+		assert.Equal(t, StateOpen, replacement.State())
+
+		expired := NewCircuitBreaker(sliding)
+		assert.NoError(t, expired.Import(state))
+		clock.Advance(6 * time.Second)
+		_ = expired.Execute(context.Background(), fail)
+		assert.Equal(t, StateClosed, expired.State())
+	})
+
+	t.Run("keeps forced state", func(t *testing.T) {
+		clock := NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+		forced := config
+		forced.Clock = clock
+		old := NewCircuitBreaker(forced)
+		old.ForceOpen()
+
+		replacement := NewCircuitBreaker(forced)
+		assert.NoError(t, replacement.Import(old.Export()))
+		clock.Advance(2 * forced.Timeout)
+		assert.Equal(t, StateOpen, replacement.State())
+		assert.True(t, replacement.Metrics().Forced)
+	})
+
+	t.Run("rejects mismatched or malformed state", func(t *testing.T) {
+		other := config
+		other.Name = "ledger"
+		cb := NewCircuitBreaker(config)
+
+		assert.ErrorIs(t, cb.Import(NewCircuitBreaker(other).Export()), ErrInvalidState)
+		assert.ErrorIs(t, cb.Import([]byte("garbage")), ErrInvalidState)
+		assert.ErrorIs(t, cb.Import(NewRateLimiter(RateLimiterConfig{Name: "payments"}).Export()), ErrInvalidState)
+	})
+}
+
+func TestRateLimiterExportImport(t *testing.T) {
+	config := RateLimiterConfig{Name: "api", Rate: 0.001, Burst: 3}
+
+	old := NewRateLimiter(config)
+	old.Allow()
+	old.Allow()
+	old.Allow()
+
+	replacement := NewRateLimiter(config)
+	assert.NoError(t, replacement.Import(old.Export()))
+	assert.False(t, replacement.Allow())
+
+	assert.ErrorIs(t, replacement.Import([]byte("{}")), ErrInvalidState)
+
+	t.Run("refills on the importing clock", func(t *testing.T) {
+		clock := NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+		config := RateLimiterConfig{Name: "api", Rate: 1, Burst: 3, Clock: clock}
+		ahead := NewFakeClock(clock.Now().Add(time.Hour))
+		skewed := config
+		skewed.Clock = ahead
+
+		old := NewRateLimiter(skewed)
+		for i := 0; i < 3; i++ {
+			assert.True(t, old.Allow())
+		}
+		replacement := NewRateLimiter(config)
+		assert.NoError(t, replacement.Import(old.Export()))
+		assert.False(t, replacement.Allow())
+
+		clock.Advance(time.Second)
+		assert.True(t, replacement.Allow())
+	})
+}
@@ -0,0 +1,136 @@
+package resilience
+
+// Trigger is the event that causes a circuit breaker transition
+type Trigger string
+
+const (
+	// TriggerRequest is a call admitted by the breaker
+	TriggerRequest Trigger = "request"
+
+	// TriggerSuccess is a call that completed without a failure
+	TriggerSuccess Trigger = "success"
+
+	// TriggerFailure is a call that failed
+	TriggerFailure Trigger = "failure"
+
+	// TriggerTimeout is a call that failed by timing out
+	TriggerTimeout Trigger = "timeout"
+
+	// TriggerProbeSuccess is a HealthProbe succeeding
+	TriggerProbeSuccess Trigger = "probe_success"
+
+	// TriggerFleetCounts is a CountCoordinator returning fleet totals
+	TriggerFleetCounts Trigger = "fleet_counts"
+
+	// TriggerRemoteOpen is another instance or group member announcing a trip
+	TriggerRemoteOpen Trigger = "remote_open"
+
+	// TriggerRemoteClose is another instance or group member announcing a
+	// recovery
+	TriggerRemoteClose Trigger = "remote_close"
+
+	// TriggerTrip is a call to Trip
+	TriggerTrip Trigger = "trip"
+
+	// TriggerForceOpen is a call to ForceOpen
+	TriggerForceOpen Trigger = "force_open"
+
+	// TriggerForceClosed is a call to ForceClosed
+	TriggerForceClosed Trigger = "force_closed"
+
+	// TriggerDisable is a call to Disable
+	TriggerDisable Trigger = "disable"
+
+	// TriggerReset is a call to Reset
+	TriggerReset Trigger = "reset"
+)
+
+// TransitionRule is an edge of the circuit breaker state machine
+type TransitionRule struct {
+	// From is the state the transition leaves
+	From CircuitState
+
+	// To is the state the transition enters
+	To CircuitState
+
+	// Trigger is the event that causes the transition
+	Trigger Trigger
+
+	// Guard is the condition under which the trigger causes the transition
+	Guard string
+
+	// Action describes what the breaker does besides changing state
+	Action string
+}
+
+// Guards and actions shared by several transitions
+const (
+	guardUnforced       = "not forced"
+	guardTripUnforced   = "trip condition met, past warmup_period, trip_mode binary, not forced"
+	guardRemote         = "event newer than the local transition, not forced"
+	guardDegrade        = "failure ratio reaches degraded_threshold after min_requests, trip condition not met, past warmup_period, trip_mode binary, not forced"
+	actionNone          = ""
+	actionGeneration    = "start a new generation of counts"
+	actionPublish       = "publish to the Coordinator"
+	actionPublishGen    = "start a new generation of counts; publish to the Coordinator"
+	actionPinGen        = "pin the state until Reset; start a new generation of counts"
+	actionPinPublish    = "pin the state until Reset; publish to the Coordinator"
+	actionPinPublishGen = "pin the state until Reset; start a new generation of counts; publish to the Coordinator"
+)
+
+// StateMachine returns every transition a circuit breaker can make, with
+// the guard that must hold and the action taken. States set directly by
+// Import, Warm or CircuitBreakerConfig.Disabled are not transitions.
+// Transitions to the current state are no-ops and are not listed.
+func StateMachine() []TransitionRule {
+	return []TransitionRule{
+		// Closed
+		{StateClosed, StateOpen, TriggerFailure, guardTripUnforced, actionPublish},
+		{StateClosed, StateOpen, TriggerTimeout, guardTripUnforced + " (timeouts per timeout_mode)", actionPublish},
+		{StateClosed, StateOpen, TriggerFleetCounts, "fleet totals meet the ratio condition, past warmup_period, trip_mode binary, not forced", actionPublish},
+		{StateClosed, StateOpen, TriggerRemoteOpen, guardRemote, actionNone},
+		{StateClosed, StateOpen, TriggerTrip, guardUnforced, actionPublish},
+		{StateClosed, StateOpen, TriggerForceOpen, "", actionPinPublish},
+		{StateClosed, StateDegraded, TriggerFailure, guardDegrade, actionNone},
+		{StateClosed, StateDegraded, TriggerTimeout, guardDegrade + " (timeouts per timeout_mode)", actionNone},
+		{StateClosed, StateDisabled, TriggerDisable, "", actionPinGen},
+
+		// Degraded: like closed, but only calls marked high priority pass
+		{StateDegraded, StateOpen, TriggerFailure, guardTripUnforced, actionPublish},
+		{StateDegraded, StateOpen, TriggerTimeout, guardTripUnforced + " (timeouts per timeout_mode)", actionPublish},
+		{StateDegraded, StateOpen, TriggerFleetCounts, "fleet totals meet the ratio condition, past warmup_period, trip_mode binary, not forced", actionPublish},
+		{StateDegraded, StateOpen, TriggerRemoteOpen, guardRemote, actionNone},
+		{StateDegraded, StateOpen, TriggerTrip, guardUnforced, actionPublish},
+		{StateDegraded, StateOpen, TriggerForceOpen, "", actionPinPublish},
+		{StateDegraded, StateClosed, TriggerSuccess, "failure ratio below degraded_threshold after min_requests, not forced", actionGeneration},
+		{StateDegraded, StateClosed, TriggerForceClosed, "", actionPinPublishGen},
+		{StateDegraded, StateClosed, TriggerReset, "", actionPublishGen},
+		{StateDegraded, StateDisabled, TriggerDisable, "", actionPinGen},
+
+		// Open
+		{StateOpen, StateHalfOpen, TriggerRequest, "open for longer than the open duration (timeout, min_open_duration, flap damping), no health_probe, not forced", actionGeneration},
+		{StateOpen, StateHalfOpen, TriggerProbeSuccess, "health_probe started by a request after the open duration succeeded, not forced", actionGeneration},
+		{StateOpen, StateClosed, TriggerRemoteClose, guardRemote + ", open for at least min_open_duration", actionGeneration},
+		{StateOpen, StateClosed, TriggerForceClosed, "", actionPinPublishGen},
+		{StateOpen, StateClosed, TriggerReset, "", actionPublishGen},
+		{StateOpen, StateDisabled, TriggerDisable, "", actionPinGen},
+
+		// Half-open
+		{StateHalfOpen, StateClosed, TriggerSuccess, "half_open_mode fixed: consecutive successes reach half_open_success_threshold; ramp: ramp complete", actionPublishGen},
+		{StateHalfOpen, StateClosed, TriggerRequest, "half_open_mode ramp, ramp complete", actionPublishGen},
+		{StateHalfOpen, StateOpen, TriggerFailure, guardUnforced, actionPublish},
+		{StateHalfOpen, StateOpen, TriggerTimeout, guardUnforced, actionPublish},
+		{StateHalfOpen, StateOpen, TriggerRemoteOpen, guardRemote, actionNone},
+		{StateHalfOpen, StateOpen, TriggerTrip, guardUnforced, actionPublish},
+		{StateHalfOpen, StateOpen, TriggerForceOpen, "", actionPinPublish},
+		{StateHalfOpen, StateClosed, TriggerRemoteClose, guardRemote, actionGeneration},
+		{StateHalfOpen, StateClosed, TriggerForceClosed, "", actionPinPublishGen},
+		{StateHalfOpen, StateClosed, TriggerReset, "", actionPublishGen},
+		{StateHalfOpen, StateDisabled, TriggerDisable, "", actionPinGen},
+
+		// Disabled
+		{StateDisabled, StateOpen, TriggerForceOpen, "", actionPinPublish},
+		{StateDisabled, StateClosed, TriggerForceClosed, "", actionPinPublishGen},
+		{StateDisabled, StateClosed, TriggerReset, "", actionPublishGen},
+	}
+}
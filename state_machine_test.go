@@ -0,0 +1,177 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// modelOp is an operation applied to a breaker by the model test, with the
+// triggers it may fire
+type modelOp struct {
+	name     string
+	triggers []Trigger
+	manual   bool // a manual control that may leave forced states
+	apply    func(cb *circuitBreaker, clock *time.Time, r *rand.Rand) error
+}
+
+func modelOps() []modelOp {
+	ctx := context.Background()
+	call := func(err error) func(cb *circuitBreaker, clock *time.Time, r *rand.Rand) error {
+		return func(cb *circuitBreaker, clock *time.Time, r *rand.Rand) error {
+			return cb.Execute(ctx, func(ctx context.Context) error { return err })
+		}
+	}
+	priority := func(err error) func(cb *circuitBreaker, clock *time.Time, r *rand.Rand) error {
+		return func(cb *circuitBreaker, clock *time.Time, r *rand.Rand) error {
+			return cb.Execute(WithHighPriority(ctx), func(ctx context.Context) error { return err })
+		}
+	}
+	remote := func(state CircuitState) func(cb *circuitBreaker, clock *time.Time, r *rand.Rand) error {
+		return func(cb *circuitBreaker, clock *time.Time, r *rand.Rand) error {
+			cb.onCoordinationEvent(CoordinationEvent{Breaker: cb.Name(), State: state, Time: clock.Add(time.Millisecond)})
+			return nil
+		}
+	}
+	manual := func(fn func(cb *circuitBreaker)) func(cb *circuitBreaker, clock *time.Time, r *rand.Rand) error {
+		return func(cb *circuitBreaker, clock *time.Time, r *rand.Rand) error {
+			fn(cb)
+			return nil
+		}
+	}
+
+	return []modelOp{
+		{name: "success", triggers: []Trigger{TriggerRequest, TriggerSuccess}, apply: call(nil)},
+		{name: "failure", triggers: []Trigger{TriggerRequest, TriggerFailure}, apply: call(errors.New("error"))},
+		{name: "priority success", triggers: []Trigger{TriggerRequest, TriggerSuccess}, apply: priority(nil)},
+		{name: "priority failure", triggers: []Trigger{TriggerRequest, TriggerFailure}, apply: priority(errors.New("error"))},
+		{name: "priority timeout", triggers: []Trigger{TriggerRequest, TriggerTimeout}, apply: priority(ErrTimeout)},
+		{name: "timeout", triggers: []Trigger{TriggerRequest, TriggerTimeout}, apply: call(ErrTimeout)},
+		{name: "wait", apply: func(cb *circuitBreaker, clock *time.Time, r *rand.Rand) error {
+			*clock = clock.Add(time.Duration(r.Int63n(int64(6 * time.Second))))
+			return nil
+		}},
+		{name: "remote open", triggers: []Trigger{TriggerRemoteOpen}, apply: remote(StateOpen)},
+		{name: "remote close", triggers: []Trigger{TriggerRemoteClose}, apply: remote(StateClosed)},
+		{name: "trip", triggers: []Trigger{TriggerTrip}, apply: manual((*circuitBreaker).Trip)},
+		{name: "force open", triggers: []Trigger{TriggerForceOpen}, manual: true, apply: manual((*circuitBreaker).ForceOpen)},
+		{name: "force closed", triggers: []Trigger{TriggerForceClosed}, manual: true, apply: manual((*circuitBreaker).ForceClosed)},
+		{name: "disable", triggers: []Trigger{TriggerDisable}, manual: true, apply: manual((*circuitBreaker).Disable)},
+		{name: "reset", triggers: []Trigger{TriggerReset}, manual: true, apply: manual((*circuitBreaker).Reset)},
+	}
+}
+
+// randomModelConfig picks a configuration covering the breaker's modes
+func randomModelConfig(r *rand.Rand) CircuitBreakerConfig {
+	pick := func(options ...string) string { return options[r.Intn(len(options))] }
+	return CircuitBreakerConfig{
+		Name:                     "model",
+		MaxRequests:              uint32(1 + r.Intn(3)),
+		HalfOpenSuccessThreshold: uint32(r.Intn(3)),
+		Interval:                 10 * time.Second,
+		Timeout:                  5 * time.Second,
+		MinRequests:              uint32(1 + r.Intn(5)),
+		FailureThreshold:         0.5,
+		TripStrategy:             pick(TripStrategyRatio, TripStrategyConsecutive, TripStrategyAdaptive),
+		ConsecutiveFailures:      uint32(1 + r.Intn(3)),
+		BaselineMinRequests:      5,
+		TripMode:                 pick(TripModeBinary, TripModeBinary, TripModeProportional),
+		TimeoutMode:              pick(TimeoutModeFailure, TimeoutModeDouble, TimeoutModeSeparate),
+		WindowType:               pick(WindowTypeFixed, WindowTypeSliding),
+		HalfOpenMode:             pick(HalfOpenModeFixed, HalfOpenModeRamp),
+		RampDuration:             9 * time.Second,
+		DegradedThreshold:        []float64{0, 0.25}[r.Intn(2)],
+		SyncStateChange:          true,
+	}
+}
+
+func TestStateMachineModel(t *testing.T) {
+	type edge struct {
+		from, to CircuitState
+		trigger  Trigger
+	}
+	rules := make(map[edge]bool)
+	for _, rule := range StateMachine() {
+		e := edge{rule.From, rule.To, rule.Trigger}
+		require.False(t, rules[e], "duplicate rule %v", e)
+		rules[e] = true
+	}
+	covered := make(map[edge]bool)
+	ops := modelOps()
+
+	property := func(seed int64) bool {
+		r := rand.New(rand.NewSource(seed))
+		var changes []edge
+		config := randomModelConfig(r)
+		config.OnStateChange = func(name string, from, to CircuitState) {
+			changes = append(changes, edge{from: from, to: to})
+		}
+		cb := NewCircuitBreaker(config).(*circuitBreaker)
+		clock := cb.stateTime
+		cb.now = func() time.Time { return clock }
+
+		for step := 0; step < 200; step++ {
+			op := ops[r.Intn(len(ops))]
+			before := cb.State()
+			forced := cb.Metrics().Forced
+			changes = changes[:0]
+
+			err := op.apply(cb, &clock, r)
+
+			if before == StateDisabled && errors.Is(err, ErrCircuitOpen) {
+				t.Logf("seed %d step %d: disabled breaker rejected a call", seed, step)
+				return false
+			}
+			if forced && !op.manual && len(changes) > 0 {
+				t.Logf("seed %d step %d: %s changed forced state %v", seed, step, op.name, changes)
+				return false
+			}
+			state := before
+			for _, change := range changes {
+				if change.from != state {
+					t.Logf("seed %d step %d: transition %v from %v", seed, step, change, state)
+					return false
+				}
+				legal := false
+				for _, trigger := range op.triggers {
+					e := edge{change.from, change.to, trigger}
+					if rules[e] {
+						legal = true
+						covered[e] = true
+					}
+				}
+				if !legal {
+					t.Logf("seed %d step %d: %s made illegal transition %v -> %v", seed, step, op.name, change.from, change.to)
+					return false
+				}
+				state = change.to
+			}
+			if cb.State() != state {
+				t.Logf("seed %d step %d: state %v after transitions to %v", seed, step, cb.State(), state)
+				return false
+			}
+		}
+		return true
+	}
+
+	// A fixed source keeps the walks, and so rule coverage, reproducible
+	require.NoError(t, quick.Check(property, &quick.Config{
+		MaxCount: 1000,
+		Rand:     rand.New(rand.NewSource(1)),
+	}))
+
+	// Every rule but the coordinator's fleet counts and the asynchronous
+	// health probe is reachable locally
+	for e := range rules {
+		if e.trigger == TriggerFleetCounts || e.trigger == TriggerProbeSuccess {
+			continue
+		}
+		assert.True(t, covered[e], "rule %v -> %v on %s never exercised", e.from, e.to, e.trigger)
+	}
+}
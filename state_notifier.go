@@ -0,0 +1,53 @@
+package resilience
+
+import "sync"
+
+// stateChange is a queued circuit breaker transition
+type stateChange struct {
+	from, to CircuitState
+}
+
+// stateNotifier delivers state changes to OnStateChange off the breaker's
+// lock. Changes are queued without blocking and delivered one at a time in
+// transition order by a goroutine that runs only while changes are pending.
+type stateNotifier struct {
+	name     string
+	callback OnStateChange
+	mu       sync.Mutex
+	queue    []stateChange
+	running  bool
+}
+
+// newStateNotifier creates a notifier for the named breaker
+func newStateNotifier(name string, callback OnStateChange) *stateNotifier {
+	return &stateNotifier{name: name, callback: callback}
+}
+
+// notify queues a change for delivery
+func (n *stateNotifier) notify(change stateChange) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.queue = append(n.queue, change)
+	if !n.running {
+		n.running = true
+		go n.run()
+	}
+}
+
+// run delivers queued changes until the queue is empty
+func (n *stateNotifier) run() {
+	for {
+		n.mu.Lock()
+		if len(n.queue) == 0 {
+			n.running = false
+			n.mu.Unlock()
+			return
+		}
+		change := n.queue[0]
+		n.queue = n.queue[1:]
+		n.mu.Unlock()
+
+		n.callback(n.name, change.from, change.to)
+	}
+}
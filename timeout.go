@@ -2,79 +2,262 @@ package resilience
 
 import (
 	"context"
+	"errors"
+	"math/rand"
+	"sync/atomic"
 	"time"
 )
 
+// MaxTimeoutJitter caps TimeoutConfig.Jitter, so a jittered call keeps at
+// least half of the configured duration
+const MaxTimeoutJitter = 0.5
+
 // timeout implements the Timeout interface
 type timeout struct {
 	duration time.Duration
 	name     string
+	config   TimeoutConfig
+	clock    Clock
+	stats    *timeoutStats
+}
+
+// timeoutStats holds the counters behind TimeoutStats, shared by extended
+// copies of a timeout
+type timeoutStats struct {
+	timeouts      atomic.Uint64
+	lateSuccesses atomic.Uint64
+	lateFailures  atomic.Uint64
+}
+
+// States of a call run under a timeout
+const (
+	callRunning int32 = iota
+	callReturned
+	callAbandoned
+)
+
+// timeoutCall decides whether a call returned in time or was abandoned, so
+// exactly one of the caller and the function's goroutine handles its result
+type timeoutCall struct {
+	state      atomic.Int32
+	abandoned  time.Time
+	timedOut   bool
+	generation *genCounts // LateResultBreaker generation when the call started
+}
+
+// timeoutResult is the outcome of a function run under a timeout
+type timeoutResult struct {
+	value any
+	err   error
 }
 
 // NewTimeout creates a new timeout
 func NewTimeout(duration time.Duration, name string) Timeout {
-	if duration == 0 {
-		duration = DefaultTimeoutConfig().Duration
+	return NewTimeoutWithConfig(TimeoutConfig{Duration: duration}, name)
+}
+
+// NewTimeoutWithConfig creates a new timeout that also reports functions
+// ignoring cancellation when config.StragglerThreshold is set
+func NewTimeoutWithConfig(config TimeoutConfig, name string) Timeout {
+	if config.Duration == 0 {
+		config.Duration = DefaultTimeoutConfig().Duration
 	}
 	if name == "" {
 		name = "default"
 	}
+	config.Jitter = min(max(config.Jitter, 0), MaxTimeoutJitter)
 
 	return &timeout{
-		duration: duration,
+		duration: config.Duration,
 		name:     name,
+		config:   config,
+		clock:    clockOrSystem(config.Clock),
+		stats:    &timeoutStats{},
 	}
 }
 
+// extendTimeout returns a copy of t with its duration extended by d
+func extendTimeout(t Timeout, d time.Duration) Timeout {
+	if tt, ok := t.(*timeout); ok {
+		config := tt.config
+		config.Duration = tt.duration + d
+		extended := NewTimeoutWithConfig(config, tt.name).(*timeout)
+		extended.stats = tt.stats
+		return extended
+	}
+	return NewTimeout(t.Duration()+d, t.Name())
+}
+
 func (t *timeout) Name() string {
 	return t.name
 }
 
+func (t *timeout) Duration() time.Duration {
+	return t.duration
+}
+
+func (t *timeout) Stats() TimeoutStats {
+	return TimeoutStats{
+		Timeouts:      t.stats.timeouts.Load(),
+		LateSuccesses: t.stats.lateSuccesses.Load(),
+		LateFailures:  t.stats.lateFailures.Load(),
+	}
+}
+
 func (t *timeout) Execute(ctx context.Context, fn func(context.Context) error) error {
+	_, err := t.ExecuteWithResult(ctx, func(ctx context.Context) (any, error) {
+		return nil, fn(ctx)
+	})
+	return err
+}
+
+func (t *timeout) ExecuteWithResult(ctx context.Context, fn func(context.Context) (any, error)) (any, error) {
 	// Create timeout context
-	timeoutCtx, cancel := context.WithTimeout(ctx, t.duration)
+	timeoutCtx, cancel := withTimeout(ctx, t.clock, t.callDuration())
 	defer cancel()
 
-	// Execute with timeout
-	errChan := make(chan error, 1)
+	// Execute with timeout; the result is sent before the call is marked
+	// returned, so a caller finding it returned can always receive it
+	call := &timeoutCall{}
+	if cb, ok := t.config.LateResultBreaker.(*circuitBreaker); ok {
+		call.generation = cb.currentGeneration()
+	}
+	resultChan := make(chan timeoutResult, 1)
+	goid := make(chan uint64, 1)
 	go func() {
-		errChan <- fn(timeoutCtx)
+		if t.detectStragglers() && t.config.CaptureStack {
+			goid <- goroutineID()
+		}
+		value, err := fn(timeoutCtx)
+		resultChan <- timeoutResult{value: value, err: err}
+		if !call.state.CompareAndSwap(callRunning, callReturned) {
+			t.lateResult(call, err)
+		}
 	}()
 
+	var res timeoutResult
 	select {
-	case err := <-errChan:
-		return err
+	case res = <-resultChan:
 	case <-timeoutCtx.Done():
-		if timeoutCtx.Err() == context.DeadlineExceeded {
-			return ErrTimeout
+		call.abandoned = t.clock.Now()
+		call.timedOut = timedOut(timeoutCtx)
+		if call.state.CompareAndSwap(callRunning, callAbandoned) {
+			if t.detectStragglers() {
+				go t.watchStraggler(resultChan, goid)
+			}
+			if call.timedOut {
+				t.stats.timeouts.Add(1)
+				return nil, ErrTimeout
+			}
+			return nil, timeoutCtx.Err()
 		}
-		return timeoutCtx.Err()
+		// The function returned as the context expired
+		res = <-resultChan
 	}
+
+	// A function giving up with its expired context timed out all the same
+	if res.err != nil && contextError(res.err) && timedOut(timeoutCtx) {
+		t.stats.timeouts.Add(1)
+		return nil, ErrTimeout
+	}
+	return res.value, res.err
 }
 
-func (t *timeout) ExecuteWithResult(ctx context.Context, fn func(context.Context) (any, error)) (any, error) {
-	// Create timeout context
-	timeoutCtx, cancel := context.WithTimeout(ctx, t.duration)
-	defer cancel()
+// timedOut reports whether ctx, made by withTimeout, is done because the
+// timeout expired rather than its parent being done
+func timedOut(ctx context.Context) bool {
+	return ctx.Err() == context.DeadlineExceeded || context.Cause(ctx) == ErrTimeout
+}
 
-	// Execute with timeout
-	type result struct {
-		value any
-		err   error
+// lateResult accounts for the outcome err of an abandoned call. Errors
+// from the function giving up with its context say nothing new about a
+// timed out call, so they are only reported.
+func (t *timeout) lateResult(call *timeoutCall, err error) {
+	if call.timedOut && !contextError(err) {
+		if err == nil {
+			t.stats.lateSuccesses.Add(1)
+		} else {
+			t.stats.lateFailures.Add(1)
+		}
+		if t.config.LateResultBreaker != nil {
+			recordDelayed(t.config.LateResultBreaker, call.generation, err)
+		}
 	}
-	resultChan := make(chan result, 1)
-	go func() {
-		value, err := fn(timeoutCtx)
-		resultChan <- result{value: value, err: err}
-	}()
+	if t.config.OnLateResult != nil {
+		t.config.OnLateResult(t.name, LateResult{
+			Err:      err,
+			Late:     t.clock.Now().Sub(call.abandoned),
+			TimedOut: call.timedOut,
+		})
+	}
+}
+
+// contextError reports whether err is a context's cancellation or expiry
+func contextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrTimeout)
+}
+
+// recordDelayed records the late outcome err of a timed out call started in
+// generation with breaker; breakers other than this package's need to
+// admit it
+func recordDelayed(breaker CircuitBreaker, generation *genCounts, err error) {
+	if cb, ok := breaker.(*circuitBreaker); ok {
+		cb.recordDelayed(generation, err)
+		return
+	}
+	if permit, allowErr := breaker.Allow(); allowErr == nil {
+		permit.Record(err)
+	}
+}
+
+// withTimeout returns a context canceled with cause ErrTimeout after d on
+// clock. With the system clock it carries the deadline; other clocks
+// cancel it when their timer fires.
+func withTimeout(ctx context.Context, clock Clock, d time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := clock.(systemClock); ok {
+		return context.WithTimeoutCause(ctx, d, ErrTimeout)
+	}
+	timeoutCtx, cancel := context.WithCancelCause(ctx)
+	timer := clock.AfterFunc(d, func() { cancel(ErrTimeout) })
+	return timeoutCtx, func() {
+		timer.Stop()
+		cancel(context.Canceled)
+	}
+}
+
+// callDuration returns the duration for one call, spread by up to ±Jitter
+// so calls started together don't all expire at once
+func (t *timeout) callDuration() time.Duration {
+	if t.config.Jitter == 0 {
+		return t.duration
+	}
+	spread := (rand.Float64()*2 - 1) * t.config.Jitter
+	return t.duration + time.Duration(spread*float64(t.duration))
+}
+
+func (t *timeout) detectStragglers() bool {
+	return t.config.StragglerThreshold > 0 && t.config.OnStraggler != nil
+}
+
+// watchStraggler reports fn if it keeps running past the straggler
+// threshold after its context was canceled
+func (t *timeout) watchStraggler(done <-chan timeoutResult, goid <-chan uint64) {
+	timer := t.clock.NewTimer(t.config.StragglerThreshold)
+	defer timer.Stop()
 
 	select {
-	case res := <-resultChan:
-		return res.value, res.err
-	case <-timeoutCtx.Done():
-		if timeoutCtx.Err() == context.DeadlineExceeded {
-			return nil, ErrTimeout
+	case <-done:
+		return
+	case <-timer.C():
+	}
+
+	var stack []byte
+	if t.config.CaptureStack {
+		select {
+		case id := <-goid:
+			stack = goroutineStack(id)
+		default:
 		}
-		return nil, timeoutCtx.Err()
 	}
+	t.config.OnStraggler(t.name, t.config.StragglerThreshold, stack)
 }
@@ -2,6 +2,7 @@ package resilience
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -40,3 +41,268 @@ func TestTimeoutExecution(t *testing.T) {
 		assert.Equal(t, ErrTimeout, err)
 	})
 }
+
+func TestTimeoutStragglers(t *testing.T) {
+	type report struct {
+		name  string
+		stack []byte
+	}
+
+	newTimeout := func(reports chan report) Timeout {
+		return NewTimeoutWithConfig(TimeoutConfig{
+			Duration:           10 * time.Millisecond,
+			StragglerThreshold: 20 * time.Millisecond,
+			CaptureStack:       true,
+			OnStraggler: func(name string, elapsed time.Duration, stack []byte) {
+				reports <- report{name: name, stack: stack}
+			},
+		}, "test")
+	}
+
+	t.Run("reports functions ignoring cancellation", func(t *testing.T) {
+		reports := make(chan report, 1)
+		timeout := newTimeout(reports)
+		release := make(chan struct{})
+		defer close(release)
+
+		err := timeout.Execute(context.Background(), func(ctx context.Context) error {
+			ignoreCancellation(release)
+			return nil
+		})
+		assert.Equal(t, ErrTimeout, err)
+
+		select {
+		case r := <-reports:
+			assert.Equal(t, "test", r.name)
+			assert.Contains(t, string(r.stack), "ignoreCancellation")
+		case <-time.After(time.Second):
+			t.Fatal("straggler not reported")
+		}
+	})
+
+	t.Run("does not report functions honoring cancellation", func(t *testing.T) {
+		reports := make(chan report, 1)
+		timeout := newTimeout(reports)
+
+		err := timeout.Execute(context.Background(), func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		assert.Equal(t, ErrTimeout, err)
+
+		select {
+		case <-reports:
+			t.Fatal("unexpected straggler report")
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+
+	t.Run("extended timeouts keep straggler settings", func(t *testing.T) {
+		reports := make(chan report, 1)
+		extended := extendTimeout(newTimeout(reports), time.Second).(*timeout)
+
+		assert.Equal(t, 1010*time.Millisecond, extended.Duration())
+		assert.Equal(t, 20*time.Millisecond, extended.config.StragglerThreshold)
+		assert.NotNil(t, extended.config.OnStraggler)
+	})
+}
+
+// ignoreCancellation blocks without watching any context
+func ignoreCancellation(release <-chan struct{}) {
+	<-release
+}
+
+func TestTimeoutJitter(t *testing.T) {
+	t.Run("spreads call durations within bounds", func(t *testing.T) {
+		tm := NewTimeoutWithConfig(TimeoutConfig{Duration: time.Second, Jitter: 0.2}, "test").(*timeout)
+		seen := make(map[time.Duration]bool)
+		for i := 0; i < 100; i++ {
+			d := tm.callDuration()
+			assert.GreaterOrEqual(t, d, 800*time.Millisecond)
+			assert.LessOrEqual(t, d, 1200*time.Millisecond)
+			seen[d] = true
+		}
+		assert.Greater(t, len(seen), 1)
+		assert.Equal(t, time.Second, tm.Duration())
+	})
+
+	t.Run("zero jitter keeps the configured duration", func(t *testing.T) {
+		tm := NewTimeout(time.Second, "test").(*timeout)
+		assert.Equal(t, time.Second, tm.callDuration())
+	})
+
+	t.Run("caps jitter to keep half the duration", func(t *testing.T) {
+		tm := NewTimeoutWithConfig(TimeoutConfig{Duration: time.Second, Jitter: 1}, "test").(*timeout)
+		for i := 0; i < 100; i++ {
+			assert.GreaterOrEqual(t, tm.callDuration(), 500*time.Millisecond)
+		}
+		assert.Equal(t, MaxTimeoutJitter, tm.config.Jitter)
+	})
+
+	t.Run("extended timeouts keep jitter", func(t *testing.T) {
+		tm := NewTimeoutWithConfig(TimeoutConfig{Duration: time.Second, Jitter: 5}, "test")
+		extended := extendTimeout(tm, time.Second).(*timeout)
+		assert.Equal(t, MaxTimeoutJitter, extended.config.Jitter)
+		assert.Equal(t, 2*time.Second, extended.Duration())
+	})
+}
+
+func TestTimeoutLateResults(t *testing.T) {
+	newTimeout := func(results chan LateResult, breaker CircuitBreaker) Timeout {
+		return NewTimeoutWithConfig(TimeoutConfig{
+			Duration: 10 * time.Millisecond,
+			OnLateResult: func(name string, result LateResult) {
+				results <- result
+			},
+			LateResultBreaker: breaker,
+		}, "test")
+	}
+
+	receive := func(t *testing.T, results chan LateResult) LateResult {
+		t.Helper()
+		select {
+		case result := <-results:
+			return result
+		case <-time.After(time.Second):
+			t.Fatal("late result not reported")
+			return LateResult{}
+		}
+	}
+
+	t.Run("reports and counts late outcomes", func(t *testing.T) {
+		results := make(chan LateResult, 1)
+		timeout := newTimeout(results, nil)
+		errBoom := errors.New("boom")
+
+		for _, want := range []error{nil, errBoom} {
+			release := make(chan struct{})
+			err := timeout.Execute(context.Background(), func(ctx context.Context) error {
+				ignoreCancellation(release)
+				return want
+			})
+			assert.Equal(t, ErrTimeout, err)
+			close(release)
+
+			result := receive(t, results)
+			assert.Equal(t, want, result.Err)
+			assert.True(t, result.TimedOut)
+		}
+		assert.Equal(t, TimeoutStats{Timeouts: 2, LateSuccesses: 1, LateFailures: 1}, timeout.Stats())
+	})
+
+	t.Run("does not count functions honoring cancellation", func(t *testing.T) {
+		results := make(chan LateResult, 1)
+		timeout := newTimeout(results, nil)
+
+		err := timeout.Execute(context.Background(), func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		assert.Equal(t, ErrTimeout, err)
+
+		// The function may return before the call is abandoned
+		select {
+		case result := <-results:
+			assert.ErrorIs(t, result.Err, context.DeadlineExceeded)
+		case <-time.After(50 * time.Millisecond):
+		}
+		assert.Equal(t, TimeoutStats{Timeouts: 1}, timeout.Stats())
+	})
+
+	t.Run("reports calls abandoned by the caller", func(t *testing.T) {
+		results := make(chan LateResult, 1)
+		timeout := NewTimeoutWithConfig(TimeoutConfig{
+			Duration:     time.Second,
+			OnLateResult: func(name string, result LateResult) { results <- result },
+		}, "test")
+		ctx, cancel := context.WithCancel(context.Background())
+		release := make(chan struct{})
+
+		go cancel()
+		err := timeout.Execute(ctx, func(ctx context.Context) error {
+			ignoreCancellation(release)
+			return nil
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+		close(release)
+
+		result := receive(t, results)
+		assert.NoError(t, result.Err)
+		assert.False(t, result.TimedOut)
+		assert.Equal(t, TimeoutStats{}, timeout.Stats())
+	})
+
+	t.Run("records late outcomes with the breaker", func(t *testing.T) {
+		results := make(chan LateResult, 1)
+		breaker := NewCircuitBreaker(CircuitBreakerConfig{Name: "test", MinRequests: 100})
+		timeout := newTimeout(results, breaker)
+		release := make(chan struct{})
+
+		err := breaker.Execute(context.Background(), func(ctx context.Context) error {
+			return timeout.Execute(ctx, func(ctx context.Context) error {
+				ignoreCancellation(release)
+				return nil
+			})
+		})
+		assert.Equal(t, ErrTimeout, err)
+		close(release)
+		receive(t, results)
+
+		m := breaker.Metrics()
+		assert.Equal(t, uint32(2), m.Requests)
+		assert.Equal(t, uint32(1), m.Failures+m.Timeouts)
+		assert.Equal(t, uint32(1), m.Successes)
+	})
+
+	t.Run("drops late outcomes from an earlier breaker generation", func(t *testing.T) {
+		results := make(chan LateResult, 1)
+		breaker := NewCircuitBreaker(CircuitBreakerConfig{Name: "test", MinRequests: 100})
+		timeout := newTimeout(results, breaker)
+		release := make(chan struct{})
+
+		err := breaker.Execute(context.Background(), func(ctx context.Context) error {
+			return timeout.Execute(ctx, func(ctx context.Context) error {
+				ignoreCancellation(release)
+				return nil
+			})
+		})
+		assert.Equal(t, ErrTimeout, err)
+		breaker.Reset()
+		close(release)
+		receive(t, results)
+
+		m := breaker.Metrics()
+		assert.Equal(t, uint32(0), m.Requests)
+		assert.Equal(t, uint32(0), m.Successes)
+		assert.Equal(t, uint64(1), timeout.Stats().LateSuccesses)
+	})
+
+	t.Run("returns results racing the deadline", func(t *testing.T) {
+		timeout := NewTimeout(time.Millisecond, "test")
+		for i := 0; i < 200; i++ {
+			_, err := timeout.ExecuteWithResult(context.Background(), func(ctx context.Context) (any, error) {
+				time.Sleep(time.Millisecond)
+				return nil, nil
+			})
+			if err != nil {
+				assert.Equal(t, ErrTimeout, err)
+			}
+		}
+		assert.Eventually(t, func() bool {
+			stats := timeout.Stats()
+			return stats.LateSuccesses == stats.Timeouts
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("extended timeouts share counters", func(t *testing.T) {
+		timeout := NewTimeout(time.Millisecond, "test")
+		extended := extendTimeout(timeout, 0)
+
+		err := extended.Execute(context.Background(), func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		assert.Equal(t, ErrTimeout, err)
+		assert.Equal(t, uint64(1), timeout.Stats().Timeouts)
+	})
+}
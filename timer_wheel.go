@@ -0,0 +1,239 @@
+package resilience
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultTimerWheelTick is the wheel resolution used when none is given
+const DefaultTimerWheelTick = time.Millisecond
+
+const (
+	wheelBits   = 6
+	wheelSlots  = 1 << wheelBits
+	wheelMask   = wheelSlots - 1
+	wheelLevels = 4 // 64^4 ticks, about 4.6 hours at 1ms
+)
+
+// TimerWheel is a hierarchical timer wheel for retry backoff, rate limiter
+// and bulkhead queue waits. At very high request rates it replaces one runtime timer per
+// waiting call with a single ticker shared by every waiter; the ticker
+// goroutine only runs while timers are pending.
+//
+// Waits are rounded up to the wheel's tick, so a wheel trades timer
+// precision for lower timer pressure. Share one wheel between policies
+// through RetryConfig.TimerWheel, RateLimiterConfig.TimerWheel and
+// BulkheadConfig.TimerWheel.
+type TimerWheel struct {
+	tick    time.Duration
+	clock   Clock
+	start   time.Time
+	mu      sync.Mutex
+	current uint64 // ticks processed since start
+	levels  [wheelLevels][wheelSlots][]*wheelTimer
+	live    int  // pending timers that have not been canceled
+	running bool // ticker goroutine started
+}
+
+// wheelTimer is a pending wait on a TimerWheel
+type wheelTimer struct {
+	expiry   uint64
+	done     chan struct{}
+	canceled bool
+}
+
+// NewTimerWheel creates a timer wheel with the given resolution.
+// A non-positive tick uses DefaultTimerWheelTick.
+func NewTimerWheel(tick time.Duration) *TimerWheel {
+	return NewTimerWheelWithClock(tick, nil)
+}
+
+// NewTimerWheelWithClock is NewTimerWheel ticking on clock; nil uses the
+// system clock. Policies sharing the wheel wait on its clock rather than
+// their own.
+func NewTimerWheelWithClock(tick time.Duration, clock Clock) *TimerWheel {
+	if tick <= 0 {
+		tick = DefaultTimerWheelTick
+	}
+	clock = clockOrSystem(clock)
+	return &TimerWheel{tick: tick, clock: clock, start: clock.Now()}
+}
+
+// Sleep waits for d or until ctx is done, returning ctx.Err() in that case
+func (w *TimerWheel) Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	t := w.add(w.clock.Now(), d)
+	select {
+	case <-t.done:
+		return nil
+	case <-ctx.Done():
+		w.cancel(t)
+		return ctx.Err()
+	}
+}
+
+// add schedules a timer for d after now
+func (w *TimerWheel) add(now time.Time, d time.Duration) *wheelTimer {
+	elapsed := now.Add(d).Sub(w.start)
+	expiry := uint64((elapsed + w.tick - 1) / w.tick)
+	t := &wheelTimer{expiry: expiry, done: make(chan struct{})}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.live == 0 {
+		// Everything left in the slots was canceled; skip the idle ticks
+		w.levels = [wheelLevels][wheelSlots][]*wheelTimer{}
+		if idle := uint64(now.Sub(w.start) / w.tick); idle > w.current {
+			w.current = idle
+		}
+	}
+	if t.expiry <= w.current {
+		close(t.done)
+		return t
+	}
+
+	w.live++
+	w.place(t)
+	if !w.running {
+		w.running = true
+		go w.run()
+	}
+	return t
+}
+
+// place puts a timer in the slot for its expiry; callers hold w.mu
+func (w *TimerWheel) place(t *wheelTimer) {
+	delta := t.expiry - w.current
+	expiry := t.expiry
+	for level := 0; level < wheelLevels; level++ {
+		if delta < 1<<(wheelBits*(level+1)) {
+			slot := (expiry >> (wheelBits * level)) & wheelMask
+			w.levels[level][slot] = append(w.levels[level][slot], t)
+			return
+		}
+	}
+
+	// Beyond the wheel's span: park in the last slot reachable and
+	// re-place when it cascades
+	top := wheelLevels - 1
+	expiry = w.current + 1<<(wheelBits*wheelLevels) - 1
+	slot := (expiry >> (wheelBits * top)) & wheelMask
+	w.levels[top][slot] = append(w.levels[top][slot], t)
+}
+
+// cancel stops a pending timer; its slot entry is dropped when reached
+func (w *TimerWheel) cancel(t *wheelTimer) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	select {
+	case <-t.done:
+		return
+	default:
+	}
+	if !t.canceled {
+		t.canceled = true
+		w.live--
+	}
+}
+
+// run drives the wheel until no timers are pending
+func (w *TimerWheel) run() {
+	timer := w.clock.NewTimer(w.tick)
+	defer timer.Stop()
+
+	for now := range timer.C() {
+		if !w.advance(now) {
+			return
+		}
+		timer.Reset(w.tick)
+	}
+}
+
+// advance processes every tick up to now. It returns false, marking the
+// wheel as stopped, once no timers are pending.
+func (w *TimerWheel) advance(now time.Time) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	target := uint64(now.Sub(w.start) / w.tick)
+	for w.current < target && w.live > 0 {
+		w.current++
+		w.cascade()
+
+		slot := w.current & wheelMask
+		for _, t := range w.levels[0][slot] {
+			if !t.canceled {
+				close(t.done)
+				w.live--
+			}
+		}
+		w.levels[0][slot] = nil
+	}
+
+	if w.live == 0 {
+		w.running = false
+		return false
+	}
+	return true
+}
+
+// cascade moves timers from higher levels down as their slots come due;
+// callers hold w.mu
+func (w *TimerWheel) cascade() {
+	for level := 1; level < wheelLevels; level++ {
+		if w.current&(1<<(wheelBits*level)-1) != 0 {
+			return
+		}
+		slot := (w.current >> (wheelBits * level)) & wheelMask
+		timers := w.levels[level][slot]
+		w.levels[level][slot] = nil
+		for _, t := range timers {
+			if t.canceled {
+				continue
+			}
+			if t.expiry <= w.current {
+				// Fired by the level 0 pass for this tick
+				w.levels[0][w.current&wheelMask] = append(w.levels[0][w.current&wheelMask], t)
+				continue
+			}
+			w.place(t)
+		}
+	}
+}
+
+// sleep waits for d on wheel, or on a timer of clock (the system clock if
+// nil) if wheel is nil
+func sleep(ctx context.Context, d time.Duration, wheel *TimerWheel, clock Clock) error {
+	if wheel != nil {
+		return wheel.Sleep(ctx, d)
+	}
+
+	timer := clockOrSystem(clock).NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// after returns a channel closed once d has passed, timed on wheel or on a
+// timer of clock (the system clock if nil) if wheel is nil, and a func that
+// stops the wait
+func after(d time.Duration, wheel *TimerWheel, clock Clock) (<-chan struct{}, func()) {
+	if wheel != nil {
+		t := wheel.add(wheel.clock.Now(), d)
+		return t.done, func() { wheel.cancel(t) }
+	}
+
+	done := make(chan struct{})
+	timer := clockOrSystem(clock).AfterFunc(d, func() { close(done) })
+	return done, func() { timer.Stop() }
+}
@@ -0,0 +1,137 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimerWheel(t *testing.T) {
+	fired := func(t *wheelTimer) bool {
+		select {
+		case <-t.done:
+			return true
+		default:
+			return false
+		}
+	}
+
+	t.Run("sleeps for the duration", func(t *testing.T) {
+		w := NewTimerWheel(time.Millisecond)
+		start := time.Now()
+		require.NoError(t, w.Sleep(context.Background(), 20*time.Millisecond))
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	})
+
+	t.Run("returns the context error when canceled", func(t *testing.T) {
+		w := NewTimerWheel(time.Millisecond)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		assert.ErrorIs(t, w.Sleep(ctx, time.Hour), context.DeadlineExceeded)
+		require.Eventually(t, func() bool {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			return !w.running
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("fires timers on their tick across levels", func(t *testing.T) {
+		tick := time.Millisecond
+		w := NewTimerWheel(tick)
+		w.running = true // drive the wheel by hand
+
+		ticks := []uint64{1, 63, 64, 65, 4095, 4096, 300_000, 1<<24 + 5}
+		timers := make([]*wheelTimer, len(ticks))
+		for i, n := range ticks {
+			timers[i] = w.add(w.start, time.Duration(n)*tick)
+		}
+
+		for i, n := range ticks {
+			w.advance(w.start.Add(time.Duration(n-1) * tick))
+			assert.False(t, fired(timers[i]), "timer %d fired early", n)
+			w.advance(w.start.Add(time.Duration(n) * tick))
+			assert.True(t, fired(timers[i]), "timer %d did not fire", n)
+		}
+		assert.False(t, w.running)
+	})
+
+	t.Run("ticks on the configured clock", func(t *testing.T) {
+		clock := NewFakeClock(time.Unix(0, 0))
+		w := NewTimerWheelWithClock(10*time.Millisecond, clock)
+
+		done := make(chan error)
+		go func() { done <- w.Sleep(context.Background(), 10*time.Millisecond) }()
+
+		require.NoError(t, clock.BlockUntil(context.Background(), 1))
+		select {
+		case <-done:
+			t.Fatal("slept before the clock moved")
+		default:
+		}
+		clock.Advance(10 * time.Millisecond)
+		require.NoError(t, <-done)
+	})
+
+	t.Run("skips canceled timers", func(t *testing.T) {
+		w := NewTimerWheel(time.Millisecond)
+		w.running = true
+
+		canceled := w.add(w.start, 100*time.Millisecond)
+		kept := w.add(w.start, 200*time.Millisecond)
+		w.cancel(canceled)
+
+		w.advance(w.start.Add(200 * time.Millisecond))
+		assert.False(t, fired(canceled))
+		assert.True(t, fired(kept))
+	})
+
+	t.Run("drives retry backoff and rate limiter waits", func(t *testing.T) {
+		w := NewTimerWheel(time.Millisecond)
+
+		retryConfig := DefaultRetryConfig()
+		retryConfig.InitialInterval = time.Millisecond
+		retryConfig.TimerWheel = w
+		attempts := 0
+		err := NewRetry(retryConfig).Execute(context.Background(), func(ctx context.Context) error {
+			attempts++
+			return errors.New("unavailable")
+		})
+		assert.Error(t, err)
+		assert.Equal(t, 3, attempts)
+
+		limiter := NewRateLimiter(RateLimiterConfig{Rate: 100, Burst: 1, TimerWheel: w})
+		require.NoError(t, limiter.Wait(context.Background()))
+		require.NoError(t, limiter.Wait(context.Background()))
+	})
+}
+
+// BenchmarkSleep100kWaiters compares 100k concurrent backoff waits on
+// runtime timers and on a shared timer wheel
+func BenchmarkSleep100kWaiters(b *testing.B) {
+	const waiters = 100_000
+	ctx := context.Background()
+
+	run := func(b *testing.B, wheel *TimerWheel) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var wg sync.WaitGroup
+			wg.Add(waiters)
+			for j := 0; j < waiters; j++ {
+				go func() {
+					defer wg.Done()
+					_ = sleep(ctx, 10*time.Millisecond, wheel, nil)
+				}()
+			}
+			wg.Wait()
+		}
+	}
+
+	b.Run("runtime timers", func(b *testing.B) { run(b, nil) })
+	b.Run("timer wheel", func(b *testing.B) { run(b, NewTimerWheel(time.Millisecond)) })
+}
@@ -0,0 +1,111 @@
+package resilience
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Rejections by key are counted in one-minute buckets over the last hour
+const (
+	keyRejectionBucket  = time.Minute
+	keyRejectionBuckets = 60
+)
+
+// Stats of a group report its statsTopKeys most rejected keys over the
+// last statsRejectionWindow; groups without a key bound rank at most
+// maxRankedKeys keys per bucket
+const (
+	statsTopKeys         = 10
+	statsRejectionWindow = 5 * time.Minute
+	maxRankedKeys        = 1000
+)
+
+// keyRejections counts rejected calls per key over a trailing hour, to rank
+// the keys of a keyed pattern by how hard they are being throttled. Each
+// bucket tracks at most maxKeys keys; rejections of further keys within
+// the bucket are not counted.
+type keyRejections struct {
+	mu        sync.Mutex
+	now       func() time.Time
+	maxKeys   int
+	buckets   []map[string]uint64
+	head      int
+	headStart time.Time
+}
+
+// newKeyRejections creates an empty rejection counter
+func newKeyRejections(now func() time.Time, maxKeys int) *keyRejections {
+	return &keyRejections{
+		now:       now,
+		maxKeys:   maxKeys,
+		buckets:   make([]map[string]uint64, keyRejectionBuckets),
+		headStart: now().Truncate(keyRejectionBucket),
+	}
+}
+
+// advance rotates the ring so the head bucket covers now. The caller
+// holds r.mu.
+func (r *keyRejections) advance(now time.Time) {
+	steps := int(now.Sub(r.headStart) / keyRejectionBucket)
+	if steps <= 0 {
+		return
+	}
+	for i := 0; i < min(steps, len(r.buckets)); i++ {
+		r.head = (r.head + 1) % len(r.buckets)
+		r.buckets[r.head] = nil
+	}
+	r.headStart = r.headStart.Add(time.Duration(steps) * keyRejectionBucket)
+}
+
+// record counts a rejection of key
+func (r *keyRejections) record(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.advance(r.now())
+	bucket := r.buckets[r.head]
+	if bucket == nil {
+		bucket = make(map[string]uint64)
+		r.buckets[r.head] = bucket
+	}
+	if _, ok := bucket[key]; ok || len(bucket) < r.maxKeys {
+		bucket[key]++
+	}
+}
+
+// top returns up to k keys with the most rejections within window, most
+// rejected first. window is rounded up to whole minutes, at most an hour.
+func (r *keyRejections) top(k int, window time.Duration) []KeyRejections {
+	if k <= 0 {
+		return nil
+	}
+	n := int((window + keyRejectionBucket - 1) / keyRejectionBucket)
+	n = min(max(n, 1), len(r.buckets))
+
+	r.mu.Lock()
+	r.advance(r.now())
+	totals := make(map[string]uint64)
+	for i := 0; i < n; i++ {
+		idx := (r.head - i + len(r.buckets)) % len(r.buckets)
+		for key, count := range r.buckets[idx] {
+			totals[key] += count
+		}
+	}
+	r.mu.Unlock()
+
+	ranked := make([]KeyRejections, 0, len(totals))
+	for key, count := range totals {
+		ranked = append(ranked, KeyRejections{Key: key, Rejections: count})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Rejections != ranked[j].Rejections {
+			return ranked[i].Rejections > ranked[j].Rejections
+		}
+		return ranked[i].Key < ranked[j].Key
+	})
+	if len(ranked) > k {
+		ranked = ranked[:k]
+	}
+	return ranked
+}
@@ -0,0 +1,431 @@
+package resilience
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// TripCondition is a compiled trip_when expression
+type TripCondition struct {
+	source string
+	root   exprNode
+}
+
+// tripVars holds the breaker statistics visible to trip expressions
+type tripVars struct {
+	requests             float64
+	successes            float64
+	failures             float64
+	timeouts             float64
+	consecutiveSuccesses float64
+	consecutiveFailures  float64
+	slowRate             float64
+}
+
+// tripVariables maps identifiers usable in trip expressions to their values
+var tripVariables = map[string]func(v *tripVars) float64{
+	"requests":              func(v *tripVars) float64 { return v.requests },
+	"successes":             func(v *tripVars) float64 { return v.successes },
+	"failures":              func(v *tripVars) float64 { return v.failures },
+	"timeouts":              func(v *tripVars) float64 { return v.timeouts },
+	"consecutive_successes": func(v *tripVars) float64 { return v.consecutiveSuccesses },
+	"consecutive_failures":  func(v *tripVars) float64 { return v.consecutiveFailures },
+	"failure_rate": func(v *tripVars) float64 {
+		if v.requests == 0 {
+			return 0
+		}
+		return v.failures / v.requests
+	},
+	"success_rate": func(v *tripVars) float64 {
+		if v.requests == 0 {
+			return 0
+		}
+		return v.successes / v.requests
+	},
+	"timeout_rate": func(v *tripVars) float64 {
+		if v.requests == 0 {
+			return 0
+		}
+		return v.timeouts / v.requests
+	},
+	"slow_rate": func(v *tripVars) float64 { return v.slowRate },
+}
+
+// CompileTripCondition parses a trip expression such as
+// "failure_rate > 0.5 && slow_rate > 0.3".
+//
+// Supported syntax: numeric literals, the variables requests, successes,
+// failures, timeouts, consecutive_successes, consecutive_failures,
+// failure_rate, success_rate, timeout_rate and slow_rate, arithmetic
+// (+ - * /), comparisons (< <= > >= == !=), logical operators (&& || !)
+// and parentheses. The expression must evaluate to a boolean.
+//
+// timeouts and timeout_rate are nonzero with TimeoutMode "separate" only;
+// slow_rate is the share of recent calls taking SlowCallThreshold or
+// longer, zero without one.
+func CompileTripCondition(expr string) (*TripCondition, error) {
+	root, err := compileTripExpr("trip_when", expr, kindBool)
+	if err != nil {
+		return nil, err
+	}
+	return &TripCondition{source: expr, root: root}, nil
+}
+
+// compileShedShare parses a shed_share expression: the same syntax as
+// CompileTripCondition, evaluating to the share of calls to shed
+func compileShedShare(expr string) (exprNode, error) {
+	return compileTripExpr("shed_share", expr, kindNumber)
+}
+
+// compileTripExpr parses expr, which must evaluate to want; errors name
+// the setting it came from
+func compileTripExpr(setting, expr string, want valueKind) (exprNode, error) {
+	tokens, err := lexTripExpr(setting, expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &exprParser{setting: setting, tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorf("unexpected %q at offset %d", tok.text, tok.pos)
+	}
+	if root.kind() != want {
+		kind := "a boolean"
+		if want == kindNumber {
+			kind = "a number"
+		}
+		return nil, p.errorf("expression %q does not evaluate to %s", expr, kind)
+	}
+	return root, nil
+}
+
+// String returns the source expression
+func (c *TripCondition) String() string {
+	return c.source
+}
+
+func (c *TripCondition) eval(v *tripVars) bool {
+	return c.root.eval(v) != 0
+}
+
+type valueKind int
+
+const (
+	kindNumber valueKind = iota
+	kindBool
+)
+
+// exprNode is a node of a compiled expression; booleans evaluate to 0 or 1
+type exprNode interface {
+	eval(v *tripVars) float64
+	kind() valueKind
+}
+
+type numberNode float64
+
+func (n numberNode) eval(*tripVars) float64 { return float64(n) }
+func (numberNode) kind() valueKind          { return kindNumber }
+
+type varNode func(v *tripVars) float64
+
+func (n varNode) eval(v *tripVars) float64 { return n(v) }
+func (varNode) kind() valueKind            { return kindNumber }
+
+type notNode struct{ operand exprNode }
+
+func (n notNode) eval(v *tripVars) float64 { return boolValue(n.operand.eval(v) == 0) }
+func (notNode) kind() valueKind            { return kindBool }
+
+type negNode struct{ operand exprNode }
+
+func (n negNode) eval(v *tripVars) float64 { return -n.operand.eval(v) }
+func (negNode) kind() valueKind            { return kindNumber }
+
+type binaryNode struct {
+	op          string
+	left, right exprNode
+}
+
+func (n binaryNode) kind() valueKind {
+	switch n.op {
+	case "+", "-", "*", "/":
+		return kindNumber
+	default:
+		return kindBool
+	}
+}
+
+func (n binaryNode) eval(v *tripVars) float64 {
+	switch n.op {
+	case "&&":
+		return boolValue(n.left.eval(v) != 0 && n.right.eval(v) != 0)
+	case "||":
+		return boolValue(n.left.eval(v) != 0 || n.right.eval(v) != 0)
+	}
+
+	l, r := n.left.eval(v), n.right.eval(v)
+	switch n.op {
+	case "+":
+		return l + r
+	case "-":
+		return l - r
+	case "*":
+		return l * r
+	case "/":
+		if r == 0 {
+			return 0
+		}
+		return l / r
+	case "<":
+		return boolValue(l < r)
+	case "<=":
+		return boolValue(l <= r)
+	case ">":
+		return boolValue(l > r)
+	case ">=":
+		return boolValue(l >= r)
+	case "==":
+		return boolValue(l == r)
+	case "!=":
+		return boolValue(l != r)
+	}
+	return 0
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokIdent
+	tokOp
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func lexTripExpr(setting, expr string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(expr) {
+		c := rune(expr[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: i})
+			i++
+		case unicode.IsDigit(c) || c == '.':
+			start := i
+			for i < len(expr) && (unicode.IsDigit(rune(expr[i])) || expr[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: expr[start:i], pos: start})
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(expr) && (unicode.IsLetter(rune(expr[i])) || unicode.IsDigit(rune(expr[i])) || expr[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: expr[start:i], pos: start})
+		default:
+			op := ""
+			for _, candidate := range []string{"&&", "||", "<=", ">=", "==", "!=", "<", ">", "!", "+", "-", "*", "/"} {
+				if strings.HasPrefix(expr[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("resilience: %s: unexpected character %q at offset %d", setting, c, i)
+			}
+			tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(expr)}), nil
+}
+
+// exprParser is a recursive descent parser over trip expression tokens
+type exprParser struct {
+	setting string
+	tokens  []token
+	pos     int
+}
+
+// errorf returns a parse error naming the setting being parsed
+func (p *exprParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("resilience: %s: %s", p.setting, fmt.Sprintf(format, args...))
+}
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *exprParser) acceptOp(ops ...string) (string, bool) {
+	tok := p.peek()
+	if tok.kind != tokOp {
+		return "", false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	return p.parseLogical(p.parseAnd, "||")
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	return p.parseLogical(p.parseComparison, "&&")
+}
+
+func (p *exprParser) parseLogical(operand func() (exprNode, error), op string) (exprNode, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.acceptOp(op); !ok {
+			return left, nil
+		}
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		if left.kind() != kindBool || right.kind() != kindBool {
+			return nil, p.errorf("operands of %s must be boolean", op)
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseComparison() (exprNode, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.acceptOp("<", "<=", ">", ">=", "==", "!=")
+	if !ok {
+		return left, nil
+	}
+	right, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	if left.kind() != kindNumber || right.kind() != kindNumber {
+		return nil, p.errorf("operands of %s must be numeric", op)
+	}
+	return binaryNode{op: op, left: left, right: right}, nil
+}
+
+func (p *exprParser) parseAdditive() (exprNode, error) {
+	return p.parseArithmetic(p.parseMultiplicative, "+", "-")
+}
+
+func (p *exprParser) parseMultiplicative() (exprNode, error) {
+	return p.parseArithmetic(p.parseUnary, "*", "/")
+}
+
+func (p *exprParser) parseArithmetic(operand func() (exprNode, error), ops ...string) (exprNode, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.acceptOp(ops...)
+		if !ok {
+			return left, nil
+		}
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		if left.kind() != kindNumber || right.kind() != kindNumber {
+			return nil, p.errorf("operands of %s must be numeric", op)
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if op, ok := p.acceptOp("!", "-"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if op == "!" {
+			if operand.kind() != kindBool {
+				return nil, p.errorf("operand of ! must be boolean")
+			}
+			return notNode{operand: operand}, nil
+		}
+		if operand.kind() != kindNumber {
+			return nil, p.errorf("operand of unary - must be numeric")
+		}
+		return negNode{operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		n, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q at offset %d", tok.text, tok.pos)
+		}
+		return numberNode(n), nil
+	case tokIdent:
+		if lookup, ok := tripVariables[tok.text]; ok {
+			return varNode(lookup), nil
+		}
+		return nil, p.errorf("unknown variable %q", tok.text)
+	case tokLParen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, p.errorf("expected ) at offset %d", closing.pos)
+		}
+		return node, nil
+	case tokEOF:
+		return nil, p.errorf("unexpected end of expression")
+	default:
+		return nil, p.errorf("unexpected %q at offset %d", tok.text, tok.pos)
+	}
+}
@@ -0,0 +1,135 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileTripCondition(t *testing.T) {
+	t.Run("evaluates expressions", func(t *testing.T) {
+		vars := &tripVars{requests: 10, successes: 4, failures: 6, timeouts: 2, consecutiveFailures: 3, slowRate: 0.4}
+
+		tests := []struct {
+			expr     string
+			expected bool
+		}{
+			{"failure_rate > 0.5", true},
+			{"failure_rate > 0.5 && consecutive_failures >= 4", false},
+			{"failure_rate > 0.9 || consecutive_failures >= 3", true},
+			{"!(success_rate >= 0.5)", true},
+			{"failures - successes == 2", true},
+			{"requests * 0.5 < failures", true},
+			{"failure_rate > 0.5 && slow_rate > 0.3", true},
+			{"timeouts == 2 && timeout_rate < 0.25", true},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.expr, func(t *testing.T) {
+				cond, err := CompileTripCondition(tt.expr)
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, cond.eval(vars))
+				assert.Equal(t, tt.expr, cond.String())
+			})
+		}
+	})
+
+	t.Run("rejects invalid expressions", func(t *testing.T) {
+		for _, expr := range []string{
+			"",
+			"failure_rate",
+			"failure_rate > ",
+			"p99_latency > 0.3",
+			"(failure_rate > 0.5",
+			"failure_rate > 0.5 && 3",
+			"failure_rate $ 2",
+		} {
+			_, err := CompileTripCondition(expr)
+			assert.Error(t, err, expr)
+		}
+	})
+}
+
+func TestCircuitBreakerTripWhen(t *testing.T) {
+	t.Run("trips on expression instead of failure ratio", func(t *testing.T) {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name:             "test",
+			Interval:         time.Minute,
+			FailureThreshold: 0.99,
+			MinRequests:      1,
+			TripWhen:         "consecutive_failures >= 2",
+		})
+		ctx := context.Background()
+
+		cb.Execute(ctx, func(ctx context.Context) error { return errors.New("error") })
+		assert.Equal(t, StateClosed, cb.State())
+
+		cb.Execute(ctx, func(ctx context.Context) error { return errors.New("error") })
+		assert.Equal(t, StateOpen, cb.State())
+	})
+
+	t.Run("invalid expression fails validation", func(t *testing.T) {
+		config := DefaultCircuitBreakerConfig()
+		config.TripWhen = "failure_rate >"
+		assert.Error(t, config.Validate())
+		assert.Panics(t, func() { NewCircuitBreaker(config) })
+	})
+}
+
+func TestCircuitBreakerSlowRate(t *testing.T) {
+	ctx := context.Background()
+	clock := NewFakeClock(time.Now())
+	cb := NewCircuitBreaker(CircuitBreakerConfig{
+		Name:              "test",
+		MinRequests:       4,
+		TripWhen:          "slow_rate > 0.5",
+		SlowCallThreshold: 100 * time.Millisecond,
+		Clock:             clock,
+	})
+	call := func(latency time.Duration) {
+		require.NoError(t, cb.Execute(ctx, func(ctx context.Context) error {
+			clock.Advance(latency)
+			return nil
+		}))
+	}
+
+	call(0)
+	call(0)
+	call(200 * time.Millisecond)
+	call(200 * time.Millisecond)
+	assert.Equal(t, StateClosed, cb.State(), "2 of 4 slow")
+
+	call(200 * time.Millisecond)
+	assert.Equal(t, StateOpen, cb.State(), "3 of 5 slow")
+}
+
+func TestCircuitBreakerShedShare(t *testing.T) {
+	ctx := context.Background()
+	fail := func(ctx context.Context) error { return errors.New("error") }
+
+	t.Run("replaces the failure rate as the shed share", func(t *testing.T) {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			Name:             "test",
+			Interval:         time.Minute,
+			FailureThreshold: 0.5,
+			MinRequests:      4,
+			TripMode:         TripModeProportional,
+			ShedShare:        "failure_rate - 1",
+		})
+
+		for i := 0; i < 100; i++ {
+			assert.NotErrorIs(t, cb.Execute(ctx, fail), ErrCircuitOpen)
+		}
+	})
+
+	t.Run("must evaluate to a number", func(t *testing.T) {
+		config := DefaultCircuitBreakerConfig()
+		config.ShedShare = "failure_rate > 0.5"
+		assert.ErrorContains(t, config.Validate(), "shed_share")
+		assert.Panics(t, func() { NewCircuitBreaker(config) })
+	})
+}
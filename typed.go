@@ -0,0 +1,82 @@
+package resilience
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// Do runs fn through executor and returns its result as T, sparing the
+// caller a type assertion on ExecuteWithResult's result
+func Do[T any](ctx context.Context, executor Executor, fn func(context.Context) (T, error), opts ...CallOption) (T, error) {
+	return typedResult[T](executor.ExecuteWithResult(ctx, func(ctx context.Context) (any, error) {
+		return fn(ctx)
+	}, opts...))
+}
+
+// RetryDo runs fn with retry and returns the last attempt's result
+func RetryDo[T any](ctx context.Context, retry Retry, fn func(context.Context) (T, error)) (T, error) {
+	return typedResult[T](retry.ExecuteWithResult(ctx, func(ctx context.Context) (any, error) {
+		return fn(ctx)
+	}))
+}
+
+// RetryResultOf adapts a predicate on typed results to ShouldRetryResult
+// for calls made with Do or RetryDo; results of another type are not
+// retried
+func RetryResultOf[T any](fn func(result T, err error) bool) ShouldRetryResult {
+	return func(result any, err error) bool {
+		if result == nil {
+			var zero T
+			return fn(zero, err)
+		}
+		typed, ok := result.(T)
+		return ok && fn(typed, err)
+	}
+}
+
+// CircuitBreakerDo runs fn through the circuit breaker and returns its
+// result; the zero value is returned when the breaker rejects the call
+func CircuitBreakerDo[T any](ctx context.Context, cb CircuitBreaker, fn func(context.Context) (T, error)) (T, error) {
+	var result T
+	err := cb.Execute(ctx, func(ctx context.Context) error {
+		var execErr error
+		result, execErr = fn(ctx)
+		return execErr
+	})
+	return result, err
+}
+
+// BulkheadDo runs fn in the bulkhead and returns its result; the zero
+// value is returned when the bulkhead rejects the call
+func BulkheadDo[T any](ctx context.Context, bulkhead Bulkhead, fn func(context.Context) (T, error)) (T, error) {
+	var result T
+	err := bulkhead.Execute(ctx, func(ctx context.Context) error {
+		var execErr error
+		result, execErr = fn(ctx)
+		return execErr
+	})
+	return result, err
+}
+
+// TimeoutDo runs fn with the timeout and returns its result; the zero
+// value is returned when the call times out
+func TimeoutDo[T any](ctx context.Context, timeout Timeout, fn func(context.Context) (T, error)) (T, error) {
+	return typedResult[T](timeout.ExecuteWithResult(ctx, func(ctx context.Context) (any, error) {
+		return fn(ctx)
+	}))
+}
+
+// typedResult converts an untyped call result to T. A nil result is the
+// zero value; a result of another type, e.g. from a fallback, is an error.
+func typedResult[T any](result any, err error) (T, error) {
+	var zero T
+	if result == nil {
+		return zero, err
+	}
+	typed, ok := result.(T)
+	if !ok {
+		return zero, fmt.Errorf("resilience: result of type %T is not %v", result, reflect.TypeFor[T]())
+	}
+	return typed, err
+}
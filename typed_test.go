@@ -0,0 +1,63 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypedHelpers(t *testing.T) {
+	ctx := context.Background()
+	user := func(ctx context.Context) (string, error) { return "alice", nil }
+
+	t.Run("executor", func(t *testing.T) {
+		executor := NewBuilder().WithRetry(RetryConfig{MaxAttempts: 2, InitialInterval: time.Millisecond}).Build()
+		name, err := Do(ctx, executor, user)
+		require.NoError(t, err)
+		assert.Equal(t, "alice", name)
+
+		count, err := Do(ctx, executor, func(ctx context.Context) (int, error) { return 0, errors.New("failure") })
+		assert.Error(t, err)
+		assert.Zero(t, count)
+	})
+
+	t.Run("retry returns the last attempt's result", func(t *testing.T) {
+		retry := NewRetry(RetryConfig{MaxAttempts: 3, InitialInterval: time.Millisecond})
+		attempts := 0
+		got, err := RetryDo(ctx, retry, func(ctx context.Context) (int, error) {
+			attempts++
+			if attempts < 2 {
+				return 0, errors.New("failure")
+			}
+			return attempts, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, got)
+	})
+
+	t.Run("circuit breaker, bulkhead and timeout", func(t *testing.T) {
+		name, err := CircuitBreakerDo(ctx, NewCircuitBreaker(CircuitBreakerConfig{Name: "users"}), user)
+		require.NoError(t, err)
+		assert.Equal(t, "alice", name)
+
+		name, err = BulkheadDo(ctx, NewBulkhead(BulkheadConfig{MaxConcurrent: 1}), user)
+		require.NoError(t, err)
+		assert.Equal(t, "alice", name)
+
+		name, err = TimeoutDo(ctx, NewTimeout(time.Second, "users"), user)
+		require.NoError(t, err)
+		assert.Equal(t, "alice", name)
+	})
+
+	t.Run("reports results of another type", func(t *testing.T) {
+		_, err := typedResult[int]("alice", nil)
+		assert.ErrorContains(t, err, "result of type string is not int")
+		got, err := typedResult[error](nil, nil)
+		assert.NoError(t, err)
+		assert.Nil(t, got)
+	})
+}
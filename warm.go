@@ -0,0 +1,137 @@
+package resilience
+
+import (
+	"time"
+)
+
+// breakerHolder is implemented by executors that apply a circuit breaker
+type breakerHolder interface {
+	breaker() CircuitBreaker
+}
+
+// breakerSnapshot is a point-in-time copy of circuit breaker state
+type breakerSnapshot struct {
+	state     CircuitState
+	counts    counts
+	stateTime time.Time
+	since     time.Time     // when state was entered; zero uses stateTime
+	openFor   time.Duration // length of the last opening; zero keeps the configured one
+	forced    bool          // state pinned by ForceOpen or ForceClosed
+	window    []bucketCounts
+	timeouts  []bucketCounts
+	baseline  []bucketCounts
+}
+
+// Warm copies circuit breaker state and counts from the active executor to
+// the standby one, so swapping in a new policy version during an incident
+// keeps the protective state instead of starting from a closed breaker.
+func Warm(standby, active Executor) error {
+	from, err := executorBreaker(active)
+	if err != nil {
+		return err
+	}
+	to, err := executorBreaker(standby)
+	if err != nil {
+		return err
+	}
+
+	to.restore(from.snapshot())
+	return nil
+}
+
+func executorBreaker(e Executor) (*circuitBreaker, error) {
+	for e != nil {
+		if holder, ok := e.(breakerHolder); ok {
+			if cb, ok := holder.breaker().(*circuitBreaker); ok {
+				return cb, nil
+			}
+			return nil, ErrNoCircuitBreaker
+		}
+		e = UnwrapExecutor(e)
+	}
+	return nil, ErrNoCircuitBreaker
+}
+
+func (e *executor) breaker() CircuitBreaker {
+	if !e.hasCircuitBreaker {
+		return nil
+	}
+	return e.circuitBreaker
+}
+
+func (g *sloGuard) breaker() CircuitBreaker {
+	if holder, ok := g.executor.(breakerHolder); ok {
+		return holder.breaker()
+	}
+	return nil
+}
+
+func (cb *circuitBreaker) snapshot() breakerSnapshot {
+	// Reading a window advances it, so this takes the write lock
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := cb.now()
+	s := breakerSnapshot{
+		state:     cb.state,
+		counts:    cb.gen.Load().load(),
+		stateTime: cb.stateTime,
+		since:     cb.since,
+		openFor:   cb.openFor,
+		forced:    cb.forced,
+	}
+	if cb.window != nil {
+		s.window = cb.window.snapshot(now)
+	}
+	if cb.timeouts != nil {
+		s.timeouts = cb.timeouts.snapshot(now)
+	}
+	if cb.baseline != nil {
+		s.baseline = cb.baseline.snapshot(now)
+	}
+	return s
+}
+
+func (cb *circuitBreaker) restore(s breakerSnapshot) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	// Restored state replaces any forced state, and is forced itself if it
+	// was. It is set directly rather than transitioned to: the breaker
+	// continues where the other one was, so no transition is recorded in
+	// History or metrics or announced to other instances; only
+	// OnStateChange learns of the new state. Disabled is only left by
+	// Reset.
+	prev := cb.state
+	cb.forced = s.forced || s.state == StateDisabled
+	cb.state = s.state
+	cb.since = s.since
+	if cb.since.IsZero() {
+		cb.since = s.stateTime
+	}
+	if s.openFor > 0 {
+		cb.openFor = s.openFor
+	}
+	if s.state == StateOpen {
+		cb.openedAt = cb.since
+	}
+	cb.slowUntil = time.Time{}
+
+	// Windows missing from the snapshot, e.g. from a breaker counting per
+	// interval, start empty
+	now := cb.now()
+	if cb.window != nil {
+		cb.window.restore(s.window, now)
+	}
+	if cb.timeouts != nil {
+		cb.timeouts.restore(s.timeouts, now)
+	}
+	if cb.baseline != nil {
+		cb.baseline.restore(s.baseline, now)
+	}
+
+	cb.setGeneration(s.stateTime, s.counts)
+	if prev != s.state {
+		cb.notifyStateChange(prev, s.state)
+	}
+}
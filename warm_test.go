@@ -0,0 +1,94 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWarm(t *testing.T) {
+	config := CircuitBreakerConfig{
+		Name:             "payments",
+		Interval:         time.Minute,
+		Timeout:          time.Minute,
+		FailureThreshold: 0.5,
+		MinRequests:      2,
+	}
+
+	t.Run("copies open state to standby", func(t *testing.T) {
+		active := NewBuilder().WithCircuitBreaker(config).Build()
+		ctx := context.Background()
+		active.Execute(ctx, func(ctx context.Context) error { return errors.New("error") })
+		active.Execute(ctx, func(ctx context.Context) error { return errors.New("error") })
+
+		var transitions []CircuitState
+		standbyConfig := config
+		standbyConfig.SyncStateChange = true
+		standbyConfig.OnStateChange = func(name string, from, to CircuitState) {
+			transitions = append(transitions, to)
+		}
+		standby := NewSLOGuard(NewBuilder().WithCircuitBreaker(standbyConfig).Build(), SLOConfig{})
+
+		assert.NoError(t, Warm(standby, active))
+		assert.Equal(t, []CircuitState{StateOpen}, transitions)
+
+		called := false
+		err := standby.Execute(ctx, func(ctx context.Context) error {
+			called = true
+			return nil
+		})
+		assert.ErrorIs(t, err, ErrCircuitOpen)
+		assert.False(t, called)
+	})
+
+	t.Run("copies counts", func(t *testing.T) {
+		active := NewBuilder().WithCircuitBreaker(config).Build()
+		standby := NewBuilder().WithCircuitBreaker(config).Build()
+		ctx := context.Background()
+		active.Execute(ctx, func(ctx context.Context) error { return errors.New("error") })
+
+		assert.NoError(t, Warm(standby, active))
+
+		// One more failure trips the warmed breaker
+		standby.Execute(ctx, func(ctx context.Context) error { return errors.New("error") })
+		cb, _ := executorBreaker(standby)
+		assert.Equal(t, StateOpen, cb.State())
+	})
+
+	t.Run("continues the open state without recording a transition", func(t *testing.T) {
+		clock := NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+		clocked := config
+		clocked.Clock = clock
+		active := NewBuilder().WithCircuitBreaker(clocked).Build()
+		from, _ := executorBreaker(active)
+		from.Trip()
+		opened := clock.Now()
+		clock.Advance(30 * time.Second)
+
+		metrics := &stateMetrics{durations: map[CircuitState]time.Duration{}}
+		standbyConfig := clocked
+		standbyConfig.MetricsRecorder = metrics
+		standby := NewBuilder().WithCircuitBreaker(standbyConfig).Build()
+		assert.NoError(t, Warm(standby, active))
+
+		to, _ := executorBreaker(standby)
+		assert.Equal(t, opened, to.Metrics().StateSince)
+		assert.Empty(t, to.History())
+		assert.Empty(t, metrics.durations)
+
+		// The opening ends when the active breaker's would
+		clock.Advance(31 * time.Second)
+		assert.NoError(t, standby.Execute(context.Background(), func(ctx context.Context) error { return nil }))
+	})
+
+	t.Run("requires circuit breakers", func(t *testing.T) {
+		withBreaker := NewBuilder().WithCircuitBreaker(config).Build()
+		without := NewBuilder().Build()
+
+		assert.ErrorIs(t, Warm(without, withBreaker), ErrNoCircuitBreaker)
+		assert.ErrorIs(t, Warm(withBreaker, without), ErrNoCircuitBreaker)
+	})
+}
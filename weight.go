@@ -0,0 +1,33 @@
+package resilience
+
+import (
+	"context"
+	"math"
+)
+
+// maxCallWeight is the largest call weight and batch size a breaker counts
+const maxCallWeight = math.MaxUint16
+
+type callWeightKey struct{}
+
+// WithCallWeight returns a context whose calls count as weight requests in
+// circuit breaker statistics, e.g. the item count of a batch call, so one
+// failed batch of 1000 items weighs more than a failed single-item call.
+// Weights are capped at 65535.
+// Half-open trials count once whatever their weight.
+func WithCallWeight(ctx context.Context, weight int) context.Context {
+	return context.WithValue(ctx, callWeightKey{}, weight)
+}
+
+// CallWeightFromContext returns the weight set by WithCallWeight, or 1
+func CallWeightFromContext(ctx context.Context) int {
+	if weight, _ := ctx.Value(callWeightKey{}).(int); weight > 0 {
+		return weight
+	}
+	return 1
+}
+
+// callWeight returns the weight of a call in ctx as the breaker counts it
+func callWeight(ctx context.Context) uint32 {
+	return uint32(min(CallWeightFromContext(ctx), maxCallWeight))
+}
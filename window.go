@@ -0,0 +1,138 @@
+package resilience
+
+import (
+	"time"
+)
+
+// rollingWindow counts outcomes over a trailing time window using a ring of
+// fixed-size time buckets. It is not safe for concurrent use.
+type rollingWindow struct {
+	bucketSize time.Duration
+	buckets    []windowBucket
+	head       int
+	headStart  time.Time
+}
+
+// windowBucket holds the outcomes recorded during one bucket period
+type windowBucket struct {
+	successes uint64
+	failures  uint64
+}
+
+// newRollingWindow creates a window of the given span split into n buckets
+func newRollingWindow(span time.Duration, n int, now time.Time) *rollingWindow {
+	if n <= 0 {
+		n = 1
+	}
+	bucketSize := span / time.Duration(n)
+	if bucketSize <= 0 {
+		bucketSize = time.Nanosecond
+	}
+	return &rollingWindow{
+		bucketSize: bucketSize,
+		buckets:    make([]windowBucket, n),
+		headStart:  now.Truncate(bucketSize),
+	}
+}
+
+// advance rotates the ring so the head bucket covers now
+func (w *rollingWindow) advance(now time.Time) {
+	elapsed := now.Sub(w.headStart)
+	if elapsed < w.bucketSize {
+		return
+	}
+
+	steps := int(elapsed / w.bucketSize)
+	if steps >= len(w.buckets) {
+		w.reset(now)
+		return
+	}
+
+	for i := 0; i < steps; i++ {
+		w.head = (w.head + 1) % len(w.buckets)
+		w.buckets[w.head] = windowBucket{}
+	}
+	w.headStart = w.headStart.Add(time.Duration(steps) * w.bucketSize)
+}
+
+// record adds an outcome at the given time
+func (w *rollingWindow) record(now time.Time, success bool) {
+	w.recordN(now, success, 1)
+}
+
+// recordN adds n identical outcomes at the given time
+func (w *rollingWindow) recordN(now time.Time, success bool, n uint64) {
+	w.advance(now)
+	if success {
+		w.buckets[w.head].successes += n
+	} else {
+		w.buckets[w.head].failures += n
+	}
+}
+
+// totals returns the successes and failures within the window
+func (w *rollingWindow) totals(now time.Time) (successes, failures uint64) {
+	w.advance(now)
+	for _, b := range w.buckets {
+		successes += b.successes
+		failures += b.failures
+	}
+	return successes, failures
+}
+
+// reset clears all buckets
+func (w *rollingWindow) reset(now time.Time) {
+	for i := range w.buckets {
+		w.buckets[i] = windowBucket{}
+	}
+	w.head = 0
+	w.headStart = now.Truncate(w.bucketSize)
+}
+
+// bucketCounts is the outcomes of one window bucket starting at start
+type bucketCounts struct {
+	start     time.Time
+	successes uint64
+	failures  uint64
+}
+
+// snapshot returns the non-empty buckets within the window at now, oldest
+// first
+func (w *rollingWindow) snapshot(now time.Time) []bucketCounts {
+	w.advance(now)
+	n := len(w.buckets)
+	var out []bucketCounts
+	for i := 0; i < n; i++ {
+		b := w.buckets[(w.head+1+i)%n]
+		if b.successes == 0 && b.failures == 0 {
+			continue
+		}
+		start := w.headStart.Add(-time.Duration(n-1-i) * w.bucketSize)
+		out = append(out, bucketCounts{start: start, successes: b.successes, failures: b.failures})
+	}
+	return out
+}
+
+// restore replaces the window's outcomes with buckets taken from another
+// window, which may have another bucket size; those that have expired by
+// now are dropped and those ahead of now are counted at now
+func (w *rollingWindow) restore(buckets []bucketCounts, now time.Time) {
+	start := now
+	if len(buckets) > 0 && buckets[0].start.Before(now) {
+		start = buckets[0].start
+	}
+	w.reset(start)
+	for _, b := range buckets {
+		at := b.start
+		if at.After(now) {
+			at = now
+		}
+		if b.successes > 0 {
+			w.recordN(at, true, b.successes)
+		}
+		if b.failures > 0 {
+			w.recordN(at, false, b.failures)
+		}
+	}
+	w.advance(now)
+}
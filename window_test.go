@@ -0,0 +1,69 @@
+package resilience
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRollingWindow(t *testing.T) {
+	t.Run("counts outcomes within the window", func(t *testing.T) {
+		now := time.Unix(0, 0)
+		w := newRollingWindow(10*time.Second, 10, now)
+
+		w.record(now, true)
+		w.record(now.Add(time.Second), false)
+		w.record(now.Add(2*time.Second), false)
+
+		successes, failures := w.totals(now.Add(2 * time.Second))
+		assert.Equal(t, uint64(1), successes)
+		assert.Equal(t, uint64(2), failures)
+	})
+
+	t.Run("records outcomes in bulk", func(t *testing.T) {
+		now := time.Unix(0, 0)
+		w := newRollingWindow(10*time.Second, 10, now)
+
+		w.recordN(now, true, 1<<32)
+		w.recordN(now.Add(time.Second), false, 3)
+
+		successes, failures := w.totals(now.Add(time.Second))
+		assert.Equal(t, uint64(1<<32), successes)
+		assert.Equal(t, uint64(3), failures)
+	})
+
+	t.Run("expires old buckets", func(t *testing.T) {
+		now := time.Unix(0, 0)
+		w := newRollingWindow(10*time.Second, 10, now)
+
+		w.record(now, false)
+		w.record(now.Add(5*time.Second), true)
+
+		successes, failures := w.totals(now.Add(11 * time.Second))
+		assert.Equal(t, uint64(1), successes)
+		assert.Equal(t, uint64(0), failures)
+
+		successes, failures = w.totals(now.Add(time.Minute))
+		assert.Zero(t, successes)
+		assert.Zero(t, failures)
+	})
+	t.Run("restores buckets into a window of another size", func(t *testing.T) {
+		now := time.Unix(0, 0)
+		from := newRollingWindow(10*time.Second, 10, now)
+		from.record(now, false)
+		from.record(now.Add(6*time.Second), true)
+
+		to := newRollingWindow(10*time.Second, 5, now)
+		to.record(now, true)
+		to.restore(from.snapshot(now.Add(6*time.Second)), now.Add(7*time.Second))
+
+		successes, failures := to.totals(now.Add(7 * time.Second))
+		assert.Equal(t, uint64(1), successes)
+		assert.Equal(t, uint64(1), failures)
+
+		successes, failures = to.totals(now.Add(11 * time.Second))
+		assert.Equal(t, uint64(1), successes)
+		assert.Equal(t, uint64(0), failures)
+	})
+}
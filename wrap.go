@@ -0,0 +1,121 @@
+package resilience
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// WrapTag is the struct tag naming the policy for a function field
+const WrapTag = "resilience"
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// WrapStruct replaces the function fields of the struct target points to
+// with versions that run through the executor named by the field's
+// `resilience` tag, taken from policies. Untagged fields, fields tagged
+// "-" and nil fields are left alone.
+//
+// Wrapped fields must take a context.Context first and return an error
+// last, e.g.
+//
+//	type PaymentsClient struct {
+//		Charge func(ctx context.Context, req ChargeRequest) (*Receipt, error) `resilience:"payments-write"`
+//		Get    func(ctx context.Context, id string) (*Receipt, error)          `resilience:"payments-read"`
+//	}
+//
+// Go cannot implement interfaces at runtime, so interface clients need a
+// struct of function fields (or generated decorators) to be wrapped.
+func WrapStruct(target any, policies Registry) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("resilience: wrap struct: target must be a non-nil struct pointer, got %T", target)
+	}
+	if policies == nil {
+		return fmt.Errorf("resilience: wrap struct: nil policy registry")
+	}
+
+	v = v.Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		policy, ok := field.Tag.Lookup(WrapTag)
+		if !ok || policy == "-" {
+			continue
+		}
+		if policy == "" {
+			return fmt.Errorf("resilience: wrap struct: field %s has an empty policy name", field.Name)
+		}
+		if !field.IsExported() {
+			return fmt.Errorf("resilience: wrap struct: field %s is not exported", field.Name)
+		}
+		if err := checkWrappable(field.Type); err != nil {
+			return fmt.Errorf("resilience: wrap struct: field %s: %w", field.Name, err)
+		}
+
+		fn := v.Field(i)
+		if fn.IsNil() {
+			continue
+		}
+		// Copy the function out of the field before replacing it
+		original := reflect.ValueOf(fn.Interface())
+		fn.Set(wrapFunc(original, policies.Executor(policy)))
+	}
+	return nil
+}
+
+// checkWrappable reports whether a function type can run under an executor
+func checkWrappable(t reflect.Type) error {
+	if t.Kind() != reflect.Func {
+		return fmt.Errorf("not a function")
+	}
+	if t.NumIn() == 0 || t.In(0) != contextType {
+		return fmt.Errorf("first parameter must be context.Context")
+	}
+	if t.NumOut() == 0 || t.Out(t.NumOut()-1) != errorType {
+		return fmt.Errorf("last result must be error")
+	}
+	return nil
+}
+
+// wrapFunc returns a function of fn's type that calls fn through exec
+func wrapFunc(fn reflect.Value, exec Executor) reflect.Value {
+	t := fn.Type()
+
+	return reflect.MakeFunc(t, func(args []reflect.Value) []reflect.Value {
+		ctx, _ := args[0].Interface().(context.Context)
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		result, err := exec.ExecuteWithResult(ctx, func(ctx context.Context) (any, error) {
+			in := append([]reflect.Value{reflect.ValueOf(ctx)}, args[1:]...)
+			var out []reflect.Value
+			if t.IsVariadic() {
+				out = fn.CallSlice(in)
+			} else {
+				out = fn.Call(in)
+			}
+			callErr, _ := out[len(out)-1].Interface().(error)
+			return out, callErr
+		})
+
+		// Keep the call's results, or zero values if it never completed
+		out, ok := result.([]reflect.Value)
+		if !ok {
+			out = make([]reflect.Value, t.NumOut())
+			for i := range out {
+				out[i] = reflect.Zero(t.Out(i))
+			}
+		}
+		if err != nil {
+			out[len(out)-1] = reflect.ValueOf(&err).Elem()
+		} else {
+			out[len(out)-1] = reflect.Zero(errorType)
+		}
+		return out
+	})
+}
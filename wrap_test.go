@@ -0,0 +1,108 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type wrapTestClient struct {
+	Get    func(ctx context.Context, id string) (string, error) `resilience:"reads"`
+	Delete func(ctx context.Context, ids ...string) error       `resilience:"writes"`
+	Ping   func(ctx context.Context) error
+}
+
+func TestWrapStruct(t *testing.T) {
+	newRegistry := func() Registry {
+		return NewRegistry(func(name string) Executor {
+			return NewBuilder().
+				WithName(name).
+				WithRetry(RetryConfig{
+					Name:            name,
+					MaxAttempts:     3,
+					InitialInterval: time.Millisecond,
+					MaxInterval:     time.Millisecond,
+					Multiplier:      1,
+				}).
+				Build()
+		})
+	}
+
+	t.Run("runs tagged fields through their policy", func(t *testing.T) {
+		calls := 0
+		client := wrapTestClient{
+			Get: func(ctx context.Context, id string) (string, error) {
+				calls++
+				if calls < 3 {
+					return "", errors.New("unavailable")
+				}
+				return "item-" + id, nil
+			},
+		}
+
+		require.NoError(t, WrapStruct(&client, newRegistry()))
+
+		value, err := client.Get(context.Background(), "42")
+		assert.NoError(t, err)
+		assert.Equal(t, "item-42", value)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("returns the executor error", func(t *testing.T) {
+		client := wrapTestClient{
+			Delete: func(ctx context.Context, ids ...string) error {
+				return errors.New("unavailable")
+			},
+		}
+
+		require.NoError(t, WrapStruct(&client, newRegistry()))
+
+		err := client.Delete(context.Background(), "1", "2")
+		assert.ErrorContains(t, err, "unavailable")
+	})
+
+	t.Run("passes variadic arguments through", func(t *testing.T) {
+		var got []string
+		client := wrapTestClient{
+			Delete: func(ctx context.Context, ids ...string) error {
+				got = ids
+				return nil
+			},
+		}
+
+		require.NoError(t, WrapStruct(&client, newRegistry()))
+
+		assert.NoError(t, client.Delete(context.Background(), "1", "2"))
+		assert.Equal(t, []string{"1", "2"}, got)
+	})
+
+	t.Run("leaves untagged fields alone", func(t *testing.T) {
+		calls := 0
+		client := wrapTestClient{
+			Ping: func(ctx context.Context) error {
+				calls++
+				return errors.New("down")
+			},
+		}
+
+		require.NoError(t, WrapStruct(&client, newRegistry()))
+
+		assert.EqualError(t, client.Ping(context.Background()), "down")
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("rejects invalid targets", func(t *testing.T) {
+		assert.Error(t, WrapStruct(wrapTestClient{}, newRegistry()))
+		assert.Error(t, WrapStruct(&wrapTestClient{}, nil))
+
+		var bad struct {
+			Get func(id string) error `resilience:"reads"`
+		}
+		bad.Get = func(id string) error { return nil }
+		assert.Error(t, WrapStruct(&bad, newRegistry()))
+	})
+}